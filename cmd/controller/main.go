@@ -15,11 +15,19 @@ limitations under the License.
 package main
 
 import (
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/samber/lo"
+	"knative.dev/pkg/logging"
 
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/permission"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/snapshot"
 	"github.com/aws/karpenter-provider-aws/pkg/operator"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/webhooks"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/metrics"
@@ -33,16 +41,33 @@ func main() {
 	ctx, op := operator.NewOperator(coreoperator.NewOperator())
 	awsCloudProvider := cloudprovider.New(
 		op.InstanceTypesProvider,
-		op.InstanceProvider,
+		op.AccountProvider,
 		op.EventRecorder,
 		op.GetClient(),
-		op.AMIProvider,
-		op.SecurityGroupProvider,
-		op.SubnetProvider,
+		op.PricingProvider,
 	)
 	lo.Must0(op.AddHealthzCheck("cloud-provider", awsCloudProvider.LivenessProbe))
 	cloudProvider := metrics.Decorate(awsCloudProvider)
 
+	if options.FromContext(ctx).PermissionCheck {
+		permissionChecker := permission.NewChecker(ctx, op.GetClient(), op.Clock, op.Config.Region, sts.NewFromConfig(op.Config), iam.NewFromConfig(op.Config))
+		lo.Must0(op.Add(permissionChecker))
+		lo.Must0(op.AddReadyzCheck("permissions", permissionChecker.ReadinessProbe))
+	}
+	if options.FromContext(ctx).AWSHealthCheck {
+		prober := permission.NewProber(op.GetClient(), op.EventRecorder, op.Clock, op.Config.Region, sts.NewFromConfig(op.Config), ec2.NewFromConfig(op.Config), ssm.NewFromConfig(op.Config))
+		lo.Must0(op.Add(prober))
+		lo.Must0(op.AddReadyzCheck("aws", prober.ReadinessProbe))
+	}
+	// caches are restored before the controllers start, so that the first launch decisions are made with them
+	if path := options.FromContext(ctx).CacheSnapshotPath; path != "" {
+		if err := snapshot.Restore(ctx, path, op.InstanceTypesProvider, op.PricingProvider); err != nil {
+			logging.FromContext(ctx).Errorf("restoring snapshot, %s", err)
+		}
+	}
+	for _, runnable := range controllers.NewRunnables(ctx, op.Clock, op.GetClient(), op.EventRecorder, op.Elected(), op.UnavailableOfferingsCache, cloudProvider, op.PricingProvider, op.InstanceProvider) {
+		lo.Must0(op.Add(runnable))
+	}
 	op.
 		WithControllers(ctx, corecontrollers.NewControllers(
 			op.Clock,
@@ -54,20 +79,21 @@ func main() {
 		WithWebhooks(ctx, corewebhooks.NewWebhooks()...).
 		WithControllers(ctx, controllers.NewControllers(
 			ctx,
-			op.Session,
+			op.Config,
 			op.Clock,
 			op.GetClient(),
+			op.KubernetesInterface,
 			op.EventRecorder,
 			op.UnavailableOfferingsCache,
 			cloudProvider,
-			op.SubnetProvider,
+			op.AccountProvider,
 			op.SecurityGroupProvider,
-			op.InstanceProfileProvider,
 			op.InstanceProvider,
 			op.PricingProvider,
-			op.AMIProvider,
 			op.LaunchTemplateProvider,
+			op.QuotaProvider,
+			op.InstanceTypesProvider,
 		)...).
-		WithWebhooks(ctx, webhooks.NewWebhooks()...).
+		WithWebhooks(ctx, webhooks.NewWebhooks(op.GetClient(), op.AccountProvider)...).
 		Start(ctx)
 }
@@ -15,6 +15,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"go/format"
@@ -26,8 +27,9 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 )
 
@@ -105,16 +107,11 @@ func main() {
 			}
 		}()
 	}
-	if err := os.Setenv("AWS_SDK_LOAD_CONFIG", "true"); err != nil {
-		log.Fatalf("setting AWS_SDK_LOAD_CONFIG, %s", err)
-	}
-	if err := os.Setenv("AWS_REGION", "us-east-1"); err != nil {
-		log.Fatalf("setting AWS_REGION, %s", err)
-	}
-	sess := session.Must(session.NewSession())
-	ec2api := ec2.New(sess)
-	instanceTypesOutput := lo.Must(ec2api.DescribeInstanceTypes(&ec2.DescribeInstanceTypesInput{}))
-	allInstanceTypes := lo.Map(instanceTypesOutput.InstanceTypes, func(info *ec2.InstanceTypeInfo, _ int) string { return *info.InstanceType })
+	ctx := context.Background()
+	cfg := lo.Must(config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1")))
+	ec2api := ec2.NewFromConfig(cfg)
+	instanceTypesOutput := lo.Must(ec2api.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{}))
+	allInstanceTypes := lo.Map(instanceTypesOutput.InstanceTypes, func(info ec2types.InstanceTypeInfo, _ int) string { return string(info.InstanceType) })
 
 	instanceTypes := lo.Keys(bandwidth)
 	// 2d sort for readability
@@ -25,10 +25,9 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 )
 
@@ -36,8 +35,9 @@ const packageHeader = `
 package fake
 
 import (
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 // GENERATED FILE. DO NOT EDIT DIRECTLY.
@@ -56,15 +56,12 @@ func init() {
 }
 
 func main() {
-	if err := os.Setenv("AWS_SDK_LOAD_CONFIG", "true"); err != nil {
-		log.Fatalf("setting AWS_SDK_LOAD_CONFIG, %s", err)
-	}
-	if err := os.Setenv("AWS_REGION", "us-east-1"); err != nil {
-		log.Fatalf("setting AWS_REGION, %s", err)
-	}
 	ctx := context.Background()
-	sess := session.Must(session.NewSession())
-	ec2Client := ec2.New(sess)
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"))
+	if err != nil {
+		log.Fatalf("loading aws config, %s", err)
+	}
+	ec2Client := ec2.NewFromConfig(cfg)
 	instanceTypes := strings.Split(instanceTypesStr, ",")
 
 	src := &bytes.Buffer{}
@@ -84,21 +81,21 @@ func main() {
 	}
 }
 
-func getDescribeInstanceTypesOutput(ctx context.Context, ec2Client ec2iface.EC2API, instanceTypes []string) string {
-	out, err := ec2Client.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{
-		InstanceTypes: aws.StringSlice(instanceTypes),
+func getDescribeInstanceTypesOutput(ctx context.Context, ec2Client *ec2.Client, instanceTypes []string) string {
+	out, err := ec2Client.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: lo.Map(instanceTypes, func(it string, _ int) ec2types.InstanceType { return ec2types.InstanceType(it) }),
 	})
 	if err != nil {
 		log.Fatalf("describing instance types, %s", err)
 	}
 	// Sort them by name so that we get a consistent ordering
 	sort.SliceStable(out.InstanceTypes, func(i, j int) bool {
-		return aws.StringValue(out.InstanceTypes[i].InstanceType) < aws.StringValue(out.InstanceTypes[j].InstanceType)
+		return out.InstanceTypes[i].InstanceType < out.InstanceTypes[j].InstanceType
 	})
 
 	src := &bytes.Buffer{}
 	fmt.Fprintln(src, "var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{")
-	fmt.Fprintln(src, "InstanceTypes: []*ec2.InstanceTypeInfo{")
+	fmt.Fprintln(src, "InstanceTypes: []ec2types.InstanceTypeInfo{")
 	for _, elem := range out.InstanceTypes {
 		fmt.Fprintln(src, "{")
 		data := getInstanceTypeInfo(elem)
@@ -110,29 +107,29 @@ func getDescribeInstanceTypesOutput(ctx context.Context, ec2Client ec2iface.EC2A
 	return src.String()
 }
 
-func getInstanceTypeInfo(info *ec2.InstanceTypeInfo) string {
+func getInstanceTypeInfo(info ec2types.InstanceTypeInfo) string {
 	src := &bytes.Buffer{}
-	fmt.Fprintf(src, "InstanceType: aws.String(\"%s\"),\n", lo.FromPtr(info.InstanceType))
-	fmt.Fprintf(src, "SupportedUsageClasses: aws.StringSlice([]string{%s}),\n", getStringSliceData(info.SupportedUsageClasses))
-	fmt.Fprintf(src, "SupportedVirtualizationTypes: aws.StringSlice([]string{%s}),\n", getStringSliceData(info.SupportedVirtualizationTypes))
+	fmt.Fprintf(src, "InstanceType: \"%s\",\n", info.InstanceType)
+	fmt.Fprintf(src, "SupportedUsageClasses: []ec2types.UsageClassType{%s},\n", getStringSliceData(info.SupportedUsageClasses))
+	fmt.Fprintf(src, "SupportedVirtualizationTypes: []ec2types.VirtualizationType{%s},\n", getStringSliceData(info.SupportedVirtualizationTypes))
 	fmt.Fprintf(src, "BurstablePerformanceSupported: aws.Bool(%t),\n", lo.FromPtr(info.BurstablePerformanceSupported))
 	fmt.Fprintf(src, "BareMetal: aws.Bool(%t),\n", lo.FromPtr(info.BareMetal))
-	fmt.Fprintf(src, "Hypervisor: aws.String(\"%s\"),\n", lo.FromPtr(info.Hypervisor))
-	fmt.Fprintf(src, "ProcessorInfo: &ec2.ProcessorInfo{\n")
+	fmt.Fprintf(src, "Hypervisor: \"%s\",\n", info.Hypervisor)
+	fmt.Fprintf(src, "ProcessorInfo: &ec2types.ProcessorInfo{\n")
 	fmt.Fprintf(src, "Manufacturer: aws.String(\"%s\"),\n", lo.FromPtr(info.ProcessorInfo.Manufacturer))
-	fmt.Fprintf(src, "SupportedArchitectures: aws.StringSlice([]string{%s}),\n", getStringSliceData(info.ProcessorInfo.SupportedArchitectures))
+	fmt.Fprintf(src, "SupportedArchitectures: []ec2types.ArchitectureType{%s},\n", getStringSliceData(info.ProcessorInfo.SupportedArchitectures))
 	fmt.Fprintf(src, "},\n")
-	fmt.Fprintf(src, "VCpuInfo: &ec2.VCpuInfo{\n")
-	fmt.Fprintf(src, "DefaultCores: aws.Int64(%d),\n", lo.FromPtr(info.VCpuInfo.DefaultCores))
-	fmt.Fprintf(src, "DefaultVCpus: aws.Int64(%d),\n", lo.FromPtr(info.VCpuInfo.DefaultVCpus))
+	fmt.Fprintf(src, "VCpuInfo: &ec2types.VCpuInfo{\n")
+	fmt.Fprintf(src, "DefaultCores: aws.Int32(%d),\n", lo.FromPtr(info.VCpuInfo.DefaultCores))
+	fmt.Fprintf(src, "DefaultVCpus: aws.Int32(%d),\n", lo.FromPtr(info.VCpuInfo.DefaultVCpus))
 	fmt.Fprintf(src, "},\n")
-	fmt.Fprintf(src, "MemoryInfo: &ec2.MemoryInfo{\n")
+	fmt.Fprintf(src, "MemoryInfo: &ec2types.MemoryInfo{\n")
 	fmt.Fprintf(src, "SizeInMiB: aws.Int64(%d),\n", lo.FromPtr(info.MemoryInfo.SizeInMiB))
 	fmt.Fprintf(src, "},\n")
 
 	if info.InferenceAcceleratorInfo != nil {
-		fmt.Fprintf(src, "InferenceAcceleratorInfo: &ec2.InferenceAcceleratorInfo{\n")
-		fmt.Fprintf(src, "Accelerators: []*ec2.InferenceDeviceInfo{\n")
+		fmt.Fprintf(src, "InferenceAcceleratorInfo: &ec2types.InferenceAcceleratorInfo{\n")
+		fmt.Fprintf(src, "Accelerators: []ec2types.InferenceDeviceInfo{\n")
 		for _, elem := range info.InferenceAcceleratorInfo.Accelerators {
 			fmt.Fprintf(src, getInferenceAcceleratorDeviceInfo(elem))
 		}
@@ -140,8 +137,8 @@ func getInstanceTypeInfo(info *ec2.InstanceTypeInfo) string {
 		fmt.Fprintf(src, "},\n")
 	}
 	if info.GpuInfo != nil {
-		fmt.Fprintf(src, "GpuInfo: &ec2.GpuInfo{\n")
-		fmt.Fprintf(src, "Gpus: []*ec2.GpuDeviceInfo{\n")
+		fmt.Fprintf(src, "GpuInfo: &ec2types.GpuInfo{\n")
+		fmt.Fprintf(src, "Gpus: []ec2types.GpuDeviceInfo{\n")
 		for _, elem := range info.GpuInfo.Gpus {
 			fmt.Fprintf(src, getGPUDeviceInfo(elem))
 		}
@@ -149,22 +146,22 @@ func getInstanceTypeInfo(info *ec2.InstanceTypeInfo) string {
 		fmt.Fprintf(src, "},\n")
 	}
 	if info.InstanceStorageInfo != nil {
-		fmt.Fprintf(src, "InstanceStorageInfo: &ec2.InstanceStorageInfo{")
-		fmt.Fprintf(src, "NvmeSupport: aws.String(\"%s\"),\n", lo.FromPtr(info.InstanceStorageInfo.NvmeSupport))
+		fmt.Fprintf(src, "InstanceStorageInfo: &ec2types.InstanceStorageInfo{")
+		fmt.Fprintf(src, "NvmeSupport: \"%s\",\n", info.InstanceStorageInfo.NvmeSupport)
 		fmt.Fprintf(src, "TotalSizeInGB: aws.Int64(%d),\n", lo.FromPtr(info.InstanceStorageInfo.TotalSizeInGB))
 		fmt.Fprintf(src, "},\n")
 	}
-	fmt.Fprintf(src, "NetworkInfo: &ec2.NetworkInfo{\n")
+	fmt.Fprintf(src, "NetworkInfo: &ec2types.NetworkInfo{\n")
 	if info.NetworkInfo.EfaInfo != nil {
-		fmt.Fprintf(src, "EfaInfo: &ec2.EfaInfo{\n")
-		fmt.Fprintf(src, "MaximumEfaInterfaces: aws.Int64(%d),\n", lo.FromPtr(info.NetworkInfo.EfaInfo.MaximumEfaInterfaces))
+		fmt.Fprintf(src, "EfaInfo: &ec2types.EfaInfo{\n")
+		fmt.Fprintf(src, "MaximumEfaInterfaces: aws.Int32(%d),\n", lo.FromPtr(info.NetworkInfo.EfaInfo.MaximumEfaInterfaces))
 		fmt.Fprintf(src, "},\n")
 	}
-	fmt.Fprintf(src, "MaximumNetworkInterfaces: aws.Int64(%d),\n", lo.FromPtr(info.NetworkInfo.MaximumNetworkInterfaces))
-	fmt.Fprintf(src, "Ipv4AddressesPerInterface: aws.Int64(%d),\n", lo.FromPtr(info.NetworkInfo.Ipv4AddressesPerInterface))
+	fmt.Fprintf(src, "MaximumNetworkInterfaces: aws.Int32(%d),\n", lo.FromPtr(info.NetworkInfo.MaximumNetworkInterfaces))
+	fmt.Fprintf(src, "Ipv4AddressesPerInterface: aws.Int32(%d),\n", lo.FromPtr(info.NetworkInfo.Ipv4AddressesPerInterface))
 	fmt.Fprintf(src, "EncryptionInTransitSupported: aws.Bool(%t),\n", lo.FromPtr(info.NetworkInfo.EncryptionInTransitSupported))
-	fmt.Fprintf(src, "DefaultNetworkCardIndex: aws.Int64(%d),\n", lo.FromPtr(info.NetworkInfo.DefaultNetworkCardIndex))
-	fmt.Fprintf(src, "NetworkCards: []*ec2.NetworkCardInfo{\n")
+	fmt.Fprintf(src, "DefaultNetworkCardIndex: aws.Int32(%d),\n", lo.FromPtr(info.NetworkInfo.DefaultNetworkCardIndex))
+	fmt.Fprintf(src, "NetworkCards: []ec2types.NetworkCardInfo{\n")
 	for _, networkCard := range info.NetworkInfo.NetworkCards {
 		fmt.Fprintf(src, getNetworkCardInfo(networkCard))
 	}
@@ -173,38 +170,38 @@ func getInstanceTypeInfo(info *ec2.InstanceTypeInfo) string {
 	return src.String()
 }
 
-func getNetworkCardInfo(info *ec2.NetworkCardInfo) string {
+func getNetworkCardInfo(info ec2types.NetworkCardInfo) string {
 	src := &bytes.Buffer{}
 	fmt.Fprintf(src, "{\n")
-	fmt.Fprintf(src, "NetworkCardIndex: aws.Int64(%d),\n", lo.FromPtr(info.NetworkCardIndex))
-	fmt.Fprintf(src, "MaximumNetworkInterfaces: aws.Int64(%d),\n", lo.FromPtr(info.MaximumNetworkInterfaces))
+	fmt.Fprintf(src, "NetworkCardIndex: aws.Int32(%d),\n", lo.FromPtr(info.NetworkCardIndex))
+	fmt.Fprintf(src, "MaximumNetworkInterfaces: aws.Int32(%d),\n", lo.FromPtr(info.MaximumNetworkInterfaces))
 	fmt.Fprintf(src, "},\n")
 	return src.String()
 }
 
-func getInferenceAcceleratorDeviceInfo(info *ec2.InferenceDeviceInfo) string {
+func getInferenceAcceleratorDeviceInfo(info ec2types.InferenceDeviceInfo) string {
 	src := &bytes.Buffer{}
 	fmt.Fprintf(src, "{\n")
 	fmt.Fprintf(src, "Name: aws.String(\"%s\"),\n", lo.FromPtr(info.Name))
 	fmt.Fprintf(src, "Manufacturer: aws.String(\"%s\"),\n", lo.FromPtr(info.Manufacturer))
-	fmt.Fprintf(src, "Count: aws.Int64(%d),\n", lo.FromPtr(info.Count))
+	fmt.Fprintf(src, "Count: aws.Int32(%d),\n", lo.FromPtr(info.Count))
 	fmt.Fprintf(src, "},\n")
 	return src.String()
 }
 
-func getGPUDeviceInfo(info *ec2.GpuDeviceInfo) string {
+func getGPUDeviceInfo(info ec2types.GpuDeviceInfo) string {
 	src := &bytes.Buffer{}
 	fmt.Fprintf(src, "{\n")
 	fmt.Fprintf(src, "Name: aws.String(\"%s\"),\n", lo.FromPtr(info.Name))
 	fmt.Fprintf(src, "Manufacturer: aws.String(\"%s\"),\n", lo.FromPtr(info.Manufacturer))
-	fmt.Fprintf(src, "Count: aws.Int64(%d),\n", lo.FromPtr(info.Count))
-	fmt.Fprintf(src, "MemoryInfo: &ec2.GpuDeviceMemoryInfo{\n")
-	fmt.Fprintf(src, "SizeInMiB: aws.Int64(%d),\n", lo.FromPtr(info.MemoryInfo.SizeInMiB))
+	fmt.Fprintf(src, "Count: aws.Int32(%d),\n", lo.FromPtr(info.Count))
+	fmt.Fprintf(src, "MemoryInfo: &ec2types.GpuDeviceMemoryInfo{\n")
+	fmt.Fprintf(src, "SizeInMiB: aws.Int32(%d),\n", lo.FromPtr(info.MemoryInfo.SizeInMiB))
 	fmt.Fprintf(src, "},\n")
 	fmt.Fprintf(src, "},\n")
 	return src.String()
 }
 
-func getStringSliceData(slice []*string) string {
-	return strings.Join(lo.Map(slice, func(s *string, _ int) string { return fmt.Sprintf(`"%s"`, lo.FromPtr(s)) }), ",")
+func getStringSliceData[T ~string](slice []T) string {
+	return strings.Join(lo.Map(slice, func(s T, _ int) string { return fmt.Sprintf(`"%s"`, s) }), ",")
 }
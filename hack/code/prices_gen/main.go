@@ -28,10 +28,11 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws/session"
-	ec22 "github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/pricing"
@@ -91,12 +92,11 @@ func main() {
 	defer f.Close() // error handling omitted for example
 
 	const region = "us-east-1"
-	os.Setenv("AWS_SDK_LOAD_CONFIG", "true")
 	os.Setenv("AWS_REGION", region)
 	ctx := context.Background()
 	ctx = options.ToContext(ctx, test.Options())
-	sess := session.Must(session.NewSession())
-	ec2 := ec22.New(sess)
+	cfg := lo.Must(config.LoadDefaultConfig(ctx))
+	ec2api := ec2.NewFromConfig(cfg)
 	src := &bytes.Buffer{}
 	fmt.Fprintln(src, "//go:build !ignore_autogenerated")
 	license := lo.Must(os.ReadFile("hack/boilerplate.go.txt"))
@@ -108,8 +108,8 @@ func main() {
 	// record prices for each region we are interested in
 	for _, region := range getAWSRegions(opts.partition) {
 		log.Println("fetching for", region)
-		pricingProvider := pricing.NewProvider(ctx, pricing.NewAPI(sess, region), ec2, region)
-		controller := controllerspricing.NewController(pricingProvider)
+		pricingProvider := pricing.NewProvider(ctx, pricing.NewAPI(cfg, region, ""), ec2api, nil, region)
+		controller := controllerspricing.NewController(clock.RealClock{}, pricingProvider)
 		_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{}})
 		if err != nil {
 			log.Fatalf("failed to initialize pricing provider %s", err)
@@ -95,8 +95,7 @@ func main() {
 		Manager:             &FakeManager{},
 		KubernetesInterface: kubernetes.NewForConfigOrDie(&rest.Config{}),
 	})
-	cp := awscloudprovider.New(op.InstanceTypesProvider, op.InstanceProvider,
-		op.EventRecorder, op.GetClient(), op.AMIProvider, op.SecurityGroupProvider, op.SubnetProvider)
+	cp := awscloudprovider.New(op.InstanceTypesProvider, op.AccountProvider, op.EventRecorder, op.GetClient(), op.PricingProvider)
 
 	instanceTypes, err := cp.GetInstanceTypes(ctx, nil)
 	if err != nil {
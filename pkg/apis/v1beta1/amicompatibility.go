@@ -0,0 +1,73 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"knative.dev/pkg/apis"
+)
+
+type amiCompatibilityResolverKey struct{}
+
+// AMICompatibilityResolver resolves whether the AMIs of EC2NodeClasses can launch instances for the NodePools that
+// reference them. NodePools whose requirements don't intersect with any of the AMIs, such as amd64 NodePools with
+// arm64 AMIs, never launch instances.
+type AMICompatibilityResolver interface {
+	// IncompatibleNodePools returns why none of the AMIs of the EC2NodeClass are compatible with each NodePool that
+	// references it, by NodePool name
+	IncompatibleNodePools(context.Context, *EC2NodeClass) (map[string]string, error)
+}
+
+// +k8s:deepcopy-gen=false
+type amiCompatibilityValidation struct {
+	resolver AMICompatibilityResolver
+	level    apis.DiagnosticLevel
+}
+
+// WithAMICompatibilityResolver returns a context that the AMIs of EC2NodeClasses are checked against the NodePools
+// that reference them in when they're validated. Incompatible NodePools are errors, or warnings if warn is set.
+func WithAMICompatibilityResolver(ctx context.Context, resolver AMICompatibilityResolver, warn bool) context.Context {
+	return context.WithValue(ctx, amiCompatibilityResolverKey{}, amiCompatibilityValidation{resolver: resolver, level: diagnosticLevel(warn)})
+}
+
+// validateAMICompatibility checks the AMIs against the NodePools that reference the EC2NodeClass when it's created or
+// the fields that select its AMIs are changed. AMIs that can't be resolved are only warned on.
+func (in *EC2NodeClass) validateAMICompatibility(ctx context.Context, original *EC2NodeClass) *apis.FieldError {
+	validation, ok := ctx.Value(amiCompatibilityResolverKey{}).(amiCompatibilityValidation)
+	if !ok {
+		return nil
+	}
+	if original != nil && lo.FromPtr(in.Spec.AMIFamily) == lo.FromPtr(original.Spec.AMIFamily) &&
+		equality.Semantic.DeepEqual(in.Spec.AMISelectorTerms, original.Spec.AMISelectorTerms) {
+		return nil
+	}
+	path := lo.Ternary(len(in.Spec.AMISelectorTerms) > 0, amiSelectorTermsPath, amiFamilyPath)
+	incompatible, err := validation.resolver.IncompatibleNodePools(ctx, in)
+	if err != nil {
+		return apis.ErrGeneric(fmt.Sprintf("couldn't check amis against nodepools, %s", err), path).At(apis.WarningLevel).ViaField("spec")
+	}
+	names := lo.Keys(incompatible)
+	sort.Strings(names)
+	var errs *apis.FieldError
+	for _, name := range names {
+		errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("amis aren't compatible with nodepool %q, %s", name, incompatible[name]), path).At(validation.level))
+	}
+	return errs.ViaField("spec")
+}
@@ -15,7 +15,10 @@ limitations under the License.
 package v1beta1
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/samber/lo"
@@ -60,7 +63,7 @@ type EC2NodeClassSpec struct {
 	// +optional
 	UserData *string `json:"userData,omitempty"`
 	// Role is the AWS identity that nodes use. This field is immutable.
-	// This field is mutually exclusive from instanceProfile.
+	// This field is mutually exclusive from instanceProfile and instanceProfileSelectorTerms.
 	// Marking this field as immutable avoids concerns around terminating managed instance profiles from running instances.
 	// This field may be made mutable in the future, assuming the correct garbage collection and drift handling is implemented
 	// for the old instance profiles on an update.
@@ -69,13 +72,33 @@ type EC2NodeClassSpec struct {
 	// +optional
 	Role string `json:"role,omitempty"`
 	// InstanceProfile is the AWS entity that instances use.
-	// This field is mutually exclusive from role.
+	// This field is mutually exclusive from role and instanceProfileSelectorTerms.
 	// The instance profile should already have a role assigned to it that Karpenter
 	//  has PassRole permission on for instance launch using this instanceProfile to succeed.
 	// +kubebuilder:validation:XValidation:rule="self != ''",message="instanceProfile cannot be empty"
 	// +optional
 	InstanceProfile *string `json:"instanceProfile,omitempty"`
-	// Tags to be applied on ec2 resources like instances and launch templates.
+	// InstanceProfileSelectorTerms is a list of or instance profile selector terms. The terms are ORed.
+	// Instances use the most recently created instance profile that is selected.
+	// This field is mutually exclusive from role and instanceProfile.
+	// +kubebuilder:validation:XValidation:message="instanceProfileSelectorTerms cannot be empty",rule="self.size() != 0"
+	// +kubebuilder:validation:MaxItems:=30
+	// +optional
+	InstanceProfileSelectorTerms []InstanceProfileSelectorTerm `json:"instanceProfileSelectorTerms,omitempty" hash:"ignore"`
+	// AssumeRoleARN is the ARN of an IAM role in another account that Karpenter assumes to launch the instances of the
+	// nodeclass in that account. Subnets, security groups and AMIs are selected from the role's account, and launch
+	// templates and instance profiles are created in it. This field is immutable.
+	// +kubebuilder:validation:Pattern:="^arn:aws[-a-z]*:iam::[0-9]{12}:role/.+$"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="immutable field changed"
+	// +optional
+	AssumeRoleARN *string `json:"assumeRoleARN,omitempty" hash:"ignore"`
+	// AssumeRoleExternalID is the external ID that is passed when assuming assumeRoleARN, if the role's trust policy
+	// requires one. This field is immutable.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="immutable field changed"
+	// +optional
+	AssumeRoleExternalID *string `json:"assumeRoleExternalID,omitempty" hash:"ignore"`
+	// Tags to be applied on ec2 resources like instances and launch templates. Changes to tags are applied to
+	// existing instances in place, rather than drifting them.
 	// +kubebuilder:validation:XValidation:message="empty tag keys aren't supported",rule="self.all(k, k != '')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching kubernetes.io/cluster/",rule="self.all(k, !k.startsWith('kubernetes.io/cluster') )"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodepool",rule="self.all(k, k != 'karpenter.sh/nodepool')"
@@ -83,7 +106,7 @@ type EC2NodeClassSpec struct {
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.sh/nodeclaim",rule="self.all(k, k !='karpenter.sh/nodeclaim')"
 	// +kubebuilder:validation:XValidation:message="tag contains a restricted tag matching karpenter.k8s.aws/ec2nodeclass",rule="self.all(k, k !='karpenter.k8s.aws/ec2nodeclass')"
 	// +optional
-	Tags map[string]string `json:"tags,omitempty"`
+	Tags map[string]string `json:"tags,omitempty" hash:"ignore"`
 	// BlockDeviceMappings to be applied to provisioned nodes.
 	// +kubebuilder:validation:XValidation:message="must have only one blockDeviceMappings with rootVolume",rule="self.filter(x, has(x.rootVolume)?x.rootVolume==true:false).size() <= 1"
 	// +kubebuilder:validation:MaxItems:=50
@@ -174,6 +197,17 @@ type AMISelectorTerm struct {
 	Owner string `json:"owner,omitempty"`
 }
 
+// InstanceProfileSelectorTerm defines selection logic for an instance profile that instances are launched with.
+type InstanceProfileSelectorTerm struct {
+	// Tags is a map of key/value tags used to select instance profiles
+	// Specifying '*' for a value selects all values for a given tag key.
+	// +kubebuilder:validation:XValidation:message="empty tag keys or values aren't supported",rule="self.all(k, k != '' && self[k] != '')"
+	// +kubebuilder:validation:MinProperties:=1
+	// +kubebuilder:validation:MaxProperties:=20
+	// +required
+	Tags map[string]string `json:"tags"`
+}
+
 // MetadataOptions contains parameters for specifying the exposure of the
 // Instance Metadata Service to provisioned EC2 nodes.
 type MetadataOptions struct {
@@ -293,7 +327,7 @@ type BlockDevice struct {
 	// +kubebuilder:validation:Pattern:="^((?:[1-9][0-9]{0,3}|[1-4][0-9]{4}|[5][0-8][0-9]{3}|59000)Gi|(?:[1-9][0-9]{0,3}|[1-5][0-9]{4}|[6][0-3][0-9]{3}|64000)G|([1-9]||[1-5][0-7]|58)Ti|([1-9]||[1-5][0-9]|6[0-3]|64)T)$"
 	// +kubebuilder:validation:XIntOrString
 	// +optional
-	VolumeSize *resource.Quantity `json:"volumeSize,omitempty"`
+	VolumeSize *resource.Quantity `json:"volumeSize,omitempty" hash:"string"`
 	// VolumeType of the block device.
 	// For more information, see Amazon EBS volume types (https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/EBSVolumeTypes.html)
 	// in the Amazon Elastic Compute Cloud User Guide.
@@ -323,8 +357,9 @@ type EC2NodeClass struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	// +kubebuilder:validation:XValidation:message="amiSelectorTerms is required when amiFamily == 'Custom'",rule="self.amiFamily == 'Custom' ? self.amiSelectorTerms.size() != 0 : true"
-	// +kubebuilder:validation:XValidation:message="must specify exactly one of ['role', 'instanceProfile']",rule="(has(self.role) && !has(self.instanceProfile)) || (!has(self.role) && has(self.instanceProfile))"
-	// +kubebuilder:validation:XValidation:message="changing from 'instanceProfile' to 'role' is not supported. You must delete and recreate this node class if you want to change this.",rule="(has(oldSelf.role) && has(self.role)) || (has(oldSelf.instanceProfile) && has(self.instanceProfile))"
+	// +kubebuilder:validation:XValidation:message="must specify exactly one of ['role', 'instanceProfile', 'instanceProfileSelectorTerms']",rule="(has(self.role) ? 1 : 0) + (has(self.instanceProfile) ? 1 : 0) + (has(self.instanceProfileSelectorTerms) ? 1 : 0) == 1"
+	// +kubebuilder:validation:XValidation:message="changing between 'role', 'instanceProfile' and 'instanceProfileSelectorTerms' is not supported. You must delete and recreate this node class if you want to change this.",rule="(has(oldSelf.role) && has(self.role)) || (has(oldSelf.instanceProfile) && has(self.instanceProfile)) || (has(oldSelf.instanceProfileSelectorTerms) && has(self.instanceProfileSelectorTerms))"
+	// +kubebuilder:validation:XValidation:message="assumeRoleARN and assumeRoleExternalID can't be added or removed. You must delete and recreate this node class if you want to change this.",rule="has(oldSelf.assumeRoleARN) == has(self.assumeRoleARN) && has(oldSelf.assumeRoleExternalID) == has(self.assumeRoleExternalID)"
 	Spec   EC2NodeClassSpec   `json:"spec,omitempty"`
 	Status EC2NodeClassStatus `json:"status,omitempty"`
 }
@@ -333,16 +368,119 @@ type EC2NodeClass struct {
 // 1. A field changes its default value for an existing field that is already hashed
 // 2. A field is added to the hash calculation with an already-set value
 // 3. A field is removed from the hash calculations
-const EC2NodeClassHashVersion = "v1"
+// 4. The normalization of a hashed field changes
+const EC2NodeClassHashVersion = "v3"
 
 func (in *EC2NodeClass) Hash() string {
-	return fmt.Sprint(lo.Must(hashstructure.Hash(in.Spec, hashstructure.FormatV2, &hashstructure.HashOptions{
+	spec := in.normalizedSpec()
+	// Fields that are excluded from drift through AnnotationDriftIgnoredFields are hashed as if they weren't set
+	if ignored := in.DriftIgnoredFields(); len(ignored) > 0 {
+		v := reflect.ValueOf(&spec).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if lo.Contains(ignored, specFieldName(v.Type().Field(i))) {
+				v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+			}
+		}
+	}
+	return hash(spec)
+}
+
+// FieldHashes returns the hash of each of the spec fields that are hashed for drift, encoded as JSON so that it can be
+// set on NodeClaims through AnnotationEC2NodeClassFieldHashes
+func (in *EC2NodeClass) FieldHashes() string {
+	v := reflect.ValueOf(in.normalizedSpec())
+	hashes := map[string]string{}
+	for i := 0; i < v.NumField(); i++ {
+		if name := specFieldName(v.Type().Field(i)); lo.Contains(DriftFields(), name) {
+			hashes[name] = hash(v.Field(i).Interface())
+		}
+	}
+	return string(lo.Must(json.Marshal(hashes)))
+}
+
+// DriftedFields returns the spec fields whose hashes differ from the passed field hashes, in the order that they're
+// defined in the spec. Fields that are excluded from drift aren't returned, and no fields are returned if the field
+// hashes can't be decoded.
+func (in *EC2NodeClass) DriftedFields(fieldHashes string) []string {
+	launched := map[string]string{}
+	if err := json.Unmarshal([]byte(fieldHashes), &launched); err != nil {
+		return nil
+	}
+	current := map[string]string{}
+	lo.Must0(json.Unmarshal([]byte(in.FieldHashes()), &current))
+	return lo.Filter(DriftFields(), func(f string, _ int) bool {
+		return !lo.Contains(in.DriftIgnoredFields(), f) && launched[f] != current[f]
+	})
+}
+
+// normalizedSpec returns a copy of the spec where values that can be serialized in more than one way without changing
+// their meaning are set in a single form. This keeps the hash stable when tools like GitOps controllers re-serialize
+// the EC2NodeClass, e.g. by writing empty lists or converting volume sizes between units. Volume sizes are hashed by their
+// string form, since the fields of a resource.Quantity that hold its value are unexported and aren't hashed.
+func (in *EC2NodeClass) normalizedSpec() EC2NodeClassSpec {
+	spec := *in.Spec.DeepCopy()
+	for _, bdm := range spec.BlockDeviceMappings {
+		if bdm != nil && bdm.EBS != nil && bdm.EBS.VolumeSize != nil {
+			bdm.EBS.VolumeSize = resource.NewQuantity(bdm.EBS.VolumeSize.Value(), resource.BinarySI)
+		}
+	}
+	v := reflect.ValueOf(&spec).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); (f.Kind() == reflect.Slice || f.Kind() == reflect.Map) && f.Len() == 0 {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+	return spec
+}
+
+func hash(v interface{}) string {
+	return fmt.Sprint(lo.Must(hashstructure.Hash(v, hashstructure.FormatV2, &hashstructure.HashOptions{
 		SlicesAsSets:    true,
 		IgnoreZeroValue: true,
 		ZeroNil:         true,
 	})))
 }
 
+// DriftIgnoredFields returns the spec fields that are excluded from drift through AnnotationDriftIgnoredFields
+func (in *EC2NodeClass) DriftIgnoredFields() []string {
+	value, ok := in.Annotations[AnnotationDriftIgnoredFields]
+	if !ok {
+		return nil
+	}
+	return lo.Compact(lo.Map(strings.Split(value, ","), func(f string, _ int) string { return strings.TrimSpace(f) }))
+}
+
+// BottlerocketInPlaceUpdates returns true if the nodes of the EC2NodeClass are updated to new Bottlerocket releases in
+// place by the Bottlerocket update operator, instead of being replaced
+func (in *EC2NodeClass) BottlerocketInPlaceUpdates() bool {
+	return lo.FromPtr(in.Spec.AMIFamily) == AMIFamilyBottlerocket &&
+		in.Annotations[AnnotationBottlerocketUpdateStrategy] == BottlerocketUpdateStrategyInPlace
+}
+
+// ZoneIDs returns the IDs of the zones of the EC2NodeClass' subnets, keyed by zone name
+func (in *EC2NodeClass) ZoneIDs() map[string]string {
+	return lo.SliceToMap(lo.Filter(in.Status.Subnets, func(s Subnet, _ int) bool { return s.ZoneID != "" }), func(s Subnet) (string, string) {
+		return s.Zone, s.ZoneID
+	})
+}
+
+// DriftFields returns the spec fields that are hashed to detect drift, and so may be excluded from drift
+func DriftFields() []string {
+	t := reflect.TypeOf(EC2NodeClassSpec{})
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("hash") != "ignore" {
+			fields = append(fields, specFieldName(t.Field(i)))
+		}
+	}
+	return fields
+}
+
+// specFieldName returns the name of the spec field as it's set in the EC2NodeClass
+func specFieldName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
+
 // EC2NodeClassList contains a list of EC2NodeClass
 // +kubebuilder:object:root=true
 type EC2NodeClassList struct {
@@ -15,9 +15,10 @@ limitations under the License.
 package v1beta1_test
 
 import (
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
@@ -27,7 +28,7 @@ import (
 )
 
 var _ = Describe("Hash", func() {
-	const staticHash = "16608948681250225098"
+	const staticHash = "8179066206615487308"
 	var nodeClass *v1beta1.EC2NodeClass
 	BeforeEach(func() {
 		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
@@ -63,25 +64,24 @@ var _ = Describe("Hash", func() {
 		},
 		Entry("Base EC2NodeClass", staticHash),
 		// Static fields, expect changed hash from base
-		Entry("UserData Drift", "588756456110800812", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
-		Entry("Tags Drift", "2471764681523766508", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
-		Entry("MetadataOptions Drift", "11030161632375731908", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{MetadataOptions: &v1beta1.MetadataOptions{HTTPEndpoint: aws.String("test-metadata-2")}}}),
-		Entry("BlockDeviceMappings Drift", "436753305915039702", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-test-3")}}}}),
-		Entry("Context Drift", "3729470655588343019", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
-		Entry("DetailedMonitoring Drift", "17892305444040067573", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
-		Entry("AMIFamily Drift", "9493798894326942407", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
-		Entry("Reorder Tags", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-2": "valueTag-2", "keyTag-1": "valueTag-1"}}}),
+		Entry("UserData Drift", "16964911934606718195", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
+		Entry("MetadataOptions Drift", "2752147724497671437", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{MetadataOptions: &v1beta1.MetadataOptions{HTTPEndpoint: aws.String("test-metadata-2")}}}),
+		Entry("BlockDeviceMappings Drift", "11200163953261406634", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-test-3")}}}}),
+		Entry("Context Drift", "15638857364317885556", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
+		Entry("DetailedMonitoring Drift", "14665811174340678227", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+		Entry("AMIFamily Drift", "3489838428214364875", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
 		Entry("Reorder BlockDeviceMapping", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-2")}, {DeviceName: aws.String("map-device-1")}}}}),
 
 		// Behavior / Dynamic fields, expect same hash as base
 		Entry("Modified AMISelector", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMISelectorTerms: []v1beta1.AMISelectorTerm{{Tags: map[string]string{"ami-test-key": "ami-test-value"}}}}}),
 		Entry("Modified SubnetSelector", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"subnet-test-key": "subnet-test-value"}}}}}),
 		Entry("Modified SecurityGroupSelector", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{SecurityGroupSelectorTerms: []v1beta1.SecurityGroupSelectorTerm{{Tags: map[string]string{"security-group-test-key": "security-group-test-value"}}}}}),
+		Entry("Modified Tags", staticHash, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 	)
 	It("should match static hash for instanceProfile", func() {
 		nodeClass.Spec.Role = ""
 		nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
-		Expect(nodeClass.Hash()).To(Equal("15756064858220068103"))
+		Expect(nodeClass.Hash()).To(Equal("15143407363695790155"))
 	})
 	DescribeTable("should change hash when static fields are updated", func(changes v1beta1.EC2NodeClass) {
 		hash := nodeClass.Hash()
@@ -90,7 +90,6 @@ var _ = Describe("Hash", func() {
 		Expect(hash).ToNot(Equal(updatedHash))
 	},
 		Entry("UserData Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
-		Entry("Tags Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 		Entry("MetadataOptions Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{MetadataOptions: &v1beta1.MetadataOptions{HTTPEndpoint: aws.String("test-metadata-2")}}}),
 		Entry("BlockDeviceMappings Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-test-3")}}}}),
 		Entry("Context Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
@@ -105,15 +104,82 @@ var _ = Describe("Hash", func() {
 		updatedHash := nodeClass.Hash()
 		Expect(hash).ToNot(Equal(updatedHash))
 	})
+	DescribeTable("should not change hash when fields that are excluded from drift are updated", func(changes v1beta1.EC2NodeClass) {
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
+			v1beta1.AnnotationDriftIgnoredFields: "userData, detailedMonitoring,amiFamily",
+		})
+		hash := nodeClass.Hash()
+		Expect(mergo.Merge(nodeClass, changes, mergo.WithOverride)).To(Succeed())
+		updatedHash := nodeClass.Hash()
+		Expect(hash).To(Equal(updatedHash))
+	},
+		Entry("UserData Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
+		Entry("DetailedMonitoring Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+		Entry("AMIFamily Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
+	)
+	It("should change hash when fields that aren't excluded from drift are updated", func() {
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
+			v1beta1.AnnotationDriftIgnoredFields: "userData",
+		})
+		hash := nodeClass.Hash()
+		nodeClass.Spec.DetailedMonitoring = aws.Bool(true)
+		Expect(nodeClass.Hash()).ToNot(Equal(hash))
+	})
+	DescribeTable("should return the fields that drifted from the field hashes", func(field string, changes v1beta1.EC2NodeClass) {
+		fieldHashes := nodeClass.FieldHashes()
+		Expect(nodeClass.DriftedFields(fieldHashes)).To(BeEmpty())
+		Expect(mergo.Merge(nodeClass, changes, mergo.WithOverride)).To(Succeed())
+		Expect(nodeClass.DriftedFields(fieldHashes)).To(ConsistOf(field))
+	},
+		Entry("UserData Drift", "userData", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
+		Entry("MetadataOptions Drift", "metadataOptions", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{MetadataOptions: &v1beta1.MetadataOptions{HTTPEndpoint: aws.String("test-metadata-2")}}}),
+		Entry("BlockDeviceMappings Drift", "blockDeviceMappings", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-test-3")}}}}),
+		Entry("Context Drift", "context", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
+		Entry("DetailedMonitoring Drift", "detailedMonitoring", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+		Entry("AMIFamily Drift", "amiFamily", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
+	)
+	It("should not return fields that drifted when they are excluded from drift", func() {
+		fieldHashes := nodeClass.FieldHashes()
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1beta1.AnnotationDriftIgnoredFields: "userData"})
+		nodeClass.Spec.UserData = aws.String("userdata-test-2")
+		Expect(nodeClass.DriftedFields(fieldHashes)).To(BeEmpty())
+	})
+	It("should not return fields that drifted when the field hashes are invalid", func() {
+		nodeClass.Spec.UserData = aws.String("userdata-test-2")
+		Expect(nodeClass.DriftedFields("invalid")).To(BeEmpty())
+	})
 	DescribeTable("should not change hash when slices are re-ordered", func(changes v1beta1.EC2NodeClass) {
 		hash := nodeClass.Hash()
 		Expect(mergo.Merge(nodeClass, changes, mergo.WithOverride)).To(Succeed())
 		updatedHash := nodeClass.Hash()
 		Expect(hash).To(Equal(updatedHash))
 	},
-		Entry("Reorder Tags", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-2": "valueTag-2", "keyTag-1": "valueTag-1"}}}),
 		Entry("Reorder BlockDeviceMapping", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-2")}, {DeviceName: aws.String("map-device-1")}}}}),
 	)
+	It("should not change hash when volume sizes are converted between units", func() {
+		nodeClass.Spec.BlockDeviceMappings[0].EBS = &v1beta1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("100Gi"))}
+		hash, fieldHashes := nodeClass.Hash(), nodeClass.FieldHashes()
+
+		nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = lo.ToPtr(resource.MustParse("107374182400"))
+		Expect(nodeClass.Hash()).To(Equal(hash))
+		Expect(nodeClass.DriftedFields(fieldHashes)).To(BeEmpty())
+	})
+	It("should change hash when volume sizes are changed", func() {
+		nodeClass.Spec.BlockDeviceMappings[0].EBS = &v1beta1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse("100Gi"))}
+		hash, fieldHashes := nodeClass.Hash(), nodeClass.FieldHashes()
+
+		nodeClass.Spec.BlockDeviceMappings[0].EBS.VolumeSize = lo.ToPtr(resource.MustParse("200Gi"))
+		Expect(nodeClass.Hash()).ToNot(Equal(hash))
+		Expect(nodeClass.DriftedFields(fieldHashes)).To(ConsistOf("blockDeviceMappings"))
+	})
+	It("should not change hash when unset fields are set to empty values", func() {
+		nodeClass.Spec.BlockDeviceMappings = nil
+		hash, fieldHashes := nodeClass.Hash(), nodeClass.FieldHashes()
+
+		nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{}
+		Expect(nodeClass.Hash()).To(Equal(hash))
+		Expect(nodeClass.DriftedFields(fieldHashes)).To(BeEmpty())
+	})
 	It("should not change hash when behavior/dynamic fields are updated", func() {
 		hash := nodeClass.Hash()
 
@@ -133,6 +199,7 @@ var _ = Describe("Hash", func() {
 				Tags: map[string]string{"ami-test-key": "ami-test-value"},
 			},
 		}
+		nodeClass.Spec.Tags = map[string]string{"keyTag-test-3": "valueTag-test-3"}
 		updatedHash := nodeClass.Hash()
 		Expect(hash).To(Equal(updatedHash))
 	})
@@ -15,9 +15,28 @@ limitations under the License.
 package v1beta1
 
 import (
+	"knative.dev/pkg/apis"
+
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 )
 
+const (
+	// ConditionTypeSubnetsReady is true when the subnet selector terms of the EC2NodeClass select at least one subnet
+	ConditionTypeSubnetsReady apis.ConditionType = "SubnetsReady"
+	// ConditionTypeSecurityGroupsReady is true when the security group selector terms of the EC2NodeClass select at
+	// least one security group
+	ConditionTypeSecurityGroupsReady apis.ConditionType = "SecurityGroupsReady"
+	// ConditionTypeAMIsReady is true when the AMI selector terms or the amiFamily of the EC2NodeClass resolve at least
+	// one AMI
+	ConditionTypeAMIsReady apis.ConditionType = "AMIsReady"
+	// ConditionTypeInstanceProfileReady is true when the role or instance profile of the EC2NodeClass exists and its
+	// instance profile has a role, so that instances can be launched with it
+	ConditionTypeInstanceProfileReady apis.ConditionType = "InstanceProfileReady"
+	// ConditionTypeIMDSReachableFromPods is true when pods outside of the host network can reach IMDS on the instances
+	// that are launched for the EC2NodeClass. It's informational and doesn't affect whether the EC2NodeClass is ready.
+	ConditionTypeIMDSReachableFromPods apis.ConditionType = "IMDSReachableFromPods"
+)
+
 // Subnet contains resolved Subnet selector values utilized for node launch
 type Subnet struct {
 	// ID of the subnet
@@ -26,6 +45,15 @@ type Subnet struct {
 	// The associated availability zone
 	// +required
 	Zone string `json:"zone"`
+	// The ID of the associated availability zone, which identifies the same zone across accounts
+	// +optional
+	ZoneID string `json:"zoneID,omitempty"`
+	// AvailableIPAddressCount is the number of IPv4 addresses that are free in the subnet
+	// +optional
+	AvailableIPAddressCount int32 `json:"availableIPAddressCount"`
+	// IPv6CIDRBlocks are the IPv6 CIDR blocks that are associated with the subnet
+	// +optional
+	IPv6CIDRBlocks []string `json:"ipv6CIDRBlocks,omitempty"`
 }
 
 // SecurityGroup contains resolved SecurityGroup selector values utilized for node launch
@@ -46,6 +74,12 @@ type AMI struct {
 	// Name of the AMI
 	// +optional
 	Name string `json:"name,omitempty"`
+	// CreationDate of the AMI, in RFC 3339 format
+	// +optional
+	CreationDate string `json:"creationDate,omitempty"`
+	// Architecture of the AMI, as the value of the kubernetes.io/arch label of the nodes that are launched with it
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
 	// Requirements of the AMI to be utilized on an instance type
 	// +required
 	Requirements []corev1beta1.NodeSelectorRequirementWithMinValues `json:"requirements"`
@@ -65,7 +99,35 @@ type EC2NodeClassStatus struct {
 	// cluster under the AMI selectors.
 	// +optional
 	AMIs []AMI `json:"amis,omitempty"`
-	// InstanceProfile contains the resolved instance profile for the role
+	// InstanceProfile contains the resolved instance profile for the role, or the instance profile that is selected by
+	// the instance profile selectors
 	// +optional
 	InstanceProfile string `json:"instanceProfile,omitempty"`
+	// InstanceProfileARN is the ARN of the resolved instance profile
+	// +optional
+	InstanceProfileARN string `json:"instanceProfileARN,omitempty"`
+	// InstanceProfileManaged is true when the instance profile was created for the role by Karpenter, and is deleted
+	// with the EC2NodeClass
+	// +optional
+	InstanceProfileManaged bool `json:"instanceProfileManaged,omitempty"`
+	// Conditions contains signals for health and readiness
+	// +optional
+	Conditions apis.Conditions `json:"conditions,omitempty"`
+}
+
+func (in *EC2NodeClass) StatusConditions() apis.ConditionManager {
+	return apis.NewLivingConditionSet(
+		ConditionTypeSubnetsReady,
+		ConditionTypeSecurityGroupsReady,
+		ConditionTypeAMIsReady,
+		ConditionTypeInstanceProfileReady,
+	).Manage(in)
+}
+
+func (in *EC2NodeClass) GetConditions() apis.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *EC2NodeClass) SetConditions(conditions apis.Conditions) {
+	in.Status.Conditions = conditions
 }
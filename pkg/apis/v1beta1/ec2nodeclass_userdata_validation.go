@@ -0,0 +1,192 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/samber/lo"
+	"knative.dev/pkg/apis"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// maxUserDataSize is the size that EC2 limits user data to before it's base64 encoded. Karpenter merges its own
+	// bootstrap configuration into the user data, so user data of this size can never launch.
+	maxUserDataSize = 16 * 1024
+
+	nodeConfigContentType  = "application/node.eks.aws"
+	shellScriptContentType = "text/x-shellscript"
+)
+
+// powershellTagPattern matches the tags that Karpenter wraps the user data of Windows AMIs in
+var powershellTagPattern = regexp.MustCompile(`(?i)</?powershell>`)
+
+// validateUserData checks that the user data is structured the way that the bootstrapper of the amiFamily expects, so
+// that content that would fail to bootstrap is rejected at admission rather than when nodes don't join. The user data
+// of the Custom amiFamily is passed through as is, so it isn't validated.
+func (in *EC2NodeClassSpec) validateUserData() (errs *apis.FieldError) {
+	if in.UserData == nil {
+		return nil
+	}
+	userData := *in.UserData
+	if len(userData) >= maxUserDataSize {
+		errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%d bytes exceeds the limit of %d bytes", len(userData), maxUserDataSize), apis.CurrentField))
+	}
+	switch lo.FromPtr(in.AMIFamily) {
+	case AMIFamilyCustom:
+		return errs
+	case AMIFamilyBottlerocket:
+		if err := toml.Unmarshal([]byte(userData), &map[string]interface{}{}); err != nil {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("isn't valid TOML, %s", err), apis.CurrentField))
+		}
+	case AMIFamilyWindows2019, AMIFamilyWindows2022:
+		if powershellTagPattern.MatchString(userData) {
+			errs = errs.Also(apis.ErrInvalidValue("must not contain <powershell> tags, the user data is wrapped in them", apis.CurrentField))
+		}
+	case AMIFamilyAL2023:
+		errs = errs.Also(validateAL2023UserData(userData))
+	default:
+		errs = errs.Also(validateEKSBootstrapUserData(userData))
+	}
+	return errs
+}
+
+// validateEKSBootstrapUserData validates the user data of AMI families that bootstrap with the EKS bootstrap script,
+// which is either a MIME multi-part archive or a shell script that is added to one.
+func validateEKSBootstrapUserData(userData string) (errs *apis.FieldError) {
+	if !isMIME(userData) {
+		return validateShellScript(userData)
+	}
+	parts, err := parseMIME(userData)
+	if err != nil {
+		return apis.ErrInvalidValue(fmt.Sprintf("isn't a valid MIME multi-part archive, %s", err), apis.CurrentField)
+	}
+	for _, part := range parts {
+		if part.contentType == shellScriptContentType {
+			errs = errs.Also(validateShellScript(part.content))
+		}
+	}
+	return errs
+}
+
+// validateAL2023UserData validates the user data of AL2023, which is either a MIME multi-part archive, a NodeConfig or
+// a shell script. Content that doesn't parse as YAML is treated as a shell script, like nodeadm does.
+func validateAL2023UserData(userData string) (errs *apis.FieldError) {
+	if !isMIME(userData) {
+		if err := yaml.Unmarshal([]byte(userData), &map[string]interface{}{}); err == nil {
+			return validateNodeConfig(userData)
+		}
+		return validateShellScript(userData)
+	}
+	parts, err := parseMIME(userData)
+	if err != nil {
+		return apis.ErrInvalidValue(fmt.Sprintf("isn't a valid MIME multi-part archive, %s", err), apis.CurrentField)
+	}
+	for _, part := range parts {
+		switch part.contentType {
+		case nodeConfigContentType:
+			errs = errs.Also(validateNodeConfig(part.content))
+		case shellScriptContentType:
+			errs = errs.Also(validateShellScript(part.content))
+		}
+	}
+	return errs
+}
+
+func validateNodeConfig(content string) *apis.FieldError {
+	nodeConfig := struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}{}
+	if err := yaml.Unmarshal([]byte(content), &nodeConfig); err != nil {
+		return apis.ErrInvalidValue(fmt.Sprintf("isn't a valid NodeConfig, %s", err), apis.CurrentField)
+	}
+	if nodeConfig.Kind != "NodeConfig" || !strings.HasPrefix(nodeConfig.APIVersion, "node.eks.aws/") {
+		return apis.ErrInvalidValue(fmt.Sprintf("expected a NodeConfig of node.eks.aws, got kind %q of %q", nodeConfig.Kind, nodeConfig.APIVersion), apis.CurrentField)
+	}
+	return nil
+}
+
+// validateShellScript warns on shell scripts without an interpreter directive, which cloud-init fails to execute
+func validateShellScript(content string) *apis.FieldError {
+	if strings.TrimSpace(content) == "" || strings.HasPrefix(content, "#!") {
+		return nil
+	}
+	return apis.ErrInvalidValue("shell scripts should start with an interpreter directive such as #!/bin/bash", apis.CurrentField).At(apis.WarningLevel)
+}
+
+func isMIME(userData string) bool {
+	return strings.HasPrefix(strings.TrimSpace(userData), "MIME-Version:") || strings.HasPrefix(strings.TrimSpace(userData), "Content-Type:")
+}
+
+type mimePart struct {
+	contentType string
+	content     string
+}
+
+// parseMIME parses a MIME multi-part archive into its parts
+func parseMIME(userData string) ([]mimePart, error) {
+	message, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(strings.TrimSpace(userData))))
+	if err != nil {
+		return nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing content type, %w", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("expected a multipart content type, got %s", mediaType)
+	}
+	if params["boundary"] == "" {
+		return nil, fmt.Errorf("content type is missing a boundary")
+	}
+	var parts []mimePart
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		p, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading part, %w", err)
+		}
+		content, err := io.ReadAll(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading part, %w", err)
+		}
+		// parts without a content type are allowed, cloud-init treats them as plain text
+		part := mimePart{content: string(content)}
+		if header := p.Header.Get("Content-Type"); header != "" {
+			if part.contentType, _, err = mime.ParseMediaType(header); err != nil {
+				return nil, fmt.Errorf("parsing content type of part, %w", err)
+			}
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("expected at least one part")
+	}
+	return parts, nil
+}
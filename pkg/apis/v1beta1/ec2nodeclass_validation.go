@@ -19,27 +19,53 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"knative.dev/pkg/apis"
 )
 
 const (
-	subnetSelectorTermsPath        = "subnetSelectorTerms"
-	securityGroupSelectorTermsPath = "securityGroupSelectorTerms"
-	amiSelectorTermsPath           = "amiSelectorTerms"
-	amiFamilyPath                  = "amiFamily"
-	tagsPath                       = "tags"
-	metadataOptionsPath            = "metadataOptions"
-	blockDeviceMappingsPath        = "blockDeviceMappings"
-	rolePath                       = "role"
-	instanceProfilePath            = "instanceProfile"
+	subnetSelectorTermsPath          = "subnetSelectorTerms"
+	securityGroupSelectorTermsPath   = "securityGroupSelectorTerms"
+	amiSelectorTermsPath             = "amiSelectorTerms"
+	amiFamilyPath                    = "amiFamily"
+	tagsPath                         = "tags"
+	metadataOptionsPath              = "metadataOptions"
+	blockDeviceMappingsPath          = "blockDeviceMappings"
+	rolePath                         = "role"
+	instanceProfilePath              = "instanceProfile"
+	instanceProfileSelectorTermsPath = "instanceProfileSelectorTerms"
+	assumeRoleARNPath                = "assumeRoleARN"
+	assumeRoleExternalIDPath         = "assumeRoleExternalID"
+	userDataPath                     = "userData"
 )
 
 var (
 	minVolumeSize = *resource.NewScaledQuantity(1, resource.Giga)
 	maxVolumeSize = *resource.NewScaledQuantity(64, resource.Tera)
+
+	// rootDeviceNames are the device names of the root volumes of the AMIs of each amiFamily
+	rootDeviceNames = map[string]string{
+		AMIFamilyAL2:          "/dev/xvda",
+		AMIFamilyAL2023:       "/dev/xvda",
+		AMIFamilyBottlerocket: "/dev/xvda",
+		AMIFamilyUbuntu:       "/dev/sda1",
+		AMIFamilyWindows2019:  "/dev/sda1",
+		AMIFamilyWindows2022:  "/dev/sda1",
+	}
+	// ephemeralDeviceNames are the device names of the volumes that pods' ephemeral storage is on for each amiFamily,
+	// which is the volume that rootVolume is expected on. Bottlerocket keeps the OS and container data on separate volumes.
+	ephemeralDeviceNames = map[string]string{
+		AMIFamilyAL2:          "/dev/xvda",
+		AMIFamilyAL2023:       "/dev/xvda",
+		AMIFamilyBottlerocket: "/dev/xvdb",
+		AMIFamilyUbuntu:       "/dev/sda1",
+		AMIFamilyWindows2019:  "/dev/sda1",
+		AMIFamilyWindows2022:  "/dev/sda1",
+	}
 )
 
 func (in *EC2NodeClass) SupportedVerbs() []admissionregistrationv1.OperationType {
@@ -50,40 +76,95 @@ func (in *EC2NodeClass) SupportedVerbs() []admissionregistrationv1.OperationType
 }
 
 func (in *EC2NodeClass) Validate(ctx context.Context) (errs *apis.FieldError) {
+	var original *EC2NodeClass
 	if apis.IsInUpdate(ctx) {
-		original := apis.GetBaseline(ctx).(*EC2NodeClass)
+		original = apis.GetBaseline(ctx).(*EC2NodeClass)
 		errs = in.validateImmutableFields(original)
 	}
-	return errs.Also(
+	errs = errs.Also(
 		apis.ValidateObjectMetadata(in).ViaField("metadata"),
+		in.validateDriftIgnoredFields().ViaField("metadata.annotations"),
+		in.validateBottlerocketUpdateStrategy().ViaField("metadata.annotations"),
 		in.Spec.validate(ctx).ViaField("spec"),
 	)
+	// selector terms, amis and instance profiles are only resolved once the EC2NodeClass is otherwise valid, to avoid calling AWS with them
+	if errs.Filter(apis.ErrorLevel) != nil {
+		return errs
+	}
+	return errs.Also(
+		in.validateSelectorsResolve(ctx, original),
+		in.validateAMICompatibility(ctx, original),
+		in.validateInstanceProfileResolves(ctx, original),
+		in.validatePodIMDSAccess(ctx, original),
+	)
+}
+
+func (in *EC2NodeClass) validateBottlerocketUpdateStrategy() (errs *apis.FieldError) {
+	strategy, ok := in.Annotations[AnnotationBottlerocketUpdateStrategy]
+	if !ok {
+		return nil
+	}
+	if strategy != BottlerocketUpdateStrategyReplace && strategy != BottlerocketUpdateStrategyInPlace {
+		return apis.ErrInvalidValue(fmt.Sprintf("%s not in %s, %s", strategy, BottlerocketUpdateStrategyReplace, BottlerocketUpdateStrategyInPlace), AnnotationBottlerocketUpdateStrategy)
+	}
+	if strategy == BottlerocketUpdateStrategyInPlace && lo.FromPtr(in.Spec.AMIFamily) != AMIFamilyBottlerocket {
+		return apis.ErrInvalidValue(fmt.Sprintf("%s requires the %s amiFamily", strategy, AMIFamilyBottlerocket), AnnotationBottlerocketUpdateStrategy)
+	}
+	return nil
+}
+
+func (in *EC2NodeClass) validateDriftIgnoredFields() (errs *apis.FieldError) {
+	for _, field := range in.DriftIgnoredFields() {
+		if !lo.Contains(DriftFields(), field) {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%q isn't a spec field that is hashed for drift", field), AnnotationDriftIgnoredFields))
+		}
+	}
+	return errs
 }
 
 func (in *EC2NodeClass) validateImmutableFields(original *EC2NodeClass) (errs *apis.FieldError) {
 	return errs.Also(
 		in.Spec.validateRoleImmutability(&original.Spec).ViaField("spec"),
+		in.Spec.validateAssumeRoleImmutability(&original.Spec).ViaField("spec"),
 	)
 }
 
-func (in *EC2NodeClassSpec) validate(_ context.Context) (errs *apis.FieldError) {
-	if in.Role != "" && in.InstanceProfile != nil {
-		errs = errs.Also(apis.ErrMultipleOneOf(rolePath, instanceProfilePath))
-	}
-	if in.Role == "" && in.InstanceProfile == nil {
-		errs = errs.Also(apis.ErrMissingOneOf(rolePath, instanceProfilePath))
+func (in *EC2NodeClassSpec) validate(ctx context.Context) (errs *apis.FieldError) {
+	if n := lo.Count([]bool{in.Role != "", in.InstanceProfile != nil, in.InstanceProfileSelectorTerms != nil}, true); n > 1 {
+		errs = errs.Also(apis.ErrMultipleOneOf(rolePath, instanceProfilePath, instanceProfileSelectorTermsPath))
+	} else if n == 0 {
+		errs = errs.Also(apis.ErrMissingOneOf(rolePath, instanceProfilePath, instanceProfileSelectorTermsPath))
 	}
 	return errs.Also(
 		in.validateSubnetSelectorTerms().ViaField(subnetSelectorTermsPath),
+		in.validateInstanceProfileSelectorTerms().ViaField(instanceProfileSelectorTermsPath),
 		in.validateSecurityGroupSelectorTerms().ViaField(securityGroupSelectorTermsPath),
 		in.validateAMISelectorTerms().ViaField(amiSelectorTermsPath),
 		in.validateMetadataOptions().ViaField(metadataOptionsPath),
 		in.validateAMIFamily().ViaField(amiFamilyPath),
 		in.validateBlockDeviceMappings().ViaField(blockDeviceMappingsPath),
-		in.validateTags().ViaField(tagsPath),
+		in.validateTags(ctx).ViaField(tagsPath),
+		in.validateAssumeRole(),
+		in.validateUserData().ViaField(userDataPath),
 	)
 }
 
+func (in *EC2NodeClassSpec) validateAssumeRole() (errs *apis.FieldError) {
+	if in.AssumeRoleARN == nil {
+		if in.AssumeRoleExternalID != nil {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("%s requires %s", assumeRoleExternalIDPath, assumeRoleARNPath), assumeRoleExternalIDPath))
+		}
+		return errs
+	}
+	if a, err := arn.Parse(*in.AssumeRoleARN); err != nil || a.Service != "iam" || !strings.HasPrefix(a.Resource, "role/") {
+		errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s isn't the ARN of an IAM role", *in.AssumeRoleARN), assumeRoleARNPath))
+	}
+	if in.AssumeRoleExternalID != nil && *in.AssumeRoleExternalID == "" {
+		errs = errs.Also(apis.ErrInvalidValue("external id cannot be empty", assumeRoleExternalIDPath))
+	}
+	return errs
+}
+
 func (in *EC2NodeClassSpec) validateSubnetSelectorTerms() (errs *apis.FieldError) {
 	if len(in.SubnetSelectorTerms) == 0 {
 		errs = errs.Also(apis.ErrMissingOneOf())
@@ -127,6 +208,19 @@ func (in *SecurityGroupSelectorTerm) validate() (errs *apis.FieldError) {
 	return errs
 }
 
+func (in *EC2NodeClassSpec) validateInstanceProfileSelectorTerms() (errs *apis.FieldError) {
+	if in.InstanceProfileSelectorTerms != nil && len(in.InstanceProfileSelectorTerms) == 0 {
+		errs = errs.Also(apis.ErrMissingField(apis.CurrentField))
+	}
+	for i, term := range in.InstanceProfileSelectorTerms {
+		errs = errs.Also(validateTags(term.Tags).ViaField("tags").ViaIndex(i))
+		if len(term.Tags) == 0 {
+			errs = errs.Also(apis.ErrMissingField("tags").ViaIndex(i))
+		}
+	}
+	return errs
+}
+
 func (in *EC2NodeClassSpec) validateAMISelectorTerms() (errs *apis.FieldError) {
 	for _, term := range in.AMISelectorTerms {
 		errs = errs.Also(term.validate())
@@ -173,14 +267,14 @@ func (in *EC2NodeClassSpec) validateHTTPEndpoint() *apis.FieldError {
 	if in.MetadataOptions.HTTPEndpoint == nil {
 		return nil
 	}
-	return in.validateStringEnum(*in.MetadataOptions.HTTPEndpoint, "httpEndpoint", ec2.LaunchTemplateInstanceMetadataEndpointState_Values())
+	return validateStringEnum(*in.MetadataOptions.HTTPEndpoint, "httpEndpoint", ec2types.LaunchTemplateInstanceMetadataEndpointState("").Values())
 }
 
 func (in *EC2NodeClassSpec) validateHTTPProtocolIpv6() *apis.FieldError {
 	if in.MetadataOptions.HTTPProtocolIPv6 == nil {
 		return nil
 	}
-	return in.validateStringEnum(*in.MetadataOptions.HTTPProtocolIPv6, "httpProtocolIPv6", ec2.LaunchTemplateInstanceMetadataProtocolIpv6_Values())
+	return validateStringEnum(*in.MetadataOptions.HTTPProtocolIPv6, "httpProtocolIPv6", ec2types.LaunchTemplateInstanceMetadataProtocolIpv6("").Values())
 }
 
 func (in *EC2NodeClassSpec) validateHTTPPutResponseHopLimit() *apis.FieldError {
@@ -198,16 +292,16 @@ func (in *EC2NodeClassSpec) validateHTTPTokens() *apis.FieldError {
 	if in.MetadataOptions.HTTPTokens == nil {
 		return nil
 	}
-	return in.validateStringEnum(*in.MetadataOptions.HTTPTokens, "httpTokens", ec2.LaunchTemplateHttpTokensState_Values())
+	return validateStringEnum(*in.MetadataOptions.HTTPTokens, "httpTokens", ec2types.LaunchTemplateHttpTokensState("").Values())
 }
 
-func (in *EC2NodeClassSpec) validateStringEnum(value, field string, validValues []string) *apis.FieldError {
+func validateStringEnum[T ~string](value, field string, validValues []T) *apis.FieldError {
 	for _, validValue := range validValues {
-		if value == validValue {
+		if value == string(validValue) {
 			return nil
 		}
 	}
-	return apis.ErrInvalidValue(fmt.Sprintf("%s not in %v", value, strings.Join(validValues, ", ")), field)
+	return apis.ErrInvalidValue(fmt.Sprintf("%s not in %v", value, strings.Join(lo.Map(validValues, func(v T, _ int) string { return string(v) }), ", ")), field)
 }
 
 func (in *EC2NodeClassSpec) validateBlockDeviceMappings() (errs *apis.FieldError) {
@@ -223,6 +317,33 @@ func (in *EC2NodeClassSpec) validateBlockDeviceMappings() (errs *apis.FieldError
 	if numRootVolume > 1 {
 		errs = errs.Also(apis.ErrMultipleOneOf("more than 1 root volume configured"))
 	}
+	return errs.Also(in.validateDeviceNamesForAMIFamily())
+}
+
+// validateDeviceNamesForAMIFamily warns on device names that don't match the volume layout of the AMIs of the amiFamily,
+// such as the root device of another amiFamily, which attaches an additional volume rather than configuring the root
+// volume. These are only warnings since AMIs selected with amiSelectorTerms can have a different layout.
+func (in *EC2NodeClassSpec) validateDeviceNamesForAMIFamily() (errs *apis.FieldError) {
+	amiFamily := lo.Ternary(in.AMIFamily == nil, AMIFamilyAL2, lo.FromPtr(in.AMIFamily))
+	rootDeviceName, ok := rootDeviceNames[amiFamily]
+	if !ok {
+		return nil
+	}
+	ephemeralDeviceName := ephemeralDeviceNames[amiFamily]
+	for i, blockDeviceMapping := range in.BlockDeviceMappings {
+		deviceName := lo.FromPtr(blockDeviceMapping.DeviceName)
+		if deviceName == "" {
+			continue
+		}
+		if blockDeviceMapping.RootVolume && deviceName != ephemeralDeviceName {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s AMIs keep ephemeral storage on %s, rootVolume is expected on it rather than %s", amiFamily, ephemeralDeviceName, deviceName), "rootVolume").
+				ViaIndex(i).At(apis.WarningLevel))
+		}
+		if deviceName != rootDeviceName && deviceName != ephemeralDeviceName && lo.Contains(lo.Values(rootDeviceNames), deviceName) {
+			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf("%s isn't the root device of %s AMIs, which is %s", deviceName, amiFamily, rootDeviceName), "deviceName").
+				ViaIndex(i).At(apis.WarningLevel))
+		}
+	}
 	return errs
 }
 
@@ -254,7 +375,7 @@ func (in *EC2NodeClassSpec) validateEBS(blockDeviceMapping *BlockDeviceMapping)
 
 func (in *EC2NodeClassSpec) validateVolumeType(blockDeviceMapping *BlockDeviceMapping) *apis.FieldError {
 	if blockDeviceMapping.EBS.VolumeType != nil {
-		return in.validateStringEnum(*blockDeviceMapping.EBS.VolumeType, "volumeType", ec2.VolumeType_Values())
+		return validateStringEnum(*blockDeviceMapping.EBS.VolumeType, "volumeType", ec2types.VolumeType("").Values())
 	}
 	return nil
 }
@@ -281,7 +402,7 @@ func (in *EC2NodeClassSpec) validateAMIFamily() (errs *apis.FieldError) {
 	return errs
 }
 
-func (in *EC2NodeClassSpec) validateTags() (errs *apis.FieldError) {
+func (in *EC2NodeClassSpec) validateTags(ctx context.Context) (errs *apis.FieldError) {
 	for k, v := range in.Tags {
 		if k == "" {
 			errs = errs.Also(apis.ErrInvalidValue(fmt.Sprintf(
@@ -293,6 +414,21 @@ func (in *EC2NodeClassSpec) validateTags() (errs *apis.FieldError) {
 			}
 		}
 	}
+	if policy := TagPolicyFromContext(ctx); policy != nil {
+		for _, err := range policy.Violations(in.Tags) {
+			errs = errs.Also(apis.ErrGeneric(err, "tags"))
+		}
+	}
+	return errs
+}
+
+func (in *EC2NodeClassSpec) validateAssumeRoleImmutability(originalSpec *EC2NodeClassSpec) (errs *apis.FieldError) {
+	if lo.FromPtr(in.AssumeRoleARN) != lo.FromPtr(originalSpec.AssumeRoleARN) {
+		errs = errs.Also(&apis.FieldError{Message: "Immutable field changed", Paths: []string{assumeRoleARNPath}})
+	}
+	if lo.FromPtr(in.AssumeRoleExternalID) != lo.FromPtr(originalSpec.AssumeRoleExternalID) {
+		errs = errs.Also(&apis.FieldError{Message: "Immutable field changed", Paths: []string{assumeRoleExternalIDPath}})
+	}
 	return errs
 }
 
@@ -15,7 +15,7 @@ limitations under the License.
 package v1beta1_test
 
 import (
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/resource"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -52,6 +52,25 @@ var _ = Describe("CEL/Validation", func() {
 		nc.Spec.Role = ""
 		Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
 	})
+	It("should succeed if just specifying instance profile selector terms", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "platform"}}}
+		nc.Spec.Role = ""
+		Expect(env.Client.Create(ctx, nc)).To(Succeed())
+	})
+	It("should fail if specifying both instance profile selector terms and role", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "platform"}}}
+		Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+	})
+	It("should fail if specifying empty instance profile selector terms", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{}
+		nc.Spec.Role = ""
+		Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+	})
+	It("should fail if an instance profile selector term has no tags", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{}}}
+		nc.Spec.Role = ""
+		Expect(env.Client.Create(ctx, nc)).ToNot(Succeed())
+	})
 	Context("UserData", func() {
 		It("should succeed if user data is empty", func() {
 			Expect(env.Client.Create(ctx, nc)).To(Succeed())
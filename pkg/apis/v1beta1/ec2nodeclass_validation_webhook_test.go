@@ -15,11 +15,16 @@ limitations under the License.
 package v1beta1_test
 
 import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"knative.dev/pkg/apis"
 
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go-v2/aws"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
@@ -50,10 +55,182 @@ var _ = Describe("Webhook/Validation", func() {
 		nc.Spec.Role = ""
 		Expect(nc.Validate(ctx)).ToNot(Succeed())
 	})
+	It("should succeed if just specifying instance profile selector terms", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "platform"}}}
+		nc.Spec.Role = ""
+		Expect(nc.Validate(ctx)).To(Succeed())
+	})
+	It("should fail if specifying both instance profile selector terms and role", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "platform"}}}
+		Expect(nc.Validate(ctx)).ToNot(Succeed())
+	})
+	It("should fail if specifying empty instance profile selector terms", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{}
+		nc.Spec.Role = ""
+		Expect(nc.Validate(ctx)).ToNot(Succeed())
+	})
+	It("should fail if an instance profile selector term has no tags", func() {
+		nc.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{}}}
+		nc.Spec.Role = ""
+		Expect(nc.Validate(ctx)).ToNot(Succeed())
+	})
+	Context("Drift Ignored Fields", func() {
+		It("should succeed if the ignored fields are hashed for drift", func() {
+			nc.Annotations = map[string]string{v1beta1.AnnotationDriftIgnoredFields: "userData, detailedMonitoring"}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail if an ignored field isn't a spec field", func() {
+			nc.Annotations = map[string]string{v1beta1.AnnotationDriftIgnoredFields: "userData,unknown"}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail if an ignored field isn't hashed for drift", func() {
+			nc.Annotations = map[string]string{v1beta1.AnnotationDriftIgnoredFields: "tags"}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("Bottlerocket Update Strategy", func() {
+		It("should succeed if Bottlerocket nodes are updated in place", func() {
+			nc.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+			nc.Annotations = map[string]string{v1beta1.AnnotationBottlerocketUpdateStrategy: v1beta1.BottlerocketUpdateStrategyInPlace}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should succeed if nodes are replaced", func() {
+			nc.Annotations = map[string]string{v1beta1.AnnotationBottlerocketUpdateStrategy: v1beta1.BottlerocketUpdateStrategyReplace}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail if the strategy is unknown", func() {
+			nc.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+			nc.Annotations = map[string]string{v1beta1.AnnotationBottlerocketUpdateStrategy: "Reboot"}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail if nodes that don't run Bottlerocket are updated in place", func() {
+			nc.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
+			nc.Annotations = map[string]string{v1beta1.AnnotationBottlerocketUpdateStrategy: v1beta1.BottlerocketUpdateStrategyInPlace}
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("AssumeRole", func() {
+		It("should succeed if assuming a role with an external id", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/KarpenterNodes")
+			nc.Spec.AssumeRoleExternalID = lo.ToPtr("test-external-id")
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail if the role arn isn't an iam role", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:user/KarpenterNodes")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail if the role arn is malformed", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("KarpenterNodes")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail if specifying an external id without a role arn", func() {
+			nc.Spec.AssumeRoleExternalID = lo.ToPtr("test-external-id")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when updating the role arn", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/KarpenterNodes")
+			Expect(nc.Validate(ctx)).To(Succeed())
+
+			updateCtx := apis.WithinUpdate(ctx, nc.DeepCopy())
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::210987654321:role/KarpenterNodes")
+			Expect(nc.Validate(updateCtx)).ToNot(Succeed())
+		})
+		It("should fail when removing the role arn", func() {
+			nc.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::123456789012:role/KarpenterNodes")
+			Expect(nc.Validate(ctx)).To(Succeed())
+
+			updateCtx := apis.WithinUpdate(ctx, nc.DeepCopy())
+			nc.Spec.AssumeRoleARN = nil
+			Expect(nc.Validate(updateCtx)).ToNot(Succeed())
+		})
+	})
 	Context("UserData", func() {
 		It("should succeed if user data is empty", func() {
 			Expect(nc.Validate(ctx)).To(Succeed())
 		})
+		It("should fail if user data exceeds the EC2 limit", func() {
+			nc.Spec.UserData = lo.ToPtr("#!/bin/bash\n" + strings.Repeat("a", 16*1024))
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should succeed for a shell script on AL2", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2)
+			nc.Spec.UserData = lo.ToPtr("#!/bin/bash\necho 'hello'")
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should warn for a shell script without an interpreter directive on AL2", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2)
+			nc.Spec.UserData = lo.ToPtr("echo 'hello'")
+			errs := nc.Validate(ctx)
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should succeed for a MIME multi-part archive on AL2", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2)
+			nc.Spec.UserData = lo.ToPtr(`MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Type: text/x-shellscript; charset="us-ascii"
+
+#!/bin/bash
+echo "hello"
+
+--BOUNDARY--
+`)
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail for a MIME archive without a boundary on AL2", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2)
+			nc.Spec.UserData = lo.ToPtr("MIME-Version: 1.0\nContent-Type: multipart/mixed\n\n#!/bin/bash\necho 'hello'")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail for a MIME archive that isn't multi-part on AL2023", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2023)
+			nc.Spec.UserData = lo.ToPtr("MIME-Version: 1.0\nContent-Type: text/x-shellscript\n\n#!/bin/bash\necho 'hello'")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should succeed for a NodeConfig on AL2023", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2023)
+			nc.Spec.UserData = lo.ToPtr(`apiVersion: node.eks.aws/v1alpha1
+kind: NodeConfig
+spec:
+  kubelet:
+    config:
+      maxPods: 42
+`)
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail for YAML that isn't a NodeConfig on AL2023", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2023)
+			nc.Spec.UserData = lo.ToPtr("apiVersion: v1\nkind: ConfigMap")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should succeed for TOML on Bottlerocket", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyBottlerocket)
+			nc.Spec.UserData = lo.ToPtr("[settings.kubernetes]\nmax-pods = 42")
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail for invalid TOML on Bottlerocket", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyBottlerocket)
+			nc.Spec.UserData = lo.ToPtr("[settings.kubernetes\nmax-pods = 42")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should succeed for PowerShell on Windows", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyWindows2022)
+			nc.Spec.UserData = lo.ToPtr("Write-Host 'hello'")
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should fail for PowerShell that's already wrapped in powershell tags on Windows", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyWindows2019)
+			nc.Spec.UserData = lo.ToPtr("<powershell>\nWrite-Host 'hello'\n</powershell>")
+			Expect(nc.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should not validate the structure of user data on Custom", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyCustom)
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-12345"}}
+			nc.Spec.UserData = lo.ToPtr("[settings.kubernetes")
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
 	})
 	Context("Tags", func() {
 		It("should succeed when tags are empty", func() {
@@ -104,6 +281,36 @@ var _ = Describe("Webhook/Validation", func() {
 			}
 			Expect(nc.Validate(ctx)).To(Not(Succeed()))
 		})
+		Context("Tag Policy", func() {
+			var policyCtx context.Context
+			BeforeEach(func() {
+				policyCtx = v1beta1.WithTagPolicy(ctx, &v1beta1.TagPolicy{
+					RestrictedKeys:   []*regexp.Regexp{regexp.MustCompile(`^acme:internal/`)},
+					RequiredPrefixes: []string{"acme:"},
+					MaxTags:          2,
+				})
+			})
+			It("should succeed if tags follow the tag policy", func() {
+				nc.Spec.Tags = map[string]string{"acme:team": "compute", "acme:cost-center": "1234"}
+				Expect(nc.Validate(policyCtx)).To(Succeed())
+			})
+			It("should fail if tags match a restricted key of the tag policy", func() {
+				nc.Spec.Tags = map[string]string{"acme:internal/owner": "compute"}
+				Expect(nc.Validate(policyCtx)).To(Not(Succeed()))
+			})
+			It("should fail if tags don't have a required prefix of the tag policy", func() {
+				nc.Spec.Tags = map[string]string{"team": "compute"}
+				Expect(nc.Validate(policyCtx)).To(Not(Succeed()))
+			})
+			It("should fail if there are more tags than the tag policy allows", func() {
+				nc.Spec.Tags = map[string]string{"acme:a": "1", "acme:b": "2", "acme:c": "3"}
+				Expect(nc.Validate(policyCtx)).To(Not(Succeed()))
+			})
+			It("should not enforce the tag policy without a tag policy in the context", func() {
+				nc.Spec.Tags = map[string]string{"team": "compute"}
+				Expect(nc.Validate(ctx)).To(Succeed())
+			})
+		})
 	})
 	Context("SubnetSelectorTerms", func() {
 		It("should succeed with a valid subnet selector on tags", func() {
@@ -491,6 +698,48 @@ var _ = Describe("Webhook/Validation", func() {
 			})
 			Expect(nodeClass.Validate(ctx)).To(Not(Succeed()))
 		})
+		It("should succeed with the device names of the amiFamily", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyBottlerocket)
+			nc.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{DeviceName: aws.String("/dev/xvda"), EBS: &v1beta1.BlockDevice{VolumeSize: resource.NewScaledQuantity(4, resource.Giga)}},
+				{DeviceName: aws.String("/dev/xvdb"), EBS: &v1beta1.BlockDevice{VolumeSize: resource.NewScaledQuantity(50, resource.Giga)}, RootVolume: true},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should warn if the root device of another amiFamily is used", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyAL2)
+			nc.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{DeviceName: aws.String("/dev/sda1"), EBS: &v1beta1.BlockDevice{VolumeSize: resource.NewScaledQuantity(50, resource.Giga)}},
+			}
+			errs := nc.Validate(ctx)
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should warn if rootVolume is set on the OS volume of Bottlerocket", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyBottlerocket)
+			nc.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{DeviceName: aws.String("/dev/xvda"), EBS: &v1beta1.BlockDevice{VolumeSize: resource.NewScaledQuantity(50, resource.Giga)}, RootVolume: true},
+			}
+			errs := nc.Validate(ctx)
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should succeed with additional data volumes", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyUbuntu)
+			nc.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{DeviceName: aws.String("/dev/sda1"), EBS: &v1beta1.BlockDevice{VolumeSize: resource.NewScaledQuantity(50, resource.Giga)}, RootVolume: true},
+				{DeviceName: aws.String("/dev/sdf"), EBS: &v1beta1.BlockDevice{VolumeSize: resource.NewScaledQuantity(100, resource.Giga)}},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
+		It("should not validate device names for the Custom amiFamily", func() {
+			nc.Spec.AMIFamily = lo.ToPtr(v1beta1.AMIFamilyCustom)
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-12345"}}
+			nc.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{
+				{DeviceName: aws.String("/dev/sda1"), EBS: &v1beta1.BlockDevice{VolumeSize: resource.NewScaledQuantity(50, resource.Giga)}, RootVolume: true},
+			}
+			Expect(nc.Validate(ctx)).To(Succeed())
+		})
 	})
 	Context("Role Immutability", func() {
 		It("should fail when updating the role", func() {
@@ -522,4 +771,236 @@ var _ = Describe("Webhook/Validation", func() {
 			Expect(nc.Validate(updateCtx)).ToNot(Succeed())
 		})
 	})
+	Context("Selector Resolution", func() {
+		var resolver *fakeSelectorResolver
+		BeforeEach(func() {
+			resolver = &fakeSelectorResolver{subnets: 1, securityGroups: 1, amis: 1}
+		})
+		It("should succeed if the selector terms match resources", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-123"}}
+			Expect(nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, false))).To(Succeed())
+		})
+		It("should fail if the subnet selector terms don't match any subnets", func() {
+			resolver.subnets = 0
+			Expect(nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, false))).ToNot(Succeed())
+		})
+		It("should fail if the security group selector terms don't match any security groups", func() {
+			resolver.securityGroups = 0
+			Expect(nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, false))).ToNot(Succeed())
+		})
+		It("should fail if the ami selector terms don't match any amis", func() {
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-123"}}
+			resolver.amis = 0
+			Expect(nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, false))).ToNot(Succeed())
+		})
+		It("should not resolve amis if there are no ami selector terms", func() {
+			resolver.amis = 0
+			Expect(nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, false))).To(Succeed())
+		})
+		It("should warn instead of failing if the selector terms don't match resources in warn mode", func() {
+			resolver.subnets = 0
+			errs := nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, true))
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should warn instead of failing if the selector terms can't be resolved", func() {
+			resolver.err = fmt.Errorf("throttled")
+			errs := nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, false))
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should only resolve selector terms that changed on update", func() {
+			updateCtx := apis.WithinUpdate(v1beta1.WithSelectorResolver(ctx, resolver, false), nc.DeepCopy())
+			resolver.subnets = 0
+			nc.Spec.Tags = map[string]string{"team": "compute"}
+			Expect(nc.Validate(updateCtx)).To(Succeed())
+			nc.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "typo"}}}
+			Expect(nc.Validate(updateCtx)).ToNot(Succeed())
+		})
+		It("should not resolve selector terms of invalid EC2NodeClasses", func() {
+			nc.Spec.Role = ""
+			Expect(nc.Validate(v1beta1.WithSelectorResolver(ctx, resolver, false))).ToNot(Succeed())
+			Expect(resolver.calls).To(BeZero())
+		})
+	})
+	Context("AMI Compatibility", func() {
+		var resolver *fakeAMICompatibilityResolver
+		BeforeEach(func() {
+			resolver = &fakeAMICompatibilityResolver{incompatible: map[string]string{}}
+		})
+		It("should succeed if the amis are compatible with the nodepools", func() {
+			Expect(nc.Validate(v1beta1.WithAMICompatibilityResolver(ctx, resolver, false))).To(Succeed())
+		})
+		It("should fail if the amis aren't compatible with a nodepool", func() {
+			resolver.incompatible["default"] = "the nodepool requires kubernetes.io/arch In [amd64]"
+			Expect(nc.Validate(v1beta1.WithAMICompatibilityResolver(ctx, resolver, false))).ToNot(Succeed())
+		})
+		It("should warn instead of failing if the amis aren't compatible with a nodepool in warn mode", func() {
+			resolver.incompatible["default"] = "the nodepool requires kubernetes.io/arch In [amd64]"
+			errs := nc.Validate(v1beta1.WithAMICompatibilityResolver(ctx, resolver, true))
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should warn instead of failing if the amis can't be resolved", func() {
+			resolver.err = fmt.Errorf("throttled")
+			errs := nc.Validate(v1beta1.WithAMICompatibilityResolver(ctx, resolver, false))
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should only check the amis on update if the ami family or ami selector terms changed", func() {
+			updateCtx := apis.WithinUpdate(v1beta1.WithAMICompatibilityResolver(ctx, resolver, false), nc.DeepCopy())
+			resolver.incompatible["default"] = "the nodepool requires kubernetes.io/arch In [amd64]"
+			nc.Spec.Tags = map[string]string{"team": "compute"}
+			Expect(nc.Validate(updateCtx)).To(Succeed())
+			nc.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-123"}}
+			Expect(nc.Validate(updateCtx)).ToNot(Succeed())
+		})
+	})
+	Context("Instance Profile Resolution", func() {
+		var resolver *fakeInstanceProfileResolver
+		BeforeEach(func() {
+			resolver = &fakeInstanceProfileResolver{}
+		})
+		It("should succeed if the role exists", func() {
+			Expect(nc.Validate(v1beta1.WithInstanceProfileResolver(ctx, resolver, false))).To(Succeed())
+			Expect(resolver.calls).To(Equal(1))
+		})
+		It("should fail if the role doesn't exist", func() {
+			resolver.message = `role "test-role" doesn't exist`
+			Expect(nc.Validate(v1beta1.WithInstanceProfileResolver(ctx, resolver, false))).ToNot(Succeed())
+		})
+		It("should fail if the instance profile has no role", func() {
+			nc.Spec.Role = ""
+			nc.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
+			resolver.message = `instance profile "test-instance-profile" has no role`
+			err := nc.Validate(v1beta1.WithInstanceProfileResolver(ctx, resolver, false))
+			Expect(err).ToNot(Succeed())
+			Expect(err.Error()).To(ContainSubstring("spec.instanceProfile"))
+		})
+		It("should warn instead of failing if the instance profile can't be used in warn mode", func() {
+			resolver.message = `role "test-role" doesn't exist`
+			errs := nc.Validate(v1beta1.WithInstanceProfileResolver(ctx, resolver, true))
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should warn instead of failing if the instance profile can't be resolved", func() {
+			resolver.err = fmt.Errorf("throttled")
+			errs := nc.Validate(v1beta1.WithInstanceProfileResolver(ctx, resolver, false))
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should only resolve the instance profile on update if it changed", func() {
+			nc.Spec.Role = ""
+			nc.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
+			updateCtx := apis.WithinUpdate(v1beta1.WithInstanceProfileResolver(ctx, resolver, false), nc.DeepCopy())
+			resolver.message = `instance profile "test-instance-profile-2" doesn't exist`
+			nc.Spec.Tags = map[string]string{"team": "compute"}
+			Expect(nc.Validate(updateCtx)).To(Succeed())
+			Expect(resolver.calls).To(BeZero())
+			nc.Spec.InstanceProfile = lo.ToPtr("test-instance-profile-2")
+			Expect(nc.Validate(updateCtx)).ToNot(Succeed())
+		})
+	})
+	Context("Pod IMDS Access", func() {
+		var resolver *fakeNodeCredentialsResolver
+		BeforeEach(func() {
+			resolver = &fakeNodeCredentialsResolver{usesNodeRole: true}
+			nc.Spec.MetadataOptions = &v1beta1.MetadataOptions{
+				HTTPEndpoint:            lo.ToPtr("enabled"),
+				HTTPPutResponseHopLimit: lo.ToPtr[int64](1),
+				HTTPTokens:              lo.ToPtr("required"),
+			}
+		})
+		It("should warn if the hop limit keeps pods from reaching IMDS", func() {
+			errs := nc.Validate(v1beta1.WithNodeCredentialsResolver(ctx, resolver))
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+			Expect(errs.Error()).To(ContainSubstring("spec.metadataOptions"))
+		})
+		It("should warn without a resolver", func() {
+			errs := nc.Validate(ctx)
+			Expect(errs.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(errs.Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should warn if the workloads of the cluster can't be resolved", func() {
+			resolver.err = fmt.Errorf("listing pods")
+			Expect(nc.Validate(v1beta1.WithNodeCredentialsResolver(ctx, resolver)).Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+		It("should not warn if the cluster doesn't rely on node role credentials", func() {
+			resolver.usesNodeRole = false
+			Expect(nc.Validate(v1beta1.WithNodeCredentialsResolver(ctx, resolver))).To(Succeed())
+		})
+		It("should not warn if tokens are optional", func() {
+			nc.Spec.MetadataOptions.HTTPTokens = lo.ToPtr("optional")
+			Expect(nc.Validate(v1beta1.WithNodeCredentialsResolver(ctx, resolver))).To(Succeed())
+		})
+		It("should not warn if the hop limit is greater than 1", func() {
+			nc.Spec.MetadataOptions.HTTPPutResponseHopLimit = lo.ToPtr[int64](2)
+			Expect(nc.Validate(v1beta1.WithNodeCredentialsResolver(ctx, resolver))).To(Succeed())
+		})
+		It("should not warn if the endpoint is disabled", func() {
+			nc.Spec.MetadataOptions.HTTPEndpoint = lo.ToPtr("disabled")
+			Expect(nc.Validate(v1beta1.WithNodeCredentialsResolver(ctx, resolver))).To(Succeed())
+		})
+		It("should only warn on update if the metadata options started blocking pods", func() {
+			updateCtx := apis.WithinUpdate(v1beta1.WithNodeCredentialsResolver(ctx, resolver), nc.DeepCopy())
+			nc.Spec.Tags = map[string]string{"team": "compute"}
+			Expect(nc.Validate(updateCtx)).To(Succeed())
+
+			original := nc.DeepCopy()
+			original.Spec.MetadataOptions.HTTPPutResponseHopLimit = lo.ToPtr[int64](2)
+			updateCtx = apis.WithinUpdate(v1beta1.WithNodeCredentialsResolver(ctx, resolver), original)
+			Expect(nc.Validate(updateCtx).Filter(apis.WarningLevel)).ToNot(BeNil())
+		})
+	})
 })
+
+type fakeNodeCredentialsResolver struct {
+	usesNodeRole bool
+	err          error
+}
+
+func (r *fakeNodeCredentialsResolver) UsesNodeRoleCredentials(context.Context) (bool, error) {
+	return r.usesNodeRole, r.err
+}
+
+type fakeInstanceProfileResolver struct {
+	message string
+	err     error
+	calls   int
+}
+
+func (r *fakeInstanceProfileResolver) InstanceProfile(context.Context, *v1beta1.EC2NodeClass) (string, error) {
+	r.calls++
+	return r.message, r.err
+}
+
+type fakeAMICompatibilityResolver struct {
+	incompatible map[string]string
+	err          error
+}
+
+func (r *fakeAMICompatibilityResolver) IncompatibleNodePools(context.Context, *v1beta1.EC2NodeClass) (map[string]string, error) {
+	return r.incompatible, r.err
+}
+
+type fakeSelectorResolver struct {
+	subnets, securityGroups, amis int
+	err                           error
+	calls                         int
+}
+
+func (r *fakeSelectorResolver) Subnets(context.Context, *v1beta1.EC2NodeClass) (int, error) {
+	r.calls++
+	return r.subnets, r.err
+}
+
+func (r *fakeSelectorResolver) SecurityGroups(context.Context, *v1beta1.EC2NodeClass) (int, error) {
+	r.calls++
+	return r.securityGroups, r.err
+}
+
+func (r *fakeSelectorResolver) AMIs(context.Context, *v1beta1.EC2NodeClass) (int, error) {
+	r.calls++
+	return r.amis, r.err
+}
@@ -0,0 +1,74 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"knative.dev/pkg/apis"
+)
+
+type instanceProfileResolverKey struct{}
+
+// InstanceProfileResolver resolves the role or instance profile of EC2NodeClasses. EC2NodeClasses are validated against
+// it when they're admitted, so that roles and instance profiles which don't exist, or instance profiles without a role,
+// fail early rather than when instances are launched.
+type InstanceProfileResolver interface {
+	// InstanceProfile returns a message that describes why the role or instance profile of the EC2NodeClass can't be
+	// used to launch instances, or an empty message if it can
+	InstanceProfile(context.Context, *EC2NodeClass) (string, error)
+}
+
+// +k8s:deepcopy-gen=false
+type instanceProfileValidation struct {
+	resolver InstanceProfileResolver
+	level    apis.DiagnosticLevel
+}
+
+// WithInstanceProfileResolver returns a context that the role or instance profile of EC2NodeClasses is resolved in when
+// they're validated. Roles and instance profiles that can't be used are errors, or warnings if warn is set.
+func WithInstanceProfileResolver(ctx context.Context, resolver InstanceProfileResolver, warn bool) context.Context {
+	return context.WithValue(ctx, instanceProfileResolverKey{}, instanceProfileValidation{resolver: resolver, level: diagnosticLevel(warn)})
+}
+
+// validateInstanceProfileResolves resolves the role or instance profile when it was set or changed. Ones that can't be
+// resolved are only warned on, so that EC2NodeClasses can still be admitted while AWS APIs are unavailable.
+func (in *EC2NodeClass) validateInstanceProfileResolves(ctx context.Context, original *EC2NodeClass) *apis.FieldError {
+	validation, ok := ctx.Value(instanceProfileResolverKey{}).(instanceProfileValidation)
+	if !ok {
+		return nil
+	}
+	if original != nil && original.Spec.Role == in.Spec.Role &&
+		equality.Semantic.DeepEqual(original.Spec.InstanceProfile, in.Spec.InstanceProfile) &&
+		equality.Semantic.DeepEqual(original.Spec.InstanceProfileSelectorTerms, in.Spec.InstanceProfileSelectorTerms) {
+		return nil
+	}
+	path := rolePath
+	if in.Spec.InstanceProfile != nil {
+		path = instanceProfilePath
+	} else if in.Spec.InstanceProfileSelectorTerms != nil {
+		path = instanceProfileSelectorTermsPath
+	}
+	message, err := validation.resolver.InstanceProfile(ctx, in)
+	if err != nil {
+		return apis.ErrGeneric(fmt.Sprintf("couldn't resolve %s, %s", path, err), path).At(apis.WarningLevel).ViaField("spec")
+	}
+	if message != "" {
+		return apis.ErrGeneric(message, path).At(validation.level).ViaField("spec")
+	}
+	return nil
+}
@@ -45,6 +45,7 @@ func init() {
 		LabelInstanceAcceleratorName,
 		LabelInstanceAcceleratorManufacturer,
 		LabelInstanceAcceleratorCount,
+		LabelTopologyZoneID,
 		v1.LabelWindowsBuild,
 	)
 }
@@ -94,8 +95,25 @@ var (
 	ResourcePrivateIPv4Address v1.ResourceName = "vpc.amazonaws.com/PrivateIPv4Address"
 	ResourceEFA                v1.ResourceName = "vpc.amazonaws.com/efa"
 
+	// WarmPoolNoScheduleTaint is set on warm NodeClaims so that pods aren't scheduled to them until they are claimed
+	WarmPoolNoScheduleTaint = v1.Taint{
+		Key:    LabelWarmPool,
+		Effect: v1.TaintEffectNoSchedule,
+	}
+
 	LabelNodeClass = Group + "/ec2nodeclass"
 
+	// LabelTopologyZoneID is the ID of the availability zone of a node, which identifies the same physical zone across
+	// accounts, unlike the zone name
+	LabelTopologyZoneID = "topology.k8s.aws/zone-id"
+
+	// LabelCapacityType is set on nodes to the capacity type that their instance is billed as, which is
+	// CapacityTypeReserved for instances that were launched into a capacity reservation and the karpenter.sh/capacity-type
+	// of the node otherwise. LabelCapacityReservationID is set to the ID of that capacity reservation.
+	LabelCapacityType          = Group + "/capacity-type"
+	LabelCapacityReservationID = Group + "/capacity-reservation-id"
+	CapacityTypeReserved       = "reserved"
+
 	LabelInstanceHypervisor                   = Group + "/instance-hypervisor"
 	LabelInstanceEncryptionInTransitSupported = Group + "/instance-encryption-in-transit-supported"
 	LabelInstanceCategory                     = Group + "/instance-category"
@@ -116,8 +134,94 @@ var (
 	LabelInstanceAcceleratorCount             = Group + "/instance-accelerator-count"
 	AnnotationEC2NodeClassHash                = Group + "/ec2nodeclass-hash"
 	AnnotationEC2NodeClassHashVersion         = Group + "/ec2nodeclass-hash-version"
+	AnnotationEC2NodeClassFieldHashes         = Group + "/ec2nodeclass-field-hashes"
 	AnnotationInstanceTagged                  = Group + "/tagged"
+	AnnotationSpotPrice                       = Group + "/spot-price"
+	AnnotationRebalanceRecommendationHandling = Group + "/rebalance-recommendation-handling"
+	AnnotationRebalanceRecommended            = Group + "/rebalance-recommended"
+	AnnotationScheduledMaintenance            = Group + "/scheduled-maintenance"
+	AnnotationInstanceStatusImpaired          = Group + "/instance-status-impaired"
+	// AnnotationReplace is set on a NodeClaim or node by operators to replace it through drift
+	AnnotationReplace = Group + "/replace"
+	// AnnotationMaxConcurrentLaunches is set on an EC2NodeClass to limit the number of instance launches for it that are
+	// in flight at the same time
+	AnnotationMaxConcurrentLaunches = Group + "/max-concurrent-launches"
+	// AnnotationDriftIgnoredFields is set on an EC2NodeClass to a comma-separated list of spec fields whose changes don't
+	// drift its NodeClaims, and AnnotationEC2NodeClassHashDriftIgnoredFields records the fields that were ignored when
+	// the EC2NodeClass was last hashed
+	AnnotationDriftIgnoredFields                 = Group + "/drift-ignored-fields"
+	AnnotationEC2NodeClassHashDriftIgnoredFields = Group + "/ec2nodeclass-hash-drift-ignored-fields"
+	// AnnotationBottlerocketUpdateStrategy is set on an EC2NodeClass with the Bottlerocket AMI family to control whether
+	// nodes are replaced or updated in place by the Bottlerocket update operator when a new release is selected
+	AnnotationBottlerocketUpdateStrategy = Group + "/bottlerocket-update-strategy"
+	// LabelBottlerocketUpdaterInterfaceVersion is set on nodes that are updated by the Bottlerocket update operator
+	LabelBottlerocketUpdaterInterfaceVersion = "bottlerocket.aws/updater-interface-version"
+
+	// AnnotationWarmPoolSize, AnnotationWarmPoolInstanceTypes and AnnotationWarmPoolTTL are set on a NodePool to keep a
+	// pool of launched and initialized nodes that pending pods can claim instead of waiting for a new node to launch
+	AnnotationWarmPoolSize          = Group + "/warm-pool-size"
+	AnnotationWarmPoolInstanceTypes = Group + "/warm-pool-instance-types"
+	AnnotationWarmPoolTTL           = Group + "/warm-pool-ttl"
+	// LabelWarmPool is set on NodeClaims and nodes that are part of a warm pool and haven't been claimed yet
+	LabelWarmPool = Group + "/warm-pool"
+	// AnnotationMinNodes is set on a NodePool to keep a minimum number of nodes for it, regardless of pending pods
+	AnnotationMinNodes = Group + "/min-nodes"
+	// AnnotationBaseline is set on the NodeClaims and nodes that are kept to satisfy the minimum number of nodes of their
+	// NodePool, which are excluded from disruption. It's "true" when Karpenter set the do-not-disrupt annotation, and
+	// BaselinePreexistingDoNotDisrupt when the annotation was already set, in which case it's kept when the NodeClaim or
+	// node leaves the baseline.
+	AnnotationBaseline = Group + "/baseline"
+	// BaselinePreexistingDoNotDisrupt is the value of AnnotationBaseline when the do-not-disrupt annotation wasn't set by Karpenter
+	BaselinePreexistingDoNotDisrupt = "preexisting-do-not-disrupt"
+	// AnnotationAdoptNodePool is set on a node that wasn't launched by Karpenter to create a NodeClaim in the NodePool for
+	// its instance, and AnnotationAdoptInstanceID is set on that NodeClaim so that the instance is adopted instead of
+	// launching a new one
+	AnnotationAdoptNodePool   = Group + "/adopt-nodepool"
+	AnnotationAdoptInstanceID = Group + "/adopt-instance-id"
+	// AnnotationReboot is set on a NodeClaim or node to reboot its instance, and AnnotationRebootedAt is set once the
+	// instance was rebooted
+	AnnotationReboot     = Group + "/reboot"
+	AnnotationRebootedAt = Group + "/rebooted-at"
+	// AnnotationInstanceRunningAt is set on a NodeClaim when its instance is first seen running, and
+	// AnnotationLaunchLatencyRecorded is set once the durations of its launch phases were recorded
+	AnnotationInstanceRunningAt     = Group + "/instance-running-at"
+	AnnotationLaunchLatencyRecorded = Group + "/launch-latency-recorded"
+	// AnnotationLaunchTemplateName, AnnotationLaunchTemplateVersion, AnnotationCreateFleetRequestID,
+	// AnnotationNetworkInterfaceIDs and AnnotationCapacityReservationID are set on a NodeClaim to record how its instance
+	// was launched, so that the NodeClaim can be correlated with CloudTrail events and VPC flow logs
+	AnnotationLaunchTemplateName    = Group + "/launch-template-name"
+	AnnotationLaunchTemplateVersion = Group + "/launch-template-version"
+	AnnotationCreateFleetRequestID  = Group + "/create-fleet-request-id"
+	AnnotationNetworkInterfaceIDs   = Group + "/network-interface-ids"
+	AnnotationCapacityReservationID = Group + "/capacity-reservation-id"
+
+	// RebalanceRecommendationHandling values are set on a NodePool through AnnotationRebalanceRecommendationHandling
+	// to control how EC2 rebalance recommendations are handled for its NodeClaims
+	RebalanceRecommendationHandlingIgnore  = "Ignore"
+	RebalanceRecommendationHandlingCordon  = "Cordon"
+	RebalanceRecommendationHandlingReplace = "Replace"
+
+	// BottlerocketUpdateStrategy values are set on an EC2NodeClass through AnnotationBottlerocketUpdateStrategy
+	BottlerocketUpdateStrategyReplace = "Replace"
+	BottlerocketUpdateStrategyInPlace = "InPlace"
+	// BottlerocketUpdaterInterfaceVersion is the version of the Bottlerocket update operator's interface that nodes opt
+	// in to through LabelBottlerocketUpdaterInterfaceVersion
+	BottlerocketUpdaterInterfaceVersion = "2.0.0"
 
 	TagNodeClaim = v1beta1.Group + "/nodeclaim"
 	TagName      = "Name"
+	// TagEKSClusterName is the tag that EKS uses to associate resources with the cluster
+	TagEKSClusterName = "eks:cluster-name"
+	// TagLaunchTemplateVersion is the tag that EC2 sets on instances to the version of the launch template that they were
+	// launched from, after "$Latest" or "$Default" were resolved
+	TagLaunchTemplateVersion = "aws:ec2launchtemplate:version"
+	// TagStoppedAt is set on instances that were stopped instead of terminated so that they can be reused
+	TagStoppedAt = Group + "/stopped-at"
+	// TagLaunchedBy is set to the name of the cluster on the volumes and network interfaces that are created when an
+	// instance is launched, which tells them apart from the volumes and network interfaces that are attached later, like the
+	// volumes of PersistentVolumes and the network interfaces of the VPC CNI
+	TagLaunchedBy = Group + "/launched-by"
+	// TagInstanceID is set on the volumes and network interfaces that were created at launch and are deleted on
+	// termination to the ID of their instance, so that they're only garbage collected once that instance is gone
+	TagInstanceID = Group + "/instance-id"
 )
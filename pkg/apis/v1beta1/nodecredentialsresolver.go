@@ -0,0 +1,86 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"knative.dev/pkg/apis"
+)
+
+const (
+	// IMDSReasonDisabled is the reason that pods can't reach IMDS when its endpoint is disabled
+	IMDSReasonDisabled = "IMDSDisabled"
+	// IMDSReasonHopLimitBlocksPods is the reason that pods can't reach IMDS when tokens are required with a hop limit of
+	// 1, since the responses to token requests from pods outside of the host network are dropped
+	IMDSReasonHopLimitBlocksPods = "HopLimitBlocksPods"
+)
+
+type nodeCredentialsResolverKey struct{}
+
+// NodeCredentialsResolver resolves whether the workloads of the cluster rely on the credentials of the node role.
+// EC2NodeClasses whose metadata options keep pods from reaching IMDS are only warned on when they do.
+type NodeCredentialsResolver interface {
+	// UsesNodeRoleCredentials returns true if pods appear to get their AWS credentials from the node role, rather than
+	// from IAM roles for service accounts or EKS Pod Identity
+	UsesNodeRoleCredentials(context.Context) (bool, error)
+}
+
+// WithNodeCredentialsResolver returns a context that EC2NodeClasses whose metadata options keep pods from reaching IMDS
+// are validated in
+func WithNodeCredentialsResolver(ctx context.Context, resolver NodeCredentialsResolver) context.Context {
+	return context.WithValue(ctx, nodeCredentialsResolverKey{}, resolver)
+}
+
+// PodIMDSAccess returns the reason and a message for why pods outside of the host network can't reach IMDS on the
+// instances that are launched with the metadata options, or an empty reason if they can. Unset options take the
+// defaults that Karpenter launches instances with.
+func (in *MetadataOptions) PodIMDSAccess() (reason string, message string) {
+	if in == nil {
+		return "", ""
+	}
+	if lo.FromPtrOr(in.HTTPEndpoint, "enabled") == "disabled" {
+		return IMDSReasonDisabled, "httpEndpoint is disabled, IMDS isn't reachable from the node or its pods"
+	}
+	if lo.FromPtrOr(in.HTTPTokens, "required") == "required" && lo.FromPtrOr(in.HTTPPutResponseHopLimit, 2) == 1 {
+		return IMDSReasonHopLimitBlocksPods, "httpTokens is required with an httpPutResponseHopLimit of 1, pods outside of the host network can't get IMDS tokens or node role credentials"
+	}
+	return "", ""
+}
+
+// validatePodIMDSAccess warns when the metadata options are changed so that pods can't get the credentials of the node
+// role from IMDS, since workloads that rely on them fail once they're scheduled to the new nodes. Disabling IMDS is
+// an explicit choice, so only the hop limit is warned on.
+func (in *EC2NodeClass) validatePodIMDSAccess(ctx context.Context, original *EC2NodeClass) *apis.FieldError {
+	reason, message := in.Spec.MetadataOptions.PodIMDSAccess()
+	if reason != IMDSReasonHopLimitBlocksPods {
+		return nil
+	}
+	if original != nil {
+		if originalReason, _ := original.Spec.MetadataOptions.PodIMDSAccess(); originalReason == reason {
+			return nil
+		}
+	}
+	// Without a resolver, or when the workloads of the cluster can't be resolved, the warning is surfaced anyway
+	if resolver, ok := ctx.Value(nodeCredentialsResolverKey{}).(NodeCredentialsResolver); ok {
+		if uses, err := resolver.UsesNodeRoleCredentials(ctx); err == nil && !uses {
+			return nil
+		}
+	}
+	return apis.ErrGeneric(fmt.Sprintf("%s, use IAM roles for service accounts or EKS Pod Identity for workloads that need AWS credentials", message), "metadataOptions").
+		At(apis.WarningLevel).ViaField("spec")
+}
@@ -0,0 +1,89 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"knative.dev/pkg/apis"
+)
+
+type selectorResolverKey struct{}
+
+// SelectorResolver resolves the selector terms of EC2NodeClasses to the resources that they match. EC2NodeClasses are
+// validated against it when they're admitted, so that selector terms which don't match anything, such as tags with a
+// typo, fail early rather than when nothing is provisioned.
+type SelectorResolver interface {
+	// Subnets returns the number of subnets that the subnetSelectorTerms of the EC2NodeClass match
+	Subnets(context.Context, *EC2NodeClass) (int, error)
+	// SecurityGroups returns the number of security groups that the securityGroupSelectorTerms of the EC2NodeClass match
+	SecurityGroups(context.Context, *EC2NodeClass) (int, error)
+	// AMIs returns the number of AMIs that the amiSelectorTerms of the EC2NodeClass match
+	AMIs(context.Context, *EC2NodeClass) (int, error)
+}
+
+// +k8s:deepcopy-gen=false
+type selectorValidation struct {
+	resolver SelectorResolver
+	level    apis.DiagnosticLevel
+}
+
+// WithSelectorResolver returns a context that the selector terms of EC2NodeClasses are resolved in when they're
+// validated. Selector terms that don't match anything are errors, or warnings if warn is set.
+func WithSelectorResolver(ctx context.Context, resolver SelectorResolver, warn bool) context.Context {
+	return context.WithValue(ctx, selectorResolverKey{}, selectorValidation{resolver: resolver, level: diagnosticLevel(warn)})
+}
+
+// diagnosticLevel returns the level that failures of an optional admission validation are reported at
+func diagnosticLevel(warn bool) apis.DiagnosticLevel {
+	if warn {
+		return apis.WarningLevel
+	}
+	return apis.ErrorLevel
+}
+
+// validateSelectorsResolve resolves the selector terms that were set or changed against the resolver of the context.
+// Selector terms that can't be resolved are only warned on, so that EC2NodeClasses can still be admitted while AWS APIs
+// are unavailable.
+func (in *EC2NodeClass) validateSelectorsResolve(ctx context.Context, original *EC2NodeClass) (errs *apis.FieldError) {
+	validation, ok := ctx.Value(selectorResolverKey{}).(selectorValidation)
+	if !ok {
+		return nil
+	}
+	resolve := func(kind, path string, changed bool, count func(context.Context, *EC2NodeClass) (int, error)) *apis.FieldError {
+		if !changed {
+			return nil
+		}
+		n, err := count(ctx, in)
+		if err != nil {
+			return apis.ErrGeneric(fmt.Sprintf("couldn't resolve %s, %s", kind, err), path).At(apis.WarningLevel)
+		}
+		if n == 0 {
+			return apis.ErrGeneric(fmt.Sprintf("didn't match any %s", kind), path).At(validation.level)
+		}
+		return nil
+	}
+	if original == nil {
+		original = &EC2NodeClass{}
+	}
+	// amis are only resolved when they're selected, the default AMIs of the amiFamily always exist
+	return errs.Also(
+		resolve("subnets", subnetSelectorTermsPath, !equality.Semantic.DeepEqual(in.Spec.SubnetSelectorTerms, original.Spec.SubnetSelectorTerms), validation.resolver.Subnets),
+		resolve("security groups", securityGroupSelectorTermsPath, !equality.Semantic.DeepEqual(in.Spec.SecurityGroupSelectorTerms, original.Spec.SecurityGroupSelectorTerms), validation.resolver.SecurityGroups),
+		resolve("amis", amiSelectorTermsPath, len(in.Spec.AMISelectorTerms) > 0 && !equality.Semantic.DeepEqual(in.Spec.AMISelectorTerms, original.Spec.AMISelectorTerms), validation.resolver.AMIs),
+	).ViaField("spec")
+}
@@ -0,0 +1,72 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type tagPolicyKey struct{}
+
+// TagPolicy is an organizational policy for the tags of EC2NodeClasses. It's enforced when EC2NodeClasses are admitted
+// and before instances are launched, so that tags which would be rejected by service control policies fail early
+// rather than when the fleet is created.
+// +k8s:deepcopy-gen=false
+type TagPolicy struct {
+	// RestrictedKeys are patterns of tag keys that aren't allowed, in addition to RestrictedTagPatterns
+	RestrictedKeys []*regexp.Regexp
+	// RequiredPrefixes are prefixes that each tag key must have one of, if any are set
+	RequiredPrefixes []string
+	// MaxTags is the maximum number of tags, if it's greater than 0
+	MaxTags int
+}
+
+// Violations returns a description of each way that the tags violate the policy
+func (p *TagPolicy) Violations(tags map[string]string) []string {
+	var violations []string
+	keys := lo.Keys(tags)
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, pattern := range p.RestrictedKeys {
+			if pattern.MatchString(k) {
+				violations = append(violations, fmt.Sprintf("tag %q matches restricted tag pattern %q", k, pattern.String()))
+			}
+		}
+		if len(p.RequiredPrefixes) > 0 && !lo.ContainsBy(p.RequiredPrefixes, func(prefix string) bool { return strings.HasPrefix(k, prefix) }) {
+			violations = append(violations, fmt.Sprintf("tag %q doesn't have one of the required prefixes %v", k, p.RequiredPrefixes))
+		}
+	}
+	if p.MaxTags > 0 && len(tags) > p.MaxTags {
+		violations = append(violations, fmt.Sprintf("%d tags exceed the maximum of %d tags", len(tags), p.MaxTags))
+	}
+	return violations
+}
+
+// WithTagPolicy returns a context that EC2NodeClasses are validated against the tag policy in
+func WithTagPolicy(ctx context.Context, policy *TagPolicy) context.Context {
+	return context.WithValue(ctx, tagPolicyKey{}, policy)
+}
+
+// TagPolicyFromContext returns the tag policy of the context, or nil if there is none
+func TagPolicyFromContext(ctx context.Context) *TagPolicy {
+	policy, _ := ctx.Value(tagPolicyKey{}).(*TagPolicy)
+	return policy
+}
@@ -0,0 +1,41 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sdk
+
+import (
+	"context"
+	"strings"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+)
+
+// serviceNames are the names of the services whose endpoint IDs differ from their service IDs, so that metrics and
+// rate limits keep the service names that they had before the controller moved to v2 of the SDK
+var serviceNames = map[string]string{
+	"cloudwatchlogs":   "logs",
+	"computeoptimizer": "compute-optimizer",
+	"eventbridge":      "events",
+	"pricing":          "api.pricing",
+}
+
+// ServiceName returns the name of the service that the request in the context is sent to, e.g. ec2 or iam, from the
+// service metadata that the SDK's middleware stores in the context
+func ServiceName(ctx context.Context) string {
+	name := strings.ToLower(strings.ReplaceAll(awsmiddleware.GetServiceID(ctx), " ", ""))
+	if n, ok := serviceNames[name]; ok {
+		return n
+	}
+	return name
+}
@@ -27,6 +27,8 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"sigs.k8s.io/karpenter/pkg/metrics"
+
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
 )
 
 // Options allows for configuration of the Batcher
@@ -186,7 +188,9 @@ func (b *Batcher[T, U]) runCalls(requests []*request[T, U]) {
 	// Measure the size of the request batch
 	batchSize.With(prometheus.Labels{batcherNameLabel: b.options.Name}).Observe(float64(len(requests)))
 	requestIdx := 0
-	for _, result := range b.options.BatchExecutor(requests[0].ctx, lo.Map(requests, func(req *request[T, U], _ int) *T { return req.input })) {
+	// The batched call is made with the context of the first request, which is audited as made for every request
+	ctx := audit.Merge(requests[0].ctx, lo.Map(requests[1:], func(req *request[T, U], _ int) context.Context { return req.ctx })...)
+	for _, result := range b.options.BatchExecutor(ctx, lo.Map(requests, func(req *request[T, U], _ int) *T { return req.input })) {
 		requests[requestIdx].requestor <- result
 		requestIdx++
 	}
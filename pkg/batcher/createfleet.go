@@ -16,96 +16,177 @@ package batcher
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/client-go/util/workqueue"
 	"knative.dev/pkg/logging"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
 
 type CreateFleetBatcher struct {
 	batcher *Batcher[ec2.CreateFleetInput, ec2.CreateFleetOutput]
 }
 
-func NewCreateFleetBatcher(ctx context.Context, ec2api ec2iface.EC2API) *CreateFleetBatcher {
+func NewCreateFleetBatcher(ctx context.Context, ec2api sdk.EC2API) *CreateFleetBatcher {
 	options := Options[ec2.CreateFleetInput, ec2.CreateFleetOutput]{
 		Name:          "create_fleet",
 		IdleTimeout:   35 * time.Millisecond,
 		MaxTimeout:    1 * time.Second,
 		MaxItems:      1_000,
-		RequestHasher: DefaultHasher[ec2.CreateFleetInput],
+		RequestHasher: createFleetHasher,
 		BatchExecutor: execCreateFleetBatch(ec2api),
 	}
 	return &CreateFleetBatcher{batcher: NewBatcher(ctx, options)}
 }
 
+// createFleetHasher hashes the inputs without their launch template configs, so that the inputs of NodeClaims that
+// launch with the same options are batched together and split by their launch template configs
+func createFleetHasher(ctx context.Context, input *ec2.CreateFleetInput) uint64 {
+	withoutConfigs := *input
+	withoutConfigs.LaunchTemplateConfigs = nil
+	return DefaultHasher(ctx, &withoutConfigs)
+}
+
 func (b *CreateFleetBatcher) CreateFleet(ctx context.Context, createFleetInput *ec2.CreateFleetInput) (*ec2.CreateFleetOutput, error) {
-	if createFleetInput.TargetCapacitySpecification != nil && *createFleetInput.TargetCapacitySpecification.TotalTargetCapacity != 1 {
-		return nil, fmt.Errorf("expected to receive a single instance only, found %d", *createFleetInput.TargetCapacitySpecification.TotalTargetCapacity)
+	if createFleetInput.TargetCapacitySpecification != nil && aws.ToInt32(createFleetInput.TargetCapacitySpecification.TotalTargetCapacity) != 1 {
+		return nil, fmt.Errorf("expected to receive a single instance only, found %d", aws.ToInt32(createFleetInput.TargetCapacitySpecification.TotalTargetCapacity))
 	}
 	result := b.batcher.Add(ctx, createFleetInput)
 	return result.Output, result.Err
 }
 
-func execCreateFleetBatch(ec2api ec2iface.EC2API) BatchExecutor[ec2.CreateFleetInput, ec2.CreateFleetOutput] {
+func execCreateFleetBatch(ec2api sdk.EC2API) BatchExecutor[ec2.CreateFleetInput, ec2.CreateFleetOutput] {
 	return func(ctx context.Context, inputs []*ec2.CreateFleetInput) []Result[ec2.CreateFleetOutput] {
-		results := make([]Result[ec2.CreateFleetOutput], 0, len(inputs))
-		firstInput := inputs[0]
-		firstInput.TargetCapacitySpecification.TotalTargetCapacity = aws.Int64(int64(len(inputs)))
-		output, err := ec2api.CreateFleetWithContext(ctx, firstInput)
-		if err != nil {
-			for range inputs {
-				results = append(results, Result[ec2.CreateFleetOutput]{Err: err})
+		results := make([]Result[ec2.CreateFleetOutput], len(inputs))
+		groups := groupCreateFleetInputs(inputs)
+		workqueue.ParallelizeUntil(ctx, len(groups), len(groups), func(i int) {
+			groupResults := execCreateFleet(ctx, ec2api, groups[i].input, len(groups[i].indices))
+			for j, idx := range groups[i].indices {
+				results[idx] = groupResults[j]
 			}
-			return results
+		})
+		return results
+	}
+}
+
+// createFleetGroup is a set of inputs that are launched with a single CreateFleet call, since they have the same launch
+// template configs and overrides
+type createFleetGroup struct {
+	input   *ec2.CreateFleetInput
+	indices []int
+}
+
+// groupCreateFleetInputs groups the inputs whose launch template configs and overrides are equal, ignoring their order.
+// Inputs whose overrides only overlap aren't grouped, since launching them with the overrides that they share would
+// narrow the instance types that they can launch with, and could leave fewer instance types than their minValues
+// require.
+func groupCreateFleetInputs(inputs []*ec2.CreateFleetInput) []*createFleetGroup {
+	var groups []*createFleetGroup
+	groupsByKey := map[string]*createFleetGroup{}
+	for i, input := range inputs {
+		k := launchTemplateConfigsKey(input.LaunchTemplateConfigs)
+		if group, ok := groupsByKey[k]; ok {
+			group.indices = append(group.indices, i)
+			continue
+		}
+		groupInput := *input
+		groupsByKey[k] = &createFleetGroup{input: &groupInput, indices: []int{i}}
+		groups = append(groups, groupsByKey[k])
+	}
+	return groups
+}
+
+// launchTemplateConfigsKey returns a string that identifies the launch template configs and their overrides regardless
+// of their order
+func launchTemplateConfigsKey(configs []ec2types.FleetLaunchTemplateConfigRequest) string {
+	keys := lo.Map(configs, func(c ec2types.FleetLaunchTemplateConfigRequest, _ int) string {
+		overrides := lo.Map(c.Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) string { return key(o) })
+		sort.Strings(overrides)
+		return launchTemplateKey(c.LaunchTemplateSpecification) + key(overrides)
+	})
+	sort.Strings(keys)
+	return key(keys)
+}
+
+func launchTemplateKey(spec *ec2types.FleetLaunchTemplateSpecificationRequest) string {
+	if spec == nil {
+		return ""
+	}
+	return key(spec)
+}
+
+// key returns a string that identifies the value of an SDK struct, since the SDK's types don't implement String()
+func key(v any) string {
+	return string(lo.Must(json.Marshal(v)))
+}
+
+func execCreateFleet(ctx context.Context, ec2api sdk.EC2API, input *ec2.CreateFleetInput, count int) []Result[ec2.CreateFleetOutput] {
+	results := make([]Result[ec2.CreateFleetOutput], 0, count)
+	if input.TargetCapacitySpecification != nil {
+		targetCapacitySpecification := *input.TargetCapacitySpecification
+		targetCapacitySpecification.TotalTargetCapacity = aws.Int32(int32(count))
+		input.TargetCapacitySpecification = &targetCapacitySpecification
+	}
+	output, err := ec2api.CreateFleet(ctx, input)
+	if err != nil {
+		for i := 0; i < count; i++ {
+			results = append(results, Result[ec2.CreateFleetOutput]{Err: err})
 		}
+		return results
+	}
 
-		// we can get partial fulfillment of a CreateFleet request, so we:
-		// 1) split out the single instance IDs and deliver to each requestor
-		// 2) deliver errors to any remaining requestors for which we don't have an instance
-		requestIdx := -1
-		for _, reservation := range output.Instances {
-			for _, instanceID := range reservation.InstanceIds {
-				requestIdx++
-				if requestIdx >= len(inputs) {
-					logging.FromContext(ctx).Errorf("received more instances than requested, ignoring instance %s", aws.StringValue(instanceID))
-					continue
-				}
-				results = append(results, Result[ec2.CreateFleetOutput]{
-					Output: &ec2.CreateFleetOutput{
-						FleetId: output.FleetId,
-						Errors:  output.Errors,
-						Instances: []*ec2.CreateFleetInstance{
-							{
-								InstanceIds:                []*string{instanceID},
-								InstanceType:               reservation.InstanceType,
-								LaunchTemplateAndOverrides: reservation.LaunchTemplateAndOverrides,
-								Lifecycle:                  reservation.Lifecycle,
-								Platform:                   reservation.Platform,
-							},
+	// we can get partial fulfillment of a CreateFleet request, so we:
+	// 1) split out the single instance IDs and deliver to each requestor
+	// 2) deliver errors to any remaining requestors for which we don't have an instance
+	requestIdx := -1
+	for _, reservation := range output.Instances {
+		for _, instanceID := range reservation.InstanceIds {
+			requestIdx++
+			if requestIdx >= count {
+				logging.FromContext(ctx).Errorf("received more instances than requested, ignoring instance %s", instanceID)
+				continue
+			}
+			results = append(results, Result[ec2.CreateFleetOutput]{
+				Output: &ec2.CreateFleetOutput{
+					FleetId:        output.FleetId,
+					Errors:         output.Errors,
+					ResultMetadata: output.ResultMetadata,
+					Instances: []ec2types.CreateFleetInstance{
+						{
+							InstanceIds:                []string{instanceID},
+							InstanceType:               reservation.InstanceType,
+							LaunchTemplateAndOverrides: reservation.LaunchTemplateAndOverrides,
+							Lifecycle:                  reservation.Lifecycle,
+							Platform:                   reservation.Platform,
 						},
 					},
-				})
-			}
+				},
+			})
 		}
+	}
 
-		if requestIdx != len(inputs) {
-			// we should receive some sort of error, but just in case
-			if len(output.Errors) == 0 {
-				output.Errors = append(output.Errors, &ec2.CreateFleetError{
-					ErrorCode:    aws.String("too few instances returned"),
-					ErrorMessage: aws.String("too few instances returned"),
-				})
-			}
-			for i := requestIdx + 1; i < len(inputs); i++ {
-				results = append(results, Result[ec2.CreateFleetOutput]{
-					Output: &ec2.CreateFleetOutput{
-						Errors: output.Errors,
-					}})
-			}
+	if requestIdx != count {
+		// we should receive some sort of error, but just in case
+		if len(output.Errors) == 0 {
+			output.Errors = append(output.Errors, ec2types.CreateFleetError{
+				ErrorCode:    aws.String("too few instances returned"),
+				ErrorMessage: aws.String("too few instances returned"),
+			})
+		}
+		for i := requestIdx + 1; i < count; i++ {
+			results = append(results, Result[ec2.CreateFleetOutput]{
+				Output: &ec2.CreateFleetOutput{
+					Errors: output.Errors,
+				}})
 		}
-		return results
 	}
+	return results
 }
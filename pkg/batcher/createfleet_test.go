@@ -19,8 +19,10 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/batcher"
 
@@ -38,20 +40,20 @@ var _ = Describe("CreateFleet Batching", func() {
 
 	It("should batch the same inputs into a single call", func() {
 		input := &ec2.CreateFleetInput{
-			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+			LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{
 				{
-					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 						LaunchTemplateName: aws.String("my-template"),
 					},
-					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+					Overrides: []ec2types.FleetLaunchTemplateOverridesRequest{
 						{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
 				},
 			},
-			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
-				TotalTargetCapacity: aws.Int64(1),
+			TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+				TotalTargetCapacity: aws.Int32(1),
 			},
 		}
 		var wg sync.WaitGroup
@@ -67,7 +69,7 @@ var _ = Describe("CreateFleet Batching", func() {
 				var instanceIds []string
 				for _, rsv := range rsp.Instances {
 					for _, id := range rsv.InstanceIds {
-						instanceIds = append(instanceIds, *id)
+						instanceIds = append(instanceIds, id)
 					}
 				}
 				atomic.AddInt64(&receivedInstance, 1)
@@ -83,37 +85,37 @@ var _ = Describe("CreateFleet Batching", func() {
 	})
 	It("should batch different inputs into multiple calls", func() {
 		east1input := &ec2.CreateFleetInput{
-			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+			LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{
 				{
-					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 						LaunchTemplateName: aws.String("my-template"),
 					},
-					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+					Overrides: []ec2types.FleetLaunchTemplateOverridesRequest{
 						{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
 				},
 			},
-			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
-				TotalTargetCapacity: aws.Int64(1),
+			TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+				TotalTargetCapacity: aws.Int32(1),
 			},
 		}
 		east2input := &ec2.CreateFleetInput{
-			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+			LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{
 				{
-					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 						LaunchTemplateName: aws.String("my-template"),
 					},
-					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+					Overrides: []ec2types.FleetLaunchTemplateOverridesRequest{
 						{
 							AvailabilityZone: aws.String("us-east-2"),
 						},
 					},
 				},
 			},
-			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
-				TotalTargetCapacity: aws.Int64(1),
+			TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+				TotalTargetCapacity: aws.Int32(1),
 			},
 		}
 		var wg sync.WaitGroup
@@ -134,7 +136,7 @@ var _ = Describe("CreateFleet Batching", func() {
 				var instanceIds []string
 				for _, rsv := range rsp.Instances {
 					for _, id := range rsv.InstanceIds {
-						instanceIds = append(instanceIds, *id)
+						instanceIds = append(instanceIds, id)
 					}
 				}
 				atomic.AddInt64(&receivedInstance, 1)
@@ -156,35 +158,113 @@ var _ = Describe("CreateFleet Batching", func() {
 		Expect(*east1Call.TargetCapacitySpecification.TotalTargetCapacity).To(BeNumerically("==", 4))
 		Expect(*east1Call.LaunchTemplateConfigs[0].Overrides[0].AvailabilityZone).To(Equal("us-east-1"))
 	})
+	Context("Overrides", func() {
+		inputWithOverrides := func(overrides ...ec2types.FleetLaunchTemplateOverridesRequest) *ec2.CreateFleetInput {
+			return &ec2.CreateFleetInput{
+				LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{
+					{
+						LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
+							LaunchTemplateName: aws.String("my-template"),
+						},
+						Overrides: overrides,
+					},
+				},
+				TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+					TotalTargetCapacity: aws.Int32(1),
+				},
+			}
+		}
+		zone := func(zone string) ec2types.FleetLaunchTemplateOverridesRequest {
+			return ec2types.FleetLaunchTemplateOverridesRequest{AvailabilityZone: aws.String(zone)}
+		}
+		instanceType := func(instanceType string) ec2types.FleetLaunchTemplateOverridesRequest {
+			return ec2types.FleetLaunchTemplateOverridesRequest{InstanceType: ec2types.InstanceType(instanceType)}
+		}
+		createFleets := func(inputs ...*ec2.CreateFleetInput) {
+			GinkgoHelper()
+			var wg sync.WaitGroup
+			for _, input := range inputs {
+				wg.Add(1)
+				go func(input *ec2.CreateFleetInput) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					rsp, err := cfb.CreateFleet(ctx, input)
+					Expect(err).To(BeNil())
+					Expect(rsp.Instances).To(HaveLen(1))
+					Expect(rsp.Instances[0].InstanceIds).To(HaveLen(1))
+				}(input)
+			}
+			wg.Wait()
+		}
+		It("should batch inputs with the same overrides in a different order into a single call", func() {
+			createFleets(
+				inputWithOverrides(zone("us-east-1a"), zone("us-east-1b")),
+				inputWithOverrides(zone("us-east-1b"), zone("us-east-1a")),
+			)
+			Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 1))
+			call := fakeEC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(*call.TargetCapacitySpecification.TotalTargetCapacity).To(BeNumerically("==", 2))
+			Expect(call.LaunchTemplateConfigs[0].Overrides).To(HaveLen(2))
+		})
+		It("should not batch inputs whose overrides only overlap", func() {
+			createFleets(
+				inputWithOverrides(zone("us-east-1a"), zone("us-east-1b")),
+				inputWithOverrides(zone("us-east-1b"), zone("us-east-1c")),
+				inputWithOverrides(zone("us-east-1b")),
+			)
+			Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 3))
+			fakeEC2API.CreateFleetBehavior.CalledWithInput.ForEach(func(call *ec2.CreateFleetInput) {
+				Expect(*call.TargetCapacitySpecification.TotalTargetCapacity).To(BeNumerically("==", 1))
+			})
+		})
+		It("should not narrow the instance types of inputs below the instance types that their minValues require", func() {
+			// each input has the two instance types that a minValues of 2 for instance types requires, which would be
+			// narrowed to one instance type if they were launched with the overrides that they share
+			first := inputWithOverrides(instanceType("m5.large"), instanceType("m5.xlarge"))
+			second := inputWithOverrides(instanceType("m5.xlarge"), instanceType("c5.large"))
+			createFleets(first, second)
+			Expect(fakeEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(BeNumerically("==", 2))
+			var overrides [][]ec2types.InstanceType
+			fakeEC2API.CreateFleetBehavior.CalledWithInput.ForEach(func(call *ec2.CreateFleetInput) {
+				overrides = append(overrides, lo.Map(call.LaunchTemplateConfigs[0].Overrides, func(o ec2types.FleetLaunchTemplateOverridesRequest, _ int) ec2types.InstanceType {
+					return o.InstanceType
+				}))
+			})
+			Expect(overrides).To(ConsistOf(
+				ConsistOf(ec2types.InstanceType("m5.large"), ec2types.InstanceType("m5.xlarge")),
+				ConsistOf(ec2types.InstanceType("m5.xlarge"), ec2types.InstanceType("c5.large")),
+			))
+		})
+	})
 	It("should return any errors to callers", func() {
 		input := &ec2.CreateFleetInput{
-			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+			LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{
 				{
-					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 						LaunchTemplateName: aws.String("my-template"),
 					},
-					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+					Overrides: []ec2types.FleetLaunchTemplateOverridesRequest{
 						{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
 				},
 			},
-			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
-				TotalTargetCapacity: aws.Int64(1),
+			TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+				TotalTargetCapacity: aws.Int32(1),
 			},
 		}
 
 		fakeEC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{
-			Errors: []*ec2.CreateFleetError{
+			Errors: []ec2types.CreateFleetError{
 				{
 					ErrorCode:    aws.String("some-error"),
 					ErrorMessage: aws.String("some-error"),
-					LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
-						LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecification{
+					LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+						LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecification{
 							LaunchTemplateName: aws.String("my-template"),
 						},
-						Overrides: &ec2.FleetLaunchTemplateOverrides{
+						Overrides: &ec2types.FleetLaunchTemplateOverrides{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
@@ -192,24 +272,21 @@ var _ = Describe("CreateFleet Batching", func() {
 				{
 					ErrorCode:    aws.String("some-other-error"),
 					ErrorMessage: aws.String("some-other-error"),
-					LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
-						LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecification{
+					LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+						LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecification{
 							LaunchTemplateName: aws.String("my-template"),
 						},
-						Overrides: &ec2.FleetLaunchTemplateOverrides{
+						Overrides: &ec2types.FleetLaunchTemplateOverrides{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
 				},
 			},
 			FleetId: aws.String("some-id"),
-			Instances: []*ec2.CreateFleetInstance{
+			Instances: []ec2types.CreateFleetInstance{
 				{
-					InstanceIds:                []*string{aws.String("id-1"), aws.String("id-2"), aws.String("id-3"), aws.String("id-4"), aws.String("id-5")},
-					InstanceType:               nil,
+					InstanceIds:                []string{"id-1", "id-2", "id-3", "id-4", "id-5"},
 					LaunchTemplateAndOverrides: nil,
-					Lifecycle:                  nil,
-					Platform:                   nil,
 				},
 			},
 		})
@@ -232,7 +309,7 @@ var _ = Describe("CreateFleet Batching", func() {
 				var instanceIds []string
 				for _, rsv := range rsp.Instances {
 					for _, id := range rsv.InstanceIds {
-						instanceIds = append(instanceIds, *id)
+						instanceIds = append(instanceIds, id)
 					}
 				}
 				atomic.AddInt64(&receivedInstance, 1)
@@ -251,33 +328,33 @@ var _ = Describe("CreateFleet Batching", func() {
 	})
 	It("should handle partial fulfillment", func() {
 		input := &ec2.CreateFleetInput{
-			LaunchTemplateConfigs: []*ec2.FleetLaunchTemplateConfigRequest{
+			LaunchTemplateConfigs: []ec2types.FleetLaunchTemplateConfigRequest{
 				{
-					LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 						LaunchTemplateName: aws.String("my-template"),
 					},
-					Overrides: []*ec2.FleetLaunchTemplateOverridesRequest{
+					Overrides: []ec2types.FleetLaunchTemplateOverridesRequest{
 						{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
 				},
 			},
-			TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
-				TotalTargetCapacity: aws.Int64(1),
+			TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+				TotalTargetCapacity: aws.Int32(1),
 			},
 		}
 
 		fakeEC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{
-			Errors: []*ec2.CreateFleetError{
+			Errors: []ec2types.CreateFleetError{
 				{
 					ErrorCode:    aws.String("some-error"),
 					ErrorMessage: aws.String("some-error"),
-					LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
-						LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecification{
+					LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+						LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecification{
 							LaunchTemplateName: aws.String("my-template"),
 						},
-						Overrides: &ec2.FleetLaunchTemplateOverrides{
+						Overrides: &ec2types.FleetLaunchTemplateOverrides{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
@@ -285,24 +362,21 @@ var _ = Describe("CreateFleet Batching", func() {
 				{
 					ErrorCode:    aws.String("some-other-error"),
 					ErrorMessage: aws.String("some-other-error"),
-					LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
-						LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecification{
+					LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+						LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecification{
 							LaunchTemplateName: aws.String("my-template"),
 						},
-						Overrides: &ec2.FleetLaunchTemplateOverrides{
+						Overrides: &ec2types.FleetLaunchTemplateOverrides{
 							AvailabilityZone: aws.String("us-east-1"),
 						},
 					},
 				},
 			},
 			FleetId: aws.String("some-id"),
-			Instances: []*ec2.CreateFleetInstance{
+			Instances: []ec2types.CreateFleetInstance{
 				{
-					InstanceIds:                []*string{aws.String("id-1"), aws.String("id-2"), aws.String("id-3")},
-					InstanceType:               nil,
+					InstanceIds:                []string{"id-1", "id-2", "id-3"},
 					LaunchTemplateAndOverrides: nil,
-					Lifecycle:                  nil,
-					Platform:                   nil,
 				},
 			},
 		})
@@ -325,7 +399,7 @@ var _ = Describe("CreateFleet Batching", func() {
 				var instanceIds []string
 				for _, rsv := range rsp.Instances {
 					for _, id := range rsv.InstanceIds {
-						instanceIds = append(instanceIds, *id)
+						instanceIds = append(instanceIds, id)
 					}
 				}
 				Expect(instanceIds).To(Or(HaveLen(0), HaveLen(1)))
@@ -31,6 +31,12 @@ const (
 	InstanceTypesAndZonesTTL = 5 * time.Minute
 	// InstanceProfileTTL is the time before we refresh checking instance profile existence at IAM
 	InstanceProfileTTL = 15 * time.Minute
+	// InstanceOwnerTTL is the time before we refresh the cluster that launched an instance which was involved in an
+	// interruption message on a shared interruption queue
+	InstanceOwnerTTL = 15 * time.Minute
+	// PreTerminationHookTTL is the time that the pre-termination hook calls of an instance are tracked after Delete was
+	// last called for the instance
+	PreTerminationHookTTL = time.Hour
 )
 
 const (
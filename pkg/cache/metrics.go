@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+// Names of the caches that metrics are recorded for
+const (
+	InstanceTypes   = "instancetypes"
+	Pricing         = "pricing"
+	AMIs            = "amis"
+	Subnets         = "subnets"
+	SecurityGroups  = "securitygroups"
+	LaunchTemplates = "launchtemplates"
+)
+
+const (
+	cacheSubsystem = "cache"
+	cacheLabel     = "cache"
+)
+
+var (
+	hits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cacheSubsystem,
+			Name:      "hits_total",
+			Help:      "Number of lookups that were served from the cache. Labeled by cache.",
+		},
+		[]string{cacheLabel},
+	)
+	misses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cacheSubsystem,
+			Name:      "misses_total",
+			Help:      "Number of lookups that weren't served from the cache, either because the item was never cached or because it expired. Labeled by cache.",
+		},
+		[]string{cacheLabel},
+	)
+	expirations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cacheSubsystem,
+			Name:      "expirations_total",
+			Help:      "Number of items that expired and were evicted from the cache. Labeled by cache.",
+		},
+		[]string{cacheLabel},
+	)
+	lastRefresh = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cacheSubsystem,
+			Name:      "last_refresh_timestamp_seconds",
+			Help:      "Time that the cache was last refreshed successfully from AWS, in seconds since the epoch. Labeled by cache.",
+		},
+		[]string{cacheLabel},
+	)
+	unavailableOfferingsCount = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cacheSubsystem,
+			Name:      "unavailable_offerings",
+			Help:      "Number of offerings in the unavailable offerings cache, which aren't launched until they expire after insufficient capacity errors.",
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(hits, misses, expirations, lastRefresh, unavailableOfferingsCount)
+}
+
+// RecordLookup records whether a lookup in the named cache was a hit or a miss
+func RecordLookup(name string, hit bool) {
+	if hit {
+		hits.WithLabelValues(name).Inc()
+	} else {
+		misses.WithLabelValues(name).Inc()
+	}
+}
+
+// RecordRefresh records that the named cache was refreshed successfully from AWS
+func RecordRefresh(name string) {
+	lastRefresh.WithLabelValues(name).Set(float64(time.Now().Unix()))
+}
+
+// RecordExpiration records that an item expired from the named cache. Caches that don't have an eviction handler of
+// their own record expirations with WithExpirationMetrics instead.
+func RecordExpiration(name string) {
+	expirations.WithLabelValues(name).Inc()
+}
+
+// WithExpirationMetrics records the items that are evicted from the named cache as expirations. Items are only
+// evicted when they expire, unless they are deleted explicitly.
+func WithExpirationMetrics(name string, c *cache.Cache) *cache.Cache {
+	c.OnEvicted(func(_ string, _ interface{}) { RecordExpiration(name) })
+	return c
+}
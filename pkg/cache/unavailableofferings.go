@@ -17,10 +17,12 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync/atomic"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/patrickmn/go-cache"
 	"knative.dev/pkg/logging"
 )
@@ -41,6 +43,7 @@ func NewUnavailableOfferings() *UnavailableOfferings {
 	}
 	uo.cache.OnEvicted(func(_ string, _ interface{}) {
 		atomic.AddUint64(&uo.SeqNum, 1)
+		uo.recordCount()
 	})
 	return uo
 }
@@ -62,20 +65,54 @@ func (u *UnavailableOfferings) MarkUnavailable(ctx context.Context, unavailableR
 		"ttl", UnavailableOfferingsTTL).Debugf("removing offering from offerings")
 	u.cache.SetDefault(u.key(instanceType, zone, capacityType), struct{}{})
 	atomic.AddUint64(&u.SeqNum, 1)
+	u.recordCount()
 }
 
-func (u *UnavailableOfferings) MarkUnavailableForFleetErr(ctx context.Context, fleetErr *ec2.CreateFleetError, capacityType string) {
-	instanceType := aws.StringValue(fleetErr.LaunchTemplateAndOverrides.Overrides.InstanceType)
-	zone := aws.StringValue(fleetErr.LaunchTemplateAndOverrides.Overrides.AvailabilityZone)
-	u.MarkUnavailable(ctx, aws.StringValue(fleetErr.ErrorCode), instanceType, zone, capacityType)
+func (u *UnavailableOfferings) MarkUnavailableForFleetErr(ctx context.Context, fleetErr ec2types.CreateFleetError, capacityType string) {
+	instanceType := string(fleetErr.LaunchTemplateAndOverrides.Overrides.InstanceType)
+	zone := aws.ToString(fleetErr.LaunchTemplateAndOverrides.Overrides.AvailabilityZone)
+	u.MarkUnavailable(ctx, aws.ToString(fleetErr.ErrorCode), instanceType, zone, capacityType)
 }
 
 func (u *UnavailableOfferings) Delete(instanceType string, zone string, capacityType string) {
 	u.cache.Delete(u.key(instanceType, zone, capacityType))
 }
 
+// UnavailableOffering is an offering in the cache and the time at which it's available again
+type UnavailableOffering struct {
+	InstanceType string    `json:"instanceType"`
+	Zone         string    `json:"zone"`
+	CapacityType string    `json:"capacityType"`
+	Expiration   time.Time `json:"expiration"`
+}
+
+// List returns the offerings that are unavailable
+func (u *UnavailableOfferings) List() []UnavailableOffering {
+	var offerings []UnavailableOffering
+	for key, item := range u.cache.Items() {
+		parts := strings.SplitN(key, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		offerings = append(offerings, UnavailableOffering{
+			CapacityType: parts[0],
+			InstanceType: parts[1],
+			Zone:         parts[2],
+			Expiration:   time.Unix(0, item.Expiration),
+		})
+	}
+	return offerings
+}
+
 func (u *UnavailableOfferings) Flush() {
 	u.cache.Flush()
+	u.recordCount()
+}
+
+// recordCount records the number of offerings in the cache. Offerings that expired are counted until they're evicted,
+// which happens within the cleanup interval.
+func (u *UnavailableOfferings) recordCount() {
+	unavailableOfferingsCount.Set(float64(u.cache.ItemCount()))
 }
 
 // key returns the cache key for all offerings in the cache
@@ -18,12 +18,15 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/aws/aws-sdk-go/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"go.uber.org/multierr"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -32,21 +35,26 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/tracing"
 
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
 	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	cloudproviderevents "github.com/aws/karpenter-provider-aws/pkg/cloudprovider/events"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 )
@@ -54,30 +62,32 @@ import (
 var _ cloudprovider.CloudProvider = (*CloudProvider)(nil)
 
 type CloudProvider struct {
-	instanceTypeProvider  *instancetype.Provider
-	instanceProvider      *instance.Provider
-	kubeClient            client.Client
-	amiProvider           *amifamily.Provider
-	securityGroupProvider *securitygroup.Provider
-	subnetProvider        *subnet.Provider
-	recorder              events.Recorder
+	instanceTypeProvider *instancetype.Provider
+	accountProvider      *account.Provider
+	kubeClient           client.Client
+	pricingProvider      *pricing.Provider
+	recorder             events.Recorder
 }
 
-func New(instanceTypeProvider *instancetype.Provider, instanceProvider *instance.Provider, recorder events.Recorder,
-	kubeClient client.Client, amiProvider *amifamily.Provider, securityGroupProvider *securitygroup.Provider, subnetProvider *subnet.Provider) *CloudProvider {
+func New(instanceTypeProvider *instancetype.Provider, accountProvider *account.Provider, recorder events.Recorder,
+	kubeClient client.Client, pricingProvider *pricing.Provider) *CloudProvider {
 	return &CloudProvider{
-		instanceTypeProvider:  instanceTypeProvider,
-		instanceProvider:      instanceProvider,
-		kubeClient:            kubeClient,
-		amiProvider:           amiProvider,
-		securityGroupProvider: securityGroupProvider,
-		subnetProvider:        subnetProvider,
-		recorder:              recorder,
+		instanceTypeProvider: instanceTypeProvider,
+		accountProvider:      accountProvider,
+		kubeClient:           kubeClient,
+		pricingProvider:      pricingProvider,
+		recorder:             recorder,
 	}
 }
 
 // Create a NodeClaim given the constraints.
-func (c *CloudProvider) Create(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (*corev1beta1.NodeClaim, error) {
+func (c *CloudProvider) Create(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (_ *corev1beta1.NodeClaim, err error) {
+	ctx, span := tracing.Start(ctx, "CloudProvider.Create", attribute.String("nodeclaim", nodeClaim.Name),
+		attribute.String("nodepool", nodeClaim.Labels[corev1beta1.NodePoolLabelKey]))
+	defer func() { tracing.End(span, err) }()
+	ctx = tracing.WithTraceID(ctx)
+	ctx = audit.WithNodeClaim(ctx, nodeClaim)
+
 	nodeClass, err := c.resolveNodeClassFromNodeClaim(ctx, nodeClaim)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -86,32 +96,82 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *corev1beta1.NodeC
 		// We treat a failure to resolve the NodeClass as an ICE since this means there is no capacity possibilities for this NodeClaim
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("resolving node class, %w", err))
 	}
-	instanceTypes, err := c.resolveInstanceTypes(ctx, nodeClaim, nodeClass)
+	// NodeClasses whose subnets, security groups, AMIs or instance profile couldn't be resolved can't launch instances,
+	// so launches are refused rather than failing part way through. NodeClasses that haven't been reconciled yet don't
+	// have a Ready condition, and aren't refused.
+	if ready := nodeClass.StatusConditions().GetCondition(apis.ConditionReady); ready.IsFalse() {
+		c.recorder.Publish(cloudproviderevents.NodeClaimNodeClassNotReady(nodeClaim, nodeClass.Name, ready.Message))
+		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("node class %q is not ready, %s", nodeClass.Name, ready.Message))
+	}
+	launchNodeClaim := resolveZoneIDRequirement(nodeClaim, nodeClass)
+	instanceTypes, err := c.resolveInstanceTypes(ctx, launchNodeClaim, nodeClass)
 	if err != nil {
 		return nil, fmt.Errorf("resolving instance types, %w", err)
 	}
 	if len(instanceTypes) == 0 {
 		return nil, cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all requested instance types were unavailable during launch"))
 	}
-	instance, err := c.instanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+	nodePool, err := c.resolveNodePoolFromNodeClaim(ctx, nodeClaim)
+	if err != nil {
+		return nil, fmt.Errorf("resolving nodepool, %w", err)
+	}
+	instance, err := c.accountProvider.For(nodeClass).Instance.Create(ctx, nodeClass, launchNodeClaim, nodePool, instanceTypes)
 	if err != nil {
+		if quota.IsExceededError(err) {
+			c.recorder.Publish(cloudproviderevents.NodeClaimFailedQuotaExceeded(nodeClaim, err))
+		}
+		c.publishFleetErrors(nodeClaim, err)
 		return nil, fmt.Errorf("creating instance, %w", err)
 	}
+	span.SetAttributes(attribute.String("instance.id", instance.ID), attribute.String("instance.type", instance.Type),
+		attribute.String("instance.zone", instance.Zone), attribute.String("instance.capacity_type", instance.CapacityType))
 	instanceType, _ := lo.Find(instanceTypes, func(i *cloudprovider.InstanceType) bool {
 		return i.Name == instance.Type
 	})
 	nc := c.instanceToNodeClaim(instance, instanceType)
+	if zoneID, ok := nodeClass.ZoneIDs()[instance.Zone]; ok {
+		nc.Labels[v1beta1.LabelTopologyZoneID] = zoneID
+	}
+	// Labels are synced to the node when it registers, which opts the node in to the Bottlerocket update operator
+	if nodeClass.BottlerocketInPlaceUpdates() {
+		nc.Labels[v1beta1.LabelBottlerocketUpdaterInterfaceVersion] = v1beta1.BottlerocketUpdaterInterfaceVersion
+	}
 	nc.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
 		v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
 		v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
+		v1beta1.AnnotationEC2NodeClassFieldHashes: nodeClass.FieldHashes(),
 	})
+	// record the spot price at the time of fulfillment, which is synced to the node once the instance is tagged
+	if instance.CapacityType == corev1beta1.CapacityTypeSpot {
+		if price, ok := c.pricingProvider.LaunchSpotPrice(instance.Type, instance.Zone); ok {
+			nc.Annotations[v1beta1.AnnotationSpotPrice] = strconv.FormatFloat(price, 'f', -1, 64)
+		}
+	}
+	// record the launch details that are known at launch, the rest are recorded once the instance is tagged
+	nc.Annotations = lo.Assign(nc.Annotations, instance.LaunchAnnotations())
+	// The event involves the NodeClaim with the labels and provider id of the launched instance, so that recorders that
+	// forward events outside the cluster can describe the instance
+	launched := nodeClaim.DeepCopy()
+	launched.Labels = lo.Assign(launched.Labels, nc.Labels)
+	launched.Status.ProviderID = nc.Status.ProviderID
+	c.recorder.Publish(cloudproviderevents.NodeClaimLaunched(launched, instance.ID, instance.Type, instance.Zone))
 	return nc, nil
 }
 
+// publishFleetErrors reports the instance types and zones that couldn't be launched individually, since the error of the
+// launch combines them
+func (c *CloudProvider) publishFleetErrors(nodeClaim *corev1beta1.NodeClaim, err error) {
+	for _, fleetErr := range instance.FleetErrors(err) {
+		if fleetErr.InstanceType != "" {
+			c.recorder.Publish(cloudproviderevents.NodeClaimFleetError(nodeClaim, fleetErr.Offering(), fleetErr.CapacityType, fleetErr.Code, fleetErr.Message))
+		}
+	}
+}
+
 func (c *CloudProvider) List(ctx context.Context) ([]*corev1beta1.NodeClaim, error) {
-	instances, err := c.instanceProvider.List(ctx)
+	instances, err := c.listInstances(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("listing instances, %w", err)
+		return nil, err
 	}
 	var nodeClaims []*corev1beta1.NodeClaim
 	for _, instance := range instances {
@@ -130,7 +190,11 @@ func (c *CloudProvider) Get(ctx context.Context, providerID string) (*corev1beta
 		return nil, fmt.Errorf("getting instance ID, %w", err)
 	}
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("id", id))
-	instance, err := c.instanceProvider.Get(ctx, id)
+	instanceProvider, err := c.instanceProviderForProviderID(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := instanceProvider.Get(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("getting instance, %w", err)
 	}
@@ -168,18 +232,175 @@ func (c *CloudProvider) GetInstanceTypes(ctx context.Context, nodePool *corev1be
 	return instanceTypes, nil
 }
 
-func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) error {
+func (c *CloudProvider) Delete(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (err error) {
+	ctx, span := tracing.Start(ctx, "CloudProvider.Delete", attribute.String("nodeclaim", nodeClaim.Name),
+		attribute.String("nodepool", nodeClaim.Labels[corev1beta1.NodePoolLabelKey]))
+	defer func() { tracing.End(span, err) }()
+	ctx = tracing.WithTraceID(ctx)
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name))
+	ctx = audit.WithNodeClaim(ctx, nodeClaim)
 
 	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
 	if err != nil {
 		return fmt.Errorf("getting instance ID, %w", err)
 	}
+	span.SetAttributes(attribute.String("instance.id", id))
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("id", id))
-	return c.instanceProvider.Delete(ctx, id)
+	providers, err := c.accountProvider.ForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		return err
+	}
+	if options.FromContext(ctx).StoppedInstancePoolSize > 0 && c.stopForReuse(ctx, providers.Instance, nodeClaim.Status.ProviderID, id) {
+		return nil
+	}
+	return providers.Instance.Delete(ctx, id)
+}
+
+// listInstances lists the instances in Karpenter's own account and in the account of every EC2NodeClass that assumes a
+// role, since instances that are missing from the list are garbage collected
+func (c *CloudProvider) listInstances(ctx context.Context) ([]*instance.Instance, error) {
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return nil, fmt.Errorf("listing nodeclasses, %w", err)
+	}
+	roles := lo.Uniq(append([]account.Role{{}}, lo.Map(nodeClassList.Items, func(nc v1beta1.EC2NodeClass, _ int) account.Role {
+		return account.RoleFor(&nc)
+	})...))
+	instances := make([][]*instance.Instance, len(roles))
+	errs := make([]error, len(roles))
+	workqueue.ParallelizeUntil(ctx, lo.Max([]int{options.FromContext(ctx).GarbageCollectionListWorkers, 1}), len(roles), func(i int) {
+		instances[i], errs[i] = c.accountProvider.ForRole(roles[i]).Instance.List(ctx)
+	})
+	if err := multierr.Combine(errs...); err != nil {
+		return nil, fmt.Errorf("listing instances, %w", err)
+	}
+	// Roles that are assumed by different EC2NodeClasses may belong to the same account
+	return lo.UniqBy(lo.Flatten(instances), func(i *instance.Instance) string { return i.ID }), nil
+}
+
+// instanceProviderForProviderID returns the instance provider of the account that the instance with the provider ID is
+// launched in, which is resolved from the instance's NodeClaim
+func (c *CloudProvider) instanceProviderForProviderID(ctx context.Context, providerID string) (*instance.Provider, error) {
+	nodeClaim, err := c.nodeClaimForProviderID(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+	if nodeClaim == nil {
+		return c.accountProvider.Home().Instance, nil
+	}
+	providers, err := c.accountProvider.ForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		return nil, err
+	}
+	return providers.Instance, nil
+}
+
+// nodeClaimForProviderID returns the NodeClaim with the provider ID, or nil if there isn't one
+func (c *CloudProvider) nodeClaimForProviderID(ctx context.Context, providerID string) (*corev1beta1.NodeClaim, error) {
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingFields{"status.providerID": providerID}); err != nil {
+		return nil, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	if len(nodeClaimList.Items) == 0 {
+		return nil, nil
+	}
+	return &nodeClaimList.Items[0], nil
+}
+
+// stopForReuse stops the instance instead of terminating it if it can be reused by a later NodeClaim, and returns true
+// if the instance is stopped for reuse. Only running on-demand instances whose NodeClaim was disrupted through
+// consolidation or emptiness are stopped, since drifted, expired and interrupted instances shouldn't be started again.
+// Delete is called for both the Node and the NodeClaim, so the NodeClaim is looked up rather than relying on the one
+// that is passed.
+func (c *CloudProvider) stopForReuse(ctx context.Context, instanceProvider *instance.Provider, providerID string, id string) bool {
+	i, err := instanceProvider.Get(ctx, id)
+	if err != nil {
+		return false
+	}
+	if _, ok := i.Tags[v1beta1.TagStoppedAt]; ok {
+		return true
+	}
+	if i.CapacityType != corev1beta1.CapacityTypeOnDemand || i.State != string(ec2types.InstanceStateNameRunning) {
+		return false
+	}
+	nodeClaim, err := c.nodeClaimForProviderID(ctx, providerID)
+	if err != nil || nodeClaim == nil {
+		return false
+	}
+	nodePool := &corev1beta1.NodePool{}
+	if err = c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Labels[corev1beta1.NodePoolLabelKey]}, nodePool); err != nil {
+		return false
+	}
+	if !reusable(nodeClaim, nodePool) {
+		return false
+	}
+	nodeClass, err := c.resolveNodeClassFromNodeClaim(ctx, nodeClaim)
+	if err != nil || nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHash] != nodeClass.Hash() {
+		return false
+	}
+	stopped, err := instanceProvider.ListStopped(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("listing stopped instances, %v", err)
+		return false
+	}
+	if lo.CountBy(stopped, func(s *instance.Instance) bool {
+		return s.Tags[corev1beta1.NodePoolLabelKey] == i.Tags[corev1beta1.NodePoolLabelKey]
+	}) >= options.FromContext(ctx).StoppedInstancePoolSize {
+		return false
+	}
+	if err = instanceProvider.Stop(ctx, id, nodeClass.Hash()); err != nil {
+		logging.FromContext(ctx).Errorf("stopping instance for reuse, %v", err)
+		return false
+	}
+	logging.FromContext(ctx).Infof("stopped instance for reuse")
+	return true
+}
+
+// reusable returns true if the NodeClaim's node became ready and was disrupted through consolidation or emptiness, and
+// if it still matches its NodePool. Karpenter doesn't record why a NodeClaim was disrupted, so NodeClaims are treated
+// as consolidated if they're empty, or if their NodePool consolidates underutilized nodes and they weren't disrupted
+// for any of the reasons that also apply to their instance.
+func reusable(nodeClaim *corev1beta1.NodeClaim, nodePool *corev1beta1.NodePool) bool {
+	conditions := nodeClaim.StatusConditions()
+	if nodeClaim.Spec.NodeClassRef == nil ||
+		!conditions.GetCondition(corev1beta1.Registered).IsTrue() ||
+		!conditions.GetCondition(corev1beta1.Initialized).IsTrue() ||
+		conditions.GetCondition(corev1beta1.Drifted).IsTrue() ||
+		conditions.GetCondition(corev1beta1.Expired).IsTrue() {
+		return false
+	}
+	if nodeClaim.Annotations[corev1beta1.NodePoolHashAnnotationKey] != nodePool.Hash() {
+		return false
+	}
+	if lo.SomeBy([]string{v1beta1.AnnotationScheduledMaintenance, v1beta1.AnnotationInstanceStatusImpaired, v1beta1.AnnotationRebalanceRecommended, v1beta1.AnnotationReplace}, func(k string) bool {
+		_, ok := nodeClaim.Annotations[k]
+		return ok
+	}) {
+		return false
+	}
+	return conditions.GetCondition(corev1beta1.Empty).IsTrue() ||
+		nodePool.Spec.Disruption.ConsolidationPolicy == corev1beta1.ConsolidationPolicyWhenUnderutilized
 }
 
 func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (cloudprovider.DriftReason, error) {
+	// NodeClaims marked for replacement by the interruption or repair controllers, or by operators, are drifted regardless
+	// of their NodeClass
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationScheduledMaintenance]; ok {
+		return ScheduledMaintenanceDrift, nil
+	}
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationInstanceStatusImpaired]; ok {
+		return InstanceStatusImpairedDrift, nil
+	}
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationRebalanceRecommended]; ok {
+		return RebalanceRecommendationDrift, nil
+	}
+	replace, err := c.isReplacementRequested(ctx, nodeClaim)
+	if err != nil {
+		return "", err
+	}
+	if replace {
+		return ReplacementRequestedDrift, nil
+	}
 	// Not needed when GetInstanceTypes removes nodepool dependency
 	nodePoolName, ok := nodeClaim.Labels[corev1beta1.NodePoolLabelKey]
 	if !ok {
@@ -206,6 +427,22 @@ func (c *CloudProvider) IsDrifted(ctx context.Context, nodeClaim *corev1beta1.No
 	return driftReason, nil
 }
 
+// isReplacementRequested returns true if the NodeClaim or its node was annotated to be replaced
+func (c *CloudProvider) isReplacementRequested(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (bool, error) {
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationReplace]; ok {
+		return true, nil
+	}
+	if nodeClaim.Status.NodeName == "" {
+		return false, nil
+	}
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return false, client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	_, ok := node.Annotations[v1beta1.AnnotationReplace]
+	return ok, nil
+}
+
 // Name returns the CloudProvider implementation name.
 func (c *CloudProvider) Name() string {
 	return "aws"
@@ -238,6 +475,21 @@ func (c *CloudProvider) resolveNodeClassFromNodePool(ctx context.Context, nodePo
 	return nodeClass, nil
 }
 
+// resolveZoneIDRequirement returns the NodeClaim with a requirement on the zones whose IDs it requires, since offerings
+// are resolved and instances are launched by zone name
+func resolveZoneIDRequirement(nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass) *corev1beta1.NodeClaim {
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	if !reqs.Has(v1beta1.LabelTopologyZoneID) {
+		return nodeClaim
+	}
+	zones := lo.Keys(lo.PickBy(nodeClass.ZoneIDs(), func(_, zoneID string) bool { return reqs.Get(v1beta1.LabelTopologyZoneID).Has(zoneID) }))
+	nodeClaim = nodeClaim.DeepCopy()
+	nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+		NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: zones},
+	})
+	return nodeClaim
+}
+
 func (c *CloudProvider) resolveInstanceTypes(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass) ([]*cloudprovider.InstanceType, error) {
 	instanceTypes, err := c.instanceTypeProvider.List(ctx, nodeClaim.Spec.Kubelet, nodeClass)
 	if err != nil {
@@ -279,6 +531,20 @@ func (c *CloudProvider) resolveNodePoolFromInstance(ctx context.Context, instanc
 	return nil, errors.NewNotFound(schema.GroupResource{Group: corev1beta1.Group, Resource: "nodepools"}, "")
 }
 
+// resolveNodePoolFromNodeClaim returns the NodePool that owns the NodeClaim. The NodePool is only needed to render cost
+// allocation tags, so nil is returned if they aren't configured or the NodeClaim isn't owned by a NodePool.
+func (c *CloudProvider) resolveNodePoolFromNodeClaim(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (*corev1beta1.NodePool, error) {
+	nodePoolName, ok := nodeClaim.Labels[corev1beta1.NodePoolLabelKey]
+	if !ok || options.FromContext(ctx).CostAllocationTags == "" {
+		return nil, nil
+	}
+	nodePool := &corev1beta1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return nodePool, nil
+}
+
 func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *cloudprovider.InstanceType) *corev1beta1.NodeClaim {
 	nodeClaim := &corev1beta1.NodeClaim{}
 	labels := map[string]string{}
@@ -306,17 +572,22 @@ func (c *CloudProvider) instanceToNodeClaim(i *instance.Instance, instanceType *
 	}
 	labels[v1.LabelTopologyZone] = i.Zone
 	labels[corev1beta1.CapacityTypeLabelKey] = i.CapacityType
+	labels = lo.Assign(labels, i.CapacityTypeLabels())
 	if v, ok := i.Tags[corev1beta1.NodePoolLabelKey]; ok {
 		labels[corev1beta1.NodePoolLabelKey] = v
 	}
 	if v, ok := i.Tags[corev1beta1.ManagedByAnnotationKey]; ok {
 		annotations[corev1beta1.ManagedByAnnotationKey] = v
 	}
+	// The EC2NodeClass is used to resolve the account of instances that are garbage collected
+	if v, ok := i.Tags[v1beta1.LabelNodeClass]; ok {
+		nodeClaim.Spec.NodeClassRef = &corev1beta1.NodeClassReference{Name: v}
+	}
 	nodeClaim.Labels = labels
 	nodeClaim.Annotations = annotations
 	nodeClaim.CreationTimestamp = metav1.Time{Time: i.LaunchTime}
 	// Set the deletionTimestamp to be the current time if the instance is currently terminating
-	if i.State == ec2.InstanceStateNameShuttingDown || i.State == ec2.InstanceStateNameTerminated {
+	if i.State == string(ec2types.InstanceStateNameShuttingDown) || i.State == string(ec2types.InstanceStateNameTerminated) {
 		nodeClaim.DeletionTimestamp = &metav1.Time{Time: time.Now()}
 	}
 	nodeClaim.Status.ProviderID = fmt.Sprintf("aws:///%s/%s", i.Zone, i.ID)
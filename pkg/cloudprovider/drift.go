@@ -17,52 +17,97 @@ package cloudprovider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
 const (
-	AMIDrift           cloudprovider.DriftReason = "AMIDrift"
-	SubnetDrift        cloudprovider.DriftReason = "SubnetDrift"
-	SecurityGroupDrift cloudprovider.DriftReason = "SecurityGroupDrift"
-	NodeClassDrift     cloudprovider.DriftReason = "NodeClassDrift"
+	AMIDrift                     cloudprovider.DriftReason = "AMIDrift"
+	SubnetDrift                  cloudprovider.DriftReason = "SubnetDrift"
+	SecurityGroupDrift           cloudprovider.DriftReason = "SecurityGroupDrift"
+	NodeClassDrift               cloudprovider.DriftReason = "NodeClassDrift"
+	RebalanceRecommendationDrift cloudprovider.DriftReason = "RebalanceRecommendationDrift"
+	ScheduledMaintenanceDrift    cloudprovider.DriftReason = "ScheduledMaintenanceDrift"
+	InstanceStatusImpairedDrift  cloudprovider.DriftReason = "InstanceStatusImpairedDrift"
+	ReplacementRequestedDrift    cloudprovider.DriftReason = "ReplacementRequestedDrift"
+	InstanceProfileDrift         cloudprovider.DriftReason = "InstanceProfileDrift"
+	MetadataOptionsDrift         cloudprovider.DriftReason = "MetadataOptionsDrift"
+	// Drift reasons for changes to static fields of the EC2NodeClass
+	AssociatePublicIPAddressDrift cloudprovider.DriftReason = "AssociatePublicIPAddressDrift"
+	AMIFamilyDrift                cloudprovider.DriftReason = "AMIFamilyDrift"
+	UserDataDrift                 cloudprovider.DriftReason = "UserDataDrift"
+	RoleDrift                     cloudprovider.DriftReason = "RoleDrift"
+	BlockDeviceMappingsDrift      cloudprovider.DriftReason = "BlockDeviceMappingsDrift"
+	InstanceStorePolicyDrift      cloudprovider.DriftReason = "InstanceStorePolicyDrift"
+	DetailedMonitoringDrift       cloudprovider.DriftReason = "DetailedMonitoringDrift"
+	ContextDrift                  cloudprovider.DriftReason = "ContextDrift"
 )
 
+// staticFieldDriftReasons are the drift reasons for changes to each of the EC2NodeClass spec fields that are hashed for
+// drift. NodeClassDrift is used when the field that changed isn't known.
+var staticFieldDriftReasons = map[string]cloudprovider.DriftReason{
+	"associatePublicIPAddress": AssociatePublicIPAddressDrift,
+	"amiFamily":                AMIFamilyDrift,
+	"userData":                 UserDataDrift,
+	"role":                     RoleDrift,
+	"instanceProfile":          InstanceProfileDrift,
+	"blockDeviceMappings":      BlockDeviceMappingsDrift,
+	"instanceStorePolicy":      InstanceStorePolicyDrift,
+	"detailedMonitoring":       DetailedMonitoringDrift,
+	"metadataOptions":          MetadataOptionsDrift,
+	"context":                  ContextDrift,
+}
+
 func (c *CloudProvider) isNodeClassDrifted(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, nodePool *corev1beta1.NodePool, nodeClass *v1beta1.EC2NodeClass) (cloudprovider.DriftReason, error) {
 	// First check if the node class is statically drifted to save on API calls.
-	if drifted := c.areStaticFieldsDrifted(nodeClaim, nodeClass); drifted != "" {
-		return drifted, nil
+	staticDrifted := c.areStaticFieldsDrifted(nodeClaim, nodeClass)
+	if staticDrifted != "" && !options.FromContext(ctx).InPlaceMetadataOptionsUpdate {
+		return staticDrifted, nil
 	}
-	instance, err := c.getInstance(ctx, nodeClaim.Status.ProviderID)
+	i, err := c.getInstance(ctx, nodeClass, nodeClaim.Status.ProviderID)
 	if err != nil {
 		return "", err
 	}
-	amiDrifted, err := c.isAMIDrifted(ctx, nodeClaim, nodePool, instance, nodeClass)
+	// Changes to metadata options are applied to the instance in place by the in-place update controller
+	if staticDrifted != "" && !instance.MetadataOptionsUpdatable(nodeClaim, nodeClass, i) {
+		return staticDrifted, nil
+	}
+	amiDrifted, err := c.isAMIDrifted(ctx, nodeClaim, nodePool, i, nodeClass)
 	if err != nil {
 		return "", fmt.Errorf("calculating ami drift, %w", err)
 	}
-	securitygroupDrifted, err := c.areSecurityGroupsDrifted(ctx, instance, nodeClass)
+	securitygroupDrifted, err := c.areSecurityGroupsDrifted(ctx, i, nodeClass)
 	if err != nil {
 		return "", fmt.Errorf("calculating securitygroup drift, %w", err)
 	}
-	subnetDrifted, err := c.isSubnetDrifted(ctx, instance, nodeClass)
+	subnetDrifted, err := c.isSubnetDrifted(ctx, i, nodeClass)
 	if err != nil {
 		return "", fmt.Errorf("calculating subnet drift, %w", err)
 	}
-	drifted := lo.FindOrElse([]cloudprovider.DriftReason{amiDrifted, securitygroupDrifted, subnetDrifted}, "", func(i cloudprovider.DriftReason) bool {
+	drifted := lo.FindOrElse([]cloudprovider.DriftReason{
+		amiDrifted,
+		securitygroupDrifted,
+		subnetDrifted,
+		isInstanceProfileDrifted(i, nodeClass),
+		areMetadataOptionsDrifted(nodeClaim, i, nodeClass),
+	}, "", func(i cloudprovider.DriftReason) bool {
 		return string(i) != ""
 	})
 	return drifted, nil
@@ -80,7 +125,7 @@ func (c *CloudProvider) isAMIDrifted(ctx context.Context, nodeClaim *corev1beta1
 	if !found {
 		return "", fmt.Errorf(`finding node instance type "%s"`, nodeClaim.Labels[v1.LabelInstanceTypeStable])
 	}
-	amis, err := c.amiProvider.Get(ctx, nodeClass, &amifamily.Options{})
+	amis, err := c.accountProvider.For(nodeClass).AMI.Get(ctx, nodeClass, &amifamily.Options{})
 	if err != nil {
 		return "", fmt.Errorf("getting amis, %w", err)
 	}
@@ -88,16 +133,43 @@ func (c *CloudProvider) isAMIDrifted(ctx context.Context, nodeClaim *corev1beta1
 		return "", fmt.Errorf("no amis exist given constraints")
 	}
 	mappedAMIs := amis.MapToInstanceTypes([]*cloudprovider.InstanceType{nodeInstanceType})
-	if !lo.Contains(lo.Keys(mappedAMIs), instance.ImageID) {
-		return AMIDrift, nil
+	if lo.Contains(lo.Keys(mappedAMIs), instance.ImageID) {
+		return "", nil
 	}
-	return "", nil
+	if nodeClass.BottlerocketInPlaceUpdates() {
+		updatable, err := c.isBottlerocketUpdatable(ctx, nodeClaim, lo.Filter(amis, func(ami amifamily.AMI, _ int) bool {
+			return lo.Contains(lo.Keys(mappedAMIs), ami.AmiID)
+		}))
+		if err != nil {
+			return "", err
+		}
+		if updatable {
+			return "", nil
+		}
+	}
+	return AMIDrift, nil
+}
+
+// isBottlerocketUpdatable returns true if the AMIs are releases of the Bottlerocket variant that the NodeClaim's node is
+// running, so that the Bottlerocket update operator updates the node in place. The instance keeps the AMI that it was
+// launched with after it's updated, so the node's OS image is compared instead.
+func (c *CloudProvider) isBottlerocketUpdatable(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, amis []amifamily.AMI) (bool, error) {
+	if nodeClaim.Status.NodeName == "" {
+		return false, nil
+	}
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return false, client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	return lo.ContainsBy(amis, func(ami amifamily.AMI) bool {
+		return amifamily.IsBottlerocketRelease(ami.Name, node.Status.NodeInfo)
+	}), nil
 }
 
 // Checks if the security groups are drifted, by comparing the subnet returned from the subnetProvider
 // to the ec2 instance subnets
 func (c *CloudProvider) isSubnetDrifted(ctx context.Context, instance *instance.Instance, nodeClass *v1beta1.EC2NodeClass) (cloudprovider.DriftReason, error) {
-	subnets, err := c.subnetProvider.List(ctx, nodeClass)
+	subnets, err := c.accountProvider.For(nodeClass).Subnet.List(ctx, nodeClass)
 	if err != nil {
 		return "", err
 	}
@@ -106,8 +178,8 @@ func (c *CloudProvider) isSubnetDrifted(ctx context.Context, instance *instance.
 		return "", fmt.Errorf("no subnets are discovered")
 	}
 
-	_, found := lo.Find(subnets, func(subnet *ec2.Subnet) bool {
-		return aws.StringValue(subnet.SubnetId) == instance.SubnetID
+	_, found := lo.Find(subnets, func(subnet *ec2types.Subnet) bool {
+		return aws.ToString(subnet.SubnetId) == instance.SubnetID
 	})
 
 	if !found {
@@ -119,21 +191,62 @@ func (c *CloudProvider) isSubnetDrifted(ctx context.Context, instance *instance.
 // Checks if the security groups are drifted, by comparing the security groups returned from the SecurityGroupProvider
 // to the ec2 instance security groups
 func (c *CloudProvider) areSecurityGroupsDrifted(ctx context.Context, ec2Instance *instance.Instance, nodeClass *v1beta1.EC2NodeClass) (cloudprovider.DriftReason, error) {
-	securitygroup, err := c.securityGroupProvider.List(ctx, nodeClass)
+	securitygroup, err := c.accountProvider.For(nodeClass).SecurityGroup.List(ctx, nodeClass)
 	if err != nil {
 		return "", err
 	}
-	securityGroupIds := sets.New(lo.Map(securitygroup, func(sg *ec2.SecurityGroup, _ int) string { return aws.StringValue(sg.GroupId) })...)
+	securityGroupIds := sets.New(lo.Map(securitygroup, func(sg *ec2types.SecurityGroup, _ int) string { return aws.ToString(sg.GroupId) })...)
 	if len(securityGroupIds) == 0 {
 		return "", fmt.Errorf("no security groups are discovered")
 	}
 
 	if !securityGroupIds.Equal(sets.New(ec2Instance.SecurityGroupIDs...)) {
+		// Changes to security groups are applied to the instance's network interfaces in place by the in-place update controller
+		if options.FromContext(ctx).InPlaceSecurityGroupsUpdate && len(ec2Instance.NetworkInterfaceIDs) > 0 {
+			return "", nil
+		}
 		return SecurityGroupDrift, nil
 	}
 	return "", nil
 }
 
+// Checks if the instance profile is drifted, by comparing the instance profile that the EC2NodeClass resolves to with the
+// instance profile that is associated with the instance, which may have been replaced outside of Karpenter
+func isInstanceProfileDrifted(instance *instance.Instance, nodeClass *v1beta1.EC2NodeClass) cloudprovider.DriftReason {
+	expected := lo.Ternary(nodeClass.Spec.InstanceProfile != nil, lo.FromPtr(nodeClass.Spec.InstanceProfile), nodeClass.Status.InstanceProfile)
+	// The instance profile can't be compared until it's resolved, or if the instance's profile isn't known
+	if expected == "" || instance.InstanceProfileARN == "" || lo.Contains(nodeClass.DriftIgnoredFields(), "instanceProfile") {
+		return ""
+	}
+	// Instance profile ARNs are of the form arn:<partition>:iam::<account>:instance-profile/<path>/<name>
+	arn := strings.Split(instance.InstanceProfileARN, "/")
+	return lo.Ternary(arn[len(arn)-1] != expected, InstanceProfileDrift, "")
+}
+
+// Checks if the metadata options are drifted, by comparing the metadata options of the EC2NodeClass with the instance's
+// current metadata options, which may have been modified outside of Karpenter. Metadata options are only compared when
+// the EC2NodeClass hasn't changed since the NodeClaim was launched, since changes to the EC2NodeClass are detected as
+// static drift or are applied to the instance in place.
+func areMetadataOptionsDrifted(nodeClaim *corev1beta1.NodeClaim, instance *instance.Instance, nodeClass *v1beta1.EC2NodeClass) cloudprovider.DriftReason {
+	if instance.MetadataOptions == nil || nodeClass.Spec.MetadataOptions == nil || lo.Contains(nodeClass.DriftIgnoredFields(), "metadataOptions") {
+		return ""
+	}
+	if nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHash] != nodeClass.Hash() ||
+		nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion] != v1beta1.EC2NodeClassHashVersion {
+		return ""
+	}
+	expected, actual := nodeClass.Spec.MetadataOptions, instance.MetadataOptions
+	// Options that aren't set on the EC2NodeClass are left to the EC2 defaults, so they aren't compared
+	drifted := func(expected, actual *string) bool { return expected != nil && actual != nil && *expected != *actual }
+	if drifted(expected.HTTPEndpoint, actual.HTTPEndpoint) ||
+		drifted(expected.HTTPProtocolIPv6, actual.HTTPProtocolIPv6) ||
+		drifted(expected.HTTPTokens, actual.HTTPTokens) ||
+		(expected.HTTPPutResponseHopLimit != nil && actual.HTTPPutResponseHopLimit != nil && *expected.HTTPPutResponseHopLimit != *actual.HTTPPutResponseHopLimit) {
+		return MetadataOptionsDrift
+	}
+	return ""
+}
+
 func (c *CloudProvider) areStaticFieldsDrifted(nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass) cloudprovider.DriftReason {
 	nodeClassHash, foundNodeClassHash := nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHash]
 	nodeClassHashVersion, foundNodeClassHashVersion := nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion]
@@ -144,19 +257,27 @@ func (c *CloudProvider) areStaticFieldsDrifted(nodeClaim *corev1beta1.NodeClaim,
 		return ""
 	}
 	// validate that the hash version for the EC2NodeClass is the same as the NodeClaim before evaluating for static drift
-	if nodeClassHashVersion != nodeClaimHashVersion {
+	if nodeClassHashVersion != nodeClaimHashVersion || nodeClassHash == nodeClaimHash {
 		return ""
 	}
-	return lo.Ternary(nodeClassHash != nodeClaimHash, NodeClassDrift, "")
+	// NodeClaims that were launched with the hash of each field report the first field that changed
+	if fieldHashes, ok := nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassFieldHashes]; ok {
+		if fields := nodeClass.DriftedFields(fieldHashes); len(fields) > 0 {
+			if reason, ok := staticFieldDriftReasons[fields[0]]; ok {
+				return reason
+			}
+		}
+	}
+	return NodeClassDrift
 }
 
-func (c *CloudProvider) getInstance(ctx context.Context, providerID string) (*instance.Instance, error) {
+func (c *CloudProvider) getInstance(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, providerID string) (*instance.Instance, error) {
 	// Get InstanceID to fetch from EC2
 	instanceID, err := utils.ParseInstanceID(providerID)
 	if err != nil {
 		return nil, err
 	}
-	instance, err := c.instanceProvider.Get(ctx, instanceID)
+	instance, err := c.accountProvider.For(nodeClass).Instance.Get(ctx, instanceID)
 	if err != nil {
 		return nil, fmt.Errorf("getting instance, %w", err)
 	}
@@ -15,6 +15,9 @@ limitations under the License.
 package events
 
 import (
+	"fmt"
+	"strings"
+
 	v1 "k8s.io/api/core/v1"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -38,3 +41,44 @@ func NodeClaimFailedToResolveNodeClass(nodeClaim *v1beta1.NodeClaim) events.Even
 		DedupeValues:   []string{string(nodeClaim.UID)},
 	}
 }
+
+func NodeClaimNodeClassNotReady(nodeClaim *v1beta1.NodeClaim, nodeClassName, message string) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           v1.EventTypeWarning,
+		Reason:         "NodeClassNotReady",
+		Message:        fmt.Sprintf("EC2NodeClass %q isn't ready, %s", nodeClassName, message),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+func NodeClaimFailedQuotaExceeded(nodeClaim *v1beta1.NodeClaim, err error) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           v1.EventTypeWarning,
+		Reason:         "QuotaExceeded",
+		Message:        fmt.Sprintf("Launching the NodeClaim would exceed the EC2 vCPU service quota, %s", err),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
+
+// NodeClaimFleetError is published for each instance type and zone that CreateFleet couldn't launch the NodeClaim in
+func NodeClaimFleetError(nodeClaim *v1beta1.NodeClaim, offering, capacityType, code, message string) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           v1.EventTypeWarning,
+		Reason:         "FleetError",
+		Message:        fmt.Sprintf("Failed launching %s, %s: %s", strings.TrimSpace(capacityType+" "+offering), code, message),
+		DedupeValues:   []string{string(nodeClaim.UID), offering, capacityType, code},
+	}
+}
+
+func NodeClaimLaunched(nodeClaim *v1beta1.NodeClaim, instanceID, instanceType, zone string) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClaim,
+		Type:           v1.EventTypeNormal,
+		Reason:         "Launched",
+		Message:        fmt.Sprintf("Launched instance %s of type %s in %s", instanceID, instanceType, zone),
+		DedupeValues:   []string{string(nodeClaim.UID)},
+	}
+}
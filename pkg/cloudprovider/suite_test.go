@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -30,9 +31,11 @@ import (
 
 	clock "k8s.io/utils/clock/testing"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
 
@@ -41,6 +44,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -75,15 +79,15 @@ func TestAWS(t *testing.T) {
 }
 
 var _ = BeforeSuite(func() {
-	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	ctx = options.ToContext(ctx, test.Options())
 	ctx, stop = context.WithCancel(ctx)
 	awsEnv = test.NewEnvironment(ctx, env)
 	fakeClock = clock.NewFakeClock(time.Now())
 	recorder = events.NewRecorder(&record.FakeRecorder{})
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, recorder,
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, recorder,
+		env.Client, awsEnv.PricingProvider)
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster)
 })
@@ -155,6 +159,28 @@ var _ = Describe("CloudProvider", func() {
 		Expect(corecloudproivder.IsInsufficientCapacityError(err)).To(BeTrue())
 		Expect(cloudProviderNodeClaim).To(BeNil())
 	})
+	It("should return an ICE error when the nodeClass isn't ready", func() {
+		nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeSecurityGroupsReady)
+		nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeAMIsReady)
+		nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeInstanceProfileReady)
+		nodeClass.StatusConditions().MarkFalse(v1beta1.ConditionTypeSubnetsReady, "SubnetsNotFound", "no subnets exist given constraints")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(corecloudproivder.IsInsufficientCapacityError(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("no subnets exist given constraints"))
+		Expect(cloudProviderNodeClaim).To(BeNil())
+		Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(BeZero())
+	})
+	It("should launch when the nodeClass is ready", func() {
+		nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeSubnetsReady)
+		nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeSecurityGroupsReady)
+		nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeAMIsReady)
+		nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeInstanceProfileReady)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim).ToNot(BeNil())
+	})
 	It("should set ImageID in the status field of the nodeClaim", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
 		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
@@ -170,6 +196,154 @@ var _ = Describe("CloudProvider", func() {
 		_, ok := cloudProviderNodeClaim.ObjectMeta.Annotations[v1beta1.AnnotationEC2NodeClassHash]
 		Expect(ok).To(BeTrue())
 	})
+	It("should label nodeClaims for the Bottlerocket update operator when nodes are updated in place", func() {
+		nodeClass.Spec.AMIFamily = aws.String(v1beta1.AMIFamilyBottlerocket)
+		nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
+			v1beta1.AnnotationBottlerocketUpdateStrategy: v1beta1.BottlerocketUpdateStrategyInPlace,
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelBottlerocketUpdaterInterfaceVersion, v1beta1.BottlerocketUpdaterInterfaceVersion))
+	})
+	It("should not label nodeClaims for the Bottlerocket update operator by default", func() {
+		nodeClass.Spec.AMIFamily = aws.String(v1beta1.AMIFamilyBottlerocket)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+		cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+		Expect(err).To(BeNil())
+		Expect(cloudProviderNodeClaim.Labels).ToNot(HaveKey(v1beta1.LabelBottlerocketUpdaterInterfaceVersion))
+	})
+	Context("Spot Price", func() {
+		BeforeEach(func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"m5.large"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}}},
+			}
+		})
+		It("should annotate spot nodeClaims with the last known spot price at launch", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeSpot}},
+			})
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "m5.large",
+						SpotPrice:        aws.String("0.0412"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
+			awsEnv.EC2API.DescribeSpotPriceHistoryInput.Reset()
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationSpotPrice, "0.0412"))
+			// the spot price is read from the prices that are already known, rather than from EC2 on every launch
+			Expect(awsEnv.EC2API.DescribeSpotPriceHistoryInput.IsNil()).To(BeTrue())
+		})
+		It("should not annotate spot nodeClaims with an overridden spot price", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeSpot}},
+			})
+			price, ok := awsEnv.PricingProvider.SpotPrice("m5.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			awsEnv.PricingProvider.SetOverrides([]pricing.Override{
+				{InstanceType: "m5.large", CapacityType: corev1beta1.CapacityTypeSpot, Zone: "test-zone-1a", Price: price / 2},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationSpotPrice, strconv.FormatFloat(price, 'f', -1, 64)))
+		})
+		It("should not annotate on-demand nodeClaims with a spot price", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationSpotPrice))
+		})
+	})
+	Context("Launch Details", func() {
+		It("should annotate nodeClaims with the launch template and request of their launch", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			launchTemplateName := aws.ToString(createFleetInput.LaunchTemplateConfigs[0].LaunchTemplateSpecification.LaunchTemplateName)
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationLaunchTemplateName, launchTemplateName))
+			Expect(cloudProviderNodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationCreateFleetRequestID, fake.CreateFleetRequestID))
+		})
+		It("should not annotate nodeClaims with launch details that aren't known at launch", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			// the launch template is launched at "$Latest", which EC2 resolves on the instance
+			Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationLaunchTemplateVersion))
+			Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationNetworkInterfaceIDs))
+			Expect(cloudProviderNodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationCapacityReservationID))
+		})
+		It("should label nodeClaims with the capacity type of their instance", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelCapacityType, corev1beta1.CapacityTypeOnDemand))
+			Expect(cloudProviderNodeClaim.Labels).ToNot(HaveKey(v1beta1.LabelCapacityReservationID))
+		})
+		It("should label nodeClaims of instances in a capacity reservation as reserved", func() {
+			instanceID := fake.InstanceID()
+			ec2Instance := runningInstance(instanceID, nodePool.Name)
+			ec2Instance.CapacityReservationId = aws.String("cr-1234567890abcdef0")
+			awsEnv.EC2API.Instances.Store(instanceID, ec2Instance)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			nodeClaims, err := cloudProvider.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			nc, ok := lo.Find(nodeClaims, func(nc *corev1beta1.NodeClaim) bool { return strings.HasSuffix(nc.Status.ProviderID, instanceID) })
+			Expect(ok).To(BeTrue())
+			Expect(nc.Labels).To(HaveKeyWithValue(v1beta1.LabelCapacityType, v1beta1.CapacityTypeReserved))
+			Expect(nc.Labels).To(HaveKeyWithValue(v1beta1.LabelCapacityReservationID, "cr-1234567890abcdef0"))
+			Expect(nc.Labels).To(HaveKeyWithValue(corev1beta1.CapacityTypeLabelKey, corev1beta1.CapacityTypeOnDemand))
+		})
+	})
+	Context("Zone IDs", func() {
+		BeforeEach(func() {
+			nodeClass.Status.Subnets = []v1beta1.Subnet{
+				{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "testzone1a"},
+				{ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "testzone1b"},
+				{ID: "subnet-test3", Zone: "test-zone-1c", ZoneID: "testzone1c"},
+			}
+		})
+		It("should label nodeClaims with the zone ID of the zone they were launched in", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			zone := cloudProviderNodeClaim.Labels[v1.LabelTopologyZone]
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelTopologyZoneID, nodeClass.ZoneIDs()[zone]))
+		})
+		It("should only launch in the zones of the zone IDs that are required", func() {
+			nodeClaim.Spec.Requirements = append(nodeClaim.Spec.Requirements, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.LabelTopologyZoneID, Operator: v1.NodeSelectorOpIn, Values: []string{"testzone1c"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			cloudProviderNodeClaim, err := cloudProvider.Create(ctx, nodeClaim)
+			Expect(err).To(BeNil())
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1c"))
+			Expect(cloudProviderNodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelTopologyZoneID, "testzone1c"))
+
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(aws.ToString(override.AvailabilityZone)).To(Equal("test-zone-1c"))
+				}
+			}
+		})
+	})
 	Context("EC2 Context", func() {
 		contextID := "context-1234"
 		It("should set context on the CreateFleet request if specified on the NodePool", func() {
@@ -180,7 +354,7 @@ var _ = Describe("CloudProvider", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
 			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
-			Expect(aws.StringValue(createFleetInput.Context)).To(Equal(contextID))
+			Expect(aws.ToString(createFleetInput.Context)).To(Equal(contextID))
 		})
 		It("should default to no EC2 Context", func() {
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -192,6 +366,38 @@ var _ = Describe("CloudProvider", func() {
 			Expect(createFleetInput.Context).To(BeNil())
 		})
 	})
+	Context("Assume Role", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AssumeRoleARN = aws.String("arn:aws:iam::123456789012:role/KarpenterNodes")
+		})
+		It("should launch instances in the account of the assumed role", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(awsEnv.AccountEC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			Expect(awsEnv.AccountEC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+		It("should terminate instances in the account of the assumed role", func() {
+			instanceID := fake.InstanceID()
+			awsEnv.AccountEC2API.Instances.Store(instanceID, runningInstance(instanceID, nodePool.Name))
+			nodeClaim.Status.ProviderID = fmt.Sprintf("aws:///test-zone-1a/%s", instanceID)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.AccountEC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should list instances in the account of the assumed role", func() {
+			instanceID := fake.InstanceID()
+			awsEnv.AccountEC2API.Instances.Store(instanceID, runningInstance(instanceID, nodePool.Name))
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			nodeClaims, err := cloudProvider.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) string { return nc.Status.ProviderID })).To(ContainElement(ContainSubstring(instanceID)))
+		})
+	})
 	Context("MinValues", func() {
 		It("CreateFleet input should respect minValues for In operator requirement from NodePool", func() {
 			// Create fake InstanceTypes where one instances can fit 2 pods and another one can fit only 1 pod.
@@ -200,13 +406,13 @@ var _ = Describe("CloudProvider", func() {
 			// instances to meet the minimum requirement.
 			instances := fake.MakeInstances()
 			instances, _ = fake.MakeUniqueInstancesAndFamilies(instances, 2)
-			instances[0].VCpuInfo = &ec2.VCpuInfo{DefaultVCpus: aws.Int64(1)}
-			instances[1].VCpuInfo = &ec2.VCpuInfo{DefaultVCpus: aws.Int64(8)}
+			instances[0].VCpuInfo = &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(1)}
+			instances[1].VCpuInfo = &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(8)}
 			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: instances})
 			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: fake.MakeInstanceOfferings(instances)})
 			now := time.Now()
 			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-				SpotPriceHistory: []*ec2.SpotPrice{
+				SpotPriceHistory: []ec2types.SpotPrice{
 					{
 						AvailabilityZone: aws.String("test-zone-1a"),
 						InstanceType:     instances[0].InstanceType,
@@ -222,7 +428,7 @@ var _ = Describe("CloudProvider", func() {
 				},
 			})
 			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
-			instanceNames := lo.Map(instances, func(info *ec2.InstanceTypeInfo, _ int) string { return *info.InstanceType })
+			instanceNames := lo.Map(instances, func(info ec2types.InstanceTypeInfo, _ int) string { return string(info.InstanceType) })
 
 			// Define NodePool that has minValues on instance-type requirement.
 			nodePool = coretest.NodePool(corev1beta1.NodePool{
@@ -284,7 +490,7 @@ var _ = Describe("CloudProvider", func() {
 			uniqueInstanceTypes := sets.Set[string]{}
 			for _, launchTemplateConfig := range createFleetInput.LaunchTemplateConfigs {
 				for _, override := range launchTemplateConfig.Overrides {
-					uniqueInstanceTypes.Insert(*override.InstanceType)
+					uniqueInstanceTypes.Insert(string(override.InstanceType))
 				}
 			}
 			// This ensures that we have sent the minimum number of requirements defined in the NodePool.
@@ -294,13 +500,13 @@ var _ = Describe("CloudProvider", func() {
 			// Create fake InstanceTypes where one instances can fit 2 pods and another one can fit only 1 pod.
 			instances := fake.MakeInstances()
 			instances, _ = fake.MakeUniqueInstancesAndFamilies(instances, 2)
-			instances[0].VCpuInfo = &ec2.VCpuInfo{DefaultVCpus: aws.Int64(1)}
-			instances[1].VCpuInfo = &ec2.VCpuInfo{DefaultVCpus: aws.Int64(8)}
+			instances[0].VCpuInfo = &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(1)}
+			instances[1].VCpuInfo = &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(8)}
 			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: instances})
 			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: fake.MakeInstanceOfferings(instances)})
 			now := time.Now()
 			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-				SpotPriceHistory: []*ec2.SpotPrice{
+				SpotPriceHistory: []ec2types.SpotPrice{
 					{
 						AvailabilityZone: aws.String("test-zone-1a"),
 						InstanceType:     instances[0].InstanceType,
@@ -316,7 +522,7 @@ var _ = Describe("CloudProvider", func() {
 				},
 			})
 			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
-			instanceNames := lo.Map(instances, func(info *ec2.InstanceTypeInfo, _ int) string { return *info.InstanceType })
+			instanceNames := lo.Map(instances, func(info ec2types.InstanceTypeInfo, _ int) string { return string(info.InstanceType) })
 
 			// Define NodePool that has minValues on instance-type requirement.
 			nodePool = coretest.NodePool(corev1beta1.NodePool{
@@ -378,7 +584,7 @@ var _ = Describe("CloudProvider", func() {
 			uniqueInstanceTypes := sets.Set[string]{}
 			for _, launchTemplateConfig := range createFleetInput.LaunchTemplateConfigs {
 				for _, override := range launchTemplateConfig.Overrides {
-					uniqueInstanceTypes.Insert(*override.InstanceType)
+					uniqueInstanceTypes.Insert(string(override.InstanceType))
 				}
 			}
 			// This ensures that we have sent the minimum number of requirements defined in the NodePool.
@@ -388,14 +594,14 @@ var _ = Describe("CloudProvider", func() {
 			// Create fake InstanceTypes where 2 instances can fit 2 pods individually and one can fit only 1 pod.
 			instances := fake.MakeInstances()
 			uniqInstanceTypes, instanceFamilies := fake.MakeUniqueInstancesAndFamilies(instances, 3)
-			uniqInstanceTypes[0].VCpuInfo = &ec2.VCpuInfo{DefaultVCpus: aws.Int64(1)}
-			uniqInstanceTypes[1].VCpuInfo = &ec2.VCpuInfo{DefaultVCpus: aws.Int64(4)}
-			uniqInstanceTypes[2].VCpuInfo = &ec2.VCpuInfo{DefaultVCpus: aws.Int64(8)}
+			uniqInstanceTypes[0].VCpuInfo = &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(1)}
+			uniqInstanceTypes[1].VCpuInfo = &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(4)}
+			uniqInstanceTypes[2].VCpuInfo = &ec2types.VCpuInfo{DefaultVCpus: aws.Int32(8)}
 			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{InstanceTypes: uniqInstanceTypes})
 			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{InstanceTypeOfferings: fake.MakeInstanceOfferings(uniqInstanceTypes)})
 			now := time.Now()
 			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-				SpotPriceHistory: []*ec2.SpotPrice{
+				SpotPriceHistory: []ec2types.SpotPrice{
 					{
 						AvailabilityZone: aws.String("test-zone-1a"),
 						InstanceType:     uniqInstanceTypes[0].InstanceType,
@@ -417,7 +623,7 @@ var _ = Describe("CloudProvider", func() {
 				},
 			})
 			Expect(awsEnv.PricingProvider.UpdateSpotPricing(ctx)).To(Succeed())
-			instanceNames := lo.Map(uniqInstanceTypes, func(info *ec2.InstanceTypeInfo, _ int) string { return *info.InstanceType })
+			instanceNames := lo.Map(uniqInstanceTypes, func(info ec2types.InstanceTypeInfo, _ int) string { return string(info.InstanceType) })
 
 			// Define NodePool that has minValues in multiple requirements.
 			nodePool = coretest.NodePool(corev1beta1.NodePool{
@@ -478,8 +684,8 @@ var _ = Describe("CloudProvider", func() {
 			uniqueInstanceTypes, uniqueInstanceFamilies := sets.Set[string]{}, sets.Set[string]{}
 			for _, launchTemplateConfig := range createFleetInput.LaunchTemplateConfigs {
 				for _, override := range launchTemplateConfig.Overrides {
-					uniqueInstanceTypes.Insert(*override.InstanceType)
-					uniqueInstanceFamilies.Insert(strings.Split(*override.InstanceType, ".")[0])
+					uniqueInstanceTypes.Insert(string(override.InstanceType))
+					uniqueInstanceFamilies.Insert(strings.Split(string(override.InstanceType), ".")[0])
 				}
 			}
 			// Ensure that there are at least minimum number of unique instance types as per the requirement in the CreateFleet request.
@@ -492,7 +698,7 @@ var _ = Describe("CloudProvider", func() {
 		var armAMIID, amdAMIID string
 		var validSecurityGroup string
 		var selectedInstanceType *corecloudproivder.InstanceType
-		var instance *ec2.Instance
+		var instance *ec2types.Instance
 		var validSubnet1 string
 		var validSubnet2 string
 		BeforeEach(func() {
@@ -501,16 +707,16 @@ var _ = Describe("CloudProvider", func() {
 			validSubnet1 = fake.SubnetID()
 			validSubnet2 = fake.SubnetID()
 			awsEnv.SSMAPI.GetParameterOutput = &ssm.GetParameterOutput{
-				Parameter: &ssm.Parameter{Value: aws.String(armAMIID)},
+				Parameter: &ssmtypes.Parameter{Value: aws.String(armAMIID)},
 			}
 			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
-				Images: []*ec2.Image{
+				Images: []ec2types.Image{
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String(armAMIID),
-						Architecture: aws.String("arm64"),
+						Architecture: "arm64",
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
-						Tags: []*ec2.Tag{
+						Tags: []ec2types.Tag{
 							{
 								Key:   aws.String("ami-key-1"),
 								Value: aws.String("ami-value-1"),
@@ -520,9 +726,9 @@ var _ = Describe("CloudProvider", func() {
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String(amdAMIID),
-						Architecture: aws.String("x86_64"),
+						Architecture: "x86_64",
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
-						Tags: []*ec2.Tag{
+						Tags: []ec2types.Tag{
 							{
 								Key:   aws.String("ami-key-2"),
 								Value: aws.String("ami-value-2"),
@@ -532,11 +738,11 @@ var _ = Describe("CloudProvider", func() {
 				},
 			})
 			awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{
-				SecurityGroups: []*ec2.SecurityGroup{
+				SecurityGroups: []ec2types.SecurityGroup{
 					{
 						GroupId:   aws.String(validSecurityGroup),
 						GroupName: aws.String("test-securitygroup"),
-						Tags: []*ec2.Tag{
+						Tags: []ec2types.Tag{
 							{
 								Key:   aws.String("sg-key"),
 								Value: aws.String("sg-value"),
@@ -546,11 +752,11 @@ var _ = Describe("CloudProvider", func() {
 				},
 			})
 			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{
-				Subnets: []*ec2.Subnet{
+				Subnets: []ec2types.Subnet{
 					{
 						SubnetId:         aws.String(validSubnet1),
 						AvailabilityZone: aws.String("zone-1"),
-						Tags: []*ec2.Tag{
+						Tags: []ec2types.Tag{
 							{
 								Key:   aws.String("sn-key-1"),
 								Value: aws.String("sn-value-1"),
@@ -560,7 +766,7 @@ var _ = Describe("CloudProvider", func() {
 					{
 						SubnetId:         aws.String(validSubnet2),
 						AvailabilityZone: aws.String("zone-2"),
-						Tags: []*ec2.Tag{
+						Tags: []ec2types.Tag{
 							{
 								Key:   aws.String("sn-key-2"),
 								Value: aws.String("sn-value-2"),
@@ -575,23 +781,24 @@ var _ = Describe("CloudProvider", func() {
 			selectedInstanceType = instanceTypes[0]
 
 			// Create the instance we want returned from the EC2 API
-			instance = &ec2.Instance{
+			instance = &ec2types.Instance{
 				ImageId:               aws.String(armAMIID),
-				InstanceType:          aws.String(selectedInstanceType.Name),
+				InstanceType:          ec2types.InstanceType(selectedInstanceType.Name),
 				SubnetId:              aws.String(validSubnet1),
 				SpotInstanceRequestId: aws.String(coretest.RandomName()),
-				State: &ec2.InstanceState{
-					Name: aws.String(ec2.InstanceStateNameRunning),
+				State: &ec2types.InstanceState{
+					Name: ec2types.InstanceStateNameRunning,
 				},
 				InstanceId: aws.String(fake.InstanceID()),
-				Placement: &ec2.Placement{
+				Placement: &ec2types.Placement{
 					AvailabilityZone: aws.String("test-zone-1a"),
 				},
-				SecurityGroups: []*ec2.GroupIdentifier{{GroupId: aws.String(validSecurityGroup)}},
+				SecurityGroups: []ec2types.GroupIdentifier{{GroupId: aws.String(validSecurityGroup)}},
 			}
-			awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
-				Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{instance}}},
-			})
+			reservations := []ec2types.Reservation{{Instances: []ec2types.Instance{*instance}}}
+			// the instance is modified by the tests after the output is set
+			instance = &reservations[0].Instances[0]
+			awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{Reservations: reservations})
 			nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
 				v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
 				v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
@@ -615,6 +822,51 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(drifted).To(BeEmpty())
 		})
+		It("should return drifted if the NodeClaim was marked for replacement on a rebalance recommendation", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1beta1.AnnotationRebalanceRecommended: time.Now().UTC().Format(time.RFC3339),
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.RebalanceRecommendationDrift))
+		})
+		It("should return drifted if the NodeClaim was marked for replacement on a scheduled maintenance event", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1beta1.AnnotationScheduledMaintenance: time.Now().Add(time.Hour * 24).UTC().Format(time.RFC3339),
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.ScheduledMaintenanceDrift))
+		})
+		It("should return drifted if the NodeClaim was marked for replacement on impaired status checks", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1beta1.AnnotationInstanceStatusImpaired: time.Now().UTC().Format(time.RFC3339),
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.InstanceStatusImpairedDrift))
+		})
+		It("should return drifted if the NodeClaim was annotated to be replaced", func() {
+			nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+				v1beta1.AnnotationReplace: "true",
+			})
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.ReplacementRequestedDrift))
+		})
+		It("should return drifted if the node was annotated to be replaced", func() {
+			node := coretest.Node(coretest.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{v1beta1.AnnotationReplace: "true"},
+				},
+				ProviderID: nodeClaim.Status.ProviderID,
+			})
+			ExpectApplied(ctx, env.Client, node)
+			nodeClaim.Status.NodeName = node.Name
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.ReplacementRequestedDrift))
+		})
 		It("should return drifted if the AMI is not valid", func() {
 			// Instance is a reference to what we return in the GetInstances call
 			instance.ImageId = aws.String(fake.ImageID())
@@ -626,7 +878,7 @@ var _ = Describe("CloudProvider", func() {
 			// Instance is a reference to what we return in the GetInstances call
 			instance.ImageId = aws.String(fake.ImageID())
 			instance.SubnetId = aws.String(fake.SubnetID())
-			instance.SecurityGroups = []*ec2.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}}
+			instance.SecurityGroups = []ec2types.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}}
 			// Assign a fake hash
 			nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
 				v1beta1.AnnotationEC2NodeClassHash: "abcdefghijkl",
@@ -644,7 +896,7 @@ var _ = Describe("CloudProvider", func() {
 		})
 		It("should return an error if subnets are empty", func() {
 			awsEnv.SubnetCache.Flush()
-			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{}})
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{}})
 			_, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 			Expect(err).To(HaveOccurred())
 		})
@@ -654,29 +906,37 @@ var _ = Describe("CloudProvider", func() {
 			Expect(isDrifted).To(BeEmpty())
 		})
 		It("should return an error if the security groups are empty", func() {
-			awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []*ec2.SecurityGroup{}})
+			awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: []ec2types.SecurityGroup{}})
 			// Instance is a reference to what we return in the GetInstances call
-			instance.SecurityGroups = []*ec2.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}}
+			instance.SecurityGroups = []ec2types.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}}
 			_, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 			Expect(err).To(HaveOccurred())
 		})
 		It("should return drifted if the instance security groups doesn't match the discovered values", func() {
 			// Instance is a reference to what we return in the GetInstances call
-			instance.SecurityGroups = []*ec2.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}}
+			instance.SecurityGroups = []ec2types.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}}
 			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.SecurityGroupDrift))
 		})
+		It("should not return drifted if the instance security groups don't match when they are updated in place", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InPlaceSecurityGroupsUpdate: lo.ToPtr(true)}))
+			instance.SecurityGroups = []ec2types.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}}
+			instance.NetworkInterfaces = []ec2types.InstanceNetworkInterface{{NetworkInterfaceId: aws.String("eni-test1")}}
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
 		It("should return drifted if there are more instance security groups present than in the discovered values", func() {
 			// Instance is a reference to what we return in the GetInstances call
-			instance.SecurityGroups = []*ec2.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}, {GroupId: aws.String(validSecurityGroup)}}
+			instance.SecurityGroups = []ec2types.GroupIdentifier{{GroupId: aws.String(fake.SecurityGroupID())}, {GroupId: aws.String(validSecurityGroup)}}
 			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.SecurityGroupDrift))
 		})
 		It("should return drifted if more security groups are present than instance security groups then discovered from nodeclass", func() {
 			awsEnv.EC2API.DescribeSecurityGroupsOutput.Set(&ec2.DescribeSecurityGroupsOutput{
-				SecurityGroups: []*ec2.SecurityGroup{
+				SecurityGroups: []ec2types.SecurityGroup{
 					{
 						GroupId:   aws.String(validSecurityGroup),
 						GroupName: aws.String("test-securitygroup"),
@@ -696,6 +956,44 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(BeEmpty())
 		})
+		It("should return drifted if the instance profile was replaced", func() {
+			nodeClass.Status.InstanceProfile = "test-instance-profile"
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.IamInstanceProfile = &ec2types.IamInstanceProfile{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/other-instance-profile")}
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.InstanceProfileDrift))
+		})
+		It("should not return drifted if the instance profile matches", func() {
+			nodeClass.Status.InstanceProfile = "test-instance-profile"
+			ExpectApplied(ctx, env.Client, nodeClass)
+			instance.IamInstanceProfile = &ec2types.IamInstanceProfile{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/karpenter/test-instance-profile")}
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
+		It("should return drifted if the instance metadata options were modified", func() {
+			instance.MetadataOptions = &ec2types.InstanceMetadataOptionsResponse{
+				HttpEndpoint:            ec2types.InstanceMetadataEndpointState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPEndpoint)),
+				HttpProtocolIpv6:        ec2types.InstanceMetadataProtocolState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPProtocolIPv6)),
+				HttpPutResponseHopLimit: aws.Int32(int32(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit))),
+				HttpTokens:              ec2types.HttpTokensStateOptional,
+			}
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(Equal(cloudprovider.MetadataOptionsDrift))
+		})
+		It("should not return drifted if the instance metadata options match", func() {
+			instance.MetadataOptions = &ec2types.InstanceMetadataOptionsResponse{
+				HttpEndpoint:            ec2types.InstanceMetadataEndpointState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPEndpoint)),
+				HttpProtocolIpv6:        ec2types.InstanceMetadataProtocolState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPProtocolIPv6)),
+				HttpPutResponseHopLimit: aws.Int32(int32(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit))),
+				HttpTokens:              ec2types.HttpTokensState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPTokens)),
+			}
+			isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isDrifted).To(BeEmpty())
+		})
 		It("should error if the NodeClaim doesn't have the instance-type label", func() {
 			delete(nodeClaim.Labels, v1.LabelInstanceTypeStable)
 			_, err := cloudProvider.IsDrifted(ctx, nodeClaim)
@@ -709,7 +1007,7 @@ var _ = Describe("CloudProvider", func() {
 		})
 		It("should error if the underlying NodeClaim doesn't exist", func() {
 			awsEnv.EC2API.DescribeInstancesBehavior.Output.Set(&ec2.DescribeInstancesOutput{
-				Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{}}},
+				Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{}}},
 			})
 			_, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 			Expect(err).To(HaveOccurred())
@@ -721,6 +1019,69 @@ var _ = Describe("CloudProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
 		})
+		Context("Bottlerocket In-Place Updates", func() {
+			var node *v1.Node
+			BeforeEach(func() {
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
+					Images: []ec2types.Image{
+						{
+							Name:         aws.String("bottlerocket-aws-k8s-1.29-aarch64-v1.20.0-7d2d3a9c"),
+							ImageId:      aws.String(armAMIID),
+							Architecture: "arm64",
+							CreationDate: aws.String("2022-08-15T12:00:00Z"),
+						},
+						{
+							Name:         aws.String("bottlerocket-aws-k8s-1.29-x86_64-v1.20.0-7d2d3a9c"),
+							ImageId:      aws.String(amdAMIID),
+							Architecture: "x86_64",
+							CreationDate: aws.String("2022-08-15T12:00:00Z"),
+						},
+					},
+				})
+				nodeClass.Spec.AMIFamily = aws.String(v1beta1.AMIFamilyBottlerocket)
+				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: armAMIID}, {ID: amdAMIID}}
+				nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{
+					v1beta1.AnnotationBottlerocketUpdateStrategy: v1beta1.BottlerocketUpdateStrategyInPlace,
+				})
+				nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHash] = nodeClass.Hash()
+				nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHash] = nodeClass.Hash()
+				// The instance was launched with a previous release that is no longer selected
+				instance.ImageId = aws.String(fake.ImageID())
+				node = coretest.Node(coretest.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+				node.Status.NodeInfo = v1.NodeSystemInfo{
+					OSImage:      "Bottlerocket OS 1.19.2 (aws-k8s-1.29)",
+					Architecture: selectedInstanceType.Requirements.Get(v1.LabelArchStable).Any(),
+				}
+				nodeClaim.Status.NodeName = node.Name
+			})
+			It("should not return drifted if a release of the node's variant is selected", func() {
+				ExpectApplied(ctx, env.Client, nodeClass, node)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(BeEmpty())
+			})
+			It("should return drifted if the node is running a different variant", func() {
+				node.Status.NodeInfo.OSImage = "Bottlerocket OS 1.19.2 (aws-k8s-1.28)"
+				ExpectApplied(ctx, env.Client, nodeClass, node)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
+			})
+			It("should return drifted if nodes are replaced on new releases", func() {
+				nodeClass.Annotations[v1beta1.AnnotationBottlerocketUpdateStrategy] = v1beta1.BottlerocketUpdateStrategyReplace
+				ExpectApplied(ctx, env.Client, nodeClass, node)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
+			})
+			It("should return drifted if the node hasn't registered", func() {
+				nodeClaim.Status.NodeName = ""
+				ExpectApplied(ctx, env.Client, nodeClass)
+				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(isDrifted).To(Equal(cloudprovider.AMIDrift))
+			})
+		})
 		Context("Static Drift Detection", func() {
 			DescribeTable("should return drifted if the spec is updated",
 				func(changes v1beta1.EC2NodeClass) {
@@ -738,13 +1099,30 @@ var _ = Describe("CloudProvider", func() {
 					Expect(isDrifted).To(Equal(cloudprovider.NodeClassDrift))
 				},
 				Entry("UserData Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
-				Entry("Tags Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 				Entry("MetadataOptions Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{MetadataOptions: &v1beta1.MetadataOptions{HTTPEndpoint: aws.String("disabled")}}}),
 				Entry("BlockDeviceMappings Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-test-3")}}}}),
 				Entry("Context Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Context: aws.String("context-2")}}),
 				Entry("DetailedMonitoring Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
 				Entry("AMIFamily Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
 			)
+			DescribeTable("should return the field that drifted if the NodeClaim has field hashes",
+				func(reason corecloudproivder.DriftReason, changes v1beta1.EC2NodeClass) {
+					nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationEC2NodeClassFieldHashes: nodeClass.FieldHashes()})
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					Expect(mergo.Merge(nodeClass, changes, mergo.WithOverride))
+					nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1beta1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+
+					ExpectApplied(ctx, env.Client, nodeClass)
+					isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(isDrifted).To(Equal(reason))
+				},
+				Entry("UserData Drift", cloudprovider.UserDataDrift, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
+				Entry("MetadataOptions Drift", cloudprovider.MetadataOptionsDrift, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{MetadataOptions: &v1beta1.MetadataOptions{HTTPEndpoint: aws.String("disabled")}}}),
+				Entry("BlockDeviceMappings Drift", cloudprovider.BlockDeviceMappingsDrift, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-test-3")}}}}),
+				Entry("DetailedMonitoring Drift", cloudprovider.DetailedMonitoringDrift, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
+				Entry("AMIFamily Drift", cloudprovider.AMIFamilyDrift, v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
+			)
 			DescribeTable("should not return drifted if dynamic fields are updated",
 				func(changes v1beta1.EC2NodeClass) {
 					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -763,14 +1141,41 @@ var _ = Describe("CloudProvider", func() {
 				Entry("AMI Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMISelectorTerms: []v1beta1.AMISelectorTerm{{Tags: map[string]string{"ami-key-1": "ami-value-1"}}}}}),
 				Entry("Subnet Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"sn-key-1": "sn-value-1"}}}}}),
 				Entry("SecurityGroup Drift", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{SecurityGroupSelectorTerms: []v1beta1.SecurityGroupSelectorTerm{{Tags: map[string]string{"sg-key": "sg-value"}}}}}),
+				Entry("Tags", v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 			)
+			Context("In-Place Metadata Options Update", func() {
+				BeforeEach(func() {
+					ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InPlaceMetadataOptionsUpdate: lo.ToPtr(true)}))
+					instance.MetadataOptions = &ec2types.InstanceMetadataOptionsResponse{
+						HttpEndpoint:            ec2types.InstanceMetadataEndpointState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPEndpoint)),
+						HttpProtocolIpv6:        ec2types.InstanceMetadataProtocolState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPProtocolIPv6)),
+						HttpPutResponseHopLimit: aws.Int32(int32(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit))),
+						HttpTokens:              ec2types.HttpTokensState(lo.FromPtr(nodeClass.Spec.MetadataOptions.HTTPTokens)),
+					}
+				})
+				It("should not return drifted if only metadata options are updated", func() {
+					nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit = aws.Int64(1)
+					nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1beta1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(isDrifted).To(BeEmpty())
+				})
+				It("should return drifted if metadata options and other static fields are updated", func() {
+					nodeClass.Spec.MetadataOptions.HTTPPutResponseHopLimit = aws.Int64(1)
+					nodeClass.Spec.UserData = aws.String("userdata-test-2")
+					nodeClass.Annotations = lo.Assign(nodeClass.Annotations, map[string]string{v1beta1.AnnotationEC2NodeClassHash: nodeClass.Hash()})
+					ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+					isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(isDrifted).To(Equal(cloudprovider.NodeClassDrift))
+				})
+			})
 			It("should not return drifted if karpenter.k8s.aws/ec2nodeclass-hash annotation is not present on the NodeClaim", func() {
 				nodeClaim.Annotations = map[string]string{
 					v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
 				}
-				nodeClass.Spec.Tags = map[string]string{
-					"Test Key": "Test Value",
-				}
+				nodeClass.Spec.UserData = aws.String("userdata-test-2")
 				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 				Expect(err).NotTo(HaveOccurred())
@@ -799,9 +1204,7 @@ var _ = Describe("CloudProvider", func() {
 					v1beta1.AnnotationEC2NodeClassHashVersion: "test-hash-version-2",
 				}
 				// should trigger drift
-				nodeClass.Spec.Tags = map[string]string{
-					"Test Key": "Test Value",
-				}
+				nodeClass.Spec.UserData = aws.String("userdata-test-2")
 				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 				Expect(err).NotTo(HaveOccurred())
@@ -816,9 +1219,7 @@ var _ = Describe("CloudProvider", func() {
 					v1beta1.AnnotationEC2NodeClassHash: "test-hash-222222",
 				}
 				// should trigger drift
-				nodeClass.Spec.Tags = map[string]string{
-					"Test Key": "Test Value",
-				}
+				nodeClass.Spec.UserData = aws.String("userdata-test-2")
 				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 				isDrifted, err := cloudProvider.IsDrifted(ctx, nodeClaim)
 				Expect(err).NotTo(HaveOccurred())
@@ -842,12 +1243,12 @@ var _ = Describe("CloudProvider", func() {
 			foundNonGPULT := false
 			for _, v := range input.LaunchTemplateConfigs {
 				for _, ov := range v.Overrides {
-					if *ov.InstanceType == "m5.large" {
+					if string(ov.InstanceType) == "m5.large" {
 						foundNonGPULT = true
 						Expect(v.Overrides).To(ContainElements(
-							&ec2.FleetLaunchTemplateOverridesRequest{SubnetId: aws.String("subnet-test1"), ImageId: ov.ImageId, InstanceType: aws.String("m5.large"), AvailabilityZone: aws.String("test-zone-1a")},
-							&ec2.FleetLaunchTemplateOverridesRequest{SubnetId: aws.String("subnet-test2"), ImageId: ov.ImageId, InstanceType: aws.String("m5.large"), AvailabilityZone: aws.String("test-zone-1b")},
-							&ec2.FleetLaunchTemplateOverridesRequest{SubnetId: aws.String("subnet-test3"), ImageId: ov.ImageId, InstanceType: aws.String("m5.large"), AvailabilityZone: aws.String("test-zone-1c")},
+							&ec2types.FleetLaunchTemplateOverridesRequest{SubnetId: aws.String("subnet-test1"), ImageId: ov.ImageId, InstanceType: "m5.large", AvailabilityZone: aws.String("test-zone-1a")},
+							&ec2types.FleetLaunchTemplateOverridesRequest{SubnetId: aws.String("subnet-test2"), ImageId: ov.ImageId, InstanceType: "m5.large", AvailabilityZone: aws.String("test-zone-1b")},
+							&ec2types.FleetLaunchTemplateOverridesRequest{SubnetId: aws.String("subnet-test3"), ImageId: ov.ImageId, InstanceType: "m5.large", AvailabilityZone: aws.String("test-zone-1c")},
 						))
 					}
 				}
@@ -855,11 +1256,11 @@ var _ = Describe("CloudProvider", func() {
 			Expect(foundNonGPULT).To(BeTrue())
 		})
 		It("should launch instances into subnet with the most available IP addresses", func() {
-			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
-				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(10),
-					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
-				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(100),
-					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(10),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(100),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-1a"}})
@@ -869,11 +1270,11 @@ var _ = Describe("CloudProvider", func() {
 			Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-2"))
 		})
 		It("should launch instances into subnet with the most available IP addresses in-between cache refreshes", func() {
-			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
-				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(10),
-					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
-				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(11),
-					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(10),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(11),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
 			nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{MaxPods: aws.Int32(1)}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -892,9 +1293,9 @@ var _ = Describe("CloudProvider", func() {
 			Expect(fake.SubnetsFromFleetRequest(createFleetInput)).To(ConsistOf("test-subnet-1"))
 		})
 		It("should update in-flight IPs when a CreateFleet error occurs", func() {
-			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
-				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(10),
-					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(10),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
 			}})
 			pod1 := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-1a"}})
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass, pod1)
@@ -903,11 +1304,11 @@ var _ = Describe("CloudProvider", func() {
 			Expect(len(bindings)).To(Equal(0))
 		})
 		It("should launch instances into subnets that are excluded by another NodePool", func() {
-			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
-				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(10),
-					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
-				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1b"), AvailableIpAddressCount: aws.Int64(100),
-					Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("test-subnet-1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(10),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-1")}}},
+				{SubnetId: aws.String("test-subnet-2"), AvailabilityZone: aws.String("test-zone-1b"), AvailableIpAddressCount: aws.Int32(100),
+					Tags: []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-2")}}},
 			}})
 			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-1"}}}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -1022,4 +1423,125 @@ var _ = Describe("CloudProvider", func() {
 			Expect(lo.Keys(cloudProviderNodeClaim.Status.Allocatable)).ToNot(ContainElement(v1beta1.ResourceEFA))
 		})
 	})
+	Context("Stopped Instances", func() {
+		var instanceID string
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				StoppedInstancePoolSize: lo.ToPtr(1),
+			}))
+			instanceID = fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, runningInstance(instanceID, nodePool.Name))
+			nodeClaim.Annotations = map[string]string{
+				v1beta1.AnnotationEC2NodeClassHash:    nodeClass.Hash(),
+				corev1beta1.NodePoolHashAnnotationKey: nodePool.Hash(),
+			}
+			nodeClaim.Status.ProviderID = fmt.Sprintf("aws:///test-zone-1a/%s", instanceID)
+			nodeClaim.StatusConditions().MarkTrue(corev1beta1.Registered)
+			nodeClaim.StatusConditions().MarkTrue(corev1beta1.Initialized)
+			nodePool.Spec.Disruption.ConsolidationPolicy = corev1beta1.ConsolidationPolicyWhenUnderutilized
+		})
+		It("should stop instances of NodeClaims that were consolidated", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+
+			// Deleting the NodeClaim again doesn't terminate the stopped instance
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should stop instances of NodeClaims that were empty", func() {
+			nodePool.Spec.Disruption.ConsolidationPolicy = corev1beta1.ConsolidationPolicyWhenEmpty
+			nodeClaim.StatusConditions().MarkTrue(corev1beta1.Empty)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should terminate instances of NodeClaims that weren't empty when their NodePool only consolidates empty nodes", func() {
+			nodePool.Spec.Disruption.ConsolidationPolicy = corev1beta1.ConsolidationPolicyWhenEmpty
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should terminate instances of NodeClaims that never initialized", func() {
+			nodeClaim.StatusConditions().MarkFalse(corev1beta1.Initialized, "NotReady", "Node not ready")
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should terminate instances that were launched with a different NodePool", func() {
+			nodeClaim.Annotations[corev1beta1.NodePoolHashAnnotationKey] = "different-hash"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should terminate instances of NodeClaims that are drifted", func() {
+			nodeClaim.StatusConditions().MarkTrue(corev1beta1.Drifted)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should terminate instances of NodeClaims that are marked for replacement", func() {
+			nodeClaim.Annotations[v1beta1.AnnotationInstanceStatusImpaired] = time.Now().UTC().Format(time.RFC3339)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should terminate instances that were launched with a different EC2NodeClass", func() {
+			nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHash] = "different-hash"
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should terminate instances when the pool for the NodePool is full", func() {
+			stoppedID := fake.InstanceID()
+			stopped := runningInstance(stoppedID, nodePool.Name)
+			stopped.State = &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped}
+			stopped.Tags = append(stopped.Tags, ec2types.Tag{Key: aws.String(v1beta1.TagStoppedAt), Value: aws.String(time.Now().UTC().Format(time.RFC3339))})
+			awsEnv.EC2API.Instances.Store(stoppedID, stopped)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+		})
+		It("should terminate instances when the stopped instance pool is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass, nodeClaim)
+			Expect(cloudProvider.Delete(ctx, nodeClaim)).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+	})
 })
+
+func runningInstance(id, nodePoolName string) *ec2types.Instance {
+	return &ec2types.Instance{
+		InstanceId:   aws.String(id),
+		InstanceType: "m5.large",
+		State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+		LaunchTime:   aws.Time(time.Now().Add(-time.Hour)),
+		Tags: []ec2types.Tag{
+			{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+			{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String(nodePoolName)},
+		},
+	}
+}
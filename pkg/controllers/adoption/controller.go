@@ -0,0 +1,180 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	provisioningscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/events"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// Controller creates NodeClaims for nodes that weren't launched by Karpenter and are annotated with the NodePool that
+// should manage them. The NodeClaim is annotated with the instance ID so that the cloudprovider adopts the instance
+// instead of launching a new one. Once the NodeClaim registers with the node, the node is drifted, consolidated and
+// expired like any other node in the NodePool.
+type Controller struct {
+	kubeClient       client.Client
+	recorder         events.Recorder
+	cloudProvider    cloudprovider.CloudProvider
+	instanceProvider *instance.Provider
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, cloudProvider cloudprovider.CloudProvider, instanceProvider *instance.Provider) corecontroller.Controller {
+	return corecontroller.Typed[*v1.Node](kubeClient, &Controller{
+		kubeClient:       kubeClient,
+		recorder:         recorder,
+		cloudProvider:    cloudProvider,
+		instanceProvider: instanceProvider,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "node.adoption"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, node *v1.Node) (reconcile.Result, error) {
+	nodePoolName, ok := node.Annotations[v1beta1.AnnotationAdoptNodePool]
+	if !ok || !node.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	if _, ok = node.Labels[corev1beta1.NodePoolLabelKey]; ok {
+		return reconcile.Result{}, nil
+	}
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingFields{"status.providerID": node.Spec.ProviderID}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	if len(nodeClaimList.Items) > 0 {
+		return reconcile.Result{}, nil
+	}
+	id, err := utils.ParseInstanceID(node.Spec.ProviderID)
+	if err != nil {
+		logging.FromContext(ctx).With("provider-id", node.Spec.ProviderID).Errorf("failed to parse instance ID, %w", err)
+		c.recorder.Publish(AdoptionFailed(node, fmt.Sprintf("parsing instance id, %s", err)))
+		return reconcile.Result{}, nil
+	}
+	nodePool := &corev1beta1.NodePool{}
+	if err = c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			c.recorder.Publish(AdoptionFailed(node, fmt.Sprintf("nodepool %q not found", nodePoolName)))
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("getting nodepool, %w", err)
+	}
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err = c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		if errors.IsNotFound(err) {
+			c.recorder.Publish(AdoptionFailed(node, fmt.Sprintf("ec2nodeclass %q not found", nodePool.Spec.Template.Spec.NodeClassRef.Name)))
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("getting ec2nodeclass, %w", err)
+	}
+	i, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		if cloudprovider.IsNodeClaimNotFoundError(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("getting instance, %w", err)
+	}
+	if err = instance.Adoptable(i, nodeClass, nodePool.Name); err != nil {
+		c.recorder.Publish(AdoptionFailed(node, err.Error()))
+		return reconcile.Result{}, nil
+	}
+	nodeClaim, err := c.nodeClaim(ctx, nodePool, node, i)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if nodeClaim == nil {
+		c.recorder.Publish(AdoptionFailed(node, fmt.Sprintf("instance type %q in %q isn't allowed by nodepool %q", i.Type, i.Zone, nodePool.Name)))
+		return reconcile.Result{}, nil
+	}
+	// NodeClaims are named after the node so that a NodeClaim that hasn't launched yet isn't created again
+	if err = c.kubeClient.Create(ctx, nodeClaim); err != nil {
+		if errors.IsAlreadyExists(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("creating nodeclaim, %w", err)
+	}
+	logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "nodepool", nodePool.Name, "id", i.ID).Infof("created nodeclaim to adopt node")
+	c.recorder.Publish(NodeAdopted(node, nodeClaim))
+	return reconcile.Result{}, nil
+}
+
+// nodeClaim returns a NodeClaim in the NodePool for the instance, or nil if the NodePool doesn't allow the instance's
+// type and zone
+func (c *Controller) nodeClaim(ctx context.Context, nodePool *corev1beta1.NodePool, node *v1.Node, i *instance.Instance) (*corev1beta1.NodeClaim, error) {
+	nct := provisioningscheduling.NewNodeClaimTemplate(nodePool)
+	if err := nct.Requirements.Compatible(scheduling.NewLabelRequirements(map[string]string{
+		v1.LabelInstanceTypeStable:       i.Type,
+		v1.LabelTopologyZone:             i.Zone,
+		corev1beta1.CapacityTypeLabelKey: i.CapacityType,
+	}), scheduling.AllowUndefinedWellKnownLabels); err != nil {
+		return nil, nil
+	}
+	nct.Requirements.Add(scheduling.NewLabelRequirements(map[string]string{
+		v1.LabelTopologyZone:             i.Zone,
+		corev1beta1.CapacityTypeLabelKey: i.CapacityType,
+	}).Values()...)
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance types, %w", err)
+	}
+	nct.InstanceTypeOptions = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool { return it.Name == i.Type })
+	if len(nct.InstanceTypeOptions) == 0 {
+		return nil, nil
+	}
+	nodeClaim := nct.ToNodeClaim(nodePool)
+	nodeClaim.GenerateName = ""
+	nodeClaim.Name = node.Name
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationAdoptInstanceID: i.ID})
+	// Taints are synced from the NodeClaim to the node when it registers, so the NodePool's taints aren't applied to
+	// nodes that are already running pods
+	nodeClaim.Spec.Taints = nil
+	nodeClaim.Spec.StartupTaints = nil
+	return nodeClaim, nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&v1.Node{}).
+			WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+				_, ok := o.GetAnnotations()[v1beta1.AnnotationAdoptNodePool]
+				return ok
+			})),
+	)
+}
@@ -0,0 +1,44 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func NodeAdopted(node *v1.Node, nodeClaim *v1beta1.NodeClaim) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           v1.EventTypeNormal,
+		Reason:         "NodeAdopted",
+		Message:        fmt.Sprintf("Created NodeClaim %s in NodePool %s to adopt the node", nodeClaim.Name, nodeClaim.Labels[v1beta1.NodePoolLabelKey]),
+		DedupeValues:   []string{string(node.UID)},
+	}
+}
+
+func AdoptionFailed(node *v1.Node, reason string) events.Event {
+	return events.Event{
+		InvolvedObject: node,
+		Type:           v1.EventTypeWarning,
+		Reason:         "AdoptionFailed",
+		Message:        fmt.Sprintf("Unable to adopt the node, %s", reason),
+		DedupeValues:   []string{string(node.UID), reason},
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/adoption"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var recorder *coretest.EventRecorder
+var adoptionController corecontroller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Adoption")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	recorder = coretest.NewEventRecorder()
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, recorder,
+		env.Client, awsEnv.PricingProvider)
+	adoptionController = adoption.NewController(env.Client, recorder, cloudProvider, awsEnv.InstanceProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Adoption", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	var nodePool *corev1beta1.NodePool
+	var node *v1.Node
+	var instanceID string
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Status: v1beta1.EC2NodeClassStatus{
+				AMIs:           []v1beta1.AMI{{ID: "ami-test1"}},
+				Subnets:        []v1beta1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a"}},
+				SecurityGroups: []v1beta1.SecurityGroup{{ID: "sg-test1"}},
+			},
+		})
+		nodePool = coretest.NodePool(corev1beta1.NodePool{
+			Spec: corev1beta1.NodePoolSpec{
+				Template: corev1beta1.NodeClaimTemplate{
+					Spec: corev1beta1.NodeClaimSpec{
+						NodeClassRef: &corev1beta1.NodeClassReference{
+							Name: nodeClass.Name,
+						},
+						Taints: []v1.Taint{{Key: "example.com/taint", Effect: v1.TaintEffectNoExecute}},
+					},
+				},
+			},
+		})
+		instanceID = fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, &ec2types.Instance{
+			InstanceId:     aws.String(instanceID),
+			InstanceType:   "m5.large",
+			ImageId:        aws.String("ami-test1"),
+			SubnetId:       aws.String("subnet-test1"),
+			SecurityGroups: []ec2types.GroupIdentifier{{GroupId: aws.String("sg-test1")}},
+			State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+			Placement:      &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			LaunchTime:     aws.Time(time.Now().Add(-24 * time.Hour)),
+		})
+		node = coretest.Node(coretest.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.AnnotationAdoptNodePool: nodePool.Name},
+			},
+			ProviderID: fmt.Sprintf("aws:///test-zone-1a/%s", instanceID),
+		})
+	})
+	It("should create a nodeclaim that adopts the node's instance", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, node)
+
+		ExpectReconcileSucceeded(ctx, adoptionController, client.ObjectKeyFromObject(node))
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(1))
+		Expect(nodeClaims[0].Name).To(Equal(node.Name))
+		Expect(nodeClaims[0].Labels).To(HaveKeyWithValue(corev1beta1.NodePoolLabelKey, nodePool.Name))
+		Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1beta1.AnnotationAdoptInstanceID, instanceID))
+		Expect(nodeClaims[0].Spec.Taints).To(BeEmpty())
+		Expect(nodeClaims[0].Spec.Requirements).To(ContainElement(corev1beta1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"m5.large"}},
+		}))
+		Expect(recorder.Calls("NodeAdopted")).To(Equal(1))
+
+		// The nodeclaim isn't created again before it has launched
+		ExpectReconcileSucceeded(ctx, adoptionController, client.ObjectKeyFromObject(node))
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+	})
+	It("should not adopt nodes that are already managed by a nodepool", func() {
+		node.Labels[corev1beta1.NodePoolLabelKey] = nodePool.Name
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, node)
+
+		ExpectReconcileSucceeded(ctx, adoptionController, client.ObjectKeyFromObject(node))
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+	})
+	It("should fail to adopt nodes when the nodepool doesn't exist", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, node)
+
+		ExpectReconcileSucceeded(ctx, adoptionController, client.ObjectKeyFromObject(node))
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		Expect(recorder.Calls("AdoptionFailed")).To(Equal(1))
+	})
+	It("should fail to adopt nodes whose instance wasn't launched with the ec2nodeclass's security groups", func() {
+		nodeClass.Status.SecurityGroups = []v1beta1.SecurityGroup{{ID: "sg-test2"}}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, node)
+
+		ExpectReconcileSucceeded(ctx, adoptionController, client.ObjectKeyFromObject(node))
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		Expect(recorder.Calls("AdoptionFailed")).To(Equal(1))
+	})
+	It("should fail to adopt nodes whose instance type isn't allowed by the nodepool", func() {
+		nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"m5.xlarge"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool, node)
+
+		ExpectReconcileSucceeded(ctx, adoptionController, client.ObjectKeyFromObject(node))
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		Expect(recorder.Calls("AdoptionFailed")).To(Equal(1))
+	})
+})
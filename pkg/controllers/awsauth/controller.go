@@ -0,0 +1,174 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awsauth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap in kube-system that maps IAM roles to Kubernetes identities
+	ConfigMapName = "aws-auth"
+	// MapRolesKey is the key in the ConfigMap data that holds the role mappings
+	MapRolesKey = "mapRoles"
+	// NodeUsername is the username of nodes in the role mappings
+	NodeUsername = "system:node:{{EC2PrivateDNSName}}"
+)
+
+// Controller makes sure that the node role of every EC2NodeClass is mapped in the aws-auth ConfigMap, so that nodes
+// can join clusters that authenticate with the ConfigMap instead of EKS access entries. Mappings are only added, since
+// removing a mapping would prevent running nodes from authenticating. The ConfigMap is read and written directly
+// through the API server rather than through the manager's cache so that Karpenter doesn't need to watch ConfigMaps
+// in kube-system.
+type Controller struct {
+	kubeClient          client.Client
+	kubernetesInterface kubernetes.Interface
+	accountProvider     *account.Provider
+}
+
+func NewController(kubeClient client.Client, kubernetesInterface kubernetes.Interface, accountProvider *account.Provider) *Controller {
+	return &Controller{
+		kubeClient:          kubeClient,
+		kubernetesInterface: kubernetesInterface,
+		accountProvider:     accountProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "awsauth"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	// Windows nodes need an additional group, so roles that are used by any Windows EC2NodeClass are mapped with it
+	roles := map[string]bool{}
+	var errs error
+	for i := range nodeClassList.Items {
+		nodeClass := &nodeClassList.Items[i]
+		if !nodeClass.DeletionTimestamp.IsZero() || nodeClass.Status.InstanceProfile == "" {
+			continue
+		}
+		roleARN, err := c.roleARN(ctx, nodeClass)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("resolving node role for ec2nodeclass %q, %w", nodeClass.Name, err))
+			continue
+		}
+		if roleARN == "" {
+			continue
+		}
+		roles[roleARN] = roles[roleARN] || lo.Contains([]string{v1beta1.AMIFamilyWindows2019, v1beta1.AMIFamilyWindows2022}, lo.FromPtr(nodeClass.Spec.AMIFamily))
+	}
+	if err := c.ensureMappings(ctx, roles); err != nil {
+		errs = multierr.Append(errs, err)
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, errs
+}
+
+// roleARN returns the ARN of the role that's assigned to the instance profile of the EC2NodeClass. The aws-auth
+// ConfigMap doesn't support paths in role ARNs, so the path is removed.
+func (c *Controller) roleARN(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (string, error) {
+	instanceProfile, err := c.accountProvider.For(nodeClass).InstanceProfile.Get(ctx, nodeClass.Status.InstanceProfile)
+	if err != nil {
+		return "", awserrors.IgnoreNotFound(err)
+	}
+	if len(instanceProfile.Roles) == 0 {
+		return "", nil
+	}
+	a, err := arn.Parse(aws.ToString(instanceProfile.Roles[0].Arn))
+	if err != nil {
+		return "", fmt.Errorf("parsing role arn, %w", err)
+	}
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", a.Partition, a.AccountID, aws.ToString(instanceProfile.Roles[0].RoleName)), nil
+}
+
+// ensureMappings adds the roles that aren't mapped yet to the aws-auth ConfigMap. Existing mappings are decoded
+// without a schema so that fields that Karpenter doesn't know about are kept as they are.
+func (c *Controller) ensureMappings(ctx context.Context, roles map[string]bool) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	configMap, err := c.kubernetesInterface.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(ctx, ConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("getting aws-auth configmap, %w", err)
+		}
+		configMap = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName, Namespace: metav1.NamespaceSystem}}
+	}
+	var mappings []map[string]interface{}
+	if err = yaml.Unmarshal([]byte(configMap.Data[MapRolesKey]), &mappings); err != nil {
+		return fmt.Errorf("parsing %s of aws-auth configmap, %w", MapRolesKey, err)
+	}
+	mapped := sets.New(lo.Map(mappings, func(m map[string]interface{}, _ int) string { return fmt.Sprint(m["rolearn"]) })...)
+	missing := sets.List(sets.KeySet(roles).Difference(mapped))
+	if len(missing) == 0 {
+		return nil
+	}
+	for _, roleARN := range missing {
+		groups := []string{"system:bootstrappers", "system:nodes"}
+		if roles[roleARN] {
+			groups = append(groups, "eks:kube-proxy-windows")
+		}
+		mappings = append(mappings, map[string]interface{}{
+			"rolearn":  roleARN,
+			"username": NodeUsername,
+			"groups":   groups,
+		})
+	}
+	data, err := yaml.Marshal(mappings)
+	if err != nil {
+		return fmt.Errorf("encoding %s of aws-auth configmap, %w", MapRolesKey, err)
+	}
+	configMap.Data = lo.Assign(configMap.Data, map[string]string{MapRolesKey: string(data)})
+	if configMap.ResourceVersion == "" {
+		_, err = c.kubernetesInterface.CoreV1().ConfigMaps(metav1.NamespaceSystem).Create(ctx, configMap, metav1.CreateOptions{})
+	} else {
+		_, err = c.kubernetesInterface.CoreV1().ConfigMaps(metav1.NamespaceSystem).Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("updating aws-auth configmap, %w", err)
+	}
+	logging.FromContext(ctx).With("roles", missing).Infof("mapped node roles in aws-auth configmap")
+	return nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
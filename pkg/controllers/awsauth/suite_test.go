@@ -0,0 +1,155 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awsauth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/awsauth"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *awsauth.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AWSAuth")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = awsauth.NewController(env.Client, env.KubernetesInterface, awsEnv.AccountProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	Expect(client.IgnoreNotFound(env.KubernetesInterface.CoreV1().ConfigMaps(metav1.NamespaceSystem).Delete(ctx, awsauth.ConfigMapName, metav1.DeleteOptions{}))).To(Succeed())
+})
+
+var _ = Describe("AWSAuth", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
+			"test-profile": {
+				InstanceProfileName: aws.String("test-profile"),
+				Roles: []iamtypes.Role{{
+					Arn:      aws.String("arn:aws:iam::123456789012:role/nodes/test-role"),
+					RoleName: aws.String("test-role"),
+				}},
+			},
+		}
+	})
+	It("should create the configmap with the node role when it doesn't exist", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		Expect(ExpectMapRoles()).To(ConsistOf(map[string]interface{}{
+			"rolearn":  "arn:aws:iam::123456789012:role/test-role",
+			"username": awsauth.NodeUsername,
+			"groups":   []interface{}{"system:bootstrappers", "system:nodes"},
+		}))
+	})
+	It("should add the node role to the existing mappings and keep them unchanged", func() {
+		existing := `- rolearn: arn:aws:iam::123456789012:role/admin
+  username: admin
+  groups:
+  - system:masters
+  custom: value
+`
+		_, err := env.KubernetesInterface.CoreV1().ConfigMaps(metav1.NamespaceSystem).Create(ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: awsauth.ConfigMapName, Namespace: metav1.NamespaceSystem},
+			Data:       map[string]string{awsauth.MapRolesKey: existing},
+		}, metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		mapRoles := ExpectMapRoles()
+		Expect(mapRoles).To(HaveLen(2))
+		Expect(mapRoles[0]).To(HaveKeyWithValue("custom", "value"))
+		Expect(mapRoles[1]).To(HaveKeyWithValue("rolearn", "arn:aws:iam::123456789012:role/test-role"))
+	})
+	It("should not add the node role again when it's already mapped", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		Expect(ExpectMapRoles()).To(HaveLen(1))
+	})
+	It("should map the node role with the windows group for windows ec2nodeclasses", func() {
+		nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		mapRoles := ExpectMapRoles()
+		Expect(mapRoles).To(HaveLen(1))
+		Expect(mapRoles[0]).To(HaveKeyWithValue("groups", ConsistOf("system:bootstrappers", "system:nodes", "eks:kube-proxy-windows")))
+	})
+	It("should not create the configmap when the instance profile has no role", func() {
+		awsEnv.IAMAPI.InstanceProfiles["test-profile"].Roles = nil
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		_, err := env.KubernetesInterface.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(ctx, awsauth.ConfigMapName, metav1.GetOptions{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+func ExpectMapRoles() []map[string]interface{} {
+	GinkgoHelper()
+	configMap, err := env.KubernetesInterface.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(ctx, awsauth.ConfigMapName, metav1.GetOptions{})
+	Expect(err).ToNot(HaveOccurred())
+	var mapRoles []map[string]interface{}
+	Expect(yaml.Unmarshal([]byte(configMap.Data[awsauth.MapRolesKey]), &mapRoles)).To(Succeed())
+	return mapRoles
+}
@@ -0,0 +1,141 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudwatch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// publishInterval is the period of the published metrics, which matches the standard resolution of CloudWatch metrics
+const publishInterval = time.Minute
+
+// Controller publishes key metrics of the controller to CloudWatch Logs in the embedded metric format, so that CloudWatch
+// extracts them as CloudWatch metrics that dashboards and alarms can use without scraping the Prometheus endpoint.
+// Counters are published as their increase since the previous publish, and histograms as the average of their
+// observations since the previous publish.
+type Controller struct {
+	clk       clock.Clock
+	logsapi   sdk.CloudWatchLogsAPI
+	gatherer  prometheus.Gatherer
+	logStream string
+
+	previous      snapshot
+	streamCreated bool
+}
+
+func NewController(clk clock.Clock, logsapi sdk.CloudWatchLogsAPI, gatherer prometheus.Gatherer, logStream string) *Controller {
+	return &Controller{
+		clk:       clk,
+		logsapi:   logsapi,
+		gatherer:  gatherer,
+		logStream: logStream,
+		previous:  snapshot{},
+	}
+}
+
+func (c *Controller) Name() string {
+	return "cloudwatch.metrics"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	logGroup := options.FromContext(ctx).CloudWatchMetricsLogGroup
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("log-group", logGroup, "log-stream", c.logStream))
+
+	families, err := c.gatherer.Gather()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("gathering metrics, %w", err)
+	}
+	values, current := compute(families, c.previous)
+	now := c.clk.Now()
+	logEvents, err := events(values, options.FromContext(ctx).ClusterName, now)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err = c.ensureLogStream(ctx, logGroup); err != nil {
+		return reconcile.Result{}, err
+	}
+	if _, err = c.logsapi.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(c.logStream),
+		LogEvents: lo.Map(logEvents, func(e string, _ int) cloudwatchlogstypes.InputLogEvent {
+			return cloudwatchlogstypes.InputLogEvent{Message: aws.String(e), Timestamp: aws.Int64(now.UnixMilli())}
+		}),
+	}); err != nil {
+		// the log group or stream is created again if it was deleted while the controller was running
+		if awserrors.IsNotFound(err) {
+			c.streamCreated = false
+		}
+		return reconcile.Result{}, fmt.Errorf("putting log events, %w", err)
+	}
+	// the increases that failed to publish are included in the next publish
+	c.previous = current
+	logging.FromContext(ctx).With("count", len(logEvents)).Debugf("published metrics to cloudwatch")
+	return reconcile.Result{RequeueAfter: publishInterval}, nil
+}
+
+// ensureLogStream creates the log stream of the controller if it doesn't exist. The log group is only created if it
+// doesn't exist either, so that logs:CreateLogGroup isn't needed when the log group is created ahead of time.
+func (c *Controller) ensureLogStream(ctx context.Context, logGroup string) error {
+	if c.streamCreated {
+		return nil
+	}
+	err := c.createLogStream(ctx, logGroup)
+	if awserrors.IsNotFound(err) {
+		if _, err = c.logsapi.CreateLogGroup(ctx, &cloudwatchlogs.CreateLogGroupInput{
+			LogGroupName: aws.String(logGroup),
+		}); awserrors.IgnoreAlreadyExists(err) != nil {
+			return fmt.Errorf("creating log group, %w", err)
+		}
+		logging.FromContext(ctx).Infof("created log group")
+		err = c.createLogStream(ctx, logGroup)
+	}
+	if err != nil {
+		return err
+	}
+	c.streamCreated = true
+	return nil
+}
+
+func (c *Controller) createLogStream(ctx context.Context, logGroup string) error {
+	if _, err := c.logsapi.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(logGroup),
+		LogStreamName: aws.String(c.logStream),
+	}); awserrors.IgnoreAlreadyExists(err) != nil {
+		return fmt.Errorf("creating log stream, %w", err)
+	}
+	return nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,199 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+)
+
+// namespace is the CloudWatch namespace that metrics are published to
+const namespace = "Karpenter"
+
+type kind int
+
+const (
+	gauge kind = iota
+	// counter metrics are published as the increase since the previous publish
+	counter
+	// average metrics are published as the average of the histogram's observations since the previous publish
+	average
+)
+
+// metric is a CloudWatch metric that is computed from the series of a Prometheus metric family
+type metric struct {
+	name   string
+	unit   string
+	family string
+	kind   kind
+	// labels select the series of the family that the metric is computed from
+	labels map[string]string
+	// dimension is the CloudWatch dimension that the series are aggregated by, read from the label of the series
+	dimension string
+	label     string
+}
+
+var metrics = []metric{
+	{name: "PendingPods", unit: "Count", family: "karpenter_pods_state", kind: gauge, labels: map[string]string{"phase": string(v1.PodPending)}},
+	{name: "NodeClaimsLaunched", unit: "Count", family: "karpenter_nodeclaims_launched", kind: counter, dimension: "NodePool", label: "nodepool"},
+	{name: "NodeClaimsTerminated", unit: "Count", family: "karpenter_nodeclaims_terminated", kind: counter, dimension: "NodePool", label: "nodepool"},
+	{name: "NodeLaunchDuration", unit: "Seconds", family: "karpenter_cloudprovider_duration_seconds", kind: average, labels: map[string]string{"method": "Create"}},
+	{name: "InterruptionMessages", unit: "Count", family: "karpenter_interruption_received_messages", kind: counter, dimension: "MessageType", label: "message_type"},
+	{name: "HourlyCostEstimate", unit: "None", family: "karpenter_cost_hourly_estimate", kind: gauge, dimension: "NodePool", label: "nodepool"},
+}
+
+// group is the set of metrics that are published in one log event, since the metrics of an event share its dimensions
+type group struct {
+	dimension string
+	value     string
+}
+
+type metadata struct {
+	Timestamp         int64       `json:"Timestamp"`
+	CloudWatchMetrics []directive `json:"CloudWatchMetrics"`
+}
+
+type directive struct {
+	Namespace  string       `json:"Namespace"`
+	Dimensions [][]string   `json:"Dimensions"`
+	Metrics    []definition `json:"Metrics"`
+}
+
+type definition struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+// snapshot is the value of every counter and histogram series that the metrics are computed from, so that the
+// increase of the series can be computed at the next publish
+type snapshot map[string]float64
+
+// compute returns the values of the metrics, grouped by the dimension that they're published with, and the snapshot of
+// the series that the increases were computed from
+func compute(families []*dto.MetricFamily, previous snapshot) (map[group]map[*metric]float64, snapshot) {
+	byName := lo.SliceToMap(families, func(f *dto.MetricFamily) (string, *dto.MetricFamily) { return f.GetName(), f })
+	values := map[group]map[*metric]float64{}
+	// the counts of the histogram observations that averages are computed from
+	counts := map[group]map[*metric]float64{}
+	current := snapshot{}
+	for i := range metrics {
+		m := &metrics[i]
+		// metrics without a dimension are published even without series, e.g. when no pods are pending
+		if m.dimension == "" && m.kind != average {
+			add(values, group{}, m, 0)
+		}
+		family, ok := byName[m.family]
+		if !ok {
+			continue
+		}
+		for _, series := range family.GetMetric() {
+			labels := lo.SliceToMap(series.GetLabel(), func(l *dto.LabelPair) (string, string) { return l.GetName(), l.GetValue() })
+			if lo.SomeBy(lo.Entries(m.labels), func(e lo.Entry[string, string]) bool { return labels[e.Key] != e.Value }) {
+				continue
+			}
+			g := lo.Ternary(m.dimension == "", group{}, group{dimension: m.dimension, value: labels[m.label]})
+			key := seriesKey(m.family, labels)
+			switch m.kind {
+			case gauge:
+				add(values, g, m, series.GetGauge().GetValue())
+			case counter:
+				current[key] = series.GetCounter().GetValue()
+				add(values, g, m, increase(previous, current, key))
+			case average:
+				current[key+"/sum"] = series.GetHistogram().GetSampleSum()
+				current[key+"/count"] = float64(series.GetHistogram().GetSampleCount())
+				add(values, g, m, increase(previous, current, key+"/sum"))
+				add(counts, g, m, increase(previous, current, key+"/count"))
+			}
+		}
+	}
+	// averages are only published while there were observations, since there's no meaningful value otherwise
+	for g, byMetric := range counts {
+		for m, count := range byMetric {
+			if count == 0 {
+				delete(values[g], m)
+				continue
+			}
+			values[g][m] /= count
+		}
+	}
+	return values, current
+}
+
+// events returns the log events in the embedded metric format, with one event for each group of metrics
+func events(values map[group]map[*metric]float64, clusterName string, timestamp time.Time) ([]string, error) {
+	var result []string
+	for g, byMetric := range values {
+		if len(byMetric) == 0 {
+			continue
+		}
+		dimensions := lo.Ternary(g.dimension == "", []string{"ClusterName"}, []string{"ClusterName", g.dimension})
+		definitions := lo.MapToSlice(byMetric, func(m *metric, _ float64) definition { return definition{Name: m.name, Unit: m.unit} })
+		sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+		document := map[string]any{
+			"_aws": metadata{
+				Timestamp: timestamp.UnixMilli(),
+				CloudWatchMetrics: []directive{{
+					Namespace:  namespace,
+					Dimensions: [][]string{dimensions},
+					Metrics:    definitions,
+				}},
+			},
+			"ClusterName": clusterName,
+		}
+		if g.dimension != "" {
+			document[g.dimension] = g.value
+		}
+		for m, value := range byMetric {
+			document[m.name] = value
+		}
+		raw, err := json.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling log event, %w", err)
+		}
+		result = append(result, string(raw))
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// increase returns the increase of the series since the previous snapshot. Series that weren't in the previous
+// snapshot started at zero, and series that are lower than before were reset when the controller restarted.
+func increase(previous, current snapshot, key string) float64 {
+	if last, ok := previous[key]; ok && current[key] >= last {
+		return current[key] - last
+	}
+	return current[key]
+}
+
+func add(values map[group]map[*metric]float64, g group, m *metric, value float64) {
+	if _, ok := values[g]; !ok {
+		values[g] = map[*metric]float64{}
+	}
+	values[g][m] += value
+}
+
+func seriesKey(family string, labels map[string]string) string {
+	pairs := lo.MapToSlice(labels, func(k, v string) string { return fmt.Sprintf("%s=%q", k, v) })
+	sort.Strings(pairs)
+	return fmt.Sprintf("%s{%s}", family, strings.Join(pairs, ","))
+}
@@ -0,0 +1,156 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudwatch_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cloudwatchlogstypes "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/smithy-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/cloudwatch"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var fakeClock *clock.FakeClock
+var logsapi *fake.CloudWatchLogsAPI
+var registry *prometheus.Registry
+var controller *cloudwatch.Controller
+
+var podsState *prometheus.GaugeVec
+var nodeClaimsLaunched *prometheus.CounterVec
+var cloudProviderDuration *prometheus.HistogramVec
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudWatch")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{CloudWatchMetricsLogGroup: lo.ToPtr("/karpenter/metrics")}))
+	fakeClock = clock.NewFakeClock(time.Now())
+	logsapi = &fake.CloudWatchLogsAPI{}
+})
+
+var _ = BeforeEach(func() {
+	logsapi.Reset()
+	// the metrics are registered with the names of the metrics that the controller publishes
+	registry = prometheus.NewRegistry()
+	podsState = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "karpenter_pods_state"}, []string{"name", "phase"})
+	nodeClaimsLaunched = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "karpenter_nodeclaims_launched"}, []string{"nodepool"})
+	cloudProviderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "karpenter_cloudprovider_duration_seconds"}, []string{"method"})
+	registry.MustRegister(podsState, nodeClaimsLaunched, cloudProviderDuration)
+	controller = cloudwatch.NewController(fakeClock, logsapi, registry, "karpenter-0")
+})
+
+// expectEvents returns the log events that were published by the last call to PutLogEvents
+func expectEvents() []map[string]any {
+	Expect(logsapi.PutLogEventsBehavior.CalledWithInput.Len()).To(BeNumerically(">", 0))
+	input := logsapi.PutLogEventsBehavior.CalledWithInput.Pop()
+	Expect(aws.ToString(input.LogGroupName)).To(Equal("/karpenter/metrics"))
+	Expect(aws.ToString(input.LogStreamName)).To(Equal("karpenter-0"))
+	return lo.Map(input.LogEvents, func(e cloudwatchlogstypes.InputLogEvent, _ int) map[string]any {
+		event := map[string]any{}
+		Expect(json.Unmarshal([]byte(aws.ToString(e.Message)), &event)).To(Succeed())
+		return event
+	})
+}
+
+func findEvent(events []map[string]any, key string, value any) map[string]any {
+	event, ok := lo.Find(events, func(e map[string]any) bool { return e[key] == value })
+	Expect(ok).To(BeTrue())
+	return event
+}
+
+var _ = Describe("CloudWatch", func() {
+	It("should publish metrics in the embedded metric format", func() {
+		podsState.With(prometheus.Labels{"name": "a", "phase": "Pending"}).Set(1)
+		podsState.With(prometheus.Labels{"name": "b", "phase": "Pending"}).Set(1)
+		podsState.With(prometheus.Labels{"name": "c", "phase": "Running"}).Set(1)
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(logsapi.CreateLogStreamBehavior.Calls()).To(Equal(1))
+		Expect(logsapi.CreateLogGroupBehavior.Calls()).To(Equal(0))
+		event := findEvent(expectEvents(), "PendingPods", 2.0)
+		Expect(event).To(HaveKeyWithValue("ClusterName", options.FromContext(ctx).ClusterName))
+		Expect(event["_aws"]).To(HaveKeyWithValue("CloudWatchMetrics", ContainElement(HaveKeyWithValue("Namespace", "Karpenter"))))
+	})
+	It("should publish the increase of counters since the previous publish", func() {
+		nodeClaimsLaunched.With(prometheus.Labels{"nodepool": "default"}).Add(3)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(findEvent(expectEvents(), "NodePool", "default")).To(HaveKeyWithValue("NodeClaimsLaunched", 3.0))
+
+		nodeClaimsLaunched.With(prometheus.Labels{"nodepool": "default"}).Add(2)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(findEvent(expectEvents(), "NodePool", "default")).To(HaveKeyWithValue("NodeClaimsLaunched", 2.0))
+	})
+	It("should publish the average of histogram observations since the previous publish", func() {
+		cloudProviderDuration.With(prometheus.Labels{"method": "Create"}).Observe(10)
+		cloudProviderDuration.With(prometheus.Labels{"method": "Create"}).Observe(20)
+		cloudProviderDuration.With(prometheus.Labels{"method": "Delete"}).Observe(100)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(findEvent(expectEvents(), "PendingPods", 0.0)).To(HaveKeyWithValue("NodeLaunchDuration", 15.0))
+
+		// the average isn't published without new observations
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(findEvent(expectEvents(), "PendingPods", 0.0)).ToNot(HaveKey("NodeLaunchDuration"))
+	})
+	It("should create the log group if it doesn't exist", func() {
+		logsapi.CreateLogStreamBehavior.Error.Set(&smithy.GenericAPIError{Code: "ResourceNotFoundException", Message: "log group doesn't exist"}, fake.MaxCalls(1))
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(logsapi.CreateLogGroupBehavior.Calls()).To(Equal(1))
+		Expect(logsapi.CreateLogStreamBehavior.Calls()).To(Equal(2))
+		Expect(logsapi.PutLogEventsBehavior.Calls()).To(Equal(1))
+	})
+	It("should include the increases that failed to publish in the next publish", func() {
+		nodeClaimsLaunched.With(prometheus.Labels{"nodepool": "default"}).Add(3)
+		logsapi.PutLogEventsBehavior.Error.Set(&smithy.GenericAPIError{Code: "ServiceUnavailableException", Message: "unavailable"}, fake.MaxCalls(1))
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+		nodeClaimsLaunched.With(prometheus.Labels{"nodepool": "default"}).Add(1)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(findEvent(expectEvents(), "NodePool", "default")).To(HaveKeyWithValue("NodeClaimsLaunched", 4.0))
+	})
+	It("should create the log stream again if it was deleted", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		logsapi.PutLogEventsBehavior.Error.Set(&smithy.GenericAPIError{Code: "ResourceNotFoundException", Message: "log stream doesn't exist"}, fake.MaxCalls(1))
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(logsapi.CreateLogStreamBehavior.Calls()).To(Equal(2))
+	})
+})
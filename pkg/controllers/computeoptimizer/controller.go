@@ -0,0 +1,153 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package computeoptimizer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/computeoptimizer"
+	computeoptimizertypes "github.com/aws/aws-sdk-go-v2/service/computeoptimizer/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	computeoptimizerevents "github.com/aws/karpenter-provider-aws/pkg/controllers/computeoptimizer/events"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// Controller reads Compute Optimizer recommendations for the instances that Karpenter launched and summarizes them
+// by NodePool. Compute Optimizer refreshes its recommendations daily, so they are only read periodically.
+type Controller struct {
+	kubeClient       client.Client
+	recorder         events.Recorder
+	computeOptimizer sdk.ComputeOptimizerAPI
+}
+
+func NewController(kubeClient client.Client, recorder events.Recorder, computeOptimizer sdk.ComputeOptimizerAPI) *Controller {
+	return &Controller{
+		kubeClient:       kubeClient,
+		recorder:         recorder,
+		computeOptimizer: computeOptimizer,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "computeoptimizer"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	recommendations, err := c.listRecommendations(ctx)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting compute optimizer recommendations, %w", err)
+	}
+	summaries := map[string]*summary{}
+	for _, recommendation := range recommendations {
+		nodePoolName, ok := lo.Find(recommendation.Tags, func(t computeoptimizertypes.Tag) bool {
+			return aws.ToString(t.Key) == corev1beta1.NodePoolLabelKey
+		})
+		if !ok {
+			continue
+		}
+		s, ok := summaries[aws.ToString(nodePoolName.Value)]
+		if !ok {
+			s = &summary{findings: map[string]int{}, reasons: map[string]int{}}
+			summaries[aws.ToString(nodePoolName.Value)] = s
+		}
+		s.instances++
+		s.findings[string(recommendation.Finding)]++
+		for _, reason := range lo.Uniq(recommendation.FindingReasonCodes) {
+			s.reasons[string(reason)]++
+		}
+	}
+	instanceFindings.Reset()
+	instanceFindingReasons.Reset()
+	for nodePoolName, s := range summaries {
+		for finding, count := range s.findings {
+			instanceFindings.With(prometheus.Labels{nodePoolLabel: nodePoolName, findingLabel: finding}).Set(float64(count))
+		}
+		for reason, count := range s.reasons {
+			instanceFindingReasons.With(prometheus.Labels{nodePoolLabel: nodePoolName, reasonLabel: reason}).Set(float64(count))
+		}
+		if err := c.publishRecommendations(ctx, nodePoolName, s); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{RequeueAfter: 12 * time.Hour}, nil
+}
+
+type summary struct {
+	instances int
+	findings  map[string]int
+	reasons   map[string]int
+}
+
+// publishRecommendations publishes an event against the NodePool for each finding reason that applies to the
+// majority of its instances, since a finding for a single instance is likely to be a property of its workload
+// rather than the NodePool
+func (c *Controller) publishRecommendations(ctx context.Context, nodePoolName string, s *summary) error {
+	nodePool := &corev1beta1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	for reason, count := range s.reasons {
+		if count*2 > s.instances {
+			logging.FromContext(ctx).With("nodepool", nodePoolName, "reason", reason, "count", count, "total", s.instances).Debugf("discovered compute optimizer recommendation")
+			c.recorder.Publish(computeoptimizerevents.NodePoolRecommendation(nodePool, reason, count, s.instances))
+		}
+	}
+	return nil
+}
+
+// listRecommendations returns the recommendations for running instances that are managed by this cluster
+func (c *Controller) listRecommendations(ctx context.Context) ([]computeoptimizertypes.InstanceRecommendation, error) {
+	var recommendations []computeoptimizertypes.InstanceRecommendation
+	input := &computeoptimizer.GetEC2InstanceRecommendationsInput{
+		Filters: []computeoptimizertypes.Filter{
+			{
+				Name:   computeoptimizertypes.FilterName(fmt.Sprintf("tag:%s", corev1beta1.ManagedByAnnotationKey)),
+				Values: []string{options.FromContext(ctx).ClusterName},
+			},
+		},
+	}
+	for {
+		out, err := c.computeOptimizer.GetEC2InstanceRecommendations(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		recommendations = append(recommendations, lo.Filter(out.InstanceRecommendations, func(r computeoptimizertypes.InstanceRecommendation, _ int) bool {
+			return r.InstanceState == computeoptimizertypes.InstanceStateRunning
+		})...)
+		if aws.ToString(out.NextToken) == "" {
+			return recommendations, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
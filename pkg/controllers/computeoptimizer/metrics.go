@@ -0,0 +1,60 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package computeoptimizer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	computeOptimizerSubsystem = "compute_optimizer"
+	nodePoolLabel             = "nodepool"
+	findingLabel              = "finding"
+	reasonLabel               = "reason"
+)
+
+var (
+	instanceFindings = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: computeOptimizerSubsystem,
+			Name:      "instance_findings",
+			Help:      "Number of running instances with a Compute Optimizer finding. Labeled by nodepool and finding.",
+		},
+		[]string{
+			nodePoolLabel,
+			findingLabel,
+		},
+	)
+	instanceFindingReasons = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: computeOptimizerSubsystem,
+			Name:      "instance_finding_reasons",
+			Help:      "Number of running instances with a Compute Optimizer finding reason, such as MemoryOverprovisioned. Labeled by nodepool and reason.",
+		},
+		[]string{
+			nodePoolLabel,
+			reasonLabel,
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(instanceFindings, instanceFindingReasons)
+}
@@ -0,0 +1,160 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package computeoptimizer_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/computeoptimizer"
+	computeoptimizertypes "github.com/aws/aws-sdk-go-v2/service/computeoptimizer/types"
+	"k8s.io/apimachinery/pkg/types"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	controllerscomputeoptimizer "github.com/aws/karpenter-provider-aws/pkg/controllers/computeoptimizer"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var computeOptimizerAPI *fake.ComputeOptimizerAPI
+var recorder *coretest.EventRecorder
+var controller *controllerscomputeoptimizer.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ComputeOptimizer")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	computeOptimizerAPI = &fake.ComputeOptimizerAPI{}
+	recorder = coretest.NewEventRecorder()
+	controller = controllerscomputeoptimizer.NewController(env.Client, recorder, computeOptimizerAPI)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	computeOptimizerAPI.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func recommendation(nodePoolName string, finding computeoptimizertypes.Finding, reasons ...computeoptimizertypes.InstanceRecommendationFindingReasonCode) computeoptimizertypes.InstanceRecommendation {
+	return computeoptimizertypes.InstanceRecommendation{
+		InstanceArn:        aws.String(fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", fake.DefaultRegion, fake.DefaultAccount, fake.InstanceID())),
+		InstanceState:      computeoptimizertypes.InstanceStateRunning,
+		Finding:            finding,
+		FindingReasonCodes: reasons,
+		Tags: []computeoptimizertypes.Tag{
+			{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String(nodePoolName)},
+			{Key: aws.String(corev1beta1.ManagedByAnnotationKey), Value: aws.String(options.FromContext(ctx).ClusterName)},
+		},
+	}
+}
+
+var _ = Describe("ComputeOptimizer", func() {
+	var nodePool *corev1beta1.NodePool
+	BeforeEach(func() {
+		nodePool = coretest.NodePool()
+	})
+	It("should only request recommendations for instances managed by the cluster", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		input := computeOptimizerAPI.GetEC2InstanceRecommendationsInput.Clone()
+		Expect(input.Filters).To(HaveLen(1))
+		Expect(string(input.Filters[0].Name)).To(Equal(fmt.Sprintf("tag:%s", corev1beta1.ManagedByAnnotationKey)))
+		Expect(input.Filters[0].Values).To(ConsistOf(options.FromContext(ctx).ClusterName))
+	})
+	It("should expose findings and finding reasons by nodepool", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		computeOptimizerAPI.GetEC2InstanceRecommendationsOutput.Set(&computeoptimizer.GetEC2InstanceRecommendationsOutput{
+			InstanceRecommendations: []computeoptimizertypes.InstanceRecommendation{
+				recommendation(nodePool.Name, computeoptimizertypes.FindingOverProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeMemoryOverProvisioned),
+				recommendation(nodePool.Name, computeoptimizertypes.FindingOverProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeMemoryOverProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeCpuOverProvisioned),
+				recommendation(nodePool.Name, computeoptimizertypes.FindingOptimized),
+			},
+		})
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		ExpectMetricGaugeValue("karpenter_compute_optimizer_instance_findings", 2, map[string]string{"nodepool": nodePool.Name, "finding": string(computeoptimizertypes.FindingOverProvisioned)})
+		ExpectMetricGaugeValue("karpenter_compute_optimizer_instance_findings", 1, map[string]string{"nodepool": nodePool.Name, "finding": string(computeoptimizertypes.FindingOptimized)})
+		ExpectMetricGaugeValue("karpenter_compute_optimizer_instance_finding_reasons", 2, map[string]string{"nodepool": nodePool.Name, "reason": string(computeoptimizertypes.InstanceRecommendationFindingReasonCodeMemoryOverProvisioned)})
+		ExpectMetricGaugeValue("karpenter_compute_optimizer_instance_finding_reasons", 1, map[string]string{"nodepool": nodePool.Name, "reason": string(computeoptimizertypes.InstanceRecommendationFindingReasonCodeCpuOverProvisioned)})
+	})
+	It("should publish an event for finding reasons that apply to most of a nodepool's instances", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		computeOptimizerAPI.GetEC2InstanceRecommendationsOutput.Set(&computeoptimizer.GetEC2InstanceRecommendationsOutput{
+			InstanceRecommendations: []computeoptimizertypes.InstanceRecommendation{
+				recommendation(nodePool.Name, computeoptimizertypes.FindingOverProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeMemoryOverProvisioned),
+				recommendation(nodePool.Name, computeoptimizertypes.FindingOverProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeMemoryOverProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeCpuOverProvisioned),
+				recommendation(nodePool.Name, computeoptimizertypes.FindingOptimized),
+			},
+		})
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		Expect(recorder.Calls("ComputeOptimizerRecommendation")).To(Equal(1))
+		Expect(recorder.DetectedEvent(fmt.Sprintf("Compute Optimizer reports 2 of 3 instances as %s", computeoptimizertypes.InstanceRecommendationFindingReasonCodeMemoryOverProvisioned))).To(BeTrue())
+	})
+	It("should ignore recommendations for instances that aren't running", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		stopped := recommendation(nodePool.Name, computeoptimizertypes.FindingUnderProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeCpuUnderProvisioned)
+		stopped.InstanceState = computeoptimizertypes.InstanceStateTerminated
+		computeOptimizerAPI.GetEC2InstanceRecommendationsOutput.Set(&computeoptimizer.GetEC2InstanceRecommendationsOutput{
+			InstanceRecommendations: []computeoptimizertypes.InstanceRecommendation{stopped},
+		})
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		_, ok := FindMetricWithLabelValues("karpenter_compute_optimizer_instance_findings", map[string]string{"nodepool": nodePool.Name})
+		Expect(ok).To(BeFalse())
+		Expect(recorder.Calls("ComputeOptimizerRecommendation")).To(Equal(0))
+	})
+	It("should not publish events for nodepools that no longer exist", func() {
+		computeOptimizerAPI.GetEC2InstanceRecommendationsOutput.Set(&computeoptimizer.GetEC2InstanceRecommendationsOutput{
+			InstanceRecommendations: []computeoptimizertypes.InstanceRecommendation{
+				recommendation("deleted", computeoptimizertypes.FindingOverProvisioned, computeoptimizertypes.InstanceRecommendationFindingReasonCodeMemoryOverProvisioned),
+			},
+		})
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		ExpectMetricGaugeValue("karpenter_compute_optimizer_instance_findings", 1, map[string]string{"nodepool": "deleted", "finding": string(computeoptimizertypes.FindingOverProvisioned)})
+		Expect(recorder.Calls("ComputeOptimizerRecommendation")).To(Equal(0))
+	})
+	It("should fail if recommendations can't be retrieved", func() {
+		computeOptimizerAPI.NextError.Set(fmt.Errorf("opt-in required"))
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+	})
+})
@@ -16,49 +16,162 @@ package controllers
 
 import (
 	"context"
+	"os"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 
 	controllerspricing "github.com/aws/karpenter-provider-aws/pkg/controllers/pricing"
+	controllerspricingoverrides "github.com/aws/karpenter-provider-aws/pkg/controllers/pricing/overrides"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
 
-	"github.com/aws/aws-sdk-go/aws/session"
-	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	servicecloudwatchlogs "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	servicecomputeoptimizer "github.com/aws/aws-sdk-go-v2/service/computeoptimizer"
+	serviceec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	serviceeventbridge "github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	services3 "github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/samber/lo"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/adoption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/awsauth"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/cloudwatch"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/computeoptimizer"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/debug"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/minnodes"
+	nodeclaimcost "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/cost"
+	nodeclaimdiagnostics "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/diagnostics"
+	nodeclaimdrift "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/drift"
 	nodeclaimgarbagecollection "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/garbagecollection"
+	nodeclaiminplaceupdate "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/inplaceupdate"
+	nodeclaimlaunchlatency "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/launchlatency"
+	nodeclaimmaintenance "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/maintenance"
+	nodeclaimreboot "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/reboot"
+	nodeclaimrepair "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/repair"
 	nodeclaimtagging "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/tagging"
+	nodeclaimtagsync "github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/tagsync"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclass"
+	controllersquota "github.com/aws/karpenter-provider-aws/pkg/controllers/quota"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/snapshot"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/stoppedinstances"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/warmpool"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/eventbridge"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
 )
 
-func NewControllers(ctx context.Context, sess *session.Session, clk clock.Clock, kubeClient client.Client, recorder events.Recorder,
-	unavailableOfferings *cache.UnavailableOfferings, cloudProvider cloudprovider.CloudProvider, subnetProvider *subnet.Provider,
-	securityGroupProvider *securitygroup.Provider, instanceProfileProvider *instanceprofile.Provider, instanceProvider *instance.Provider,
-	pricingProvider *pricing.Provider, amiProvider *amifamily.Provider, launchTemplateProvider *launchtemplate.Provider) []controller.Controller {
+func NewControllers(ctx context.Context, cfg aws.Config, clk clock.Clock, kubeClient client.Client, kubernetesInterface kubernetes.Interface, recorder events.Recorder,
+	unavailableOfferings *cache.UnavailableOfferings, cloudProvider cloudprovider.CloudProvider, accountProvider *account.Provider,
+	securityGroupProvider *securitygroup.Provider, instanceProvider *instance.Provider, pricingProvider *pricing.Provider,
+	launchTemplateProvider *launchtemplate.Provider, quotaProvider *quota.Provider, instanceTypeProvider *instancetype.Provider) []controller.Controller {
 
+	var nodeClassEvents chan event.GenericEvent
+	if options.FromContext(ctx).ResourceChangeEvents {
+		// EC2NodeClasses are re-resolved when the interruption controller handles changes to the resources they select
+		nodeClassEvents = make(chan event.GenericEvent, 100)
+	}
 	controllers := []controller.Controller{
-		nodeclass.NewController(kubeClient, recorder, subnetProvider, securityGroupProvider, amiProvider, instanceProfileProvider, launchTemplateProvider),
-		nodeclaimgarbagecollection.NewController(kubeClient, cloudProvider),
-		nodeclaimtagging.NewController(kubeClient, instanceProvider),
-		controllerspricing.NewController(pricingProvider),
+		nodeclass.NewController(kubeClient, recorder, accountProvider, nodeClassEvents),
+		nodeclass.NewGarbageCollectionController(kubeClient, launchTemplateProvider),
+		nodeclaimgarbagecollection.NewController(kubeClient, clk, cloudProvider, serviceec2.NewFromConfig(cfg)),
+		nodeclaimtagging.NewController(kubeClient, accountProvider),
+		nodeclaimcost.NewController(kubeClient, pricingProvider),
+		controllerspricing.NewController(clk, pricingProvider),
+		warmpool.NewController(kubeClient, clk, cloudProvider),
+		warmpool.NewClaimController(kubeClient, recorder),
+		minnodes.NewController(kubeClient, cloudProvider),
+		adoption.NewController(kubeClient, recorder, cloudProvider, instanceProvider),
+		nodeclaimreboot.NewController(kubeClient, clk, recorder, serviceec2.NewFromConfig(cfg)),
+		nodeclaimlaunchlatency.NewController(kubeClient, clk, instanceProvider),
+		// Stopped instances are terminated even when the pool is disabled, since instances may have been stopped before
+		stoppedinstances.NewController(kubeClient, clk, accountProvider),
+	}
+	if options.FromContext(ctx).PricingOverridesConfigMap != "" {
+		controllers = append(controllers, controllerspricingoverrides.NewController(kubernetesInterface, pricingProvider))
+	}
+	if options.FromContext(ctx).CacheSnapshotPath != "" {
+		controllers = append(controllers, snapshot.NewController(clk, instanceTypeProvider, pricingProvider))
+	}
+	if options.FromContext(ctx).ManageAWSAuth {
+		controllers = append(controllers, awsauth.NewController(kubeClient, kubernetesInterface, accountProvider))
+	}
+	if options.FromContext(ctx).InstanceStatusRepairPeriod != 0 {
+		controllers = append(controllers, nodeclaimrepair.NewController(kubeClient, clk, recorder, serviceec2.NewFromConfig(cfg)))
+	}
+	if options.FromContext(ctx).DriftReconciliationInterval != 0 {
+		controllers = append(controllers, nodeclaimdrift.NewController(kubeClient, cloudProvider))
+	}
+	if options.FromContext(ctx).QuotaAwareProvisioning || options.FromContext(ctx).QuotaWarningThreshold > 0 {
+		controllers = append(controllers, controllersquota.NewController(kubeClient, clk, recorder, quotaProvider))
+	}
+	if options.FromContext(ctx).InPlaceMetadataOptionsUpdate || options.FromContext(ctx).InPlaceSecurityGroupsUpdate {
+		controllers = append(controllers, nodeclaiminplaceupdate.NewController(kubeClient, instanceProvider, securityGroupProvider))
+	}
+	if options.FromContext(ctx).TagLabels != "" || options.FromContext(ctx).TagAnnotations != "" {
+		controllers = append(controllers, nodeclaimtagsync.NewController(kubeClient, instanceProvider))
 	}
-	if options.FromContext(ctx).InterruptionQueue != "" {
-		controllers = append(controllers, interruption.NewController(kubeClient, clk, recorder, lo.Must(sqs.NewProvider(ctx, servicesqs.New(sess), options.FromContext(ctx).InterruptionQueue)), unavailableOfferings))
+	if options.FromContext(ctx).LaunchDiagnostics {
+		controllers = append(controllers, nodeclaimdiagnostics.NewController(kubeClient, clk, recorder, serviceec2.NewFromConfig(cfg), services3.NewFromConfig(cfg)))
+	}
+	if options.FromContext(ctx).ComputeOptimizerRecommendations {
+		// Compute Optimizer isn't available in every partition
+		if utils.ServiceAvailable(cfg.Region, servicecomputeoptimizer.ServiceID) {
+			controllers = append(controllers, computeoptimizer.NewController(kubeClient, recorder, servicecomputeoptimizer.NewFromConfig(cfg)))
+		} else {
+			logging.FromContext(ctx).With("partition", utils.Partition(cfg.Region).ID()).Errorf("compute optimizer isn't available in the partition, recommendations are disabled")
+		}
+	}
+	if options.FromContext(ctx).CloudWatchMetricsLogGroup != "" {
+		// every replica publishes to its own log stream, named after the pod, while it's the leader
+		controllers = append(controllers, cloudwatch.NewController(clk, servicecloudwatchlogs.NewFromConfig(cfg), crmetrics.Registry, lo.Must(os.Hostname())))
+	}
+	// The interruption queue can be set or changed by the settings ConfigMap while the controller runs
+	if options.FromContext(ctx).InterruptionQueue != "" || options.FromContext(ctx).SettingsConfigMap != "" {
+		// Messages fail to be received until the queue is ensured, and are retried with backoff. Managed queues are
+		// always named rather than referred to by URL, so they're in the controller's region.
+		if options.FromContext(ctx).ManagedInterruptionQueue {
+			sqsapi := sqs.NewAPI(cfg, options.FromContext(ctx).InterruptionQueue, options.FromContext(ctx).InterruptionQueueRoleARN)
+			controllers = append(controllers, interruption.NewInfrastructureController(kubeClient, recorder, sqsapi, eventbridge.NewProvider(serviceeventbridge.NewFromConfig(cfg))))
+		}
+		newSQSProvider := func(queue string) *sqs.Provider {
+			return sqs.NewProvider(sqs.NewAPI(cfg, queue, options.FromContext(ctx).InterruptionQueueRoleARN), queue)
+		}
+		controllers = append(controllers, interruption.NewController(kubeClient, clk, recorder, newSQSProvider, unavailableOfferings, accountProvider, nodeClassEvents))
+	}
+	if options.FromContext(ctx).InterruptionQueue != "" || options.FromContext(ctx).SettingsConfigMap != "" || options.FromContext(ctx).InterruptionEndpointPort != 0 {
+		controllers = append(controllers, nodeclaimmaintenance.NewController(kubeClient, clk, recorder))
 	}
 	return controllers
 }
+
+// NewRunnables returns the components that are started by the manager alongside the controllers but aren't reconcilers
+func NewRunnables(ctx context.Context, clk clock.Clock, kubeClient client.Client, recorder events.Recorder, elected <-chan struct{},
+	unavailableOfferings *cache.UnavailableOfferings, cloudProvider cloudprovider.CloudProvider, pricingProvider *pricing.Provider, instanceProvider *instance.Provider) []manager.Runnable {
+
+	var runnables []manager.Runnable
+	if options.FromContext(ctx).InterruptionEndpointPort != 0 {
+		runnables = append(runnables, interruption.NewServer(ctx, kubeClient, clk, recorder, unavailableOfferings, elected))
+	}
+	if options.FromContext(ctx).DebugEndpointPort != 0 {
+		runnables = append(runnables, debug.NewServer(ctx, kubeClient, cloudProvider, pricingProvider, instanceProvider, unavailableOfferings))
+	}
+	return runnables
+}
@@ -0,0 +1,193 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	provisioningscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+// previewRequest is a pod and, optionally, the NodePool to launch it from. If the NodePool's spec is set, the pod is
+// previewed against the spec instead of the NodePool that exists in the cluster, so that changes to a NodePool can be
+// previewed before they are applied.
+type previewRequest struct {
+	Pod          v1.PodSpec                `json:"pod"`
+	NodePool     string                    `json:"nodePool,omitempty"`
+	NodePoolSpec *corev1beta1.NodePoolSpec `json:"nodePoolSpec,omitempty"`
+}
+
+type preview struct {
+	// NodePool is the NodePool that a NodeClaim would be launched from for the pod, if any
+	NodePool  string            `json:"nodePool,omitempty"`
+	NodePools []nodePoolPreview `json:"nodePools"`
+}
+
+type nodePoolPreview struct {
+	Name         string          `json:"name"`
+	Requests     v1.ResourceList `json:"requests,omitempty"`
+	CapacityType string          `json:"capacityType,omitempty"`
+	Options      []launchOption  `json:"options,omitempty"`
+	Reason       string          `json:"reason,omitempty"`
+}
+
+type launchOption struct {
+	InstanceType string  `json:"instanceType"`
+	Zone         string  `json:"zone"`
+	Price        float64 `json:"price"`
+}
+
+// preview returns the instance types, zones and prices that a NodeClaim would be launched with for the pod, without
+// launching anything. NodePools are tried in the order of their weight, like the provisioner does, and the pod's
+// requests are added to the requests of the daemonsets that would run on the node.
+func (s *Server) preview(w http.ResponseWriter, r *http.Request) {
+	req := previewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("decoding request, %s", err), http.StatusBadRequest)
+		return
+	}
+	nodePools, code, err := s.previewNodePools(r, req)
+	if err != nil {
+		http.Error(w, err.Error(), code)
+		return
+	}
+	daemonSetList := &appsv1.DaemonSetList{}
+	if err = s.kubeClient.List(r.Context(), daemonSetList); err != nil {
+		http.Error(w, fmt.Sprintf("listing daemonsets, %s", err), http.StatusInternalServerError)
+		return
+	}
+	daemonSetPods := lo.Map(daemonSetList.Items, func(d appsv1.DaemonSet, _ int) *v1.Pod { return &v1.Pod{Spec: d.Spec.Template.Spec} })
+	pod := &v1.Pod{Spec: req.Pod}
+	result := preview{NodePools: []nodePoolPreview{}}
+	for _, nodePool := range nodePools {
+		p := s.previewNodePool(r, nodePool, pod, daemonSetPods)
+		if result.NodePool == "" && len(p.Options) > 0 {
+			result.NodePool = p.Name
+		}
+		result.NodePools = append(result.NodePools, p)
+	}
+	writeJSON(w, result)
+}
+
+// previewNodePools returns the NodePools that the pod is previewed against, ordered by their weight
+func (s *Server) previewNodePools(r *http.Request, req previewRequest) ([]*corev1beta1.NodePool, int, error) {
+	if req.NodePoolSpec != nil {
+		return []*corev1beta1.NodePool{{
+			ObjectMeta: metav1.ObjectMeta{Name: lo.Ternary(req.NodePool == "", "preview", req.NodePool)},
+			Spec:       *req.NodePoolSpec,
+		}}, 0, nil
+	}
+	if req.NodePool != "" {
+		nodePool := &corev1beta1.NodePool{}
+		if err := s.kubeClient.Get(r.Context(), types.NamespacedName{Name: req.NodePool}, nodePool); err != nil {
+			return nil, lo.Ternary(client.IgnoreNotFound(err) == nil, http.StatusNotFound, http.StatusInternalServerError), fmt.Errorf("getting nodepool, %w", err)
+		}
+		return []*corev1beta1.NodePool{nodePool}, 0, nil
+	}
+	nodePoolList := &corev1beta1.NodePoolList{}
+	if err := s.kubeClient.List(r.Context(), nodePoolList); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodePools := lo.ToSlicePtr(lo.Filter(nodePoolList.Items, func(np corev1beta1.NodePool, _ int) bool { return np.DeletionTimestamp.IsZero() }))
+	sort.Slice(nodePools, func(i, j int) bool {
+		if lo.FromPtr(nodePools[i].Spec.Weight) != lo.FromPtr(nodePools[j].Spec.Weight) {
+			return lo.FromPtr(nodePools[i].Spec.Weight) > lo.FromPtr(nodePools[j].Spec.Weight)
+		}
+		return nodePools[i].Name < nodePools[j].Name
+	})
+	return nodePools, 0, nil
+}
+
+func (s *Server) previewNodePool(r *http.Request, nodePool *corev1beta1.NodePool, pod *v1.Pod, daemonSetPods []*v1.Pod) nodePoolPreview {
+	result := nodePoolPreview{Name: nodePool.Name}
+	nct := provisioningscheduling.NewNodeClaimTemplate(nodePool)
+	if err := scheduling.Taints(nct.Spec.Taints).Tolerates(pod); err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	// Preferred node affinity is relaxed if the NodePool can't satisfy it, like the scheduler does
+	podRequirements := scheduling.NewPodRequirements(pod)
+	if nct.Requirements.Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels) != nil {
+		podRequirements = scheduling.NewStrictPodRequirements(pod)
+	}
+	if err := nct.Requirements.Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
+		result.Reason = fmt.Sprintf("incompatible requirements, %s", err)
+		return result
+	}
+	if err := nodePool.Spec.Limits.ExceededBy(nodePool.Status.Resources); err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	daemons := lo.Filter(daemonSetPods, func(p *v1.Pod, _ int) bool {
+		return scheduling.Taints(nct.Spec.Taints).Tolerates(p) == nil &&
+			nct.Requirements.Compatible(scheduling.NewPodRequirements(p), scheduling.AllowUndefinedWellKnownLabels) == nil
+	})
+	nct.Requirements.Add(podRequirements.Values()...)
+	result.Requests = resources.Merge(resources.RequestsForPods(daemons...), resources.RequestsForPods(pod))
+
+	instanceTypes, err := s.cloudProvider.GetInstanceTypes(r.Context(), nodePool)
+	if err != nil {
+		result.Reason = fmt.Sprintf("getting instance types, %s", err)
+		return result
+	}
+	nct.InstanceTypeOptions = lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return nct.Requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil &&
+			len(it.Offerings.Compatible(nct.Requirements).Available()) > 0 &&
+			resources.Fits(result.Requests, it.Allocatable())
+	})
+	if len(nct.InstanceTypeOptions) == 0 {
+		result.Reason = "no instance types satisfy the pod's requirements and requests"
+		return result
+	}
+	nodeClaim := nct.ToNodeClaim(nodePool)
+	nodeClaim.Spec.Resources.Requests = result.Requests
+	capacityType, launchable := s.instanceProvider.Preview(nodeClaim, lo.Filter(nct.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+		return nct.Requirements.Get(v1.LabelInstanceTypeStable).Has(it.Name)
+	}))
+	requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	result.CapacityType = capacityType
+	for _, it := range launchable {
+		for _, o := range it.Offerings.Compatible(requirements).Available() {
+			if o.CapacityType == capacityType {
+				result.Options = append(result.Options, launchOption{InstanceType: it.Name, Zone: o.Zone, Price: o.Price})
+			}
+		}
+	}
+	if len(result.Options) == 0 {
+		result.Reason = "no offerings are available"
+		return result
+	}
+	sort.SliceStable(result.Options, func(i, j int) bool {
+		if result.Options[i].Price != result.Options[j].Price {
+			return result.Options[i].Price < result.Options[j].Price
+		}
+		return result.Options[i].InstanceType < result.Options[j].InstanceType
+	})
+	return result
+}
@@ -0,0 +1,219 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// APIKeyHeader is the header that requests to the debug endpoint must set to the configured API key
+const APIKeyHeader = "X-Karpenter-Api-Key"
+
+const previewPath = "/debug/preview"
+
+// Server serves the state that the controller has cached as JSON, so that provisioning decisions can be explained
+// without attaching a debugger:
+//
+//	/debug/instancetypes?nodepool=<name>  instance types and offerings that the NodePool can launch
+//	/debug/pricing                        on-demand and spot prices of each instance type
+//	/debug/unavailableofferings           offerings that are skipped after insufficient capacity errors
+//	/debug/nodeclasses                    AMIs, subnets, security groups and instance profile of each EC2NodeClass
+//	/debug/preview (POST)                 instance types, zones and prices that would be launched for a pod
+type Server struct {
+	kubeClient                client.Client
+	cloudProvider             cloudprovider.CloudProvider
+	pricingProvider           *pricing.Provider
+	instanceProvider          *instance.Provider
+	unavailableOfferingsCache *cache.UnavailableOfferings
+	apiKey                    string
+	port                      int
+	mux                       *http.ServeMux
+}
+
+func NewServer(ctx context.Context, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, pricingProvider *pricing.Provider,
+	instanceProvider *instance.Provider, unavailableOfferingsCache *cache.UnavailableOfferings) *Server {
+
+	s := &Server{
+		kubeClient:                kubeClient,
+		cloudProvider:             cloudProvider,
+		pricingProvider:           pricingProvider,
+		instanceProvider:          instanceProvider,
+		unavailableOfferingsCache: unavailableOfferingsCache,
+		apiKey:                    options.FromContext(ctx).DebugEndpointAPIKey,
+		port:                      options.FromContext(ctx).DebugEndpointPort,
+		mux:                       http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/debug/instancetypes", s.instanceTypes)
+	s.mux.HandleFunc("/debug/pricing", s.pricing)
+	s.mux.HandleFunc("/debug/unavailableofferings", s.unavailableOfferings)
+	s.mux.HandleFunc("/debug/nodeclasses", s.nodeClasses)
+	s.mux.HandleFunc(previewPath, s.preview)
+	return s
+}
+
+// Start serves the debug endpoint until the context is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.port),
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logging.FromContext(ctx).Errorf("shutting down debug endpoint, %v", err)
+		}
+	}()
+	logging.FromContext(ctx).With("port", s.port).Infof("serving debug endpoint")
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving debug endpoint, %w", err)
+	}
+	return nil
+}
+
+// NeedLeaderElection is false so that the caches of every replica can be inspected
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Previews are the only requests that have a body
+	if r.Method != lo.Ternary(r.URL.Path == previewPath, http.MethodPost, http.MethodGet) {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if s.apiKey == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(APIKeyHeader)), []byte(s.apiKey)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+type instanceType struct {
+	Name        string          `json:"name"`
+	Capacity    v1.ResourceList `json:"capacity"`
+	Allocatable v1.ResourceList `json:"allocatable"`
+	Offerings   []offering      `json:"offerings"`
+}
+
+type offering struct {
+	Zone         string  `json:"zone"`
+	CapacityType string  `json:"capacityType"`
+	Price        float64 `json:"price"`
+	Available    bool    `json:"available"`
+}
+
+func (s *Server) instanceTypes(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("nodepool")
+	if name == "" {
+		http.Error(w, "nodepool query parameter is required", http.StatusBadRequest)
+		return
+	}
+	nodePool := &corev1beta1.NodePool{}
+	if err := s.kubeClient.Get(r.Context(), types.NamespacedName{Name: name}, nodePool); err != nil {
+		http.Error(w, fmt.Sprintf("getting nodepool, %s", err), lo.Ternary(client.IgnoreNotFound(err) == nil, http.StatusNotFound, http.StatusInternalServerError))
+		return
+	}
+	instanceTypes, err := s.cloudProvider.GetInstanceTypes(r.Context(), nodePool)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("getting instance types, %s", err), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(instanceTypes, func(i, j int) bool { return instanceTypes[i].Name < instanceTypes[j].Name })
+	writeJSON(w, lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) instanceType {
+		return instanceType{
+			Name:        it.Name,
+			Capacity:    it.Capacity,
+			Allocatable: it.Allocatable(),
+			Offerings: lo.Map(it.Offerings, func(o cloudprovider.Offering, _ int) offering {
+				return offering{Zone: o.Zone, CapacityType: o.CapacityType, Price: o.Price, Available: o.Available}
+			}),
+		}
+	}))
+}
+
+type price struct {
+	OnDemand *float64           `json:"onDemand,omitempty"`
+	Spot     map[string]float64 `json:"spot,omitempty"`
+}
+
+func (s *Server) pricing(w http.ResponseWriter, _ *http.Request) {
+	prices := map[string]price{}
+	for _, name := range s.pricingProvider.InstanceTypes() {
+		p := price{Spot: s.pricingProvider.SpotPrices(name)}
+		if onDemand, ok := s.pricingProvider.OnDemandPrice(name); ok {
+			p.OnDemand = lo.ToPtr(onDemand)
+		}
+		prices[name] = p
+	}
+	writeJSON(w, prices)
+}
+
+func (s *Server) unavailableOfferings(w http.ResponseWriter, _ *http.Request) {
+	offerings := s.unavailableOfferingsCache.List()
+	sort.Slice(offerings, func(i, j int) bool { return offerings[i].Expiration.Before(offerings[j].Expiration) })
+	writeJSON(w, lo.Ternary(offerings == nil, []cache.UnavailableOffering{}, offerings))
+}
+
+type nodeClass struct {
+	Name string `json:"name"`
+	v1beta1.EC2NodeClassStatus
+}
+
+func (s *Server) nodeClasses(w http.ResponseWriter, r *http.Request) {
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := s.kubeClient.List(r.Context(), nodeClassList); err != nil {
+		http.Error(w, fmt.Sprintf("listing ec2nodeclasses, %s", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, lo.Map(nodeClassList.Items, func(nc v1beta1.EC2NodeClass, _ int) nodeClass {
+		return nodeClass{Name: nc.Name, EC2NodeClassStatus: nc.Status}
+	}))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("encoding response, %s", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/cloudprovider"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/debug"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var server *debug.Server
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Debug")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		DebugEndpointPort:   lo.ToPtr(8091),
+		DebugEndpointAPIKey: lo.ToPtr("api-key"),
+	}))
+	awsEnv = test.NewEnvironment(ctx, env)
+	cloudProvider := cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, coretest.NewEventRecorder(),
+		env.Client, awsEnv.PricingProvider)
+	server = debug.NewServer(ctx, env.Client, cloudProvider, awsEnv.PricingProvider, awsEnv.InstanceProvider, awsEnv.UnavailableOfferingsCache)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+// post serves a POST request for the path with the body and decodes the response into v if the request succeeds
+func post(path string, body any, v any) int {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(lo.Must(json.Marshal(body)))).WithContext(ctx)
+	req.Header.Set(debug.APIKeyHeader, "api-key")
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	if resp.Code == http.StatusOK {
+		Expect(json.Unmarshal(resp.Body.Bytes(), v)).To(Succeed())
+	}
+	return resp.Code
+}
+
+// get serves a GET request for the path and decodes the response into v if the request succeeds
+func get(path string, v any) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+	req.Header.Set(debug.APIKeyHeader, "api-key")
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	if resp.Code == http.StatusOK {
+		Expect(json.Unmarshal(resp.Body.Bytes(), v)).To(Succeed())
+	}
+	return resp.Code
+}
+
+var _ = Describe("Debug", func() {
+	It("should reject requests without the api key", func() {
+		req := httptest.NewRequest(http.MethodGet, "/debug/pricing", nil)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+	})
+	It("should reject requests that aren't GET requests", func() {
+		req := httptest.NewRequest(http.MethodPost, "/debug/pricing", nil)
+		req.Header.Set(debug.APIKeyHeader, "api-key")
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+	It("should serve the instance types of a nodepool", func() {
+		nodeClass := test.EC2NodeClass()
+		nodePool := coretest.NodePool(corev1beta1.NodePool{
+			Spec: corev1beta1.NodePoolSpec{
+				Template: corev1beta1.NodeClaimTemplate{
+					Spec: corev1beta1.NodeClaimSpec{
+						NodeClassRef: &corev1beta1.NodeClassReference{Name: nodeClass.Name},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+
+		var instanceTypes []map[string]any
+		Expect(get("/debug/instancetypes?nodepool="+nodePool.Name, &instanceTypes)).To(Equal(http.StatusOK))
+		Expect(instanceTypes).ToNot(BeEmpty())
+		Expect(instanceTypes[0]).To(HaveKey("offerings"))
+		Expect(instanceTypes[0]).To(HaveKey("allocatable"))
+	})
+	It("should fail to serve the instance types of a nodepool that doesn't exist", func() {
+		Expect(get("/debug/instancetypes?nodepool=missing", nil)).To(Equal(http.StatusNotFound))
+		Expect(get("/debug/instancetypes", nil)).To(Equal(http.StatusBadRequest))
+	})
+	It("should serve the prices of each instance type", func() {
+		prices := map[string]map[string]any{}
+		Expect(get("/debug/pricing", &prices)).To(Equal(http.StatusOK))
+		Expect(prices).To(HaveKey("m5.large"))
+		Expect(prices["m5.large"]).To(HaveKey("onDemand"))
+	})
+	It("should serve the unavailable offerings", func() {
+		awsEnv.UnavailableOfferingsCache.MarkUnavailable(ctx, "InsufficientInstanceCapacity", "m5.large", "test-zone-1a", corev1beta1.CapacityTypeSpot)
+
+		var offerings []cache.UnavailableOffering
+		Expect(get("/debug/unavailableofferings", &offerings)).To(Equal(http.StatusOK))
+		Expect(offerings).To(HaveLen(1))
+		Expect(offerings[0].InstanceType).To(Equal("m5.large"))
+		Expect(offerings[0].Zone).To(Equal("test-zone-1a"))
+		Expect(offerings[0].CapacityType).To(Equal(corev1beta1.CapacityTypeSpot))
+	})
+	It("should serve the resolved resources of each ec2nodeclass", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Status: v1beta1.EC2NodeClassStatus{
+				AMIs:           []v1beta1.AMI{{ID: "ami-test1"}},
+				Subnets:        []v1beta1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a"}},
+				SecurityGroups: []v1beta1.SecurityGroup{{ID: "sg-test1"}},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+
+		var nodeClasses []map[string]any
+		Expect(get("/debug/nodeclasses", &nodeClasses)).To(Equal(http.StatusOK))
+		Expect(nodeClasses).To(HaveLen(1))
+		Expect(nodeClasses[0]).To(HaveKeyWithValue("name", nodeClass.Name))
+		Expect(nodeClasses[0]).To(HaveKeyWithValue("subnets", ConsistOf(HaveKeyWithValue("id", "subnet-test1"))))
+	})
+	Context("Preview", func() {
+		var nodeClass *v1beta1.EC2NodeClass
+		var nodePool *corev1beta1.NodePool
+		var pod v1.PodSpec
+
+		BeforeEach(func() {
+			nodeClass = test.EC2NodeClass()
+			nodePool = coretest.NodePool(corev1beta1.NodePool{
+				Spec: corev1beta1.NodePoolSpec{
+					Template: corev1beta1.NodeClaimTemplate{
+						Spec: corev1beta1.NodeClaimSpec{
+							Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
+								{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeOnDemand}}},
+							},
+							NodeClassRef: &corev1beta1.NodeClassReference{Name: nodeClass.Name},
+						},
+					},
+				},
+			})
+			pod = coretest.UnschedulablePod(coretest.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+				NodeSelector:         map[string]string{v1.LabelTopologyZone: "test-zone-1a"},
+			}).Spec
+		})
+		It("should preview the cheapest offerings that a pod would launch with", func() {
+			ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+
+			result := map[string]any{}
+			Expect(post("/debug/preview", map[string]any{"pod": pod}, &result)).To(Equal(http.StatusOK))
+			Expect(result).To(HaveKeyWithValue("nodePool", nodePool.Name))
+			Expect(result["nodePools"]).To(HaveLen(1))
+			preview := result["nodePools"].([]any)[0].(map[string]any)
+			Expect(preview).To(HaveKeyWithValue("capacityType", corev1beta1.CapacityTypeOnDemand))
+			options := preview["options"].([]any)
+			Expect(options).ToNot(BeEmpty())
+			for i, o := range options {
+				Expect(o).To(HaveKeyWithValue("zone", "test-zone-1a"))
+				if i > 0 {
+					Expect(o.(map[string]any)["price"]).To(BeNumerically(">=", options[i-1].(map[string]any)["price"]))
+				}
+			}
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+		It("should preview a change to a nodepool", func() {
+			ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+			nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"m5.xlarge"}}},
+			}
+
+			result := map[string]any{}
+			Expect(post("/debug/preview", map[string]any{"pod": pod, "nodePool": nodePool.Name, "nodePoolSpec": nodePool.Spec}, &result)).To(Equal(http.StatusOK))
+			preview := result["nodePools"].([]any)[0].(map[string]any)
+			Expect(preview["options"]).To(ConsistOf(HaveKeyWithValue("instanceType", "m5.xlarge")))
+		})
+		It("should explain why a nodepool can't launch the pod", func() {
+			nodePool.Spec.Template.Spec.Taints = []v1.Taint{{Key: "example.com/taint", Effect: v1.TaintEffectNoSchedule}}
+			ExpectApplied(ctx, env.Client, nodeClass, nodePool)
+
+			result := map[string]any{}
+			Expect(post("/debug/preview", map[string]any{"pod": pod}, &result)).To(Equal(http.StatusOK))
+			Expect(result).ToNot(HaveKey("nodePool"))
+			preview := result["nodePools"].([]any)[0].(map[string]any)
+			Expect(preview).To(HaveKey("reason"))
+			Expect(preview).ToNot(HaveKey("options"))
+		})
+		It("should fail to preview a nodepool that doesn't exist", func() {
+			Expect(post("/debug/preview", map[string]any{"pod": pod, "nodePool": "missing"}, nil)).To(Equal(http.StatusNotFound))
+		})
+		It("should reject previews that aren't POST requests", func() {
+			Expect(get("/debug/preview", nil)).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
@@ -17,28 +17,41 @@ package interruption
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
-	sqsapi "github.com/aws/aws-sdk-go/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	gocache "github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
 	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
+	apisv1beta1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	"github.com/aws/karpenter-provider-aws/pkg/cache"
 	interruptionevents "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/resourcechange"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
@@ -49,38 +62,73 @@ import (
 type Action string
 
 const (
-	CordonAndDrain Action = "CordonAndDrain"
-	NoAction       Action = "NoAction"
+	CordonAndDrain     Action = "CordonAndDrain"
+	Cordon             Action = "Cordon"
+	MarkForReplacement Action = "MarkForReplacement"
+	NoAction           Action = "NoAction"
+)
+
+const (
+	// releaseVisibilityTimeout is how long a message that is released to other clusters sharing the queue is hidden
+	// before it can be received again, so that a message that no cluster handles isn't received in a tight loop
+	releaseVisibilityTimeout = 5 * time.Second
+	// maxReleaseReceives is the number of times that a message is received before it's deleted rather than released,
+	// since a message that no cluster handles would otherwise be released until it expires or is moved to a
+	// dead-letter queue
+	maxReleaseReceives = 5
+	// disabledInterval is how often the controller checks whether an interruption queue was set by the settings
+	// ConfigMap while none is set
+	disabledInterval = 10 * time.Second
 )
 
 // Controller is an AWS interruption controller.
 // It continually polls an SQS queue for events from aws.ec2 and aws.health that
 // trigger node health events or node spot interruption/rebalance events.
 type Controller struct {
-	kubeClient                client.Client
-	clk                       clock.Clock
-	recorder                  events.Recorder
-	sqsProvider               *sqs.Provider
+	kubeClient  client.Client
+	clk         clock.Clock
+	recorder    events.Recorder
+	sqsProvider *sqs.Provider
+	// newSQSProvider returns the provider of the interruption queue, which is replaced when the queue is changed by the
+	// settings ConfigMap
+	newSQSProvider            func(queue string) *sqs.Provider
+	queue                     string
 	unavailableOfferingsCache *cache.UnavailableOfferings
+	accountProvider           *account.Provider
 	parser                    *EventParser
 	cm                        *pretty.ChangeMonitor
+	// instanceOwners caches the cluster that launched each instance, used to filter messages on shared queues
+	instanceOwners *gocache.Cache
+	// nodeClassEvents enqueues EC2NodeClasses to be re-resolved when the resources that they select change
+	nodeClassEvents chan<- event.GenericEvent
 }
 
 func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder,
-	sqsProvider *sqs.Provider, unavailableOfferingsCache *cache.UnavailableOfferings) *Controller {
+	newSQSProvider func(queue string) *sqs.Provider, unavailableOfferingsCache *cache.UnavailableOfferings, accountProvider *account.Provider,
+	nodeClassEvents chan<- event.GenericEvent) *Controller {
 
 	return &Controller{
 		kubeClient:                kubeClient,
 		clk:                       clk,
 		recorder:                  recorder,
-		sqsProvider:               sqsProvider,
+		newSQSProvider:            newSQSProvider,
 		unavailableOfferingsCache: unavailableOfferingsCache,
-		parser:                    NewEventParser(DefaultParsers...),
+		accountProvider:           accountProvider,
+		nodeClassEvents:           nodeClassEvents,
+		parser:                    NewEventParser(lo.Flatten([][]messages.Parser{DefaultParsers, ResourceChangeParsers})...),
 		cm:                        pretty.NewChangeMonitor(),
+		instanceOwners:            gocache.New(cache.InstanceOwnerTTL, cache.DefaultCleanupInterval),
 	}
 }
 
 func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	queue := options.Live(ctx).InterruptionQueue
+	if queue == "" {
+		return reconcile.Result{RequeueAfter: disabledInterval}, nil
+	}
+	if queue != c.queue {
+		c.sqsProvider, c.queue = c.newSQSProvider(queue), queue
+	}
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("queue", c.sqsProvider.Name()))
 	if c.cm.HasChanged(c.sqsProvider.Name(), nil) {
 		logging.FromContext(ctx).Debugf("watching interruption queue")
@@ -100,20 +148,17 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("making node instance id map, %w", err)
 	}
-	errs := make([]error, len(sqsMessages))
-	workqueue.ParallelizeUntil(ctx, 10, len(sqsMessages), func(i int) {
-		msg, e := c.parseMessage(sqsMessages[i])
-		if e != nil {
-			// If we fail to parse, then we should delete the message but still log the error
-			logging.FromContext(ctx).Errorf("parsing message, %v", e)
-			errs[i] = c.deleteMessage(ctx, sqsMessages[i])
-			return
-		}
-		if e = c.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, msg); e != nil {
-			errs[i] = fmt.Errorf("handling message, %w", e)
-			return
+	// Messages in the same message group of a FIFO queue are handled in order. Every message on a standard queue
+	// is in its own group, so all messages are handled in parallel.
+	groups := lo.Values(lo.GroupBy(sqsMessages, sqs.MessageGroupID))
+	errs := make([]error, len(groups))
+	workqueue.ParallelizeUntil(ctx, 10, len(groups), func(i int) {
+		for j := range groups[i] {
+			// Stop on the first failure so that later messages in the group are not deleted ahead of this one
+			if errs[i] = c.handleSQSMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, &groups[i][j]); errs[i] != nil {
+				return
+			}
 		}
-		errs[i] = c.deleteMessage(ctx, sqsMessages[i])
 	})
 	if err = multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, err
@@ -121,6 +166,95 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 	return reconcile.Result{}, nil
 }
 
+// handleSQSMessage parses and handles the passed SQS message, deleting it from the queue once it has been handled
+func (c *Controller) handleSQSMessage(ctx context.Context, nodeClaimInstanceIDMap map[string]*v1beta1.NodeClaim,
+	nodeInstanceIDMap map[string]*v1.Node, raw *sqstypes.Message) error {
+
+	msg, err := c.parseMessage(raw)
+	if err != nil {
+		// If we fail to parse, then we should delete the message but still log the error
+		logging.FromContext(ctx).Errorf("parsing message, %v", err)
+		return c.deleteMessage(ctx, raw)
+	}
+	if err = c.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, msg); err != nil {
+		return fmt.Errorf("handling message, %w", err)
+	}
+	if options.FromContext(ctx).SharedInterruptionQueue {
+		owner, err := c.otherOwner(ctx, nodeClaimInstanceIDMap, msg)
+		if err != nil {
+			return fmt.Errorf("resolving instance owner, %w", err)
+		}
+		if owner != "" {
+			if receives := sqs.ReceiveCount(raw); receives >= maxReleaseReceives {
+				logging.FromContext(ctx).With("cluster", owner, "receives", receives).Debugf("deleting message for instance launched by another cluster, message was released too many times")
+				return c.deleteMessage(ctx, raw)
+			}
+			logging.FromContext(ctx).With("cluster", owner).Debugf("releasing message for instance launched by another cluster")
+			return c.releaseMessage(ctx, raw)
+		}
+	}
+	return c.deleteMessage(ctx, raw)
+}
+
+// otherOwner returns the name of a different cluster that launched one of the instances in the message, if any
+func (c *Controller) otherOwner(ctx context.Context, nodeClaimInstanceIDMap map[string]*v1beta1.NodeClaim, msg messages.Message) (string, error) {
+	instanceIDs := lo.Reject(msg.EC2InstanceIDs(), func(id string, _ int) bool {
+		_, ok := nodeClaimInstanceIDMap[id]
+		return ok
+	})
+	if len(instanceIDs) == 0 {
+		return "", nil
+	}
+	roles, err := c.roles(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, instanceID := range instanceIDs {
+		owner, err := c.instanceOwner(ctx, roles, instanceID)
+		if err != nil {
+			return "", err
+		}
+		if owner != "" && owner != options.FromContext(ctx).ClusterName {
+			return owner, nil
+		}
+	}
+	return "", nil
+}
+
+// roles returns Karpenter's own account and the role of every EC2NodeClass that assumes a role, since messages on a
+// shared queue may be for instances in any of their accounts
+func (c *Controller) roles(ctx context.Context) ([]account.Role, error) {
+	nodeClassList := &apisv1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return nil, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	return lo.Uniq(append([]account.Role{{}}, lo.Map(nodeClassList.Items, func(nc apisv1beta1.EC2NodeClass, _ int) account.Role {
+		return account.RoleFor(&nc)
+	})...)), nil
+}
+
+// instanceOwner returns the name of the cluster that launched the instance, or an empty string if the instance
+// wasn't launched by Karpenter or isn't found in any of the accounts. Owners are only cached once the instance is found,
+// so that instances that aren't visible to DescribeInstances yet are looked up again on the next message.
+func (c *Controller) instanceOwner(ctx context.Context, roles []account.Role, instanceID string) (string, error) {
+	if owner, ok := c.instanceOwners.Get(instanceID); ok {
+		return owner.(string), nil
+	}
+	for _, role := range roles {
+		instance, err := c.accountProvider.ForRole(role).Instance.Get(ctx, instanceID)
+		if corecloudprovider.IsNodeClaimNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("getting instance %s, %w", instanceID, err)
+		}
+		owner := instance.Tags[v1beta1.ManagedByAnnotationKey]
+		c.instanceOwners.SetDefault(instanceID, owner)
+		return owner, nil
+	}
+	return "", nil
+}
+
 func (c *Controller) Name() string {
 	return "interruption"
 }
@@ -130,7 +264,7 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontrolle
 }
 
 // parseMessage parses the passed SQS message into an internal Message interface
-func (c *Controller) parseMessage(raw *sqsapi.Message) (messages.Message, error) {
+func (c *Controller) parseMessage(raw *sqstypes.Message) (messages.Message, error) {
 	// No message to parse in this case
 	if raw == nil || raw.Body == nil {
 		return nil, fmt.Errorf("message or message body is nil")
@@ -152,6 +286,9 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	if msg.Kind() == messages.NoOpKind {
 		return nil
 	}
+	if changes, ok := msg.(resourcechange.Changes); ok {
+		return c.invalidateCaches(ctx, changes)
+	}
 	for _, instanceID := range msg.EC2InstanceIDs() {
 		nodeClaim, ok := nodeClaimInstanceIDMap[instanceID]
 		if !ok {
@@ -169,8 +306,49 @@ func (c *Controller) handleMessage(ctx context.Context, nodeClaimInstanceIDMap m
 	return nil
 }
 
+// invalidateCaches removes the resources that were changed from the caches of Karpenter's own account and enqueues
+// the EC2NodeClasses in the account to be re-resolved, so that the changes are used without waiting on the caches to
+// expire
+func (c *Controller) invalidateCaches(ctx context.Context, changes resourcechange.Changes) error {
+	// Resource changes are only handled from the queue, since the interruption endpoint isn't given the providers whose
+	// caches are invalidated
+	if c.accountProvider == nil {
+		return nil
+	}
+	providers := c.accountProvider.Home()
+	for _, resource := range changes.Resources() {
+		switch resource {
+		case resourcechange.Subnet:
+			providers.Subnet.Invalidate()
+		case resourcechange.SecurityGroup:
+			providers.SecurityGroup.Invalidate()
+		case resourcechange.Image:
+			providers.AMI.Invalidate()
+		}
+	}
+	logging.FromContext(ctx).With("resources", changes.Resources()).Debugf("invalidated caches for changed resources")
+	if c.nodeClassEvents == nil {
+		return nil
+	}
+	nodeClassList := &apisv1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	for i := range nodeClassList.Items {
+		if account.RoleFor(&nodeClassList.Items[i]).ARN != "" {
+			continue
+		}
+		select {
+		case c.nodeClassEvents <- event.GenericEvent{Object: &nodeClassList.Items[i]}:
+		default:
+			// EC2NodeClasses that can't be enqueued are re-resolved when they are next requeued
+		}
+	}
+	return nil
+}
+
 // deleteMessage removes the passed SQS message from the queue and fires a metric for the deletion
-func (c *Controller) deleteMessage(ctx context.Context, msg *sqsapi.Message) error {
+func (c *Controller) deleteMessage(ctx context.Context, msg *sqstypes.Message) error {
 	if err := c.sqsProvider.DeleteSQSMessage(ctx, msg); err != nil {
 		return fmt.Errorf("deleting sqs message, %w", err)
 	}
@@ -178,17 +356,36 @@ func (c *Controller) deleteMessage(ctx context.Context, msg *sqsapi.Message) err
 	return nil
 }
 
+// releaseMessage returns the passed SQS message to the queue and fires a metric for the release
+func (c *Controller) releaseMessage(ctx context.Context, msg *sqstypes.Message) error {
+	if err := c.sqsProvider.ReleaseSQSMessage(ctx, msg, releaseVisibilityTimeout); err != nil {
+		return fmt.Errorf("releasing sqs message, %w", err)
+	}
+	releasedMessages.Inc()
+	return nil
+}
+
 // handleNodeClaim retrieves the action for the message and then performs the appropriate action against the node
 func (c *Controller) handleNodeClaim(ctx context.Context, msg messages.Message, nodeClaim *v1beta1.NodeClaim, node *v1.Node) error {
-	action := actionForMessage(msg)
+	action, err := c.actionForMessage(ctx, msg, nodeClaim)
+	if err != nil {
+		return fmt.Errorf("resolving action, %w", err)
+	}
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "action", string(action)))
 	if node != nil {
 		ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("node", node.Name))
 	}
 
 	// Record metric and event for this action
-	c.notifyForMessage(msg, nodeClaim, node)
+	c.notifyForMessage(ctx, msg, nodeClaim, node)
 	actionsPerformed.WithLabelValues(string(action)).Inc()
+	nodeClaimsInterrupted.With(prometheus.Labels{
+		messageTypeLabel:  string(msg.Kind()),
+		instanceTypeLabel: nodeClaim.Labels[v1.LabelInstanceTypeStable],
+		capacityTypeLabel: nodeClaim.Labels[v1beta1.CapacityTypeLabelKey],
+		zoneLabel:         nodeClaim.Labels[v1.LabelTopologyZone],
+		nodePoolLabel:     nodeClaim.Labels[v1beta1.NodePoolLabelKey],
+	}).Inc()
 
 	// Mark the offering as unavailable in the ICE cache since we got a spot interruption warning
 	if msg.Kind() == messages.SpotInterruptionKind {
@@ -198,9 +395,52 @@ func (c *Controller) handleNodeClaim(ctx context.Context, msg messages.Message,
 			c.unavailableOfferingsCache.MarkUnavailable(ctx, string(msg.Kind()), instanceType, zone, v1beta1.CapacityTypeSpot)
 		}
 	}
-	if action != NoAction {
+	switch action {
+	case CordonAndDrain:
+		if drainPolicy(ctx, msg) == options.DrainPolicyDelete {
+			if err = c.deletePods(ctx, node); err != nil {
+				return err
+			}
+		}
 		return c.deleteNodeClaim(ctx, nodeClaim, node)
+	case Cordon:
+		return c.cordonNode(ctx, node)
+	case MarkForReplacement:
+		return c.markNodeClaimForReplacement(ctx, nodeClaim, c.replacementAnnotations(msg))
+	default:
+		return nil
+	}
+}
+
+// cordonNode marks the node as unschedulable without evicting any of the pods running on it
+func (c *Controller) cordonNode(ctx context.Context, node *v1.Node) error {
+	if node == nil || node.Spec.Unschedulable {
+		return nil
+	}
+	stored := node.DeepCopy()
+	node.Spec.Unschedulable = true
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("cordoning the node on interruption message, %w", err))
 	}
+	logging.FromContext(ctx).Infof("cordoned node from interruption message")
+	return nil
+}
+
+// markNodeClaimForReplacement annotates the NodeClaim so that it is reported as drifted by the cloudprovider. This lets
+// the disruption controller replace it while respecting the disruption budgets of its NodePool.
+func (c *Controller) markNodeClaimForReplacement(ctx context.Context, nodeClaim *v1beta1.NodeClaim, annotations map[string]string) error {
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	if lo.Every(lo.Keys(nodeClaim.Annotations), lo.Keys(annotations)) {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, annotations)
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("marking the nodeclaim for replacement on interruption message, %w", err))
+	}
+	logging.FromContext(ctx).Infof("marked nodeclaim for replacement from interruption message")
 	return nil
 }
 
@@ -222,27 +462,88 @@ func (c *Controller) deleteNodeClaim(ctx context.Context, nodeClaim *v1beta1.Nod
 	return nil
 }
 
+// deletePods cordons the node and deletes the pods that are running on it without going through the eviction API. This
+// skips waiting on PodDisruptionBudgets so that pods are given their full termination grace period before the instance
+// is reclaimed. Pods that are owned by the node or by a DaemonSet are left to the termination controller.
+func (c *Controller) deletePods(ctx context.Context, node *v1.Node) error {
+	if node == nil {
+		return nil
+	}
+	if err := c.cordonNode(ctx, node); err != nil {
+		return err
+	}
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return fmt.Errorf("listing pods on interruption message, %w", err)
+	}
+	var errs error
+	for _, p := range lo.Filter(pods, func(p *v1.Pod, _ int) bool {
+		return podutils.IsActive(p) && !podutils.IsOwnedByDaemonSet(p) && !podutils.IsOwnedByNode(p)
+	}) {
+		if err = c.kubeClient.Delete(ctx, p); client.IgnoreNotFound(err) != nil {
+			errs = multierr.Append(errs, fmt.Errorf("deleting pod %s/%s on interruption message, %w", p.Namespace, p.Name, err))
+		}
+	}
+	return errs
+}
+
+// drainPolicy returns the configured drain policy for the message kind
+func drainPolicy(ctx context.Context, msg messages.Message) string {
+	// The drain policy has already been validated when parsing options
+	policies := lo.Must(options.ParseInterruptionDrainPolicy(options.FromContext(ctx).InterruptionDrainPolicy))
+	return lo.ValueOr(policies, strings.TrimSuffix(string(msg.Kind()), "Kind"), options.DrainPolicyEvict)
+}
+
+// replacementAnnotations returns the annotations that mark a NodeClaim for replacement based on the message kind
+func (c *Controller) replacementAnnotations(msg messages.Message) map[string]string {
+	switch msg.Kind() {
+	case messages.ScheduledChangeKind:
+		// The start time has already been validated when resolving the action
+		start := lo.Must(msg.(scheduledchange.Message).ScheduledStartTime())
+		return map[string]string{apisv1beta1.AnnotationScheduledMaintenance: start.UTC().Format(time.RFC3339)}
+	default:
+		return map[string]string{apisv1beta1.AnnotationRebalanceRecommended: c.clk.Now().UTC().Format(time.RFC3339)}
+	}
+}
+
 // notifyForMessage publishes the relevant alert based on the message kind
-func (c *Controller) notifyForMessage(msg messages.Message, nodeClaim *v1beta1.NodeClaim, n *v1.Node) {
+func (c *Controller) notifyForMessage(ctx context.Context, msg messages.Message, nodeClaim *v1beta1.NodeClaim, n *v1.Node) {
+	var evts []events.Event
 	switch msg.Kind() {
 	case messages.RebalanceRecommendationKind:
-		c.recorder.Publish(interruptionevents.RebalanceRecommendation(n, nodeClaim)...)
+		evts = interruptionevents.RebalanceRecommendation(n, nodeClaim)
 
 	case messages.ScheduledChangeKind:
-		c.recorder.Publish(interruptionevents.Unhealthy(n, nodeClaim)...)
+		evts = interruptionevents.Unhealthy(n, nodeClaim)
 
 	case messages.SpotInterruptionKind:
-		c.recorder.Publish(interruptionevents.SpotInterrupted(n, nodeClaim)...)
+		evts = interruptionevents.SpotInterrupted(n, nodeClaim)
 
 	case messages.StateChangeKind:
 		typed := msg.(statechange.Message)
 		if lo.Contains([]string{"stopping", "stopped"}, typed.Detail.State) {
-			c.recorder.Publish(interruptionevents.Stopping(n, nodeClaim)...)
+			evts = interruptionevents.Stopping(n, nodeClaim)
 		} else {
-			c.recorder.Publish(interruptionevents.Terminating(n, nodeClaim)...)
+			evts = interruptionevents.Terminating(n, nodeClaim)
 		}
 
 	default:
+		return
+	}
+	c.recorder.Publish(evts...)
+	if n == nil {
+		return
+	}
+	// Publish the event to the pods on the node as well, since application owners don't usually watch nodes
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, n)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("listing pods to publish interruption events to, %v", err)
+		return
+	}
+	for _, p := range pods {
+		if podutils.IsActive(p) && !podutils.IsOwnedByNode(p) {
+			c.recorder.Publish(interruptionevents.PodInterrupted(p, n, evts[0]))
+		}
 	}
 }
 
@@ -288,11 +589,56 @@ func (c *Controller) makeNodeInstanceIDMap(ctx context.Context) (map[string]*v1.
 	return m, nil
 }
 
-func actionForMessage(msg messages.Message) Action {
+func (c *Controller) actionForMessage(ctx context.Context, msg messages.Message, nodeClaim *v1beta1.NodeClaim) (Action, error) {
 	switch msg.Kind() {
-	case messages.ScheduledChangeKind, messages.SpotInterruptionKind, messages.StateChangeKind:
+	case messages.SpotInterruptionKind, messages.StateChangeKind:
+		return CordonAndDrain, nil
+	case messages.ScheduledChangeKind:
+		return c.actionForScheduledChange(ctx, msg.(scheduledchange.Message)), nil
+	case messages.RebalanceRecommendationKind:
+		return c.actionForRebalanceRecommendation(ctx, nodeClaim)
+	default:
+		return NoAction, nil
+	}
+}
+
+// actionForScheduledChange marks NodeClaims for replacement when there is enough time to replace them ahead
+// of the scheduled change while respecting disruption budgets. Otherwise, the NodeClaims are deleted immediately.
+func (c *Controller) actionForScheduledChange(ctx context.Context, msg scheduledchange.Message) Action {
+	start, err := msg.ScheduledStartTime()
+	if err != nil {
+		logging.FromContext(ctx).Errorf("resolving scheduled change window, %v", err)
 		return CordonAndDrain
+	}
+	if c.clk.Now().Before(start.Add(-options.FromContext(ctx).ScheduledMaintenanceLeadTime)) {
+		return MarkForReplacement
+	}
+	return CordonAndDrain
+}
+
+// actionForRebalanceRecommendation resolves the action from the rebalance recommendation handling configured
+// on the NodePool that owns the NodeClaim. Rebalance recommendations are ignored when no handling is configured.
+func (c *Controller) actionForRebalanceRecommendation(ctx context.Context, nodeClaim *v1beta1.NodeClaim) (Action, error) {
+	nodePoolName, ok := nodeClaim.Labels[v1beta1.NodePoolLabelKey]
+	if !ok {
+		return NoAction, nil
+	}
+	nodePool := &v1beta1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+		if errors.IsNotFound(err) {
+			return NoAction, nil
+		}
+		return NoAction, fmt.Errorf("getting nodepool, %w", err)
+	}
+	switch handling := nodePool.Annotations[apisv1beta1.AnnotationRebalanceRecommendationHandling]; handling {
+	case "", apisv1beta1.RebalanceRecommendationHandlingIgnore:
+		return NoAction, nil
+	case apisv1beta1.RebalanceRecommendationHandlingCordon:
+		return Cordon, nil
+	case apisv1beta1.RebalanceRecommendationHandlingReplace:
+		return MarkForReplacement, nil
 	default:
-		return NoAction
+		logging.FromContext(ctx).With("nodepool", nodePool.Name).Errorf("ignoring rebalance recommendation, unsupported %s value %q", apisv1beta1.AnnotationRebalanceRecommendationHandling, handling)
+		return NoAction, nil
 	}
 }
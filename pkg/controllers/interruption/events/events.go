@@ -15,10 +15,14 @@ limitations under the License.
 package events
 
 import (
+	"fmt"
+
 	v1 "k8s.io/api/core/v1"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/events"
+
+	apisv1beta1 "github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 )
 
 func SpotInterrupted(node *v1.Node, nodeClaim *v1beta1.NodeClaim) (evts []events.Event) {
@@ -140,3 +144,25 @@ func TerminatingOnInterruption(node *v1.Node, nodeClaim *v1beta1.NodeClaim) (evt
 	}
 	return evts
 }
+
+// PodInterrupted copies an interruption event that was published to a node onto one of the pods that is running on
+// that node, so that the owners of the pod can see why it was disrupted
+func PodInterrupted(pod *v1.Pod, node *v1.Node, evt events.Event) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           evt.Type,
+		Reason:         evt.Reason,
+		Message:        fmt.Sprintf("%s for node %s", evt.Message, node.Name),
+		DedupeValues:   []string{string(pod.UID), evt.Reason},
+	}
+}
+
+func InfrastructureFailed(nodeClass *apisv1beta1.EC2NodeClass, err error) events.Event {
+	return events.Event{
+		InvolvedObject: nodeClass,
+		Type:           v1.EventTypeWarning,
+		Reason:         "InterruptionInfrastructureFailed",
+		Message:        fmt.Sprintf("Interruptions aren't handled, ensuring the interruption queue and rules failed, %s", err),
+		DedupeValues:   []string{string(nodeClass.UID), err.Error()},
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/samber/lo"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	interruptionevents "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/eventbridge"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+)
+
+// messageGroupID is the message group that EventBridge adds events to on FIFO queues. The message group of an SQS target
+// is static, and input transformers only change the body of the message, so events can't be grouped by instance. Every
+// event is in the same group, which the controller handles one message at a time in the order that they were received.
+const messageGroupID = "karpenter-interruption"
+
+// maxRuleNameLength is the maximum length of an EventBridge rule name
+const maxRuleNameLength = 64
+
+// infrastructureInterval is how often the interruption queue and rules are ensured once they've been ensured, so that
+// rules which are deleted or changed outside of Karpenter are restored
+const infrastructureInterval = time.Hour
+
+var invalidRuleNameCharacters = regexp.MustCompile(`[^.\-_A-Za-z0-9]`)
+
+// InfrastructureController ensures the interruption queue and rules when they're managed by Karpenter. Failures are
+// retried with backoff rather than stopping the controller, since they're usually transient or fixed by changing the
+// controller's permissions, and a warning event is published on every EC2NodeClass while interruptions aren't handled.
+type InfrastructureController struct {
+	kubeClient          client.Client
+	recorder            events.Recorder
+	sqsapi              sdk.SQSAPI
+	eventBridgeProvider *eventbridge.Provider
+}
+
+func NewInfrastructureController(kubeClient client.Client, recorder events.Recorder, sqsapi sdk.SQSAPI, eventBridgeProvider *eventbridge.Provider) *InfrastructureController {
+	return &InfrastructureController{
+		kubeClient:          kubeClient,
+		recorder:            recorder,
+		sqsapi:              sqsapi,
+		eventBridgeProvider: eventBridgeProvider,
+	}
+}
+
+func (c *InfrastructureController) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	if err := EnsureInfrastructure(ctx, c.sqsapi, c.eventBridgeProvider); err != nil {
+		nodeClassList := &v1beta1.EC2NodeClassList{}
+		if listErr := c.kubeClient.List(ctx, nodeClassList); listErr != nil {
+			logging.FromContext(ctx).Errorf("listing ec2nodeclasses, %s", listErr)
+		}
+		for i := range nodeClassList.Items {
+			c.recorder.Publish(interruptionevents.InfrastructureFailed(&nodeClassList.Items[i], err))
+		}
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: infrastructureInterval}, nil
+}
+
+func (c *InfrastructureController) Name() string {
+	return "interruption.infrastructure"
+}
+
+func (c *InfrastructureController) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
+
+// EnsureInfrastructure creates or updates the interruption queue and an EventBridge rule for each of the
+// Parsers so that every event that the controller handles is forwarded to the queue
+func EnsureInfrastructure(ctx context.Context, sqsapi sdk.SQSAPI, eventBridgeProvider *eventbridge.Provider) error {
+	queueName := options.Live(ctx).InterruptionQueue
+	tags, err := options.ParseInterruptionQueueTags(options.FromContext(ctx).InterruptionQueueTags)
+	if err != nil {
+		return fmt.Errorf("parsing interruption queue tags, %w", err)
+	}
+	arn, err := sqs.EnsureQueue(ctx, sqsapi, queueName, options.FromContext(ctx).InterruptionQueueKMSKeyID, tags)
+	if err != nil {
+		return fmt.Errorf("ensuring interruption queue, %w", err)
+	}
+	target := eventbridge.Target{ARN: arn}
+	if sqs.IsFIFO(queueName) {
+		target.MessageGroupID = messageGroupID
+	}
+	for _, rule := range Rules(queueName, Parsers(ctx)) {
+		if err = eventBridgeProvider.EnsureRule(ctx, rule, target, tags); err != nil {
+			return fmt.Errorf("ensuring interruption rules, %w", err)
+		}
+	}
+	logging.FromContext(ctx).With("queue", queueName).Debugf("ensured interruption queue and rules")
+	return nil
+}
+
+// Rules returns the EventBridge rules that forward the events handled by the parsers to the queue.
+// Rules are named after the queue and the detail type of the event that they match, e.g.
+// <queue>-EC2SpotInstanceInterruptionWarning.
+func Rules(queueName string, parsers []messages.Parser) []eventbridge.Rule {
+	return lo.Map(parsers, func(p messages.Parser, _ int) eventbridge.Rule {
+		suffix := invalidRuleNameCharacters.ReplaceAllString(p.DetailType(), "")
+		// Truncate the queue name rather than the suffix so that rule names remain unique
+		prefix := lo.Substring(invalidRuleNameCharacters.ReplaceAllString(queueName, ""), 0, uint(maxRuleNameLength-len(suffix)-1))
+		rule := eventbridge.Rule{
+			Name:       fmt.Sprintf("%s-%s", prefix, suffix),
+			Source:     p.Source(),
+			DetailType: p.DetailType(),
+		}
+		if filter, ok := p.(messages.DetailFilter); ok {
+			rule.Detail = filter.Detail()
+		}
+		return rule
+	})
+}
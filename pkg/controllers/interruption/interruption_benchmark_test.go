@@ -24,12 +24,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	awsclient "github.com/aws/aws-sdk-go/aws/client"
-	"github.com/aws/aws-sdk-go/aws/endpoints"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/aws/session"
-	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/config"
+	servicesqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
@@ -112,7 +109,7 @@ func benchmarkNotificationController(b *testing.B, messageCount int) {
 	unavailableOfferingsCache = awscache.NewUnavailableOfferings()
 
 	// Set-up the controllers
-	interruptionController := interruption.NewController(env.Client, fakeClock, recorder, providers.sqsProvider, unavailableOfferingsCache)
+	interruptionController := interruption.NewController(env.Client, fakeClock, recorder, func(string) *sqs.Provider { return providers.sqsProvider }, unavailableOfferingsCache, nil, nil)
 
 	messages, nodes := makeDiverseMessagesAndNodes(messageCount)
 	logging.FromContext(ctx).Infof("provisioning nodes")
@@ -161,30 +158,24 @@ func benchmarkNotificationController(b *testing.B, messageCount int) {
 
 type providerSet struct {
 	kubeClient  client.Client
-	sqsAPI      *servicesqs.SQS
+	sqsAPI      *servicesqs.Client
 	sqsProvider *sqs.Provider
 }
 
 func newProviders(ctx context.Context, kubeClient client.Client) providerSet {
-	sess := session.Must(session.NewSession(
-		request.WithRetryer(
-			&aws.Config{STSRegionalEndpoint: endpoints.RegionalSTSEndpoint},
-			awsclient.DefaultRetryer{NumMaxRetries: awsclient.DefaultRetryerMaxNumRetries},
-		),
-	))
-	sqsAPI := servicesqs.New(sess)
+	sqsAPI := servicesqs.NewFromConfig(lo.Must(config.LoadDefaultConfig(ctx)))
 	return providerSet{
 		kubeClient:  kubeClient,
 		sqsAPI:      sqsAPI,
-		sqsProvider: sqs.NewProvider(ctx, sqsAPI, "test-cluster"),
+		sqsProvider: sqs.NewProvider(sqsAPI, "test-cluster"),
 	}
 }
 
 func (p *providerSet) makeInfrastructure(ctx context.Context) error {
-	if _, err := p.sqsAPI.CreateQueueWithContext(ctx, &servicesqs.CreateQueueInput{
+	if _, err := p.sqsAPI.CreateQueue(ctx, &servicesqs.CreateQueueInput{
 		QueueName: lo.ToPtr(options.FromContext(ctx).InterruptionQueueName),
-		Attributes: map[string]*string{
-			servicesqs.QueueAttributeNameMessageRetentionPeriod: aws.String("1200"), // 20 minutes for this test
+		Attributes: map[string]string{
+			string(sqstypes.QueueAttributeNameMessageRetentionPeriod): "1200", // 20 minutes for this test
 		},
 	}); err != nil {
 		return fmt.Errorf("creating servicesqs queue, %w", err)
@@ -197,7 +188,7 @@ func (p *providerSet) cleanupInfrastructure(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("discovering queue url for deletion, %w", err)
 	}
-	if _, err = p.sqsAPI.DeleteQueueWithContext(ctx, &servicesqs.DeleteQueueInput{
+	if _, err = p.sqsAPI.DeleteQueue(ctx, &servicesqs.DeleteQueueInput{
 		QueueUrl: lo.ToPtr(queueURL),
 	}); err != nil {
 		return fmt.Errorf("deleting servicesqs queue, %w", err)
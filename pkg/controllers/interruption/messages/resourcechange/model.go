@@ -0,0 +1,126 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcechange
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// Resource is a kind of EC2 resource that is cached by Karpenter
+type Resource string
+
+const (
+	Subnet        Resource = "subnet"
+	SecurityGroup Resource = "security-group"
+	Image         Resource = "image"
+)
+
+// eventResources are the resources that are changed by API calls that act on a single kind of resource
+var eventResources = map[string]Resource{
+	"CreateSubnet":          Subnet,
+	"DeleteSubnet":          Subnet,
+	"ModifySubnetAttribute": Subnet,
+	"CreateSecurityGroup":   SecurityGroup,
+	"DeleteSecurityGroup":   SecurityGroup,
+}
+
+// idPrefixResources are the resources whose IDs start with each prefix
+var idPrefixResources = map[string]Resource{
+	"subnet": Subnet,
+	"sg":     SecurityGroup,
+	"ami":    Image,
+}
+
+// Changes is a message that reports changes to resources that are cached by Karpenter
+type Changes interface {
+	messages.Message
+	Resources() []Resource
+}
+
+// Message contains the properties defined in AWS EventBridge schema
+// aws.ec2@AWSAPICallViaCloudTrail v0 that describe the resources that were changed by the API call.
+type Message struct {
+	messages.Metadata
+
+	Detail Detail `json:"detail"`
+}
+
+func (Message) EC2InstanceIDs() []string {
+	return []string{}
+}
+
+func (Message) Kind() messages.Kind {
+	return messages.ResourceChangeKind
+}
+
+// Resources returns the kinds of resources that were changed by the API call
+func (m Message) Resources() []Resource {
+	if resource, ok := eventResources[m.Detail.EventName]; ok {
+		return []Resource{resource}
+	}
+	// Resources of every kind are tagged by the same API calls, so the kind is taken from the prefix of each resource ID
+	return lo.Uniq(lo.FilterMap(m.Detail.RequestParameters.ResourcesSet.Items, func(item ResourceItem, _ int) (Resource, bool) {
+		resource, ok := idPrefixResources[strings.SplitN(item.ResourceID, "-", 2)[0]]
+		return resource, ok
+	}))
+}
+
+type Detail struct {
+	EventSource       string            `json:"eventSource"`
+	EventName         string            `json:"eventName"`
+	ErrorCode         string            `json:"errorCode"`
+	RequestParameters RequestParameters `json:"requestParameters"`
+}
+
+type RequestParameters struct {
+	ResourcesSet ResourcesSet `json:"resourcesSet"`
+}
+
+type ResourcesSet struct {
+	Items []ResourceItem `json:"items"`
+}
+
+type ResourceItem struct {
+	ResourceID string `json:"resourceId"`
+}
+
+// ImageStateMessage contains the properties defined in AWS EventBridge schema
+// aws.ec2@EC2AMIStateChange v0.
+type ImageStateMessage struct {
+	messages.Metadata
+
+	Detail ImageStateDetail `json:"detail"`
+}
+
+func (ImageStateMessage) EC2InstanceIDs() []string {
+	return []string{}
+}
+
+func (ImageStateMessage) Kind() messages.Kind {
+	return messages.ResourceChangeKind
+}
+
+func (ImageStateMessage) Resources() []Resource {
+	return []Resource{Image}
+}
+
+type ImageStateDetail struct {
+	ImageID string `json:"ImageId"`
+	State   string `json:"State"`
+}
@@ -0,0 +1,97 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcechange
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+)
+
+// eventNames are the API calls that change the subnets, security groups and images that are selected by EC2NodeClasses
+var eventNames = func() []string {
+	names := append(lo.Keys(eventResources), "CreateTags", "DeleteTags")
+	sort.Strings(names)
+	return names
+}()
+
+// acceptedImageStates are the states in which an image starts or stops being usable
+var acceptedImageStates = sets.New("available", "deregistered", "disabled")
+
+type Parser struct{}
+
+func (p Parser) Parse(raw string) (messages.Message, error) {
+	msg := Message{}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("unmarshalling the message as AWSAPICallViaCloudTrail, %w", err)
+	}
+	// We ignore API calls that failed or that didn't change any resources that we cache
+	if msg.Detail.ErrorCode != "" || len(msg.Resources()) == 0 {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+func (p Parser) Version() string {
+	return "0"
+}
+
+func (p Parser) Source() string {
+	return "aws.ec2"
+}
+
+func (p Parser) DetailType() string {
+	return "AWS API Call via CloudTrail"
+}
+
+// Detail limits the API calls that are forwarded to the queue, since every EC2 API call has the same detail type
+func (p Parser) Detail() map[string][]string {
+	return map[string][]string{
+		"eventSource": {"ec2.amazonaws.com"},
+		"eventName":   eventNames,
+	}
+}
+
+type ImageStateParser struct{}
+
+func (p ImageStateParser) Parse(raw string) (messages.Message, error) {
+	msg := ImageStateMessage{}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		return nil, fmt.Errorf("unmarshalling the message as EC2AMIStateChange, %w", err)
+	}
+	// We ignore states that don't change whether the image can be used, such as pending
+	if !acceptedImageStates.Has(strings.ToLower(msg.Detail.State)) {
+		return nil, nil
+	}
+	return msg, nil
+}
+
+func (p ImageStateParser) Version() string {
+	return "0"
+}
+
+func (p ImageStateParser) Source() string {
+	return "aws.ec2"
+}
+
+func (p ImageStateParser) DetailType() string {
+	return "EC2 AMI State Change"
+}
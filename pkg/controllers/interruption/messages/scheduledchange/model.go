@@ -15,6 +15,9 @@ limitations under the License.
 package scheduledchange
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
 )
 
@@ -38,6 +41,17 @@ func (Message) Kind() messages.Kind {
 	return messages.ScheduledChangeKind
 }
 
+// ScheduledStartTime returns the start of the window in which the scheduled change, such as an instance
+// retirement or a reboot for maintenance, will be performed
+func (m Message) ScheduledStartTime() (time.Time, error) {
+	for _, layout := range []string{time.RFC1123, time.RFC3339} {
+		if t, err := time.Parse(layout, m.Detail.StartTime); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("parsing start time %q", m.Detail.StartTime)
+}
+
 type Detail struct {
 	EventARN          string             `json:"eventArn"`
 	EventTypeCode     string             `json:"eventTypeCode"`
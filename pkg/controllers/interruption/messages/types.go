@@ -26,6 +26,12 @@ type Parser interface {
 	DetailType() string
 }
 
+// DetailFilter is implemented by parsers that only handle events whose detail matches the filter, so that other events
+// with the same source and detail type aren't forwarded to the queue
+type DetailFilter interface {
+	Detail() map[string][]string
+}
+
 type Message interface {
 	EC2InstanceIDs() []string
 	Kind() Kind
@@ -39,6 +45,7 @@ const (
 	ScheduledChangeKind         Kind = "ScheduledChangeKind"
 	SpotInterruptionKind        Kind = "SpotInterruptionKind"
 	StateChangeKind             Kind = "StateChangeKind"
+	ResourceChangeKind          Kind = "ResourceChangeKind"
 	NoOpKind                    Kind = "NoOpKind"
 )
 
@@ -26,6 +26,10 @@ const (
 	messageTypeLabel       = "message_type"
 	actionTypeLabel        = "action_type"
 	terminationReasonLabel = "interruption"
+	instanceTypeLabel      = "instance_type"
+	capacityTypeLabel      = "capacity_type"
+	zoneLabel              = "zone"
+	nodePoolLabel          = "nodepool"
 )
 
 var (
@@ -46,6 +50,14 @@ var (
 			Help:      "Count of messages deleted from the SQS queue.",
 		},
 	)
+	releasedMessages = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "released_messages",
+			Help:      "Count of messages returned to a shared SQS queue to be handled by the cluster that launched the instance.",
+		},
+	)
 	messageLatency = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Namespace: metrics.Namespace,
@@ -64,8 +76,17 @@ var (
 		},
 		[]string{actionTypeLabel},
 	)
+	nodeClaimsInterrupted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: interruptionSubsystem,
+			Name:      "nodeclaims_interrupted",
+			Help:      "Number of NodeClaims that interruption messages were received for. Labeled by message type, instance type, capacity type, zone, and NodePool.",
+		},
+		[]string{messageTypeLabel, instanceTypeLabel, capacityTypeLabel, zoneLabel, nodePoolLabel},
+	)
 )
 
 func init() {
-	crmetrics.Registry.MustRegister(receivedMessages, deletedMessages, messageLatency, actionsPerformed)
+	crmetrics.Registry.MustRegister(receivedMessages, deletedMessages, releasedMessages, messageLatency, actionsPerformed, nodeClaimsInterrupted)
 }
@@ -15,6 +15,7 @@ limitations under the License.
 package interruption
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -23,9 +24,11 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/noop"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rebalancerecommendation"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/resourcechange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
 type parserKey struct {
@@ -57,8 +60,21 @@ var (
 		scheduledchange.Parser{},
 		rebalancerecommendation.Parser{},
 	}
+	// ResourceChangeParsers handle events for changes to the subnets, security groups and images that are cached
+	ResourceChangeParsers = []messages.Parser{
+		resourcechange.Parser{},
+		resourcechange.ImageStateParser{},
+	}
 )
 
+// Parsers returns the parsers of the events that are forwarded to the queue
+func Parsers(ctx context.Context) []messages.Parser {
+	if options.FromContext(ctx).ResourceChangeEvents {
+		return lo.Flatten([][]messages.Parser{DefaultParsers, ResourceChangeParsers})
+	}
+	return DefaultParsers
+}
+
 type EventParser struct {
 	parserMap map[parserKey]messages.Parser
 }
@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interruption
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	// APIKeyHeader is the header that EventBridge connections must set to the configured API key
+	APIKeyHeader = "X-Karpenter-Api-Key"
+	// maxEventSize is the maximum size of an event body. EventBridge events are limited to 256KB.
+	maxEventSize = 256 * 1024
+)
+
+// Server receives interruption events that are pushed to Karpenter by an EventBridge API destination, as an
+// alternative to polling an SQS queue. Events are handled in the same way as messages received from the queue.
+type Server struct {
+	controller *Controller
+	apiKey     string
+	port       int
+	// elected is closed once this replica is the leader
+	elected <-chan struct{}
+}
+
+func NewServer(ctx context.Context, kubeClient client.Client, clk clock.Clock, recorder events.Recorder,
+	unavailableOfferingsCache *cache.UnavailableOfferings, elected <-chan struct{}) *Server {
+
+	return &Server{
+		controller: NewController(kubeClient, clk, recorder, nil, unavailableOfferingsCache, nil, nil),
+		apiKey:     options.FromContext(ctx).InterruptionEndpointAPIKey,
+		port:       options.FromContext(ctx).InterruptionEndpointPort,
+		elected:    elected,
+	}
+}
+
+// Start serves the interruption endpoint until the context is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.port),
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logging.FromContext(ctx).Errorf("shutting down interruption endpoint, %v", err)
+		}
+	}()
+	logging.FromContext(ctx).With("port", s.port).Infof("serving interruption endpoint")
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving interruption endpoint, %w", err)
+	}
+	return nil
+}
+
+// NeedLeaderElection is false so that every replica behind the endpoint accepts connections, but only the leader
+// handles events, since the offerings that are marked as unavailable are only read by the leader's provisioner. Other
+// replicas ask EventBridge to retry, and handling an event is idempotent, so events that are retried against the
+// leader are safe.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// ServeHTTP handles a single event. EventBridge retries requests that fail with a 5xx or 429 response, so only
+// failures to act on the event return those codes.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if s.apiKey == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(APIKeyHeader)), []byte(s.apiKey)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	select {
+	case <-s.elected:
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "not the leader", http.StatusServiceUnavailable)
+		return
+	}
+	ctx := logging.WithLogger(r.Context(), logging.FromContext(r.Context()).With("endpoint", r.URL.Path))
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxEventSize+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading event, %s", err), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxEventSize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	msg, err := s.controller.parser.Parse(string(body))
+	if err != nil {
+		logging.FromContext(ctx).Errorf("parsing event, %v", err)
+		http.Error(w, fmt.Sprintf("parsing event, %s", err), http.StatusBadRequest)
+		return
+	}
+	if err = s.handleMessage(ctx, msg); err != nil {
+		logging.FromContext(ctx).Errorf("handling event, %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleMessage(ctx context.Context, msg messages.Message) error {
+	nodeClaimInstanceIDMap, err := s.controller.makeNodeClaimInstanceIDMap(ctx)
+	if err != nil {
+		return fmt.Errorf("making nodeclaim instance id map, %w", err)
+	}
+	nodeInstanceIDMap, err := s.controller.makeNodeInstanceIDMap(ctx)
+	if err != nil {
+		return fmt.Errorf("making node instance id map, %w", err)
+	}
+	return s.controller.handleMessage(ctx, nodeClaimInstanceIDMap, nodeInstanceIDMap, msg)
+}
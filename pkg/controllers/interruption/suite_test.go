@@ -15,17 +15,25 @@ limitations under the License.
 package interruption_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	serviceeventbridge "github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	servicesqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
@@ -33,6 +41,8 @@ import (
 	clock "k8s.io/utils/clock/testing"
 	_ "knative.dev/pkg/system/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -41,14 +51,21 @@ import (
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/rebalancerecommendation"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/resourcechange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/scheduledchange"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/spotinterruption"
 	"github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/messages/statechange"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/eventbridge"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/sqs"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -65,6 +82,7 @@ const (
 
 var ctx context.Context
 var env *coretest.Environment
+var awsEnv *test.Environment
 var sqsapi *fake.SQSAPI
 var sqsProvider *sqs.Provider
 var unavailableOfferingsCache *awscache.UnavailableOfferings
@@ -79,11 +97,14 @@ func TestAPIs(t *testing.T) {
 
 var _ = BeforeSuite(func() {
 	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionQueue: lo.ToPtr("test-cluster")}))
+	awsEnv = test.NewEnvironment(ctx, env)
 	fakeClock = &clock.FakeClock{}
 	unavailableOfferingsCache = awscache.NewUnavailableOfferings()
 	sqsapi = &fake.SQSAPI{}
-	sqsProvider = lo.Must(sqs.NewProvider(ctx, sqsapi, "test-cluster"))
-	controller = interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProvider, unavailableOfferingsCache)
+	sqsProvider = sqs.NewProvider(sqsapi, "test-cluster")
+	controller = interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProviderFor(sqsProvider), unavailableOfferingsCache, awsEnv.AccountProvider, nil)
 })
 
 var _ = AfterSuite(func() {
@@ -92,8 +113,10 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionQueue: lo.ToPtr("test-cluster")}))
 	unavailableOfferingsCache.Flush()
 	sqsapi.Reset()
+	awsEnv.Reset()
 })
 
 var _ = AfterEach(func() {
@@ -125,6 +148,69 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectNotFound(ctx, env.Client, nodeClaim)
 			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
 		})
+		It("should record the interrupted NodeClaim by instance type, capacity type, zone, and NodePool", func() {
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+				v1.LabelInstanceTypeStable:       "m5.metal",
+				corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeSpot,
+				v1.LabelTopologyZone:             "test-zone-metrics",
+			})
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			ExpectMetricCounterValue("karpenter_interruption_nodeclaims_interrupted", 1, map[string]string{
+				"message_type":  string(messages.SpotInterruptionKind),
+				"instance_type": "m5.metal",
+				"capacity_type": corev1beta1.CapacityTypeSpot,
+				"zone":          "test-zone-metrics",
+				"nodepool":      "default",
+			})
+		})
+		It("should not delete pods when receiving a spot interruption warning with the default drain policy", func() {
+			pod := coretest.Pod(coretest.PodOptions{NodeName: node.Name})
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			pod = ExpectExists(ctx, env.Client, pod)
+			Expect(pod.DeletionTimestamp.IsZero()).To(BeTrue())
+		})
+		It("should cordon the node and delete pods when receiving a spot interruption warning with the Delete drain policy", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				InterruptionQueue:       lo.ToPtr("test-cluster"),
+				InterruptionDrainPolicy: lo.ToPtr(`{"SpotInterruption":"Delete"}`),
+			}))
+			pod := coretest.Pod(coretest.PodOptions{NodeName: node.Name})
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(ExpectExists(ctx, env.Client, node).Spec.Unschedulable).To(BeTrue())
+			err := env.Client.Get(ctx, client.ObjectKeyFromObject(pod), pod)
+			Expect(errors.IsNotFound(err) || !pod.DeletionTimestamp.IsZero()).To(BeTrue())
+		})
+		It("should publish the interruption event to the pods on the node", func() {
+			recorder := coretest.NewEventRecorder()
+			eventController := interruption.NewController(env.Client, fakeClock, recorder, sqsProviderFor(sqsProvider), unavailableOfferingsCache, awsEnv.AccountProvider, nil)
+			pod := coretest.Pod(coretest.PodOptions{NodeName: node.Name})
+			terminalPod := coretest.Pod(coretest.PodOptions{NodeName: node.Name, Phase: v1.PodSucceeded})
+			ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+			ExpectApplied(ctx, env.Client, nodeClaim, node, pod, terminalPod)
+
+			ExpectReconcileSucceeded(ctx, eventController, types.NamespacedName{})
+			var podEvents []events.Event
+			recorder.ForEachEvent(func(evt events.Event) {
+				if _, ok := evt.InvolvedObject.(*v1.Pod); ok {
+					podEvents = append(podEvents, evt)
+				}
+			})
+			Expect(podEvents).To(HaveLen(1))
+			Expect(podEvents[0].InvolvedObject.(*v1.Pod).Name).To(Equal(pod.Name))
+			Expect(podEvents[0].Reason).To(Equal("SpotInterrupted"))
+			Expect(podEvents[0].Message).To(ContainSubstring(node.Name))
+		})
 		It("should delete the NodeClaim when receiving a scheduled change message", func() {
 			ExpectMessagesCreated(scheduledChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
 			ExpectApplied(ctx, env.Client, nodeClaim, node)
@@ -134,6 +220,28 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectNotFound(ctx, env.Client, nodeClaim)
 			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
 		})
+		It("should mark the NodeClaim for replacement when receiving a scheduled change message ahead of the lead time", func() {
+			msg := scheduledChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))
+			start := fakeClock.Now().Add(24 * time.Hour).UTC()
+			msg.Detail.StartTime = start.Format(time.RFC1123)
+			ExpectMessagesCreated(msg)
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationScheduledMaintenance, start.Truncate(time.Second).Format(time.RFC3339)))
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
+		It("should delete the NodeClaim when receiving a scheduled change message within the lead time", func() {
+			msg := scheduledChangeMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))
+			msg.Detail.StartTime = fakeClock.Now().Add(30 * time.Minute).UTC().Format(time.RFC1123)
+			ExpectMessagesCreated(msg)
+			ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		})
 		It("should delete the NodeClaim when receiving a state change message", func() {
 			var nodeClaims []*corev1beta1.NodeClaim
 			var messages []interface{}
@@ -190,7 +298,7 @@ var _ = Describe("InterruptionHandling", func() {
 			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(100))
 		})
 		It("should delete a message when the message can't be parsed", func() {
-			badMessage := &servicesqs.Message{
+			badMessage := sqstypes.Message{
 				Body: aws.String(string(lo.Must(json.Marshal(map[string]string{
 					"field1": "value1",
 					"field2": "value2",
@@ -213,6 +321,74 @@ var _ = Describe("InterruptionHandling", func() {
 			ExpectExists(ctx, env.Client, nodeClaim)
 			Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
 		})
+		Context("Rebalance Recommendations", func() {
+			var nodePool *corev1beta1.NodePool
+			BeforeEach(func() {
+				nodePool = coretest.NodePool(corev1beta1.NodePool{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "default",
+					},
+				})
+			})
+			It("should not act on the NodeClaim when no rebalance recommendation handling is configured", func() {
+				ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+				ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+				node = ExpectExists(ctx, env.Client, node)
+				Expect(node.Spec.Unschedulable).To(BeFalse())
+				Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			})
+			It("should not act on the NodeClaim when rebalance recommendations are ignored", func() {
+				nodePool.Annotations = map[string]string{v1beta1.AnnotationRebalanceRecommendationHandling: v1beta1.RebalanceRecommendationHandlingIgnore}
+				ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+				ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+				node = ExpectExists(ctx, env.Client, node)
+				Expect(node.Spec.Unschedulable).To(BeFalse())
+			})
+			It("should not act on the NodeClaim when the rebalance recommendation handling is unsupported", func() {
+				nodePool.Annotations = map[string]string{v1beta1.AnnotationRebalanceRecommendationHandling: "Terminate"}
+				ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+				ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+				node = ExpectExists(ctx, env.Client, node)
+				Expect(node.Spec.Unschedulable).To(BeFalse())
+				Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			})
+			It("should cordon the Node when rebalance recommendations are set to cordon", func() {
+				nodePool.Annotations = map[string]string{v1beta1.AnnotationRebalanceRecommendationHandling: v1beta1.RebalanceRecommendationHandlingCordon}
+				ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+				ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+				node = ExpectExists(ctx, env.Client, node)
+				Expect(node.Spec.Unschedulable).To(BeTrue())
+				Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			})
+			It("should mark the NodeClaim for replacement when rebalance recommendations are set to replace", func() {
+				nodePool.Annotations = map[string]string{v1beta1.AnnotationRebalanceRecommendationHandling: v1beta1.RebalanceRecommendationHandlingReplace}
+				ExpectMessagesCreated(rebalanceRecommendationMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+				ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+				ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.Annotations).To(HaveKey(v1beta1.AnnotationRebalanceRecommended))
+				node = ExpectExists(ctx, env.Client, node)
+				Expect(node.Spec.Unschedulable).To(BeFalse())
+				Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+			})
+		})
 		It("should mark the ICE cache for the offering when getting a spot interruption warning", func() {
 			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
 				v1.LabelTopologyZone:             "coretest-zone-1a",
@@ -233,15 +409,459 @@ var _ = Describe("InterruptionHandling", func() {
 	})
 })
 
+var _ = Describe("Infrastructure", func() {
+	var eventbridgeapi *fake.EventBridgeAPI
+	BeforeEach(func() {
+		eventbridgeapi = &fake.EventBridgeAPI{}
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue:        lo.ToPtr("test-cluster"),
+			ManagedInterruptionQueue: lo.ToPtr(true),
+		}))
+	})
+	It("should create the queue and a rule for every handled event", func() {
+		Expect(interruption.EnsureInfrastructure(ctx, sqsapi, eventbridge.NewProvider(eventbridgeapi))).To(Succeed())
+		Expect(sqsapi.CreateQueueBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(aws.ToString(sqsapi.CreateQueueBehavior.CalledWithInput.Pop().QueueName)).To(Equal("test-cluster"))
+		Expect(sqsapi.SetQueueAttributesBehavior.CalledWithInput.Len()).To(Equal(1))
+		attributes := sqsapi.SetQueueAttributesBehavior.CalledWithInput.Pop().Attributes
+		Expect(attributes[string(sqstypes.QueueAttributeNameSqsManagedSseEnabled)]).To(Equal("true"))
+		Expect(attributes[string(sqstypes.QueueAttributeNamePolicy)]).To(ContainSubstring("events.amazonaws.com"))
+
+		Expect(eventbridgeapi.PutRuleBehavior.CalledWithInput.Len()).To(Equal(len(interruption.DefaultParsers)))
+		Expect(eventbridgeapi.PutTargetsBehavior.CalledWithInput.Len()).To(Equal(len(interruption.DefaultParsers)))
+		eventbridgeapi.PutTargetsBehavior.CalledWithInput.ForEach(func(input *serviceeventbridge.PutTargetsInput) {
+			Expect(input.Targets).To(HaveLen(1))
+			Expect(aws.ToString(input.Targets[0].Arn)).To(HavePrefix("arn:aws:sqs:"))
+		})
+		Expect(sqsapi.TagQueueBehavior.Calls()).To(Equal(0))
+		Expect(eventbridgeapi.TagResourceBehavior.Calls()).To(Equal(0))
+	})
+	It("should encrypt the queue with the configured KMS key and apply the configured tags", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue:         lo.ToPtr("test-cluster"),
+			ManagedInterruptionQueue:  lo.ToPtr(true),
+			InterruptionQueueKMSKeyID: lo.ToPtr("alias/karpenter"),
+			InterruptionQueueTags:     lo.ToPtr(`{"team":"platform"}`),
+		}))
+		Expect(interruption.EnsureInfrastructure(ctx, sqsapi, eventbridge.NewProvider(eventbridgeapi))).To(Succeed())
+		attributes := sqsapi.SetQueueAttributesBehavior.CalledWithInput.Pop().Attributes
+		Expect(attributes[string(sqstypes.QueueAttributeNameKmsMasterKeyId)]).To(Equal("alias/karpenter"))
+		Expect(attributes).ToNot(HaveKey(string(sqstypes.QueueAttributeNameSqsManagedSseEnabled)))
+		Expect(sqsapi.TagQueueBehavior.CalledWithInput.Pop().Tags).To(Equal(map[string]string{"team": "platform"}))
+		Expect(eventbridgeapi.TagResourceBehavior.CalledWithInput.Len()).To(Equal(len(interruption.DefaultParsers)))
+	})
+	It("should return an error when the queue can't be created", func() {
+		sqsapi.CreateQueueBehavior.Error.Set(awsErrWithCode("AccessDenied"))
+		Expect(interruption.EnsureInfrastructure(ctx, sqsapi, eventbridge.NewProvider(eventbridgeapi))).ToNot(Succeed())
+		Expect(eventbridgeapi.PutRuleBehavior.Calls()).To(Equal(0))
+	})
+	It("should retry ensuring the infrastructure and publish an event on every EC2NodeClass when it fails", func() {
+		nodeClass := test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+		recorder := coretest.NewEventRecorder()
+		infrastructureController := interruption.NewInfrastructureController(env.Client, recorder, sqsapi, eventbridge.NewProvider(eventbridgeapi))
+		sqsapi.CreateQueueBehavior.Error.Set(awsErrWithCode("AccessDenied"))
+		ExpectReconcileFailed(ctx, infrastructureController, types.NamespacedName{})
+		Expect(recorder.Calls("InterruptionInfrastructureFailed")).To(Equal(1))
+		Expect(recorder.Events()[0].InvolvedObject.(*v1beta1.EC2NodeClass).Name).To(Equal(nodeClass.Name))
+
+		result := ExpectReconcileSucceeded(ctx, infrastructureController, types.NamespacedName{})
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(eventbridgeapi.PutRuleBehavior.CalledWithInput.Len()).To(Equal(len(interruption.DefaultParsers)))
+		Expect(recorder.Calls("InterruptionInfrastructureFailed")).To(Equal(1))
+	})
+	It("should receive messages once the queue exists", func() {
+		queueController := interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProviderFor(sqs.NewProvider(sqsapi, "test-cluster")), unavailableOfferingsCache, awsEnv.AccountProvider, nil)
+		sqsapi.GetQueueURLBehavior.Error.Set(awsErrWithCode("AWS.SimpleQueueService.NonExistentQueue"))
+		ExpectReconcileFailed(ctx, queueController, types.NamespacedName{})
+		Expect(sqsapi.ReceiveMessageBehavior.Calls()).To(Equal(0))
+
+		ExpectReconcileSucceeded(ctx, queueController, types.NamespacedName{})
+		ExpectReconcileSucceeded(ctx, queueController, types.NamespacedName{})
+		Expect(sqsapi.ReceiveMessageBehavior.Calls()).To(Equal(2))
+		// the url of the queue is only looked up until it's found
+		Expect(sqsapi.GetQueueURLBehavior.Calls()).To(Equal(2))
+	})
+	It("should create rules for resource changes when resource change events are enabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue:        lo.ToPtr("test-cluster"),
+			ManagedInterruptionQueue: lo.ToPtr(true),
+			ResourceChangeEvents:     lo.ToPtr(true),
+		}))
+		Expect(interruption.EnsureInfrastructure(ctx, sqsapi, eventbridge.NewProvider(eventbridgeapi))).To(Succeed())
+		Expect(eventbridgeapi.PutRuleBehavior.CalledWithInput.Len()).To(Equal(len(interruption.DefaultParsers) + len(interruption.ResourceChangeParsers)))
+		var patterns []string
+		eventbridgeapi.PutRuleBehavior.CalledWithInput.ForEach(func(input *serviceeventbridge.PutRuleInput) {
+			patterns = append(patterns, aws.ToString(input.EventPattern))
+		})
+		// Only the API calls that change resources are forwarded, rather than every EC2 API call
+		Expect(patterns).To(ContainElement(And(ContainSubstring("AWS API Call via CloudTrail"), ContainSubstring(`"CreateTags"`))))
+		Expect(patterns).To(ContainElement(ContainSubstring("EC2 AMI State Change")))
+	})
+	It("should name rules uniquely when the queue name is long", func() {
+		parsers := lo.Flatten([][]messages.Parser{interruption.DefaultParsers, interruption.ResourceChangeParsers})
+		rules := interruption.Rules(strings.Repeat("a", 80), parsers)
+		Expect(lo.Uniq(lo.Map(rules, func(r eventbridge.Rule, _ int) string { return r.Name }))).To(HaveLen(len(parsers)))
+		for _, r := range rules {
+			Expect(len(r.Name)).To(BeNumerically("<=", 64))
+		}
+	})
+})
+
+var _ = Describe("Live Queues", func() {
+	It("should poll the interruption queue that is set while the controller runs", func() {
+		var queues []string
+		liveController := interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), func(queue string) *sqs.Provider {
+			queues = append(queues, queue)
+			return sqs.NewProvider(sqsapi, queue)
+		}, unavailableOfferingsCache, awsEnv.AccountProvider, nil)
+		ExpectReconcileSucceeded(ctx, liveController, types.NamespacedName{})
+
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionQueue: lo.ToPtr("https://sqs.us-west-2.amazonaws.com/111111111111/other-queue")}))
+		ExpectReconcileSucceeded(ctx, liveController, types.NamespacedName{})
+		ExpectReconcileSucceeded(ctx, liveController, types.NamespacedName{})
+		Expect(queues).To(Equal([]string{"test-cluster", "https://sqs.us-west-2.amazonaws.com/111111111111/other-queue"}))
+		Expect(aws.ToString(sqsapi.ReceiveMessageBehavior.CalledWithInput.Pop().QueueUrl)).To(Equal("https://sqs.us-west-2.amazonaws.com/111111111111/other-queue"))
+	})
+	It("should not poll while the interruption queue is unset", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(sqsapi.ReceiveMessageBehavior.Calls()).To(Equal(0))
+	})
+})
+
+var _ = Describe("Cross-Account Queues", func() {
+	It("should poll a queue by URL without resolving it", func() {
+		queueURL := "https://sqs.us-west-2.amazonaws.com/111111111111/central-interruption-queue"
+		crossAccountProvider := sqs.NewProvider(sqsapi, queueURL)
+		Expect(crossAccountProvider.Name()).To(Equal("central-interruption-queue"))
+		Expect(sqsapi.GetQueueURLBehavior.Calls()).To(Equal(0))
+
+		crossAccountController := interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProviderFor(crossAccountProvider), unavailableOfferingsCache, awsEnv.AccountProvider, nil)
+		ExpectReconcileSucceeded(ctx, crossAccountController, types.NamespacedName{})
+		Expect(aws.ToString(sqsapi.ReceiveMessageBehavior.CalledWithInput.Pop().QueueUrl)).To(Equal(queueURL))
+	})
+})
+
+var _ = Describe("Interruption Endpoint", func() {
+	var server *interruption.Server
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionEndpointPort:   lo.ToPtr(8090),
+			InterruptionEndpointAPIKey: lo.ToPtr("api-key"),
+		}))
+		server = interruption.NewServer(ctx, env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), unavailableOfferingsCache, elected())
+	})
+	It("should delete the NodeClaim when a spot interruption warning is pushed", func() {
+		nodeClaim, node := coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey: "default",
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+		resp := pushEvent(server, "api-key", spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		Expect(sqsapi.ReceiveMessageBehavior.Calls()).To(Equal(0))
+	})
+	It("should only handle events on the leader, where the provisioner reads unavailable offerings", func() {
+		nodeClaim, node := coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey:     "default",
+					v1.LabelTopologyZone:             "coretest-zone-1a",
+					v1.LabelInstanceTypeStable:       "t3.large",
+					corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeSpot,
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		event := spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID)))
+
+		nonLeader := interruption.NewServer(ctx, env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), unavailableOfferingsCache, make(chan struct{}))
+		resp := pushEvent(nonLeader, "api-key", event)
+		Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+		ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", corev1beta1.CapacityTypeSpot)).To(BeFalse())
+
+		// EventBridge retries the event until it reaches the leader
+		Expect(pushEvent(server, "api-key", event).Code).To(Equal(http.StatusOK))
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		Expect(unavailableOfferingsCache.IsUnavailable("t3.large", "coretest-zone-1a", corev1beta1.CapacityTypeSpot)).To(BeTrue())
+	})
+	It("should reject events without the API key", func() {
+		Expect(pushEvent(server, "", spotInterruptionMessage(fake.InstanceID())).Code).To(Equal(http.StatusUnauthorized))
+		Expect(pushEvent(server, "wrong-key", spotInterruptionMessage(fake.InstanceID())).Code).To(Equal(http.StatusUnauthorized))
+	})
+	It("should reject events that can't be parsed without asking for a retry", func() {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not-json"))
+		req.Header.Set(interruption.APIKeyHeader, "api-key")
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req.WithContext(ctx))
+		Expect(resp.Code).To(Equal(http.StatusBadRequest))
+	})
+	It("should only accept POST requests", func() {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(interruption.APIKeyHeader, "api-key")
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req.WithContext(ctx))
+		Expect(resp.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
+
+var _ = Describe("Shared Queues", func() {
+	var sharedController *interruption.Controller
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue:       lo.ToPtr("test-cluster"),
+			SharedInterruptionQueue: lo.ToPtr(true),
+		}))
+		// Use a new controller for every test so that instance owners aren't cached between tests
+		sharedController = interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProviderFor(sqsProvider), unavailableOfferingsCache, awsEnv.AccountProvider, nil)
+	})
+	It("should release messages for instances that were launched by another cluster", func() {
+		instanceID := fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, ec2Instance(instanceID, "other-cluster"))
+		ExpectMessagesCreated(spotInterruptionMessage(instanceID))
+
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.Calls()).To(Equal(0))
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.CalledWithInput.Len()).To(Equal(1))
+		Expect(int64(sqsapi.ChangeMessageVisibilityBehavior.CalledWithInput.Pop().VisibilityTimeout)).To(BeNumerically(">", 0))
+	})
+	It("should release messages for instances that were launched by another cluster in the account of an EC2NodeClass", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::111111111111:role/karpenter")}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		instanceID := fake.InstanceID()
+		awsEnv.AccountEC2API.Instances.Store(instanceID, ec2Instance(instanceID, "other-cluster"))
+		ExpectMessagesCreated(spotInterruptionMessage(instanceID))
+
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.Calls()).To(Equal(0))
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(1))
+	})
+	It("should delete messages that have been released too many times", func() {
+		instanceID := fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, ec2Instance(instanceID, "other-cluster"))
+		sqsapi.ReceiveMessageBehavior.Output.Set(&servicesqs.ReceiveMessageOutput{
+			Messages: []sqstypes.Message{{
+				Body:      aws.String(string(lo.Must(json.Marshal(spotInterruptionMessage(instanceID))))),
+				MessageId: aws.String(string(uuid.NewUUID())),
+				Attributes: map[string]string{
+					string(sqstypes.MessageSystemAttributeNameApproximateReceiveCount): "5",
+				},
+			}},
+		})
+
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(0))
+	})
+	It("should delete messages for instances that were launched by this cluster", func() {
+		nodeClaim, node := coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey: "default",
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		ExpectMessagesCreated(spotInterruptionMessage(lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))))
+
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(0))
+	})
+	It("should delete messages for instances that weren't launched by Karpenter", func() {
+		instanceID := fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, ec2Instance(instanceID, ""))
+		ExpectMessagesCreated(spotInterruptionMessage(instanceID))
+
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(0))
+	})
+	It("should delete messages for instances that no longer exist", func() {
+		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()))
+
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(0))
+	})
+	It("should cache the owner of instances between messages", func() {
+		instanceID := fake.InstanceID()
+		awsEnv.EC2API.Instances.Store(instanceID, ec2Instance(instanceID, "other-cluster"))
+		ExpectMessagesCreated(spotInterruptionMessage(instanceID))
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.DescribeInstancesBehavior.Calls()).To(Equal(1))
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(2))
+	})
+	It("should not cache instances that weren't found", func() {
+		instanceID := fake.InstanceID()
+		ExpectMessagesCreated(spotInterruptionMessage(instanceID))
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+
+		awsEnv.EC2API.Instances.Store(instanceID, ec2Instance(instanceID, "other-cluster"))
+		ExpectReconcileSucceeded(ctx, sharedController, types.NamespacedName{})
+		Expect(sqsapi.ChangeMessageVisibilityBehavior.Calls()).To(Equal(1))
+	})
+})
+
+var _ = Describe("FIFO Queues", func() {
+	It("should stop handling a message group when a message in the group fails", func() {
+		sqsapi.ReceiveMessageBehavior.Output.Set(&servicesqs.ReceiveMessageOutput{
+			Messages: []sqstypes.Message{noopMessage("group-a"), noopMessage("group-a")},
+		})
+		sqsapi.DeleteMessageBehavior.Error.Set(awsErrWithCode("InternalError"))
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.Calls()).To(Equal(1))
+	})
+	It("should continue handling other message groups when a message in one group fails", func() {
+		sqsapi.ReceiveMessageBehavior.Output.Set(&servicesqs.ReceiveMessageOutput{
+			Messages: []sqstypes.Message{noopMessage("group-a"), noopMessage("group-b")},
+		})
+		sqsapi.DeleteMessageBehavior.Error.Set(awsErrWithCode("InternalError"))
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.DeleteMessageBehavior.Calls()).To(Equal(2))
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+	})
+	It("should request the message group of received messages", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(sqsapi.ReceiveMessageBehavior.CalledWithInput.Pop().MessageSystemAttributeNames).To(ContainElement(sqstypes.MessageSystemAttributeNameMessageGroupId))
+	})
+	It("should create a FIFO queue and target it with a message group when managed", func() {
+		eventbridgeapi := &fake.EventBridgeAPI{}
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue:        lo.ToPtr("test-cluster.fifo"),
+			ManagedInterruptionQueue: lo.ToPtr(true),
+		}))
+		Expect(interruption.EnsureInfrastructure(ctx, sqsapi, eventbridge.NewProvider(eventbridgeapi))).To(Succeed())
+		attributes := sqsapi.CreateQueueBehavior.CalledWithInput.Pop().Attributes
+		Expect(attributes[string(sqstypes.QueueAttributeNameFifoQueue)]).To(Equal("true"))
+		eventbridgeapi.PutTargetsBehavior.CalledWithInput.ForEach(func(input *serviceeventbridge.PutTargetsInput) {
+			Expect(input.Targets[0].SqsParameters).ToNot(BeNil())
+			Expect(aws.ToString(input.Targets[0].SqsParameters.MessageGroupId)).ToNot(BeEmpty())
+		})
+	})
+	It("should handle the events of the managed rules one at a time in the order that they were received", func() {
+		eventbridgeapi := &fake.EventBridgeAPI{}
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InterruptionQueue:        lo.ToPtr("test-cluster.fifo"),
+			ManagedInterruptionQueue: lo.ToPtr(true),
+		}))
+		Expect(interruption.EnsureInfrastructure(ctx, sqsapi, eventbridge.NewProvider(eventbridgeapi))).To(Succeed())
+		// every rule delivers its events into the same message group, since EventBridge can't group them by instance
+		var groups []string
+		eventbridgeapi.PutTargetsBehavior.CalledWithInput.ForEach(func(input *serviceeventbridge.PutTargetsInput) {
+			groups = append(groups, aws.ToString(input.Targets[0].SqsParameters.MessageGroupId))
+		})
+		Expect(lo.Uniq(groups)).To(HaveLen(1))
+
+		msgs := lo.Times(5, func(_ int) sqstypes.Message { return noopMessage(groups[0]) })
+		sqsapi.ReceiveMessageBehavior.Output.Set(&servicesqs.ReceiveMessageOutput{Messages: msgs})
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		var deleted []string
+		sqsapi.DeleteMessageBehavior.CalledWithInput.ForEach(func(input *servicesqs.DeleteMessageInput) {
+			deleted = append(deleted, aws.ToString(input.ReceiptHandle))
+		})
+		Expect(deleted).To(Equal(lo.Map(msgs, func(m sqstypes.Message, _ int) string { return aws.ToString(m.ReceiptHandle) })))
+	})
+})
+
+var _ = Describe("Resource Changes", func() {
+	BeforeEach(func() {
+		awsEnv.SubnetCache.SetDefault("subnets", []*ec2types.Subnet{})
+		awsEnv.SecurityGroupCache.SetDefault("security-groups", []*ec2types.SecurityGroup{})
+		awsEnv.EC2Cache.SetDefault("images/1", []*ec2types.Image{})
+		awsEnv.EC2Cache.SetDefault("ssm/test-parameter", "ami-test1")
+	})
+	It("should invalidate the subnet cache when a subnet is tagged", func() {
+		ExpectMessagesCreated(createTagsMessage("subnet-test1"))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.SubnetCache.ItemCount()).To(Equal(0))
+		Expect(awsEnv.SecurityGroupCache.ItemCount()).To(Equal(1))
+		Expect(sqsapi.DeleteMessageBehavior.SuccessfulCalls()).To(Equal(1))
+	})
+	It("should invalidate the security group cache when a security group is created", func() {
+		msg := createTagsMessage()
+		msg.Detail.EventName = "CreateSecurityGroup"
+		ExpectMessagesCreated(msg)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.SecurityGroupCache.ItemCount()).To(Equal(0))
+		Expect(awsEnv.SubnetCache.ItemCount()).To(Equal(1))
+	})
+	It("should invalidate images but keep SSM parameters when an image becomes available", func() {
+		ExpectMessagesCreated(imageStateMessage("ami-test1", "available"))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		_, ok := awsEnv.EC2Cache.Get("images/1")
+		Expect(ok).To(BeFalse())
+		_, ok = awsEnv.EC2Cache.Get("ssm/test-parameter")
+		Expect(ok).To(BeTrue())
+	})
+	It("should not invalidate caches for images that are pending", func() {
+		ExpectMessagesCreated(imageStateMessage("ami-test1", "pending"))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		_, ok := awsEnv.EC2Cache.Get("images/1")
+		Expect(ok).To(BeTrue())
+	})
+	It("should not invalidate caches for API calls that failed", func() {
+		msg := createTagsMessage("subnet-test1")
+		msg.Detail.ErrorCode = "Client.UnauthorizedOperation"
+		ExpectMessagesCreated(msg)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.SubnetCache.ItemCount()).To(Equal(1))
+	})
+	It("should not invalidate caches when other resources are tagged", func() {
+		ExpectMessagesCreated(createTagsMessage("vpc-test1", "i-test1"))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.SubnetCache.ItemCount()).To(Equal(1))
+		Expect(awsEnv.SecurityGroupCache.ItemCount()).To(Equal(1))
+	})
+	It("should enqueue the ec2nodeclasses in Karpenter's own account to be re-resolved", func() {
+		nodeClass := test.EC2NodeClass()
+		assumedNodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AssumeRoleARN: lo.ToPtr("arn:aws:iam::111111111111:role/karpenter")}})
+		ExpectApplied(ctx, env.Client, nodeClass, assumedNodeClass)
+		nodeClassEvents := make(chan event.GenericEvent, 10)
+		resourceChangeController := interruption.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), sqsProviderFor(sqsProvider), unavailableOfferingsCache, awsEnv.AccountProvider, nodeClassEvents)
+
+		ExpectMessagesCreated(createTagsMessage("subnet-test1"))
+		ExpectReconcileSucceeded(ctx, resourceChangeController, types.NamespacedName{})
+		Expect(nodeClassEvents).To(HaveLen(1))
+		Expect((<-nodeClassEvents).Object.GetName()).To(Equal(nodeClass.Name))
+	})
+})
+
 var _ = Describe("Error Handling", func() {
 	It("should send an error on polling when QueueNotExists", func() {
-		sqsapi.ReceiveMessageBehavior.Error.Set(awsErrWithCode(servicesqs.ErrCodeQueueDoesNotExist), fake.MaxCalls(0))
+		sqsapi.ReceiveMessageBehavior.Error.Set(&sqstypes.QueueDoesNotExist{}, fake.MaxCalls(0))
 		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
 	})
 	It("should send an error on polling when AccessDenied", func() {
 		sqsapi.ReceiveMessageBehavior.Error.Set(awsErrWithCode("AccessDenied"), fake.MaxCalls(0))
 		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
 	})
+	It("should send an error that identifies the KMS key when SQS can't use it", func() {
+		sqsapi.ReceiveMessageBehavior.Error.Set(awsErrWithCode("KMS.AccessDeniedException"), fake.MaxCalls(0))
+		_, err := controller.Reconcile(ctx, reconcile.Request{})
+		Expect(awserrors.IsKMSError(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("kms:Decrypt"))
+	})
 	It("should not return an error when deleting a nodeClaim that is already deleted", func() {
 		ExpectMessagesCreated(spotInterruptionMessage(fake.InstanceID()))
 		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
@@ -249,8 +869,8 @@ var _ = Describe("Error Handling", func() {
 })
 
 func ExpectMessagesCreated(messages ...interface{}) {
-	raw := lo.Map(messages, func(m interface{}, _ int) *servicesqs.Message {
-		return &servicesqs.Message{
+	raw := lo.Map(messages, func(m interface{}, _ int) sqstypes.Message {
+		return sqstypes.Message{
 			Body:      aws.String(string(lo.Must(json.Marshal(m)))),
 			MessageId: aws.String(string(uuid.NewUUID())),
 		}
@@ -262,8 +882,54 @@ func ExpectMessagesCreated(messages ...interface{}) {
 	)
 }
 
-func awsErrWithCode(code string) awserr.Error {
-	return awserr.New(code, "", fmt.Errorf(""))
+// elected returns a closed channel, for a server that is running on the leader
+func elected() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// sqsProviderFor returns a function that returns the provider for every interruption queue
+func sqsProviderFor(sqsProvider *sqs.Provider) func(string) *sqs.Provider {
+	return func(string) *sqs.Provider { return sqsProvider }
+}
+
+func pushEvent(server *interruption.Server, apiKey string, event interface{}) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(lo.Must(json.Marshal(event))))
+	if apiKey != "" {
+		req.Header.Set(interruption.APIKeyHeader, apiKey)
+	}
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req.WithContext(ctx))
+	return resp
+}
+
+func ec2Instance(instanceID, clusterName string) *ec2types.Instance {
+	instance := &ec2types.Instance{
+		InstanceId:   aws.String(instanceID),
+		InstanceType: "m5.large",
+		State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		Placement:    &ec2types.Placement{AvailabilityZone: aws.String(fake.DefaultRegion)},
+	}
+	if clusterName != "" {
+		instance.Tags = []ec2types.Tag{{Key: aws.String(corev1beta1.ManagedByAnnotationKey), Value: aws.String(clusterName)}}
+	}
+	return instance
+}
+
+func noopMessage(messageGroupID string) sqstypes.Message {
+	return sqstypes.Message{
+		Body:          aws.String("{}"),
+		MessageId:     aws.String(string(uuid.NewUUID())),
+		ReceiptHandle: aws.String(string(uuid.NewUUID())),
+		Attributes: map[string]string{
+			string(sqstypes.MessageSystemAttributeNameMessageGroupId): messageGroupID,
+		},
+	}
+}
+
+func awsErrWithCode(code string) error {
+	return &smithy.GenericAPIError{Code: code}
 }
 
 func spotInterruptionMessage(involvedInstanceID string) spotinterruption.Message {
@@ -287,6 +953,26 @@ func spotInterruptionMessage(involvedInstanceID string) spotinterruption.Message
 	}
 }
 
+func rebalanceRecommendationMessage(involvedInstanceID string) rebalancerecommendation.Message {
+	return rebalancerecommendation.Message{
+		Metadata: messages.Metadata{
+			Version:    "0",
+			Account:    defaultAccountID,
+			DetailType: "EC2 Instance Rebalance Recommendation",
+			ID:         string(uuid.NewUUID()),
+			Region:     fake.DefaultRegion,
+			Resources: []string{
+				fmt.Sprintf("arn:aws:ec2:%s:instance/%s", fake.DefaultRegion, involvedInstanceID),
+			},
+			Source: ec2Source,
+			Time:   time.Now(),
+		},
+		Detail: rebalancerecommendation.Detail{
+			InstanceID: involvedInstanceID,
+		},
+	}
+}
+
 func stateChangeMessage(involvedInstanceID, state string) statechange.Message {
 	return statechange.Message{
 		Metadata: messages.Metadata{
@@ -333,3 +1019,49 @@ func scheduledChangeMessage(involvedInstanceID string) scheduledchange.Message {
 		},
 	}
 }
+
+func createTagsMessage(resourceIDs ...string) resourcechange.Message {
+	return resourcechange.Message{
+		Metadata: messages.Metadata{
+			Version:    "0",
+			Account:    defaultAccountID,
+			DetailType: "AWS API Call via CloudTrail",
+			ID:         string(uuid.NewUUID()),
+			Region:     fake.DefaultRegion,
+			Source:     ec2Source,
+			Time:       time.Now(),
+		},
+		Detail: resourcechange.Detail{
+			EventSource: "ec2.amazonaws.com",
+			EventName:   "CreateTags",
+			RequestParameters: resourcechange.RequestParameters{
+				ResourcesSet: resourcechange.ResourcesSet{
+					Items: lo.Map(resourceIDs, func(id string, _ int) resourcechange.ResourceItem {
+						return resourcechange.ResourceItem{ResourceID: id}
+					}),
+				},
+			},
+		},
+	}
+}
+
+func imageStateMessage(imageID, state string) resourcechange.ImageStateMessage {
+	return resourcechange.ImageStateMessage{
+		Metadata: messages.Metadata{
+			Version:    "0",
+			Account:    defaultAccountID,
+			DetailType: "EC2 AMI State Change",
+			ID:         string(uuid.NewUUID()),
+			Region:     fake.DefaultRegion,
+			Resources: []string{
+				fmt.Sprintf("arn:aws:ec2:%s::image/%s", fake.DefaultRegion, imageID),
+			},
+			Source: ec2Source,
+			Time:   time.Now(),
+		},
+		Detail: resourcechange.ImageStateDetail{
+			ImageID: imageID,
+			State:   state,
+		},
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minnodes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	provisioningscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// Controller keeps the minimum number of nodes that is set on each NodePool through AnnotationMinNodes. NodeClaims are
+// created when the NodePool has fewer nodes than the minimum, regardless of pending pods. The oldest NodeClaims of the
+// NodePool are excluded from disruption so that consolidation never removes nodes below the minimum. NodeClaims that
+// have drifted or expired aren't kept as part of the minimum, so they are replaced before they are disrupted.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "minnodes"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	nodePoolList := &corev1beta1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePoolList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.HasLabels{corev1beta1.NodePoolLabelKey}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	nodeClaims := lo.GroupBy(lo.ToSlicePtr(nodeClaimList.Items), func(nc *corev1beta1.NodeClaim) string {
+		return nc.Labels[corev1beta1.NodePoolLabelKey]
+	})
+	var errs error
+	for i := range nodePoolList.Items {
+		if err := c.reconcileNodePool(ctx, &nodePoolList.Items[i], nodeClaims[nodePoolList.Items[i].Name]); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("reconciling minimum nodes for nodepool %q, %w", nodePoolList.Items[i].Name, err))
+		}
+	}
+	return reconcile.Result{RequeueAfter: 30 * time.Second}, errs
+}
+
+func (c *Controller) reconcileNodePool(ctx context.Context, nodePool *corev1beta1.NodePool, nodeClaims []*corev1beta1.NodeClaim) error {
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("nodepool", nodePool.Name))
+	minNodes := 0
+	if value, ok := nodePool.Annotations[v1beta1.AnnotationMinNodes]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("parsing %s, %q is not a non-negative integer", v1beta1.AnnotationMinNodes, value)
+		}
+		minNodes = n
+	}
+	// The oldest NodeClaims are kept as the baseline so that the baseline doesn't change as nodes are added and removed
+	sort.Slice(nodeClaims, func(a, b int) bool {
+		if !nodeClaims[a].CreationTimestamp.Equal(&nodeClaims[b].CreationTimestamp) {
+			return nodeClaims[a].CreationTimestamp.Before(&nodeClaims[b].CreationTimestamp)
+		}
+		return nodeClaims[a].Name < nodeClaims[b].Name
+	})
+	baseline := lo.Slice(lo.Filter(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) bool {
+		_, warm := nc.Labels[v1beta1.LabelWarmPool]
+		return nc.DeletionTimestamp.IsZero() && !warm &&
+			!nc.StatusConditions().GetCondition(corev1beta1.Drifted).IsTrue() &&
+			!nc.StatusConditions().GetCondition(corev1beta1.Expired).IsTrue()
+	}), 0, minNodes)
+	var errs error
+	for _, nodeClaim := range nodeClaims {
+		if err := c.reconcileBaseline(ctx, nodeClaim, lo.Contains(baseline, nodeClaim)); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	if len(baseline) >= minNodes || !nodePool.DeletionTimestamp.IsZero() {
+		return errs
+	}
+	if err := nodePool.Spec.Limits.ExceededBy(nodePool.Status.Resources); err != nil {
+		logging.FromContext(ctx).Debugf("skipping launches for minimum nodes, %s", err)
+		return errs
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return multierr.Append(errs, fmt.Errorf("getting instance types, %w", err))
+	}
+	// Each NodeClaim may launch as the largest of its instance types, so that capacity is reserved against the limits of
+	// the NodePool before the next NodeClaim is created, in the same way that the provisioner does
+	remaining := utils.RemainingResources(nodePool)
+	for i := len(baseline); i < minNodes; i++ {
+		nodeClaim, launchable, err := newNodeClaim(nodePool, instanceTypes, remaining)
+		if err != nil {
+			return multierr.Append(errs, err)
+		}
+		if nodeClaim == nil {
+			logging.FromContext(ctx).With("min-nodes", minNodes).Debugf("skipping launches for minimum nodes, no instance types fit within the nodepool limits")
+			return errs
+		}
+		if err = c.kubeClient.Create(ctx, nodeClaim); err != nil {
+			return multierr.Append(errs, fmt.Errorf("creating nodeclaim, %w", err))
+		}
+		logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "min-nodes", minNodes).Infof("created nodeclaim for minimum nodes")
+		remaining = utils.SubtractMaxResources(remaining, launchable)
+	}
+	return errs
+}
+
+// reconcileBaseline excludes NodeClaims that are part of the baseline and their nodes from disruption, and removes the
+// exclusion from NodeClaims that are no longer part of the baseline
+func (c *Controller) reconcileBaseline(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, baseline bool) error {
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationBaseline]; ok == baseline {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = withBaseline(nodeClaim.Annotations, baseline)
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	// Annotations are copied from the NodeClaim when the node registers, so only registered nodes are patched
+	if nodeClaim.Status.NodeName != "" {
+		node := &v1.Node{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+			return client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+		}
+		stored := node.DeepCopy()
+		node.Annotations = withBaseline(node.Annotations, baseline)
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+			return client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+		}
+	}
+	logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "baseline", baseline).Debugf("updated minimum nodes baseline")
+	return nil
+}
+
+// withBaseline adds or removes the baseline annotation. The do-not-disrupt annotation is only added when it isn't set
+// already, and the value of the baseline annotation records whether it was added, so that a do-not-disrupt annotation
+// that was set by someone else is kept when the NodeClaim or node leaves the baseline.
+func withBaseline(annotations map[string]string, baseline bool) map[string]string {
+	if baseline {
+		if _, ok := annotations[v1beta1.AnnotationBaseline]; ok {
+			return annotations
+		}
+		if _, ok := annotations[corev1beta1.DoNotDisruptAnnotationKey]; ok {
+			return lo.Assign(annotations, map[string]string{v1beta1.AnnotationBaseline: v1beta1.BaselinePreexistingDoNotDisrupt})
+		}
+		return lo.Assign(annotations, map[string]string{
+			v1beta1.AnnotationBaseline:            "true",
+			corev1beta1.DoNotDisruptAnnotationKey: "true",
+		})
+	}
+	if annotations[v1beta1.AnnotationBaseline] == v1beta1.BaselinePreexistingDoNotDisrupt {
+		return lo.OmitByKeys(annotations, []string{v1beta1.AnnotationBaseline})
+	}
+	return lo.OmitByKeys(annotations, []string{v1beta1.AnnotationBaseline, corev1beta1.DoNotDisruptAnnotationKey})
+}
+
+// newNodeClaim returns a NodeClaim for the NodePool that may launch as any of the instance types that the NodePool allows
+// and that fit within the remaining resources, along with those instance types. No NodeClaim is returned when none of
+// the available instance types fit.
+func newNodeClaim(nodePool *corev1beta1.NodePool, instanceTypes []*cloudprovider.InstanceType, remaining v1.ResourceList) (*corev1beta1.NodeClaim, []*cloudprovider.InstanceType, error) {
+	nct := provisioningscheduling.NewNodeClaimTemplate(nodePool)
+	available := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return nct.Requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil &&
+			len(it.Offerings.Compatible(nct.Requirements).Available()) > 0
+	})
+	if len(available) == 0 {
+		return nil, nil, fmt.Errorf("no instance types are available for the nodepool")
+	}
+	nct.InstanceTypeOptions = utils.FilterByRemainingResources(available, remaining)
+	if len(nct.InstanceTypeOptions) == 0 {
+		return nil, nil, nil
+	}
+	nodeClaim := nct.ToNodeClaim(nodePool)
+	nodeClaim.Annotations = withBaseline(nodeClaim.Annotations, true)
+	return nodeClaim, nct.InstanceTypeOptions, nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,210 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package minnodes_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	corefake "sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/minnodes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var cloudProvider *corefake.CloudProvider
+var minNodesController *minnodes.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MinNodes")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	cloudProvider = corefake.NewCloudProvider()
+	minNodesController = minnodes.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("MinNodes", func() {
+	var nodePool *corev1beta1.NodePool
+
+	BeforeEach(func() {
+		nodePool = coretest.NodePool(corev1beta1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1beta1.AnnotationMinNodes: "2",
+				},
+			},
+		})
+	})
+	It("should create nodeclaims up to the minimum nodes", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(2))
+		for _, nodeClaim := range nodeClaims {
+			Expect(nodeClaim.Labels).To(HaveKeyWithValue(corev1beta1.NodePoolLabelKey, nodePool.Name))
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationBaseline, "true"))
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(corev1beta1.DoNotDisruptAnnotationKey, "true"))
+		}
+
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+	})
+	It("should count existing nodeclaims towards the minimum nodes", func() {
+		nodeClaim, node := coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKeyWithValue(corev1beta1.DoNotDisruptAnnotationKey, "true"))
+		Expect(ExpectExists(ctx, env.Client, node).Annotations).To(HaveKeyWithValue(corev1beta1.DoNotDisruptAnnotationKey, "true"))
+	})
+	It("should only exclude the minimum number of nodeclaims from disruption", func() {
+		nodeClaims := lo.Times(3, func(_ int) *corev1beta1.NodeClaim {
+			return coretest.NodeClaim(corev1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+				},
+			})
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+		Expect(lo.CountBy(ExpectNodeClaims(ctx, env.Client), func(nc *corev1beta1.NodeClaim) bool {
+			_, ok := nc.Annotations[v1beta1.AnnotationBaseline]
+			return ok
+		})).To(Equal(2))
+	})
+	It("should replace nodeclaims that have drifted instead of excluding them from disruption", func() {
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+				Annotations: map[string]string{
+					v1beta1.AnnotationBaseline:            "true",
+					corev1beta1.DoNotDisruptAnnotationKey: "true",
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		nodeClaim.StatusConditions().MarkTrue(corev1beta1.Drifted)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationBaseline))
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(corev1beta1.DoNotDisruptAnnotationKey))
+	})
+	It("should keep a do-not-disrupt annotation that wasn't set for the minimum nodes", func() {
+		nodeClaim, node := coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels:      map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+				Annotations: map[string]string{corev1beta1.DoNotDisruptAnnotationKey: "true"},
+			},
+		})
+		node.Annotations = map[string]string{corev1beta1.DoNotDisruptAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKeyWithValue(v1beta1.AnnotationBaseline, v1beta1.BaselinePreexistingDoNotDisrupt))
+		Expect(ExpectExists(ctx, env.Client, node).Annotations).To(HaveKeyWithValue(v1beta1.AnnotationBaseline, v1beta1.BaselinePreexistingDoNotDisrupt))
+
+		delete(nodePool.Annotations, v1beta1.AnnotationMinNodes)
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationBaseline))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(corev1beta1.DoNotDisruptAnnotationKey, "true"))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.AnnotationBaseline))
+		Expect(node.Annotations).To(HaveKeyWithValue(corev1beta1.DoNotDisruptAnnotationKey, "true"))
+	})
+	It("should only create nodeclaims that fit within the limits of the nodepool", func() {
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			corefake.NewInstanceType(corefake.InstanceTypeOptions{
+				Name:      "small-instance-type",
+				Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+			}),
+		}
+		nodePool.Spec.Limits = corev1beta1.Limits{v1.ResourceCPU: resource.MustParse("6")}
+		ExpectApplied(ctx, env.Client, nodePool)
+
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+	})
+	It("should stop excluding nodeclaims from disruption when the minimum nodes is removed", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+
+		delete(nodePool.Annotations, v1beta1.AnnotationMinNodes)
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, minNodesController, types.NamespacedName{})
+		for _, nodeClaim := range ExpectNodeClaims(ctx, env.Client) {
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationBaseline))
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(corev1beta1.DoNotDisruptAnnotationKey))
+		}
+	})
+	It("should fail to reconcile nodepools with an invalid minimum nodes", func() {
+		nodePool.Annotations[v1beta1.AnnotationMinNodes] = "many"
+		ExpectApplied(ctx, env.Client, nodePool)
+
+		ExpectReconcileFailed(ctx, minNodesController, types.NamespacedName{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+	})
+})
@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// Controller periodically aggregates the estimated hourly cost of all launched NodeClaims so that cost can be
+// attributed to NodePools and NodeClasses without joining against billing data.
+type Controller struct {
+	kubeClient      client.Client
+	pricingProvider *pricing.Provider
+}
+
+func NewController(kubeClient client.Client, pricingProvider *pricing.Provider) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		pricingProvider: pricingProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.cost"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	costs := map[key]float64{}
+	counts := map[key]int{}
+	unpriced := 0
+	for i := range nodeClaimList.Items {
+		nodeClaim := &nodeClaimList.Items[i]
+		// only NodeClaims with a launched instance are incurring cost
+		if nodeClaim.Status.ProviderID == "" {
+			continue
+		}
+		price, ok := c.price(nodeClaim)
+		if !ok {
+			unpriced++
+			continue
+		}
+		k := key{
+			nodePool:     nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
+			nodeClass:    nodeClassName(nodeClaim),
+			capacityType: nodeClaim.Labels[corev1beta1.CapacityTypeLabelKey],
+			zone:         nodeClaim.Labels[v1.LabelTopologyZone],
+		}
+		costs[k] += price
+		counts[k]++
+	}
+	// reset the metrics so that label sets for NodePools that no longer have any NodeClaims are removed
+	hourlyCostEstimate.Reset()
+	nodeClaimsPriced.Reset()
+	for k, cost := range costs {
+		hourlyCostEstimate.With(k.labels()).Set(cost)
+		nodeClaimsPriced.With(k.labels()).Set(float64(counts[k]))
+	}
+	nodeClaimsUnpriced.Set(float64(unpriced))
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+type key struct {
+	nodePool     string
+	nodeClass    string
+	capacityType string
+	zone         string
+}
+
+func (k key) labels() prometheus.Labels {
+	return prometheus.Labels{
+		nodePoolLabel:     k.nodePool,
+		nodeClassLabel:    k.nodeClass,
+		capacityTypeLabel: k.capacityType,
+		zoneLabel:         k.zone,
+	}
+}
+
+func (c *Controller) price(nodeClaim *corev1beta1.NodeClaim) (float64, bool) {
+	instanceType := nodeClaim.Labels[v1.LabelInstanceTypeStable]
+	switch nodeClaim.Labels[corev1beta1.CapacityTypeLabelKey] {
+	case corev1beta1.CapacityTypeSpot:
+		return c.pricingProvider.SpotPrice(instanceType, nodeClaim.Labels[v1.LabelTopologyZone])
+	case corev1beta1.CapacityTypeOnDemand:
+		return c.pricingProvider.OnDemandPrice(instanceType)
+	default:
+		return 0.0, false
+	}
+}
+
+func nodeClassName(nodeClaim *corev1beta1.NodeClaim) string {
+	if nodeClaim.Spec.NodeClassRef == nil {
+		return ""
+	}
+	return nodeClaim.Spec.NodeClassRef.Name
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,73 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	costSubsystem     = "cost"
+	nodePoolLabel     = "nodepool"
+	nodeClassLabel    = "nodeclass"
+	capacityTypeLabel = "capacity_type"
+	zoneLabel         = "zone"
+)
+
+var (
+	hourlyCostEstimate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: costSubsystem,
+			Name:      "hourly_estimate",
+			Help:      "Estimated hourly cost of the launched nodeclaims, based on the prices known by the pricing provider. Labeled by nodepool, nodeclass, capacity type, and zone.",
+		},
+		[]string{
+			nodePoolLabel,
+			nodeClassLabel,
+			capacityTypeLabel,
+			zoneLabel,
+		},
+	)
+	nodeClaimsPriced = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: costSubsystem,
+			Name:      "nodeclaims",
+			Help:      "Number of launched nodeclaims included in the hourly cost estimate. Labeled by nodepool, nodeclass, capacity type, and zone.",
+		},
+		[]string{
+			nodePoolLabel,
+			nodeClassLabel,
+			capacityTypeLabel,
+			zoneLabel,
+		},
+	)
+	nodeClaimsUnpriced = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: costSubsystem,
+			Name:      "unpriced_nodeclaims",
+			Help:      "Number of launched nodeclaims that are excluded from the hourly cost estimate because no price is known for their offering.",
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(hourlyCostEstimate, nodeClaimsPriced, nodeClaimsUnpriced)
+}
@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cost_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/cost"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var costController *cost.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CostController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	costController = cost.NewController(env.Client, awsEnv.PricingProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	awsEnv.PricingProvider.SetOverrides([]pricing.Override{
+		{InstanceType: "m5.large", CapacityType: corev1beta1.CapacityTypeOnDemand, Price: 0.10},
+		{InstanceType: "m5.large", CapacityType: corev1beta1.CapacityTypeSpot, Zone: "test-zone-1a", Price: 0.03},
+	})
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+func launchedNodeClaim(nodePool, nodeClass, capacityType, zone string) *corev1beta1.NodeClaim {
+	return coretest.NodeClaim(corev1beta1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				corev1beta1.NodePoolLabelKey:     nodePool,
+				corev1beta1.CapacityTypeLabelKey: capacityType,
+				v1.LabelInstanceTypeStable:       "m5.large",
+				v1.LabelTopologyZone:             zone,
+			},
+		},
+		Spec: corev1beta1.NodeClaimSpec{
+			NodeClassRef: &corev1beta1.NodeClassReference{
+				Name: nodeClass,
+			},
+		},
+		Status: corev1beta1.NodeClaimStatus{
+			ProviderID: fake.RandomProviderID(),
+		},
+	})
+}
+
+var _ = Describe("CostController", func() {
+	It("should aggregate the hourly cost of launched nodeclaims", func() {
+		ExpectApplied(ctx, env.Client,
+			launchedNodeClaim("default", "default", corev1beta1.CapacityTypeOnDemand, "test-zone-1a"),
+			launchedNodeClaim("default", "default", corev1beta1.CapacityTypeOnDemand, "test-zone-1a"),
+			launchedNodeClaim("default", "default", corev1beta1.CapacityTypeSpot, "test-zone-1a"),
+		)
+		ExpectReconcileSucceeded(ctx, costController, types.NamespacedName{})
+
+		ExpectMetricGaugeValue("karpenter_cost_hourly_estimate", 0.20, map[string]string{
+			"nodepool":      "default",
+			"nodeclass":     "default",
+			"capacity_type": corev1beta1.CapacityTypeOnDemand,
+			"zone":          "test-zone-1a",
+		})
+		ExpectMetricGaugeValue("karpenter_cost_nodeclaims", 2, map[string]string{
+			"nodepool":      "default",
+			"nodeclass":     "default",
+			"capacity_type": corev1beta1.CapacityTypeOnDemand,
+			"zone":          "test-zone-1a",
+		})
+		ExpectMetricGaugeValue("karpenter_cost_hourly_estimate", 0.03, map[string]string{
+			"nodepool":      "default",
+			"nodeclass":     "default",
+			"capacity_type": corev1beta1.CapacityTypeSpot,
+			"zone":          "test-zone-1a",
+		})
+	})
+	It("should attribute cost to separate nodepools and nodeclasses", func() {
+		ExpectApplied(ctx, env.Client,
+			launchedNodeClaim("default", "default", corev1beta1.CapacityTypeOnDemand, "test-zone-1a"),
+			launchedNodeClaim("batch", "gpu", corev1beta1.CapacityTypeOnDemand, "test-zone-1a"),
+		)
+		ExpectReconcileSucceeded(ctx, costController, types.NamespacedName{})
+
+		ExpectMetricGaugeValue("karpenter_cost_hourly_estimate", 0.10, map[string]string{
+			"nodepool":      "default",
+			"nodeclass":     "default",
+			"capacity_type": corev1beta1.CapacityTypeOnDemand,
+			"zone":          "test-zone-1a",
+		})
+		ExpectMetricGaugeValue("karpenter_cost_hourly_estimate", 0.10, map[string]string{
+			"nodepool":      "batch",
+			"nodeclass":     "gpu",
+			"capacity_type": corev1beta1.CapacityTypeOnDemand,
+			"zone":          "test-zone-1a",
+		})
+	})
+	It("should not include nodeclaims that haven't launched", func() {
+		nodeClaim := launchedNodeClaim("default", "default", corev1beta1.CapacityTypeOnDemand, "test-zone-1a")
+		nodeClaim.Status.ProviderID = ""
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, costController, types.NamespacedName{})
+
+		_, ok := FindMetricWithLabelValues("karpenter_cost_hourly_estimate", map[string]string{
+			"nodepool": "default",
+		})
+		Expect(ok).To(BeFalse())
+	})
+	It("should count nodeclaims without a known price", func() {
+		ExpectApplied(ctx, env.Client, launchedNodeClaim("default", "default", corev1beta1.CapacityTypeSpot, "test-zone-1b"))
+		ExpectReconcileSucceeded(ctx, costController, types.NamespacedName{})
+
+		ExpectMetricGaugeValue("karpenter_cost_unpriced_nodeclaims", 1, map[string]string{})
+	})
+	It("should remove metrics for nodepools that no longer have nodeclaims", func() {
+		nodeClaim := launchedNodeClaim("default", "default", corev1beta1.CapacityTypeOnDemand, "test-zone-1a")
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, costController, types.NamespacedName{})
+		ExpectDeleted(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, costController, types.NamespacedName{})
+
+		_, ok := FindMetricWithLabelValues("karpenter_cost_hourly_estimate", map[string]string{
+			"nodepool": "default",
+		})
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,211 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+)
+
+const (
+	// LaunchDiagnostics is the NodeClaim condition that summarizes the diagnostics that were collected for an
+	// instance that didn't register or initialize in time
+	LaunchDiagnostics apis.ConditionType = "LaunchDiagnostics"
+	// Delay is how long after launch or registration diagnostics are collected. This is shorter than the registration
+	// TTL after which NodeClaims that haven't registered are deleted, so that the instance still exists.
+	Delay = 10 * time.Minute
+	// summaryLines is the number of lines from the end of the console output that are included in the summary
+	summaryLines = 10
+	// maxSummaryLength is the maximum length of the console output that is included in the summary
+	maxSummaryLength = 1024
+)
+
+// Controller collects the EC2 console output of instances that were launched for NodeClaims but that haven't
+// registered or initialized in time, so that bootstrap failures can be debugged after the instance is terminated.
+// A summary is recorded on the NodeClaim and, if a bucket is configured, the full console output and a console
+// screenshot are uploaded to S3.
+type Controller struct {
+	kubeClient client.Client
+	clk        clock.Clock
+	recorder   events.Recorder
+	ec2api     sdk.EC2API
+	s3api      sdk.S3API
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder, ec2api sdk.EC2API, s3api sdk.S3API) corecontroller.Controller {
+	return corecontroller.Typed[*corev1beta1.NodeClaim](kubeClient, &Controller{
+		kubeClient: kubeClient,
+		clk:        clk,
+		recorder:   recorder,
+		ec2api:     ec2api,
+		s3api:      s3api,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.diagnostics"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	ctx = audit.WithNodeClaim(ctx, nodeClaim)
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.StatusConditions().GetCondition(LaunchDiagnostics) != nil {
+		return reconcile.Result{}, nil
+	}
+	stage, since, ok := pendingStage(nodeClaim)
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	if wait := Delay - c.clk.Since(since); wait > 0 {
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		logging.FromContext(ctx).With("provider-id", nodeClaim.Status.ProviderID).Errorf("failed to parse instance ID, %w", err)
+		return reconcile.Result{}, nil
+	}
+	consoleOutput, err := c.consoleOutput(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	location := ""
+	if bucket := options.FromContext(ctx).LaunchDiagnosticsBucket; bucket != "" {
+		if location, err = c.upload(ctx, bucket, nodeClaim, id, consoleOutput); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	message := fmt.Sprintf("Instance %s did not complete %s within %s", id, stage, Delay)
+	if location != "" {
+		message += fmt.Sprintf(", diagnostics uploaded to %s", location)
+	}
+	if summary := summarize(consoleOutput); summary != "" {
+		message += fmt.Sprintf(", console output: %s", summary)
+	} else {
+		message += ", no console output is available"
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.StatusConditions().MarkTrueWithReason(LaunchDiagnostics, strings.ToUpper(stage[:1])+stage[1:]+"Timeout", message)
+	if err = c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodeclaim status, %w", err))
+	}
+	logging.FromContext(ctx).With("stage", stage, "location", location).Infof("collected launch diagnostics")
+	c.recorder.Publish(DiagnosticsCollected(nodeClaim, message))
+	return reconcile.Result{}, nil
+}
+
+// pendingStage returns the stage that a launched NodeClaim is waiting to complete, along with when it started
+// waiting. NodeClaims that haven't launched or have already initialized aren't waiting on the instance.
+func pendingStage(nodeClaim *corev1beta1.NodeClaim) (string, time.Time, bool) {
+	launched := nodeClaim.StatusConditions().GetCondition(corev1beta1.Launched)
+	if launched == nil || !launched.IsTrue() {
+		return "", time.Time{}, false
+	}
+	registered := nodeClaim.StatusConditions().GetCondition(corev1beta1.Registered)
+	if registered == nil || !registered.IsTrue() {
+		return "registration", launched.LastTransitionTime.Inner.Time, true
+	}
+	initialized := nodeClaim.StatusConditions().GetCondition(corev1beta1.Initialized)
+	if initialized == nil || !initialized.IsTrue() {
+		return "initialization", registered.LastTransitionTime.Inner.Time, true
+	}
+	return "", time.Time{}, false
+}
+
+func (c *Controller) consoleOutput(ctx context.Context, id string) (string, error) {
+	out, err := c.ec2api.GetConsoleOutput(ctx, &ec2.GetConsoleOutputInput{InstanceId: aws.String(id)})
+	if err != nil {
+		return "", fmt.Errorf("getting console output, %w", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(aws.ToString(out.Output))
+	if err != nil {
+		return "", fmt.Errorf("decoding console output, %w", err)
+	}
+	return string(decoded), nil
+}
+
+// upload writes the console output and a console screenshot to the bucket and returns the location that they were
+// written to. Screenshots aren't supported by all instance types, so failing to take one doesn't fail the upload.
+func (c *Controller) upload(ctx context.Context, bucket string, nodeClaim *corev1beta1.NodeClaim, id string, consoleOutput string) (string, error) {
+	prefix := path.Join(options.FromContext(ctx).ClusterName, nodeClaim.Name, id)
+	if err := c.putObject(ctx, bucket, path.Join(prefix, "console-output.txt"), "text/plain", []byte(consoleOutput)); err != nil {
+		return "", err
+	}
+	out, err := c.ec2api.GetConsoleScreenshot(ctx, &ec2.GetConsoleScreenshotInput{InstanceId: aws.String(id)})
+	if err != nil {
+		logging.FromContext(ctx).Debugf("getting console screenshot, %v", err)
+	} else if screenshot, err := base64.StdEncoding.DecodeString(aws.ToString(out.ImageData)); err == nil && len(screenshot) > 0 {
+		if err = c.putObject(ctx, bucket, path.Join(prefix, "console-screenshot.jpg"), "image/jpeg", screenshot); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("s3://%s/%s/", bucket, prefix), nil
+}
+
+func (c *Controller) putObject(ctx context.Context, bucket, key, contentType string, body []byte) error {
+	if _, err := c.s3api.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        bytes.NewReader(body),
+	}); err != nil {
+		return fmt.Errorf("uploading %s to bucket %s, %w", key, bucket, err)
+	}
+	return nil
+}
+
+// summarize returns the last lines of the console output, which usually contain the error that stopped the bootstrap
+func summarize(consoleOutput string) string {
+	lines := strings.Split(strings.TrimSpace(consoleOutput), "\n")
+	if len(lines) > summaryLines {
+		lines = lines[len(lines)-summaryLines:]
+	}
+	summary := strings.TrimSpace(strings.Join(lines, "\n"))
+	if len(summary) > maxSummaryLength {
+		summary = "..." + summary[len(summary)-maxSummaryLength:]
+	}
+	return summary
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&corev1beta1.NodeClaim{}),
+	)
+}
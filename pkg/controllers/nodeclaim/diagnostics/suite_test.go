@@ -0,0 +1,213 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics_test
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/diagnostics"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var ec2api *fake.EC2API
+var s3api *fake.S3API
+var fakeClock *clock.FakeClock
+var recorder *coretest.EventRecorder
+var diagnosticsController corecontroller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "DiagnosticsController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		LaunchDiagnostics: lo.ToPtr(true),
+	}))
+	ec2api = fake.NewEC2API()
+	s3api = &fake.S3API{}
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ec2api.Reset()
+	s3api.Reset()
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = coretest.NewEventRecorder()
+	diagnosticsController = diagnostics.NewController(env.Client, fakeClock, recorder, ec2api, s3api)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("DiagnosticsController", func() {
+	var nodeClaim *corev1beta1.NodeClaim
+	var instanceID string
+
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey: "default",
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+			},
+		})
+		nodeClaim.StatusConditions().MarkTrue(corev1beta1.Launched)
+		instanceID = lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))
+		ec2api.GetConsoleOutputBehavior.Output.Set(&ec2.GetConsoleOutputOutput{
+			InstanceId: aws.String(instanceID),
+			Output:     aws.String(base64.StdEncoding.EncodeToString([]byte(consoleOutput(20)))),
+		})
+	})
+	It("should collect diagnostics for NodeClaims that haven't registered in time", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(diagnostics.Delay + time.Minute)
+
+		ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		condition := nodeClaim.StatusConditions().GetCondition(diagnostics.LaunchDiagnostics)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal("RegistrationTimeout"))
+		Expect(condition.Message).To(ContainSubstring("line 19"))
+		Expect(condition.Message).ToNot(ContainSubstring("line 9\n"))
+		Expect(nodeClaim.StatusConditions().IsHappy()).To(BeFalse())
+		Expect(aws.ToString(ec2api.GetConsoleOutputBehavior.CalledWithInput.Pop().InstanceId)).To(Equal(instanceID))
+		Expect(recorder.Calls("LaunchDiagnosticsCollected")).To(Equal(1))
+		Expect(s3api.PutObjectBehavior.Calls()).To(Equal(0))
+	})
+	It("should collect diagnostics for NodeClaims that haven't initialized in time", func() {
+		nodeClaim.StatusConditions().MarkTrue(corev1beta1.Registered)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(diagnostics.Delay + time.Minute)
+
+		ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		condition := ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(diagnostics.LaunchDiagnostics)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Reason).To(Equal("InitializationTimeout"))
+	})
+	It("should requeue NodeClaims until the delay has passed", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		result := ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(diagnostics.LaunchDiagnostics)).To(BeNil())
+		Expect(ec2api.GetConsoleOutputBehavior.Calls()).To(Equal(0))
+	})
+	It("should not collect diagnostics for NodeClaims that have initialized", func() {
+		nodeClaim.StatusConditions().MarkTrue(corev1beta1.Registered)
+		nodeClaim.StatusConditions().MarkTrue(corev1beta1.Initialized)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(diagnostics.Delay + time.Minute)
+
+		ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ec2api.GetConsoleOutputBehavior.Calls()).To(Equal(0))
+	})
+	It("should only collect diagnostics once", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(diagnostics.Delay + time.Minute)
+
+		ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ec2api.GetConsoleOutputBehavior.Calls()).To(Equal(1))
+	})
+	It("should upload the console output and screenshot when a bucket is configured", func() {
+		ctx := options.ToContext(ctx, test.Options(test.OptionsFields{
+			LaunchDiagnostics:       lo.ToPtr(true),
+			LaunchDiagnosticsBucket: lo.ToPtr("karpenter-diagnostics"),
+		}))
+		ec2api.GetConsoleScreenshotBehavior.Output.Set(&ec2.GetConsoleScreenshotOutput{
+			InstanceId: aws.String(instanceID),
+			ImageData:  aws.String(base64.StdEncoding.EncodeToString([]byte("screenshot"))),
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(diagnostics.Delay + time.Minute)
+
+		ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		var keys []string
+		s3api.PutObjectBehavior.CalledWithInput.ForEach(func(input *s3.PutObjectInput) {
+			Expect(aws.ToString(input.Bucket)).To(Equal("karpenter-diagnostics"))
+			keys = append(keys, aws.ToString(input.Key))
+		})
+		prefix := fmt.Sprintf("%s/%s/%s", options.FromContext(ctx).ClusterName, nodeClaim.Name, instanceID)
+		Expect(keys).To(ConsistOf(prefix+"/console-output.txt", prefix+"/console-screenshot.jpg"))
+		condition := ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(diagnostics.LaunchDiagnostics)
+		Expect(condition.Message).To(ContainSubstring(fmt.Sprintf("s3://karpenter-diagnostics/%s/", prefix)))
+	})
+	It("should upload the console output when a screenshot can't be taken", func() {
+		ctx := options.ToContext(ctx, test.Options(test.OptionsFields{
+			LaunchDiagnostics:       lo.ToPtr(true),
+			LaunchDiagnosticsBucket: lo.ToPtr("karpenter-diagnostics"),
+		}))
+		ec2api.GetConsoleScreenshotBehavior.Error.Set(&smithy.GenericAPIError{Code: "UnsupportedOperation", Message: ""})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(diagnostics.Delay + time.Minute)
+
+		ExpectReconcileSucceeded(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(s3api.PutObjectBehavior.Calls()).To(Equal(1))
+	})
+	It("should return an error when the console output can't be retrieved", func() {
+		ec2api.GetConsoleOutputBehavior.Error.Set(&smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: ""})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		fakeClock.Step(diagnostics.Delay + time.Minute)
+
+		ExpectReconcileFailed(ctx, diagnosticsController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(diagnostics.LaunchDiagnostics)).To(BeNil())
+	})
+})
+
+func consoleOutput(lines int) string {
+	return strings.Join(lo.Times(lines, func(i int) string { return fmt.Sprintf("line %d", i) }), "\n")
+}
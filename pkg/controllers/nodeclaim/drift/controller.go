@@ -0,0 +1,118 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/workqueue"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// Controller periodically checks every launched NodeClaim for drift against the current resolution of its
+// EC2NodeClass, independently of watch events. Drift is normally detected when NodeClaims are reconciled by the
+// disruption controller, which can miss drift when reconciles are lost, such as during controller outages. NodeClaims
+// that are found to be drifted are marked with the Drifted status condition, which is removed again by the disruption
+// controller if the NodeClaim is no longer drifted.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.drift"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	if !coreoptions.FromContext(ctx).FeatureGates.Drift {
+		return reconcile.Result{RequeueAfter: options.FromContext(ctx).DriftReconciliationInterval}, nil
+	}
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	var mu sync.Mutex
+	var errs error
+	workqueue.ParallelizeUntil(ctx, 10, len(nodeClaimList.Items), func(i int) {
+		if err := c.reconcileNodeClaim(ctx, &nodeClaimList.Items[i]); err != nil {
+			mu.Lock()
+			errs = multierr.Append(errs, err)
+			mu.Unlock()
+		}
+	})
+	return reconcile.Result{RequeueAfter: options.FromContext(ctx).DriftReconciliationInterval}, errs
+}
+
+func (c *Controller) reconcileNodeClaim(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) error {
+	if !nodeClaim.DeletionTimestamp.IsZero() || !nodeClaim.StatusConditions().GetCondition(corev1beta1.Launched).IsTrue() ||
+		nodeClaim.StatusConditions().GetCondition(corev1beta1.Drifted).IsTrue() {
+		return nil
+	}
+	reason, err := c.cloudProvider.IsDrifted(ctx, nodeClaim)
+	if err != nil {
+		return cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting drift for nodeclaim %q, %w", nodeClaim.Name, err))
+	}
+	if reason == "" {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.StatusConditions().SetCondition(apis.Condition{
+		Type:     corev1beta1.Drifted,
+		Status:   v1.ConditionTrue,
+		Severity: apis.ConditionSeverityWarning,
+		Reason:   string(reason),
+	})
+	if err = c.kubeClient.Status().Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim status, %w", err))
+	}
+	logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "reason", string(reason)).Infof("marked nodeclaim as drifted during periodic drift reconciliation")
+	metrics.NodeClaimsDisruptedCounter.With(prometheus.Labels{
+		metrics.TypeLabel:     metrics.DriftReason,
+		metrics.NodePoolLabel: nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
+	}).Inc()
+	metrics.NodeClaimsDriftedCounter.With(prometheus.Labels{
+		metrics.TypeLabel:     string(reason),
+		metrics.NodePoolLabel: nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
+	}).Inc()
+	return nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
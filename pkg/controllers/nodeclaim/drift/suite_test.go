@@ -0,0 +1,105 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corefake "sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/drift"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var cloudProvider *corefake.CloudProvider
+var driftController *drift.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Drift")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DriftReconciliationInterval: lo.ToPtr(time.Hour)}))
+	cloudProvider = corefake.NewCloudProvider()
+	driftController = drift.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options(coretest.OptionsFields{FeatureGates: coretest.FeatureGates{Drift: lo.ToPtr(true)}}))
+	cloudProvider.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Drift", func() {
+	var nodeClaim *corev1beta1.NodeClaim
+
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		nodeClaim.StatusConditions().MarkTrue(corev1beta1.Launched)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+	})
+	It("should mark drifted nodeclaims as drifted", func() {
+		result := ExpectReconcileSucceeded(ctx, driftController, types.NamespacedName{})
+		Expect(result.RequeueAfter).To(Equal(time.Hour))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(corev1beta1.Drifted).IsTrue()).To(BeTrue())
+		Expect(nodeClaim.StatusConditions().GetCondition(corev1beta1.Drifted).Reason).To(Equal("drifted"))
+	})
+	It("should not mark nodeclaims that aren't drifted", func() {
+		cloudProvider.Drifted = ""
+		ExpectReconcileSucceeded(ctx, driftController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(corev1beta1.Drifted)).To(BeNil())
+	})
+	It("should not mark nodeclaims that haven't launched", func() {
+		nodeClaim.StatusConditions().MarkFalse(corev1beta1.Launched, "", "")
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, driftController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(corev1beta1.Drifted)).To(BeNil())
+	})
+	It("should not mark nodeclaims when the drift feature gate is disabled", func() {
+		ctx = coreoptions.ToContext(ctx, coretest.Options())
+		ExpectReconcileSucceeded(ctx, driftController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(corev1beta1.Drifted)).To(BeNil())
+	})
+})
@@ -19,11 +19,15 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
 	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -32,19 +36,32 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
 type Controller struct {
 	kubeClient      client.Client
+	clk             clock.Clock
 	cloudProvider   cloudprovider.CloudProvider
+	ec2api          sdk.EC2API
 	successfulCount uint64 // keeps track of successful reconciles for more aggressive requeueing near the start of the controller
+	// networkInterfacesDetachedAt and volumesDetachedAt record when network interfaces and volumes were first seen
+	// detached, since EC2 doesn't record when they were detached
+	networkInterfacesDetachedAt map[string]time.Time
+	volumesDetachedAt           map[string]time.Time
 }
 
-func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) *Controller {
+func NewController(kubeClient client.Client, clk clock.Clock, cloudProvider cloudprovider.CloudProvider, ec2api sdk.EC2API) *Controller {
 	return &Controller{
-		kubeClient:      kubeClient,
-		cloudProvider:   cloudProvider,
-		successfulCount: 0,
+		kubeClient:                  kubeClient,
+		clk:                         clk,
+		cloudProvider:               cloudProvider,
+		ec2api:                      ec2api,
+		successfulCount:             0,
+		networkInterfacesDetachedAt: map[string]time.Time{},
+		volumesDetachedAt:           map[string]time.Time{},
 	}
 }
 
@@ -75,17 +92,18 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 		return n.Status.ProviderID, n.Status.ProviderID != ""
 	})...)
 	errs := make([]error, len(retrieved))
-	workqueue.ParallelizeUntil(ctx, 100, len(managedRetrieved), func(i int) {
+	workqueue.ParallelizeUntil(ctx, options.FromContext(ctx).GarbageCollectionBatchSize, len(managedRetrieved), func(i int) {
 		if !resolvedProviderIDs.Has(managedRetrieved[i].Status.ProviderID) &&
 			time.Since(managedRetrieved[i].CreationTimestamp.Time) > time.Second*30 {
 			errs[i] = c.garbageCollect(ctx, managedRetrieved[i], nodeList)
 		}
 	})
+	errs = append(errs, c.garbageCollectNetworkInterfaces(ctx), c.garbageCollectVolumes(ctx))
 	if err = multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, err
 	}
 	c.successfulCount++
-	return reconcile.Result{RequeueAfter: lo.Ternary(c.successfulCount <= 20, time.Second*10, time.Minute*2)}, nil
+	return reconcile.Result{RequeueAfter: lo.Ternary(c.successfulCount <= 20, time.Second*10, options.FromContext(ctx).GarbageCollectionInterval)}, nil
 }
 
 func (c *Controller) garbageCollect(ctx context.Context, nodeClaim *v1beta1.NodeClaim, nodeList *v1.NodeList) error {
@@ -107,6 +125,61 @@ func (c *Controller) garbageCollect(ctx context.Context, nodeClaim *v1beta1.Node
 	return nil
 }
 
+// detachedFor records when each of the resources was first seen detached and forgets the resources that are no longer
+// detached, returning how long each of the resources has been seen detached for. Resources are seen detached from the
+// first time they're seen after the controller starts, so the time is conservative.
+func (c *Controller) detachedFor(detachedAt map[string]time.Time, ids []string) map[string]time.Duration {
+	for id := range detachedAt {
+		if !lo.Contains(ids, id) {
+			delete(detachedAt, id)
+		}
+	}
+	return lo.SliceToMap(ids, func(id string) (string, time.Duration) {
+		if _, ok := detachedAt[id]; !ok {
+			detachedAt[id] = c.clk.Now()
+		}
+		return id, c.clk.Since(detachedAt[id])
+	})
+}
+
+// existingInstances returns the IDs of the instances that haven't terminated. Instances are described with a filter,
+// rather than by ID, so that instances that no longer exist aren't an error.
+func (c *Controller) existingInstances(ctx context.Context, ids []string) (sets.Set[string], error) {
+	existing := sets.New[string]()
+	for _, chunk := range lo.Chunk(lo.Uniq(ids), 200) {
+		paginator := ec2.NewDescribeInstancesPaginator(c.ec2api, &ec2.DescribeInstancesInput{
+			Filters: []ec2types.Filter{
+				{
+					Name:   aws.String("instance-id"),
+					Values: chunk,
+				},
+				{
+					Name: aws.String("instance-state-name"),
+					Values: []string{
+						string(ec2types.InstanceStateNamePending),
+						string(ec2types.InstanceStateNameRunning),
+						string(ec2types.InstanceStateNameShuttingDown),
+						string(ec2types.InstanceStateNameStopping),
+						string(ec2types.InstanceStateNameStopped),
+					},
+				},
+			},
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("describing instances, %w", err)
+			}
+			for _, r := range page.Reservations {
+				for _, i := range r.Instances {
+					existing.Insert(aws.ToString(i.InstanceId))
+				}
+			}
+		}
+	}
+	return existing, nil
+}
+
 func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
 	return controller.NewSingletonManagedBy(m)
 }
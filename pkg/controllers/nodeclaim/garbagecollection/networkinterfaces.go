@@ -0,0 +1,101 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/client-go/util/workqueue"
+	"knative.dev/pkg/logging"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// networkInterfaceGarbageCollectionAge is how long a network interface must have been seen detached before it's deleted,
+// so that network interfaces that are being attached, or were just detached from an instance that is terminating, are
+// left alone
+const networkInterfaceGarbageCollectionAge = 10 * time.Minute
+
+// garbageCollectNetworkInterfaces deletes the network interfaces that were created when Karpenter launched an instance
+// and have been detached for longer than the network interface garbage collection age. Network interfaces are deleted
+// when their instance terminates, but they may be left behind when the instance fails to terminate cleanly or the
+// network interface fails to detach. Network interfaces that are tagged with the ID of their instance are only deleted
+// once that instance no longer exists.
+func (c *Controller) garbageCollectNetworkInterfaces(ctx context.Context) error {
+	var networkInterfaces []ec2types.NetworkInterface
+	paginator := ec2.NewDescribeNetworkInterfacesPaginator(c.ec2api, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("status"),
+				Values: []string{string(ec2types.NetworkInterfaceStatusAvailable)},
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1beta1.TagLaunchedBy)),
+				Values: []string{options.FromContext(ctx).ClusterName},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing network interfaces, %w", err)
+		}
+		networkInterfaces = append(networkInterfaces, page.NetworkInterfaces...)
+	}
+	detachedFor := c.detachedFor(c.networkInterfacesDetachedAt, lo.Map(networkInterfaces, func(ni ec2types.NetworkInterface, _ int) string {
+		return aws.ToString(ni.NetworkInterfaceId)
+	}))
+	networkInterfaces = lo.Filter(networkInterfaces, func(ni ec2types.NetworkInterface, _ int) bool {
+		return detachedFor[aws.ToString(ni.NetworkInterfaceId)] > networkInterfaceGarbageCollectionAge
+	})
+	instanceIDs := lo.FilterMap(networkInterfaces, func(ni ec2types.NetworkInterface, _ int) (string, bool) {
+		return instanceID(ni.TagSet)
+	})
+	existing, err := c.existingInstances(ctx, instanceIDs)
+	if err != nil {
+		return err
+	}
+	networkInterfaces = lo.Reject(networkInterfaces, func(ni ec2types.NetworkInterface, _ int) bool {
+		id, ok := instanceID(ni.TagSet)
+		return ok && existing.Has(id)
+	})
+	errs := make([]error, len(networkInterfaces))
+	workqueue.ParallelizeUntil(ctx, 20, len(networkInterfaces), func(i int) {
+		id := aws.ToString(networkInterfaces[i].NetworkInterfaceId)
+		if _, err := c.ec2api.DeleteNetworkInterface(ctx, &ec2.DeleteNetworkInterfaceInput{
+			NetworkInterfaceId: networkInterfaces[i].NetworkInterfaceId,
+		}); awserrors.IgnoreNotFound(err) != nil {
+			errs[i] = fmt.Errorf("deleting network interface %s, %w", id, err)
+			return
+		}
+		logging.FromContext(ctx).With("network-interface-id", id).Debugf("garbage collected network interface")
+	})
+	return multierr.Combine(errs...)
+}
+
+// instanceID returns the ID of the instance that the volume or network interface was created for, if it's tagged with it
+func instanceID(tags []ec2types.Tag) (string, bool) {
+	tag, ok := lo.Find(tags, func(t ec2types.Tag) bool { return aws.ToString(t.Key) == v1beta1.TagInstanceID })
+	return aws.ToString(tag.Value), ok
+}
@@ -21,12 +21,13 @@ import (
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	clock "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"github.com/aws/aws-sdk-go/service/ec2"
 	"k8s.io/client-go/tools/record"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -54,6 +55,7 @@ var awsEnv *test.Environment
 var env *coretest.Environment
 var garbageCollectionController controller.Controller
 var cloudProvider *cloudprovider.CloudProvider
+var fakeClock *clock.FakeClock
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -63,11 +65,12 @@ func TestAPIs(t *testing.T) {
 
 var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
-	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
 	awsEnv = test.NewEnvironment(ctx, env)
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
-	garbageCollectionController = garbagecollection.NewController(env.Client, cloudProvider)
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.PricingProvider)
+	fakeClock = clock.NewFakeClock(time.Now())
+	garbageCollectionController = garbagecollection.NewController(env.Client, fakeClock, cloudProvider, awsEnv.EC2API)
 })
 
 var _ = AfterSuite(func() {
@@ -75,11 +78,12 @@ var _ = AfterSuite(func() {
 })
 
 var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
 	awsEnv.Reset()
 })
 
 var _ = Describe("GarbageCollection", func() {
-	var instance *ec2.Instance
+	var instance *ec2types.Instance
 	var nodeClass *v1beta1.EC2NodeClass
 	var providerID string
 
@@ -98,11 +102,11 @@ var _ = Describe("GarbageCollection", func() {
 				},
 			},
 		})
-		instance = &ec2.Instance{
-			State: &ec2.InstanceState{
-				Name: aws.String(ec2.InstanceStateNameRunning),
+		instance = &ec2types.Instance{
+			State: &ec2types.InstanceState{
+				Name: ec2types.InstanceStateNameRunning,
 			},
-			Tags: []*ec2.Tag{
+			Tags: []ec2types.Tag{
 				{
 					Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
 					Value: aws.String("owned"),
@@ -121,11 +125,11 @@ var _ = Describe("GarbageCollection", func() {
 				},
 			},
 			PrivateDnsName: aws.String(fake.PrivateDNSName()),
-			Placement: &ec2.Placement{
+			Placement: &ec2types.Placement{
 				AvailabilityZone: aws.String(fake.DefaultRegion),
 			},
 			InstanceId:   aws.String(instanceID),
-			InstanceType: aws.String("m5.large"),
+			InstanceType: "m5.large",
 		}
 	})
 	AfterEach(func() {
@@ -135,7 +139,7 @@ var _ = Describe("GarbageCollection", func() {
 	It("should delete an instance if there is no NodeClaim owner", func() {
 		// Launch time was 1m ago
 		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
-		awsEnv.EC2API.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+		awsEnv.EC2API.Instances.Store(aws.ToString(instance.InstanceId), instance)
 
 		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
 		_, err := cloudProvider.Get(ctx, providerID)
@@ -145,7 +149,7 @@ var _ = Describe("GarbageCollection", func() {
 	It("should delete an instance along with the node if there is no NodeClaim owner (to quicken scheduling)", func() {
 		// Launch time was 1m ago
 		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
-		awsEnv.EC2API.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+		awsEnv.EC2API.Instances.Store(aws.ToString(instance.InstanceId), instance)
 
 		node := coretest.Node(coretest.NodeOptions{
 			ProviderID: providerID,
@@ -166,11 +170,11 @@ var _ = Describe("GarbageCollection", func() {
 			instanceID := fake.InstanceID()
 			awsEnv.EC2API.Instances.Store(
 				instanceID,
-				&ec2.Instance{
-					State: &ec2.InstanceState{
-						Name: aws.String(ec2.InstanceStateNameRunning),
+				&ec2types.Instance{
+					State: &ec2types.InstanceState{
+						Name: ec2types.InstanceStateNameRunning,
 					},
-					Tags: []*ec2.Tag{
+					Tags: []ec2types.Tag{
 						{
 							Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
 							Value: aws.String("owned"),
@@ -189,13 +193,13 @@ var _ = Describe("GarbageCollection", func() {
 						},
 					},
 					PrivateDnsName: aws.String(fake.PrivateDNSName()),
-					Placement: &ec2.Placement{
+					Placement: &ec2types.Placement{
 						AvailabilityZone: aws.String(fake.DefaultRegion),
 					},
 					// Launch time was 1m ago
 					LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
 					InstanceId:   aws.String(instanceID),
-					InstanceType: aws.String("m5.large"),
+					InstanceType: "m5.large",
 				},
 			)
 			ids = append(ids, instanceID)
@@ -224,24 +228,24 @@ var _ = Describe("GarbageCollection", func() {
 			instanceID := fake.InstanceID()
 			awsEnv.EC2API.Instances.Store(
 				instanceID,
-				&ec2.Instance{
-					State: &ec2.InstanceState{
-						Name: aws.String(ec2.InstanceStateNameRunning),
+				&ec2types.Instance{
+					State: &ec2types.InstanceState{
+						Name: ec2types.InstanceStateNameRunning,
 					},
-					Tags: []*ec2.Tag{
+					Tags: []ec2types.Tag{
 						{
 							Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
 							Value: aws.String("owned"),
 						},
 					},
 					PrivateDnsName: aws.String(fake.PrivateDNSName()),
-					Placement: &ec2.Placement{
+					Placement: &ec2types.Placement{
 						AvailabilityZone: aws.String(fake.DefaultRegion),
 					},
 					// Launch time was 1m ago
 					LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
 					InstanceId:   aws.String(instanceID),
-					InstanceType: aws.String("m5.large"),
+					InstanceType: "m5.large",
 				},
 			)
 			nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
@@ -280,7 +284,7 @@ var _ = Describe("GarbageCollection", func() {
 	It("should not delete an instance if it is within the NodeClaim resolution window (1m)", func() {
 		// Launch time just happened
 		instance.LaunchTime = aws.Time(time.Now())
-		awsEnv.EC2API.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+		awsEnv.EC2API.Instances.Store(aws.ToString(instance.InstanceId), instance)
 
 		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
 		_, err := cloudProvider.Get(ctx, providerID)
@@ -288,13 +292,13 @@ var _ = Describe("GarbageCollection", func() {
 	})
 	It("should not delete an instance if it was not launched by a NodeClaim", func() {
 		// Remove the "karpenter.sh/managed-by" tag (this isn't launched by a machine)
-		instance.Tags = lo.Reject(instance.Tags, func(t *ec2.Tag, _ int) bool {
-			return aws.StringValue(t.Key) == corev1beta1.ManagedByAnnotationKey
+		instance.Tags = lo.Reject(instance.Tags, func(t ec2types.Tag, _ int) bool {
+			return aws.ToString(t.Key) == corev1beta1.ManagedByAnnotationKey
 		})
 
 		// Launch time was 1m ago
 		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
-		awsEnv.EC2API.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+		awsEnv.EC2API.Instances.Store(aws.ToString(instance.InstanceId), instance)
 
 		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
 		_, err := cloudProvider.Get(ctx, providerID)
@@ -303,7 +307,7 @@ var _ = Describe("GarbageCollection", func() {
 	It("should not delete the instance or node if it already has a NodeClaim that matches it", func() {
 		// Launch time was 1m ago
 		instance.LaunchTime = aws.Time(time.Now().Add(-time.Minute))
-		awsEnv.EC2API.Instances.Store(aws.StringValue(instance.InstanceId), instance)
+		awsEnv.EC2API.Instances.Store(aws.ToString(instance.InstanceId), instance)
 
 		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
 			Spec: corev1beta1.NodeClaimSpec{
@@ -333,11 +337,11 @@ var _ = Describe("GarbageCollection", func() {
 			instanceID := fake.InstanceID()
 			awsEnv.EC2API.Instances.Store(
 				instanceID,
-				&ec2.Instance{
-					State: &ec2.InstanceState{
-						Name: aws.String(ec2.InstanceStateNameRunning),
+				&ec2types.Instance{
+					State: &ec2types.InstanceState{
+						Name: ec2types.InstanceStateNameRunning,
 					},
-					Tags: []*ec2.Tag{
+					Tags: []ec2types.Tag{
 						{
 							Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
 							Value: aws.String("owned"),
@@ -352,13 +356,13 @@ var _ = Describe("GarbageCollection", func() {
 						},
 					},
 					PrivateDnsName: aws.String(fake.PrivateDNSName()),
-					Placement: &ec2.Placement{
+					Placement: &ec2types.Placement{
 						AvailabilityZone: aws.String(fake.DefaultRegion),
 					},
 					// Launch time was 1m ago
 					LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
 					InstanceId:   aws.String(instanceID),
-					InstanceType: aws.String("m5.large"),
+					InstanceType: "m5.large",
 				},
 			)
 			nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
@@ -394,4 +398,278 @@ var _ = Describe("GarbageCollection", func() {
 		}
 		wg.Wait()
 	})
+	Context("Network Interfaces", func() {
+		var networkInterface *ec2types.NetworkInterface
+
+		BeforeEach(func() {
+			networkInterface = &ec2types.NetworkInterface{
+				NetworkInterfaceId: aws.String(fake.NetworkInterfaceID()),
+				Status:             ec2types.NetworkInterfaceStatusAvailable,
+				TagSet: []ec2types.Tag{
+					{
+						Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
+						Value: aws.String("owned"),
+					},
+					{
+						Key:   aws.String(corev1beta1.ManagedByAnnotationKey),
+						Value: aws.String(options.FromContext(ctx).ClusterName),
+					},
+					{
+						Key:   aws.String(v1beta1.TagLaunchedBy),
+						Value: aws.String(options.FromContext(ctx).ClusterName),
+					},
+					{
+						Key:   aws.String(v1beta1.TagInstanceID),
+						Value: aws.String(fake.InstanceID()),
+					},
+				},
+			}
+		})
+		It("should delete network interfaces that were left behind by Karpenter's instances", func() {
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(1))
+			_, ok := awsEnv.EC2API.NetworkInterfaces.Load(aws.ToString(networkInterface.NetworkInterfaceId))
+			Expect(ok).To(BeFalse())
+		})
+		It("should delete network interfaces that were left behind by failed launches", func() {
+			// the network interfaces of instances that never registered aren't tagged with the ID of their instance
+			networkInterface.TagSet = lo.Reject(networkInterface.TagSet, func(t ec2types.Tag, _ int) bool {
+				return aws.ToString(t.Key) == v1beta1.TagInstanceID
+			})
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(1))
+		})
+		It("should not delete network interfaces that were just detached", func() {
+			networkInterface.Status = ec2types.NetworkInterfaceStatusInUse
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+
+			networkInterface.Status = ec2types.NetworkInterfaceStatusAvailable
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(5 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete network interfaces that are attached", func() {
+			networkInterface.Status = ec2types.NetworkInterfaceStatusInUse
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete network interfaces whose instance still exists", func() {
+			networkInterface.TagSet = lo.Reject(networkInterface.TagSet, func(t ec2types.Tag, _ int) bool {
+				return aws.ToString(t.Key) == v1beta1.TagInstanceID
+			})
+			// stopped instances still exist, so the attachments that are tagged with them are kept
+			stopped := &ec2types.Instance{InstanceId: aws.String(fake.InstanceID()), State: &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped}}
+			networkInterface.TagSet = append(networkInterface.TagSet, ec2types.Tag{Key: aws.String(v1beta1.TagInstanceID), Value: stopped.InstanceId})
+			awsEnv.EC2API.Instances.Store(aws.ToString(stopped.InstanceId), stopped)
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete network interfaces that weren't created at launch", func() {
+			// the network interfaces that the VPC CNI attaches are tagged with the cluster, but aren't created at launch
+			networkInterface.TagSet = lo.Reject(networkInterface.TagSet, func(t ec2types.Tag, _ int) bool {
+				return aws.ToString(t.Key) == v1beta1.TagLaunchedBy
+			})
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete network interfaces that were launched by another cluster", func() {
+			networkInterface.TagSet = lo.Reject(networkInterface.TagSet, func(t ec2types.Tag, _ int) bool {
+				return aws.ToString(t.Key) == v1beta1.TagLaunchedBy
+			})
+			networkInterface.TagSet = append(networkInterface.TagSet, ec2types.Tag{Key: aws.String(v1beta1.TagLaunchedBy), Value: aws.String("other-cluster")})
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Calls()).To(Equal(0))
+		})
+		It("should fail to reconcile when a network interface fails to delete", func() {
+			awsEnv.EC2API.NetworkInterfaces.Store(aws.ToString(networkInterface.NetworkInterfaceId), networkInterface)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(15 * time.Minute)
+			awsEnv.EC2API.DeleteNetworkInterfaceBehavior.Error.Set(fmt.Errorf("failed"))
+
+			ExpectReconcileFailed(ctx, garbageCollectionController, client.ObjectKey{})
+			_, ok := awsEnv.EC2API.NetworkInterfaces.Load(aws.ToString(networkInterface.NetworkInterfaceId))
+			Expect(ok).To(BeTrue())
+		})
+	})
+	Context("Volumes", func() {
+		var volume *ec2types.Volume
+
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				VolumeGarbageCollectionAge: lo.ToPtr(time.Hour),
+			}))
+			volume = &ec2types.Volume{
+				VolumeId:   aws.String(fake.VolumeID()),
+				VolumeType: ec2types.VolumeTypeGp3,
+				Size:       aws.Int32(20),
+				State:      ec2types.VolumeStateAvailable,
+				CreateTime: aws.Time(fakeClock.Now().Add(-2 * time.Hour)),
+				Tags: []ec2types.Tag{
+					{
+						Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
+						Value: aws.String("owned"),
+					},
+					{
+						Key:   aws.String(corev1beta1.ManagedByAnnotationKey),
+						Value: aws.String(options.FromContext(ctx).ClusterName),
+					},
+					{
+						Key:   aws.String(v1beta1.TagLaunchedBy),
+						Value: aws.String(options.FromContext(ctx).ClusterName),
+					},
+					{
+						Key:   aws.String(v1beta1.TagInstanceID),
+						Value: aws.String(fake.InstanceID()),
+					},
+				},
+			}
+		})
+		It("should delete volumes that were left behind by Karpenter's instances", func() {
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(1))
+			_, ok := awsEnv.EC2API.Volumes.Load(aws.ToString(volume.VolumeId))
+			Expect(ok).To(BeFalse())
+		})
+		It("should not delete volumes when volume garbage collection is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete volumes in dry run mode", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				VolumeGarbageCollectionAge:    lo.ToPtr(time.Hour),
+				VolumeGarbageCollectionDryRun: lo.ToPtr(true),
+			}))
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+			_, ok := awsEnv.EC2API.Volumes.Load(aws.ToString(volume.VolumeId))
+			Expect(ok).To(BeTrue())
+		})
+		It("should not delete volumes that have been detached for less than the volume garbage collection age", func() {
+			// volumes are aged from when they're detached rather than from when they're created
+			volume.State = ec2types.VolumeStateInUse
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+
+			volume.State = ec2types.VolumeStateAvailable
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(30 * time.Minute)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete volumes that are attached", func() {
+			volume.State = ec2types.VolumeStateInUse
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete volumes whose instance still exists", func() {
+			volume.Tags = lo.Reject(volume.Tags, func(t ec2types.Tag, _ int) bool {
+				return aws.ToString(t.Key) == v1beta1.TagInstanceID
+			})
+			// stopped instances still exist, so the attachments that are tagged with them are kept
+			stopped := &ec2types.Instance{InstanceId: aws.String(fake.InstanceID()), State: &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped}}
+			volume.Tags = append(volume.Tags, ec2types.Tag{Key: aws.String(v1beta1.TagInstanceID), Value: stopped.InstanceId})
+			awsEnv.EC2API.Instances.Store(aws.ToString(stopped.InstanceId), stopped)
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete volumes that are retained on termination", func() {
+			// volumes with deleteOnTermination disabled aren't tagged with the ID of their instance
+			volume.Tags = lo.Reject(volume.Tags, func(t ec2types.Tag, _ int) bool {
+				return aws.ToString(t.Key) == v1beta1.TagInstanceID
+			})
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+		})
+		It("should not delete volumes that weren't created at launch", func() {
+			volume.Tags = lo.Reject(volume.Tags, func(t ec2types.Tag, _ int) bool {
+				return aws.ToString(t.Key) == v1beta1.TagLaunchedBy
+			})
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+		})
+		It("should never delete the volumes of bound persistent volumes", func() {
+			pv := coretest.PersistentVolume(coretest.PersistentVolumeOptions{Driver: "ebs.csi.aws.com"})
+			pv.Spec.CSI.VolumeHandle = aws.ToString(volume.VolumeId)
+			pv.Spec.ClaimRef = &v1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: "default", Name: "data"}
+			ExpectApplied(ctx, env.Client, pv)
+			pv.Status.Phase = v1.VolumeBound
+			Expect(env.Client.Status().Update(ctx, pv)).To(Succeed())
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+			_, ok := awsEnv.EC2API.Volumes.Load(aws.ToString(volume.VolumeId))
+			Expect(ok).To(BeTrue())
+		})
+		It("should never delete the volumes of in-tree persistent volumes", func() {
+			pv := coretest.PersistentVolume(coretest.PersistentVolumeOptions{UseAWSInTreeDriver: true})
+			pv.Spec.AWSElasticBlockStore.VolumeID = fmt.Sprintf("aws://%s/%s", fake.DefaultRegion, aws.ToString(volume.VolumeId))
+			ExpectApplied(ctx, env.Client, pv)
+			awsEnv.EC2API.Volumes.Store(aws.ToString(volume.VolumeId), volume)
+
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			fakeClock.Step(2 * time.Hour)
+			ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+			Expect(awsEnv.EC2API.DeleteVolumeBehavior.Calls()).To(Equal(0))
+		})
+	})
 })
@@ -0,0 +1,127 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollection
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"knative.dev/pkg/logging"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// garbageCollectVolumes deletes the EBS volumes that were created when Karpenter launched an instance, are deleted on
+// termination, and have been detached for longer than the volume garbage collection age. Volumes are deleted when their
+// instance terminates, but they may be left behind when the instance fails to terminate cleanly. Only the volumes that
+// are tagged with the ID of their instance are deleted, which they're only tagged with if they're deleted on termination,
+// and only once that instance no longer exists. Volumes of PersistentVolumes are never deleted. Volumes are only logged
+// when the volume garbage collection dry run is enabled.
+func (c *Controller) garbageCollectVolumes(ctx context.Context) error {
+	age := options.FromContext(ctx).VolumeGarbageCollectionAge
+	if age == 0 {
+		return nil
+	}
+	var volumes []ec2types.Volume
+	paginator := ec2.NewDescribeVolumesPaginator(c.ec2api, &ec2.DescribeVolumesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("status"),
+				Values: []string{string(ec2types.VolumeStateAvailable)},
+			},
+			{
+				Name:   aws.String(fmt.Sprintf("tag:%s", v1beta1.TagLaunchedBy)),
+				Values: []string{options.FromContext(ctx).ClusterName},
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{v1beta1.TagInstanceID},
+			},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing volumes, %w", err)
+		}
+		volumes = append(volumes, page.Volumes...)
+	}
+	detachedFor := c.detachedFor(c.volumesDetachedAt, lo.Map(volumes, func(v ec2types.Volume, _ int) string { return aws.ToString(v.VolumeId) }))
+	volumes = lo.Filter(volumes, func(v ec2types.Volume, _ int) bool {
+		return detachedFor[aws.ToString(v.VolumeId)] > age
+	})
+	if len(volumes) == 0 {
+		return nil
+	}
+	existing, err := c.existingInstances(ctx, lo.FilterMap(volumes, func(v ec2types.Volume, _ int) (string, bool) { return instanceID(v.Tags) }))
+	if err != nil {
+		return err
+	}
+	persistentVolumes, err := c.persistentVolumeIDs(ctx)
+	if err != nil {
+		return err
+	}
+	volumes = lo.Reject(volumes, func(v ec2types.Volume, _ int) bool {
+		id, _ := instanceID(v.Tags)
+		return existing.Has(id) || persistentVolumes.Has(aws.ToString(v.VolumeId))
+	})
+	errs := make([]error, len(volumes))
+	workqueue.ParallelizeUntil(ctx, 20, len(volumes), func(i int) {
+		id := aws.ToString(volumes[i].VolumeId)
+		logger := logging.FromContext(ctx).With("volume-id", id, "volume-type", string(volumes[i].VolumeType),
+			"size", fmt.Sprintf("%dGi", aws.ToInt32(volumes[i].Size)), "detached-for", detachedFor[id])
+		if options.FromContext(ctx).VolumeGarbageCollectionDryRun {
+			logger.Infof("would garbage collect volume (dry run)")
+			return
+		}
+		if _, err := c.ec2api.DeleteVolume(ctx, &ec2.DeleteVolumeInput{VolumeId: volumes[i].VolumeId}); awserrors.IgnoreNotFound(err) != nil {
+			errs[i] = fmt.Errorf("deleting volume %s, %w", id, err)
+			return
+		}
+		logger.Infof("garbage collected volume")
+	})
+	return multierr.Combine(errs...)
+}
+
+// persistentVolumeIDs returns the IDs of the volumes of the cluster's PersistentVolumes, whether they're provisioned by
+// the EBS CSI driver or the in-tree plugin
+func (c *Controller) persistentVolumeIDs(ctx context.Context) (sets.Set[string], error) {
+	pvs := &v1.PersistentVolumeList{}
+	if err := c.kubeClient.List(ctx, pvs); err != nil {
+		return nil, fmt.Errorf("listing persistent volumes, %w", err)
+	}
+	ids := sets.New[string]()
+	for _, pv := range pvs.Items {
+		if pv.Spec.CSI != nil {
+			ids.Insert(pv.Spec.CSI.VolumeHandle)
+		}
+		// in-tree volume IDs may be prefixed with the zone of the volume, e.g. aws://us-west-2a/vol-0123456789abcdef0
+		if pv.Spec.AWSElasticBlockStore != nil {
+			ids.Insert(path.Base(pv.Spec.AWSElasticBlockStore.VolumeID))
+		}
+	}
+	return ids, nil
+}
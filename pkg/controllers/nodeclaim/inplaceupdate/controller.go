@@ -0,0 +1,169 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inplaceupdate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+)
+
+// Controller applies changes to the EC2NodeClass of a NodeClaim to its instance in place, for changes that would
+// otherwise drift the NodeClaim and replace its node. Changes to metadata options are applied with
+// ModifyInstanceMetadataOptions, after which the NodeClaim's hash annotation is updated so that it's no longer drifted.
+// Changes to the selected security groups are applied to each of the instance's network interfaces with
+// ModifyNetworkInterfaceAttribute. Each kind of change is only applied when its option is enabled.
+type Controller struct {
+	kubeClient            client.Client
+	instanceProvider      *instance.Provider
+	securityGroupProvider *securitygroup.Provider
+}
+
+func NewController(kubeClient client.Client, instanceProvider *instance.Provider, securityGroupProvider *securitygroup.Provider) corecontroller.Controller {
+	return corecontroller.Typed[*corev1beta1.NodeClaim](kubeClient, &Controller{
+		kubeClient:            kubeClient,
+		instanceProvider:      instanceProvider,
+		securityGroupProvider: securityGroupProvider,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.inplaceupdate"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	ctx = audit.WithNodeClaim(ctx, nodeClaim)
+	if nodeClaim.Status.ProviderID == "" || !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Spec.NodeClassRef == nil {
+		return reconcile.Result{}, nil
+	}
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting ec2nodeclass, %w", err))
+	}
+	// Only NodeClaims whose EC2NodeClass has changed since they were launched need their metadata options updated, but
+	// the security groups that are selected by an EC2NodeClass can change without the EC2NodeClass changing
+	if nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHash] == nodeClass.Hash() && !options.FromContext(ctx).InPlaceSecurityGroupsUpdate {
+		return reconcile.Result{}, nil
+	}
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("failed to parse instance ID, %w", err)
+		return reconcile.Result{}, nil
+	}
+	i, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	}
+	if options.FromContext(ctx).InPlaceMetadataOptionsUpdate {
+		if err = c.updateMetadataOptions(ctx, nodeClaim, nodeClass, i); err != nil {
+			return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+		}
+	}
+	if options.FromContext(ctx).InPlaceSecurityGroupsUpdate {
+		if err = c.updateSecurityGroups(ctx, nodeClass, i); err != nil {
+			return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// updateMetadataOptions applies the metadata options of the EC2NodeClass to the instance, if they are the only change
+// to the EC2NodeClass since the instance was launched
+func (c *Controller) updateMetadataOptions(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass, i *instance.Instance) error {
+	if !instance.MetadataOptionsUpdatable(nodeClaim, nodeClass, i) {
+		return nil
+	}
+	if err := c.instanceProvider.UpdateMetadataOptions(ctx, i.ID, nodeClass.Spec.MetadataOptions); err != nil {
+		return err
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+		v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
+		v1beta1.AnnotationEC2NodeClassFieldHashes: nodeClass.FieldHashes(),
+	})
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	logging.FromContext(ctx).With("id", i.ID).Infof("updated instance metadata options in place")
+	return nil
+}
+
+// updateSecurityGroups applies the security groups that are selected by the EC2NodeClass to the instance's network
+// interfaces, if they differ from the security groups of the instance
+func (c *Controller) updateSecurityGroups(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, i *instance.Instance) error {
+	securityGroups, err := c.securityGroupProvider.List(ctx, nodeClass)
+	if err != nil {
+		return fmt.Errorf("listing security groups, %w", err)
+	}
+	ids := lo.Map(securityGroups, func(sg *ec2types.SecurityGroup, _ int) string { return aws.ToString(sg.GroupId) })
+	// Instances are never left without security groups, so a selector that doesn't match any is ignored
+	if len(ids) == 0 || sets.New(ids...).Equal(sets.New(i.SecurityGroupIDs...)) {
+		return nil
+	}
+	if err = c.instanceProvider.UpdateSecurityGroups(ctx, i, ids); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).With("id", i.ID, "security-groups", ids).Infof("updated instance security groups in place")
+	return nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&corev1beta1.NodeClaim{}).
+			Watches(
+				&v1beta1.EC2NodeClass{},
+				utils.NodeClassEventHandler(c.kubeClient),
+				builder.WithPredicates(predicate.Funcs{
+					CreateFunc: func(_ event.CreateEvent) bool { return false },
+					UpdateFunc: func(e event.UpdateEvent) bool {
+						oldNodeClass, newNodeClass := e.ObjectOld.(*v1beta1.EC2NodeClass), e.ObjectNew.(*v1beta1.EC2NodeClass)
+						// Security groups that are selected by tags may change without the EC2NodeClass's spec changing
+						return !equality.Semantic.DeepEqual(oldNodeClass.Spec, newNodeClass.Spec) ||
+							!equality.Semantic.DeepEqual(oldNodeClass.Status.SecurityGroups, newNodeClass.Status.SecurityGroups)
+					},
+					DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
+					GenericFunc: func(_ event.GenericEvent) bool { return false },
+				}),
+			),
+	)
+}
@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inplaceupdate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/inplaceupdate"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var inPlaceUpdateController corecontroller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "InPlaceUpdate")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InPlaceMetadataOptionsUpdate: lo.ToPtr(true), InPlaceSecurityGroupsUpdate: lo.ToPtr(true)}))
+	awsEnv = test.NewEnvironment(ctx, env)
+	inPlaceUpdateController = inplaceupdate.NewController(env.Client, awsEnv.InstanceProvider, awsEnv.SecurityGroupProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("InPlaceUpdate", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	var nodeClaim *corev1beta1.NodeClaim
+	var ec2Instance *ec2types.Instance
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Spec: v1beta1.EC2NodeClassSpec{
+				MetadataOptions: &v1beta1.MetadataOptions{
+					HTTPEndpoint:            aws.String("enabled"),
+					HTTPProtocolIPv6:        aws.String("disabled"),
+					HTTPPutResponseHopLimit: aws.Int64(2),
+					HTTPTokens:              aws.String("optional"),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		ec2Instance = &ec2types.Instance{
+			InstanceId:   aws.String(fake.InstanceID()),
+			InstanceType: "m5.large",
+			State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+			Placement:    &ec2types.Placement{AvailabilityZone: aws.String(fake.DefaultRegion)},
+			SecurityGroups: []ec2types.GroupIdentifier{
+				{GroupId: aws.String("sg-test1")}, {GroupId: aws.String("sg-test2")}, {GroupId: aws.String("sg-test3")},
+			},
+			NetworkInterfaces: []ec2types.InstanceNetworkInterface{{NetworkInterfaceId: aws.String("eni-test1")}},
+			MetadataOptions: &ec2types.InstanceMetadataOptionsResponse{
+				HttpEndpoint:            "enabled",
+				HttpProtocolIpv6:        "disabled",
+				HttpPutResponseHopLimit: aws.Int32(2),
+				HttpTokens:              "optional",
+			},
+		}
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{Name: nodeClass.Name},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+			},
+		})
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
+			v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
+		})
+	})
+	It("should update metadata options in place when they are the only change to the ec2nodeclass", func() {
+		nodeClass.Spec.MetadataOptions.HTTPTokens = aws.String("required")
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, inPlaceUpdateController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(awsEnv.EC2API.ModifyInstanceMetadataOptionsBehavior.Calls()).To(Equal(1))
+		input := awsEnv.EC2API.ModifyInstanceMetadataOptionsBehavior.CalledWithInput.Pop()
+		Expect(aws.ToString(input.InstanceId)).To(Equal(*ec2Instance.InstanceId))
+		Expect(string(input.HttpTokens)).To(Equal("required"))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+
+		// The instance is up to date, so it isn't updated again
+		ExpectReconcileSucceeded(ctx, inPlaceUpdateController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(awsEnv.EC2API.ModifyInstanceMetadataOptionsBehavior.Calls()).To(Equal(1))
+	})
+	It("should not update metadata options when other static fields of the ec2nodeclass have changed", func() {
+		nodeClass.Spec.MetadataOptions.HTTPTokens = aws.String("required")
+		nodeClass.Spec.UserData = aws.String("userdata-test-2")
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, inPlaceUpdateController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(awsEnv.EC2API.ModifyInstanceMetadataOptionsBehavior.Calls()).To(Equal(0))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).ToNot(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+	})
+	It("should not update instances whose ec2nodeclass hasn't changed", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, inPlaceUpdateController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(awsEnv.EC2API.ModifyInstanceMetadataOptionsBehavior.Calls()).To(Equal(0))
+		Expect(awsEnv.EC2API.ModifyNetworkInterfaceAttributeBehavior.Calls()).To(Equal(0))
+	})
+	It("should update the security groups of the instance's network interfaces in place", func() {
+		nodeClass.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{{ID: "sg-test1"}, {ID: "sg-test2"}}
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, inPlaceUpdateController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(awsEnv.EC2API.ModifyNetworkInterfaceAttributeBehavior.Calls()).To(Equal(1))
+		input := awsEnv.EC2API.ModifyNetworkInterfaceAttributeBehavior.CalledWithInput.Pop()
+		Expect(aws.ToString(input.NetworkInterfaceId)).To(Equal("eni-test1"))
+		Expect(input.Groups).To(ConsistOf("sg-test1", "sg-test2"))
+
+		// The instance's security groups are up to date, so they aren't updated again
+		ExpectReconcileSucceeded(ctx, inPlaceUpdateController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(awsEnv.EC2API.ModifyNetworkInterfaceAttributeBehavior.Calls()).To(Equal(1))
+	})
+	It("should not update the security groups of the instance when no security groups are selected", func() {
+		nodeClass.Spec.SecurityGroupSelectorTerms = []v1beta1.SecurityGroupSelectorTerm{{ID: "sg-unknown"}}
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, inPlaceUpdateController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(awsEnv.EC2API.ModifyNetworkInterfaceAttributeBehavior.Calls()).To(Equal(0))
+	})
+})
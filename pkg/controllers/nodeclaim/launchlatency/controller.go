@@ -0,0 +1,203 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchlatency
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+const (
+	// RunningPollInterval is how often the instances of NodeClaims that haven't registered are checked for whether
+	// they are running, which bounds the accuracy of the instance_running and registration phases
+	RunningPollInterval = 5 * time.Second
+	// FirstPodTimeout is how long after a node initializes its phases are recorded without the first_pod_scheduled
+	// phase, if no pod has been scheduled to it
+	FirstPodTimeout = 10 * time.Minute
+)
+
+// Controller records how long each phase of launching the nodes of NodeClaims took, so that slow scale-ups can be
+// attributed to EC2, the boot of the AMI or the kubelet. The CreateFleet request is measured by the instance provider,
+// and the phases after it are measured here: until the instance is running, until the node registers, until the node
+// initializes and until the first pod that isn't a daemonset pod is scheduled to the node. The time that the instance
+// started running is recorded on the NodeClaim through AnnotationInstanceRunningAt, and once the phases are recorded
+// the NodeClaim is annotated with AnnotationLaunchLatencyRecorded so that they're recorded once.
+type Controller struct {
+	kubeClient       client.Client
+	clk              clock.Clock
+	instanceProvider *instance.Provider
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, instanceProvider *instance.Provider) corecontroller.Controller {
+	return corecontroller.Typed[*corev1beta1.NodeClaim](kubeClient, &Controller{
+		kubeClient:       kubeClient,
+		clk:              clk,
+		instanceProvider: instanceProvider,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.launchlatency"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Annotations[v1beta1.AnnotationLaunchLatencyRecorded] == "true" {
+		return reconcile.Result{}, nil
+	}
+	launched := nodeClaim.StatusConditions().GetCondition(corev1beta1.Launched)
+	if launched == nil || !launched.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	registered := nodeClaim.StatusConditions().GetCondition(corev1beta1.Registered)
+	if registered == nil || !registered.IsTrue() {
+		return c.reconcileRunning(ctx, nodeClaim)
+	}
+	initialized := nodeClaim.StatusConditions().GetCondition(corev1beta1.Initialized)
+	if initialized == nil || !initialized.IsTrue() {
+		return reconcile.Result{}, nil
+	}
+	firstPodScheduled, err := c.firstPodScheduled(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if firstPodScheduled.IsZero() {
+		if wait := FirstPodTimeout - c.clk.Since(initialized.LastTransitionTime.Inner.Time); wait > 0 {
+			return reconcile.Result{RequeueAfter: wait}, nil
+		}
+	}
+	labels, err := c.labels(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// The instance isn't seen running if it registered before it was polled, or the NodeClaim was launched by a replica
+	// that didn't record it, in which case the phases that it separates can't be told apart
+	if running, err := time.Parse(time.RFC3339, nodeClaim.Annotations[v1beta1.AnnotationInstanceRunningAt]); err == nil {
+		observe(phaseInstanceRunning, labels, launched.LastTransitionTime.Inner.Time, running)
+		observe(phaseRegistration, labels, running, registered.LastTransitionTime.Inner.Time)
+	}
+	observe(phaseInitialization, labels, registered.LastTransitionTime.Inner.Time, initialized.LastTransitionTime.Inner.Time)
+	if !firstPodScheduled.IsZero() {
+		observe(phaseFirstPodScheduled, labels, initialized.LastTransitionTime.Inner.Time, firstPodScheduled)
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationLaunchLatencyRecorded: "true"})
+	if err = c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	return reconcile.Result{}, nil
+}
+
+// reconcileRunning records when the instance of a NodeClaim that hasn't registered yet is first seen running
+func (c *Controller) reconcileRunning(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	if _, ok := nodeClaim.Annotations[v1beta1.AnnotationInstanceRunningAt]; ok {
+		return reconcile.Result{}, nil
+	}
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		logging.FromContext(ctx).With("provider-id", nodeClaim.Status.ProviderID).Errorf("failed to parse instance ID, %w", err)
+		return reconcile.Result{}, nil
+	}
+	i, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting instance, %w", err))
+	}
+	if i.State != string(ec2types.InstanceStateNameRunning) {
+		return reconcile.Result{RequeueAfter: RunningPollInterval}, nil
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationInstanceRunningAt: c.clk.Now().UTC().Format(time.RFC3339)})
+	if err = c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	return reconcile.Result{}, nil
+}
+
+// firstPodScheduled returns when the first pod that isn't owned by a daemonset or the node was scheduled to the
+// NodeClaim's node, or the zero time if none has been scheduled
+func (c *Controller) firstPodScheduled(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (time.Time, error) {
+	podList := &v1.PodList{}
+	if err := c.kubeClient.List(ctx, podList, client.MatchingFields{"spec.nodeName": nodeClaim.Status.NodeName}); err != nil {
+		return time.Time{}, fmt.Errorf("listing pods, %w", err)
+	}
+	var first time.Time
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if podutil.IsOwnedByDaemonSet(pod) || podutil.IsOwnedByNode(pod) {
+			continue
+		}
+		condition, ok := lo.Find(pod.Status.Conditions, func(c v1.PodCondition) bool {
+			return c.Type == v1.PodScheduled && c.Status == v1.ConditionTrue
+		})
+		if ok && (first.IsZero() || condition.LastTransitionTime.Time.Before(first)) {
+			first = condition.LastTransitionTime.Time
+		}
+	}
+	return first, nil
+}
+
+func (c *Controller) labels(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (prometheus.Labels, error) {
+	amiFamily := ""
+	if nodeClaim.Spec.NodeClassRef != nil {
+		nodeClass := &v1beta1.EC2NodeClass{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("getting ec2nodeclass, %w", err)
+		}
+		amiFamily = lo.FromPtr(nodeClass.Spec.AMIFamily)
+	}
+	return prometheus.Labels{
+		instanceTypeLabel: nodeClaim.Labels[v1.LabelInstanceTypeStable],
+		amiFamilyLabel:    amiFamily,
+		zoneLabel:         nodeClaim.Labels[v1.LabelTopologyZone],
+	}, nil
+}
+
+// observe records the duration of the phase, which is never negative since conditions and annotations are only
+// accurate to the second
+func observe(phase string, labels prometheus.Labels, start, end time.Time) {
+	phaseDuration.With(lo.Assign(labels, prometheus.Labels{phaseLabel: phase})).Observe(lo.Max([]float64{end.Sub(start).Seconds(), 0}))
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&corev1beta1.NodeClaim{}).
+			// The first pod that is scheduled to a node is watched for, rather than polled
+			Watches(&v1.Pod{}, nodeclaimutil.PodEventHandler(c.kubeClient)),
+	)
+}
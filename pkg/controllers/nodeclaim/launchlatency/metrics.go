@@ -0,0 +1,50 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchlatency
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	launchesSubsystem = "launches"
+	phaseLabel        = "phase"
+	instanceTypeLabel = "instance_type"
+	amiFamilyLabel    = "ami_family"
+	zoneLabel         = "zone"
+
+	phaseInstanceRunning   = "instance_running"
+	phaseRegistration      = "registration"
+	phaseInitialization    = "initialization"
+	phaseFirstPodScheduled = "first_pod_scheduled"
+)
+
+var phaseDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: launchesSubsystem,
+		Name:      "phase_duration_seconds",
+		Help:      "Duration of each phase of launching a node, from the end of the previous phase. The instance_running phase starts when the instance is launched, registration when the instance is running, initialization when the node registers and first_pod_scheduled when the node is initialized. Labeled by phase, instance type, AMI family and zone.",
+		Buckets:   metrics.DurationBuckets(),
+	},
+	[]string{phaseLabel, instanceTypeLabel, amiFamilyLabel, zoneLabel},
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(phaseDuration)
+}
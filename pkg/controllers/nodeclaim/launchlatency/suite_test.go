@@ -0,0 +1,225 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchlatency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	knativeapis "knative.dev/pkg/apis"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/launchlatency"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
+var launchLatencyController corecontroller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LaunchLatency")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	fakeClock = clock.NewFakeClock(time.Now())
+	launchLatencyController = launchlatency.NewController(env.Client, fakeClock, awsEnv.InstanceProvider)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+// markTrue marks the condition of the NodeClaim as true at the time
+func markTrue(nodeClaim *corev1beta1.NodeClaim, conditionType knativeapis.ConditionType, at time.Time) {
+	nodeClaim.StatusConditions().MarkTrue(conditionType)
+	nodeClaim.StatusConditions().GetCondition(conditionType).LastTransitionTime = knativeapis.VolatileTime{Inner: metav1.NewTime(at)}
+}
+
+// sampleCount returns the number of durations that were recorded for the phase of the instance type
+func sampleCount(phase string, instanceType string) uint64 {
+	metric, ok := FindMetricWithLabelValues("karpenter_launches_phase_duration_seconds", map[string]string{
+		"phase":         phase,
+		"instance_type": instanceType,
+	})
+	if !ok {
+		return 0
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+var _ = Describe("LaunchLatency", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	var nodeClaim *corev1beta1.NodeClaim
+	var instanceType string
+	var instanceID string
+	var launched time.Time
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		// Metrics are kept between tests, so each test records them for its own instance type
+		instanceType = coretest.RandomName()
+		nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1.LabelInstanceTypeStable: instanceType,
+					v1.LabelTopologyZone:       "test-zone-1a",
+				},
+			},
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{Name: nodeClass.Name},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+				NodeName:   coretest.RandomName(),
+			},
+		})
+		launched = fakeClock.Now().Add(-10 * time.Minute).Truncate(time.Second)
+		markTrue(nodeClaim, corev1beta1.Launched, launched)
+		instanceID = lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))
+		awsEnv.EC2API.Instances.Store(instanceID, &ec2types.Instance{
+			InstanceId:   aws.String(instanceID),
+			InstanceType: "m5.large",
+			State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNamePending},
+			Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			LaunchTime:   aws.Time(launched),
+		})
+	})
+	It("should record when the instance of a NodeClaim that hasn't registered starts running", func() {
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+		result := ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).To(Equal(launchlatency.RunningPollInterval))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).ToNot(HaveKey(v1beta1.AnnotationInstanceRunningAt))
+
+		awsEnv.EC2API.Instances.Store(instanceID, &ec2types.Instance{
+			InstanceId:   aws.String(instanceID),
+			InstanceType: "m5.large",
+			State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+			Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			LaunchTime:   aws.Time(launched),
+		})
+		ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKeyWithValue(v1beta1.AnnotationInstanceRunningAt, fakeClock.Now().UTC().Format(time.RFC3339)))
+	})
+	It("should record the duration of each phase once the first pod is scheduled", func() {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1beta1.AnnotationInstanceRunningAt: launched.Add(time.Minute).UTC().Format(time.RFC3339),
+		})
+		markTrue(nodeClaim, corev1beta1.Registered, launched.Add(2*time.Minute))
+		markTrue(nodeClaim, corev1beta1.Initialized, launched.Add(3*time.Minute))
+		pod := coretest.Pod(coretest.PodOptions{
+			NodeName:   nodeClaim.Status.NodeName,
+			Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(launched.Add(4 * time.Minute))}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, pod)
+
+		ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		for _, phase := range []string{"instance_running", "registration", "initialization", "first_pod_scheduled"} {
+			Expect(sampleCount(phase, instanceType)).To(BeNumerically("==", 1), phase)
+		}
+		metric, ok := FindMetricWithLabelValues("karpenter_launches_phase_duration_seconds", map[string]string{
+			"phase":         "registration",
+			"instance_type": instanceType,
+			"zone":          "test-zone-1a",
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("~", time.Minute.Seconds()))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKeyWithValue(v1beta1.AnnotationLaunchLatencyRecorded, "true"))
+
+		// The phases are only recorded once
+		ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(sampleCount("initialization", instanceType)).To(BeNumerically("==", 1))
+	})
+	It("should wait for the first pod to be scheduled before recording the phases", func() {
+		markTrue(nodeClaim, corev1beta1.Registered, fakeClock.Now().Add(-time.Minute))
+		markTrue(nodeClaim, corev1beta1.Initialized, fakeClock.Now())
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+		result := ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(sampleCount("initialization", instanceType)).To(BeNumerically("==", 0))
+
+		fakeClock.Step(launchlatency.FirstPodTimeout)
+		ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(sampleCount("initialization", instanceType)).To(BeNumerically("==", 1))
+		Expect(sampleCount("first_pod_scheduled", instanceType)).To(BeNumerically("==", 0))
+	})
+	It("should not record the instance running and registration phases if the instance wasn't seen running", func() {
+		markTrue(nodeClaim, corev1beta1.Registered, launched.Add(2*time.Minute))
+		markTrue(nodeClaim, corev1beta1.Initialized, launched.Add(3*time.Minute))
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(sampleCount("instance_running", instanceType)).To(BeNumerically("==", 0))
+		Expect(sampleCount("registration", instanceType)).To(BeNumerically("==", 0))
+		Expect(sampleCount("initialization", instanceType)).To(BeNumerically("==", 1))
+	})
+	It("should ignore pods of daemonsets when finding the first pod", func() {
+		markTrue(nodeClaim, corev1beta1.Registered, fakeClock.Now().Add(-time.Minute))
+		markTrue(nodeClaim, corev1beta1.Initialized, fakeClock.Now())
+		pod := coretest.Pod(coretest.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "daemonset", UID: "daemonset"}},
+			},
+			NodeName:   nodeClaim.Status.NodeName,
+			Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(fakeClock.Now())}},
+		})
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim, pod)
+
+		ExpectReconcileSucceeded(ctx, launchLatencyController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(sampleCount("initialization", instanceType)).To(BeNumerically("==", 0))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).ToNot(HaveKey(v1beta1.AnnotationLaunchLatencyRecorded))
+	})
+})
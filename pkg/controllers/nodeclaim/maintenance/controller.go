@@ -0,0 +1,101 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	interruptionevents "github.com/aws/karpenter-provider-aws/pkg/controllers/interruption/events"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// Controller deletes NodeClaims that were marked for replacement ahead of a scheduled maintenance event but have
+// not been replaced through drift by the time the event is about to start, e.g. because disruption budgets blocked it.
+type Controller struct {
+	kubeClient client.Client
+	clk        clock.Clock
+	recorder   events.Recorder
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder) corecontroller.Controller {
+	return corecontroller.Typed[*corev1beta1.NodeClaim](kubeClient, &Controller{
+		kubeClient: kubeClient,
+		clk:        clk,
+		recorder:   recorder,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.maintenance"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	if !nodeClaim.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	val, ok := nodeClaim.Annotations[v1beta1.AnnotationScheduledMaintenance]
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	start, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		// We don't throw an error here since retrying won't fix the annotation value
+		logging.FromContext(ctx).Errorf("parsing %s annotation, %v", v1beta1.AnnotationScheduledMaintenance, err)
+		return reconcile.Result{}, nil
+	}
+	if deadline := start.Add(-options.FromContext(ctx).ScheduledMaintenanceLeadTime); c.clk.Now().Before(deadline) {
+		return reconcile.Result{RequeueAfter: deadline.Sub(c.clk.Now())}, nil
+	}
+	if err = c.kubeClient.Delete(ctx, nodeClaim); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("deleting nodeclaim ahead of scheduled maintenance, %w", err))
+	}
+	logging.FromContext(ctx).With("scheduled-maintenance", val).Infof("initiating delete ahead of scheduled maintenance")
+	c.recorder.Publish(interruptionevents.TerminatingOnInterruption(nil, nodeClaim)...)
+	metrics.NodeClaimsTerminatedCounter.With(prometheus.Labels{
+		metrics.ReasonLabel:       "interruption",
+		metrics.NodePoolLabel:     nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[corev1beta1.CapacityTypeLabelKey],
+	}).Inc()
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&corev1beta1.NodeClaim{}).
+			WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+				_, ok := o.GetAnnotations()[v1beta1.AnnotationScheduledMaintenance]
+				return ok
+			})),
+	)
+}
@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reboot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+)
+
+// Controller reboots the instances of NodeClaims when the NodeClaim or its node is annotated with AnnotationReboot.
+// Rebooting keeps the instance, its volumes and the pods that are bound to the node, unlike replacing the node. Once
+// the instance is rebooted, the annotation is removed and AnnotationRebootedAt is set on the NodeClaim and node.
+type Controller struct {
+	kubeClient client.Client
+	clk        clock.Clock
+	recorder   events.Recorder
+	ec2api     sdk.EC2API
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder, ec2api sdk.EC2API) corecontroller.Controller {
+	return corecontroller.Typed[*corev1beta1.NodeClaim](kubeClient, &Controller{
+		kubeClient: kubeClient,
+		clk:        clk,
+		recorder:   recorder,
+		ec2api:     ec2api,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.reboot"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	ctx = audit.WithNodeClaim(ctx, nodeClaim)
+	if !nodeClaim.DeletionTimestamp.IsZero() || nodeClaim.Status.ProviderID == "" {
+		return reconcile.Result{}, nil
+	}
+	var node *v1.Node
+	if nodeClaim.Status.NodeName != "" {
+		node = &v1.Node{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return reconcile.Result{}, fmt.Errorf("getting node, %w", err)
+			}
+			node = nil
+		}
+	}
+	_, requested := nodeClaim.Annotations[v1beta1.AnnotationReboot]
+	if node != nil {
+		_, nodeRequested := node.Annotations[v1beta1.AnnotationReboot]
+		requested = requested || nodeRequested
+	}
+	if !requested {
+		return reconcile.Result{}, nil
+	}
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		// The instance can't be rebooted until the provider ID changes, so the reboot is completed as failed
+		c.recorder.Publish(RebootFailed(nodeClaim, err.Error()))
+		return reconcile.Result{}, c.complete(ctx, nodeClaim, node, false)
+	}
+	if _, err = c.ec2api.RebootInstances(ctx, &ec2.RebootInstancesInput{InstanceIds: []string{id}}); err != nil {
+		c.recorder.Publish(RebootFailed(nodeClaim, err.Error()))
+		if awserrors.IsNotFound(err) {
+			return reconcile.Result{}, c.complete(ctx, nodeClaim, node, false)
+		}
+		return reconcile.Result{}, fmt.Errorf("rebooting instance, %w", err)
+	}
+	logging.FromContext(ctx).With("id", id).Infof("rebooted instance")
+	c.recorder.Publish(InstanceRebooted(nodeClaim))
+	return reconcile.Result{}, c.complete(ctx, nodeClaim, node, true)
+}
+
+// complete removes the reboot annotation from the NodeClaim and node, and records when the instance was rebooted
+func (c *Controller) complete(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, node *v1.Node, rebooted bool) error {
+	annotations := func(annotations map[string]string) map[string]string {
+		annotations = lo.OmitByKeys(annotations, []string{v1beta1.AnnotationReboot})
+		if rebooted {
+			annotations[v1beta1.AnnotationRebootedAt] = c.clk.Now().UTC().Format(time.RFC3339)
+		}
+		return annotations
+	}
+	stored := nodeClaim.DeepCopy()
+	nodeClaim.Annotations = annotations(nodeClaim.Annotations)
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	if node == nil {
+		return nil
+	}
+	storedNode := node.DeepCopy()
+	node.Annotations = annotations(node.Annotations)
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(storedNode)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+	}
+	return nil
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&corev1beta1.NodeClaim{}).
+			Watches(&v1.Node{}, nodeclaimutil.NodeEventHandler(c.kubeClient)).
+			WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+				_, ok := o.GetAnnotations()[v1beta1.AnnotationReboot]
+				return ok
+			})),
+	)
+}
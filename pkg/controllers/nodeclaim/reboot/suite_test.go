@@ -0,0 +1,167 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reboot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/reboot"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var ec2api *fake.EC2API
+var fakeClock *clock.FakeClock
+var recorder *coretest.EventRecorder
+var rebootController corecontroller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RebootController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ec2api = fake.NewEC2API()
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = coretest.NewEventRecorder()
+	rebootController = reboot.NewController(env.Client, fakeClock, recorder, ec2api)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ec2api.Reset()
+	recorder.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("RebootController", func() {
+	var nodeClaim *corev1beta1.NodeClaim
+	var instanceID string
+
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey: "default",
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+			},
+		})
+		instanceID = lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))
+		ec2api.Instances.Store(instanceID, &ec2types.Instance{
+			InstanceId: aws.String(instanceID),
+			State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		})
+	})
+	It("should reboot the instance when the NodeClaim is annotated", func() {
+		nodeClaim.Annotations = map[string]string{v1beta1.AnnotationReboot: "true"}
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, rebootController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ec2api.RebootInstancesBehavior.Calls()).To(Equal(1))
+		Expect(ec2api.RebootInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationReboot))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationRebootedAt, fakeClock.Now().UTC().Format(time.RFC3339)))
+		Expect(recorder.Calls("InstanceRebooted")).To(Equal(1))
+
+		// The instance isn't rebooted again once the annotation is removed
+		ExpectReconcileSucceeded(ctx, rebootController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ec2api.RebootInstancesBehavior.Calls()).To(Equal(1))
+	})
+	It("should reboot the instance when the node is annotated", func() {
+		node := coretest.Node(coretest.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.AnnotationReboot: "true"},
+			},
+			ProviderID: nodeClaim.Status.ProviderID,
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+		nodeClaim.Status.NodeName = node.Name
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, rebootController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ec2api.RebootInstancesBehavior.Calls()).To(Equal(1))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.AnnotationReboot))
+		Expect(node.Annotations).To(HaveKey(v1beta1.AnnotationRebootedAt))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKey(v1beta1.AnnotationRebootedAt))
+	})
+	It("should not reboot the instance when neither the NodeClaim nor the node are annotated", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, rebootController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ec2api.RebootInstancesBehavior.Calls()).To(Equal(0))
+	})
+	It("should keep the annotation and retry when the instance can't be rebooted", func() {
+		nodeClaim.Annotations = map[string]string{v1beta1.AnnotationReboot: "true"}
+		ec2api.RebootInstancesBehavior.Error.Set(&smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: ""})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileFailed(ctx, rebootController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKey(v1beta1.AnnotationReboot))
+		Expect(recorder.Calls("RebootFailed")).To(Equal(1))
+	})
+	It("should remove the annotation when the instance no longer exists", func() {
+		nodeClaim.Annotations = map[string]string{v1beta1.AnnotationReboot: "true"}
+		ec2api.Instances.Delete(instanceID)
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, rebootController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationReboot))
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationRebootedAt))
+		Expect(recorder.Calls("RebootFailed")).To(Equal(1))
+	})
+})
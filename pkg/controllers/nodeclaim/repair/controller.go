@@ -0,0 +1,187 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repair
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// maxInstanceIDs is the maximum number of instance ids that can be passed to a single DescribeInstanceStatus call
+const maxInstanceIDs = 100
+
+// Controller periodically checks the EC2 status checks of the instances that back NodeClaims. NodeClaims whose instance
+// or system status checks have been impaired for longer than the repair period are annotated so that they are reported
+// as drifted by the cloudprovider and replaced by the disruption controller while respecting disruption budgets.
+type Controller struct {
+	kubeClient client.Client
+	clk        clock.Clock
+	recorder   events.Recorder
+	ec2api     sdk.EC2API
+	// firstImpaired is when each currently impaired instance was first observed to be impaired, for status checks that
+	// don't report when they started failing
+	firstImpaired map[string]time.Time
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder, ec2api sdk.EC2API) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		clk:           clk,
+		recorder:      recorder,
+		ec2api:        ec2api,
+		firstImpaired: map[string]time.Time{},
+	}
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.repair"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	nodeClaims := map[string]*corev1beta1.NodeClaim{}
+	for i := range nodeClaimList.Items {
+		nodeClaim := &nodeClaimList.Items[i]
+		if nodeClaim.Status.ProviderID == "" || !nodeClaim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+		if err != nil {
+			continue
+		}
+		nodeClaims[id] = nodeClaim
+	}
+	impaired, err := c.impairedInstances(ctx, lo.Keys(nodeClaims))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	var errs error
+	for id, nodeClaim := range nodeClaims {
+		since, ok := impaired[id]
+		if ok && c.clk.Since(since) < options.FromContext(ctx).InstanceStatusRepairPeriod {
+			continue
+		}
+		if err = c.reconcileAnnotation(ctx, nodeClaim, since, ok); err != nil {
+			errs = multierr.Append(errs, err)
+		}
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, errs
+}
+
+// reconcileAnnotation adds the impaired annotation to NodeClaims that need to be repaired and removes it from NodeClaims
+// whose instances have recovered before they were replaced
+func (c *Controller) reconcileAnnotation(ctx context.Context, nodeClaim *corev1beta1.NodeClaim, since time.Time, impaired bool) error {
+	_, annotated := nodeClaim.Annotations[v1beta1.AnnotationInstanceStatusImpaired]
+	if impaired == annotated {
+		return nil
+	}
+	stored := nodeClaim.DeepCopy()
+	if impaired {
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+			v1beta1.AnnotationInstanceStatusImpaired: since.UTC().Format(time.RFC3339),
+		})
+	} else {
+		delete(nodeClaim.Annotations, v1beta1.AnnotationInstanceStatusImpaired)
+	}
+	if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+	}
+	if impaired {
+		logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "impaired-since", since).Infof("marked nodeclaim with impaired status checks for replacement")
+		c.recorder.Publish(InstanceStatusImpaired(nodeClaim, since))
+	} else {
+		logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name).Infof("status checks recovered for nodeclaim that was marked for replacement")
+	}
+	return nil
+}
+
+// impairedInstances returns the instances whose instance or system status checks are impaired, along with the earliest
+// time that a check started failing
+func (c *Controller) impairedInstances(ctx context.Context, ids []string) (map[string]time.Time, error) {
+	impaired := map[string]time.Time{}
+	for _, chunk := range lo.Chunk(ids, maxInstanceIDs) {
+		paginator := ec2.NewDescribeInstanceStatusPaginator(c.ec2api, &ec2.DescribeInstanceStatusInput{
+			InstanceIds: chunk,
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("describing instance status, %w", err)
+			}
+			for _, status := range page.InstanceStatuses {
+				id := aws.ToString(status.InstanceId)
+				if since, ok := impairedSince(c.clk, status.InstanceStatus, status.SystemStatus); ok {
+					if first, ok := c.firstImpaired[id]; ok && first.Before(since) {
+						since = first
+					}
+					impaired[id] = since
+				}
+			}
+		}
+	}
+	c.firstImpaired = impaired
+	return impaired, nil
+}
+
+// impairedSince returns the earliest time that any of the passed status checks started failing. Checks that are
+// impaired without reporting when they started are considered to have started failing now.
+func impairedSince(clk clock.Clock, summaries ...*ec2types.InstanceStatusSummary) (time.Time, bool) {
+	var since time.Time
+	impaired := false
+	for _, summary := range summaries {
+		if summary == nil || summary.Status != ec2types.SummaryStatusImpaired {
+			continue
+		}
+		impaired = true
+		checkSince := clk.Now()
+		for _, detail := range summary.Details {
+			if detail.Status == ec2types.StatusTypeFailed && detail.ImpairedSince != nil && detail.ImpairedSince.Before(checkSince) {
+				checkSince = aws.ToTime(detail.ImpairedSince)
+			}
+		}
+		if since.IsZero() || checkSince.Before(since) {
+			since = checkSince
+		}
+	}
+	return since, impaired
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,183 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repair_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clock "k8s.io/utils/clock/testing"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/repair"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var ec2api *fake.EC2API
+var fakeClock *clock.FakeClock
+var repairController *repair.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RepairController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		InstanceStatusRepairPeriod: lo.ToPtr(10 * time.Minute),
+	}))
+	ec2api = fake.NewEC2API()
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ec2api.Reset()
+	fakeClock = clock.NewFakeClock(time.Now())
+	repairController = repair.NewController(env.Client, fakeClock, events.NewRecorder(&record.FakeRecorder{}), ec2api)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("RepairController", func() {
+	var nodeClaim *corev1beta1.NodeClaim
+	var instanceID string
+
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey: "default",
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.RandomProviderID(),
+			},
+		})
+		instanceID = lo.Must(utils.ParseInstanceID(nodeClaim.Status.ProviderID))
+	})
+	It("should mark the NodeClaim for replacement when status checks have been impaired for the repair period", func() {
+		since := fakeClock.Now().Add(-15 * time.Minute)
+		ec2api.DescribeInstanceStatusBehavior.Output.Set(instanceStatus(instanceID, nil, impaired(&since)))
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, repairController, types.NamespacedName{})
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationInstanceStatusImpaired, since.UTC().Format(time.RFC3339)))
+		Expect(ec2api.DescribeInstanceStatusBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+	})
+	It("should not mark the NodeClaim for replacement before the repair period has passed", func() {
+		since := fakeClock.Now().Add(-5 * time.Minute)
+		ec2api.DescribeInstanceStatusBehavior.Output.Set(instanceStatus(instanceID, impaired(&since), nil))
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, repairController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).ToNot(HaveKey(v1beta1.AnnotationInstanceStatusImpaired))
+	})
+	It("should track when status checks were first observed impaired if they don't report it", func() {
+		ec2api.DescribeInstanceStatusBehavior.Output.Set(instanceStatus(instanceID, impaired(nil), nil))
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, repairController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).ToNot(HaveKey(v1beta1.AnnotationInstanceStatusImpaired))
+
+		fakeClock.Step(11 * time.Minute)
+		ExpectReconcileSucceeded(ctx, repairController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).To(HaveKey(v1beta1.AnnotationInstanceStatusImpaired))
+	})
+	It("should unmark the NodeClaim when status checks recover", func() {
+		nodeClaim.Annotations = map[string]string{v1beta1.AnnotationInstanceStatusImpaired: fakeClock.Now().UTC().Format(time.RFC3339)}
+		ec2api.DescribeInstanceStatusBehavior.Output.Set(instanceStatus(instanceID, ok(), ok()))
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, repairController, types.NamespacedName{})
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Annotations).ToNot(HaveKey(v1beta1.AnnotationInstanceStatusImpaired))
+	})
+	It("should not check NodeClaims that haven't launched", func() {
+		nodeClaim.Status.ProviderID = ""
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileSucceeded(ctx, repairController, types.NamespacedName{})
+		Expect(ec2api.DescribeInstanceStatusBehavior.Calls()).To(Equal(0))
+	})
+	It("should return an error when instance status can't be described", func() {
+		ec2api.DescribeInstanceStatusBehavior.Error.Set(&smithy.GenericAPIError{Code: "UnauthorizedOperation", Message: ""})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+
+		ExpectReconcileFailed(ctx, repairController, types.NamespacedName{})
+	})
+})
+
+func instanceStatus(instanceID string, instanceSummary, systemSummary *ec2types.InstanceStatusSummary) *ec2.DescribeInstanceStatusOutput {
+	return &ec2.DescribeInstanceStatusOutput{
+		InstanceStatuses: []ec2types.InstanceStatus{
+			{
+				InstanceId:     aws.String(instanceID),
+				InstanceStatus: instanceSummary,
+				SystemStatus:   systemSummary,
+			},
+		},
+	}
+}
+
+func impaired(since *time.Time) *ec2types.InstanceStatusSummary {
+	return &ec2types.InstanceStatusSummary{
+		Status: ec2types.SummaryStatusImpaired,
+		Details: []ec2types.InstanceStatusDetails{
+			{
+				Name:          ec2types.StatusNameReachability,
+				Status:        ec2types.StatusTypeFailed,
+				ImpairedSince: since,
+			},
+		},
+	}
+}
+
+func ok() *ec2types.InstanceStatusSummary {
+	return &ec2types.InstanceStatusSummary{Status: ec2types.SummaryStatusOk}
+}
@@ -19,10 +19,18 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/logging"
 	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -30,23 +38,41 @@ import (
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/throttling"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 )
 
+// tagReconciliationInterval is how often the tags of instances that have already been tagged are checked, so that
+// tags which are removed or changed out-of-band are re-asserted
+const tagReconciliationInterval = 10 * time.Minute
+
+// Controller tags the instance of a NodeClaim with its node name once the node has registered. Afterwards, it
+// periodically re-asserts the tags that are required for Karpenter to discover the instance and the volumes and network
+// interface that were created when it was launched, since instances whose tags are removed are no longer listed and would be leaked. Changes to
+// the tags of the EC2NodeClass are applied in place, rather than drifting the NodeClaim, and the label tags that are
+// configured with --label-tags are kept in sync with the labels of the node. The launch details of the instance that
+// aren't known at launch are recorded on the NodeClaim as well, and the NodeClaims and nodes of instances that were
+// launched into a capacity reservation are labeled with it.
 type Controller struct {
-	kubeClient       client.Client
-	instanceProvider *instance.Provider
+	kubeClient      client.Client
+	accountProvider *account.Provider
 }
 
-func NewController(kubeClient client.Client, instanceProvider *instance.Provider) corecontroller.Controller {
+func NewController(kubeClient client.Client, accountProvider *account.Provider) corecontroller.Controller {
 	return corecontroller.Typed[*corev1beta1.NodeClaim](kubeClient, &Controller{
-		kubeClient:       kubeClient,
-		instanceProvider: instanceProvider,
+		kubeClient:      kubeClient,
+		accountProvider: accountProvider,
 	})
 }
 
@@ -55,6 +81,8 @@ func (c *Controller) Name() string {
 }
 
 func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	// Tags are reconciled again once the circuit breaker closes, so the calls are shed while AWS APIs are throttling
+	ctx = throttling.NonCritical(audit.WithNodeClaim(ctx, nodeClaim))
 	stored := nodeClaim.DeepCopy()
 	if !isTaggable(nodeClaim) {
 		return reconcile.Result{}, nil
@@ -66,59 +94,296 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeC
 		logging.FromContext(ctx).Errorf("failed to parse instance ID, %w", err)
 		return reconcile.Result{}, nil
 	}
-	if err = c.tagInstance(ctx, nodeClaim, id); err != nil {
+	nodeClass, err := c.nodeClass(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	tags, err := c.tags(ctx, nodeClaim, nodeClass)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	providers, err := c.accountProvider.ForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	i, err := c.tagInstance(ctx, providers.Instance, nodeClaim, id, tags)
+	if err != nil {
 		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
 	}
-	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.AnnotationInstanceTagged: "true"})
+	if err = c.tagAttachments(ctx, providers.EC2API, i, launchDeviceNames(nodeClass), tags); err != nil {
+		return reconcile.Result{}, err
+	}
+	// The launch details that aren't known until the instance is described are recorded alongside the ones recorded at
+	// launch, and network interfaces that are attached later are recorded as they're tagged
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, i.LaunchAnnotations(), map[string]string{v1beta1.AnnotationInstanceTagged: "true"})
+	// Instances that were launched into a capacity reservation are only known to be once they're described, so their
+	// NodeClaim and node are relabeled after they registered
+	nodeClaim.Labels = lo.Assign(nodeClaim.Labels, i.CapacityTypeLabels())
 	if !equality.Semantic.DeepEqual(nodeClaim, stored) {
 		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
 			return reconcile.Result{}, client.IgnoreNotFound(err)
 		}
 	}
-	return reconcile.Result{}, nil
+	// The spot price at launch is synced to the node alongside the capacity type, since the node may have registered
+	// before the NodeClaim was annotated
+	if err = c.syncNode(ctx, nodeClaim.Status.NodeName, i.CapacityTypeLabels(), lo.PickByKeys(nodeClaim.Annotations, []string{v1beta1.AnnotationSpotPrice})); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: tagReconciliationInterval}, nil
 }
 
-func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
-	return corecontroller.Adapt(
-		controllerruntime.
-			NewControllerManagedBy(m).
-			For(&corev1beta1.NodeClaim{}).
-			WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
-				return isTaggable(o.(*corev1beta1.NodeClaim))
-			})),
-	)
+func (c *Controller) Builder(ctx context.Context, m manager.Manager) corecontroller.Builder {
+	b := controllerruntime.
+		NewControllerManagedBy(m).
+		For(&corev1beta1.NodeClaim{}, builder.WithPredicates(predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool { return isTaggable(e.Object.(*corev1beta1.NodeClaim)) },
+			// NodeClaims that have already been tagged are requeued periodically, so updates to them are ignored
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				nc := e.ObjectNew.(*corev1beta1.NodeClaim)
+				return isTaggable(nc) && nc.Annotations[v1beta1.AnnotationInstanceTagged] != "true"
+			},
+			DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
+			GenericFunc: func(e event.GenericEvent) bool { return isTaggable(e.Object.(*corev1beta1.NodeClaim)) },
+		})).
+		Watches(
+			&v1beta1.EC2NodeClass{},
+			utils.NodeClassEventHandler(c.kubeClient),
+			// Apply changes to the tags of the EC2NodeClass to its NodeClaims' instances in place
+			builder.WithPredicates(predicate.Funcs{
+				CreateFunc: func(_ event.CreateEvent) bool { return false },
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return !equality.Semantic.DeepEqual(e.ObjectOld.(*v1beta1.EC2NodeClass).Spec.Tags, e.ObjectNew.(*v1beta1.EC2NodeClass).Spec.Tags)
+				},
+				DeleteFunc:  func(_ event.DeleteEvent) bool { return false },
+				GenericFunc: func(_ event.GenericEvent) bool { return false },
+			}),
+		)
+	if options.FromContext(ctx).LabelTags != "" {
+		// Apply changes to the labels of nodes to their instances' label tags
+		b = b.Watches(
+			&v1.Node{},
+			nodeclaimutil.NodeEventHandler(c.kubeClient),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		)
+	}
+	return corecontroller.Adapt(b)
 }
 
-func (c *Controller) tagInstance(ctx context.Context, nc *corev1beta1.NodeClaim, id string) error {
-	tags := map[string]string{
-		v1beta1.TagName:      nc.Status.NodeName,
-		v1beta1.TagNodeClaim: nc.Name,
+// nodeClass returns the EC2NodeClass of the NodeClaim, or nil if it doesn't exist
+func (c *Controller) nodeClass(ctx context.Context, nc *corev1beta1.NodeClaim) (*v1beta1.EC2NodeClass, error) {
+	if nc.Spec.NodeClassRef == nil {
+		return nil, nil
 	}
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nc.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("getting ec2nodeclass, %w", err)
+		}
+		return nil, nil
+	}
+	return nodeClass, nil
+}
 
-	// Remove tags which have been already populated
-	instance, err := c.instanceProvider.Get(ctx, id)
+// tags returns the label tags of the NodeClaim's node, the tags of its EC2NodeClass and the required tags, which take
+// precedence
+func (c *Controller) tags(ctx context.Context, nc *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass) (map[string]string, error) {
+	// Labels are synced from the NodeClaim to the node, but may also be added to the node directly
+	labels := nc.Labels
+	if options.FromContext(ctx).LabelTags != "" {
+		node := &v1.Node{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nc.Status.NodeName}, node); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("getting node, %w", err)
+		} else if err == nil {
+			labels = node.Labels
+		}
+	}
+	labelTags, err := instance.LabelTags(ctx, labels)
 	if err != nil {
-		return fmt.Errorf("tagging nodeclaim, %w", err)
+		return nil, err
 	}
-	tags = lo.OmitByKeys(tags, lo.Keys(instance.Tags))
-	if len(tags) == 0 {
+	var nodeClassTags map[string]string
+	if nodeClass != nil {
+		nodeClassTags = nodeClass.Spec.Tags
+	}
+	return lo.Assign(labelTags, nodeClassTags, instance.RequiredTags(ctx, nc)), nil
+}
+
+func (c *Controller) syncNode(ctx context.Context, name string, labels, annotations map[string]string) error {
+	node := &v1.Node{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: name}, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	stored := node.DeepCopy()
+	node.Labels = lo.Assign(node.Labels, labels)
+	if len(annotations) > 0 {
+		node.Annotations = lo.Assign(node.Annotations, annotations)
+	}
+	if equality.Semantic.DeepEqual(node, stored) {
 		return nil
 	}
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+		return fmt.Errorf("syncing node, %w", err)
+	}
+	return nil
+}
+
+func (c *Controller) tagInstance(ctx context.Context, instanceProvider *instance.Provider, nc *corev1beta1.NodeClaim, id string, nodeClassTags map[string]string) (*instance.Instance, error) {
+	i, err := instanceProvider.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("tagging nodeclaim, %w", err)
+	}
+	name, err := c.name(ctx, nc, i)
+	if err != nil {
+		return nil, fmt.Errorf("tagging nodeclaim, %w", err)
+	}
+	tags := map[string]string{
+		v1beta1.TagName:      name,
+		v1beta1.TagNodeClaim: nc.Name,
+	}
+	// Remove tags which have been already populated, and tags of the EC2NodeClass which haven't been changed
+	tags = lo.Assign(lo.OmitByKeys(tags, lo.Keys(i.Tags)), outOfSync(i.Tags, nodeClassTags))
+	if len(tags) == 0 {
+		return i, nil
+	}
+	if nc.Annotations[v1beta1.AnnotationInstanceTagged] == "true" {
+		logging.FromContext(ctx).With("tags", tags).Infof("updating instance tags")
+	}
 
 	// Ensures that no more than 1 CreateTags call is made per second. Rate limiting is required since CreateTags
 	// shares a pool with other mutating calls (e.g. CreateFleet).
 	defer time.Sleep(time.Second)
-	if err := c.instanceProvider.CreateTags(ctx, id, tags); err != nil {
-		return fmt.Errorf("tagging nodeclaim, %w", err)
+	if err := instanceProvider.CreateTags(ctx, id, tags); err != nil {
+		return nil, fmt.Errorf("tagging nodeclaim, %w", err)
+	}
+	return i, nil
+}
+
+// name returns the value of the instance's Name tag, which is rendered from the instance name template if one is set
+func (c *Controller) name(ctx context.Context, nc *corev1beta1.NodeClaim, i *instance.Instance) (string, error) {
+	if options.FromContext(ctx).InstanceNameTemplate == "" {
+		return nc.Status.NodeName, nil
+	}
+	var nodePool *corev1beta1.NodePool
+	if name, ok := nc.Labels[corev1beta1.NodePoolLabelKey]; ok {
+		nodePool = &corev1beta1.NodePool{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: name}, nodePool); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return "", fmt.Errorf("getting nodepool, %w", err)
+			}
+			nodePool = nil
+		}
+	}
+	var nodeClass *v1beta1.EC2NodeClass
+	if nc.Spec.NodeClassRef != nil {
+		nodeClass = &v1beta1.EC2NodeClass{}
+		if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nc.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return "", fmt.Errorf("getting ec2nodeclass, %w", err)
+			}
+			nodeClass = nil
+		}
+	}
+	return instance.InstanceName(ctx, i, nc, nodePool, nodeClass)
+}
+
+// tagAttachments updates the tags of the volumes and network interface that were created when the instance was
+// launched, which are its root volume, the volumes of the block device mappings of its EC2NodeClass and its primary
+// network interface. They are looked up through the instance rather than by tag, since their tags may have been removed.
+// Volumes and network interfaces that are attached later, like the volumes of PersistentVolumes and the network
+// interfaces of the VPC CNI, are left alone.
+func (c *Controller) tagAttachments(ctx context.Context, ec2api sdk.EC2API, i *instance.Instance, deviceNames sets.Set[string], tags map[string]string) error {
+	volumes := lo.SliceToMap(lo.Values(lo.PickBy(i.Volumes, func(name string, _ instance.Attachment) bool {
+		return name == i.RootDeviceName || deviceNames.Has(name)
+	})), func(a instance.Attachment) (string, instance.Attachment) { return a.ID, a })
+	var outdated []instance.Attachment
+	if len(volumes) > 0 {
+		pager := ec2.NewDescribeVolumesPaginator(ec2api, &ec2.DescribeVolumesInput{VolumeIds: lo.Keys(volumes)})
+		for pager.HasMorePages() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("describing volumes, %w", err)
+			}
+			for _, v := range page.Volumes {
+				if a := volumes[aws.ToString(v.VolumeId)]; len(outOfSync(toMap(v.Tags), attachmentTags(ctx, i.ID, a, tags))) > 0 {
+					outdated = append(outdated, a)
+				}
+			}
+		}
+	}
+	if ni := i.PrimaryNetworkInterface; ni != nil {
+		pager := ec2.NewDescribeNetworkInterfacesPaginator(ec2api, &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: []string{ni.ID}})
+		for pager.HasMorePages() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("describing network interfaces, %w", err)
+			}
+			for _, out := range page.NetworkInterfaces {
+				if len(outOfSync(toMap(out.TagSet), attachmentTags(ctx, i.ID, *ni, tags))) > 0 {
+					outdated = append(outdated, *ni)
+				}
+			}
+		}
+	}
+	if len(outdated) == 0 {
+		return nil
+	}
+	defer time.Sleep(time.Second)
+	// Attachments that are deleted on termination are tagged with the ID of the instance as well, so they're tagged in
+	// up to two calls
+	for deleteOnTermination, attachments := range lo.GroupBy(outdated, func(a instance.Attachment) bool { return a.DeleteOnTermination }) {
+		ids := lo.Map(attachments, func(a instance.Attachment, _ int) string { return a.ID })
+		if _, err := ec2api.CreateTags(ctx, &ec2.CreateTagsInput{
+			Resources: ids,
+			Tags: lo.MapToSlice(attachmentTags(ctx, i.ID, instance.Attachment{DeleteOnTermination: deleteOnTermination}, tags), func(k, v string) ec2types.Tag {
+				return ec2types.Tag{Key: aws.String(k), Value: aws.String(v)}
+			}),
+		}); err != nil {
+			return fmt.Errorf("tagging attachments, %w", err)
+		}
+		logging.FromContext(ctx).With("ids", ids).Infof("updated tags on attached volumes and network interfaces")
 	}
 	return nil
 }
 
-func isTaggable(nc *corev1beta1.NodeClaim) bool {
-	// Instance has already been tagged
-	if val := nc.Annotations[v1beta1.AnnotationInstanceTagged]; val == "true" {
-		return false
+// attachmentTags returns the tags of a volume or network interface that was created when the instance was launched.
+// Attachments that are deleted on termination are tagged with the ID of the instance, so that they're garbage collected
+// if they outlive it, while the ones that are retained on purpose never are.
+func attachmentTags(ctx context.Context, id string, a instance.Attachment, tags map[string]string) map[string]string {
+	tags = lo.Assign(tags, map[string]string{v1beta1.TagLaunchedBy: options.FromContext(ctx).ClusterName})
+	if a.DeleteOnTermination {
+		tags[v1beta1.TagInstanceID] = id
+	}
+	return tags
+}
+
+// launchDeviceNames returns the device names of the volumes that are created from the block device mappings of the
+// EC2NodeClass, which default to the ones of its AMI family
+func launchDeviceNames(nodeClass *v1beta1.EC2NodeClass) sets.Set[string] {
+	if nodeClass == nil {
+		return sets.New[string]()
+	}
+	blockDeviceMappings := nodeClass.Spec.BlockDeviceMappings
+	if len(blockDeviceMappings) == 0 {
+		blockDeviceMappings = amifamily.GetAMIFamily(nodeClass.Spec.AMIFamily, &amifamily.Options{}).DefaultBlockDeviceMappings()
 	}
+	return sets.New(lo.FilterMap(blockDeviceMappings, func(bdm *v1beta1.BlockDeviceMapping, _ int) (string, bool) {
+		return aws.ToString(bdm.DeviceName), bdm.DeviceName != nil
+	})...)
+}
+
+// outOfSync returns the tags that are missing from, or have a different value in, the existing tags
+func outOfSync(existing, tags map[string]string) map[string]string {
+	return lo.OmitBy(tags, func(k, v string) bool {
+		value, ok := existing[k]
+		return ok && value == v
+	})
+}
+
+func toMap(tags []ec2types.Tag) map[string]string {
+	return lo.SliceToMap(tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+}
+
+func isTaggable(nc *corev1beta1.NodeClaim) bool {
 	// Node name is not yet known
 	if nc.Status.NodeName == "" {
 		return false
@@ -17,14 +17,15 @@ package tagging_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
@@ -62,13 +63,14 @@ var _ = BeforeSuite(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
-	taggingController = tagging.NewController(env.Client, awsEnv.InstanceProvider)
+	taggingController = tagging.NewController(env.Client, awsEnv.AccountProvider)
 })
 var _ = AfterSuite(func() {
 	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
 })
 
 var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
 	awsEnv.Reset()
 })
 
@@ -77,14 +79,14 @@ var _ = AfterEach(func() {
 })
 
 var _ = Describe("TaggingController", func() {
-	var ec2Instance *ec2.Instance
+	var ec2Instance *ec2types.Instance
 
 	BeforeEach(func() {
-		ec2Instance = &ec2.Instance{
-			State: &ec2.InstanceState{
-				Name: aws.String(ec2.InstanceStateNameRunning),
+		ec2Instance = &ec2types.Instance{
+			State: &ec2types.InstanceState{
+				Name: ec2types.InstanceStateNameRunning,
 			},
-			Tags: []*ec2.Tag{
+			Tags: []ec2types.Tag{
 				{
 					Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
 					Value: aws.String("owned"),
@@ -99,11 +101,11 @@ var _ = Describe("TaggingController", func() {
 				},
 			},
 			PrivateDnsName: aws.String(fake.PrivateDNSName()),
-			Placement: &ec2.Placement{
+			Placement: &ec2types.Placement{
 				AvailabilityZone: aws.String(fake.DefaultRegion),
 			},
 			InstanceId:   aws.String(fake.InstanceID()),
-			InstanceType: aws.String("m5.large"),
+			InstanceType: "m5.large",
 		}
 
 		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
@@ -119,7 +121,7 @@ var _ = Describe("TaggingController", func() {
 		ExpectApplied(ctx, env.Client, nodeClaim)
 		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
 		Expect(nodeClaim.Annotations).To(Not(HaveKey(v1beta1.AnnotationInstanceTagged)))
-		Expect(lo.ContainsBy(ec2Instance.Tags, func(tag *ec2.Tag) bool {
+		Expect(lo.ContainsBy(ec2Instance.Tags, func(tag ec2types.Tag) bool {
 			return *tag.Key == v1beta1.TagName
 		})).To(BeFalse())
 	})
@@ -135,7 +137,7 @@ var _ = Describe("TaggingController", func() {
 		ExpectApplied(ctx, env.Client, nodeClaim)
 		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
 		Expect(nodeClaim.Annotations).To(Not(HaveKey(v1beta1.AnnotationInstanceTagged)))
-		Expect(lo.ContainsBy(ec2Instance.Tags, func(tag *ec2.Tag) bool {
+		Expect(lo.ContainsBy(ec2Instance.Tags, func(tag ec2types.Tag) bool {
 			return *tag.Key == v1beta1.TagName
 		})).To(BeFalse())
 	})
@@ -182,11 +184,366 @@ var _ = Describe("TaggingController", func() {
 		Expect(env.Client.Delete(ctx, nodeClaim)).To(Succeed())
 		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
 		Expect(nodeClaim.Annotations).To(Not(HaveKey(v1beta1.AnnotationInstanceTagged)))
-		Expect(lo.ContainsBy(ec2Instance.Tags, func(tag *ec2.Tag) bool {
+		Expect(lo.ContainsBy(ec2Instance.Tags, func(tag ec2types.Tag) bool {
 			return *tag.Key == v1beta1.TagName
 		})).To(BeFalse())
 	})
 
+	It("should re-assert required instance tags that were removed or changed", func() {
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Labels:      map[string]string{corev1beta1.NodePoolLabelKey: "default"},
+				Annotations: map[string]string{v1beta1.AnnotationInstanceTagged: "true"},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ec2Instance.Tags = []ec2types.Tag{
+			{Key: aws.String(corev1beta1.ManagedByAnnotationKey), Value: aws.String("mangled")},
+			{Key: aws.String(v1beta1.TagName), Value: aws.String("custom-tag")},
+		}
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		result := ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).ToNot(BeZero())
+
+		instanceTags := instance.NewInstance(ec2Instance).Tags
+		Expect(instanceTags).To(HaveKeyWithValue(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName), "owned"))
+		Expect(instanceTags).To(HaveKeyWithValue(v1beta1.TagEKSClusterName, options.FromContext(ctx).ClusterName))
+		Expect(instanceTags).To(HaveKeyWithValue(corev1beta1.ManagedByAnnotationKey, options.FromContext(ctx).ClusterName))
+		Expect(instanceTags).To(HaveKeyWithValue(corev1beta1.NodePoolLabelKey, "default"))
+		Expect(instanceTags).To(HaveKeyWithValue(v1beta1.LabelNodeClass, nodeClaim.Spec.NodeClassRef.Name))
+		Expect(instanceTags).To(HaveKeyWithValue(v1beta1.TagNodeClaim, nodeClaim.Name))
+		// Tags that aren't required are only set when they are missing
+		Expect(instanceTags).To(HaveKeyWithValue(v1beta1.TagName, "custom-tag"))
+	})
+	It("should re-assert required tags on the volumes and network interface created at launch", func() {
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Labels: map[string]string{corev1beta1.NodePoolLabelKey: "default"},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		rootVolume := &ec2types.Volume{VolumeId: aws.String(fake.VolumeID())}
+		// the volume of a PersistentVolume is attached after launch, and the volume of a previous instance is detached
+		persistentVolume := &ec2types.Volume{VolumeId: aws.String(fake.VolumeID())}
+		detachedVolume := &ec2types.Volume{VolumeId: aws.String(fake.VolumeID())}
+		networkInterface := &ec2types.NetworkInterface{
+			NetworkInterfaceId: aws.String(fake.NetworkInterfaceID()),
+			TagSet:             []ec2types.Tag{{Key: aws.String(v1beta1.TagEKSClusterName), Value: aws.String("mangled")}},
+		}
+		// the network interfaces of the VPC CNI are attached after launch
+		secondaryNetworkInterface := &ec2types.NetworkInterface{NetworkInterfaceId: aws.String(fake.NetworkInterfaceID())}
+		ec2Instance.RootDeviceName = aws.String("/dev/xvda")
+		ec2Instance.BlockDeviceMappings = []ec2types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: rootVolume.VolumeId, DeleteOnTermination: aws.Bool(true)}},
+			{DeviceName: aws.String("/dev/xvdba"), Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: persistentVolume.VolumeId, DeleteOnTermination: aws.Bool(false)}},
+		}
+		ec2Instance.NetworkInterfaces = []ec2types.InstanceNetworkInterface{
+			{NetworkInterfaceId: networkInterface.NetworkInterfaceId, Attachment: &ec2types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(0), DeleteOnTermination: aws.Bool(true)}},
+			{NetworkInterfaceId: secondaryNetworkInterface.NetworkInterfaceId, Attachment: &ec2types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(1), DeleteOnTermination: aws.Bool(true)}},
+		}
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		for _, v := range []*ec2types.Volume{rootVolume, persistentVolume, detachedVolume} {
+			awsEnv.EC2API.Volumes.Store(*v.VolumeId, v)
+		}
+		for _, ni := range []*ec2types.NetworkInterface{networkInterface, secondaryNetworkInterface} {
+			awsEnv.EC2API.NetworkInterfaces.Store(*ni.NetworkInterfaceId, ni)
+		}
+
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+
+		expectedTags := map[string]string{
+			fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+			v1beta1.TagEKSClusterName:          options.FromContext(ctx).ClusterName,
+			corev1beta1.ManagedByAnnotationKey: options.FromContext(ctx).ClusterName,
+			corev1beta1.NodePoolLabelKey:       "default",
+			v1beta1.LabelNodeClass:             nodeClaim.Spec.NodeClassRef.Name,
+			v1beta1.TagLaunchedBy:              options.FromContext(ctx).ClusterName,
+			v1beta1.TagInstanceID:              *ec2Instance.InstanceId,
+		}
+		toMap := func(tags []ec2types.Tag) map[string]string {
+			return lo.SliceToMap(tags, func(t ec2types.Tag) (string, string) { return *t.Key, *t.Value })
+		}
+		Expect(toMap(rootVolume.Tags)).To(Equal(expectedTags))
+		Expect(toMap(networkInterface.TagSet)).To(Equal(expectedTags))
+		Expect(persistentVolume.Tags).To(BeEmpty())
+		Expect(detachedVolume.Tags).To(BeEmpty())
+		Expect(secondaryNetworkInterface.TagSet).To(BeEmpty())
+	})
+	It("should not tag the volumes of the ec2nodeclass that are retained on termination with the instance ID", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Spec: v1beta1.EC2NodeClassSpec{
+				BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{
+					{DeviceName: aws.String("/dev/xvda"), RootVolume: true, EBS: &v1beta1.BlockDevice{DeleteOnTermination: aws.Bool(true)}},
+					{DeviceName: aws.String("/dev/xvdb"), EBS: &v1beta1.BlockDevice{DeleteOnTermination: aws.Bool(false)}},
+				},
+			},
+		})
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{Name: nodeClass.Name},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		rootVolume := &ec2types.Volume{VolumeId: aws.String(fake.VolumeID())}
+		dataVolume := &ec2types.Volume{VolumeId: aws.String(fake.VolumeID())}
+		ec2Instance.RootDeviceName = aws.String("/dev/xvda")
+		ec2Instance.BlockDeviceMappings = []ec2types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: rootVolume.VolumeId, DeleteOnTermination: aws.Bool(true)}},
+			{DeviceName: aws.String("/dev/xvdb"), Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: dataVolume.VolumeId, DeleteOnTermination: aws.Bool(false)}},
+		}
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		awsEnv.EC2API.Volumes.Store(*rootVolume.VolumeId, rootVolume)
+		awsEnv.EC2API.Volumes.Store(*dataVolume.VolumeId, dataVolume)
+
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+
+		rootVolumeTags := lo.SliceToMap(rootVolume.Tags, func(t ec2types.Tag) (string, string) { return *t.Key, *t.Value })
+		Expect(rootVolumeTags).To(HaveKeyWithValue(v1beta1.TagLaunchedBy, options.FromContext(ctx).ClusterName))
+		Expect(rootVolumeTags).To(HaveKeyWithValue(v1beta1.TagInstanceID, *ec2Instance.InstanceId))
+		dataVolumeTags := lo.SliceToMap(dataVolume.Tags, func(t ec2types.Tag) (string, string) { return *t.Key, *t.Value })
+		Expect(dataVolumeTags).To(HaveKeyWithValue(v1beta1.TagLaunchedBy, options.FromContext(ctx).ClusterName))
+		Expect(dataVolumeTags).ToNot(HaveKey(v1beta1.TagInstanceID))
+	})
+
+	It("should apply the tags of the ec2nodeclass to the instance and its attachments in place", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Spec: v1beta1.EC2NodeClassSpec{
+				Tags: map[string]string{"cost-center": "1234", "team": "compute"},
+			},
+		})
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Labels:      map[string]string{corev1beta1.NodePoolLabelKey: "default"},
+				Annotations: map[string]string{v1beta1.AnnotationInstanceTagged: "true"},
+			},
+			Spec: corev1beta1.NodeClaimSpec{
+				NodeClassRef: &corev1beta1.NodeClassReference{Name: nodeClass.Name},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ec2Instance.Tags = append(ec2Instance.Tags, ec2types.Tag{Key: aws.String("team"), Value: aws.String("storage")})
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		volume := &ec2types.Volume{VolumeId: aws.String(fake.VolumeID())}
+		ec2Instance.RootDeviceName = aws.String("/dev/xvda")
+		ec2Instance.BlockDeviceMappings = []ec2types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: volume.VolumeId}},
+		}
+		awsEnv.EC2API.Volumes.Store(*volume.VolumeId, volume)
+
+		ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+
+		instanceTags := instance.NewInstance(ec2Instance).Tags
+		Expect(instanceTags).To(HaveKeyWithValue("cost-center", "1234"))
+		Expect(instanceTags).To(HaveKeyWithValue("team", "compute"))
+		volumeTags := lo.SliceToMap(volume.Tags, func(t ec2types.Tag) (string, string) { return *t.Key, *t.Value })
+		Expect(volumeTags).To(HaveKeyWithValue("cost-center", "1234"))
+		Expect(volumeTags).To(HaveKeyWithValue("team", "compute"))
+	})
+
+	It("should render the Name tag from the instance name template", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InstanceNameTemplate: lo.ToPtr(`{{ .ClusterName }}-{{ .NodePool.Name }}-{{ .Zone }}-{{ .Suffix }}`),
+		}))
+		nodePool := coretest.NodePool(corev1beta1.NodePool{ObjectMeta: v1.ObjectMeta{Name: "default"}})
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   "default-abc12",
+				Labels: map[string]string{corev1beta1.NodePoolLabelKey: nodePool.Name},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+
+		Expect(instance.NewInstance(ec2Instance).Tags).To(HaveKeyWithValue(v1beta1.TagName,
+			fmt.Sprintf("%s-default-%s-abc12", options.FromContext(ctx).ClusterName, fake.DefaultRegion)))
+	})
+	It("should not override an existing Name tag with the instance name template", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			InstanceNameTemplate: lo.ToPtr(`{{ .ClusterName }}-{{ .Suffix }}`),
+		}))
+		ec2Instance.Tags = append(ec2Instance.Tags, ec2types.Tag{Key: aws.String(v1beta1.TagName), Value: aws.String("custom-name")})
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+
+		Expect(instance.NewInstance(ec2Instance).Tags).To(HaveKeyWithValue(v1beta1.TagName, "custom-name"))
+	})
+	It("should apply the label tags of the node to the instance and its attachments", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{LabelTags: lo.ToPtr(`{"team":"Team","billing-code":"BillingCode"}`)}))
+		node := coretest.Node(coretest.NodeOptions{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   "default",
+				Labels: map[string]string{"team": "compute"},
+			},
+			ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+		})
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Labels: map[string]string{"team": "storage"},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   node.Name,
+			},
+		})
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		volume := &ec2types.Volume{VolumeId: aws.String(fake.VolumeID())}
+		ec2Instance.RootDeviceName = aws.String("/dev/xvda")
+		ec2Instance.BlockDeviceMappings = []ec2types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &ec2types.EbsInstanceBlockDevice{VolumeId: volume.VolumeId}},
+		}
+		awsEnv.EC2API.Volumes.Store(*volume.VolumeId, volume)
+
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+
+		// The labels of the node take precedence over the labels of the nodeclaim, and labels that aren't set aren't applied
+		instanceTags := instance.NewInstance(ec2Instance).Tags
+		Expect(instanceTags).To(HaveKeyWithValue("Team", "compute"))
+		Expect(instanceTags).ToNot(HaveKey("BillingCode"))
+		volumeTags := lo.SliceToMap(volume.Tags, func(t ec2types.Tag) (string, string) { return *t.Key, *t.Value })
+		Expect(volumeTags).To(HaveKeyWithValue("Team", "compute"))
+	})
+
+	It("should annotate the nodeClaim with the launch details of the instance", func() {
+		networkInterfaceIDs := []string{fake.NetworkInterfaceID(), fake.NetworkInterfaceID()}
+		ec2Instance.NetworkInterfaces = lo.Map(networkInterfaceIDs, func(id string, _ int) ec2types.InstanceNetworkInterface {
+			return ec2types.InstanceNetworkInterface{NetworkInterfaceId: aws.String(id)}
+		})
+		ec2Instance.CapacityReservationId = aws.String("cr-1234567890abcdef0")
+		ec2Instance.Tags = append(ec2Instance.Tags, ec2types.Tag{Key: aws.String(v1beta1.TagLaunchTemplateVersion), Value: aws.String("3")})
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Annotations: map[string]string{
+					v1beta1.AnnotationLaunchTemplateName:   "karpenter.k8s.aws/12345",
+					v1beta1.AnnotationCreateFleetRequestID: fake.CreateFleetRequestID,
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationLaunchTemplateVersion, "3"))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationNetworkInterfaceIDs, strings.Join(networkInterfaceIDs, ",")))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationCapacityReservationID, "cr-1234567890abcdef0"))
+		// the details that were recorded at launch are kept
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationLaunchTemplateName, "karpenter.k8s.aws/12345"))
+		Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationCreateFleetRequestID, fake.CreateFleetRequestID))
+	})
+	It("should not annotate the nodeClaim with launch details that aren't known", func() {
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Annotations).To(HaveKey(v1beta1.AnnotationInstanceTagged))
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationLaunchTemplateVersion))
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationNetworkInterfaceIDs))
+		Expect(nodeClaim.Annotations).ToNot(HaveKey(v1beta1.AnnotationCapacityReservationID))
+	})
+	It("should label the nodeClaim and node of instances in a capacity reservation as reserved", func() {
+		ec2Instance.CapacityReservationId = aws.String("cr-1234567890abcdef0")
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		node := coretest.Node(coretest.NodeOptions{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   "default",
+				Labels: map[string]string{v1beta1.LabelCapacityType: corev1beta1.CapacityTypeOnDemand},
+			},
+			ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+		})
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeOnDemand,
+					v1beta1.LabelCapacityType:        corev1beta1.CapacityTypeOnDemand,
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   node.Name,
+			},
+		})
+
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+		for _, labels := range []map[string]string{ExpectExists(ctx, env.Client, nodeClaim).Labels, ExpectExists(ctx, env.Client, node).Labels} {
+			Expect(labels).To(HaveKeyWithValue(v1beta1.LabelCapacityType, v1beta1.CapacityTypeReserved))
+			Expect(labels).To(HaveKeyWithValue(v1beta1.LabelCapacityReservationID, "cr-1234567890abcdef0"))
+		}
+		// the capacity type that Karpenter schedules against is unchanged
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Labels).To(HaveKeyWithValue(corev1beta1.CapacityTypeLabelKey, corev1beta1.CapacityTypeOnDemand))
+	})
+	It("should annotate the node with the spot price that the nodeClaim was launched at", func() {
+		node := coretest.Node(coretest.NodeOptions{
+			ObjectMeta: v1.ObjectMeta{Name: "default"},
+			ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+		})
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: v1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.AnnotationSpotPrice: "0.0412"},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   node.Name,
+			},
+		})
+
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Annotations).To(HaveKeyWithValue(v1beta1.AnnotationSpotPrice, "0.0412"))
+	})
+	It("should not label the nodeClaim of instances outside of capacity reservations as reserved", func() {
+		nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   "default",
+			},
+		})
+
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, taggingController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelCapacityType, corev1beta1.CapacityTypeOnDemand))
+		Expect(nodeClaim.Labels).ToNot(HaveKey(v1beta1.LabelCapacityReservationID))
+	})
+
 	DescribeTable(
 		"should tag taggable instances",
 		func(customTags ...string) {
@@ -198,7 +555,7 @@ var _ = Describe("TaggingController", func() {
 			})
 
 			for _, tag := range customTags {
-				ec2Instance.Tags = append(ec2Instance.Tags, &ec2.Tag{
+				ec2Instance.Tags = append(ec2Instance.Tags, ec2types.Tag{
 					Key:   aws.String(tag),
 					Value: aws.String("custom-tag"),
 				})
@@ -0,0 +1,135 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/throttling"
+)
+
+// tagSyncInterval is how often the tags of instances are synced to their nodes, since tags are changed out-of-band
+const tagSyncInterval = 5 * time.Minute
+
+// Controller syncs the tags of the instances of registered NodeClaims to the labels and annotations of their nodes,
+// for the tag keys that are configured with --tag-labels and --tag-annotations. Labels and annotations are added and
+// updated, but aren't removed when their tag is removed from the instance.
+type Controller struct {
+	kubeClient       client.Client
+	instanceProvider *instance.Provider
+}
+
+func NewController(kubeClient client.Client, instanceProvider *instance.Provider) corecontroller.Controller {
+	return corecontroller.Typed[*corev1beta1.NodeClaim](kubeClient, &Controller{
+		kubeClient:       kubeClient,
+		instanceProvider: instanceProvider,
+	})
+}
+
+func (c *Controller) Name() string {
+	return "nodeclaim.tagsync"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) (reconcile.Result, error) {
+	// Tags are reconciled again once the circuit breaker closes, so the calls are shed while AWS APIs are throttling
+	ctx = throttling.NonCritical(audit.WithNodeClaim(ctx, nodeClaim))
+	if nodeClaim.Status.NodeName == "" || !nodeClaim.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("provider-id", nodeClaim.Status.ProviderID))
+	id, err := utils.ParseInstanceID(nodeClaim.Status.ProviderID)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("failed to parse instance ID, %w", err)
+		return reconcile.Result{}, nil
+	}
+	node := &v1.Node{}
+	if err = c.kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("getting node, %w", err))
+	}
+	i, err := c.instanceProvider.Get(ctx, id)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	}
+	labelKeys, err := options.ParseTagKeys(options.FromContext(ctx).TagLabels)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("parsing tag labels, %w", err)
+	}
+	annotationKeys, err := options.ParseTagKeys(options.FromContext(ctx).TagAnnotations)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("parsing tag annotations, %w", err)
+	}
+	stored := node.DeepCopy()
+	node.Labels = lo.Assign(node.Labels, lo.PickBy(i.Tags, func(k, v string) bool {
+		return matches(labelKeys, k) && len(validation.IsQualifiedName(k)) == 0 && len(validation.IsValidLabelValue(v)) == 0
+	}))
+	node.Annotations = lo.Assign(node.Annotations, lo.PickBy(i.Tags, func(k, _ string) bool {
+		return matches(annotationKeys, k) && len(validation.IsQualifiedName(k)) == 0
+	}))
+	if !equality.Semantic.DeepEqual(node, stored) {
+		if err = c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+		}
+		logging.FromContext(ctx).With("node", node.Name).Debugf("synced instance tags to node")
+	}
+	return reconcile.Result{RequeueAfter: tagSyncInterval}, nil
+}
+
+// matches returns true if the tag key is one of the keys, or has the prefix of one of the keys that end in *
+func matches(keys []string, tag string) bool {
+	return lo.ContainsBy(keys, func(k string) bool {
+		if prefix, ok := strings.CutSuffix(k, "*"); ok {
+			return strings.HasPrefix(tag, prefix)
+		}
+		return k == tag
+	})
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&corev1beta1.NodeClaim{}).
+			// Registered NodeClaims are requeued periodically, so only NodeClaims that have just registered are watched
+			WithEventFilter(predicate.Funcs{
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					return e.ObjectOld.(*corev1beta1.NodeClaim).Status.NodeName == "" && e.ObjectNew.(*corev1beta1.NodeClaim).Status.NodeName != ""
+				},
+			}),
+	)
+}
@@ -0,0 +1,140 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagsync_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/nodeclaim/tagsync"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var tagSyncController corecontroller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TagSync")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		TagLabels:      lo.ToPtr(`["rack","team"]`),
+		TagAnnotations: lo.ToPtr(`["billing-*"]`),
+	}))
+	awsEnv = test.NewEnvironment(ctx, env)
+	tagSyncController = tagsync.NewController(env.Client, awsEnv.InstanceProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("TagSync", func() {
+	var nodeClaim *corev1beta1.NodeClaim
+	var node *v1.Node
+	var ec2Instance *ec2types.Instance
+
+	BeforeEach(func() {
+		ec2Instance = &ec2types.Instance{
+			InstanceId:   aws.String(fake.InstanceID()),
+			InstanceType: "m5.large",
+			State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+			Placement:    &ec2types.Placement{AvailabilityZone: aws.String(fake.DefaultRegion)},
+			Tags: []ec2types.Tag{
+				{Key: aws.String("rack"), Value: aws.String("r42")},
+				{Key: aws.String("team"), Value: aws.String("not a valid label value")},
+				{Key: aws.String("billing-code"), Value: aws.String("cc-1234")},
+				{Key: aws.String("patch-group"), Value: aws.String("weekly")},
+			},
+		}
+		awsEnv.EC2API.Instances.Store(*ec2Instance.InstanceId, ec2Instance)
+		node = coretest.Node(coretest.NodeOptions{ProviderID: fake.ProviderID(*ec2Instance.InstanceId)})
+		nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: fake.ProviderID(*ec2Instance.InstanceId),
+				NodeName:   node.Name,
+			},
+		})
+	})
+	It("should sync the configured instance tags to the labels and annotations of the node", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+		ExpectReconcileSucceeded(ctx, tagSyncController, client.ObjectKeyFromObject(nodeClaim))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue("rack", "r42"))
+		Expect(node.Annotations).To(HaveKeyWithValue("billing-code", "cc-1234"))
+		// Tags whose values aren't valid label values aren't synced to labels
+		Expect(node.Labels).ToNot(HaveKey("team"))
+		// Tags that aren't configured aren't synced
+		Expect(node.Labels).ToNot(HaveKey("patch-group"))
+		Expect(node.Annotations).ToNot(HaveKey("patch-group"))
+	})
+	It("should update labels when the instance's tags change", func() {
+		node.Labels["rack"] = "r1"
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+		ExpectReconcileSucceeded(ctx, tagSyncController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKeyWithValue("rack", "r42"))
+	})
+	It("should not sync tags to nodeclaims that haven't registered", func() {
+		nodeClaim.Status.NodeName = ""
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+		ExpectReconcileSucceeded(ctx, tagSyncController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).ToNot(HaveKey("rack"))
+	})
+	It("should not remove labels when their tag is removed from the instance", func() {
+		node.Labels["team"] = "compute"
+		ExpectApplied(ctx, env.Client, nodeClaim, node)
+
+		ExpectReconcileSucceeded(ctx, tagSyncController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKeyWithValue("team", "compute"))
+	})
+})
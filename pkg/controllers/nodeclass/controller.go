@@ -18,14 +18,16 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
-	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
-
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
@@ -39,50 +41,57 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/events"
 	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
-	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+)
+
+// Reasons that the subnets, security groups or AMIs of an EC2NodeClass aren't ready
+const (
+	ReasonSubnetsNotFound        = "SubnetsNotFound"
+	ReasonSecurityGroupsNotFound = "SecurityGroupsNotFound"
+	ReasonAMIsNotFound           = "AMIsNotFound"
 )
 
 var _ corecontroller.FinalizingTypedController[*v1beta1.EC2NodeClass] = (*Controller)(nil)
 
 type Controller struct {
-	kubeClient              client.Client
-	recorder                events.Recorder
-	subnetProvider          *subnet.Provider
-	securityGroupProvider   *securitygroup.Provider
-	amiProvider             *amifamily.Provider
-	instanceProfileProvider *instanceprofile.Provider
-	launchTemplateProvider  *launchtemplate.Provider
+	kubeClient      client.Client
+	recorder        events.Recorder
+	accountProvider *account.Provider
+	lastResolved    sync.Map // map[nodeclass/resource]time.Time
+	// nodeClassEvents enqueues EC2NodeClasses whose resources should be re-resolved before they are next requeued
+	nodeClassEvents <-chan event.GenericEvent
 }
 
-func NewController(kubeClient client.Client, recorder events.Recorder, subnetProvider *subnet.Provider, securityGroupProvider *securitygroup.Provider,
-	amiProvider *amifamily.Provider, instanceProfileProvider *instanceprofile.Provider, launchTemplateProvider *launchtemplate.Provider) corecontroller.Controller {
-
+func NewController(kubeClient client.Client, recorder events.Recorder, accountProvider *account.Provider, nodeClassEvents <-chan event.GenericEvent) corecontroller.Controller {
 	return corecontroller.Typed[*v1beta1.EC2NodeClass](kubeClient, &Controller{
-		kubeClient:              kubeClient,
-		recorder:                recorder,
-		subnetProvider:          subnetProvider,
-		securityGroupProvider:   securityGroupProvider,
-		amiProvider:             amiProvider,
-		instanceProfileProvider: instanceProfileProvider,
-		launchTemplateProvider:  launchTemplateProvider,
+		kubeClient:      kubeClient,
+		recorder:        recorder,
+		accountProvider: accountProvider,
+		nodeClassEvents: nodeClassEvents,
 	})
 }
 
 func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+	ctx = audit.WithNodeClass(ctx, nodeClass)
 	stored := nodeClass.DeepCopy()
 	controllerutil.AddFinalizer(nodeClass, v1beta1.TerminationFinalizer)
 
-	if nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion] != v1beta1.EC2NodeClassHashVersion {
-		if err := c.updateNodeClaimHash(ctx, nodeClass); err != nil {
+	// Changing the fields that are excluded from drift changes the hash without changing the instances that would be
+	// launched, so NodeClaims are re-hashed in the same way as when the hash version changes
+	ignoredFieldsChanged := nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHashDriftIgnoredFields] != nodeClass.Annotations[v1beta1.AnnotationDriftIgnoredFields]
+	if nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion] != v1beta1.EC2NodeClassHashVersion || ignoredFieldsChanged {
+		if err := c.updateNodeClaimHash(ctx, nodeClass, ignoredFieldsChanged); err != nil {
 			return reconcile.Result{}, err
 		}
 	}
@@ -90,15 +99,21 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 		v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
 		v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
 	})
+	if ignored, ok := nodeClass.Annotations[v1beta1.AnnotationDriftIgnoredFields]; ok {
+		nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHashDriftIgnoredFields] = ignored
+	} else {
+		delete(nodeClass.Annotations, v1beta1.AnnotationEC2NodeClassHashDriftIgnoredFields)
+	}
 
 	err := multierr.Combine(
-		c.resolveSubnets(ctx, nodeClass),
-		c.resolveSecurityGroups(ctx, nodeClass),
-		c.resolveAMIs(ctx, nodeClass),
+		c.recordResolution(nodeClass, resourceSubnets, c.resolveSubnets(ctx, nodeClass)),
+		c.recordResolution(nodeClass, resourceSecurityGroups, c.resolveSecurityGroups(ctx, nodeClass)),
+		c.recordResolution(nodeClass, resourceAMIs, c.resolveAMIs(ctx, nodeClass)),
 		c.resolveInstanceProfile(ctx, nodeClass),
 	)
+	c.resolveIMDSAccess(nodeClass)
 	if lo.FromPtr(nodeClass.Spec.AMIFamily) == v1beta1.AMIFamilyAL2023 {
-		if cidrErr := c.launchTemplateProvider.ResolveClusterCIDR(ctx); err != nil {
+		if cidrErr := c.accountProvider.For(nodeClass).LaunchTemplate.ResolveClusterCIDR(ctx); err != nil {
 			err = multierr.Append(err, fmt.Errorf("resolving cluster CIDR, %w", cidrErr))
 		}
 	}
@@ -114,10 +129,14 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClass *v1beta1.EC2NodeCl
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	if err := c.accountProvider.For(nodeClass).LaunchTemplate.DeleteSupersededLaunchTemplates(ctx, nodeClass); err != nil {
+		return reconcile.Result{}, fmt.Errorf("deleting superseded launch templates, %w", err)
+	}
 	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
 }
 
 func (c *Controller) Finalize(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (reconcile.Result, error) {
+	ctx = audit.WithNodeClass(ctx, nodeClass)
 	stored := nodeClass.DeepCopy()
 	if !controllerutil.ContainsFinalizer(nodeClass, v1beta1.TerminationFinalizer) {
 		return reconcile.Result{}, nil
@@ -131,11 +150,14 @@ func (c *Controller) Finalize(ctx context.Context, nodeClass *v1beta1.EC2NodeCla
 		return reconcile.Result{RequeueAfter: time.Minute * 10}, nil // periodically fire the event
 	}
 	if nodeClass.Spec.Role != "" {
-		if err := c.instanceProfileProvider.Delete(ctx, nodeClass); err != nil {
+		if err := c.accountProvider.For(nodeClass).InstanceProfile.Delete(ctx, nodeClass); err != nil {
 			return reconcile.Result{}, fmt.Errorf("deleting instance profile, %w", err)
 		}
+		if err := c.deleteNodeRole(ctx, nodeClass); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
-	if err := c.launchTemplateProvider.DeleteLaunchTemplates(ctx, nodeClass); err != nil {
+	if err := c.accountProvider.For(nodeClass).LaunchTemplate.DeleteLaunchTemplates(ctx, nodeClass); err != nil {
 		return reconcile.Result{}, fmt.Errorf("deleting launch templates, %w", err)
 	}
 	controllerutil.RemoveFinalizer(nodeClass, v1beta1.TerminationFinalizer)
@@ -144,17 +166,40 @@ func (c *Controller) Finalize(ctx context.Context, nodeClass *v1beta1.EC2NodeCla
 			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("removing termination finalizer, %w", err))
 		}
 	}
+	for _, resource := range []string{resourceSubnets, resourceSecurityGroups, resourceAMIs} {
+		c.lastResolved.Delete(nodeClass.Name + "/" + resource)
+	}
+	resolutionAge.DeletePartialMatch(prometheus.Labels{nodeClassLabel: nodeClass.Name})
+	resolutionFailures.DeletePartialMatch(prometheus.Labels{nodeClassLabel: nodeClass.Name})
 	return reconcile.Result{}, nil
 }
 
+// recordResolution records the outcome of resolving a resource of the EC2NodeClass and returns the error, if any. The
+// age is measured from the last successful resolution, or from the first attempt if none have succeeded since the
+// controller started, so that EC2NodeClasses that have been failing discovery for a long time can be alerted on.
+func (c *Controller) recordResolution(nodeClass *v1beta1.EC2NodeClass, resource string, err error) error {
+	key := nodeClass.Name + "/" + resource
+	now := time.Now()
+	if err != nil {
+		resolutionFailures.With(prometheus.Labels{nodeClassLabel: nodeClass.Name, resourceLabel: resource}).Inc()
+	} else {
+		c.lastResolved.Store(key, now)
+	}
+	lastResolved, _ := c.lastResolved.LoadOrStore(key, now)
+	resolutionAge.With(prometheus.Labels{nodeClassLabel: nodeClass.Name, resourceLabel: resource}).Set(now.Sub(lastResolved.(time.Time)).Seconds())
+	return err
+}
+
 func (c *Controller) resolveSubnets(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
-	subnets, err := c.subnetProvider.List(ctx, nodeClass)
+	subnets, err := c.accountProvider.For(nodeClass).Subnet.List(ctx, nodeClass)
 	if err != nil {
 		return err
 	}
 	if len(subnets) == 0 {
 		nodeClass.Status.Subnets = nil
-		return fmt.Errorf("no subnets exist given constraints %v", nodeClass.Spec.SubnetSelectorTerms)
+		err := fmt.Errorf("no subnets exist given constraints %v", nodeClass.Spec.SubnetSelectorTerms)
+		nodeClass.StatusConditions().MarkFalse(v1beta1.ConditionTypeSubnetsReady, ReasonSubnetsNotFound, "%s", err)
+		return err
 	}
 	sort.Slice(subnets, func(i, j int) bool {
 		if int(*subnets[i].AvailableIpAddressCount) != int(*subnets[j].AvailableIpAddressCount) {
@@ -162,44 +207,58 @@ func (c *Controller) resolveSubnets(ctx context.Context, nodeClass *v1beta1.EC2N
 		}
 		return *subnets[i].SubnetId < *subnets[j].SubnetId
 	})
-	nodeClass.Status.Subnets = lo.Map(subnets, func(ec2subnet *ec2.Subnet, _ int) v1beta1.Subnet {
+	nodeClass.Status.Subnets = lo.Map(subnets, func(ec2subnet *ec2types.Subnet, _ int) v1beta1.Subnet {
 		return v1beta1.Subnet{
-			ID:   *ec2subnet.SubnetId,
-			Zone: *ec2subnet.AvailabilityZone,
+			ID:                      *ec2subnet.SubnetId,
+			Zone:                    *ec2subnet.AvailabilityZone,
+			ZoneID:                  aws.ToString(ec2subnet.AvailabilityZoneId),
+			AvailableIPAddressCount: aws.ToInt32(ec2subnet.AvailableIpAddressCount),
+			IPv6CIDRBlocks: lo.FilterMap(ec2subnet.Ipv6CidrBlockAssociationSet, func(association ec2types.SubnetIpv6CidrBlockAssociation, _ int) (string, bool) {
+				return aws.ToString(association.Ipv6CidrBlock), association.Ipv6CidrBlockState != nil && association.Ipv6CidrBlockState.State == ec2types.SubnetCidrBlockStateCodeAssociated
+			}),
 		}
 	})
+	nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeSubnetsReady)
 	return nil
 }
 
 func (c *Controller) resolveSecurityGroups(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
-	securityGroups, err := c.securityGroupProvider.List(ctx, nodeClass)
+	securityGroups, err := c.accountProvider.For(nodeClass).SecurityGroup.List(ctx, nodeClass)
 	if err != nil {
 		return err
 	}
 	if len(securityGroups) == 0 && len(nodeClass.Spec.SecurityGroupSelectorTerms) > 0 {
 		nodeClass.Status.SecurityGroups = nil
-		return fmt.Errorf("no security groups exist given constraints")
+		err := fmt.Errorf("no security groups exist given constraints %v", nodeClass.Spec.SecurityGroupSelectorTerms)
+		nodeClass.StatusConditions().MarkFalse(v1beta1.ConditionTypeSecurityGroupsReady, ReasonSecurityGroupsNotFound, "%s", err)
+		return err
 	}
 	sort.Slice(securityGroups, func(i, j int) bool {
 		return *securityGroups[i].GroupId < *securityGroups[j].GroupId
 	})
-	nodeClass.Status.SecurityGroups = lo.Map(securityGroups, func(securityGroup *ec2.SecurityGroup, _ int) v1beta1.SecurityGroup {
+	nodeClass.Status.SecurityGroups = lo.Map(securityGroups, func(securityGroup *ec2types.SecurityGroup, _ int) v1beta1.SecurityGroup {
 		return v1beta1.SecurityGroup{
 			ID:   *securityGroup.GroupId,
-			Name: *securityGroup.GroupName,
+			Name: aws.ToString(securityGroup.GroupName),
 		}
 	})
+	nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeSecurityGroupsReady)
 	return nil
 }
 
 func (c *Controller) resolveAMIs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
-	amis, err := c.amiProvider.Get(ctx, nodeClass, &amifamily.Options{})
+	amis, err := c.accountProvider.For(nodeClass).AMI.Get(ctx, nodeClass, &amifamily.Options{})
 	if err != nil {
 		return err
 	}
 	if len(amis) == 0 {
 		nodeClass.Status.AMIs = nil
-		return fmt.Errorf("no amis exist given constraints")
+		err := fmt.Errorf("no amis exist given constraints %v", nodeClass.Spec.AMISelectorTerms)
+		if len(nodeClass.Spec.AMISelectorTerms) == 0 {
+			err = fmt.Errorf("no amis exist for amiFamily %s", lo.FromPtr(nodeClass.Spec.AMIFamily))
+		}
+		nodeClass.StatusConditions().MarkFalse(v1beta1.ConditionTypeAMIsReady, ReasonAMIsNotFound, "%s", err)
+		return err
 	}
 	nodeClass.Status.AMIs = lo.Map(amis, func(ami amifamily.AMI, _ int) v1beta1.AMI {
 		reqs := ami.Requirements.NodeSelectorRequirements()
@@ -212,22 +271,129 @@ func (c *Controller) resolveAMIs(ctx context.Context, nodeClass *v1beta1.EC2Node
 		return v1beta1.AMI{
 			Name:         ami.Name,
 			ID:           ami.AmiID,
+			CreationDate: ami.CreationDate,
+			Architecture: amiArchitecture(ami),
 			Requirements: reqs,
 		}
 	})
-
+	nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeAMIsReady)
 	return nil
 }
 
+// resolveInstanceProfileARN returns the ARN of the instance profile in spec.instanceProfile. It's only looked up when
+// the instance profile changes, and is left empty in isolated VPCs, which can't reach IAM, or when Karpenter isn't
+// allowed to get the instance profile.
+func (c *Controller) resolveInstanceProfileARN(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (string, error) {
+	name := lo.FromPtr(nodeClass.Spec.InstanceProfile)
+	if options.FromContext(ctx).IsolatedVPC {
+		return "", nil
+	}
+	if nodeClass.Status.InstanceProfile == name && nodeClass.Status.InstanceProfileARN != "" {
+		return nodeClass.Status.InstanceProfileARN, nil
+	}
+	instanceProfile, err := c.accountProvider.For(nodeClass).InstanceProfile.Get(ctx, name)
+	if err != nil {
+		if awserrors.IsAccessDenied(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return aws.ToString(instanceProfile.Arn), nil
+}
+
+// amiArchitecture returns the architecture that the AMI is restricted to by its requirements, or an empty string if
+// its requirements don't restrict it to a single architecture
+func amiArchitecture(ami amifamily.AMI) string {
+	if !ami.Requirements.Has(v1.LabelArchStable) {
+		return ""
+	}
+	if requirement := ami.Requirements.Get(v1.LabelArchStable); requirement.Operator() == v1.NodeSelectorOpIn && requirement.Len() == 1 {
+		return requirement.Any()
+	}
+	return ""
+}
+
 func (c *Controller) resolveInstanceProfile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
+	reason, message, err := c.accountProvider.For(nodeClass).InstanceProfile.Check(ctx, nodeClass)
+	if err != nil {
+		return fmt.Errorf("checking instance profile, %w", err)
+	}
+	if reason != "" {
+		nodeClass.Status.InstanceProfile = ""
+		nodeClass.Status.InstanceProfileARN = ""
+		nodeClass.Status.InstanceProfileManaged = false
+		nodeClass.StatusConditions().MarkFalse(v1beta1.ConditionTypeInstanceProfileReady, reason, "%s", message)
+		return fmt.Errorf("%s", message)
+	}
 	if nodeClass.Spec.Role != "" {
-		name, err := c.instanceProfileProvider.Create(ctx, nodeClass)
+		if options.FromContext(ctx).ManageNodeRoles {
+			if err := c.accountProvider.For(nodeClass).NodeRole.Create(ctx, nodeClass); err != nil {
+				return fmt.Errorf("creating node role, %w", err)
+			}
+		}
+		instanceProfile, err := c.accountProvider.For(nodeClass).InstanceProfile.Create(ctx, nodeClass)
 		if err != nil {
 			return fmt.Errorf("creating instance profile, %w", err)
 		}
-		nodeClass.Status.InstanceProfile = name
+		nodeClass.Status.InstanceProfile = aws.ToString(instanceProfile.InstanceProfileName)
+		nodeClass.Status.InstanceProfileARN = aws.ToString(instanceProfile.Arn)
+		nodeClass.Status.InstanceProfileManaged = true
+	} else if nodeClass.Spec.InstanceProfileSelectorTerms != nil {
+		instanceProfiles, err := c.accountProvider.For(nodeClass).InstanceProfile.List(ctx, nodeClass)
+		if err != nil {
+			return err
+		}
+		if len(instanceProfiles) == 0 {
+			nodeClass.Status.InstanceProfile = ""
+			nodeClass.Status.InstanceProfileARN = ""
+			nodeClass.Status.InstanceProfileManaged = false
+			return fmt.Errorf("no instance profiles exist given constraints %v", nodeClass.Spec.InstanceProfileSelectorTerms)
+		}
+		// The most recently created instance profile is used, so that instance profiles can be rotated by creating a new one
+		nodeClass.Status.InstanceProfile = aws.ToString(instanceProfiles[0].InstanceProfileName)
+		nodeClass.Status.InstanceProfileARN = aws.ToString(instanceProfiles[0].Arn)
+		nodeClass.Status.InstanceProfileManaged = false
 	} else {
+		arn, err := c.resolveInstanceProfileARN(ctx, nodeClass)
+		if err != nil {
+			return err
+		}
 		nodeClass.Status.InstanceProfile = lo.FromPtr(nodeClass.Spec.InstanceProfile)
+		nodeClass.Status.InstanceProfileARN = arn
+		nodeClass.Status.InstanceProfileManaged = false
+	}
+	nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeInstanceProfileReady)
+	return nil
+}
+
+// resolveIMDSAccess surfaces whether pods can reach IMDS on the instances that are launched with the metadata options.
+// It's only informational, since pods that use IAM roles for service accounts or EKS Pod Identity don't need to.
+func (c *Controller) resolveIMDSAccess(nodeClass *v1beta1.EC2NodeClass) {
+	if reason, message := nodeClass.Spec.MetadataOptions.PodIMDSAccess(); reason != "" {
+		nodeClass.StatusConditions().MarkFalse(v1beta1.ConditionTypeIMDSReachableFromPods, reason, "%s", message)
+		return
+	}
+	nodeClass.StatusConditions().MarkTrue(v1beta1.ConditionTypeIMDSReachableFromPods)
+}
+
+// deleteNodeRole deletes the node role of the EC2NodeClass when it's managed by Karpenter and no other EC2NodeClass
+// uses it
+func (c *Controller) deleteNodeRole(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
+	if !options.FromContext(ctx).ManageNodeRoles {
+		return nil
+	}
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	if lo.ContainsBy(nodeClassList.Items, func(nc v1beta1.EC2NodeClass) bool {
+		return nc.Name != nodeClass.Name && nc.DeletionTimestamp.IsZero() &&
+			nc.Spec.Role == nodeClass.Spec.Role && account.RoleFor(&nc) == account.RoleFor(nodeClass)
+	}) {
+		return nil
+	}
+	if err := c.accountProvider.For(nodeClass).NodeRole.Delete(ctx, nodeClass); err != nil {
+		return fmt.Errorf("deleting node role, %w", err)
 	}
 	return nil
 }
@@ -236,7 +402,8 @@ func (c *Controller) resolveInstanceProfile(ctx context.Context, nodeClass *v1be
 // `ec2nodeclass-hash` annotation on the EC2NodeClass will be updated, due to the breaking change, making the `ec2nodeclass-hash` on the NodeClaim different from
 // EC2NodeClass. Since, we cannot rely on the `ec2nodeclass-hash` on the NodeClaims, due to the breaking change, we will need to re-calculate the hash and update the annotation.
 // For more information on the Drift Hash Versioning: https://github.com/kubernetes-sigs/karpenter/blob/main/designs/drift-hash-versioning.md
-func (c *Controller) updateNodeClaimHash(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
+// The hash of every NodeClaim is re-calculated when the fields that are excluded from drift have changed.
+func (c *Controller) updateNodeClaimHash(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, ignoredFieldsChanged bool) error {
 	ncList := &corev1beta1.NodeClaimList{}
 	if err := c.kubeClient.List(ctx, ncList, client.MatchingFields{"spec.nodeClassRef.name": nodeClass.Name}); err != nil {
 		return err
@@ -247,7 +414,7 @@ func (c *Controller) updateNodeClaimHash(ctx context.Context, nodeClass *v1beta1
 		nc := ncList.Items[i]
 		stored := nc.DeepCopy()
 
-		if nc.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion] != v1beta1.EC2NodeClassHashVersion {
+		if nc.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion] != v1beta1.EC2NodeClassHashVersion || ignoredFieldsChanged {
 			nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
 				v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
 			})
@@ -256,7 +423,8 @@ func (c *Controller) updateNodeClaimHash(ctx context.Context, nodeClass *v1beta1
 			// Since the hashing mechanism has changed we will not be able to determine if the drifted status of the NodeClaim has changed
 			if nc.StatusConditions().GetCondition(corev1beta1.Drifted) == nil {
 				nc.Annotations = lo.Assign(nc.Annotations, map[string]string{
-					v1beta1.AnnotationEC2NodeClassHash: nodeClass.Hash(),
+					v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
+					v1beta1.AnnotationEC2NodeClassFieldHashes: nodeClass.FieldHashes(),
 				})
 			}
 
@@ -276,7 +444,7 @@ func (c *Controller) Name() string {
 }
 
 func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
-	return corecontroller.Adapt(controllerruntime.
+	b := controllerruntime.
 		NewControllerManagedBy(m).
 		For(&v1beta1.EC2NodeClass{}).
 		Watches(
@@ -302,5 +470,9 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontrolle
 				&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
 			),
 			MaxConcurrentReconciles: 10,
-		}))
+		})
+	if c.nodeClassEvents != nil {
+		b = b.WatchesRawSource(&source.Channel{Source: c.nodeClassEvents}, &handler.EnqueueRequestForObject{})
+	}
+	return corecontroller.Adapt(b)
 }
@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+)
+
+// GarbageCollectionController deletes the launch templates of EC2NodeClasses that no longer exist. Launch templates are
+// deleted when their EC2NodeClass is finalized, but they are left behind if the EC2NodeClass's finalizer was removed
+// before the launch templates could be deleted. Since launch templates are discovered through their tags, they are
+// collected even if they were created before the controller restarted.
+type GarbageCollectionController struct {
+	kubeClient             client.Client
+	launchTemplateProvider *launchtemplate.Provider
+}
+
+func NewGarbageCollectionController(kubeClient client.Client, launchTemplateProvider *launchtemplate.Provider) *GarbageCollectionController {
+	return &GarbageCollectionController{
+		kubeClient:             kubeClient,
+		launchTemplateProvider: launchTemplateProvider,
+	}
+}
+
+func (c *GarbageCollectionController) Name() string {
+	return "nodeclass.garbagecollection"
+}
+
+func (c *GarbageCollectionController) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	listedAt := time.Now()
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	nodeClassNames := sets.New(lo.Map(nodeClassList.Items, func(nc v1beta1.EC2NodeClass, _ int) string { return nc.Name })...)
+	if err := c.launchTemplateProvider.DeleteOrphanedLaunchTemplates(ctx, nodeClassNames, listedAt); err != nil {
+		return reconcile.Result{}, fmt.Errorf("deleting orphaned launch templates, %w", err)
+	}
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
+
+func (c *GarbageCollectionController) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,66 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodeclass
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	nodeClassSubsystem = "ec2nodeclass"
+	nodeClassLabel     = "ec2nodeclass"
+	resourceLabel      = "resource"
+)
+
+// Resources of the EC2NodeClass that resolution metrics are recorded for
+const (
+	resourceAMIs           = "amis"
+	resourceSubnets        = "subnets"
+	resourceSecurityGroups = "securitygroups"
+)
+
+var (
+	resolutionAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodeClassSubsystem,
+			Name:      "resolution_age_seconds",
+			Help:      "Time since the resources of the EC2NodeClass were last resolved successfully, or since resolution was first attempted if it hasn't succeeded. Labeled by ec2nodeclass and resource, which is amis, subnets or securitygroups.",
+		},
+		[]string{
+			nodeClassLabel,
+			resourceLabel,
+		},
+	)
+	resolutionFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodeClassSubsystem,
+			Name:      "resolution_failures_total",
+			Help:      "Number of attempts to resolve the resources of the EC2NodeClass that failed. Labeled by ec2nodeclass and resource, which is amis, subnets or securitygroups.",
+		},
+		[]string{
+			nodeClassLabel,
+			resourceLabel,
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(resolutionAge, resolutionFailures)
+}
@@ -24,14 +24,18 @@ import (
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
+	knativeapis "knative.dev/pkg/apis"
 	_ "knative.dev/pkg/system/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/eks"
-	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/events"
 	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
@@ -57,6 +61,7 @@ var ctx context.Context
 var env *coretest.Environment
 var awsEnv *test.Environment
 var nodeClassController corecontroller.Controller
+var garbageCollectionController *nodeclass.GarbageCollectionController
 
 func TestAPIs(t *testing.T) {
 	ctx = TestContextWithLogger(t)
@@ -70,7 +75,8 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
 
-	nodeClassController = nodeclass.NewController(env.Client, events.NewRecorder(&record.FakeRecorder{}), awsEnv.SubnetProvider, awsEnv.SecurityGroupProvider, awsEnv.AMIProvider, awsEnv.InstanceProfileProvider, awsEnv.LaunchTemplateProvider)
+	nodeClassController = nodeclass.NewController(env.Client, events.NewRecorder(&record.FakeRecorder{}), awsEnv.AccountProvider, nil)
+	garbageCollectionController = nodeclass.NewGarbageCollectionController(env.Client, awsEnv.LaunchTemplateProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -79,6 +85,7 @@ var _ = AfterSuite(func() {
 
 var _ = BeforeEach(func() {
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
 	awsEnv.Reset()
 })
 
@@ -89,6 +96,11 @@ var _ = AfterEach(func() {
 var _ = Describe("NodeClassController", func() {
 	var nodeClass *v1beta1.EC2NodeClass
 	BeforeEach(func() {
+		// The role of the EC2NodeClass must exist for it to be resolved when Karpenter doesn't manage node roles
+		awsEnv.IAMAPI.Roles["test-role"] = &iamtypes.Role{
+			Arn:      aws.String("arn:aws:iam::123456789012:role/test-role"),
+			RoleName: aws.String("test-role"),
+		}
 		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
 			Spec: v1beta1.EC2NodeClassSpec{
 				SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{
@@ -137,8 +149,8 @@ var _ = Describe("NodeClassController", func() {
 		})
 		It("should resolve cluster CIDR for IPv6 clusters", func() {
 			awsEnv.EKSAPI.DescribeClusterBehavior.Output.Set(&eks.DescribeClusterOutput{
-				Cluster: &eks.Cluster{
-					KubernetesNetworkConfig: &eks.KubernetesNetworkConfigResponse{
+				Cluster: &ekstypes.Cluster{
+					KubernetesNetworkConfig: &ekstypes.KubernetesNetworkConfigResponse{
 						ServiceIpv6Cidr: lo.ToPtr("2001:db8::/64"),
 					},
 				},
@@ -156,44 +168,82 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test2",
-					Zone: "test-zone-1b",
+					ID:                      "subnet-test2",
+					Zone:                    "test-zone-1b",
+					ZoneID:                  "testzone1b",
+					AvailableIPAddressCount: 100,
+					IPv6CIDRBlocks:          []string{"2001:db8::/64"},
 				},
 				{
-					ID:   "subnet-test3",
-					Zone: "test-zone-1c",
+					ID:                      "subnet-test3",
+					Zone:                    "test-zone-1c",
+					ZoneID:                  "testzone1c",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test4",
-					Zone: "test-zone-1a-local",
+					ID:                      "subnet-test4",
+					Zone:                    "test-zone-1a-local",
+					ZoneID:                  "testzone1alocal",
+					AvailableIPAddressCount: 100,
 				},
 			}))
 		})
 		It("Should have the correct ordering for the Subnets", func() {
-			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []*ec2.Subnet{
-				{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int64(20)},
-				{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailableIpAddressCount: aws.Int64(100)},
-				{SubnetId: aws.String("subnet-test3"), AvailabilityZone: aws.String("test-zone-1c"), AvailableIpAddressCount: aws.Int64(50)},
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{SubnetId: aws.String("subnet-test1"), AvailabilityZone: aws.String("test-zone-1a"), AvailableIpAddressCount: aws.Int32(20)},
+				{SubnetId: aws.String("subnet-test2"), AvailabilityZone: aws.String("test-zone-1b"), AvailableIpAddressCount: aws.Int32(100)},
+				{SubnetId: aws.String("subnet-test3"), AvailabilityZone: aws.String("test-zone-1c"), AvailableIpAddressCount: aws.Int32(50)},
 			}})
 			ExpectApplied(ctx, env.Client, nodeClass)
 			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test2",
-					Zone: "test-zone-1b",
+					ID:                      "subnet-test2",
+					Zone:                    "test-zone-1b",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test3",
-					Zone: "test-zone-1c",
+					ID:                      "subnet-test3",
+					Zone:                    "test-zone-1c",
+					AvailableIPAddressCount: 50,
 				},
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					AvailableIPAddressCount: 20,
+				},
+			}))
+		})
+		It("Should only include the IPv6 CIDR blocks that are associated with the Subnets", func() {
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{
+					SubnetId:                aws.String("subnet-test1"),
+					AvailabilityZone:        aws.String("test-zone-1a"),
+					AvailabilityZoneId:      aws.String("testzone1a"),
+					AvailableIpAddressCount: aws.Int32(0),
+					Ipv6CidrBlockAssociationSet: []ec2types.SubnetIpv6CidrBlockAssociation{
+						{Ipv6CidrBlock: aws.String("2001:db8::/64"), Ipv6CidrBlockState: &ec2types.SubnetCidrBlockState{State: ec2types.SubnetCidrBlockStateCodeAssociated}},
+						{Ipv6CidrBlock: aws.String("2001:db8:1::/64"), Ipv6CidrBlockState: &ec2types.SubnetCidrBlockState{State: ec2types.SubnetCidrBlockStateCodeDisassociated}},
+					},
+				},
+			}})
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
+				{
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 0,
+					IPv6CIDRBlocks:          []string{"2001:db8::/64"},
 				},
 			}))
 		})
@@ -211,12 +261,17 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test2",
-					Zone: "test-zone-1b",
+					ID:                      "subnet-test2",
+					Zone:                    "test-zone-1b",
+					ZoneID:                  "testzone1b",
+					AvailableIPAddressCount: 100,
+					IPv6CIDRBlocks:          []string{"2001:db8::/64"},
 				},
 			}))
 		})
@@ -231,8 +286,10 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 			}))
 		})
@@ -242,20 +299,29 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test2",
-					Zone: "test-zone-1b",
+					ID:                      "subnet-test2",
+					Zone:                    "test-zone-1b",
+					ZoneID:                  "testzone1b",
+					AvailableIPAddressCount: 100,
+					IPv6CIDRBlocks:          []string{"2001:db8::/64"},
 				},
 				{
-					ID:   "subnet-test3",
-					Zone: "test-zone-1c",
+					ID:                      "subnet-test3",
+					Zone:                    "test-zone-1c",
+					ZoneID:                  "testzone1c",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test4",
-					Zone: "test-zone-1a-local",
+					ID:                      "subnet-test4",
+					Zone:                    "test-zone-1a-local",
+					ZoneID:                  "testzone1alocal",
+					AvailableIPAddressCount: 100,
 				},
 			}))
 
@@ -276,12 +342,17 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test2",
-					Zone: "test-zone-1b",
+					ID:                      "subnet-test2",
+					Zone:                    "test-zone-1b",
+					ZoneID:                  "testzone1b",
+					AvailableIPAddressCount: 100,
+					IPv6CIDRBlocks:          []string{"2001:db8::/64"},
 				},
 			}))
 		})
@@ -291,20 +362,29 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test2",
-					Zone: "test-zone-1b",
+					ID:                      "subnet-test2",
+					Zone:                    "test-zone-1b",
+					ZoneID:                  "testzone1b",
+					AvailableIPAddressCount: 100,
+					IPv6CIDRBlocks:          []string{"2001:db8::/64"},
 				},
 				{
-					ID:   "subnet-test3",
-					Zone: "test-zone-1c",
+					ID:                      "subnet-test3",
+					Zone:                    "test-zone-1c",
+					ZoneID:                  "testzone1c",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test4",
-					Zone: "test-zone-1a-local",
+					ID:                      "subnet-test4",
+					Zone:                    "test-zone-1a-local",
+					ZoneID:                  "testzone1alocal",
+					AvailableIPAddressCount: 100,
 				},
 			}))
 
@@ -318,8 +398,10 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 			}))
 		})
@@ -340,20 +422,29 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.Subnets).To(Equal([]v1beta1.Subnet{
 				{
-					ID:   "subnet-test1",
-					Zone: "test-zone-1a",
+					ID:                      "subnet-test1",
+					Zone:                    "test-zone-1a",
+					ZoneID:                  "testzone1a",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test2",
-					Zone: "test-zone-1b",
+					ID:                      "subnet-test2",
+					Zone:                    "test-zone-1b",
+					ZoneID:                  "testzone1b",
+					AvailableIPAddressCount: 100,
+					IPv6CIDRBlocks:          []string{"2001:db8::/64"},
 				},
 				{
-					ID:   "subnet-test3",
-					Zone: "test-zone-1c",
+					ID:                      "subnet-test3",
+					Zone:                    "test-zone-1c",
+					ZoneID:                  "testzone1c",
+					AvailableIPAddressCount: 100,
 				},
 				{
-					ID:   "subnet-test4",
-					Zone: "test-zone-1a-local",
+					ID:                      "subnet-test4",
+					Zone:                    "test-zone-1a-local",
+					ZoneID:                  "testzone1alocal",
+					AvailableIPAddressCount: 100,
 				},
 			}))
 
@@ -544,15 +635,17 @@ var _ = Describe("NodeClassController", func() {
 		})
 	})
 	Context("AMI Status", func() {
+		var now time.Time
 		BeforeEach(func() {
+			now = time.Now()
 			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
-				Images: []*ec2.Image{
+				Images: []ec2types.Image{
 					{
 						Name:         aws.String("test-ami-1"),
 						ImageId:      aws.String("ami-test1"),
-						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
-						Architecture: aws.String("x86_64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-1")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -560,9 +653,9 @@ var _ = Describe("NodeClassController", func() {
 					{
 						Name:         aws.String("test-ami-2"),
 						ImageId:      aws.String("ami-test2"),
-						CreationDate: aws.String(time.Now().Add(time.Minute).Format(time.RFC3339)),
-						Architecture: aws.String("x86_64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Add(time.Minute).Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-2")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -570,9 +663,9 @@ var _ = Describe("NodeClassController", func() {
 					{
 						Name:         aws.String("test-ami-3"),
 						ImageId:      aws.String("ami-test3"),
-						CreationDate: aws.String(time.Now().Add(2 * time.Minute).Format(time.RFC3339)),
-						Architecture: aws.String("x86_64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Add(2 * time.Minute).Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-3")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -590,13 +683,13 @@ var _ = Describe("NodeClassController", func() {
 			}
 
 			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
-				Images: []*ec2.Image{
+				Images: []ec2types.Image{
 					{
 						Name:         aws.String("test-ami-1"),
 						ImageId:      aws.String("ami-id-123"),
-						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
-						Architecture: aws.String("x86_64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-1")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -604,9 +697,9 @@ var _ = Describe("NodeClassController", func() {
 					{
 						Name:         aws.String("test-ami-2"),
 						ImageId:      aws.String("ami-id-456"),
-						CreationDate: aws.String(time.Now().Add(time.Minute).Format(time.RFC3339)),
-						Architecture: aws.String("x86_64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Add(time.Minute).Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-2")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -614,9 +707,9 @@ var _ = Describe("NodeClassController", func() {
 					{
 						Name:         aws.String("test-ami-3"),
 						ImageId:      aws.String("ami-id-789"),
-						CreationDate: aws.String(time.Now().Add(2 * time.Minute).Format(time.RFC3339)),
-						Architecture: aws.String("x86_64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Add(2 * time.Minute).Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-3")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -629,8 +722,10 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.AMIs).To(Equal([]v1beta1.AMI{
 				{
-					Name: "test-ami-3",
-					ID:   "ami-id-789",
+					Name:         "test-ami-3",
+					ID:           "ami-id-789",
+					CreationDate: now.Add(2 * time.Minute).Format(time.RFC3339),
+					Architecture: corev1beta1.ArchitectureArm64,
 					Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
 						{
 							NodeSelectorRequirement: v1.NodeSelectorRequirement{
@@ -654,8 +749,10 @@ var _ = Describe("NodeClassController", func() {
 					},
 				},
 				{
-					Name: "test-ami-2",
-					ID:   "ami-id-456",
+					Name:         "test-ami-2",
+					ID:           "ami-id-456",
+					CreationDate: now.Add(time.Minute).Format(time.RFC3339),
+					Architecture: corev1beta1.ArchitectureAmd64,
 					Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
 						{
 							NodeSelectorRequirement: v1.NodeSelectorRequirement{
@@ -673,8 +770,10 @@ var _ = Describe("NodeClassController", func() {
 					},
 				},
 				{
-					Name: "test-ami-2",
-					ID:   "ami-id-456",
+					Name:         "test-ami-2",
+					ID:           "ami-id-456",
+					CreationDate: now.Add(time.Minute).Format(time.RFC3339),
+					Architecture: corev1beta1.ArchitectureAmd64,
 					Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
 						{
 							NodeSelectorRequirement: v1.NodeSelectorRequirement{
@@ -692,8 +791,10 @@ var _ = Describe("NodeClassController", func() {
 					},
 				},
 				{
-					Name: "test-ami-1",
-					ID:   "ami-id-123",
+					Name:         "test-ami-1",
+					ID:           "ami-id-123",
+					CreationDate: now.Format(time.RFC3339),
+					Architecture: corev1beta1.ArchitectureAmd64,
 					Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
 						{
 							NodeSelectorRequirement: v1.NodeSelectorRequirement{
@@ -728,13 +829,13 @@ var _ = Describe("NodeClassController", func() {
 			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
 			nodeClass.Spec.AMISelectorTerms = nil
 			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
-				Images: []*ec2.Image{
+				Images: []ec2types.Image{
 					{
 						Name:         aws.String("test-ami-1"),
 						ImageId:      aws.String("ami-id-123"),
-						CreationDate: aws.String(time.Now().Format(time.RFC3339)),
-						Architecture: aws.String("x86_64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Format(time.RFC3339)),
+						Architecture: "x86_64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-1")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -742,9 +843,9 @@ var _ = Describe("NodeClassController", func() {
 					{
 						Name:         aws.String("test-ami-2"),
 						ImageId:      aws.String("ami-id-456"),
-						CreationDate: aws.String(time.Now().Add(time.Minute).Format(time.RFC3339)),
-						Architecture: aws.String("arm64"),
-						Tags: []*ec2.Tag{
+						CreationDate: aws.String(now.Add(time.Minute).Format(time.RFC3339)),
+						Architecture: "arm64",
+						Tags: []ec2types.Tag{
 							{Key: aws.String("Name"), Value: aws.String("test-ami-2")},
 							{Key: aws.String("foo"), Value: aws.String("bar")},
 						},
@@ -757,8 +858,10 @@ var _ = Describe("NodeClassController", func() {
 
 			Expect(nodeClass.Status.AMIs).To(Equal([]v1beta1.AMI{
 				{
-					Name: "test-ami-2",
-					ID:   "ami-id-456",
+					Name:         "test-ami-2",
+					ID:           "ami-id-456",
+					CreationDate: now.Add(time.Minute).Format(time.RFC3339),
+					Architecture: corev1beta1.ArchitectureArm64,
 					Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
 						{
 							NodeSelectorRequirement: v1.NodeSelectorRequirement{
@@ -782,8 +885,10 @@ var _ = Describe("NodeClassController", func() {
 					},
 				},
 				{
-					Name: "test-ami-1",
-					ID:   "ami-id-123",
+					Name:         "test-ami-1",
+					ID:           "ami-id-123",
+					CreationDate: now.Format(time.RFC3339),
+					Architecture: corev1beta1.ArchitectureAmd64,
 					Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
 						{
 							NodeSelectorRequirement: v1.NodeSelectorRequirement{
@@ -815,8 +920,10 @@ var _ = Describe("NodeClassController", func() {
 			Expect(nodeClass.Status.AMIs).To(Equal(
 				[]v1beta1.AMI{
 					{
-						Name: "test-ami-3",
-						ID:   "ami-test3",
+						Name:         "test-ami-3",
+						ID:           "ami-test3",
+						CreationDate: now.Add(2 * time.Minute).Format(time.RFC3339),
+						Architecture: corev1beta1.ArchitectureAmd64,
 						Requirements: []corev1beta1.NodeSelectorRequirementWithMinValues{
 							{
 								NodeSelectorRequirement: v1.NodeSelectorRequirement{
@@ -855,7 +962,6 @@ var _ = Describe("NodeClassController", func() {
 		},
 			Entry("AMIFamily Drift", &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{AMIFamily: aws.String(v1beta1.AMIFamilyBottlerocket)}}),
 			Entry("UserData Drift", &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{UserData: aws.String("userdata-test-2")}}),
-			Entry("Tags Drift", &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Tags: map[string]string{"keyTag-test-3": "valueTag-test-3"}}}),
 			Entry("BlockDeviceMappings Drift", &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{BlockDeviceMappings: []*v1beta1.BlockDeviceMapping{{DeviceName: aws.String("map-device-test-3")}}}}),
 			Entry("DetailedMonitoring Drift", &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{DetailedMonitoring: aws.Bool(true)}}),
 			Entry("MetadataOptions Drift", &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{MetadataOptions: &v1beta1.MetadataOptions{HTTPEndpoint: aws.String("disabled")}}}),
@@ -884,6 +990,7 @@ var _ = Describe("NodeClassController", func() {
 					Tags: map[string]string{"ami-test-key": "ami-test-value"},
 				},
 			}
+			nodeClass.Spec.Tags = map[string]string{"keyTag-test-3": "valueTag-test-3"}
 
 			ExpectApplied(ctx, env.Client, nodeClass)
 			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
@@ -1012,6 +1119,113 @@ var _ = Describe("NodeClassController", func() {
 			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, "123456"))
 			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHashVersion, v1beta1.EC2NodeClassHashVersion))
 		})
+		It("should update ec2nodeclass-hash on NodeClaims when the drift ignored fields change", func() {
+			nodeClass.Annotations = map[string]string{
+				v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
+				v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
+				v1beta1.AnnotationDriftIgnoredFields:      "userData,detailedMonitoring",
+			}
+			nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						v1beta1.AnnotationEC2NodeClassHash:        nodeClass.Hash(),
+						v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
+					},
+				},
+				Spec: corev1beta1.NodeClaimSpec{
+					NodeClassRef: &corev1beta1.NodeClassReference{
+						Name: nodeClass.Name,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			Expect(nodeClass.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+			Expect(nodeClass.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHashDriftIgnoredFields, "userData,detailedMonitoring"))
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+
+			// Changes to ignored fields don't change the hash
+			hash := nodeClass.Hash()
+			nodeClass.Spec.UserData = aws.String("userdata-test-2")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, hash))
+		})
+		It("should not update ec2nodeclass-hash on NodeClaims when the drift ignored fields haven't changed", func() {
+			nodeClass.Annotations = map[string]string{
+				v1beta1.AnnotationEC2NodeClassHash:                   "abceduefed",
+				v1beta1.AnnotationEC2NodeClassHashVersion:            v1beta1.EC2NodeClassHashVersion,
+				v1beta1.AnnotationDriftIgnoredFields:                 "userData",
+				v1beta1.AnnotationEC2NodeClassHashDriftIgnoredFields: "userData",
+			}
+			nodeClaim := coretest.NodeClaim(corev1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						v1beta1.AnnotationEC2NodeClassHash:        "1234564654",
+						v1beta1.AnnotationEC2NodeClassHashVersion: v1beta1.EC2NodeClassHashVersion,
+					},
+				},
+				Spec: corev1beta1.NodeClaimSpec{
+					NodeClassRef: &corev1beta1.NodeClassReference{
+						Name: nodeClass.Name,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodeClass, nodeClaim)
+
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, "1234564654"))
+		})
+	})
+	Context("Resolution Metrics", func() {
+		It("should count failed resolution attempts", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"foo": "invalid"}}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			metric, ok := FindMetricWithLabelValues("karpenter_ec2nodeclass_resolution_failures_total", map[string]string{
+				"ec2nodeclass": nodeClass.Name,
+				"resource":     "subnets",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically("==", 1))
+			_, ok = FindMetricWithLabelValues("karpenter_ec2nodeclass_resolution_failures_total", map[string]string{
+				"ec2nodeclass": nodeClass.Name,
+				"resource":     "amis",
+			})
+			Expect(ok).To(BeFalse())
+		})
+		It("should record the age of the resolved resources", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			for _, resource := range []string{"amis", "subnets", "securitygroups"} {
+				metric, ok := FindMetricWithLabelValues("karpenter_ec2nodeclass_resolution_age_seconds", map[string]string{
+					"ec2nodeclass": nodeClass.Name,
+					"resource":     resource,
+				})
+				Expect(ok).To(BeTrue())
+				Expect(metric.GetGauge().GetValue()).To(BeNumerically("<", 1))
+			}
+		})
+		It("should remove the metrics when the nodeclass is deleted", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			_, ok := FindMetricWithLabelValues("karpenter_ec2nodeclass_resolution_age_seconds", map[string]string{
+				"ec2nodeclass": nodeClass.Name,
+				"resource":     "subnets",
+			})
+			Expect(ok).To(BeFalse())
+		})
 	})
 	Context("NodeClass Termination", func() {
 		var profileName string
@@ -1020,7 +1234,7 @@ var _ = Describe("NodeClassController", func() {
 		})
 		It("should not delete the NodeClass if launch template deletion fails", func() {
 			launchTemplateName := aws.String(fake.LaunchTemplateName())
-			awsEnv.EC2API.LaunchTemplates.Store(launchTemplateName, &ec2.LaunchTemplate{LaunchTemplateName: launchTemplateName, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []*ec2.Tag{&ec2.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}}})
+			awsEnv.EC2API.LaunchTemplates.Store(launchTemplateName, &ec2types.LaunchTemplate{LaunchTemplateName: launchTemplateName, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []ec2types.Tag{ec2types.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}}})
 			_, ok := awsEnv.EC2API.LaunchTemplates.Load(launchTemplateName)
 			Expect(ok).To(BeTrue())
 			ExpectApplied(ctx, env.Client, nodeClass)
@@ -1033,7 +1247,7 @@ var _ = Describe("NodeClassController", func() {
 		})
 		It("should not delete the launch template not associated with the nodeClass", func() {
 			launchTemplateName := aws.String(fake.LaunchTemplateName())
-			awsEnv.EC2API.LaunchTemplates.Store(launchTemplateName, &ec2.LaunchTemplate{LaunchTemplateName: launchTemplateName, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []*ec2.Tag{&ec2.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}}})
+			awsEnv.EC2API.LaunchTemplates.Store(launchTemplateName, &ec2types.LaunchTemplate{LaunchTemplateName: launchTemplateName, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []ec2types.Tag{ec2types.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}}})
 			_, ok := awsEnv.EC2API.LaunchTemplates.Load(launchTemplateName)
 			Expect(ok).To(BeTrue())
 			ExpectApplied(ctx, env.Client, nodeClass)
@@ -1047,9 +1261,9 @@ var _ = Describe("NodeClassController", func() {
 		})
 		It("should succeed to delete the launch template", func() {
 			ltName1 := aws.String(fake.LaunchTemplateName())
-			awsEnv.EC2API.LaunchTemplates.Store(ltName1, &ec2.LaunchTemplate{LaunchTemplateName: ltName1, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []*ec2.Tag{&ec2.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}, {Key: aws.String("karpenter.k8s.aws/ec2nodeclass"), Value: aws.String(nodeClass.Name)}}})
+			awsEnv.EC2API.LaunchTemplates.Store(ltName1, &ec2types.LaunchTemplate{LaunchTemplateName: ltName1, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []ec2types.Tag{ec2types.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}, {Key: aws.String("karpenter.k8s.aws/ec2nodeclass"), Value: aws.String(nodeClass.Name)}}})
 			ltName2 := aws.String(fake.LaunchTemplateName())
-			awsEnv.EC2API.LaunchTemplates.Store(ltName2, &ec2.LaunchTemplate{LaunchTemplateName: ltName2, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []*ec2.Tag{&ec2.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}, {Key: aws.String("karpenter.k8s.aws/ec2nodeclass"), Value: aws.String(nodeClass.Name)}}})
+			awsEnv.EC2API.LaunchTemplates.Store(ltName2, &ec2types.LaunchTemplate{LaunchTemplateName: ltName2, LaunchTemplateId: aws.String(fake.LaunchTemplateID()), Tags: []ec2types.Tag{ec2types.Tag{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")}, {Key: aws.String("karpenter.k8s.aws/ec2nodeclass"), Value: aws.String(nodeClass.Name)}}})
 			_, ok := awsEnv.EC2API.LaunchTemplates.Load(ltName1)
 			Expect(ok).To(BeTrue())
 			_, ok = awsEnv.EC2API.LaunchTemplates.Load(ltName2)
@@ -1066,10 +1280,10 @@ var _ = Describe("NodeClassController", func() {
 			ExpectNotFound(ctx, env.Client, nodeClass)
 		})
 		It("should succeed to delete the instance profile with no NodeClaims", func() {
-			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 				profileName: {
 					InstanceProfileName: aws.String(profileName),
-					Roles: []*iam.Role{
+					Roles: []iamtypes.Role{
 						{
 							RoleId:   aws.String(fake.RoleID()),
 							RoleName: aws.String(nodeClass.Spec.Role),
@@ -1087,7 +1301,7 @@ var _ = Describe("NodeClassController", func() {
 			ExpectNotFound(ctx, env.Client, nodeClass)
 		})
 		It("should succeed to delete the instance profile when no roles exist with no NodeClaims", func() {
-			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 				profileName: {
 					InstanceProfileName: aws.String(profileName),
 				},
@@ -1123,10 +1337,10 @@ var _ = Describe("NodeClassController", func() {
 				ExpectApplied(ctx, env.Client, nc)
 				nodeClaims = append(nodeClaims, nc)
 			}
-			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 				profileName: {
 					InstanceProfileName: aws.String(profileName),
-					Roles: []*iam.Role{
+					Roles: []iamtypes.Role{
 						{
 							RoleId:   aws.String(fake.RoleID()),
 							RoleName: aws.String(nodeClass.Spec.Role),
@@ -1160,10 +1374,10 @@ var _ = Describe("NodeClassController", func() {
 			ExpectNotFound(ctx, env.Client, nodeClass)
 		})
 		It("should not call the IAM API when deleting a NodeClass with an instanceProfile specified", func() {
-			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 				profileName: {
 					InstanceProfileName: aws.String("test-instance-profile"),
-					Roles: []*iam.Role{
+					Roles: []iamtypes.Role{
 						{
 							RoleId:   aws.String(fake.RoleID()),
 							RoleName: aws.String("fake-role"),
@@ -1202,9 +1416,11 @@ var _ = Describe("NodeClassController", func() {
 
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
+			Expect(nodeClass.Status.InstanceProfileARN).To(Equal(fmt.Sprintf("arn:aws:iam::123456789012:instance-profile/%s", profileName)))
+			Expect(nodeClass.Status.InstanceProfileManaged).To(BeTrue())
 		})
 		It("should add the role to the instance profile when it exists without a role", func() {
-			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 				profileName: {
 					InstanceProfileId:   aws.String(fake.InstanceProfileID()),
 					InstanceProfileName: aws.String(profileName),
@@ -1223,11 +1439,11 @@ var _ = Describe("NodeClassController", func() {
 			Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
 		})
 		It("should update the role for the instance profile when the wrong role exists", func() {
-			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 				profileName: {
 					InstanceProfileId:   aws.String(fake.InstanceProfileID()),
 					InstanceProfileName: aws.String(profileName),
-					Roles: []*iam.Role{
+					Roles: []iamtypes.Role{
 						{
 							RoleName: aws.String("other-role"),
 						},
@@ -1247,11 +1463,11 @@ var _ = Describe("NodeClassController", func() {
 			Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
 		})
 		It("should not call CreateInstanceProfile or AddRoleToInstanceProfile when instance profile exists with correct role", func() {
-			awsEnv.IAMAPI.InstanceProfiles = map[string]*iam.InstanceProfile{
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
 				profileName: {
 					InstanceProfileId:   aws.String(fake.InstanceProfileID()),
 					InstanceProfileName: aws.String(profileName),
-					Roles: []*iam.Role{
+					Roles: []iamtypes.Role{
 						{
 							RoleName: aws.String("test-role"),
 						},
@@ -1271,6 +1487,13 @@ var _ = Describe("NodeClassController", func() {
 			Expect(awsEnv.IAMAPI.AddRoleToInstanceProfileBehavior.Calls()).To(BeZero())
 		})
 		It("should resolve the specified instance profile into the status when using instanceProfile field", func() {
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
+				"test-instance-profile": {
+					Arn:                 aws.String("arn:aws:iam::123456789012:instance-profile/test-instance-profile"),
+					InstanceProfileName: aws.String("test-instance-profile"),
+					Roles:               []iamtypes.Role{{RoleName: aws.String("test-role")}},
+				},
+			}
 			nodeClass.Spec.Role = ""
 			nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
 			ExpectApplied(ctx, env.Client, nodeClass)
@@ -1278,8 +1501,28 @@ var _ = Describe("NodeClassController", func() {
 
 			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
 			Expect(nodeClass.Status.InstanceProfile).To(Equal(lo.FromPtr(nodeClass.Spec.InstanceProfile)))
+			Expect(nodeClass.Status.InstanceProfileARN).To(Equal("arn:aws:iam::123456789012:instance-profile/test-instance-profile"))
+			Expect(nodeClass.Status.InstanceProfileManaged).To(BeFalse())
+		})
+		It("should leave the ARN of the specified instance profile empty in an isolated VPC", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{IsolatedVPC: lo.ToPtr(true)}))
+			nodeClass.Spec.Role = ""
+			nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.InstanceProfile).To(Equal("test-instance-profile"))
+			Expect(nodeClass.Status.InstanceProfileARN).To(BeEmpty())
+			Expect(awsEnv.IAMAPI.GetInstanceProfileBehavior.Calls()).To(BeZero())
 		})
-		It("should not call the the IAM API when specifying an instance profile", func() {
+		It("should not create or modify the instance profile when specifying an instance profile", func() {
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
+				"test-instance-profile": {
+					InstanceProfileName: aws.String("test-instance-profile"),
+					Roles:               []iamtypes.Role{{RoleName: aws.String("test-role")}},
+				},
+			}
 			nodeClass.Spec.Role = ""
 			nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
 			ExpectApplied(ctx, env.Client, nodeClass)
@@ -1288,5 +1531,412 @@ var _ = Describe("NodeClassController", func() {
 			Expect(awsEnv.IAMAPI.CreateInstanceProfileBehavior.Calls()).To(BeZero())
 			Expect(awsEnv.IAMAPI.AddRoleToInstanceProfileBehavior.Calls()).To(BeZero())
 		})
+		It("should be ready when the role exists", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeInstanceProfileReady).IsTrue()).To(BeTrue())
+		})
+		It("should not be ready when the role doesn't exist", func() {
+			nodeClass.Spec.Role = "missing-role"
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeInstanceProfileReady)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal(instanceprofile.ReasonRoleNotFound))
+			Expect(nodeClass.Status.InstanceProfile).To(BeEmpty())
+			Expect(awsEnv.IAMAPI.CreateInstanceProfileBehavior.Calls()).To(BeZero())
+		})
+		It("should not be ready when the specified instance profile doesn't exist", func() {
+			nodeClass.Spec.Role = ""
+			nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeInstanceProfileReady)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal(instanceprofile.ReasonInstanceProfileNotFound))
+			Expect(nodeClass.Status.InstanceProfile).To(BeEmpty())
+		})
+		It("should not be ready when the specified instance profile has no role", func() {
+			awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
+				"test-instance-profile": {
+					InstanceProfileName: aws.String("test-instance-profile"),
+				},
+			}
+			nodeClass.Spec.Role = ""
+			nodeClass.Spec.InstanceProfile = lo.ToPtr("test-instance-profile")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeInstanceProfileReady)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal(instanceprofile.ReasonInstanceProfileHasNoRole))
+			Expect(condition.Message).To(ContainSubstring("test-instance-profile"))
+		})
+		It("should become ready once the missing role is created", func() {
+			nodeClass.Spec.Role = "missing-role"
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			awsEnv.IAMAPI.Roles["missing-role"] = &iamtypes.Role{RoleName: aws.String("missing-role")}
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeInstanceProfileReady).IsTrue()).To(BeTrue())
+			Expect(nodeClass.Status.InstanceProfile).To(Equal(profileName))
+		})
+		Context("Instance Profile Selector Terms", func() {
+			BeforeEach(func() {
+				awsEnv.IAMAPI.InstanceProfiles = map[string]*iamtypes.InstanceProfile{
+					"test-instance-profile-1": {
+						CreateDate:          aws.Time(time.Now().Add(-time.Hour)),
+						InstanceProfileName: aws.String("test-instance-profile-1"),
+						Roles:               []iamtypes.Role{{RoleName: aws.String("test-role")}},
+						Tags:                []iamtypes.Tag{{Key: aws.String("team"), Value: aws.String("platform")}},
+					},
+					"test-instance-profile-2": {
+						Arn:                 aws.String("arn:aws:iam::123456789012:instance-profile/test-instance-profile-2"),
+						CreateDate:          aws.Time(time.Now()),
+						InstanceProfileName: aws.String("test-instance-profile-2"),
+						Roles:               []iamtypes.Role{{RoleName: aws.String("test-role")}},
+						Tags:                []iamtypes.Tag{{Key: aws.String("team"), Value: aws.String("platform")}},
+					},
+					"test-instance-profile-3": {
+						CreateDate:          aws.Time(time.Now().Add(time.Hour)),
+						InstanceProfileName: aws.String("test-instance-profile-3"),
+						Roles:               []iamtypes.Role{{RoleName: aws.String("test-role")}},
+						Tags:                []iamtypes.Tag{{Key: aws.String("team"), Value: aws.String("data")}},
+					},
+				}
+				nodeClass.Spec.Role = ""
+			})
+			It("should resolve the most recently created instance profile that is selected", func() {
+				nodeClass.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "platform"}}}
+				ExpectApplied(ctx, env.Client, nodeClass)
+				ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+				nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+				Expect(nodeClass.Status.InstanceProfile).To(Equal("test-instance-profile-2"))
+				Expect(nodeClass.Status.InstanceProfileARN).To(Equal("arn:aws:iam::123456789012:instance-profile/test-instance-profile-2"))
+				Expect(nodeClass.Status.InstanceProfileManaged).To(BeFalse())
+				Expect(awsEnv.IAMAPI.CreateInstanceProfileBehavior.Calls()).To(BeZero())
+			})
+			It("should select instance profiles with any value for a tag with a wildcard", func() {
+				nodeClass.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "*"}}}
+				ExpectApplied(ctx, env.Client, nodeClass)
+				ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+				nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+				Expect(nodeClass.Status.InstanceProfile).To(Equal("test-instance-profile-3"))
+			})
+			It("should not be ready when the selected instance profile has no role", func() {
+				awsEnv.IAMAPI.InstanceProfiles["test-instance-profile-2"].Roles = nil
+				nodeClass.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "platform"}}}
+				ExpectApplied(ctx, env.Client, nodeClass)
+				ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+				nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+				condition := nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeInstanceProfileReady)
+				Expect(condition.IsFalse()).To(BeTrue())
+				Expect(condition.Reason).To(Equal(instanceprofile.ReasonInstanceProfileHasNoRole))
+			})
+			It("should fail to resolve when no instance profile is selected", func() {
+				nodeClass.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "security"}}}
+				ExpectApplied(ctx, env.Client, nodeClass)
+				ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+				nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+				Expect(nodeClass.Status.InstanceProfile).To(BeEmpty())
+			})
+			It("should not delete the selected instance profile when the nodeclass is deleted", func() {
+				nodeClass.Spec.InstanceProfileSelectorTerms = []v1beta1.InstanceProfileSelectorTerm{{Tags: map[string]string{"team": "platform"}}}
+				ExpectApplied(ctx, env.Client, nodeClass)
+				ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+				Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+				ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+				ExpectNotFound(ctx, env.Client, nodeClass)
+				Expect(awsEnv.IAMAPI.DeleteInstanceProfileBehavior.Calls()).To(BeZero())
+				Expect(awsEnv.IAMAPI.InstanceProfiles).To(HaveLen(3))
+			})
+		})
+	})
+	Context("Readiness", func() {
+		It("should be ready when the subnets, security groups, amis and instance profile are resolved", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			for _, conditionType := range []knativeapis.ConditionType{
+				v1beta1.ConditionTypeSubnetsReady,
+				v1beta1.ConditionTypeSecurityGroupsReady,
+				v1beta1.ConditionTypeAMIsReady,
+				v1beta1.ConditionTypeInstanceProfileReady,
+			} {
+				Expect(nodeClass.StatusConditions().GetCondition(conditionType).IsTrue()).To(BeTrue(), string(conditionType))
+			}
+			Expect(nodeClass.StatusConditions().IsHappy()).To(BeTrue())
+		})
+		DescribeTable("should not be ready when a resource isn't resolved",
+			func(conditionType knativeapis.ConditionType, reason string, changes *v1beta1.EC2NodeClass) {
+				Expect(mergo.Merge(nodeClass, changes, mergo.WithOverride)).To(Succeed())
+				ExpectApplied(ctx, env.Client, nodeClass)
+				ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+				nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+				condition := nodeClass.StatusConditions().GetCondition(conditionType)
+				Expect(condition.IsFalse()).To(BeTrue())
+				Expect(condition.Reason).To(Equal(reason))
+				ready := nodeClass.StatusConditions().GetCondition(knativeapis.ConditionReady)
+				Expect(ready.IsFalse()).To(BeTrue())
+				Expect(ready.Reason).To(Equal(reason))
+			},
+			Entry("subnets", v1beta1.ConditionTypeSubnetsReady, nodeclass.ReasonSubnetsNotFound, &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{
+				SubnetSelectorTerms: []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"foo": "invalid"}}},
+			}}),
+			Entry("security groups", v1beta1.ConditionTypeSecurityGroupsReady, nodeclass.ReasonSecurityGroupsNotFound, &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{
+				SecurityGroupSelectorTerms: []v1beta1.SecurityGroupSelectorTerm{{Tags: map[string]string{"foo": "invalid"}}},
+			}}),
+			Entry("amis", v1beta1.ConditionTypeAMIsReady, nodeclass.ReasonAMIsNotFound, &v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{
+				AMISelectorTerms: []v1beta1.AMISelectorTerm{{Tags: map[string]string{"foo": "invalid"}}},
+			}}),
+		)
+		It("should become ready once the missing subnets exist", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"Name": "test-subnet-5"}}}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeSubnetsReady).IsFalse()).To(BeTrue())
+
+			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{
+				{
+					SubnetId:                aws.String("subnet-test5"),
+					AvailabilityZone:        aws.String("test-zone-1a"),
+					AvailableIpAddressCount: aws.Int32(100),
+					Tags:                    []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("test-subnet-5")}},
+				},
+			}})
+			awsEnv.SubnetCache.Flush()
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeSubnetsReady).IsTrue()).To(BeTrue())
+			Expect(nodeClass.StatusConditions().IsHappy()).To(BeTrue())
+		})
+	})
+	Context("IMDS Status", func() {
+		It("should mark IMDS reachable from pods with the default metadata options", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeIMDSReachableFromPods).IsTrue()).To(BeTrue())
+		})
+		It("should mark IMDS unreachable from pods when tokens are required with a hop limit of 1", func() {
+			nodeClass.Spec.MetadataOptions = &v1beta1.MetadataOptions{
+				HTTPEndpoint:            lo.ToPtr("enabled"),
+				HTTPPutResponseHopLimit: lo.ToPtr[int64](1),
+				HTTPTokens:              lo.ToPtr("required"),
+			}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeIMDSReachableFromPods)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal(v1beta1.IMDSReasonHopLimitBlocksPods))
+			// the condition is informational, so the nodeclass is still ready
+			Expect(nodeClass.StatusConditions().IsHappy()).To(BeTrue())
+		})
+		It("should mark IMDS unreachable from pods when the endpoint is disabled", func() {
+			nodeClass.Spec.MetadataOptions = &v1beta1.MetadataOptions{
+				HTTPEndpoint: lo.ToPtr("disabled"),
+			}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			condition := nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeIMDSReachableFromPods)
+			Expect(condition.IsFalse()).To(BeTrue())
+			Expect(condition.Reason).To(Equal(v1beta1.IMDSReasonDisabled))
+		})
+	})
+	Context("Node Role Management", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				ManageNodeRoles:    lo.ToPtr(true),
+				NodeRolePolicyARNs: lo.ToPtr("arn:aws:iam::123456789012:policy/test-policy"),
+			}))
+			nodeClass.Spec.Role = "test-role"
+			awsEnv.IAMAPI.Roles = map[string]*iamtypes.Role{}
+		})
+		It("should create the role with the node policies and an access entry when it doesn't exist", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			Expect(awsEnv.IAMAPI.Roles).To(HaveKey("test-role"))
+			Expect(awsEnv.IAMAPI.Roles["test-role"].Tags).To(ContainElement(&iamtypes.Tag{Key: aws.String(corev1beta1.ManagedByAnnotationKey), Value: aws.String("test-cluster")}))
+			Expect(sets.List(awsEnv.IAMAPI.RolePolicies["test-role"])).To(ConsistOf(
+				"arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy",
+				"arn:aws:iam::aws:policy/AmazonEKS_CNI_Policy",
+				"arn:aws:iam::aws:policy/AmazonEC2ContainerRegistryReadOnly",
+				"arn:aws:iam::aws:policy/AmazonSSMManagedInstanceCore",
+				"arn:aws:iam::123456789012:policy/test-policy",
+			))
+			Expect(awsEnv.EKSAPI.AccessEntries).To(HaveKey(aws.ToString(awsEnv.IAMAPI.Roles["test-role"].Arn)))
+			Expect(aws.ToString(awsEnv.EKSAPI.AccessEntries[aws.ToString(awsEnv.IAMAPI.Roles["test-role"].Arn)].Type)).To(Equal("EC2_LINUX"))
+
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.Status.InstanceProfile).To(Equal(instanceprofile.GetProfileName(ctx, fake.DefaultRegion, nodeClass)))
+		})
+		It("should create a Windows access entry for Windows AMI families", func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyWindows2022
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			Expect(aws.ToString(awsEnv.EKSAPI.AccessEntries[aws.ToString(awsEnv.IAMAPI.Roles["test-role"].Arn)].Type)).To(Equal("EC2_WINDOWS"))
+		})
+		It("should not modify roles that aren't managed by Karpenter", func() {
+			awsEnv.IAMAPI.Roles["test-role"] = &iamtypes.Role{
+				Arn:      aws.String("arn:aws:iam::123456789012:role/test-role"),
+				RoleName: aws.String("test-role"),
+			}
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			Expect(awsEnv.IAMAPI.CreateRoleBehavior.Calls()).To(BeZero())
+			Expect(awsEnv.IAMAPI.AttachRolePolicyBehavior.Calls()).To(BeZero())
+			Expect(awsEnv.EKSAPI.AccessEntries).To(BeEmpty())
+
+			Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			ExpectNotFound(ctx, env.Client, nodeClass)
+			Expect(awsEnv.IAMAPI.Roles).To(HaveKey("test-role"))
+		})
+		It("should not create the role when managing node roles is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileFailed(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			Expect(awsEnv.IAMAPI.Roles).To(BeEmpty())
+			Expect(awsEnv.EKSAPI.AccessEntries).To(BeEmpty())
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			Expect(nodeClass.StatusConditions().GetCondition(v1beta1.ConditionTypeInstanceProfileReady).IsFalse()).To(BeTrue())
+		})
+		It("should delete the role and its access entry when the nodeclass is deleted", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			Expect(awsEnv.IAMAPI.Roles).To(HaveKey("test-role"))
+
+			Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			ExpectNotFound(ctx, env.Client, nodeClass)
+			Expect(awsEnv.IAMAPI.Roles).To(BeEmpty())
+			Expect(awsEnv.EKSAPI.AccessEntries).To(BeEmpty())
+		})
+		It("should not delete the role while another nodeclass uses it", func() {
+			other := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: nodeClass.Spec})
+			ExpectApplied(ctx, env.Client, nodeClass, other)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			Expect(env.Client.Delete(ctx, nodeClass)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+			ExpectNotFound(ctx, env.Client, nodeClass)
+			Expect(awsEnv.IAMAPI.Roles).To(HaveKey("test-role"))
+			Expect(awsEnv.EKSAPI.AccessEntries).To(HaveLen(1))
+		})
+	})
+	Context("Superseded Launch Templates", func() {
+		It("should delete launch templates that were created for an older generation", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			nodeClass = ExpectExists(ctx, env.Client, nodeClass)
+			superseded := launchTemplate(nodeClass.Name, lo.ToPtr(nodeClass.Generation-1))
+			current := launchTemplate(nodeClass.Name, lo.ToPtr(nodeClass.Generation))
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			_, ok := awsEnv.EC2API.LaunchTemplates.Load(superseded.LaunchTemplateName)
+			Expect(ok).To(BeFalse())
+			_, ok = awsEnv.EC2API.LaunchTemplates.Load(current.LaunchTemplateName)
+			Expect(ok).To(BeTrue())
+		})
+		It("should not delete launch templates that weren't tagged with a generation", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			lt := launchTemplate(nodeClass.Name, nil)
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			_, ok := awsEnv.EC2API.LaunchTemplates.Load(lt.LaunchTemplateName)
+			Expect(ok).To(BeTrue())
+		})
+		It("should not delete launch templates of other nodeclasses", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			lt := launchTemplate("other-nodeclass", lo.ToPtr[int64](0))
+			ExpectReconcileSucceeded(ctx, nodeClassController, client.ObjectKeyFromObject(nodeClass))
+
+			_, ok := awsEnv.EC2API.LaunchTemplates.Load(lt.LaunchTemplateName)
+			Expect(ok).To(BeTrue())
+		})
 	})
 })
+
+var _ = Describe("GarbageCollectionController", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+	})
+	It("should delete launch templates of nodeclasses that no longer exist", func() {
+		lt := launchTemplate("deleted-nodeclass", lo.ToPtr[int64](1))
+
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		_, ok := awsEnv.EC2API.LaunchTemplates.Load(lt.LaunchTemplateName)
+		Expect(ok).To(BeFalse())
+	})
+	It("should not delete launch templates of nodeclasses that exist", func() {
+		ExpectApplied(ctx, env.Client, nodeClass)
+		lt := launchTemplate(nodeClass.Name, lo.ToPtr[int64](1))
+
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		_, ok := awsEnv.EC2API.LaunchTemplates.Load(lt.LaunchTemplateName)
+		Expect(ok).To(BeTrue())
+	})
+	It("should not delete launch templates that were created after the nodeclasses were listed", func() {
+		lt := launchTemplate("new-nodeclass", lo.ToPtr[int64](1))
+		lt.CreateTime = aws.Time(time.Now().Add(time.Minute))
+
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		_, ok := awsEnv.EC2API.LaunchTemplates.Load(lt.LaunchTemplateName)
+		Expect(ok).To(BeTrue())
+	})
+	It("should not delete launch templates that weren't created by Karpenter for the cluster", func() {
+		lt := launchTemplate("deleted-nodeclass", lo.ToPtr[int64](1))
+		lt.Tags = lo.Reject(lt.Tags, func(t ec2types.Tag, _ int) bool { return aws.ToString(t.Key) == "karpenter.k8s.aws/cluster" })
+
+		ExpectReconcileSucceeded(ctx, garbageCollectionController, client.ObjectKey{})
+		_, ok := awsEnv.EC2API.LaunchTemplates.Load(lt.LaunchTemplateName)
+		Expect(ok).To(BeTrue())
+	})
+})
+
+// launchTemplate stores a launch template for the nodeclass that was created an hour ago, tagged with the generation
+// if it's set
+func launchTemplate(nodeClassName string, generation *int64) *ec2types.LaunchTemplate {
+	name := aws.String(fake.LaunchTemplateName())
+	lt := &ec2types.LaunchTemplate{
+		LaunchTemplateName: name,
+		LaunchTemplateId:   aws.String(fake.LaunchTemplateID()),
+		CreateTime:         aws.Time(time.Now().Add(-time.Hour)),
+		Tags: []ec2types.Tag{
+			{Key: aws.String("karpenter.k8s.aws/cluster"), Value: aws.String("test-cluster")},
+			{Key: aws.String(v1beta1.LabelNodeClass), Value: aws.String(nodeClassName)},
+		},
+	}
+	if generation != nil {
+		lt.Tags = append(lt.Tags, ec2types.Tag{Key: aws.String("karpenter.k8s.aws/ec2nodeclass-generation"), Value: aws.String(fmt.Sprint(*generation))})
+	}
+	awsEnv.EC2API.LaunchTemplates.Store(name, lt)
+	return lt
+}
@@ -0,0 +1,328 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package permission
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+const (
+	// checkInterval is how often permissions are simulated again, so that changes to the controller's policies are
+	// picked up without restarting the controller
+	checkInterval = time.Hour
+	// RecheckParameter is the query parameter of the readiness check that simulates the permissions again before the
+	// result is returned, e.g. /readyz/permissions?recheck
+	RecheckParameter = "recheck"
+	// placeholderTagValue is the value of the tags that are set on resources at launch, whose value depends on the
+	// NodePool or EC2NodeClass. The recommended policies only require these tags to be present.
+	placeholderTagValue = "permission-check"
+)
+
+// Checker simulates the IAM actions that the controller calls against the controller's own identity with
+// iam:SimulatePrincipalPolicy, so that missing permissions are found at startup instead of when a launch fails. Actions
+// are simulated with the tags and condition keys that the controller sets on its requests, so that policies which are
+// scoped to the cluster's resources are evaluated as they are for the real calls.
+type Checker struct {
+	kubeClient client.Client
+	clk        clock.Clock
+	region     string
+	stsapi     sdk.STSAPI
+	iamapi     sdk.IAMAPI
+
+	clusterName            string
+	interruptionQueue      string
+	manageNodeRoles        bool
+	quotaAwareProvisioning bool
+	quotaWarnings          bool
+	metricsLogGroup        string
+	eventBridgeBus         string
+
+	mu      sync.RWMutex
+	missing []string
+}
+
+// NewChecker reads the options that determine which permissions are needed from the context, since the checks that are
+// run on demand by the readiness probe don't have access to it
+func NewChecker(ctx context.Context, kubeClient client.Client, clk clock.Clock, region string, stsapi sdk.STSAPI, iamapi sdk.IAMAPI) *Checker {
+	return &Checker{
+		kubeClient:  kubeClient,
+		clk:         clk,
+		region:      region,
+		stsapi:      stsapi,
+		iamapi:      iamapi,
+		clusterName: options.FromContext(ctx).ClusterName,
+		// The interruption queue is called with a different identity when a role is assumed for it
+		interruptionQueue:      lo.Ternary(options.FromContext(ctx).InterruptionQueueRoleARN == "", options.FromContext(ctx).InterruptionQueue, ""),
+		manageNodeRoles:        options.FromContext(ctx).ManageNodeRoles,
+		quotaAwareProvisioning: options.FromContext(ctx).QuotaAwareProvisioning,
+		quotaWarnings:          options.FromContext(ctx).QuotaWarningThreshold > 0,
+		metricsLogGroup:        options.FromContext(ctx).CloudWatchMetricsLogGroup,
+		eventBridgeBus:         options.FromContext(ctx).EventBridgeBus,
+	}
+}
+
+// Start checks permissions immediately and then every checkInterval until the context is cancelled. Failures to run
+// the simulation are logged and don't change the result of the previous check.
+func (c *Checker) Start(ctx context.Context) error {
+	for {
+		if _, err := c.Check(ctx); err != nil {
+			logging.FromContext(ctx).Errorf("checking permissions, %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.clk.After(checkInterval):
+		}
+	}
+}
+
+// NeedLeaderElection is false so that every replica reports its own readiness
+func (c *Checker) NeedLeaderElection() bool {
+	return false
+}
+
+// ReadinessProbe fails while the last check found missing permissions. Permissions are simulated again before the
+// result is returned when the request sets RecheckParameter.
+func (c *Checker) ReadinessProbe(req *http.Request) error {
+	if req.URL.Query().Has(RecheckParameter) {
+		if _, err := c.Check(req.Context()); err != nil {
+			return fmt.Errorf("checking permissions, %w", err)
+		}
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.missing) > 0 {
+		return fmt.Errorf("missing permissions, %s", strings.Join(c.missing, ", "))
+	}
+	return nil
+}
+
+// Check simulates the permissions that the controller needs and returns the ones that aren't allowed, formatted as
+// "<action>" for actions on any resource and "<action> on <resource>" for actions on a specific resource
+func (c *Checker) Check(ctx context.Context) ([]string, error) {
+	identity, err := c.stsapi.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, fmt.Errorf("getting caller identity, %w", err)
+	}
+	principal, err := c.principalARN(ctx, aws.ToString(identity.Arn))
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := c.permissions(ctx, aws.ToString(identity.Account))
+	if err != nil {
+		return nil, err
+	}
+	contextEntries := c.contextEntries()
+	var missing []string
+	// Every action in a simulation is evaluated against every resource, so actions are simulated per resource
+	byResource := lo.GroupBy(permissions, func(p permission) string { return p.resource })
+	resources := lo.Keys(byResource)
+	sort.Strings(resources)
+	for _, resource := range resources {
+		paginator := iam.NewSimulatePrincipalPolicyPaginator(c.iamapi, &iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principal),
+			ActionNames:     lo.Map(byResource[resource], func(p permission, _ int) string { return p.action }),
+			ResourceArns:    []string{resource},
+			ContextEntries:  contextEntries,
+		})
+		for paginator.HasMorePages() {
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("simulating principal policy, %w", err)
+			}
+			for _, result := range out.EvaluationResults {
+				if result.EvalDecision == iamtypes.PolicyEvaluationDecisionTypeAllowed {
+					continue
+				}
+				missing = append(missing, lo.Ternary(resource == "*", aws.ToString(result.EvalActionName),
+					fmt.Sprintf("%s on %s", aws.ToString(result.EvalActionName), resource)))
+			}
+		}
+	}
+	sort.Strings(missing)
+	c.mu.Lock()
+	c.missing = missing
+	c.mu.Unlock()
+	if len(missing) > 0 {
+		logging.FromContext(ctx).With("principal", principal, "missing", missing).Errorf("controller is missing permissions, launches or other operations will fail")
+	} else {
+		logging.FromContext(ctx).With("principal", principal, "count", len(permissions)).Infof("verified controller permissions")
+	}
+	return missing, nil
+}
+
+// principalARN returns the ARN of the IAM user or role of the caller. Sessions of an assumed role are resolved to the
+// role, including its path, since only users and roles can be simulated.
+func (c *Checker) principalARN(ctx context.Context, callerARN string) (string, error) {
+	parsed, err := arn.Parse(callerARN)
+	if err != nil {
+		return "", fmt.Errorf("parsing caller arn %q, %w", callerARN, err)
+	}
+	switch {
+	case parsed.Service == "iam" && strings.HasPrefix(parsed.Resource, "user/"):
+		return callerARN, nil
+	case parsed.Service == "sts" && strings.HasPrefix(parsed.Resource, "assumed-role/"):
+		roleName := strings.Split(parsed.Resource, "/")[1]
+		out, err := c.iamapi.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+		if err != nil {
+			return "", fmt.Errorf("getting role %q, %w", roleName, err)
+		}
+		return aws.ToString(out.Role.Arn), nil
+	default:
+		return "", fmt.Errorf("simulating permissions of %q isn't supported, only users and roles can be simulated", callerARN)
+	}
+}
+
+// permission is an action that the controller calls and the resource that it's simulated against
+type permission struct {
+	action   string
+	resource string
+}
+
+// permissions returns the actions that the controller calls with the enabled options
+func (c *Checker) permissions(ctx context.Context, account string) ([]permission, error) {
+	partition := utils.Partition(c.region).ID()
+	ec2ARN := func(resource string) string {
+		return fmt.Sprintf("arn:%s:ec2:%s:%s:%s", partition, c.region, account, resource)
+	}
+	clusterARN := fmt.Sprintf("arn:%s:eks:%s:%s:cluster/%s", partition, c.region, account, c.clusterName)
+	permissions := []permission{
+		{"ec2:CreateFleet", ec2ARN("fleet/*")},
+		{"ec2:RunInstances", ec2ARN("instance/*")},
+		{"ec2:CreateTags", ec2ARN("instance/*")},
+		{"ec2:TerminateInstances", ec2ARN("instance/*")},
+		{"ec2:CreateLaunchTemplate", ec2ARN("launch-template/*")},
+		{"ec2:DeleteLaunchTemplate", ec2ARN("launch-template/*")},
+		{"ec2:DescribeAvailabilityZones", "*"},
+		{"ec2:DescribeImages", "*"},
+		{"ec2:DescribeInstances", "*"},
+		{"ec2:DescribeInstanceTypeOfferings", "*"},
+		{"ec2:DescribeInstanceTypes", "*"},
+		{"ec2:DescribeLaunchTemplates", "*"},
+		{"ec2:DescribeSecurityGroups", "*"},
+		{"ec2:DescribeSpotPriceHistory", "*"},
+		{"ec2:DescribeSubnets", "*"},
+		{"ssm:GetParameter", fmt.Sprintf("arn:%s:ssm:%s::parameter/aws/service/*", partition, c.region)},
+		{"pricing:GetProducts", "*"},
+		{"iam:GetInstanceProfile", "*"},
+		{"iam:ListInstanceProfiles", "*"},
+		{"iam:ListInstanceProfileTags", "*"},
+		{"iam:CreateInstanceProfile", "*"},
+		{"iam:TagInstanceProfile", "*"},
+		{"iam:AddRoleToInstanceProfile", "*"},
+		{"iam:RemoveRoleFromInstanceProfile", "*"},
+		{"iam:DeleteInstanceProfile", "*"},
+		{"eks:DescribeCluster", clusterARN},
+	}
+	if c.interruptionQueue != "" {
+		queueARN := fmt.Sprintf("arn:%s:sqs:%s:%s:%s", partition, c.region, account, path.Base(c.interruptionQueue))
+		permissions = append(permissions,
+			permission{"sqs:GetQueueUrl", queueARN},
+			permission{"sqs:ReceiveMessage", queueARN},
+			permission{"sqs:DeleteMessage", queueARN},
+		)
+	}
+	if c.manageNodeRoles {
+		for _, action := range []string{"iam:GetRole", "iam:CreateRole", "iam:TagRole", "iam:DeleteRole", "iam:AttachRolePolicy", "iam:DetachRolePolicy", "iam:ListAttachedRolePolicies"} {
+			permissions = append(permissions, permission{action, "*"})
+		}
+		permissions = append(permissions,
+			permission{"eks:CreateAccessEntry", clusterARN},
+			permission{"eks:DeleteAccessEntry", clusterARN},
+		)
+	}
+	if c.quotaAwareProvisioning || c.quotaWarnings {
+		permissions = append(permissions, permission{"servicequotas:GetServiceQuota", "*"})
+	}
+	if c.quotaWarnings {
+		permissions = append(permissions, permission{"ec2:DescribeNetworkInterfaces", "*"})
+	}
+	if c.metricsLogGroup != "" {
+		logGroupARN := fmt.Sprintf("arn:%s:logs:%s:%s:log-group:%s", partition, c.region, account, c.metricsLogGroup)
+		permissions = append(permissions,
+			permission{"logs:CreateLogStream", logGroupARN},
+			permission{"logs:PutLogEvents", logGroupARN + ":log-stream:*"},
+		)
+	}
+	if c.eventBridgeBus != "" {
+		busARN := c.eventBridgeBus
+		if !arn.IsARN(busARN) {
+			busARN = fmt.Sprintf("arn:%s:events:%s:%s:event-bus/%s", partition, c.region, account, c.eventBridgeBus)
+		}
+		permissions = append(permissions, permission{"events:PutEvents", busARN})
+	}
+	// Roles are passed to EC2 through the instance profiles of EC2NodeClasses that are managed in Karpenter's account
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return nil, fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	for _, nodeClass := range nodeClassList.Items {
+		if nodeClass.Spec.Role == "" || lo.FromPtr(nodeClass.Spec.AssumeRoleARN) != "" {
+			continue
+		}
+		permissions = append(permissions, permission{"iam:PassRole", fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, account, nodeClass.Spec.Role)})
+	}
+	return lo.Uniq(permissions), nil
+}
+
+// contextEntries returns the condition keys that are set on the requests of the controller
+func (c *Checker) contextEntries() []iamtypes.ContextEntry {
+	clusterTag := fmt.Sprintf("kubernetes.io/cluster/%s", c.clusterName)
+	entries := map[string]string{
+		"aws:RequestedRegion": c.region,
+		"ec2:CreateAction":    "RunInstances",
+		"iam:PassedToService": fmt.Sprintf("ec2.%s", utils.Partition(c.region).DNSSuffix()),
+	}
+	for _, prefix := range []string{"aws:RequestTag/", "aws:ResourceTag/"} {
+		entries[prefix+clusterTag] = "owned"
+		entries[prefix+v1.LabelTopologyRegion] = c.region
+		entries[prefix+corev1beta1.NodePoolLabelKey] = placeholderTagValue
+		entries[prefix+v1beta1.LabelNodeClass] = placeholderTagValue
+	}
+	keys := lo.Keys(entries)
+	sort.Strings(keys)
+	return lo.Map(keys, func(key string, _ int) iamtypes.ContextEntry {
+		return iamtypes.ContextEntry{
+			ContextKeyName:   aws.String(key),
+			ContextKeyType:   iamtypes.ContextKeyTypeEnumString,
+			ContextKeyValues: []string{entries[key]},
+		}
+	})
+}
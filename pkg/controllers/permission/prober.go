@@ -0,0 +1,149 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package permission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"go.uber.org/multierr"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// probeInterval is how often the AWS API calls of the health check are made again
+const probeInterval = 5 * time.Minute
+
+// Prober verifies that the controller's AWS credentials are valid and that the read permissions which launches depend
+// on work, by making the API calls instead of simulating them. Expired credentials, such as an IRSA trust policy that
+// no longer matches the service account, or revoked policies otherwise only show up as launches that stall.
+type Prober struct {
+	kubeClient client.Client
+	recorder   events.Recorder
+	clk        clock.Clock
+	region     string
+	stsapi     sdk.STSAPI
+	ec2api     sdk.EC2API
+	ssmapi     sdk.SSMAPI
+
+	mu  sync.RWMutex
+	err error
+}
+
+func NewProber(kubeClient client.Client, recorder events.Recorder, clk clock.Clock, region string, stsapi sdk.STSAPI, ec2api sdk.EC2API, ssmapi sdk.SSMAPI) *Prober {
+	return &Prober{
+		kubeClient: kubeClient,
+		recorder:   recorder,
+		clk:        clk,
+		region:     region,
+		stsapi:     stsapi,
+		ec2api:     ec2api,
+		ssmapi:     ssmapi,
+	}
+}
+
+// Start probes immediately and then every probeInterval until the context is cancelled. Failures are logged and
+// returned by the readiness probe until a later probe succeeds.
+func (p *Prober) Start(ctx context.Context) error {
+	for {
+		if err := p.Probe(ctx); err != nil {
+			logging.FromContext(ctx).Errorf("aws health check failed, %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-p.clk.After(probeInterval):
+		}
+	}
+}
+
+// NeedLeaderElection is false so that every replica reports its own readiness
+func (p *Prober) NeedLeaderElection() bool {
+	return false
+}
+
+// ReadinessProbe fails while the last probe failed
+func (p *Prober) ReadinessProbe(_ *http.Request) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.err
+}
+
+// Probe makes the AWS API calls of the health check and returns the error of the calls that failed. A warning event is
+// published on every EC2NodeClass when the calls fail, since launches with them can't succeed.
+func (p *Prober) Probe(ctx context.Context) error {
+	err := p.probe(ctx)
+	p.mu.Lock()
+	recovered := p.err != nil && err == nil
+	p.err = err
+	p.mu.Unlock()
+	if err == nil {
+		if recovered {
+			logging.FromContext(ctx).Infof("verified aws credentials and permissions")
+		}
+		return nil
+	}
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if listErr := p.kubeClient.List(ctx, nodeClassList); listErr != nil {
+		logging.FromContext(ctx).Errorf("listing ec2nodeclasses, %s", listErr)
+		return err
+	}
+	for i := range nodeClassList.Items {
+		p.recorder.Publish(AWSHealthCheckFailedEvent(&nodeClassList.Items[i], err))
+	}
+	return err
+}
+
+func (p *Prober) probe(ctx context.Context) error {
+	// The credentials are checked first, since every other call fails in the same way when they aren't valid
+	if _, err := p.stsapi.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{}); err != nil {
+		return fmt.Errorf("verifying aws credentials, %w", err)
+	}
+	var errs error
+	if _, err := p.ec2api.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{MaxResults: aws.Int32(5)}); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("calling ec2:DescribeInstanceTypes, %w", err))
+	}
+	if _, err := p.ec2api.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{MaxResults: aws.Int32(5)}); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("calling ec2:DescribeSubnets, %w", err))
+	}
+	// A public parameter is read since the controller's policy only allows reading the parameters under /aws/service/.
+	// The parameter not existing in the partition means that the call was allowed.
+	if _, err := p.ssmapi.GetParameter(ctx, &ssm.GetParameterInput{
+		Name: aws.String(fmt.Sprintf("/aws/service/global-infrastructure/regions/%s", p.region)),
+	}); err != nil && !isParameterNotFound(err) {
+		errs = multierr.Append(errs, fmt.Errorf("calling ssm:GetParameter, %w", err))
+	}
+	return errs
+}
+
+func isParameterNotFound(err error) bool {
+	var notFound *ssmtypes.ParameterNotFound
+	return errors.As(err, &notFound)
+}
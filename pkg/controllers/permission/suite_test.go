@@ -0,0 +1,226 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package permission_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/samber/lo"
+	clock "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/permission"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var stsapi *fake.STSAPI
+var iamapi *fake.IAMAPI
+var ec2api *fake.EC2API
+var ssmapi *fake.SSMAPI
+var recorder *coretest.EventRecorder
+var checker *permission.Checker
+var prober *permission.Prober
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Permission")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	stsapi = fake.NewSTSAPI()
+	iamapi = fake.NewIAMAPI()
+	ec2api = fake.NewEC2API()
+	ssmapi = fake.NewSSMAPI()
+	recorder = coretest.NewEventRecorder()
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+	checker = permission.NewChecker(ctx, env.Client, &clock.FakeClock{}, fake.DefaultRegion, stsapi, iamapi)
+	prober = permission.NewProber(env.Client, recorder, &clock.FakeClock{}, fake.DefaultRegion, stsapi, ec2api, ssmapi)
+	stsapi.Reset()
+	iamapi.Reset()
+	ec2api.Reset()
+	ssmapi.Reset()
+	recorder.Reset()
+	iamapi.Roles["KarpenterControllerRole"] = &iamtypes.Role{
+		Arn:      aws.String("arn:aws:iam::123456789012:role/karpenter/KarpenterControllerRole"),
+		RoleName: aws.String("KarpenterControllerRole"),
+	}
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Permission", func() {
+	It("should find no missing permissions when every action is allowed", func() {
+		missing, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(BeEmpty())
+		Expect(checker.ReadinessProbe(httptest.NewRequest("GET", "/readyz/permissions", nil))).To(Succeed())
+	})
+	It("should simulate the role of the caller's session, including its path", func() {
+		_, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.Len()).To(BeNumerically(">", 0))
+		iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.ForEach(func(input *iam.SimulatePrincipalPolicyInput) {
+			Expect(aws.ToString(input.PolicySourceArn)).To(Equal("arn:aws:iam::123456789012:role/karpenter/KarpenterControllerRole"))
+		})
+	})
+	It("should simulate users directly", func() {
+		stsapi.GetCallerIdentityBehavior.Output.Set(&sts.GetCallerIdentityOutput{
+			Account: aws.String("123456789012"),
+			Arn:     aws.String("arn:aws:iam::123456789012:user/karpenter"),
+		})
+		_, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.ForEach(func(input *iam.SimulatePrincipalPolicyInput) {
+			Expect(aws.ToString(input.PolicySourceArn)).To(Equal("arn:aws:iam::123456789012:user/karpenter"))
+		})
+	})
+	It("should simulate actions with the tags that the controller sets on its requests", func() {
+		_, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		input := iamapi.SimulatePrincipalPolicyBehavior.CalledWithInput.Pop()
+		keys := lo.Map(input.ContextEntries, func(e iamtypes.ContextEntry, _ int) string { return aws.ToString(e.ContextKeyName) })
+		Expect(keys).To(ContainElements(
+			"aws:RequestTag/kubernetes.io/cluster/"+options.FromContext(ctx).ClusterName,
+			"aws:ResourceTag/kubernetes.io/cluster/"+options.FromContext(ctx).ClusterName,
+			"aws:RequestTag/karpenter.sh/nodepool",
+			"aws:RequestTag/"+v1beta1.LabelNodeClass,
+			"aws:RequestedRegion",
+		))
+	})
+	It("should report missing permissions and fail the readiness check", func() {
+		iamapi.DeniedActions.Insert("ec2:CreateFleet", "ssm:GetParameter")
+		missing, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(ConsistOf(
+			"ec2:CreateFleet on arn:aws:ec2:us-west-2:123456789012:fleet/*",
+			"ssm:GetParameter on arn:aws:ssm:us-west-2::parameter/aws/service/*",
+		))
+		Expect(checker.ReadinessProbe(httptest.NewRequest("GET", "/readyz/permissions", nil))).ToNot(Succeed())
+
+		// The readiness check only simulates permissions again when asked to
+		iamapi.DeniedActions.Delete("ec2:CreateFleet", "ssm:GetParameter")
+		Expect(checker.ReadinessProbe(httptest.NewRequest("GET", "/readyz/permissions", nil))).ToNot(Succeed())
+		Expect(checker.ReadinessProbe(httptest.NewRequest("GET", "/readyz/permissions?recheck", nil))).To(Succeed())
+	})
+	It("should check that the role of each ec2nodeclass can be passed", func() {
+		nodeClass := test.EC2NodeClass(v1beta1.EC2NodeClass{Spec: v1beta1.EC2NodeClassSpec{Role: "KarpenterNodeRole"}})
+		ExpectApplied(ctx, env.Client, nodeClass)
+		iamapi.DeniedActions.Insert("iam:PassRole")
+
+		missing, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(ConsistOf("iam:PassRole on arn:aws:iam::123456789012:role/KarpenterNodeRole"))
+	})
+	It("should check interruption queue permissions when the interruption queue is enabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{InterruptionQueue: lo.ToPtr("test-cluster")}))
+		checker = permission.NewChecker(ctx, env.Client, &clock.FakeClock{}, fake.DefaultRegion, stsapi, iamapi)
+		iamapi.DeniedActions.Insert("sqs:ReceiveMessage")
+
+		missing, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(ConsistOf("sqs:ReceiveMessage on arn:aws:sqs:us-west-2:123456789012:test-cluster"))
+	})
+	It("should not check interruption queue permissions when the interruption queue is disabled", func() {
+		iamapi.DeniedActions.Insert("sqs:ReceiveMessage")
+
+		missing, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(BeEmpty())
+	})
+	It("should check that events can be put on the event bus when events are published to eventbridge", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EventBridgeBus: lo.ToPtr("karpenter-events")}))
+		checker = permission.NewChecker(ctx, env.Client, &clock.FakeClock{}, fake.DefaultRegion, stsapi, iamapi)
+		iamapi.DeniedActions.Insert("events:PutEvents")
+
+		missing, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missing).To(ConsistOf("events:PutEvents on arn:aws:events:us-west-2:123456789012:event-bus/karpenter-events"))
+	})
+	It("should keep the previous result when the simulation fails", func() {
+		iamapi.DeniedActions.Insert("ec2:CreateFleet")
+		_, err := checker.Check(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		iamapi.SimulatePrincipalPolicyBehavior.Error.Set(errors.New("access denied"))
+		_, err = checker.Check(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(checker.ReadinessProbe(httptest.NewRequest("GET", "/readyz/permissions", nil))).ToNot(Succeed())
+	})
+})
+
+var _ = Describe("Prober", func() {
+	It("should be ready when the credentials and permissions work", func() {
+		Expect(prober.Probe(ctx)).To(Succeed())
+		Expect(prober.ReadinessProbe(httptest.NewRequest("GET", "/readyz/aws", nil))).To(Succeed())
+	})
+	It("should fail the readiness check and publish events when the credentials aren't valid", func() {
+		nodeClass := test.EC2NodeClass()
+		ExpectApplied(ctx, env.Client, nodeClass)
+		stsapi.GetCallerIdentityBehavior.Error.Set(errors.New("ExpiredToken"))
+
+		Expect(prober.Probe(ctx)).ToNot(Succeed())
+		Expect(prober.ReadinessProbe(httptest.NewRequest("GET", "/readyz/aws", nil))).ToNot(Succeed())
+		Expect(recorder.Calls("AWSHealthCheckFailed")).To(Equal(1))
+
+		// The error is only returned once, so the next probe recovers
+		Expect(prober.Probe(ctx)).To(Succeed())
+		Expect(prober.ReadinessProbe(httptest.NewRequest("GET", "/readyz/aws", nil))).To(Succeed())
+	})
+	It("should fail the readiness check when a read permission is revoked", func() {
+		ec2api.NextError.Set(errors.New("UnauthorizedOperation"))
+		Expect(prober.Probe(ctx)).To(MatchError(ContainSubstring("ec2:DescribeInstanceTypes")))
+		Expect(prober.ReadinessProbe(httptest.NewRequest("GET", "/readyz/aws", nil))).ToNot(Succeed())
+	})
+	It("should fail the readiness check when parameters can't be read", func() {
+		ssmapi.WantErr = errors.New("AccessDeniedException")
+		Expect(prober.Probe(ctx)).To(MatchError(ContainSubstring("ssm:GetParameter")))
+	})
+	It("should succeed when the parameter doesn't exist", func() {
+		ssmapi.WantErr = &ssmtypes.ParameterNotFound{Message: aws.String("not found")}
+		Expect(prober.Probe(ctx)).To(Succeed())
+	})
+})
@@ -21,39 +21,81 @@ import (
 
 	lop "github.com/samber/lo/parallel"
 	"go.uber.org/multierr"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/throttling"
 )
 
+// commitmentRefreshInterval is the longest interval at which commitment pricing is refreshed
+const commitmentRefreshInterval = 10 * time.Minute
+
 type Controller struct {
+	clock           clock.Clock
 	pricingProvider *pricing.Provider
+
+	// spot, on-demand and commitment pricing are refreshed on separate cadences, so we track when each is next due
+	nextSpotUpdate       time.Time
+	nextOnDemandUpdate   time.Time
+	nextCommitmentUpdate time.Time
 }
 
-func NewController(pricingProvider *pricing.Provider) *Controller {
+func NewController(clk clock.Clock, pricingProvider *pricing.Provider) *Controller {
 	return &Controller{
+		clock:           clk,
 		pricingProvider: pricingProvider,
 	}
 }
 
+type refresh struct {
+	next     *time.Time
+	interval time.Duration
+	update   func(ctx context.Context) error
+}
+
 func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
-	work := []func(ctx context.Context) error{
-		c.pricingProvider.UpdateSpotPricing,
-		c.pricingProvider.UpdateOnDemandPricing,
+	// Prices that are out of date are still used for launches, so refreshes are shed while AWS APIs are throttling
+	ctx = throttling.NonCritical(ctx)
+	now := c.clock.Now()
+	refreshes := []refresh{
+		{
+			next:     &c.nextSpotUpdate,
+			interval: options.FromContext(ctx).SpotPricingRefreshInterval,
+			update:   c.pricingProvider.UpdateSpotPricing,
+		},
+		{
+			next:     &c.nextOnDemandUpdate,
+			interval: options.FromContext(ctx).PricingRefreshInterval,
+			update:   c.pricingProvider.UpdateOnDemandPricing,
+		},
+		// commitment pricing is refreshed on its own, so that failing to discover commitments doesn't refresh on-demand
+		// pricing again while it's retried, and more often, since the coverage that's left changes as instances launch
+		{
+			next:     &c.nextCommitmentUpdate,
+			interval: min(options.FromContext(ctx).PricingRefreshInterval, commitmentRefreshInterval),
+			update:   c.pricingProvider.UpdateCommitmentPricing,
+		},
 	}
-	errs := make([]error, len(work))
-	lop.ForEach(work, func(f func(ctx context.Context) error, i int) {
-		if err := f(ctx); err != nil {
-			errs[i] = err
+	errs := make([]error, len(refreshes))
+	lop.ForEach(refreshes, func(r refresh, i int) {
+		if now.Before(*r.next) {
+			return
+		}
+		errs[i] = r.update(ctx)
+		// a failed refresh is retried with backoff, without waiting for the next interval
+		if errs[i] == nil {
+			*r.next = now.Add(r.interval)
 		}
 	})
 	if err := multierr.Combine(errs...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("updating pricing, %w", err)
 	}
-	return reconcile.Result{RequeueAfter: 12 * time.Hour}, nil
+	return reconcile.Result{RequeueAfter: min(c.nextSpotUpdate.Sub(now), c.nextOnDemandUpdate.Sub(now), c.nextCommitmentUpdate.Sub(now))}, nil
 }
 
 func (c *Controller) Name() string {
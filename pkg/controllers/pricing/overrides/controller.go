@@ -0,0 +1,90 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package overrides
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/utils/pretty"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// OverridesKey is the key in the ConfigMap data that holds the list of price overrides
+const OverridesKey = "overrides"
+
+// Controller periodically reads the price overrides ConfigMap and applies it to the pricing provider. The ConfigMap
+// is read directly from the API server rather than through the manager's cache so that Karpenter doesn't need to watch
+// every ConfigMap in the cluster.
+type Controller struct {
+	kubernetesInterface kubernetes.Interface
+	pricingProvider     *pricing.Provider
+	cm                  *pretty.ChangeMonitor
+}
+
+func NewController(kubernetesInterface kubernetes.Interface, pricingProvider *pricing.Provider) *Controller {
+	return &Controller{
+		kubernetesInterface: kubernetesInterface,
+		pricingProvider:     pricingProvider,
+		cm:                  pretty.NewChangeMonitor(),
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	name := options.FromContext(ctx).PricingOverridesConfigMap
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("configmap", name))
+
+	configMap, err := c.kubernetesInterface.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			c.pricingProvider.SetOverrides(nil)
+			if c.cm.HasChanged("overrides", nil) {
+				logging.FromContext(ctx).Debugf("price overrides configmap not found, clearing price overrides")
+			}
+			return reconcile.Result{RequeueAfter: time.Minute}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("getting price overrides configmap, %w", err)
+	}
+	// if the overrides are invalid, we retain the last valid set of overrides rather than falling back to public pricing
+	overrides, err := pricing.ParseOverrides([]byte(configMap.Data[OverridesKey]))
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("parsing price overrides, %w", err)
+	}
+	c.pricingProvider.SetOverrides(overrides)
+	if c.cm.HasChanged("overrides", overrides) {
+		logging.FromContext(ctx).With("override-count", len(overrides)).Infof("updated price overrides")
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, nil
+}
+
+func (c *Controller) Name() string {
+	return "pricing.overrides"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
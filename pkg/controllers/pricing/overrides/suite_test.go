@@ -0,0 +1,184 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package overrides_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/system"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/pricing/overrides"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var controller *overrides.Controller
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PricingOverrides")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PricingOverridesConfigMap: lo.ToPtr("karpenter-pricing-overrides")}))
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	controller = overrides.NewController(env.KubernetesInterface, awsEnv.PricingProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PricingOverridesConfigMap: lo.ToPtr("karpenter-pricing-overrides")}))
+	awsEnv.Reset()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("PricingOverrides", func() {
+	var configMap *v1.ConfigMap
+	BeforeEach(func() {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "karpenter-pricing-overrides",
+				Namespace: system.Namespace(),
+			},
+			Data: map[string]string{
+				overrides.OverridesKey: `
+- instanceType: c5.large
+  price: 0.01
+- instanceType: m5.large
+  capacityType: on-demand
+  price: 0.02
+- instanceType: m5.large
+  capacityType: spot
+  price: 0.03
+- instanceType: m5.large
+  capacityType: spot
+  zone: test-zone-1a
+  price: 0.04
+`,
+			},
+		}
+	})
+	AfterEach(func() {
+		ExpectDeleted(ctx, env.Client, configMap)
+	})
+	It("should apply overrides from the configmap", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		price, ok := awsEnv.PricingProvider.OnDemandPrice("c5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.01))
+		price, ok = awsEnv.PricingProvider.SpotPrice("c5.large", "test-zone-1b")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.01))
+	})
+	It("should prefer the most specific override", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		price, ok := awsEnv.PricingProvider.OnDemandPrice("m5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.02))
+		price, ok = awsEnv.PricingProvider.SpotPrice("m5.large", "test-zone-1b")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.03))
+		price, ok = awsEnv.PricingProvider.SpotPrice("m5.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.04))
+	})
+	It("should report instance types that are only known through overrides", func() {
+		configMap.Data[overrides.OverridesKey] = `[{"instanceType": "c99.large", "price": 1.5}]`
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		Expect(awsEnv.PricingProvider.InstanceTypes()).To(ContainElement("c99.large"))
+		price, ok := awsEnv.PricingProvider.OnDemandPrice("c99.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 1.5))
+	})
+	It("should retain the previous overrides if the configmap is invalid", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		configMap.Data[overrides.OverridesKey] = `[{"instanceType": "c5.large", "capacityType": "on-demand", "zone": "test-zone-1a", "price": 1.5}]`
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+		price, ok := awsEnv.PricingProvider.OnDemandPrice("c5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.01))
+	})
+	It("should clear overrides when the configmap is deleted", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		ExpectDeleted(ctx, env.Client, configMap)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		price, ok := awsEnv.PricingProvider.OnDemandPrice("c5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).ToNot(BeNumerically("==", 0.01))
+	})
+	DescribeTable("should reject invalid overrides",
+		func(o string) {
+			configMap.Data[overrides.OverridesKey] = o
+			ExpectApplied(ctx, env.Client, configMap)
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+		},
+		Entry("missing instance type", `[{"price": 1.5}]`),
+		Entry("unknown capacity type", `[{"instanceType": "c5.large", "capacityType": "reserved", "price": 1.5}]`),
+		Entry("negative price", `[{"instanceType": "c5.large", "price": -1}]`),
+		Entry("unknown field", `[{"instanceType": "c5.large", "cost": 1.5}]`),
+		Entry("zone without capacity type", `[{"instanceType": "c5.large", "zone": "test-zone-1a", "price": 1.5}]`),
+	)
+	It("should not use overrides for capacity types they don't apply to", func() {
+		configMap.Data[overrides.OverridesKey] = `[{"instanceType": "c5.large", "capacityType": "spot", "price": 0.001}]`
+		ExpectApplied(ctx, env.Client, configMap)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		price, ok := awsEnv.PricingProvider.OnDemandPrice("c5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).ToNot(BeNumerically("==", 0.001))
+	})
+})
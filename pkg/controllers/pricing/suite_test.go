@@ -17,14 +17,20 @@ package pricing_test
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	awspricing "github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+	savingsplanstypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -46,6 +52,7 @@ var ctx context.Context
 var stop context.CancelFunc
 var env *coretest.Environment
 var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
 var controller *controllerspricing.Controller
 
 func TestAWS(t *testing.T) {
@@ -60,7 +67,7 @@ var _ = BeforeSuite(func() {
 	ctx = options.ToContext(ctx, test.Options())
 	ctx, stop = context.WithCancel(ctx)
 	awsEnv = test.NewEnvironment(ctx, env)
-	controller = controllerspricing.NewController(awsEnv.PricingProvider)
+	fakeClock = clock.NewFakeClock(time.Now())
 })
 
 var _ = AfterSuite(func() {
@@ -73,6 +80,8 @@ var _ = BeforeEach(func() {
 	ctx = options.ToContext(ctx, test.Options())
 
 	awsEnv.Reset()
+	// the controller tracks when pricing is next due to be refreshed, so we start each test with a new controller
+	controller = controllerspricing.NewController(fakeClock, awsEnv.PricingProvider)
 })
 
 var _ = AfterEach(func() {
@@ -84,7 +93,7 @@ var _ = Describe("Pricing", func() {
 		"should return correct static data for all partitions",
 		func(staticPricing map[string]map[string]float64) {
 			for region, prices := range staticPricing {
-				provider := pricing.NewProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, region)
+				provider := pricing.NewProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, awsEnv.SavingsPlansAPI, region)
 				for instance, price := range prices {
 					val, ok := provider.OnDemandPrice(instance)
 					Expect(ok).To(BeTrue())
@@ -96,6 +105,22 @@ var _ = Describe("Pricing", func() {
 		Entry("aws-us-gov", pricing.InitialOnDemandPricesUSGov),
 		Entry("aws-cn", pricing.InitialOnDemandPricesCN),
 	)
+	It("should fall back to the static data of a region in the same partition", func() {
+		provider := pricing.NewProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, awsEnv.SavingsPlansAPI, "cn-northwest-1")
+		for instance, price := range pricing.InitialOnDemandPricesCN["cn-north-1"] {
+			val, ok := provider.OnDemandPrice(instance)
+			Expect(ok).To(BeTrue())
+			Expect(val).To(Equal(price))
+		}
+	})
+	It("should not call the pricing API in partitions where it isn't available", func() {
+		awsEnv.PricingAPI.NextError.Set(fmt.Errorf("failed"))
+		provider := pricing.NewProvider(ctx, awsEnv.PricingAPI, awsEnv.EC2API, awsEnv.SavingsPlansAPI, "us-gov-west-1")
+		Expect(provider.UpdateOnDemandPricing(ctx)).To(Succeed())
+		price, ok := provider.OnDemandPrice("c5.large")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(Equal(pricing.InitialOnDemandPricesUSGov["us-gov-west-1"]["c5.large"]))
+	})
 	It("should return static on-demand data if pricing API fails", func() {
 		awsEnv.PricingAPI.NextError.Set(fmt.Errorf("failed"))
 		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
@@ -114,7 +139,7 @@ var _ = Describe("Pricing", func() {
 		// modify our API before creating the pricing provider as it performs an initial update on creation. The pricing
 		// API provides on-demand prices, the ec2 API provides spot prices
 		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
-			PriceList: []aws.JSONValue{
+			PriceList: []string{
 				fake.NewOnDemandPrice("c98.large", 1.20),
 				fake.NewOnDemandPrice("c99.large", 1.23),
 			},
@@ -132,35 +157,35 @@ var _ = Describe("Pricing", func() {
 	It("should update spot pricing with response from the pricing API", func() {
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-			SpotPriceHistory: []*ec2.SpotPrice{
+			SpotPriceHistory: []ec2types.SpotPrice{
 				{
 					AvailabilityZone: aws.String("test-zone-1a"),
-					InstanceType:     aws.String("c99.large"),
+					InstanceType:     "c99.large",
 					SpotPrice:        aws.String("1.23"),
 					Timestamp:        &now,
 				},
 				{
 					AvailabilityZone: aws.String("test-zone-1a"),
-					InstanceType:     aws.String("c98.large"),
+					InstanceType:     "c98.large",
 					SpotPrice:        aws.String("1.20"),
 					Timestamp:        &now,
 				},
 				{
 					AvailabilityZone: aws.String("test-zone-1b"),
-					InstanceType:     aws.String("c99.large"),
+					InstanceType:     "c99.large",
 					SpotPrice:        aws.String("1.50"),
 					Timestamp:        &now,
 				},
 				{
 					AvailabilityZone: aws.String("test-zone-1b"),
-					InstanceType:     aws.String("c98.large"),
+					InstanceType:     "c98.large",
 					SpotPrice:        aws.String("1.10"),
 					Timestamp:        &now,
 				},
 			},
 		})
 		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
-			PriceList: []aws.JSONValue{
+			PriceList: []string{
 				fake.NewOnDemandPrice("c98.large", 1.20),
 				fake.NewOnDemandPrice("c99.large", 1.23),
 			},
@@ -178,23 +203,23 @@ var _ = Describe("Pricing", func() {
 	It("should update zonal pricing with data from the spot pricing API", func() {
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-			SpotPriceHistory: []*ec2.SpotPrice{
+			SpotPriceHistory: []ec2types.SpotPrice{
 				{
 					AvailabilityZone: aws.String("test-zone-1a"),
-					InstanceType:     aws.String("c99.large"),
+					InstanceType:     "c99.large",
 					SpotPrice:        aws.String("1.23"),
 					Timestamp:        &now,
 				},
 				{
 					AvailabilityZone: aws.String("test-zone-1a"),
-					InstanceType:     aws.String("c98.large"),
+					InstanceType:     "c98.large",
 					SpotPrice:        aws.String("1.20"),
 					Timestamp:        &now,
 				},
 			},
 		})
 		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
-			PriceList: []aws.JSONValue{
+			PriceList: []string{
 				fake.NewOnDemandPrice("c98.large", 1.20),
 				fake.NewOnDemandPrice("c99.large", 1.23),
 			},
@@ -211,17 +236,17 @@ var _ = Describe("Pricing", func() {
 	It("should respond with false if price doesn't exist in zone", func() {
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-			SpotPriceHistory: []*ec2.SpotPrice{
+			SpotPriceHistory: []ec2types.SpotPrice{
 				{
 					AvailabilityZone: aws.String("test-zone-1a"),
-					InstanceType:     aws.String("c99.large"),
+					InstanceType:     "c99.large",
 					SpotPrice:        aws.String("1.23"),
 					Timestamp:        &now,
 				},
 			},
 		})
 		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
-			PriceList: []aws.JSONValue{
+			PriceList: []string{
 				fake.NewOnDemandPrice("c98.large", 1.20),
 				fake.NewOnDemandPrice("c99.large", 1.23),
 			},
@@ -238,24 +263,24 @@ var _ = Describe("Pricing", func() {
 		// need to search for both values.
 		updateStart := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-			SpotPriceHistory: []*ec2.SpotPrice{
+			SpotPriceHistory: []ec2types.SpotPrice{
 				{
 					AvailabilityZone: aws.String("test-zone-1a"),
-					InstanceType:     aws.String("c99.large"),
+					InstanceType:     "c99.large",
 					SpotPrice:        aws.String("1.23"),
 					Timestamp:        &updateStart,
 				},
 			},
 		})
 		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
-			PriceList: []aws.JSONValue{
+			PriceList: []string{
 				fake.NewOnDemandPrice("c98.large", 1.20),
 				fake.NewOnDemandPrice("c99.large", 1.23),
 			},
 		})
 		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
 		inp := awsEnv.EC2API.DescribeSpotPriceHistoryInput.Clone()
-		Expect(lo.Map(inp.ProductDescriptions, func(x *string, _ int) string { return *x })).
+		Expect(inp.ProductDescriptions).
 			To(ContainElements("Linux/UNIX", "Linux/UNIX (Amazon VPC)"))
 	})
 	It("should return static on-demand data when in isolated-vpc", func() {
@@ -264,16 +289,16 @@ var _ = Describe("Pricing", func() {
 		}))
 		now := time.Now()
 		awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-			SpotPriceHistory: []*ec2.SpotPrice{
+			SpotPriceHistory: []ec2types.SpotPrice{
 				{
 					AvailabilityZone: aws.String("test-zone-1b"),
-					InstanceType:     aws.String("c99.large"),
+					InstanceType:     "c99.large",
 					SpotPrice:        aws.String("1.50"),
 					Timestamp:        &now,
 				},
 				{
 					AvailabilityZone: aws.String("test-zone-1b"),
-					InstanceType:     aws.String("c98.large"),
+					InstanceType:     "c98.large",
 					SpotPrice:        aws.String("1.10"),
 					Timestamp:        &now,
 				},
@@ -283,7 +308,7 @@ var _ = Describe("Pricing", func() {
 		awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
 			// these are incorrect prices which are here to ensure that
 			// results from only static pricing are used
-			PriceList: []aws.JSONValue{
+			PriceList: []string{
 				fake.NewOnDemandPrice("c3.2xlarge", 1.20),
 				fake.NewOnDemandPrice("c5.xlarge", 1.23),
 			},
@@ -297,4 +322,513 @@ var _ = Describe("Pricing", func() {
 		Expect(ok).To(BeTrue())
 		Expect(price).To(BeNumerically("==", 1.10))
 	})
+	Context("Pricing File", func() {
+		var path string
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "pricing.json")
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				PricingFile: lo.ToPtr(path),
+			}))
+		})
+		It("should use on-demand and spot prices from the pricing file", func() {
+			Expect(os.WriteFile(path, []byte(`{
+				"updatedAt": "2024-01-01T00:00:00Z",
+				"onDemand": {"c98.large": 1.20, "c99.large": 1.23},
+				"spot": {"c99.large": {"test-zone-1a": 0.50}}
+			}`), 0600)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.23))
+			price, ok = awsEnv.PricingProvider.SpotPrice("c99.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.50))
+			Expect(awsEnv.EC2API.DescribeSpotPriceHistoryInput.IsNil()).To(BeTrue())
+			ExpectMetricGaugeValue("karpenter_pricing_last_updated_timestamp_seconds", float64(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()), map[string]string{
+				"capacity_type": "on-demand",
+			})
+		})
+		It("should not call the pricing API when a pricing file is provided", func() {
+			Expect(os.WriteFile(path, []byte(`{"onDemand": {"c98.large": 1.20}}`), 0600)).To(Succeed())
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 5.00),
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+		It("should fall back to the EC2 API for spot prices if the pricing file doesn't contain them", func() {
+			Expect(os.WriteFile(path, []byte(`{"onDemand": {"c98.large": 1.20}}`), 0600)).To(Succeed())
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c98.large",
+						SpotPrice:        aws.String("0.30"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.30))
+		})
+		It("should retain the previous prices if the pricing file can't be read", func() {
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c5.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically(">", 0))
+		})
+		It("should fail to load a pricing file with unknown fields", func() {
+			Expect(os.WriteFile(path, []byte(`{"onDemandPrices": {"c98.large": 1.20}}`), 0600)).To(Succeed())
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			_, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeFalse())
+		})
+	})
+	Context("Refresh Intervals", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				PricingRefreshInterval:     lo.ToPtr(12 * time.Hour),
+				SpotPricingRefreshInterval: lo.ToPtr(5 * time.Minute),
+			}))
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c98.large",
+						SpotPrice:        aws.String("1.20"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.20),
+				},
+			})
+		})
+		It("should requeue at the earliest refresh interval", func() {
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+		})
+		It("should only refresh spot pricing until on-demand pricing is due", func() {
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 2.00),
+				},
+			})
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c98.large",
+						SpotPrice:        aws.String("0.50"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			fakeClock.Step(5 * time.Minute)
+			result := ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+			price, ok := awsEnv.PricingProvider.SpotPrice("c98.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.50))
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+
+			fakeClock.Step(12 * time.Hour)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 2.00))
+		})
+		It("should retry a failed refresh without waiting for the next interval", func() {
+			awsEnv.PricingAPI.NextError.Set(fmt.Errorf("failed"))
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+			awsEnv.PricingAPI.NextError.Reset()
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+	})
+	Context("Metrics", func() {
+		It("should expose on-demand and spot prices", func() {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c99.large",
+						SpotPrice:        aws.String("1.23"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.20),
+					fake.NewOnDemandPrice("c99.large", 1.50),
+				},
+			})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			ExpectMetricGaugeValue("karpenter_pricing_instance_type_price", 1.50, map[string]string{
+				"instance_type": "c99.large",
+				"capacity_type": "on-demand",
+				"zone":          "",
+			})
+			ExpectMetricGaugeValue("karpenter_pricing_instance_type_price", 1.23, map[string]string{
+				"instance_type": "c99.large",
+				"capacity_type": "spot",
+				"zone":          "test-zone-1a",
+			})
+		})
+		It("should only delete the prices of offerings that no longer have a price", func() {
+			awsEnv.PricingProvider.SetOverrides([]pricing.Override{
+				{InstanceType: "c97.large", CapacityType: "spot", Zone: "test-zone-1b", Price: 0.50},
+				{InstanceType: "c99.large", CapacityType: "on-demand", Price: 1.50},
+			})
+			ExpectMetricGaugeValue("karpenter_pricing_instance_type_price", 0.50, map[string]string{
+				"instance_type": "c97.large",
+				"capacity_type": "spot",
+				"zone":          "test-zone-1b",
+			})
+
+			awsEnv.PricingProvider.SetOverrides([]pricing.Override{
+				{InstanceType: "c99.large", CapacityType: "on-demand", Price: 1.50},
+			})
+			_, ok := FindMetricWithLabelValues("karpenter_pricing_instance_type_price", map[string]string{
+				"instance_type": "c97.large",
+				"capacity_type": "spot",
+				"zone":          "test-zone-1b",
+			})
+			Expect(ok).To(BeFalse())
+			ExpectMetricGaugeValue("karpenter_pricing_instance_type_price", 1.50, map[string]string{
+				"instance_type": "c99.large",
+				"capacity_type": "on-demand",
+				"zone":          "",
+			})
+		})
+		It("should expose the last updated timestamp for each capacity type", func() {
+			now := time.Now()
+			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
+				SpotPriceHistory: []ec2types.SpotPrice{
+					{
+						AvailabilityZone: aws.String("test-zone-1a"),
+						InstanceType:     "c99.large",
+						SpotPrice:        aws.String("1.23"),
+						Timestamp:        &now,
+					},
+				},
+			})
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.20),
+				},
+			})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			for _, capacityType := range []string{"on-demand", "spot"} {
+				metric, ok := FindMetricWithLabelValues("karpenter_pricing_last_updated_timestamp_seconds", map[string]string{
+					"capacity_type": capacityType,
+				})
+				Expect(ok).To(BeTrue())
+				Expect(metric.GetGauge().GetValue()).To(BeNumerically(">=", float64(now.Unix())))
+			}
+		})
+	})
+	Context("Commitment Aware Pricing", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				CommitmentAwarePricing: lo.ToPtr(true),
+			}))
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 1.20),
+					fake.NewOnDemandPrice("c99.large", 1.23),
+				},
+			})
+		})
+		It("should use the recurring charge of an active reserved instance", func() {
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Linux/UNIX (Amazon VPC)",
+						InstanceCount:      aws.Int32(1),
+						RecurringCharges: []ec2types.RecurringCharge{
+							{Amount: aws.Float64(0.45), Frequency: ec2types.RecurringChargeFrequencyHourly},
+						},
+					},
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.45))
+
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.23))
+		})
+		It("should ignore reserved instances for other platforms", func() {
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Windows",
+						InstanceCount:      aws.Int32(1),
+						UsagePrice:         aws.Float32(0.10),
+					},
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+		It("should use the rate of an active savings plan", func() {
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlansOutput.Set(&savingsplans.DescribeSavingsPlansOutput{
+				SavingsPlans: []savingsplanstypes.SavingsPlan{
+					{
+						SavingsPlanId:   aws.String("sp-1"),
+						Commitment:      aws.String("10.00"),
+						SavingsPlanType: savingsplanstypes.SavingsPlanTypeCompute,
+						ProductTypes:    []savingsplanstypes.SavingsPlanProductType{savingsplanstypes.SavingsPlanProductTypeEc2},
+					},
+				},
+			})
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlanRatesOutput.Set(&savingsplans.DescribeSavingsPlanRatesOutput{
+				SearchResults: []savingsplanstypes.SavingsPlanRate{
+					fake.NewSavingsPlanRate("c99.large", 0.80),
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.80))
+		})
+		It("should ignore EC2 instance savings plans for other regions", func() {
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlansOutput.Set(&savingsplans.DescribeSavingsPlansOutput{
+				SavingsPlans: []savingsplanstypes.SavingsPlan{
+					{
+						SavingsPlanId:   aws.String("sp-1"),
+						Commitment:      aws.String("10.00"),
+						SavingsPlanType: savingsplanstypes.SavingsPlanType(savingsplanstypes.SavingsPlanTypeEc2Instance),
+						ProductTypes:    []savingsplanstypes.SavingsPlanProductType{savingsplanstypes.SavingsPlanProductTypeEc2},
+						Region:          aws.String("eu-west-1"),
+					},
+				},
+			})
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlanRatesOutput.Set(&savingsplans.DescribeSavingsPlanRatesOutput{
+				SearchResults: []savingsplanstypes.SavingsPlanRate{
+					fake.NewSavingsPlanRate("c99.large", 0.80),
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.23))
+		})
+		It("should not use the rate of reserved instances that are used by running instances", func() {
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Linux/UNIX",
+						InstanceCount:      aws.Int32(2),
+						UsagePrice:         aws.Float32(0.10),
+					},
+				},
+			})
+			for _, id := range []string{"i-1", "i-2"} {
+				awsEnv.EC2API.Instances.Store(id, &ec2types.Instance{
+					InstanceId:   aws.String(id),
+					InstanceType: "c98.large",
+					State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+					Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+				})
+			}
+			// spot instances aren't covered by reserved instances
+			awsEnv.EC2API.Instances.Store("i-3", &ec2types.Instance{
+				InstanceId:        aws.String("i-3"),
+				InstanceType:      "c98.large",
+				InstanceLifecycle: ec2types.InstanceLifecycleTypeSpot,
+				State:             &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				Placement:         &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+		It("should only use the rate of zonal reserved instances in their zone", func() {
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Linux/UNIX",
+						InstanceCount:      aws.Int32(1),
+						Scope:              ec2types.ScopeAvailabilityZone,
+						AvailabilityZone:   aws.String("test-zone-1a"),
+						UsagePrice:         aws.Float32(0.10),
+					},
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandOfferingPrice("c98.large", "test-zone-1a")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.10))
+			price, ok = awsEnv.PricingProvider.OnDemandOfferingPrice("c98.large", "test-zone-1b")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+		It("should not use the rate of savings plans whose commitment is used by running instances", func() {
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlansOutput.Set(&savingsplans.DescribeSavingsPlansOutput{
+				SavingsPlans: []savingsplanstypes.SavingsPlan{
+					{
+						SavingsPlanId:   aws.String("sp-1"),
+						Commitment:      aws.String("1.00"),
+						SavingsPlanType: savingsplanstypes.SavingsPlanTypeCompute,
+						ProductTypes:    []savingsplanstypes.SavingsPlanProductType{savingsplanstypes.SavingsPlanProductTypeEc2},
+					},
+				},
+			})
+			awsEnv.SavingsPlansAPI.DescribeSavingsPlanRatesOutput.Set(&savingsplans.DescribeSavingsPlanRatesOutput{
+				SearchResults: []savingsplanstypes.SavingsPlanRate{
+					fake.NewSavingsPlanRate("c99.large", 0.80),
+				},
+			})
+			awsEnv.EC2API.Instances.Store("i-1", &ec2types.Instance{
+				InstanceId:   aws.String("i-1"),
+				InstanceType: "c99.large",
+				State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				Placement:    &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			// the running instance uses $0.80 of the $1.00 hourly commitment, which doesn't cover another instance
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.23))
+		})
+		It("should not use a committed rate that is higher than the on-demand price", func() {
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Linux/UNIX",
+						InstanceCount:      aws.Int32(1),
+						UsagePrice:         aws.Float32(2.00),
+					},
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+		It("should not adjust pricing when commitment aware pricing is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Linux/UNIX",
+						InstanceCount:      aws.Int32(1),
+						UsagePrice:         aws.Float32(0.10),
+					},
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+		It("should retry commitment pricing without refreshing on-demand pricing", func() {
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Linux/UNIX",
+						InstanceCount:      aws.Int32(1),
+						UsagePrice:         aws.Float32(0.10),
+					},
+				},
+			})
+			awsEnv.SavingsPlansAPI.NextError.Set(fmt.Errorf("failed"))
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+
+			// on-demand pricing isn't due, so the new on-demand price isn't picked up while commitment pricing is retried
+			awsEnv.PricingAPI.GetProductsOutput.Set(&awspricing.GetProductsOutput{
+				PriceList: []string{
+					fake.NewOnDemandPrice("c98.large", 2.00),
+					fake.NewOnDemandPrice("c99.large", 2.00),
+				},
+			})
+			fakeClock.Step(time.Minute)
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.10))
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c99.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.23))
+		})
+		It("should clear commitment prices when commitment aware pricing is disabled", func() {
+			awsEnv.EC2API.DescribeReservedInstancesOutput.Set(&ec2.DescribeReservedInstancesOutput{
+				ReservedInstances: []ec2types.ReservedInstances{
+					{
+						InstanceType:       "c98.large",
+						ProductDescription: "Linux/UNIX",
+						InstanceCount:      aws.Int32(1),
+						UsagePrice:         aws.Float32(0.10),
+					},
+				},
+			})
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+			price, ok := awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 0.10))
+
+			ctx = options.ToContext(ctx, test.Options())
+			fakeClock.Step(12 * time.Hour)
+			ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+			price, ok = awsEnv.PricingProvider.OnDemandPrice("c98.large")
+			Expect(ok).To(BeTrue())
+			Expect(price).To(BeNumerically("==", 1.20))
+		})
+	})
 })
@@ -0,0 +1,123 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
+)
+
+const (
+	limitsRefreshInterval        = time.Hour
+	usageRefreshInterval         = time.Minute
+	resourceUsageRefreshInterval = 5 * time.Minute
+)
+
+// Controller refreshes the EC2 vCPU service quotas every hour and the vCPUs that the account's instances use every
+// minute, so that offerings whose launch would exceed a quota are unavailable. While a quota warning threshold is set,
+// the launch templates and network interfaces of the account are counted every 5 minutes, and warning events are
+// published on the EC2NodeClasses while a quota is used beyond the threshold.
+type Controller struct {
+	kubeClient    client.Client
+	clock         clock.Clock
+	recorder      events.Recorder
+	quotaProvider *quota.Provider
+
+	nextLimitsUpdate        time.Time
+	nextResourceUsageUpdate time.Time
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, recorder events.Recorder, quotaProvider *quota.Provider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		clock:         clk,
+		recorder:      recorder,
+		quotaProvider: quotaProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	now := c.clock.Now()
+	if !now.Before(c.nextLimitsUpdate) {
+		if err := c.quotaProvider.UpdateLimits(ctx); err != nil {
+			return reconcile.Result{}, fmt.Errorf("updating service quotas, %w", err)
+		}
+		c.nextLimitsUpdate = now.Add(limitsRefreshInterval)
+	}
+	if err := c.quotaProvider.UpdateUsage(ctx); err != nil {
+		return reconcile.Result{}, fmt.Errorf("updating service quota usage, %w", err)
+	}
+	threshold := options.FromContext(ctx).QuotaWarningThreshold
+	if threshold == 0 {
+		return reconcile.Result{RequeueAfter: usageRefreshInterval}, nil
+	}
+	if !now.Before(c.nextResourceUsageUpdate) {
+		if err := c.quotaProvider.UpdateResourceUsage(ctx); err != nil {
+			return reconcile.Result{}, fmt.Errorf("updating resource quota usage, %w", err)
+		}
+		c.nextResourceUsageUpdate = now.Add(resourceUsageRefreshInterval)
+	}
+	if err := c.warn(ctx, threshold); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: usageRefreshInterval}, nil
+}
+
+// warn publishes an event on every EC2NodeClass that launches instances in Karpenter's account for each quota that is
+// used beyond the threshold percentage
+func (c *Controller) warn(ctx context.Context, threshold float64) error {
+	utilization := lo.PickBy(c.quotaProvider.Utilization(), func(_ string, ratio float64) bool { return ratio*100 >= threshold })
+	if len(utilization) == 0 {
+		return nil
+	}
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return fmt.Errorf("listing ec2nodeclasses, %w", err)
+	}
+	names := lo.Keys(utilization)
+	sort.Strings(names)
+	for i := range nodeClassList.Items {
+		if lo.FromPtr(nodeClassList.Items[i].Spec.AssumeRoleARN) != "" {
+			continue
+		}
+		for _, name := range names {
+			c.recorder.Publish(QuotaUtilizationHigh(&nodeClassList.Items[i], name, utilization[name]))
+		}
+	}
+	return nil
+}
+
+func (c *Controller) Name() string {
+	return "quota"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,214 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	controllersquota "github.com/aws/karpenter-provider-aws/pkg/controllers/quota"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
+var recorder *coretest.EventRecorder
+var controller *controllersquota.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Quota")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	recorder = coretest.NewEventRecorder()
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{QuotaAwareProvisioning: lo.ToPtr(true)}))
+	awsEnv.Reset()
+	recorder.Reset()
+	// the controller tracks when the quotas are next due to be refreshed, so we start each test with a new controller
+	controller = controllersquota.NewController(env.Client, fakeClock, recorder, awsEnv.QuotaProvider)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Quota", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass()
+		// 4 of the 8 on-demand standard vCPUs are used by a running instance
+		awsEnv.ServiceQuotasAPI.Quotas["L-1216C47A"] = 8
+		awsEnv.EC2API.Instances.Store("i-test1", &ec2types.Instance{
+			InstanceId:   aws.String("i-test1"),
+			InstanceType: "m5.xlarge",
+			State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+			CpuOptions:   &ec2types.CpuOptions{CoreCount: aws.Int32(2), ThreadsPerCore: aws.Int32(2)},
+		})
+	})
+	available := func(instanceTypes []*corecloudprovider.InstanceType, name, capacityType string) bool {
+		instanceType, ok := lo.Find(instanceTypes, func(i *corecloudprovider.InstanceType) bool { return i.Name == name })
+		Expect(ok).To(BeTrue())
+		return lo.SomeBy(instanceType.Offerings, func(o corecloudprovider.Offering) bool {
+			return o.CapacityType == capacityType && o.Available
+		})
+	}
+	It("should make offerings unavailable when launching them would exceed the vcpu quota", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		// m5.xlarge has 4 vCPUs and m5.metal has 96 vCPUs
+		Expect(available(instanceTypes, "m5.xlarge", corev1beta1.CapacityTypeOnDemand)).To(BeTrue())
+		Expect(available(instanceTypes, "m5.metal", corev1beta1.CapacityTypeOnDemand)).To(BeFalse())
+		// spot instances have a separate quota, which isn't set
+		Expect(available(instanceTypes, "m5.metal", corev1beta1.CapacityTypeSpot)).To(BeTrue())
+	})
+	It("should reserve the vcpus of launched instances", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		awsEnv.QuotaProvider.Reserve("m5.xlarge", 4, corev1beta1.CapacityTypeOnDemand)
+
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(available(instanceTypes, "m5.large", corev1beta1.CapacityTypeOnDemand)).To(BeFalse())
+		Expect(available(instanceTypes, "m5.large", corev1beta1.CapacityTypeSpot)).To(BeTrue())
+	})
+	It("should make offerings unavailable when the vcpu quota is exceeded on launch", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		awsEnv.QuotaProvider.MarkExceeded(ctx, "c5.large", corev1beta1.CapacityTypeOnDemand)
+
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(available(instanceTypes, "m5.large", corev1beta1.CapacityTypeOnDemand)).To(BeFalse())
+
+		// the usage is corrected when it's updated from EC2
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		instanceTypes, err = awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(available(instanceTypes, "m5.large", corev1beta1.CapacityTypeOnDemand)).To(BeTrue())
+	})
+	It("should not limit launches when the vcpu quota doesn't exist", func() {
+		delete(awsEnv.ServiceQuotasAPI.Quotas, "L-1216C47A")
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(available(instanceTypes, "m5.metal", corev1beta1.CapacityTypeOnDemand)).To(BeTrue())
+	})
+	It("should not limit launches of ec2nodeclasses that assume a role in another account", func() {
+		nodeClass.Spec.AssumeRoleARN = aws.String("arn:aws:iam::111122223333:role/KarpenterNodeLauncher")
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(available(instanceTypes, "m5.metal", corev1beta1.CapacityTypeOnDemand)).To(BeTrue())
+	})
+	It("should refresh the vcpu quotas every hour", func() {
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		calls := awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Calls()
+		Expect(calls).To(BeNumerically(">", 0))
+
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Calls()).To(Equal(calls))
+
+		fakeClock.Step(time.Hour)
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Calls()).To(Equal(2 * calls))
+	})
+	It("should not limit launches when quota aware provisioning is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{QuotaWarningThreshold: lo.ToPtr(80.0)}))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		instanceTypes, err := awsEnv.InstanceTypesProvider.List(ctx, &corev1beta1.KubeletConfiguration{}, nodeClass)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(available(instanceTypes, "m5.metal", corev1beta1.CapacityTypeOnDemand)).To(BeTrue())
+	})
+	Context("Warnings", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{QuotaWarningThreshold: lo.ToPtr(50.0)}))
+		})
+		It("should publish events on ec2nodeclasses when a quota is used beyond the threshold", func() {
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(awsEnv.QuotaProvider.Utilization()).To(HaveKeyWithValue("standard-on-demand-vcpus", 0.5))
+			Expect(recorder.Calls("QuotaUtilizationHigh")).To(Equal(1))
+		})
+		It("should not publish events when quotas are used below the threshold", func() {
+			awsEnv.ServiceQuotasAPI.Quotas["L-1216C47A"] = 16
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(recorder.Calls("QuotaUtilizationHigh")).To(Equal(0))
+		})
+		It("should not publish events on ec2nodeclasses that assume a role in another account", func() {
+			nodeClass.Spec.AssumeRoleARN = aws.String("arn:aws:iam::111122223333:role/KarpenterNodeLauncher")
+			ExpectApplied(ctx, env.Client, nodeClass)
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+			Expect(recorder.Calls("QuotaUtilizationHigh")).To(Equal(0))
+		})
+		It("should track the utilization of launch templates and network interfaces", func() {
+			awsEnv.ServiceQuotasAPI.Quotas["L-DF5E4CA3"] = 4
+			awsEnv.EC2API.NetworkInterfaces.Store("eni-test1", &ec2types.NetworkInterface{NetworkInterfaceId: aws.String("eni-test1")})
+			awsEnv.EC2API.LaunchTemplates.Store("lt-test1", &ec2types.LaunchTemplate{LaunchTemplateId: aws.String("lt-test1"), LaunchTemplateName: aws.String("karpenter.k8s.aws/test")})
+			ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+			utilization := awsEnv.QuotaProvider.Utilization()
+			Expect(utilization).To(HaveKeyWithValue("network-interfaces", 0.25))
+			Expect(utilization).To(HaveKeyWithValue("launch-templates", 0.0002))
+		})
+	})
+	It("should fail to reconcile when the vcpu quotas can't be retrieved", func() {
+		awsEnv.ServiceQuotasAPI.GetServiceQuotaBehavior.Error.Set(&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized to perform servicequotas:GetServiceQuota"})
+
+		ExpectReconcileFailed(ctx, controller, types.NamespacedName{})
+	})
+})
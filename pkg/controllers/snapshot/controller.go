@@ -0,0 +1,81 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// snapshotInterval is the interval at which the provider caches are saved
+const snapshotInterval = 5 * time.Minute
+
+// Controller saves the instance types, instance type offerings and prices that are cached to the snapshot path, so
+// that they can be restored when the controller restarts
+type Controller struct {
+	clock                clock.Clock
+	instanceTypeProvider *instancetype.Provider
+	pricingProvider      *pricing.Provider
+}
+
+func NewController(clk clock.Clock, instanceTypeProvider *instancetype.Provider, pricingProvider *pricing.Provider) *Controller {
+	return &Controller{
+		clock:                clk,
+		instanceTypeProvider: instanceTypeProvider,
+		pricingProvider:      pricingProvider,
+	}
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	instanceTypes, instanceTypeOfferings := c.instanceTypeProvider.Snapshot()
+	// the caches aren't saved until they're filled, so that a snapshot isn't replaced by an empty one
+	if len(instanceTypes) == 0 || len(instanceTypeOfferings) == 0 {
+		return reconcile.Result{RequeueAfter: snapshotInterval}, nil
+	}
+	if err := write(options.FromContext(ctx).CacheSnapshotPath, &Snapshot{
+		SavedAt:       c.clock.Now(),
+		InstanceTypes: instanceTypes,
+		InstanceTypeOfferings: lo.MapValues(instanceTypeOfferings, func(zones sets.Set[string], _ string) []string {
+			return sets.List(zones)
+		}),
+		Pricing: c.pricingProvider.Snapshot(),
+	}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("writing snapshot, %w", err)
+	}
+	logging.FromContext(ctx).With("instance-type-count", len(instanceTypes)).Debugf("saved snapshot")
+	return reconcile.Result{RequeueAfter: snapshotInterval}, nil
+}
+
+func (c *Controller) Name() string {
+	return "snapshot"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Builder {
+	return controller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,111 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+)
+
+// maxSnapshotAge is the age after which snapshots are no longer restored, since the instance types and prices that
+// they contain may be out of date
+const maxSnapshotAge = 24 * time.Hour
+
+// Snapshot is the state of the provider caches that is saved to disk, so that a controller that restarts doesn't have
+// to wait on the AWS APIs before it can make launch decisions
+type Snapshot struct {
+	SavedAt               time.Time                    `json:"savedAt"`
+	InstanceTypes         []*ec2types.InstanceTypeInfo `json:"instanceTypes,omitempty"`
+	InstanceTypeOfferings map[string][]string          `json:"instanceTypeOfferings,omitempty"`
+	Pricing               *pricing.File                `json:"pricing,omitempty"`
+}
+
+// Restore restores the instance types, instance type offerings and prices of the snapshot at the path. Snapshots that
+// don't exist or are older than 24 hours aren't restored.
+func Restore(ctx context.Context, path string, instanceTypeProvider *instancetype.Provider, pricingProvider *pricing.Provider) error {
+	s, err := read(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading snapshot, %w", err)
+	}
+	if age := time.Since(s.SavedAt); age > maxSnapshotAge {
+		logging.FromContext(ctx).With("saved-at", s.SavedAt, "age", age.Truncate(time.Minute)).Infof("not restoring stale snapshot")
+		return nil
+	}
+	instanceTypeProvider.Restore(ctx, s.InstanceTypes, lo.MapValues(s.InstanceTypeOfferings, func(zones []string, _ string) sets.Set[string] {
+		return sets.New(zones...)
+	}))
+	if s.Pricing != nil {
+		pricingProvider.Restore(ctx, s.Pricing)
+	}
+	logging.FromContext(ctx).With("saved-at", s.SavedAt).Infof("restored snapshot")
+	return nil
+}
+
+func read(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	s := &Snapshot{}
+	if err = json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("decoding snapshot, %w", err)
+	}
+	return s, nil
+}
+
+// write replaces the snapshot at the path, so that a snapshot that is partially written isn't restored
+func write(path string, s *Snapshot) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	w := gzip.NewWriter(f)
+	if err = json.NewEncoder(w).Encode(s); err != nil {
+		f.Close()
+		return fmt.Errorf("encoding snapshot, %w", err)
+	}
+	if err = w.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,130 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clock "k8s.io/utils/clock/testing"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/snapshot"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var stop context.CancelFunc
+var env *coretest.Environment
+var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
+var controller *snapshot.Controller
+var path string
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Snapshot")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options())
+	ctx, stop = context.WithCancel(ctx)
+	awsEnv = test.NewEnvironment(ctx, env)
+	fakeClock = clock.NewFakeClock(time.Now())
+	controller = snapshot.NewController(fakeClock, awsEnv.InstanceTypesProvider, awsEnv.PricingProvider)
+})
+
+var _ = AfterSuite(func() {
+	stop()
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	path = filepath.Join(GinkgoT().TempDir(), "snapshot.json.gz")
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{CacheSnapshotPath: lo.ToPtr(path)}))
+	fakeClock.SetTime(time.Now())
+	awsEnv.Reset()
+})
+
+var _ = Describe("Snapshot", func() {
+	BeforeEach(func() {
+		_, err := awsEnv.InstanceTypesProvider.GetInstanceTypes(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		awsEnv.InstanceTypesProvider.Restore(ctx, nil, map[string]sets.Set[string]{
+			"m5.large":  sets.New("test-zone-1a", "test-zone-1b"),
+			"m5.xlarge": sets.New("test-zone-1a"),
+		})
+		awsEnv.PricingProvider.Restore(ctx, &pricing.File{
+			Spot: map[string]map[string]float64{"m5.large": {"test-zone-1a": 0.042}},
+		})
+	})
+	It("should restore the instance types, offerings and prices that were saved", func() {
+		instanceTypes, _ := awsEnv.InstanceTypesProvider.Snapshot()
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(path).To(BeAnExistingFile())
+
+		awsEnv.Reset()
+		Expect(snapshot.Restore(ctx, path, awsEnv.InstanceTypesProvider, awsEnv.PricingProvider)).To(Succeed())
+		restoredInstanceTypes, restoredOfferings := awsEnv.InstanceTypesProvider.Snapshot()
+		Expect(restoredInstanceTypes).To(HaveLen(len(instanceTypes)))
+		Expect(restoredOfferings).To(HaveKeyWithValue("m5.large", sets.New("test-zone-1a", "test-zone-1b")))
+		Expect(restoredOfferings).To(HaveKeyWithValue("m5.xlarge", sets.New("test-zone-1a")))
+		price, ok := awsEnv.PricingProvider.SpotPrice("m5.large", "test-zone-1a")
+		Expect(ok).To(BeTrue())
+		Expect(price).To(BeNumerically("==", 0.042))
+	})
+	It("should not save a snapshot before the caches are filled", func() {
+		awsEnv.Reset()
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+		Expect(path).ToNot(BeAnExistingFile())
+	})
+	It("should not restore stale snapshots", func() {
+		fakeClock.SetTime(time.Now().Add(-25 * time.Hour))
+		ExpectReconcileSucceeded(ctx, controller, types.NamespacedName{})
+
+		awsEnv.Reset()
+		Expect(snapshot.Restore(ctx, path, awsEnv.InstanceTypesProvider, awsEnv.PricingProvider)).To(Succeed())
+		instanceTypes, offerings := awsEnv.InstanceTypesProvider.Snapshot()
+		Expect(instanceTypes).To(BeEmpty())
+		Expect(offerings).To(BeEmpty())
+	})
+	It("should succeed when the snapshot doesn't exist", func() {
+		Expect(snapshot.Restore(ctx, path, awsEnv.InstanceTypesProvider, awsEnv.PricingProvider)).To(Succeed())
+	})
+	It("should fail when the snapshot is corrupt", func() {
+		Expect(os.WriteFile(path, []byte("not a snapshot"), 0600)).To(Succeed())
+		Expect(snapshot.Restore(ctx, path, awsEnv.InstanceTypesProvider, awsEnv.PricingProvider)).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,158 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stoppedinstances
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+)
+
+// Controller terminates instances that were stopped for reuse once they can no longer be reused. Instances are
+// terminated when they have been stopped for longer than the TTL, when their NodePool or EC2NodeClass no longer exists
+// or has changed since they were stopped, and when their NodePool has more stopped instances than the pool size. The
+// controller runs even when the pool is disabled, so that instances which were stopped before the pool was disabled are
+// terminated rather than leaked.
+type Controller struct {
+	kubeClient      client.Client
+	clk             clock.Clock
+	accountProvider *account.Provider
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, accountProvider *account.Provider) *Controller {
+	return &Controller{
+		kubeClient:      kubeClient,
+		clk:             clk,
+		accountProvider: accountProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "stoppedinstances"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	stopped, instanceProviders, err := c.listStopped(ctx)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	// Keep the most recently stopped instances when a NodePool has more stopped instances than the pool size
+	sort.Slice(stopped, func(a, b int) bool {
+		return stoppedAt(stopped[a]).After(stoppedAt(stopped[b]))
+	})
+	var errs error
+	for nodePoolName, instances := range lo.GroupBy(stopped, func(i *instance.Instance) string { return i.Tags[corev1beta1.NodePoolLabelKey] }) {
+		nodeClass, err := c.nodeClass(ctx, nodePoolName)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		for n, i := range instances {
+			reason := ""
+			switch {
+			case options.FromContext(ctx).StoppedInstancePoolSize == 0:
+				reason = "pool is disabled"
+			case c.clk.Since(stoppedAt(i)) > options.FromContext(ctx).StoppedInstancePoolTTL:
+				reason = "expired"
+			case nodeClass == nil || i.Tags[v1beta1.AnnotationEC2NodeClassHash] != nodeClass.Hash() || !instance.CompatibleWithNodeClass(i, nodeClass):
+				reason = "drifted"
+			case n >= options.FromContext(ctx).StoppedInstancePoolSize:
+				reason = "pool is full"
+			default:
+				continue
+			}
+			if err = instanceProviders[i.ID].Delete(ctx, i.ID); cloudprovider.IgnoreNodeClaimNotFoundError(err) != nil {
+				errs = multierr.Append(errs, err)
+				continue
+			}
+			logging.FromContext(ctx).With("id", i.ID, "nodepool", nodePoolName, "reason", reason).Infof("terminated stopped instance")
+		}
+	}
+	return reconcile.Result{RequeueAfter: time.Minute}, errs
+}
+
+// listStopped lists the stopped instances in Karpenter's own account and in the account of every EC2NodeClass that
+// assumes a role, along with the instance provider that manages each instance
+func (c *Controller) listStopped(ctx context.Context) ([]*instance.Instance, map[string]*instance.Provider, error) {
+	nodeClassList := &v1beta1.EC2NodeClassList{}
+	if err := c.kubeClient.List(ctx, nodeClassList); err != nil {
+		return nil, nil, fmt.Errorf("listing nodeclasses, %w", err)
+	}
+	roles := lo.Uniq(append([]account.Role{{}}, lo.Map(nodeClassList.Items, func(nc v1beta1.EC2NodeClass, _ int) account.Role {
+		return account.RoleFor(&nc)
+	})...))
+	var stopped []*instance.Instance
+	instanceProviders := map[string]*instance.Provider{}
+	for _, role := range roles {
+		instanceProvider := c.accountProvider.ForRole(role).Instance
+		instances, err := instanceProvider.ListStopped(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing stopped instances, %w", err)
+		}
+		for _, i := range instances {
+			if _, ok := instanceProviders[i.ID]; ok {
+				continue
+			}
+			stopped = append(stopped, i)
+			instanceProviders[i.ID] = instanceProvider
+		}
+	}
+	return stopped, instanceProviders, nil
+}
+
+// nodeClass returns the EC2NodeClass that is used by the NodePool, or nil if either no longer exists
+func (c *Controller) nodeClass(ctx context.Context, nodePoolName string) (*v1beta1.EC2NodeClass, error) {
+	nodePool := &corev1beta1.NodePool{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePoolName}, nodePool); err != nil {
+		return nil, client.IgnoreNotFound(fmt.Errorf("getting nodepool, %w", err))
+	}
+	if nodePool.Spec.Template.Spec.NodeClassRef == nil {
+		return nil, nil
+	}
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err := c.kubeClient.Get(ctx, types.NamespacedName{Name: nodePool.Spec.Template.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		return nil, client.IgnoreNotFound(fmt.Errorf("getting ec2nodeclass, %w", err))
+	}
+	return nodeClass, nil
+}
+
+// stoppedAt returns when the instance was stopped. Instances with an invalid tag are treated as stopped long ago.
+func stoppedAt(i *instance.Instance) time.Time {
+	t, _ := time.Parse(time.RFC3339, i.Tags[v1beta1.TagStoppedAt])
+	return t
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
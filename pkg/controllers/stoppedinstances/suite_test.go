@@ -0,0 +1,202 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stoppedinstances_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/stoppedinstances"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var awsEnv *test.Environment
+var fakeClock *clock.FakeClock
+var stoppedInstancesController *stoppedinstances.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "StoppedInstancesController")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+		StoppedInstancePoolSize: lo.ToPtr(2),
+		StoppedInstancePoolTTL:  lo.ToPtr(time.Hour),
+	}))
+	awsEnv = test.NewEnvironment(ctx, env)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	awsEnv.Reset()
+	fakeClock = clock.NewFakeClock(time.Now())
+	stoppedInstancesController = stoppedinstances.NewController(env.Client, fakeClock, awsEnv.AccountProvider)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("StoppedInstancesController", func() {
+	var nodeClass *v1beta1.EC2NodeClass
+	var nodePool *corev1beta1.NodePool
+
+	BeforeEach(func() {
+		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Status: v1beta1.EC2NodeClassStatus{
+				AMIs:           []v1beta1.AMI{{ID: "ami-test1"}},
+				Subnets:        []v1beta1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a"}},
+				SecurityGroups: []v1beta1.SecurityGroup{{ID: "sg-test1"}},
+			},
+		})
+		nodePool = coretest.NodePool(corev1beta1.NodePool{
+			Spec: corev1beta1.NodePoolSpec{
+				Template: corev1beta1.NodeClaimTemplate{
+					Spec: corev1beta1.NodeClaimSpec{
+						NodeClassRef: &corev1beta1.NodeClassReference{
+							Name: nodeClass.Name,
+						},
+					},
+				},
+			},
+		})
+	})
+	It("should keep stopped instances that can be reused", func() {
+		id := storeStoppedInstance(nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-time.Minute))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		_, ok := awsEnv.EC2API.Instances.Load(id)
+		Expect(ok).To(BeTrue())
+	})
+	It("should terminate stopped instances that have been stopped for longer than the TTL", func() {
+		id := storeStoppedInstance(nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-2*time.Hour))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(id))
+	})
+	It("should terminate stopped instances when their NodePool no longer exists", func() {
+		id := storeStoppedInstance(nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-time.Minute))
+		ExpectApplied(ctx, env.Client, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(id))
+	})
+	It("should terminate stopped instances when their EC2NodeClass has changed", func() {
+		id := storeStoppedInstance(nodePool.Name, "different-hash", fakeClock.Now().Add(-time.Minute))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(id))
+	})
+	It("should terminate the oldest stopped instances when a NodePool has more than the pool size", func() {
+		oldest := storeStoppedInstance(nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-30*time.Minute))
+		storeStoppedInstance(nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-20*time.Minute))
+		storeStoppedInstance(nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-10*time.Minute))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(oldest))
+	})
+	It("should terminate stopped instances when the pool has been disabled since they were stopped", func() {
+		id := storeStoppedInstance(nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-time.Minute))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		disabledCtx := options.ToContext(ctx, test.Options(test.OptionsFields{
+			StoppedInstancePoolSize: lo.ToPtr(0),
+			StoppedInstancePoolTTL:  lo.ToPtr(time.Hour),
+		}))
+		ExpectReconcileSucceeded(disabledCtx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(id))
+	})
+	It("should terminate stopped instances in the account of an EC2NodeClass that assumes a role", func() {
+		nodeClass.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::111122223333:role/KarpenterNodeLauncher")
+		id := storeStoppedInstanceIn(awsEnv.AccountEC2API, nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-2*time.Hour))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		Expect(awsEnv.AccountEC2API.TerminateInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(id))
+	})
+	It("should keep stopped instances in the account of an EC2NodeClass that assumes a role when they can be reused", func() {
+		nodeClass.Spec.AssumeRoleARN = lo.ToPtr("arn:aws:iam::111122223333:role/KarpenterNodeLauncher")
+		id := storeStoppedInstanceIn(awsEnv.AccountEC2API, nodePool.Name, nodeClass.Hash(), fakeClock.Now().Add(-time.Minute))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+
+		ExpectReconcileSucceeded(ctx, stoppedInstancesController, types.NamespacedName{})
+		Expect(awsEnv.AccountEC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		_, ok := awsEnv.AccountEC2API.Instances.Load(id)
+		Expect(ok).To(BeTrue())
+	})
+})
+
+func storeStoppedInstance(nodePoolName, nodeClassHash string, stoppedAt time.Time) string {
+	return storeStoppedInstanceIn(awsEnv.EC2API, nodePoolName, nodeClassHash, stoppedAt)
+}
+
+func storeStoppedInstanceIn(ec2api *fake.EC2API, nodePoolName, nodeClassHash string, stoppedAt time.Time) string {
+	id := fake.InstanceID()
+	ec2api.Instances.Store(id, &ec2types.Instance{
+		InstanceId:     aws.String(id),
+		InstanceType:   "m5.large",
+		ImageId:        aws.String("ami-test1"),
+		SubnetId:       aws.String("subnet-test1"),
+		SecurityGroups: []ec2types.GroupIdentifier{{GroupId: aws.String("sg-test1")}},
+		State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+		Placement:      &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+		LaunchTime:     aws.Time(stoppedAt),
+		Tags: []ec2types.Tag{
+			{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+			{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String(nodePoolName)},
+			{Key: aws.String(v1beta1.TagStoppedAt), Value: aws.String(stoppedAt.UTC().Format(time.RFC3339))},
+			{Key: aws.String(v1beta1.AnnotationEC2NodeClassHash), Value: aws.String(nodeClassHash)},
+		},
+	})
+	return id
+}
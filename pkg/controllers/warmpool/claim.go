@@ -0,0 +1,180 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// claimTTL is how long the resources that a pod is expected to use on the node that it claimed are reserved for,
+// which is long enough for the pod to be bound to the node
+const claimTTL = time.Minute
+
+// claim is a node that a pending pod is expected to be scheduled to
+type claim struct {
+	nodeName string
+	requests v1.ResourceList
+}
+
+// ClaimController claims a warm node for each pending pod that can be scheduled to one. Claiming a node removes the
+// warm pool taint, label and do-not-disrupt annotation from the node and its NodeClaim, so that the pod is scheduled to
+// it and it's managed like any other node from then on. Pods that fit on a node that was recently claimed for another
+// pod are expected to be scheduled to that node and don't claim another one.
+type ClaimController struct {
+	kubeClient client.Client
+	recorder   events.Recorder
+	// claims are the nodes that recently claimed pods are expected to be scheduled to, keyed by the pod UID
+	claims *cache.Cache
+}
+
+func NewClaimController(kubeClient client.Client, recorder events.Recorder) corecontroller.Controller {
+	return corecontroller.Typed[*v1.Pod](kubeClient, &ClaimController{
+		kubeClient: kubeClient,
+		recorder:   recorder,
+		claims:     cache.New(claimTTL, claimTTL),
+	})
+}
+
+func (c *ClaimController) Name() string {
+	return "warmpool.claim"
+}
+
+func (c *ClaimController) Reconcile(ctx context.Context, pod *v1.Pod) (reconcile.Result, error) {
+	if !podutils.IsProvisionable(pod) {
+		return reconcile.Result{}, nil
+	}
+	if _, ok := c.claims.Get(string(pod.UID)); ok {
+		return reconcile.Result{}, nil
+	}
+	nodeList := &v1.NodeList{}
+	if err := c.kubeClient.List(ctx, nodeList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodes, %w", err)
+	}
+	claimed := sets.New(lo.MapToSlice(c.claims.Items(), func(_ string, item cache.Item) string { return item.Object.(claim).nodeName })...)
+	candidates := lo.Filter(lo.ToSlicePtr(nodeList.Items), func(node *v1.Node, _ int) bool {
+		_, warm := node.Labels[v1beta1.LabelWarmPool]
+		return (warm || claimed.Has(node.Name)) && node.DeletionTimestamp.IsZero() && node.Labels[corev1beta1.NodeInitializedLabelKey] == "true"
+	})
+	// Prefer nodes that were already claimed so that another node is only claimed when the pod doesn't fit, then the
+	// smallest warm nodes so that larger nodes are left for larger pods
+	sort.SliceStable(candidates, func(a, b int) bool {
+		if claimed.Has(candidates[a].Name) != claimed.Has(candidates[b].Name) {
+			return claimed.Has(candidates[a].Name)
+		}
+		return candidates[a].Status.Allocatable.Cpu().Cmp(*candidates[b].Status.Allocatable.Cpu()) < 0
+	})
+	requests := resources.RequestsForPods(pod)
+	for _, node := range candidates {
+		ok, err := c.schedulable(ctx, pod, requests, node)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !ok {
+			continue
+		}
+		if !claimed.Has(node.Name) {
+			if err = c.claim(ctx, pod, node); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		c.claims.SetDefault(string(pod.UID), claim{nodeName: node.Name, requests: requests})
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// schedulable returns true if the pod tolerates the node's taints other than the warm pool taint, is compatible with
+// the node's labels and fits in the resources that aren't used by the pods on the node or reserved by recent claims
+func (c *ClaimController) schedulable(ctx context.Context, pod *v1.Pod, requests v1.ResourceList, node *v1.Node) (bool, error) {
+	taints := lo.Reject(node.Spec.Taints, func(t v1.Taint, _ int) bool { return t.MatchTaint(&v1beta1.WarmPoolNoScheduleTaint) })
+	if scheduling.Taints(taints).Tolerates(pod) != nil {
+		return false, nil
+	}
+	if scheduling.NewLabelRequirements(node.Labels).Compatible(scheduling.NewStrictPodRequirements(pod)) != nil {
+		return false, nil
+	}
+	podList := &v1.PodList{}
+	if err := c.kubeClient.List(ctx, podList, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
+		return false, fmt.Errorf("listing pods on node, %w", err)
+	}
+	pods := lo.Filter(lo.ToSlicePtr(podList.Items), func(p *v1.Pod, _ int) bool { return !podutils.IsTerminal(p) })
+	bound := sets.New(lo.Map(pods, func(p *v1.Pod, _ int) string { return string(p.UID) })...)
+	used := []v1.ResourceList{resources.RequestsForPods(pods...)}
+	for uid, item := range c.claims.Items() {
+		if cl := item.Object.(claim); cl.nodeName == node.Name && !bound.Has(uid) {
+			used = append(used, cl.requests)
+		}
+	}
+	return resources.Fits(requests, resources.Subtract(node.Status.Allocatable, resources.Merge(used...))), nil
+}
+
+// claim removes the warm pool taint, label and do-not-disrupt annotation from the node and its NodeClaim
+func (c *ClaimController) claim(ctx context.Context, pod *v1.Pod, node *v1.Node) error {
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingFields{"status.providerID": node.Spec.ProviderID}); err != nil {
+		return fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	// The NodeClaim is updated first so that its taints and labels aren't synced back to the node
+	for i := range nodeClaimList.Items {
+		nodeClaim := &nodeClaimList.Items[i]
+		stored := nodeClaim.DeepCopy()
+		delete(nodeClaim.Labels, v1beta1.LabelWarmPool)
+		delete(nodeClaim.Annotations, corev1beta1.DoNotDisruptAnnotationKey)
+		nodeClaim.Spec.Taints = lo.Reject(nodeClaim.Spec.Taints, func(t v1.Taint, _ int) bool { return t.MatchTaint(&v1beta1.WarmPoolNoScheduleTaint) })
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return client.IgnoreNotFound(fmt.Errorf("patching nodeclaim, %w", err))
+		}
+		c.recorder.Publish(WarmNodeClaimed(nodeClaim, pod))
+	}
+	stored := node.DeepCopy()
+	delete(node.Labels, v1beta1.LabelWarmPool)
+	delete(node.Annotations, corev1beta1.DoNotDisruptAnnotationKey)
+	node.Spec.Taints = lo.Reject(node.Spec.Taints, func(t v1.Taint, _ int) bool { return t.MatchTaint(&v1beta1.WarmPoolNoScheduleTaint) })
+	if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); err != nil {
+		return client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
+	}
+	logging.FromContext(ctx).With("node", node.Name, "pod", client.ObjectKeyFromObject(pod)).Infof("claimed warm node")
+	return nil
+}
+
+func (c *ClaimController) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.Adapt(
+		controllerruntime.
+			NewControllerManagedBy(m).
+			For(&v1.Pod{}),
+	)
+}
@@ -0,0 +1,244 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	provisioningscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// Config is the warm pool configuration of a NodePool
+type Config struct {
+	// Size is the number of warm NodeClaims to keep for the NodePool
+	Size int
+	// InstanceTypes is the mix of instance types to launch warm NodeClaims with. Warm NodeClaims are spread evenly across
+	// the instance types. When empty, warm NodeClaims may launch as any instance type that the NodePool allows.
+	InstanceTypes []string
+	// TTL is how long a warm NodeClaim is kept before it's replaced. Warm NodeClaims are kept until they are claimed
+	// when the TTL is zero.
+	TTL time.Duration
+}
+
+// ParseConfig returns the warm pool configuration from the annotations of the NodePool. NodePools without a warm pool
+// size have a warm pool size of zero.
+func ParseConfig(nodePool *corev1beta1.NodePool) (Config, error) {
+	cfg := Config{}
+	if size, ok := nodePool.Annotations[v1beta1.AnnotationWarmPoolSize]; ok {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 0 {
+			return Config{}, fmt.Errorf("parsing %s, %q is not a non-negative integer", v1beta1.AnnotationWarmPoolSize, size)
+		}
+		cfg.Size = n
+	}
+	if instanceTypes, ok := nodePool.Annotations[v1beta1.AnnotationWarmPoolInstanceTypes]; ok {
+		cfg.InstanceTypes = lo.Compact(lo.Map(strings.Split(instanceTypes, ","), func(s string, _ int) string { return strings.TrimSpace(s) }))
+	}
+	if ttl, ok := nodePool.Annotations[v1beta1.AnnotationWarmPoolTTL]; ok {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d < 0 {
+			return Config{}, fmt.Errorf("parsing %s, %q is not a non-negative duration", v1beta1.AnnotationWarmPoolTTL, ttl)
+		}
+		cfg.TTL = d
+	}
+	return cfg, nil
+}
+
+// Controller keeps the configured number of warm NodeClaims for each NodePool that sets a warm pool size. Warm
+// NodeClaims are launched and initialized ahead of demand, but are tainted so that pods aren't scheduled to them until
+// a pending pod claims them through the ClaimController. Warm NodeClaims are replaced when they expire or drift, since
+// they aren't disrupted by the disruption controller until they are claimed.
+type Controller struct {
+	kubeClient    client.Client
+	clk           clock.Clock
+	cloudProvider cloudprovider.CloudProvider
+}
+
+func NewController(kubeClient client.Client, clk clock.Clock, cloudProvider cloudprovider.CloudProvider) *Controller {
+	return &Controller{
+		kubeClient:    kubeClient,
+		clk:           clk,
+		cloudProvider: cloudProvider,
+	}
+}
+
+func (c *Controller) Name() string {
+	return "warmpool"
+}
+
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	nodePoolList := &corev1beta1.NodePoolList{}
+	if err := c.kubeClient.List(ctx, nodePoolList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodeClaimList := &corev1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.HasLabels{v1beta1.LabelWarmPool}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	warm := lo.GroupBy(lo.ToSlicePtr(nodeClaimList.Items), func(nc *corev1beta1.NodeClaim) string {
+		return nc.Labels[corev1beta1.NodePoolLabelKey]
+	})
+	var errs error
+	for i := range nodePoolList.Items {
+		if err := c.reconcileNodePool(ctx, &nodePoolList.Items[i], warm[nodePoolList.Items[i].Name]); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("reconciling warm pool for nodepool %q, %w", nodePoolList.Items[i].Name, err))
+		}
+	}
+	return reconcile.Result{RequeueAfter: 30 * time.Second}, errs
+}
+
+func (c *Controller) reconcileNodePool(ctx context.Context, nodePool *corev1beta1.NodePool, nodeClaims []*corev1beta1.NodeClaim) error {
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("nodepool", nodePool.Name))
+	cfg, err := ParseConfig(nodePool)
+	if err != nil {
+		return err
+	}
+	// Keep the most recently launched NodeClaims when the pool has more warm NodeClaims than the pool size
+	sort.Slice(nodeClaims, func(a, b int) bool {
+		return nodeClaims[a].CreationTimestamp.After(nodeClaims[b].CreationTimestamp.Time)
+	})
+	var errs error
+	var pool []*corev1beta1.NodeClaim
+	for _, nodeClaim := range nodeClaims {
+		if !nodeClaim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		reason := ""
+		switch {
+		case len(pool) >= cfg.Size:
+			reason = "pool is above its size"
+		case cfg.TTL > 0 && c.clk.Since(nodeClaim.CreationTimestamp.Time) > cfg.TTL:
+			reason = "expired"
+		case nodeClaim.StatusConditions().GetCondition(corev1beta1.Drifted).IsTrue():
+			reason = "drifted"
+		}
+		if reason == "" {
+			pool = append(pool, nodeClaim)
+			continue
+		}
+		if err := c.kubeClient.Delete(ctx, nodeClaim); client.IgnoreNotFound(err) != nil {
+			errs = multierr.Append(errs, fmt.Errorf("deleting nodeclaim, %w", err))
+			continue
+		}
+		logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "reason", reason).Infof("deleted warm nodeclaim")
+	}
+	if len(pool) >= cfg.Size || !nodePool.DeletionTimestamp.IsZero() {
+		return errs
+	}
+	if err := nodePool.Spec.Limits.ExceededBy(nodePool.Status.Resources); err != nil {
+		logging.FromContext(ctx).Debugf("skipping warm pool launches, %s", err)
+		return errs
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return multierr.Append(errs, fmt.Errorf("getting instance types, %w", err))
+	}
+	// Each NodeClaim may launch as the largest of its instance types, so that capacity is reserved against the limits of
+	// the NodePool before the next NodeClaim is created, in the same way that the provisioner does
+	remaining := utils.RemainingResources(nodePool)
+	for len(pool) < cfg.Size {
+		nodeClaim, launchable, err := newNodeClaim(nodePool, instanceTypes, pool, cfg, remaining)
+		if err != nil {
+			return multierr.Append(errs, err)
+		}
+		if nodeClaim == nil {
+			logging.FromContext(ctx).Debugf("skipping warm pool launches, no instance types fit within the nodepool limits")
+			return errs
+		}
+		if err = c.kubeClient.Create(ctx, nodeClaim); err != nil {
+			return multierr.Append(errs, fmt.Errorf("creating nodeclaim, %w", err))
+		}
+		logging.FromContext(ctx).With("nodeclaim", nodeClaim.Name, "requirements", scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)).Infof("created warm nodeclaim")
+		pool = append(pool, nodeClaim)
+		remaining = utils.SubtractMaxResources(remaining, launchable)
+	}
+	return errs
+}
+
+// newNodeClaim returns a warm NodeClaim for the NodePool that only launches as instance types that fit within the
+// remaining resources, along with those instance types. No NodeClaim is returned when none of the available instance
+// types fit. When the warm pool has an instance type mix, the NodeClaim is launched as the instance type in the mix
+// that the pool has the fewest NodeClaims of.
+func newNodeClaim(nodePool *corev1beta1.NodePool, instanceTypes []*cloudprovider.InstanceType, pool []*corev1beta1.NodeClaim, cfg Config, remaining v1.ResourceList) (*corev1beta1.NodeClaim, []*cloudprovider.InstanceType, error) {
+	nct := provisioningscheduling.NewNodeClaimTemplate(nodePool)
+	options := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return nct.Requirements.Compatible(it.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil &&
+			len(it.Offerings.Compatible(nct.Requirements).Available()) > 0
+	})
+	if len(cfg.InstanceTypes) > 0 {
+		options = lo.Filter(options, func(it *cloudprovider.InstanceType, _ int) bool { return lo.Contains(cfg.InstanceTypes, it.Name) })
+	}
+	if len(options) == 0 {
+		return nil, nil, fmt.Errorf("no instance types are available for the warm pool")
+	}
+	if options = utils.FilterByRemainingResources(options, remaining); len(options) == 0 {
+		return nil, nil, nil
+	}
+	if len(cfg.InstanceTypes) > 0 {
+		counts := lo.CountValuesBy(pool, instanceType)
+		sort.SliceStable(options, func(a, b int) bool {
+			if counts[options[a].Name] != counts[options[b].Name] {
+				return counts[options[a].Name] < counts[options[b].Name]
+			}
+			return lo.IndexOf(cfg.InstanceTypes, options[a].Name) < lo.IndexOf(cfg.InstanceTypes, options[b].Name)
+		})
+		options = lo.Slice(options, 0, 1)
+	}
+	nct.InstanceTypeOptions = options
+	nodeClaim := nct.ToNodeClaim(nodePool)
+	nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{v1beta1.LabelWarmPool: "true"})
+	// Warm NodeClaims are empty until they are claimed, so they are excluded from disruption to prevent them from
+	// being consolidated
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{corev1beta1.DoNotDisruptAnnotationKey: "true"})
+	nodeClaim.Spec.Taints = append(append([]v1.Taint{}, nodeClaim.Spec.Taints...), v1beta1.WarmPoolNoScheduleTaint)
+	return nodeClaim, options, nil
+}
+
+// instanceType returns the instance type of the NodeClaim, or the instance type that it was created with if it
+// hasn't launched yet
+func instanceType(nodeClaim *corev1beta1.NodeClaim) string {
+	if it, ok := nodeClaim.Labels[v1.LabelInstanceTypeStable]; ok {
+		return it
+	}
+	if values := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1.LabelInstanceTypeStable).Values(); len(values) == 1 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) corecontroller.Builder {
+	return corecontroller.NewSingletonManagedBy(m)
+}
@@ -0,0 +1,322 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	corecloudprovider "sigs.k8s.io/karpenter/pkg/cloudprovider"
+	corefake "sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	corecontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/controllers/warmpool"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var fakeClock *clock.FakeClock
+var cloudProvider *corefake.CloudProvider
+var recorder *coretest.EventRecorder
+var warmPoolController *warmpool.Controller
+var claimController corecontroller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WarmPool")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
+	cloudProvider = corefake.NewCloudProvider()
+	recorder = coretest.NewEventRecorder()
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	cloudProvider.Reset()
+	recorder.Reset()
+	fakeClock = clock.NewFakeClock(time.Now())
+	warmPoolController = warmpool.NewController(env.Client, fakeClock, cloudProvider)
+	claimController = warmpool.NewClaimController(env.Client, recorder)
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("WarmPool", func() {
+	var nodePool *corev1beta1.NodePool
+
+	BeforeEach(func() {
+		nodePool = coretest.NodePool(corev1beta1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					v1beta1.AnnotationWarmPoolSize: "2",
+				},
+			},
+		})
+	})
+	Context("Pool", func() {
+		It("should create warm nodeclaims up to the pool size", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(2))
+			for _, nodeClaim := range nodeClaims {
+				Expect(nodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelWarmPool, "true"))
+				Expect(nodeClaim.Labels).To(HaveKeyWithValue(corev1beta1.NodePoolLabelKey, nodePool.Name))
+				Expect(nodeClaim.Annotations).To(HaveKeyWithValue(corev1beta1.DoNotDisruptAnnotationKey, "true"))
+				Expect(nodeClaim.Spec.Taints).To(ContainElement(v1beta1.WarmPoolNoScheduleTaint))
+			}
+
+			// The pool is already full, so no more nodeclaims are created
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		})
+		It("should not create warm nodeclaims for nodepools without a warm pool size", func() {
+			nodePool.Annotations = nil
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		})
+		It("should spread warm nodeclaims across the instance type mix", func() {
+			cloudProvider.InstanceTypes = []*corecloudprovider.InstanceType{
+				corefake.NewInstanceType(corefake.InstanceTypeOptions{Name: "small-instance-type"}),
+				corefake.NewInstanceType(corefake.InstanceTypeOptions{Name: "large-instance-type"}),
+				corefake.NewInstanceType(corefake.InstanceTypeOptions{Name: "other-instance-type"}),
+			}
+			nodePool.Annotations[v1beta1.AnnotationWarmPoolInstanceTypes] = "small-instance-type, large-instance-type"
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			Expect(lo.Map(ExpectNodeClaims(ctx, env.Client), func(nc *corev1beta1.NodeClaim, _ int) []string {
+				return lo.FlatMap(nc.Spec.Requirements, func(r corev1beta1.NodeSelectorRequirementWithMinValues, _ int) []string {
+					return lo.Ternary(r.Key == v1.LabelInstanceTypeStable, r.Values, nil)
+				})
+			})).To(ConsistOf([]string{"small-instance-type"}, []string{"large-instance-type"}))
+		})
+		It("should only create warm nodeclaims that fit within the limits of the nodepool", func() {
+			cloudProvider.InstanceTypes = []*corecloudprovider.InstanceType{
+				corefake.NewInstanceType(corefake.InstanceTypeOptions{
+					Name:      "small-instance-type",
+					Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+				}),
+			}
+			nodePool.Spec.Limits = corev1beta1.Limits{v1.ResourceCPU: resource.MustParse("6")}
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should only launch the instance types of the mix that fit within the limits of the nodepool", func() {
+			cloudProvider.InstanceTypes = []*corecloudprovider.InstanceType{
+				corefake.NewInstanceType(corefake.InstanceTypeOptions{
+					Name:      "small-instance-type",
+					Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+				}),
+				corefake.NewInstanceType(corefake.InstanceTypeOptions{
+					Name:      "large-instance-type",
+					Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+				}),
+			}
+			nodePool.Annotations[v1beta1.AnnotationWarmPoolInstanceTypes] = "large-instance-type, small-instance-type"
+			nodePool.Spec.Limits = corev1beta1.Limits{v1.ResourceCPU: resource.MustParse("6")}
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			Expect(lo.Map(ExpectNodeClaims(ctx, env.Client), func(nc *corev1beta1.NodeClaim, _ int) []string {
+				return lo.FlatMap(nc.Spec.Requirements, func(r corev1beta1.NodeSelectorRequirementWithMinValues, _ int) []string {
+					return lo.Ternary(r.Key == v1.LabelInstanceTypeStable, r.Values, nil)
+				})
+			})).To(ConsistOf([]string{"small-instance-type"}, []string{"small-instance-type"}))
+		})
+		It("should delete warm nodeclaims when the pool is above its size", func() {
+			nodeClaims := lo.Times(3, func(_ int) *corev1beta1.NodeClaim { return warmNodeClaim(nodePool) })
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaims[0], nodeClaims[1], nodeClaims[2])
+
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		})
+		It("should replace warm nodeclaims that have been in the pool for longer than the TTL", func() {
+			nodePool.Annotations[v1beta1.AnnotationWarmPoolSize] = "1"
+			nodePool.Annotations[v1beta1.AnnotationWarmPoolTTL] = "30m"
+			nodeClaim := warmNodeClaim(nodePool)
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			// The creation timestamp is set by the API server
+			created := ExpectExists(ctx, env.Client, nodeClaim).CreationTimestamp.Time
+
+			fakeClock.SetTime(created.Add(29 * time.Minute))
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			ExpectExists(ctx, env.Client, nodeClaim)
+
+			fakeClock.SetTime(created.Add(31 * time.Minute))
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			ExpectNotFound(ctx, env.Client, nodeClaim)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
+		It("should replace warm nodeclaims that have drifted", func() {
+			drifted := warmNodeClaim(nodePool)
+			current := warmNodeClaim(nodePool)
+			ExpectApplied(ctx, env.Client, nodePool, drifted, current)
+			drifted.StatusConditions().MarkTrue(corev1beta1.Drifted)
+			ExpectApplied(ctx, env.Client, drifted)
+
+			ExpectReconcileSucceeded(ctx, warmPoolController, types.NamespacedName{})
+			ExpectNotFound(ctx, env.Client, drifted)
+			ExpectExists(ctx, env.Client, current)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		})
+		It("should fail to reconcile nodepools with an invalid warm pool size", func() {
+			nodePool.Annotations[v1beta1.AnnotationWarmPoolSize] = "-1"
+			ExpectApplied(ctx, env.Client, nodePool)
+
+			ExpectReconcileFailed(ctx, warmPoolController, types.NamespacedName{})
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		})
+	})
+	Context("Claim", func() {
+		var nodeClaim *corev1beta1.NodeClaim
+		var node *v1.Node
+
+		BeforeEach(func() {
+			nodeClaim, node = warmNode(nodePool, "4")
+		})
+		It("should claim a warm node for a pending pod", func() {
+			pod := coretest.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, claimController, client.ObjectKeyFromObject(pod))
+			node = ExpectExists(ctx, env.Client, node)
+			Expect(node.Labels).ToNot(HaveKey(v1beta1.LabelWarmPool))
+			Expect(node.Annotations).ToNot(HaveKey(corev1beta1.DoNotDisruptAnnotationKey))
+			Expect(node.Spec.Taints).ToNot(ContainElement(v1beta1.WarmPoolNoScheduleTaint))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.Labels).ToNot(HaveKey(v1beta1.LabelWarmPool))
+			Expect(nodeClaim.Annotations).ToNot(HaveKey(corev1beta1.DoNotDisruptAnnotationKey))
+			Expect(nodeClaim.Spec.Taints).ToNot(ContainElement(v1beta1.WarmPoolNoScheduleTaint))
+			Expect(recorder.Calls("WarmNodeClaimed")).To(Equal(1))
+		})
+		It("should not claim a warm node for a pod that isn't compatible with it", func() {
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				NodeSelector: map[string]string{v1.LabelTopologyZone: "other-zone"},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, claimController, client.ObjectKeyFromObject(pod))
+			Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKey(v1beta1.LabelWarmPool))
+		})
+		It("should not claim a warm node for a pod that doesn't fit on it", func() {
+			pod := coretest.UnschedulablePod(coretest.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, claimController, client.ObjectKeyFromObject(pod))
+			Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKey(v1beta1.LabelWarmPool))
+		})
+		It("should not claim a warm node that hasn't initialized", func() {
+			delete(node.Labels, corev1beta1.NodeInitializedLabelKey)
+			pod := coretest.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
+
+			ExpectReconcileSucceeded(ctx, claimController, client.ObjectKeyFromObject(pod))
+			Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKey(v1beta1.LabelWarmPool))
+		})
+		It("should only claim another warm node when pods don't fit on the nodes that were already claimed", func() {
+			otherNodeClaim, otherNode := warmNode(nodePool, "4")
+			pods := lo.Times(3, func(_ int) *v1.Pod {
+				return coretest.UnschedulablePod(coretest.PodOptions{
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}},
+				})
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, otherNodeClaim, otherNode, pods[0], pods[1], pods[2])
+
+			ExpectReconcileSucceeded(ctx, claimController, client.ObjectKeyFromObject(pods[0]))
+			ExpectReconcileSucceeded(ctx, claimController, client.ObjectKeyFromObject(pods[1]))
+			claimed := lo.Filter([]*v1.Node{ExpectExists(ctx, env.Client, node), ExpectExists(ctx, env.Client, otherNode)}, func(n *v1.Node, _ int) bool {
+				_, warm := n.Labels[v1beta1.LabelWarmPool]
+				return !warm
+			})
+			Expect(claimed).To(HaveLen(1))
+
+			ExpectReconcileSucceeded(ctx, claimController, client.ObjectKeyFromObject(pods[2]))
+			Expect(ExpectExists(ctx, env.Client, node).Labels).ToNot(HaveKey(v1beta1.LabelWarmPool))
+			Expect(ExpectExists(ctx, env.Client, otherNode).Labels).ToNot(HaveKey(v1beta1.LabelWarmPool))
+		})
+	})
+})
+
+func warmNodeClaim(nodePool *corev1beta1.NodePool) *corev1beta1.NodeClaim {
+	return coretest.NodeClaim(corev1beta1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				corev1beta1.NodePoolLabelKey: nodePool.Name,
+				v1beta1.LabelWarmPool:        "true",
+			},
+		},
+	})
+}
+
+func warmNode(nodePool *corev1beta1.NodePool, cpu string) (*corev1beta1.NodeClaim, *v1.Node) {
+	return coretest.NodeClaimAndNode(corev1beta1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				corev1beta1.NodePoolLabelKey:        nodePool.Name,
+				corev1beta1.NodeInitializedLabelKey: "true",
+				v1.LabelTopologyZone:                "test-zone-1",
+				v1beta1.LabelWarmPool:               "true",
+			},
+			Annotations: map[string]string{
+				corev1beta1.DoNotDisruptAnnotationKey: "true",
+			},
+		},
+		Spec: corev1beta1.NodeClaimSpec{
+			Taints: []v1.Taint{v1beta1.WarmPoolNoScheduleTaint},
+		},
+		Status: corev1beta1.NodeClaimStatus{
+			Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(cpu),
+				v1.ResourceMemory: resource.MustParse("16Gi"),
+				v1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	})
+}
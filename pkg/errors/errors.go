@@ -17,10 +17,9 @@ package errors
 import (
 	"errors"
 
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/iam"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -34,11 +33,33 @@ var (
 		"InvalidInstanceID.NotFound",
 		launchTemplateNameNotFoundCode,
 		"InvalidLaunchTemplateId.NotFound",
-		sqs.ErrCodeQueueDoesNotExist,
-		iam.ErrCodeNoSuchEntityException,
+		"InvalidNetworkInterfaceID.NotFound",
+		"InvalidVolume.NotFound",
+		"QueueDoesNotExist",
+		"AWS.SimpleQueueService.NonExistentQueue",
+		"NoSuchEntity",
+		"NoSuchResourceException",
+		"ResourceNotFoundException",
+	)
+	// kmsErrorCodes signify that SQS was unable to use the KMS key that encrypts the queue
+	kmsErrorCodes = sets.New[string](
+		"KmsAccessDenied",
+		"KmsDisabled",
+		"KmsInvalidKeyUsage",
+		"KmsInvalidState",
+		"KmsNotFound",
+		"KmsOptInRequired",
+		"KmsThrottled",
+		"KMS.AccessDeniedException",
+		"KMS.DisabledException",
+		"KMS.InvalidStateException",
+		"KMS.NotFoundException",
+		"KMS.OptInRequired",
+		"KMS.ThrottlingException",
 	)
 	alreadyExistsErrorCodes = sets.New[string](
-		iam.ErrCodeEntityAlreadyExistsException,
+		"EntityAlreadyExists",
+		"ResourceAlreadyExistsException",
 	)
 	// unfulfillableCapacityErrorCodes signify that capacity is temporarily unable to be launched
 	unfulfillableCapacityErrorCodes = sets.New[string](
@@ -49,6 +70,17 @@ var (
 		"Unsupported",
 		"InsufficientFreeAddressesInSubnet",
 	)
+	// accessDeniedErrorCodes signify that the caller isn't allowed to call the action
+	accessDeniedErrorCodes = sets.New[string](
+		"AccessDenied",
+		"AccessDeniedException",
+		"UnauthorizedOperation",
+	)
+	// serviceQuotaExceededErrorCodes signify that the launch would exceed the account's vCPU service quotas
+	serviceQuotaExceededErrorCodes = sets.New[string](
+		"MaxSpotInstanceCountExceeded",
+		"VcpuLimitExceeded",
+	)
 )
 
 // IsNotFound returns true if the err is an AWS error (even if it's
@@ -58,9 +90,9 @@ func IsNotFound(err error) bool {
 	if err == nil {
 		return false
 	}
-	var awsError awserr.Error
-	if errors.As(err, &awsError) {
-		return notFoundErrorCodes.Has(awsError.Code())
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return notFoundErrorCodes.Has(apiErr.ErrorCode())
 	}
 	return false
 }
@@ -76,9 +108,9 @@ func IsAlreadyExists(err error) bool {
 	if err == nil {
 		return false
 	}
-	var awsError awserr.Error
-	if errors.As(err, &awsError) {
-		return alreadyExistsErrorCodes.Has(awsError.Code())
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return alreadyExistsErrorCodes.Has(apiErr.ErrorCode())
 	}
 	return false
 }
@@ -90,20 +122,52 @@ func IgnoreAlreadyExists(err error) error {
 	return err
 }
 
+// IsAccessDenied returns true if the err is an AWS error (even if it's
+// wrapped) that means the caller isn't allowed to call the action
+func IsAccessDenied(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return accessDeniedErrorCodes.Has(apiErr.ErrorCode())
+	}
+	return false
+}
+
 // IsUnfulfillableCapacity returns true if the Fleet err means
 // capacity is temporarily unavailable for launching.
 // This could be due to account limits, insufficient ec2 capacity, etc.
-func IsUnfulfillableCapacity(err *ec2.CreateFleetError) bool {
-	return unfulfillableCapacityErrorCodes.Has(*err.ErrorCode)
+func IsUnfulfillableCapacity(err ec2types.CreateFleetError) bool {
+	return unfulfillableCapacityErrorCodes.Has(aws.ToString(err.ErrorCode))
+}
+
+// IsServiceQuotaExceeded returns true if the Fleet err means that the
+// launch would exceed the account's on-demand or spot vCPU service quota
+func IsServiceQuotaExceeded(err ec2types.CreateFleetError) bool {
+	return serviceQuotaExceededErrorCodes.Has(aws.ToString(err.ErrorCode))
+}
+
+// IsKMSError returns true if the err is an AWS error (even if it's wrapped)
+// that was caused by SQS being unable to use the KMS key that encrypts the queue
+func IsKMSError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return kmsErrorCodes.Has(apiErr.ErrorCode())
+	}
+	return false
 }
 
 func IsLaunchTemplateNotFound(err error) bool {
 	if err == nil {
 		return false
 	}
-	var awsError awserr.Error
-	if errors.As(err, &awsError) {
-		return awsError.Code() == launchTemplateNameNotFoundCode
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode() == launchTemplateNameNotFoundCode
 	}
 	return false
 }
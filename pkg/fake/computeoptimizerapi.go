@@ -0,0 +1,51 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/computeoptimizer"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+type ComputeOptimizerAPI struct {
+	sdk.ComputeOptimizerAPI
+	ComputeOptimizerBehavior
+}
+type ComputeOptimizerBehavior struct {
+	NextError                           AtomicError
+	GetEC2InstanceRecommendationsInput  AtomicPtr[computeoptimizer.GetEC2InstanceRecommendationsInput]
+	GetEC2InstanceRecommendationsOutput AtomicPtr[computeoptimizer.GetEC2InstanceRecommendationsOutput]
+}
+
+func (c *ComputeOptimizerAPI) Reset() {
+	c.NextError.Reset()
+	c.GetEC2InstanceRecommendationsInput.Reset()
+	c.GetEC2InstanceRecommendationsOutput.Reset()
+}
+
+func (c *ComputeOptimizerAPI) GetEC2InstanceRecommendations(_ context.Context, input *computeoptimizer.GetEC2InstanceRecommendationsInput, _ ...func(*computeoptimizer.Options)) (*computeoptimizer.GetEC2InstanceRecommendationsOutput, error) {
+	c.GetEC2InstanceRecommendationsInput.Set(input)
+	if !c.NextError.IsNil() {
+		defer c.NextError.Reset()
+		return nil, c.NextError.Get()
+	}
+	if !c.GetEC2InstanceRecommendationsOutput.IsNil() {
+		return c.GetEC2InstanceRecommendationsOutput.Clone(), nil
+	}
+	return &computeoptimizer.GetEC2InstanceRecommendationsOutput{}, nil
+}
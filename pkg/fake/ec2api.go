@@ -22,21 +22,25 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws/awserr"
-
 	"github.com/Pallinder/go-randomdata"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/util/sets"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"sigs.k8s.io/karpenter/pkg/test"
 	"sigs.k8s.io/karpenter/pkg/utils/atomic"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
 
+// CreateFleetRequestID is the request ID of the responses to CreateFleet
+const CreateFleetRequestID = "5d4d0e6c-7f3a-4b1e-9c2d-8a6f1e2b3c4d"
+
 type CapacityPool struct {
 	CapacityType string
 	InstanceType string
@@ -46,29 +50,43 @@ type CapacityPool struct {
 // EC2Behavior must be reset between tests otherwise tests will
 // pollute each other.
 type EC2Behavior struct {
-	DescribeImagesOutput                AtomicPtr[ec2.DescribeImagesOutput]
-	DescribeLaunchTemplatesOutput       AtomicPtr[ec2.DescribeLaunchTemplatesOutput]
-	DescribeSubnetsOutput               AtomicPtr[ec2.DescribeSubnetsOutput]
-	DescribeSecurityGroupsOutput        AtomicPtr[ec2.DescribeSecurityGroupsOutput]
-	DescribeInstanceTypesOutput         AtomicPtr[ec2.DescribeInstanceTypesOutput]
-	DescribeInstanceTypeOfferingsOutput AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
-	DescribeAvailabilityZonesOutput     AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
-	DescribeSpotPriceHistoryInput       AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
-	DescribeSpotPriceHistoryOutput      AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
-	CreateFleetBehavior                 MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
-	TerminateInstancesBehavior          MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
-	DescribeInstancesBehavior           MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
-	CreateTagsBehavior                  MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
-	CalledWithCreateLaunchTemplateInput AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
-	CalledWithDescribeImagesInput       AtomicPtrSlice[ec2.DescribeImagesInput]
-	Instances                           sync.Map
-	LaunchTemplates                     sync.Map
-	InsufficientCapacityPools           atomic.Slice[CapacityPool]
-	NextError                           AtomicError
+	DescribeImagesOutput                    AtomicPtr[ec2.DescribeImagesOutput]
+	DescribeLaunchTemplatesOutput           AtomicPtr[ec2.DescribeLaunchTemplatesOutput]
+	DescribeSubnetsOutput                   AtomicPtr[ec2.DescribeSubnetsOutput]
+	DescribeSecurityGroupsOutput            AtomicPtr[ec2.DescribeSecurityGroupsOutput]
+	DescribeInstanceTypesOutput             AtomicPtr[ec2.DescribeInstanceTypesOutput]
+	DescribeInstanceTypeOfferingsOutput     AtomicPtr[ec2.DescribeInstanceTypeOfferingsOutput]
+	DescribeAvailabilityZonesOutput         AtomicPtr[ec2.DescribeAvailabilityZonesOutput]
+	DescribeSpotPriceHistoryInput           AtomicPtr[ec2.DescribeSpotPriceHistoryInput]
+	DescribeSpotPriceHistoryOutput          AtomicPtr[ec2.DescribeSpotPriceHistoryOutput]
+	DescribeReservedInstancesOutput         AtomicPtr[ec2.DescribeReservedInstancesOutput]
+	CreateFleetBehavior                     MockedFunction[ec2.CreateFleetInput, ec2.CreateFleetOutput]
+	TerminateInstancesBehavior              MockedFunction[ec2.TerminateInstancesInput, ec2.TerminateInstancesOutput]
+	DescribeInstancesBehavior               MockedFunction[ec2.DescribeInstancesInput, ec2.DescribeInstancesOutput]
+	CreateTagsBehavior                      MockedFunction[ec2.CreateTagsInput, ec2.CreateTagsOutput]
+	DeleteTagsBehavior                      MockedFunction[ec2.DeleteTagsInput, ec2.DeleteTagsOutput]
+	StopInstancesBehavior                   MockedFunction[ec2.StopInstancesInput, ec2.StopInstancesOutput]
+	StartInstancesBehavior                  MockedFunction[ec2.StartInstancesInput, ec2.StartInstancesOutput]
+	RebootInstancesBehavior                 MockedFunction[ec2.RebootInstancesInput, ec2.RebootInstancesOutput]
+	ModifyInstanceMetadataOptionsBehavior   MockedFunction[ec2.ModifyInstanceMetadataOptionsInput, ec2.ModifyInstanceMetadataOptionsOutput]
+	ModifyNetworkInterfaceAttributeBehavior MockedFunction[ec2.ModifyNetworkInterfaceAttributeInput, ec2.ModifyNetworkInterfaceAttributeOutput]
+	DescribeInstanceStatusBehavior          MockedFunction[ec2.DescribeInstanceStatusInput, ec2.DescribeInstanceStatusOutput]
+	GetConsoleOutputBehavior                MockedFunction[ec2.GetConsoleOutputInput, ec2.GetConsoleOutputOutput]
+	GetConsoleScreenshotBehavior            MockedFunction[ec2.GetConsoleScreenshotInput, ec2.GetConsoleScreenshotOutput]
+	DeleteNetworkInterfaceBehavior          MockedFunction[ec2.DeleteNetworkInterfaceInput, ec2.DeleteNetworkInterfaceOutput]
+	DeleteVolumeBehavior                    MockedFunction[ec2.DeleteVolumeInput, ec2.DeleteVolumeOutput]
+	CalledWithCreateLaunchTemplateInput     AtomicPtrSlice[ec2.CreateLaunchTemplateInput]
+	CalledWithDescribeImagesInput           AtomicPtrSlice[ec2.DescribeImagesInput]
+	Instances                               sync.Map
+	LaunchTemplates                         sync.Map
+	NetworkInterfaces                       sync.Map
+	Volumes                                 sync.Map
+	InsufficientCapacityPools               atomic.Slice[CapacityPool]
+	NextError                               AtomicError
 }
 
 type EC2API struct {
-	ec2iface.EC2API
+	sdk.EC2API
 	EC2Behavior
 }
 
@@ -76,8 +94,8 @@ func NewEC2API() *EC2API {
 	return &EC2API{}
 }
 
-// DefaultSupportedUsageClasses is a var because []*string can't be a const
-var DefaultSupportedUsageClasses = aws.StringSlice([]string{"on-demand", "spot"})
+// DefaultSupportedUsageClasses is a var because a slice can't be a const
+var DefaultSupportedUsageClasses = []ec2types.UsageClassType{ec2types.UsageClassTypeOnDemand, ec2types.UsageClassTypeSpot}
 
 // Reset must be called between tests otherwise tests will pollute
 // each other.
@@ -92,10 +110,19 @@ func (e *EC2API) Reset() {
 	e.CreateFleetBehavior.Reset()
 	e.TerminateInstancesBehavior.Reset()
 	e.DescribeInstancesBehavior.Reset()
+	e.DescribeInstanceStatusBehavior.Reset()
+	e.GetConsoleOutputBehavior.Reset()
+	e.GetConsoleScreenshotBehavior.Reset()
+	e.RebootInstancesBehavior.Reset()
+	e.ModifyInstanceMetadataOptionsBehavior.Reset()
+	e.ModifyNetworkInterfaceAttributeBehavior.Reset()
+	e.DeleteNetworkInterfaceBehavior.Reset()
+	e.DeleteVolumeBehavior.Reset()
 	e.CalledWithCreateLaunchTemplateInput.Reset()
 	e.CalledWithDescribeImagesInput.Reset()
 	e.DescribeSpotPriceHistoryInput.Reset()
 	e.DescribeSpotPriceHistoryOutput.Reset()
+	e.DescribeReservedInstancesOutput.Reset()
 	e.Instances.Range(func(k, v any) bool {
 		e.Instances.Delete(k)
 		return true
@@ -104,21 +131,29 @@ func (e *EC2API) Reset() {
 		e.LaunchTemplates.Delete(k)
 		return true
 	})
+	e.NetworkInterfaces.Range(func(k, v any) bool {
+		e.NetworkInterfaces.Delete(k)
+		return true
+	})
+	e.Volumes.Range(func(k, v any) bool {
+		e.Volumes.Delete(k)
+		return true
+	})
 	e.InsufficientCapacityPools.Reset()
 	e.NextError.Reset()
 }
 
 // nolint: gocyclo
-func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFleetInput, _ ...request.Option) (*ec2.CreateFleetOutput, error) {
+func (e *EC2API) CreateFleet(_ context.Context, input *ec2.CreateFleetInput, _ ...func(*ec2.Options)) (*ec2.CreateFleetOutput, error) {
 	return e.CreateFleetBehavior.Invoke(input, func(input *ec2.CreateFleetInput) (*ec2.CreateFleetOutput, error) {
 		if input.LaunchTemplateConfigs[0].LaunchTemplateSpecification.LaunchTemplateName == nil {
 			return nil, fmt.Errorf("missing launch template name")
 		}
-		var instanceIds []*string
+		var instanceIds []string
 		var skippedPools []CapacityPool
 		var spotInstanceRequestID *string
 
-		if aws.StringValue(input.TargetCapacitySpecification.DefaultTargetCapacityType) == corev1beta1.CapacityTypeSpot {
+		if string(input.TargetCapacitySpecification.DefaultTargetCapacityType) == corev1beta1.CapacityTypeSpot {
 			spotInstanceRequestID = aws.String(test.RandomName())
 		}
 
@@ -127,9 +162,9 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 			for _, override := range ltc.Overrides {
 				skipInstance := false
 				e.InsufficientCapacityPools.Range(func(pool CapacityPool) bool {
-					if pool.InstanceType == aws.StringValue(override.InstanceType) &&
-						pool.Zone == aws.StringValue(override.AvailabilityZone) &&
-						pool.CapacityType == aws.StringValue(input.TargetCapacitySpecification.DefaultTargetCapacityType) {
+					if pool.InstanceType == string(override.InstanceType) &&
+						pool.Zone == aws.ToString(override.AvailabilityZone) &&
+						pool.CapacityType == string(input.TargetCapacitySpecification.DefaultTargetCapacityType) {
 						skippedPools = append(skippedPools, pool)
 						skipInstance = true
 						return false
@@ -145,34 +180,38 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 					amiID = lt.LaunchTemplateData.ImageId
 					e.CalledWithCreateLaunchTemplateInput.Add(lt)
 				}
-				instanceState := ec2.InstanceStateNameRunning
+				instanceState := ec2types.InstanceStateNameRunning
 				for ; fulfilled < int(*input.TargetCapacitySpecification.TotalTargetCapacity); fulfilled++ {
-					instance := &ec2.Instance{
+					instance := &ec2types.Instance{
 						ImageId:               aws.String(*amiID),
 						InstanceId:            aws.String(test.RandomName()),
-						Placement:             &ec2.Placement{AvailabilityZone: input.LaunchTemplateConfigs[0].Overrides[0].AvailabilityZone},
+						Placement:             &ec2types.Placement{AvailabilityZone: input.LaunchTemplateConfigs[0].Overrides[0].AvailabilityZone},
 						PrivateDnsName:        aws.String(randomdata.IpV4Address()),
 						InstanceType:          input.LaunchTemplateConfigs[0].Overrides[0].InstanceType,
 						SpotInstanceRequestId: spotInstanceRequestID,
-						State: &ec2.InstanceState{
-							Name: &instanceState,
+						State: &ec2types.InstanceState{
+							Name: instanceState,
 						},
 					}
 					e.Instances.Store(*instance.InstanceId, instance)
-					instanceIds = append(instanceIds, instance.InstanceId)
+					instanceIds = append(instanceIds, *instance.InstanceId)
 				}
 			}
 			if fulfilled == int(*input.TargetCapacitySpecification.TotalTargetCapacity) {
 				break
 			}
 		}
-		result := &ec2.CreateFleetOutput{Instances: []*ec2.CreateFleetInstance{
+		result := &ec2.CreateFleetOutput{Instances: []ec2types.CreateFleetInstance{
 			{
 				InstanceIds:  instanceIds,
 				InstanceType: input.LaunchTemplateConfigs[0].Overrides[0].InstanceType,
-				Lifecycle:    input.TargetCapacitySpecification.DefaultTargetCapacityType,
-				LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
-					Overrides: &ec2.FleetLaunchTemplateOverrides{
+				Lifecycle:    ec2types.InstanceLifecycle(input.TargetCapacitySpecification.DefaultTargetCapacityType),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecification{
+						LaunchTemplateName: input.LaunchTemplateConfigs[0].LaunchTemplateSpecification.LaunchTemplateName,
+						Version:            input.LaunchTemplateConfigs[0].LaunchTemplateSpecification.Version,
+					},
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{
 						SubnetId:         input.LaunchTemplateConfigs[0].Overrides[0].SubnetId,
 						ImageId:          input.LaunchTemplateConfigs[0].Overrides[0].ImageId,
 						InstanceType:     input.LaunchTemplateConfigs[0].Overrides[0].InstanceType,
@@ -181,12 +220,13 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 				},
 			},
 		}}
+		awsmiddleware.SetRequestIDMetadata(&result.ResultMetadata, CreateFleetRequestID)
 		for _, pool := range skippedPools {
-			result.Errors = append(result.Errors, &ec2.CreateFleetError{
+			result.Errors = append(result.Errors, ec2types.CreateFleetError{
 				ErrorCode: aws.String("InsufficientInstanceCapacity"),
-				LaunchTemplateAndOverrides: &ec2.LaunchTemplateAndOverridesResponse{
-					Overrides: &ec2.FleetLaunchTemplateOverrides{
-						InstanceType:     aws.String(pool.InstanceType),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{
+						InstanceType:     ec2types.InstanceType(pool.InstanceType),
 						AvailabilityZone: aws.String(pool.Zone),
 					},
 				},
@@ -196,15 +236,14 @@ func (e *EC2API) CreateFleetWithContext(_ context.Context, input *ec2.CreateFlee
 	})
 }
 
-func (e *EC2API) TerminateInstancesWithContext(_ context.Context, input *ec2.TerminateInstancesInput, _ ...request.Option) (*ec2.TerminateInstancesOutput, error) {
+func (e *EC2API) TerminateInstances(_ context.Context, input *ec2.TerminateInstancesInput, _ ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
 	return e.TerminateInstancesBehavior.Invoke(input, func(input *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
-		var instanceStateChanges []*ec2.InstanceStateChange
-		for _, id := range input.InstanceIds {
-			instanceID := *id
+		var instanceStateChanges []ec2types.InstanceStateChange
+		for _, instanceID := range input.InstanceIds {
 			if _, ok := e.Instances.LoadAndDelete(instanceID); ok {
-				instanceStateChanges = append(instanceStateChanges, &ec2.InstanceStateChange{
-					PreviousState: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning), Code: aws.Int64(16)},
-					CurrentState:  &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameShuttingDown), Code: aws.Int64(32)},
+				instanceStateChanges = append(instanceStateChanges, ec2types.InstanceStateChange{
+					PreviousState: &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning, Code: aws.Int32(16)},
+					CurrentState:  &ec2types.InstanceState{Name: ec2types.InstanceStateNameShuttingDown, Code: aws.Int32(32)},
 					InstanceId:    aws.String(instanceID),
 				})
 			}
@@ -213,106 +252,238 @@ func (e *EC2API) TerminateInstancesWithContext(_ context.Context, input *ec2.Ter
 	})
 }
 
-func (e *EC2API) CreateLaunchTemplateWithContext(_ context.Context, input *ec2.CreateLaunchTemplateInput, _ ...request.Option) (*ec2.CreateLaunchTemplateOutput, error) {
+func (e *EC2API) CreateLaunchTemplate(_ context.Context, input *ec2.CreateLaunchTemplateInput, _ ...func(*ec2.Options)) (*ec2.CreateLaunchTemplateOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
 	}
 	e.CalledWithCreateLaunchTemplateInput.Add(input)
-	launchTemplate := &ec2.LaunchTemplate{LaunchTemplateName: input.LaunchTemplateName}
+	launchTemplate := &ec2types.LaunchTemplate{LaunchTemplateName: input.LaunchTemplateName}
 	e.LaunchTemplates.Store(input.LaunchTemplateName, launchTemplate)
 	return &ec2.CreateLaunchTemplateOutput{LaunchTemplate: launchTemplate}, nil
 }
 
-func (e *EC2API) CreateTagsWithContext(_ context.Context, input *ec2.CreateTagsInput, _ ...request.Option) (*ec2.CreateTagsOutput, error) {
+func (e *EC2API) CreateTags(_ context.Context, input *ec2.CreateTagsInput, _ ...func(*ec2.Options)) (*ec2.CreateTagsOutput, error) {
 	return e.CreateTagsBehavior.Invoke(input, func(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
-		// Update passed in instances with the passed tags
+		// Upsert any tags that have the same key
+		tagsToMap := func(tag ec2types.Tag) (string, string) {
+			return *tag.Key, *tag.Value
+		}
+		upsert := func(existing []ec2types.Tag) []ec2types.Tag {
+			tags := lo.Assign(lo.SliceToMap(existing, tagsToMap), lo.SliceToMap(input.Tags, tagsToMap))
+			return lo.MapToSlice(tags, func(key, value string) ec2types.Tag {
+				return ec2types.Tag{Key: aws.String(key), Value: aws.String(value)}
+			})
+		}
+		// Update passed in instances, volumes and network interfaces with the passed tags
 		for _, id := range input.Resources {
-			raw, ok := e.Instances.Load(aws.StringValue(id))
+			if raw, ok := e.Volumes.Load(string(id)); ok {
+				raw.(*ec2types.Volume).Tags = upsert(raw.(*ec2types.Volume).Tags)
+				continue
+			}
+			if raw, ok := e.NetworkInterfaces.Load(string(id)); ok {
+				raw.(*ec2types.NetworkInterface).TagSet = upsert(raw.(*ec2types.NetworkInterface).TagSet)
+				continue
+			}
+			raw, ok := e.Instances.Load(string(id))
 			if !ok {
-				return nil, fmt.Errorf("instance with id '%s' does not exist", aws.StringValue(id))
+				return nil, fmt.Errorf("instance with id '%s' does not exist", string(id))
 			}
-			instance := raw.(*ec2.Instance)
+			raw.(*ec2types.Instance).Tags = upsert(raw.(*ec2types.Instance).Tags)
+		}
+		return nil, nil
+	})
+}
 
-			// Upsert any tags that have the same key
-			tagsToMap := func(tag *ec2.Tag) (string, string) {
-				return *tag.Key, *tag.Value
+func (e *EC2API) DeleteTags(_ context.Context, input *ec2.DeleteTagsInput, _ ...func(*ec2.Options)) (*ec2.DeleteTagsOutput, error) {
+	return e.DeleteTagsBehavior.Invoke(input, func(input *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+		// Remove the passed tag keys from the passed in instances
+		for _, id := range input.Resources {
+			raw, ok := e.Instances.Load(string(id))
+			if !ok {
+				return nil, fmt.Errorf("instance with id '%s' does not exist", string(id))
 			}
-			tags := lo.Assign(lo.SliceToMap(instance.Tags, tagsToMap), lo.SliceToMap(input.Tags, tagsToMap))
-			instance.Tags = lo.MapToSlice(tags, func(key, value string) *ec2.Tag {
-				return &ec2.Tag{Key: aws.String(key), Value: aws.String(value)}
+			instance := raw.(*ec2types.Instance)
+			instance.Tags = lo.Reject(instance.Tags, func(tag ec2types.Tag, _ int) bool {
+				return lo.ContainsBy(input.Tags, func(t ec2types.Tag) bool { return aws.ToString(t.Key) == aws.ToString(tag.Key) })
 			})
 		}
-		return nil, nil
+		return &ec2.DeleteTagsOutput{}, nil
+	})
+}
+
+func (e *EC2API) StopInstances(_ context.Context, input *ec2.StopInstancesInput, _ ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	return e.StopInstancesBehavior.Invoke(input, func(input *ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error) {
+		return &ec2.StopInstancesOutput{StoppingInstances: e.setInstanceStates(input.InstanceIds, ec2types.InstanceStateNameStopped)}, nil
+	})
+}
+
+func (e *EC2API) StartInstances(_ context.Context, input *ec2.StartInstancesInput, _ ...func(*ec2.Options)) (*ec2.StartInstancesOutput, error) {
+	return e.StartInstancesBehavior.Invoke(input, func(input *ec2.StartInstancesInput) (*ec2.StartInstancesOutput, error) {
+		return &ec2.StartInstancesOutput{StartingInstances: e.setInstanceStates(input.InstanceIds, ec2types.InstanceStateNamePending)}, nil
+	})
+}
+
+func (e *EC2API) RebootInstances(_ context.Context, input *ec2.RebootInstancesInput, _ ...func(*ec2.Options)) (*ec2.RebootInstancesOutput, error) {
+	return e.RebootInstancesBehavior.Invoke(input, func(input *ec2.RebootInstancesInput) (*ec2.RebootInstancesOutput, error) {
+		for _, id := range input.InstanceIds {
+			if _, ok := e.Instances.Load(string(id)); !ok {
+				return nil, &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound", Message: fmt.Sprintf("instance %s not found", string(id))}
+			}
+		}
+		return &ec2.RebootInstancesOutput{}, nil
 	})
 }
 
-func (e *EC2API) DescribeInstancesWithContext(_ context.Context, input *ec2.DescribeInstancesInput, _ ...request.Option) (*ec2.DescribeInstancesOutput, error) {
+func (e *EC2API) ModifyInstanceMetadataOptions(_ context.Context, input *ec2.ModifyInstanceMetadataOptionsInput, _ ...func(*ec2.Options)) (*ec2.ModifyInstanceMetadataOptionsOutput, error) {
+	return e.ModifyInstanceMetadataOptionsBehavior.Invoke(input, func(input *ec2.ModifyInstanceMetadataOptionsInput) (*ec2.ModifyInstanceMetadataOptionsOutput, error) {
+		raw, ok := e.Instances.Load(aws.ToString(input.InstanceId))
+		if !ok {
+			return nil, &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound", Message: fmt.Sprintf("instance %s not found", aws.ToString(input.InstanceId))}
+		}
+		metadataOptions := &ec2types.InstanceMetadataOptionsResponse{
+			HttpEndpoint:            input.HttpEndpoint,
+			HttpProtocolIpv6:        input.HttpProtocolIpv6,
+			HttpPutResponseHopLimit: input.HttpPutResponseHopLimit,
+			HttpTokens:              input.HttpTokens,
+			State:                   ec2types.InstanceMetadataOptionsStatePending,
+		}
+		raw.(*ec2types.Instance).MetadataOptions = metadataOptions
+		return &ec2.ModifyInstanceMetadataOptionsOutput{InstanceId: input.InstanceId, InstanceMetadataOptions: metadataOptions}, nil
+	})
+}
+
+func (e *EC2API) ModifyNetworkInterfaceAttribute(_ context.Context, input *ec2.ModifyNetworkInterfaceAttributeInput, _ ...func(*ec2.Options)) (*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
+	return e.ModifyNetworkInterfaceAttributeBehavior.Invoke(input, func(input *ec2.ModifyNetworkInterfaceAttributeInput) (*ec2.ModifyNetworkInterfaceAttributeOutput, error) {
+		groups := lo.Map(input.Groups, func(id string, _ int) ec2types.GroupIdentifier {
+			return ec2types.GroupIdentifier{GroupId: aws.String(id)}
+		})
+		found := false
+		if raw, ok := e.NetworkInterfaces.Load(aws.ToString(input.NetworkInterfaceId)); ok {
+			raw.(*ec2types.NetworkInterface).Groups = groups
+			found = true
+		}
+		// The security groups of an instance are the security groups of its primary network interface
+		e.Instances.Range(func(_, v any) bool {
+			instance := v.(*ec2types.Instance)
+			if len(instance.NetworkInterfaces) > 0 && aws.ToString(instance.NetworkInterfaces[0].NetworkInterfaceId) == aws.ToString(input.NetworkInterfaceId) {
+				instance.NetworkInterfaces[0].Groups = groups
+				instance.SecurityGroups = groups
+				found = true
+			}
+			return true
+		})
+		if !found {
+			return nil, &smithy.GenericAPIError{Code: "InvalidNetworkInterfaceID.NotFound", Message: fmt.Sprintf("network interface %s not found", aws.ToString(input.NetworkInterfaceId))}
+		}
+		return &ec2.ModifyNetworkInterfaceAttributeOutput{}, nil
+	})
+}
+
+// setInstanceStates moves the passed instances to the passed state and returns their state changes
+func (e *EC2API) setInstanceStates(ids []string, state ec2types.InstanceStateName) []ec2types.InstanceStateChange {
+	var instanceStateChanges []ec2types.InstanceStateChange
+	for _, id := range ids {
+		raw, ok := e.Instances.Load(id)
+		if !ok {
+			continue
+		}
+		instance := raw.(*ec2types.Instance)
+		instanceStateChanges = append(instanceStateChanges, ec2types.InstanceStateChange{
+			PreviousState: instance.State,
+			CurrentState:  &ec2types.InstanceState{Name: state},
+			InstanceId:    aws.String(id),
+		})
+		instance.State = &ec2types.InstanceState{Name: state}
+	}
+	return instanceStateChanges
+}
+
+func (e *EC2API) DescribeInstances(_ context.Context, input *ec2.DescribeInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
 	return e.DescribeInstancesBehavior.Invoke(input, func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
-		var instances []*ec2.Instance
+		var instances []*ec2types.Instance
 
 		// If it's a list call and no instance ids are specified
-		if len(aws.StringValueSlice(input.InstanceIds)) == 0 {
+		if len(input.InstanceIds) == 0 {
 			e.Instances.Range(func(k interface{}, v interface{}) bool {
-				instances = append(instances, v.(*ec2.Instance))
+				instances = append(instances, v.(*ec2types.Instance))
 				return true
 			})
 		}
 		for _, instanceID := range input.InstanceIds {
-			instance, _ := e.Instances.Load(*instanceID)
+			instance, _ := e.Instances.Load(instanceID)
 			if instance == nil {
 				continue
 			}
-			instances = append(instances, instance.(*ec2.Instance))
+			instances = append(instances, instance.(*ec2types.Instance))
 		}
 		return &ec2.DescribeInstancesOutput{
-			Reservations: []*ec2.Reservation{{Instances: filterInstances(instances, input.Filters)}},
+			Reservations: []ec2types.Reservation{{Instances: filterInstances(instances, input.Filters)}},
 		}, nil
 	})
 }
 
-func (e *EC2API) DescribeInstancesPagesWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error {
-	output, err := e.DescribeInstancesWithContext(ctx, input, opts...)
-	if err != nil {
-		return err
-	}
-	fn(output, false)
-	return nil
+func (e *EC2API) DescribeInstanceStatus(_ context.Context, input *ec2.DescribeInstanceStatusInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceStatusOutput, error) {
+	return e.DescribeInstanceStatusBehavior.Invoke(input, func(_ *ec2.DescribeInstanceStatusInput) (*ec2.DescribeInstanceStatusOutput, error) {
+		return &ec2.DescribeInstanceStatusOutput{}, nil
+	})
+}
+
+func (e *EC2API) GetConsoleOutput(_ context.Context, input *ec2.GetConsoleOutputInput, _ ...func(*ec2.Options)) (*ec2.GetConsoleOutputOutput, error) {
+	return e.GetConsoleOutputBehavior.Invoke(input, func(input *ec2.GetConsoleOutputInput) (*ec2.GetConsoleOutputOutput, error) {
+		return &ec2.GetConsoleOutputOutput{InstanceId: input.InstanceId}, nil
+	})
+}
+
+func (e *EC2API) GetConsoleScreenshot(_ context.Context, input *ec2.GetConsoleScreenshotInput, _ ...func(*ec2.Options)) (*ec2.GetConsoleScreenshotOutput, error) {
+	return e.GetConsoleScreenshotBehavior.Invoke(input, func(input *ec2.GetConsoleScreenshotInput) (*ec2.GetConsoleScreenshotOutput, error) {
+		return &ec2.GetConsoleScreenshotOutput{InstanceId: input.InstanceId}, nil
+	})
 }
 
 //nolint:gocyclo
-func filterInstances(instances []*ec2.Instance, filters []*ec2.Filter) []*ec2.Instance {
-	var ret []*ec2.Instance
+func filterInstances(instances []*ec2types.Instance, filters []ec2types.Filter) []ec2types.Instance {
+	var ret []ec2types.Instance
 	for _, instance := range instances {
 		passesFilter := true
 	OUTER:
 		for _, filter := range filters {
 			switch {
-			case aws.StringValue(filter.Name) == "instance-state-name":
-				if !sets.New(aws.StringValueSlice(filter.Values)...).Has(aws.StringValue(instance.State.Name)) {
+			case aws.ToString(filter.Name) == "instance-id":
+				if !sets.New(filter.Values...).Has(aws.ToString(instance.InstanceId)) {
 					passesFilter = false
 					break OUTER
 				}
-			case aws.StringValue(filter.Name) == "tag-key":
-				values := sets.New(aws.StringValueSlice(filter.Values)...)
-				if _, ok := lo.Find(instance.Tags, func(t *ec2.Tag) bool {
-					return values.Has(aws.StringValue(t.Key))
+			case aws.ToString(filter.Name) == "instance-state-name":
+				if !sets.New(filter.Values...).Has(string(instance.State.Name)) {
+					passesFilter = false
+					break OUTER
+				}
+			case aws.ToString(filter.Name) == "availability-zone":
+				if instance.Placement == nil || !sets.New(filter.Values...).Has(aws.ToString(instance.Placement.AvailabilityZone)) {
+					passesFilter = false
+					break OUTER
+				}
+			case aws.ToString(filter.Name) == "tag-key":
+				values := sets.New(filter.Values...)
+				if _, ok := lo.Find(instance.Tags, func(t ec2types.Tag) bool {
+					return values.Has(aws.ToString(t.Key))
 				}); !ok {
 					passesFilter = false
 					break OUTER
 				}
-			case strings.HasPrefix(aws.StringValue(filter.Name), "tag:"):
-				k := strings.TrimPrefix(aws.StringValue(filter.Name), "tag:")
-				tag, ok := lo.Find(instance.Tags, func(t *ec2.Tag) bool {
-					return aws.StringValue(t.Key) == k
+			case strings.HasPrefix(aws.ToString(filter.Name), "tag:"):
+				k := strings.TrimPrefix(aws.ToString(filter.Name), "tag:")
+				tag, ok := lo.Find(instance.Tags, func(t ec2types.Tag) bool {
+					return aws.ToString(t.Key) == k
 				})
 				if !ok {
 					passesFilter = false
 					break OUTER
 				}
 				switch {
-				case lo.Contains(aws.StringValueSlice(filter.Values), "*"):
-				case lo.Contains(aws.StringValueSlice(filter.Values), aws.StringValue(tag.Value)):
+				case lo.Contains(filter.Values, "*"):
+				case lo.Contains(filter.Values, aws.ToString(tag.Value)):
 				default:
 					passesFilter = false
 					break OUTER
@@ -320,13 +491,87 @@ func filterInstances(instances []*ec2.Instance, filters []*ec2.Filter) []*ec2.In
 			}
 		}
 		if passesFilter {
-			ret = append(ret, instance)
+			ret = append(ret, *instance)
 		}
 	}
 	return ret
 }
 
-func (e *EC2API) DescribeImagesWithContext(_ context.Context, input *ec2.DescribeImagesInput, _ ...request.Option) (*ec2.DescribeImagesOutput, error) {
+func (e *EC2API) DescribeNetworkInterfaces(_ context.Context, input *ec2.DescribeNetworkInterfacesInput, _ ...func(*ec2.Options)) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	var networkInterfaces []ec2types.NetworkInterface
+	e.NetworkInterfaces.Range(func(_, v any) bool {
+		networkInterface := v.(*ec2types.NetworkInterface)
+		if len(input.NetworkInterfaceIds) > 0 && !lo.Contains(input.NetworkInterfaceIds, aws.ToString(networkInterface.NetworkInterfaceId)) {
+			return true
+		}
+		if lo.EveryBy(input.Filters, func(f ec2types.Filter) bool {
+			if aws.ToString(f.Name) == "status" {
+				return lo.Contains(f.Values, string(networkInterface.Status))
+			}
+			if aws.ToString(f.Name) == "attachment.instance-id" {
+				return networkInterface.Attachment != nil && lo.Contains(f.Values, aws.ToString(networkInterface.Attachment.InstanceId))
+			}
+			return Filter([]ec2types.Filter{f}, aws.ToString(networkInterface.NetworkInterfaceId), "", networkInterface.TagSet)
+		}) {
+			networkInterfaces = append(networkInterfaces, *networkInterface)
+		}
+		return true
+	})
+	return &ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: networkInterfaces}, nil
+}
+
+func (e *EC2API) DeleteNetworkInterface(_ context.Context, input *ec2.DeleteNetworkInterfaceInput, _ ...func(*ec2.Options)) (*ec2.DeleteNetworkInterfaceOutput, error) {
+	return e.DeleteNetworkInterfaceBehavior.Invoke(input, func(input *ec2.DeleteNetworkInterfaceInput) (*ec2.DeleteNetworkInterfaceOutput, error) {
+		if _, ok := e.NetworkInterfaces.LoadAndDelete(aws.ToString(input.NetworkInterfaceId)); !ok {
+			return nil, &smithy.GenericAPIError{Code: "InvalidNetworkInterfaceID.NotFound", Message: fmt.Sprintf("network interface %s not found", aws.ToString(input.NetworkInterfaceId))}
+		}
+		return &ec2.DeleteNetworkInterfaceOutput{}, nil
+	})
+}
+
+func (e *EC2API) DescribeVolumes(_ context.Context, input *ec2.DescribeVolumesInput, _ ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	var volumes []ec2types.Volume
+	e.Volumes.Range(func(_, v any) bool {
+		volume := v.(*ec2types.Volume)
+		if len(input.VolumeIds) > 0 && !lo.Contains(input.VolumeIds, aws.ToString(volume.VolumeId)) {
+			return true
+		}
+		if lo.EveryBy(input.Filters, func(f ec2types.Filter) bool {
+			if aws.ToString(f.Name) == "status" {
+				return lo.Contains(f.Values, string(volume.State))
+			}
+			if aws.ToString(f.Name) == "attachment.instance-id" {
+				return lo.ContainsBy(volume.Attachments, func(a ec2types.VolumeAttachment) bool {
+					return lo.Contains(f.Values, aws.ToString(a.InstanceId))
+				})
+			}
+			return Filter([]ec2types.Filter{f}, aws.ToString(volume.VolumeId), "", volume.Tags)
+		}) {
+			volumes = append(volumes, *volume)
+		}
+		return true
+	})
+	return &ec2.DescribeVolumesOutput{Volumes: volumes}, nil
+}
+
+func (e *EC2API) DeleteVolume(_ context.Context, input *ec2.DeleteVolumeInput, _ ...func(*ec2.Options)) (*ec2.DeleteVolumeOutput, error) {
+	return e.DeleteVolumeBehavior.Invoke(input, func(input *ec2.DeleteVolumeInput) (*ec2.DeleteVolumeOutput, error) {
+		if _, ok := e.Volumes.LoadAndDelete(aws.ToString(input.VolumeId)); !ok {
+			return nil, &smithy.GenericAPIError{Code: "InvalidVolume.NotFound", Message: fmt.Sprintf("volume %s not found", aws.ToString(input.VolumeId))}
+		}
+		return &ec2.DeleteVolumeOutput{}, nil
+	})
+}
+
+func (e *EC2API) DescribeImages(_ context.Context, input *ec2.DescribeImagesInput, _ ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -337,31 +582,22 @@ func (e *EC2API) DescribeImagesWithContext(_ context.Context, input *ec2.Describ
 		describeImagesOutput.Images = FilterDescribeImages(describeImagesOutput.Images, input.Filters)
 		return describeImagesOutput, nil
 	}
-	if aws.StringValue(input.Filters[0].Values[0]) == "invalid" {
+	if input.Filters[0].Values[0] == "invalid" {
 		return &ec2.DescribeImagesOutput{}, nil
 	}
 	return &ec2.DescribeImagesOutput{
-		Images: []*ec2.Image{
+		Images: []ec2types.Image{
 			{
 				Name:         aws.String(test.RandomName()),
 				ImageId:      aws.String(test.RandomName()),
 				CreationDate: aws.String(time.Now().Format(time.UnixDate)),
-				Architecture: aws.String("x86_64"),
+				Architecture: ec2types.ArchitectureValuesX8664,
 			},
 		},
 	}, nil
 }
 
-func (e *EC2API) DescribeImagesPagesWithContext(ctx context.Context, input *ec2.DescribeImagesInput, fn func(*ec2.DescribeImagesOutput, bool) bool, _ ...request.Option) error {
-	out, err := e.DescribeImagesWithContext(ctx, input)
-	if err != nil {
-		return err
-	}
-	fn(out, false)
-	return nil
-}
-
-func (e *EC2API) DescribeLaunchTemplatesWithContext(_ context.Context, input *ec2.DescribeLaunchTemplatesInput, _ ...request.Option) (*ec2.DescribeLaunchTemplatesOutput, error) {
+func (e *EC2API) DescribeLaunchTemplates(_ context.Context, input *ec2.DescribeLaunchTemplatesInput, _ ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplatesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -371,31 +607,24 @@ func (e *EC2API) DescribeLaunchTemplatesWithContext(_ context.Context, input *ec
 	}
 	output := &ec2.DescribeLaunchTemplatesOutput{}
 	e.LaunchTemplates.Range(func(key, value interface{}) bool {
-		launchTemplate := value.(*ec2.LaunchTemplate)
-		if lo.Contains(aws.StringValueSlice(input.LaunchTemplateNames), aws.StringValue(launchTemplate.LaunchTemplateName)) || len(input.Filters) != 0 && Filter(input.Filters, aws.StringValue(launchTemplate.LaunchTemplateId), aws.StringValue(launchTemplate.LaunchTemplateName), launchTemplate.Tags) {
-			output.LaunchTemplates = append(output.LaunchTemplates, launchTemplate)
+		launchTemplate := value.(*ec2types.LaunchTemplate)
+		// launch templates are listed when neither names nor filters are set
+		listing := len(input.LaunchTemplateNames) == 0 && len(input.Filters) == 0
+		if listing || lo.Contains(input.LaunchTemplateNames, aws.ToString(launchTemplate.LaunchTemplateName)) || len(input.Filters) != 0 && Filter(input.Filters, aws.ToString(launchTemplate.LaunchTemplateId), aws.ToString(launchTemplate.LaunchTemplateName), launchTemplate.Tags) {
+			output.LaunchTemplates = append(output.LaunchTemplates, *launchTemplate)
 		}
 		return true
 	})
-	if len(input.Filters) != 0 {
+	if len(input.Filters) != 0 || len(input.LaunchTemplateNames) == 0 {
 		return output, nil
 	}
 	if len(output.LaunchTemplates) == 0 {
-		return nil, awserr.New("InvalidLaunchTemplateName.NotFoundException", "not found", nil)
+		return nil, &smithy.GenericAPIError{Code: "InvalidLaunchTemplateName.NotFoundException", Message: "not found"}
 	}
 	return output, nil
 }
 
-func (e *EC2API) DescribeLaunchTemplatesPagesWithContext(ctx context.Context, input *ec2.DescribeLaunchTemplatesInput, fn func(*ec2.DescribeLaunchTemplatesOutput, bool) bool, _ ...request.Option) error {
-	out, err := e.DescribeLaunchTemplatesWithContext(ctx, input)
-	if err != nil {
-		return err
-	}
-	fn(out, false)
-	return nil
-}
-
-func (e *EC2API) DeleteLaunchTemplateWithContext(_ context.Context, input *ec2.DeleteLaunchTemplateInput, _ ...request.Option) (*ec2.DeleteLaunchTemplateOutput, error) {
+func (e *EC2API) DeleteLaunchTemplate(_ context.Context, input *ec2.DeleteLaunchTemplateInput, _ ...func(*ec2.Options)) (*ec2.DeleteLaunchTemplateOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -404,7 +633,7 @@ func (e *EC2API) DeleteLaunchTemplateWithContext(_ context.Context, input *ec2.D
 	return nil, nil
 }
 
-func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.DescribeSubnetsInput, _ ...request.Option) (*ec2.DescribeSubnetsOutput, error) {
+func (e *EC2API) DescribeSubnets(_ context.Context, input *ec2.DescribeSubnetsInput, _ ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -414,13 +643,14 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		describeSubnetsOutput.Subnets = FilterDescribeSubnets(describeSubnetsOutput.Subnets, input.Filters)
 		return describeSubnetsOutput, nil
 	}
-	subnets := []*ec2.Subnet{
+	subnets := []ec2types.Subnet{
 		{
 			SubnetId:                aws.String("subnet-test1"),
 			AvailabilityZone:        aws.String("test-zone-1a"),
-			AvailableIpAddressCount: aws.Int64(100),
+			AvailabilityZoneId:      aws.String("testzone1a"),
+			AvailableIpAddressCount: aws.Int32(100),
 			MapPublicIpOnLaunch:     aws.Bool(false),
-			Tags: []*ec2.Tag{
+			Tags: []ec2types.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-subnet-1")},
 				{Key: aws.String("foo"), Value: aws.String("bar")},
 			},
@@ -428,9 +658,13 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		{
 			SubnetId:                aws.String("subnet-test2"),
 			AvailabilityZone:        aws.String("test-zone-1b"),
-			AvailableIpAddressCount: aws.Int64(100),
-			MapPublicIpOnLaunch:     aws.Bool(true),
-			Tags: []*ec2.Tag{
+			AvailabilityZoneId:      aws.String("testzone1b"),
+			AvailableIpAddressCount: aws.Int32(100),
+			Ipv6CidrBlockAssociationSet: []ec2types.SubnetIpv6CidrBlockAssociation{
+				{Ipv6CidrBlock: aws.String("2001:db8::/64"), Ipv6CidrBlockState: &ec2types.SubnetCidrBlockState{State: ec2types.SubnetCidrBlockStateCodeAssociated}},
+			},
+			MapPublicIpOnLaunch: aws.Bool(true),
+			Tags: []ec2types.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-subnet-2")},
 				{Key: aws.String("foo"), Value: aws.String("bar")},
 			},
@@ -438,8 +672,9 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		{
 			SubnetId:                aws.String("subnet-test3"),
 			AvailabilityZone:        aws.String("test-zone-1c"),
-			AvailableIpAddressCount: aws.Int64(100),
-			Tags: []*ec2.Tag{
+			AvailabilityZoneId:      aws.String("testzone1c"),
+			AvailableIpAddressCount: aws.Int32(100),
+			Tags: []ec2types.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-subnet-3")},
 				{Key: aws.String("TestTag")},
 				{Key: aws.String("foo"), Value: aws.String("bar")},
@@ -448,9 +683,10 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 		{
 			SubnetId:                aws.String("subnet-test4"),
 			AvailabilityZone:        aws.String("test-zone-1a-local"),
-			AvailableIpAddressCount: aws.Int64(100),
+			AvailabilityZoneId:      aws.String("testzone1alocal"),
+			AvailableIpAddressCount: aws.Int32(100),
 			MapPublicIpOnLaunch:     aws.Bool(true),
-			Tags: []*ec2.Tag{
+			Tags: []ec2types.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-subnet-4")},
 			},
 		},
@@ -461,7 +697,7 @@ func (e *EC2API) DescribeSubnetsWithContext(_ context.Context, input *ec2.Descri
 	return &ec2.DescribeSubnetsOutput{Subnets: FilterDescribeSubnets(subnets, input.Filters)}, nil
 }
 
-func (e *EC2API) DescribeSecurityGroupsWithContext(_ context.Context, input *ec2.DescribeSecurityGroupsInput, _ ...request.Option) (*ec2.DescribeSecurityGroupsOutput, error) {
+func (e *EC2API) DescribeSecurityGroups(_ context.Context, input *ec2.DescribeSecurityGroupsInput, _ ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -471,11 +707,11 @@ func (e *EC2API) DescribeSecurityGroupsWithContext(_ context.Context, input *ec2
 		describeSecurityGroupsOutput.SecurityGroups = FilterDescribeSecurtyGroups(describeSecurityGroupsOutput.SecurityGroups, input.Filters)
 		return e.DescribeSecurityGroupsOutput.Clone(), nil
 	}
-	sgs := []*ec2.SecurityGroup{
+	sgs := []ec2types.SecurityGroup{
 		{
 			GroupId:   aws.String("sg-test1"),
 			GroupName: aws.String("securityGroup-test1"),
-			Tags: []*ec2.Tag{
+			Tags: []ec2types.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-security-group-1")},
 				{Key: aws.String("foo"), Value: aws.String("bar")},
 			},
@@ -483,7 +719,7 @@ func (e *EC2API) DescribeSecurityGroupsWithContext(_ context.Context, input *ec2
 		{
 			GroupId:   aws.String("sg-test2"),
 			GroupName: aws.String("securityGroup-test2"),
-			Tags: []*ec2.Tag{
+			Tags: []ec2types.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-security-group-2")},
 				{Key: aws.String("foo"), Value: aws.String("bar")},
 			},
@@ -491,7 +727,7 @@ func (e *EC2API) DescribeSecurityGroupsWithContext(_ context.Context, input *ec2
 		{
 			GroupId:   aws.String("sg-test3"),
 			GroupName: aws.String("securityGroup-test3"),
-			Tags: []*ec2.Tag{
+			Tags: []ec2types.Tag{
 				{Key: aws.String("Name"), Value: aws.String("test-security-group-3")},
 				{Key: aws.String("TestTag")},
 				{Key: aws.String("foo"), Value: aws.String("bar")},
@@ -504,7 +740,7 @@ func (e *EC2API) DescribeSecurityGroupsWithContext(_ context.Context, input *ec2
 	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: FilterDescribeSecurtyGroups(sgs, input.Filters)}, nil
 }
 
-func (e *EC2API) DescribeAvailabilityZonesWithContext(context.Context, *ec2.DescribeAvailabilityZonesInput, ...request.Option) (*ec2.DescribeAvailabilityZonesOutput, error) {
+func (e *EC2API) DescribeAvailabilityZones(context.Context, *ec2.DescribeAvailabilityZonesInput, ...func(*ec2.Options)) (*ec2.DescribeAvailabilityZonesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -512,7 +748,7 @@ func (e *EC2API) DescribeAvailabilityZonesWithContext(context.Context, *ec2.Desc
 	if !e.DescribeAvailabilityZonesOutput.IsNil() {
 		return e.DescribeAvailabilityZonesOutput.Clone(), nil
 	}
-	return &ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []*ec2.AvailabilityZone{
+	return &ec2.DescribeAvailabilityZonesOutput{AvailabilityZones: []ec2types.AvailabilityZone{
 		{ZoneName: aws.String("test-zone-1a"), ZoneId: aws.String("testzone1a"), ZoneType: aws.String("availability-zone")},
 		{ZoneName: aws.String("test-zone-1b"), ZoneId: aws.String("testzone1b"), ZoneType: aws.String("availability-zone")},
 		{ZoneName: aws.String("test-zone-1c"), ZoneId: aws.String("testzone1c"), ZoneType: aws.String("availability-zone")},
@@ -520,7 +756,7 @@ func (e *EC2API) DescribeAvailabilityZonesWithContext(context.Context, *ec2.Desc
 	}}, nil
 }
 
-func (e *EC2API) DescribeInstanceTypesWithContext(_ context.Context, _ *ec2.DescribeInstanceTypesInput, _ ...request.Option) (*ec2.DescribeInstanceTypesOutput, error) {
+func (e *EC2API) DescribeInstanceTypes(_ context.Context, _ *ec2.DescribeInstanceTypesInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypesOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -531,16 +767,7 @@ func (e *EC2API) DescribeInstanceTypesWithContext(_ context.Context, _ *ec2.Desc
 	return defaultDescribeInstanceTypesOutput, nil
 }
 
-func (e *EC2API) DescribeInstanceTypesPagesWithContext(ctx context.Context, input *ec2.DescribeInstanceTypesInput, fn func(*ec2.DescribeInstanceTypesOutput, bool) bool, _ ...request.Option) error {
-	out, err := e.DescribeInstanceTypesWithContext(ctx, input)
-	if err != nil {
-		return err
-	}
-	fn(out, false)
-	return nil
-}
-
-func (e *EC2API) DescribeInstanceTypeOfferingsWithContext(_ context.Context, _ *ec2.DescribeInstanceTypeOfferingsInput, _ ...request.Option) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
+func (e *EC2API) DescribeInstanceTypeOfferings(_ context.Context, _ *ec2.DescribeInstanceTypeOfferingsInput, _ ...func(*ec2.Options)) (*ec2.DescribeInstanceTypeOfferingsOutput, error) {
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
 		return nil, e.NextError.Get()
@@ -549,129 +776,120 @@ func (e *EC2API) DescribeInstanceTypeOfferingsWithContext(_ context.Context, _ *
 		return e.DescribeInstanceTypeOfferingsOutput.Clone(), nil
 	}
 	return &ec2.DescribeInstanceTypeOfferingsOutput{
-		InstanceTypeOfferings: []*ec2.InstanceTypeOffering{
+		InstanceTypeOfferings: []ec2types.InstanceTypeOffering{
 			{
-				InstanceType: aws.String("m5.large"),
+				InstanceType: "m5.large",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("m5.large"),
+				InstanceType: "m5.large",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("m5.large"),
+				InstanceType: "m5.large",
 				Location:     aws.String("test-zone-1c"),
 			},
 			{
-				InstanceType: aws.String("m5.large"),
+				InstanceType: "m5.large",
 				Location:     aws.String("test-zone-1a-local"),
 			},
 			{
-				InstanceType: aws.String("m5.xlarge"),
+				InstanceType: "m5.xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("m5.xlarge"),
+				InstanceType: "m5.xlarge",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("m5.2xlarge"),
+				InstanceType: "m5.2xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("m5.4xlarge"),
+				InstanceType: "m5.4xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("m5.8xlarge"),
+				InstanceType: "m5.8xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("p3.8xlarge"),
+				InstanceType: "p3.8xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("p3.8xlarge"),
+				InstanceType: "p3.8xlarge",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("dl1.24xlarge"),
+				InstanceType: "dl1.24xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("dl1.24xlarge"),
+				InstanceType: "dl1.24xlarge",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("g4dn.8xlarge"),
+				InstanceType: "g4dn.8xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("g4dn.8xlarge"),
+				InstanceType: "g4dn.8xlarge",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("t3.large"),
+				InstanceType: "t3.large",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("t3.large"),
+				InstanceType: "t3.large",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("inf1.2xlarge"),
+				InstanceType: "inf1.2xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("inf1.6xlarge"),
+				InstanceType: "inf1.6xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("trn1.2xlarge"),
+				InstanceType: "trn1.2xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("c6g.large"),
+				InstanceType: "c6g.large",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("m5.metal"),
+				InstanceType: "m5.metal",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("m5.metal"),
+				InstanceType: "m5.metal",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("m5.metal"),
+				InstanceType: "m5.metal",
 				Location:     aws.String("test-zone-1c"),
 			},
 			{
-				InstanceType: aws.String("m6idn.32xlarge"),
+				InstanceType: "m6idn.32xlarge",
 				Location:     aws.String("test-zone-1a"),
 			},
 			{
-				InstanceType: aws.String("m6idn.32xlarge"),
+				InstanceType: "m6idn.32xlarge",
 				Location:     aws.String("test-zone-1b"),
 			},
 			{
-				InstanceType: aws.String("m6idn.32xlarge"),
+				InstanceType: "m6idn.32xlarge",
 				Location:     aws.String("test-zone-1c"),
 			},
 		},
 	}, nil
 }
 
-func (e *EC2API) DescribeInstanceTypeOfferingsPagesWithContext(ctx context.Context, input *ec2.DescribeInstanceTypeOfferingsInput, fn func(*ec2.DescribeInstanceTypeOfferingsOutput, bool) bool, _ ...request.Option) error {
-	out, err := e.DescribeInstanceTypeOfferingsWithContext(ctx, input)
-	if err != nil {
-		return err
-	}
-	fn(out, false)
-	return nil
-}
-
-func (e *EC2API) DescribeSpotPriceHistoryWithContext(_ aws.Context, input *ec2.DescribeSpotPriceHistoryInput, _ ...request.Option) (*ec2.DescribeSpotPriceHistoryOutput, error) {
+func (e *EC2API) DescribeSpotPriceHistory(_ context.Context, input *ec2.DescribeSpotPriceHistoryInput, _ ...func(*ec2.Options)) (*ec2.DescribeSpotPriceHistoryOutput, error) {
 	e.DescribeSpotPriceHistoryInput.Set(input)
 	if !e.NextError.IsNil() {
 		defer e.NextError.Reset()
@@ -684,11 +902,13 @@ func (e *EC2API) DescribeSpotPriceHistoryWithContext(_ aws.Context, input *ec2.D
 	return nil, errors.New("no pricing data provided")
 }
 
-func (e *EC2API) DescribeSpotPriceHistoryPagesWithContext(ctx aws.Context, input *ec2.DescribeSpotPriceHistoryInput, fn func(*ec2.DescribeSpotPriceHistoryOutput, bool) bool, _ ...request.Option) error {
-	out, err := e.DescribeSpotPriceHistoryWithContext(ctx, input)
-	if err != nil {
-		return err
+func (e *EC2API) DescribeReservedInstances(_ context.Context, _ *ec2.DescribeReservedInstancesInput, _ ...func(*ec2.Options)) (*ec2.DescribeReservedInstancesOutput, error) {
+	if !e.NextError.IsNil() {
+		defer e.NextError.Reset()
+		return nil, e.NextError.Get()
+	}
+	if !e.DescribeReservedInstancesOutput.IsNil() {
+		return e.DescribeReservedInstancesOutput.Clone(), nil
 	}
-	fn(out, false)
-	return nil
+	return &ec2.DescribeReservedInstancesOutput{}, nil
 }
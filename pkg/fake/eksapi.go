@@ -16,11 +16,14 @@ package fake
 
 import (
 	"context"
+	"sync"
 
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/service/eks"
-	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
 
 const ()
@@ -28,32 +31,73 @@ const ()
 // EKSAPIBehavior must be reset between tests otherwise tests will
 // pollute each other.
 type EKSAPIBehavior struct {
-	DescribeClusterBehavior MockedFunction[eks.DescribeClusterInput, eks.DescribeClusterOutput]
+	DescribeClusterBehavior   MockedFunction[eks.DescribeClusterInput, eks.DescribeClusterOutput]
+	CreateAccessEntryBehavior MockedFunction[eks.CreateAccessEntryInput, eks.CreateAccessEntryOutput]
+	DeleteAccessEntryBehavior MockedFunction[eks.DeleteAccessEntryInput, eks.DeleteAccessEntryOutput]
 }
 
 type EKSAPI struct {
-	eksiface.EKSAPI
+	sync.Mutex
+
+	sdk.EKSAPI
 	EKSAPIBehavior
+
+	AccessEntries map[string]*ekstypes.AccessEntry
 }
 
 func NewEKSAPI() *EKSAPI {
-	return &EKSAPI{}
+	return &EKSAPI{AccessEntries: map[string]*ekstypes.AccessEntry{}}
 }
 
 // Reset must be called between tests otherwise tests will pollute
 // each other.
 func (s *EKSAPI) Reset() {
 	s.DescribeClusterBehavior.Reset()
+	s.CreateAccessEntryBehavior.Reset()
+	s.DeleteAccessEntryBehavior.Reset()
+	s.AccessEntries = map[string]*ekstypes.AccessEntry{}
 }
 
-func (s *EKSAPI) DescribeClusterWithContext(_ context.Context, input *eks.DescribeClusterInput, _ ...request.Option) (*eks.DescribeClusterOutput, error) {
+func (s *EKSAPI) DescribeCluster(_ context.Context, input *eks.DescribeClusterInput, _ ...func(*eks.Options)) (*eks.DescribeClusterOutput, error) {
 	return s.DescribeClusterBehavior.Invoke(input, func(*eks.DescribeClusterInput) (*eks.DescribeClusterOutput, error) {
 		return &eks.DescribeClusterOutput{
-			Cluster: &eks.Cluster{
-				KubernetesNetworkConfig: &eks.KubernetesNetworkConfigResponse{
+			Cluster: &ekstypes.Cluster{
+				KubernetesNetworkConfig: &ekstypes.KubernetesNetworkConfigResponse{
 					ServiceIpv4Cidr: lo.ToPtr("10.100.0.0/16"),
 				},
 			},
 		}, nil
 	})
 }
+
+func (s *EKSAPI) CreateAccessEntry(_ context.Context, input *eks.CreateAccessEntryInput, _ ...func(*eks.Options)) (*eks.CreateAccessEntryOutput, error) {
+	return s.CreateAccessEntryBehavior.Invoke(input, func(*eks.CreateAccessEntryInput) (*eks.CreateAccessEntryOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if _, ok := s.AccessEntries[aws.ToString(input.PrincipalArn)]; ok {
+			return nil, &ekstypes.ResourceInUseException{Message: aws.String("The specified access entry resource is already in use on this cluster.")}
+		}
+		accessEntry := &ekstypes.AccessEntry{
+			ClusterName:  input.ClusterName,
+			PrincipalArn: input.PrincipalArn,
+			Tags:         input.Tags,
+			Type:         input.Type,
+		}
+		s.AccessEntries[aws.ToString(input.PrincipalArn)] = accessEntry
+		return &eks.CreateAccessEntryOutput{AccessEntry: accessEntry}, nil
+	})
+}
+
+func (s *EKSAPI) DeleteAccessEntry(_ context.Context, input *eks.DeleteAccessEntryInput, _ ...func(*eks.Options)) (*eks.DeleteAccessEntryOutput, error) {
+	return s.DeleteAccessEntryBehavior.Invoke(input, func(*eks.DeleteAccessEntryInput) (*eks.DeleteAccessEntryOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if _, ok := s.AccessEntries[aws.ToString(input.PrincipalArn)]; !ok {
+			return nil, &ekstypes.ResourceNotFoundException{Message: aws.String("The specified access entry resource can't be found.")}
+		}
+		delete(s.AccessEntries, aws.ToString(input.PrincipalArn))
+		return &eks.DeleteAccessEntryOutput{}, nil
+	})
+}
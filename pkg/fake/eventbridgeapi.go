@@ -0,0 +1,82 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Pallinder/go-randomdata"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// EventBridgeBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type EventBridgeBehavior struct {
+	PutRuleBehavior     MockedFunction[eventbridge.PutRuleInput, eventbridge.PutRuleOutput]
+	TagResourceBehavior MockedFunction[eventbridge.TagResourceInput, eventbridge.TagResourceOutput]
+	PutTargetsBehavior  MockedFunction[eventbridge.PutTargetsInput, eventbridge.PutTargetsOutput]
+	PutEventsBehavior   MockedFunction[eventbridge.PutEventsInput, eventbridge.PutEventsOutput]
+}
+
+type EventBridgeAPI struct {
+	sdk.EventBridgeAPI
+	EventBridgeBehavior
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (e *EventBridgeAPI) Reset() {
+	e.PutRuleBehavior.Reset()
+	e.TagResourceBehavior.Reset()
+	e.PutTargetsBehavior.Reset()
+	e.PutEventsBehavior.Reset()
+}
+
+func (e *EventBridgeAPI) PutRule(_ context.Context, input *eventbridge.PutRuleInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutRuleOutput, error) {
+	return e.PutRuleBehavior.Invoke(input, func(input *eventbridge.PutRuleInput) (*eventbridge.PutRuleOutput, error) {
+		return &eventbridge.PutRuleOutput{
+			RuleArn: aws.String(fmt.Sprintf("arn:aws:events:%s:000000000000:rule/%s", DefaultRegion, aws.ToString(input.Name))),
+		}, nil
+	})
+}
+
+func (e *EventBridgeAPI) TagResource(_ context.Context, input *eventbridge.TagResourceInput, _ ...func(*eventbridge.Options)) (*eventbridge.TagResourceOutput, error) {
+	return e.TagResourceBehavior.Invoke(input, func(_ *eventbridge.TagResourceInput) (*eventbridge.TagResourceOutput, error) {
+		return &eventbridge.TagResourceOutput{}, nil
+	})
+}
+
+func (e *EventBridgeAPI) PutTargets(_ context.Context, input *eventbridge.PutTargetsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutTargetsOutput, error) {
+	return e.PutTargetsBehavior.Invoke(input, func(_ *eventbridge.PutTargetsInput) (*eventbridge.PutTargetsOutput, error) {
+		return &eventbridge.PutTargetsOutput{FailedEntryCount: 0}, nil
+	})
+}
+
+func (e *EventBridgeAPI) PutEvents(_ context.Context, input *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	return e.PutEventsBehavior.Invoke(input, func(input *eventbridge.PutEventsInput) (*eventbridge.PutEventsOutput, error) {
+		return &eventbridge.PutEventsOutput{
+			FailedEntryCount: 0,
+			Entries: lo.Map(input.Entries, func(_ eventbridgetypes.PutEventsRequestEntry, _ int) eventbridgetypes.PutEventsResultEntry {
+				return eventbridgetypes.PutEventsResultEntry{EventId: aws.String(randomdata.Alphanumeric(16))}
+			}),
+		}, nil
+	})
+}
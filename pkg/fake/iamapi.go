@@ -20,12 +20,13 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/service/iam"
-	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
 
 const ()
@@ -38,19 +39,35 @@ type IAMAPIBehavior struct {
 	DeleteInstanceProfileBehavior         MockedFunction[iam.DeleteInstanceProfileInput, iam.DeleteInstanceProfileOutput]
 	AddRoleToInstanceProfileBehavior      MockedFunction[iam.AddRoleToInstanceProfileInput, iam.AddRoleToInstanceProfileOutput]
 	RemoveRoleFromInstanceProfileBehavior MockedFunction[iam.RemoveRoleFromInstanceProfileInput, iam.RemoveRoleFromInstanceProfileOutput]
+	ListInstanceProfileTagsBehavior       MockedFunction[iam.ListInstanceProfileTagsInput, iam.ListInstanceProfileTagsOutput]
+	GetRoleBehavior                       MockedFunction[iam.GetRoleInput, iam.GetRoleOutput]
+	CreateRoleBehavior                    MockedFunction[iam.CreateRoleInput, iam.CreateRoleOutput]
+	DeleteRoleBehavior                    MockedFunction[iam.DeleteRoleInput, iam.DeleteRoleOutput]
+	AttachRolePolicyBehavior              MockedFunction[iam.AttachRolePolicyInput, iam.AttachRolePolicyOutput]
+	DetachRolePolicyBehavior              MockedFunction[iam.DetachRolePolicyInput, iam.DetachRolePolicyOutput]
+	SimulatePrincipalPolicyBehavior       MockedFunction[iam.SimulatePrincipalPolicyInput, iam.SimulatePrincipalPolicyOutput]
 }
 
 type IAMAPI struct {
 	sync.Mutex
 
-	iamiface.IAMAPI
+	sdk.IAMAPI
 	IAMAPIBehavior
 
-	InstanceProfiles map[string]*iam.InstanceProfile
+	InstanceProfiles map[string]*iamtypes.InstanceProfile
+	Roles            map[string]*iamtypes.Role
+	RolePolicies     map[string]sets.Set[string]
+	// DeniedActions are the actions that are denied when simulating the principal policy
+	DeniedActions sets.Set[string]
 }
 
 func NewIAMAPI() *IAMAPI {
-	return &IAMAPI{InstanceProfiles: map[string]*iam.InstanceProfile{}}
+	return &IAMAPI{
+		InstanceProfiles: map[string]*iamtypes.InstanceProfile{},
+		Roles:            map[string]*iamtypes.Role{},
+		RolePolicies:     map[string]sets.Set[string]{},
+		DeniedActions:    sets.New[string](),
+	}
 }
 
 // Reset must be called between tests otherwise tests will pollute
@@ -61,88 +78,244 @@ func (s *IAMAPI) Reset() {
 	s.DeleteInstanceProfileBehavior.Reset()
 	s.AddRoleToInstanceProfileBehavior.Reset()
 	s.RemoveRoleFromInstanceProfileBehavior.Reset()
-	s.InstanceProfiles = map[string]*iam.InstanceProfile{}
+	s.ListInstanceProfileTagsBehavior.Reset()
+	s.GetRoleBehavior.Reset()
+	s.CreateRoleBehavior.Reset()
+	s.DeleteRoleBehavior.Reset()
+	s.AttachRolePolicyBehavior.Reset()
+	s.DetachRolePolicyBehavior.Reset()
+	s.SimulatePrincipalPolicyBehavior.Reset()
+	s.InstanceProfiles = map[string]*iamtypes.InstanceProfile{}
+	s.Roles = map[string]*iamtypes.Role{}
+	s.RolePolicies = map[string]sets.Set[string]{}
+	s.DeniedActions = sets.New[string]()
 }
 
-func (s *IAMAPI) GetInstanceProfileWithContext(_ context.Context, input *iam.GetInstanceProfileInput, _ ...request.Option) (*iam.GetInstanceProfileOutput, error) {
+func (s *IAMAPI) GetInstanceProfile(_ context.Context, input *iam.GetInstanceProfileInput, _ ...func(*iam.Options)) (*iam.GetInstanceProfileOutput, error) {
 	return s.GetInstanceProfileBehavior.Invoke(input, func(*iam.GetInstanceProfileInput) (*iam.GetInstanceProfileOutput, error) {
 		s.Lock()
 		defer s.Unlock()
 
-		if i, ok := s.InstanceProfiles[aws.StringValue(input.InstanceProfileName)]; ok {
+		if i, ok := s.InstanceProfiles[aws.ToString(input.InstanceProfileName)]; ok {
 			return &iam.GetInstanceProfileOutput{InstanceProfile: i}, nil
 		}
-		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("Instance Profile %s cannot be found", aws.StringValue(input.InstanceProfileName)), nil)
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Instance Profile %s cannot be found", aws.ToString(input.InstanceProfileName)))}
 	})
 }
 
-func (s *IAMAPI) CreateInstanceProfileWithContext(_ context.Context, input *iam.CreateInstanceProfileInput, _ ...request.Option) (*iam.CreateInstanceProfileOutput, error) {
+func (s *IAMAPI) CreateInstanceProfile(_ context.Context, input *iam.CreateInstanceProfileInput, _ ...func(*iam.Options)) (*iam.CreateInstanceProfileOutput, error) {
 	return s.CreateInstanceProfileBehavior.Invoke(input, func(output *iam.CreateInstanceProfileInput) (*iam.CreateInstanceProfileOutput, error) {
 		s.Lock()
 		defer s.Unlock()
 
-		if _, ok := s.InstanceProfiles[aws.StringValue(input.InstanceProfileName)]; ok {
-			return nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, fmt.Sprintf("Instance Profile %s already exists", aws.StringValue(input.InstanceProfileName)), nil)
+		if _, ok := s.InstanceProfiles[aws.ToString(input.InstanceProfileName)]; ok {
+			return nil, &iamtypes.EntityAlreadyExistsException{Message: aws.String(fmt.Sprintf("Instance Profile %s already exists", aws.ToString(input.InstanceProfileName)))}
 		}
-		instanceProfile := &iam.InstanceProfile{
+		instanceProfile := &iamtypes.InstanceProfile{
+			Arn:                 aws.String(fmt.Sprintf("arn:aws:iam::123456789012:instance-profile%s%s", lo.FromPtrOr(input.Path, "/"), aws.ToString(input.InstanceProfileName))),
 			CreateDate:          aws.Time(time.Now()),
 			InstanceProfileId:   aws.String(InstanceProfileID()),
 			InstanceProfileName: input.InstanceProfileName,
 			Path:                input.Path,
 			Tags:                input.Tags,
 		}
-		s.InstanceProfiles[aws.StringValue(input.InstanceProfileName)] = instanceProfile
+		s.InstanceProfiles[aws.ToString(input.InstanceProfileName)] = instanceProfile
 		return &iam.CreateInstanceProfileOutput{InstanceProfile: instanceProfile}, nil
 	})
 }
 
-func (s *IAMAPI) DeleteInstanceProfileWithContext(_ context.Context, input *iam.DeleteInstanceProfileInput, _ ...request.Option) (*iam.DeleteInstanceProfileOutput, error) {
+func (s *IAMAPI) DeleteInstanceProfile(_ context.Context, input *iam.DeleteInstanceProfileInput, _ ...func(*iam.Options)) (*iam.DeleteInstanceProfileOutput, error) {
 	return s.DeleteInstanceProfileBehavior.Invoke(input, func(output *iam.DeleteInstanceProfileInput) (*iam.DeleteInstanceProfileOutput, error) {
 		s.Lock()
 		defer s.Unlock()
 
-		if i, ok := s.InstanceProfiles[aws.StringValue(input.InstanceProfileName)]; ok {
+		if i, ok := s.InstanceProfiles[aws.ToString(input.InstanceProfileName)]; ok {
 			if len(i.Roles) > 0 {
-				return nil, awserr.New(iam.ErrCodeDeleteConflictException, "Cannot delete entity, must remove roles from instance profile first.", nil)
+				return nil, &iamtypes.DeleteConflictException{Message: aws.String("Cannot delete entity, must remove roles from instance profile first.")}
 			}
-			delete(s.InstanceProfiles, aws.StringValue(input.InstanceProfileName))
+			delete(s.InstanceProfiles, aws.ToString(input.InstanceProfileName))
 			return &iam.DeleteInstanceProfileOutput{}, nil
 		}
-		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("Instance Profile %s cannot be found", aws.StringValue(input.InstanceProfileName)), nil)
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Instance Profile %s cannot be found", aws.ToString(input.InstanceProfileName)))}
 	})
 }
 
-func (s *IAMAPI) AddRoleToInstanceProfileWithContext(_ context.Context, input *iam.AddRoleToInstanceProfileInput, _ ...request.Option) (*iam.AddRoleToInstanceProfileOutput, error) {
+func (s *IAMAPI) AddRoleToInstanceProfile(_ context.Context, input *iam.AddRoleToInstanceProfileInput, _ ...func(*iam.Options)) (*iam.AddRoleToInstanceProfileOutput, error) {
 	return s.AddRoleToInstanceProfileBehavior.Invoke(input, func(output *iam.AddRoleToInstanceProfileInput) (*iam.AddRoleToInstanceProfileOutput, error) {
 		s.Lock()
 		defer s.Unlock()
 
-		if i, ok := s.InstanceProfiles[aws.StringValue(input.InstanceProfileName)]; ok {
+		if i, ok := s.InstanceProfiles[aws.ToString(input.InstanceProfileName)]; ok {
 			if len(i.Roles) > 0 {
-				return nil, awserr.New(iam.ErrCodeLimitExceededException, "Cannot exceed quota for InstanceSessionsPerInstanceProfile: 1", nil)
+				return nil, &iamtypes.LimitExceededException{Message: aws.String("Cannot exceed quota for InstanceSessionsPerInstanceProfile: 1")}
 			}
-			i.Roles = append(i.Roles, &iam.Role{RoleId: aws.String(RoleID()), RoleName: input.RoleName})
+			i.Roles = append(i.Roles, iamtypes.Role{RoleId: aws.String(RoleID()), RoleName: input.RoleName})
 			return nil, nil
 		}
-		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("Instance Profile %s cannot be found", aws.StringValue(input.InstanceProfileName)), nil)
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Instance Profile %s cannot be found", aws.ToString(input.InstanceProfileName)))}
 	})
 }
 
-func (s *IAMAPI) RemoveRoleFromInstanceProfileWithContext(_ context.Context, input *iam.RemoveRoleFromInstanceProfileInput, _ ...request.Option) (*iam.RemoveRoleFromInstanceProfileOutput, error) {
+func (s *IAMAPI) RemoveRoleFromInstanceProfile(_ context.Context, input *iam.RemoveRoleFromInstanceProfileInput, _ ...func(*iam.Options)) (*iam.RemoveRoleFromInstanceProfileOutput, error) {
 	return s.RemoveRoleFromInstanceProfileBehavior.Invoke(input, func(output *iam.RemoveRoleFromInstanceProfileInput) (*iam.RemoveRoleFromInstanceProfileOutput, error) {
 		s.Lock()
 		defer s.Unlock()
 
-		if i, ok := s.InstanceProfiles[aws.StringValue(input.InstanceProfileName)]; ok {
-			newRoles := lo.Reject(i.Roles, func(r *iam.Role, _ int) bool {
-				return aws.StringValue(r.RoleName) == aws.StringValue(input.RoleName)
+		if i, ok := s.InstanceProfiles[aws.ToString(input.InstanceProfileName)]; ok {
+			newRoles := lo.Reject(i.Roles, func(r iamtypes.Role, _ int) bool {
+				return aws.ToString(r.RoleName) == aws.ToString(input.RoleName)
 			})
 			if len(i.Roles) == len(newRoles) {
-				return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("The role with name %s cannot be found", aws.StringValue(input.RoleName)), nil)
+				return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role with name %s cannot be found", aws.ToString(input.RoleName)))}
 			}
 			i.Roles = newRoles
 			return nil, nil
 		}
-		return nil, awserr.New(iam.ErrCodeNoSuchEntityException, fmt.Sprintf("Instance Profile %s cannot be found", aws.StringValue(input.InstanceProfileName)), nil)
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Instance Profile %s cannot be found", aws.ToString(input.InstanceProfileName)))}
+	})
+}
+
+func (s *IAMAPI) ListInstanceProfiles(_ context.Context, _ *iam.ListInstanceProfilesInput, _ ...func(*iam.Options)) (*iam.ListInstanceProfilesOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	// Tags aren't returned when listing instance profiles
+	return &iam.ListInstanceProfilesOutput{
+		InstanceProfiles: lo.MapToSlice(s.InstanceProfiles, func(_ string, i *iamtypes.InstanceProfile) iamtypes.InstanceProfile {
+			return iamtypes.InstanceProfile{
+				Arn:                 i.Arn,
+				CreateDate:          i.CreateDate,
+				InstanceProfileId:   i.InstanceProfileId,
+				InstanceProfileName: i.InstanceProfileName,
+				Path:                i.Path,
+				Roles:               i.Roles,
+			}
+		}),
+	}, nil
+}
+
+func (s *IAMAPI) ListInstanceProfileTags(_ context.Context, input *iam.ListInstanceProfileTagsInput, _ ...func(*iam.Options)) (*iam.ListInstanceProfileTagsOutput, error) {
+	return s.ListInstanceProfileTagsBehavior.Invoke(input, func(input *iam.ListInstanceProfileTagsInput) (*iam.ListInstanceProfileTagsOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if i, ok := s.InstanceProfiles[aws.ToString(input.InstanceProfileName)]; ok {
+			return &iam.ListInstanceProfileTagsOutput{Tags: i.Tags}, nil
+		}
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Instance Profile %s cannot be found", aws.ToString(input.InstanceProfileName)))}
+	})
+}
+
+func (s *IAMAPI) GetRole(_ context.Context, input *iam.GetRoleInput, _ ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	return s.GetRoleBehavior.Invoke(input, func(*iam.GetRoleInput) (*iam.GetRoleOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if r, ok := s.Roles[aws.ToString(input.RoleName)]; ok {
+			return &iam.GetRoleOutput{Role: r}, nil
+		}
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role with name %s cannot be found", aws.ToString(input.RoleName)))}
+	})
+}
+
+func (s *IAMAPI) CreateRole(_ context.Context, input *iam.CreateRoleInput, _ ...func(*iam.Options)) (*iam.CreateRoleOutput, error) {
+	return s.CreateRoleBehavior.Invoke(input, func(*iam.CreateRoleInput) (*iam.CreateRoleOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if _, ok := s.Roles[aws.ToString(input.RoleName)]; ok {
+			return nil, &iamtypes.EntityAlreadyExistsException{Message: aws.String(fmt.Sprintf("Role with name %s already exists", aws.ToString(input.RoleName)))}
+		}
+		role := &iamtypes.Role{
+			Arn:                      aws.String(fmt.Sprintf("arn:aws:iam::123456789012:role/%s", aws.ToString(input.RoleName))),
+			AssumeRolePolicyDocument: input.AssumeRolePolicyDocument,
+			CreateDate:               aws.Time(time.Now()),
+			Description:              input.Description,
+			Path:                     input.Path,
+			RoleId:                   aws.String(RoleID()),
+			RoleName:                 input.RoleName,
+			Tags:                     input.Tags,
+		}
+		s.Roles[aws.ToString(input.RoleName)] = role
+		return &iam.CreateRoleOutput{Role: role}, nil
+	})
+}
+
+func (s *IAMAPI) DeleteRole(_ context.Context, input *iam.DeleteRoleInput, _ ...func(*iam.Options)) (*iam.DeleteRoleOutput, error) {
+	return s.DeleteRoleBehavior.Invoke(input, func(*iam.DeleteRoleInput) (*iam.DeleteRoleOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if _, ok := s.Roles[aws.ToString(input.RoleName)]; ok {
+			if s.RolePolicies[aws.ToString(input.RoleName)].Len() > 0 {
+				return nil, &iamtypes.DeleteConflictException{Message: aws.String("Cannot delete entity, must detach all policies first.")}
+			}
+			delete(s.Roles, aws.ToString(input.RoleName))
+			return &iam.DeleteRoleOutput{}, nil
+		}
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role with name %s cannot be found", aws.ToString(input.RoleName)))}
+	})
+}
+
+func (s *IAMAPI) AttachRolePolicy(_ context.Context, input *iam.AttachRolePolicyInput, _ ...func(*iam.Options)) (*iam.AttachRolePolicyOutput, error) {
+	return s.AttachRolePolicyBehavior.Invoke(input, func(*iam.AttachRolePolicyInput) (*iam.AttachRolePolicyOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if _, ok := s.Roles[aws.ToString(input.RoleName)]; ok {
+			if _, ok = s.RolePolicies[aws.ToString(input.RoleName)]; !ok {
+				s.RolePolicies[aws.ToString(input.RoleName)] = sets.New[string]()
+			}
+			s.RolePolicies[aws.ToString(input.RoleName)].Insert(aws.ToString(input.PolicyArn))
+			return &iam.AttachRolePolicyOutput{}, nil
+		}
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role with name %s cannot be found", aws.ToString(input.RoleName)))}
+	})
+}
+
+func (s *IAMAPI) DetachRolePolicy(_ context.Context, input *iam.DetachRolePolicyInput, _ ...func(*iam.Options)) (*iam.DetachRolePolicyOutput, error) {
+	return s.DetachRolePolicyBehavior.Invoke(input, func(*iam.DetachRolePolicyInput) (*iam.DetachRolePolicyOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		if !s.RolePolicies[aws.ToString(input.RoleName)].Has(aws.ToString(input.PolicyArn)) {
+			return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("Policy %s was not found", aws.ToString(input.PolicyArn)))}
+		}
+		s.RolePolicies[aws.ToString(input.RoleName)].Delete(aws.ToString(input.PolicyArn))
+		return &iam.DetachRolePolicyOutput{}, nil
+	})
+}
+
+func (s *IAMAPI) ListAttachedRolePolicies(_ context.Context, input *iam.ListAttachedRolePoliciesInput, _ ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.Roles[aws.ToString(input.RoleName)]; !ok {
+		return nil, &iamtypes.NoSuchEntityException{Message: aws.String(fmt.Sprintf("The role with name %s cannot be found", aws.ToString(input.RoleName)))}
+	}
+	return &iam.ListAttachedRolePoliciesOutput{
+		AttachedPolicies: lo.Map(sets.List(s.RolePolicies[aws.ToString(input.RoleName)]), func(policyARN string, _ int) iamtypes.AttachedPolicy {
+			return iamtypes.AttachedPolicy{PolicyArn: aws.String(policyARN)}
+		}),
+	}, nil
+}
+
+func (s *IAMAPI) SimulatePrincipalPolicy(_ context.Context, input *iam.SimulatePrincipalPolicyInput, _ ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	return s.SimulatePrincipalPolicyBehavior.Invoke(input, func(*iam.SimulatePrincipalPolicyInput) (*iam.SimulatePrincipalPolicyOutput, error) {
+		s.Lock()
+		defer s.Unlock()
+
+		resources := lo.Ternary(len(input.ResourceArns) > 0, input.ResourceArns, []string{"*"})
+		var results []iamtypes.EvaluationResult
+		for _, action := range input.ActionNames {
+			for _, resource := range resources {
+				results = append(results, iamtypes.EvaluationResult{
+					EvalActionName:   aws.String(action),
+					EvalResourceName: aws.String(resource),
+					EvalDecision:     lo.Ternary(s.DeniedActions.Has(action), iamtypes.PolicyEvaluationDecisionTypeImplicitDeny, iamtypes.PolicyEvaluationDecisionTypeAllowed),
+				})
+			}
+		}
+		return &iam.SimulatePrincipalPolicyOutput{EvaluationResults: results}, nil
 	})
 }
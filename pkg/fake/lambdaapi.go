@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// LambdaBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type LambdaBehavior struct {
+	InvokeBehavior MockedFunction[lambda.InvokeInput, lambda.InvokeOutput]
+}
+
+type LambdaAPI struct {
+	sdk.LambdaAPI
+	LambdaBehavior
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (l *LambdaAPI) Reset() {
+	l.InvokeBehavior.Reset()
+}
+
+func (l *LambdaAPI) Invoke(_ context.Context, input *lambda.InvokeInput, _ ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	return l.InvokeBehavior.Invoke(input, func(_ *lambda.InvokeInput) (*lambda.InvokeOutput, error) {
+		return &lambda.InvokeOutput{StatusCode: 200}, nil
+	})
+}
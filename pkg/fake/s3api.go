@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// S3Behavior must be reset between tests otherwise tests will
+// pollute each other.
+type S3Behavior struct {
+	PutObjectBehavior MockedFunction[s3.PutObjectInput, s3.PutObjectOutput]
+}
+
+type S3API struct {
+	sdk.S3API
+	S3Behavior
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *S3API) Reset() {
+	s.PutObjectBehavior.Reset()
+}
+
+func (s *S3API) PutObject(_ context.Context, input *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	return s.PutObjectBehavior.Invoke(input, func(_ *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+		return &s3.PutObjectOutput{}, nil
+	})
+}
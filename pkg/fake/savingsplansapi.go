@@ -0,0 +1,85 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+	savingsplanstypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+type SavingsPlansAPI struct {
+	sdk.SavingsPlansAPI
+	SavingsPlansBehavior
+}
+type SavingsPlansBehavior struct {
+	NextError                      AtomicError
+	DescribeSavingsPlansOutput     AtomicPtr[savingsplans.DescribeSavingsPlansOutput]
+	DescribeSavingsPlanRatesOutput AtomicPtr[savingsplans.DescribeSavingsPlanRatesOutput]
+}
+
+func (s *SavingsPlansAPI) Reset() {
+	s.NextError.Reset()
+	s.DescribeSavingsPlansOutput.Reset()
+	s.DescribeSavingsPlanRatesOutput.Reset()
+}
+
+func (s *SavingsPlansAPI) DescribeSavingsPlans(_ context.Context, _ *savingsplans.DescribeSavingsPlansInput, _ ...func(*savingsplans.Options)) (*savingsplans.DescribeSavingsPlansOutput, error) {
+	if !s.NextError.IsNil() {
+		defer s.NextError.Reset()
+		return nil, s.NextError.Get()
+	}
+	if !s.DescribeSavingsPlansOutput.IsNil() {
+		return s.DescribeSavingsPlansOutput.Clone(), nil
+	}
+	return &savingsplans.DescribeSavingsPlansOutput{}, nil
+}
+
+func (s *SavingsPlansAPI) DescribeSavingsPlanRates(_ context.Context, input *savingsplans.DescribeSavingsPlanRatesInput, _ ...func(*savingsplans.Options)) (*savingsplans.DescribeSavingsPlanRatesOutput, error) {
+	if !s.NextError.IsNil() {
+		defer s.NextError.Reset()
+		return nil, s.NextError.Get()
+	}
+	if !s.DescribeSavingsPlanRatesOutput.IsNil() {
+		out := s.DescribeSavingsPlanRatesOutput.Clone()
+		out.SavingsPlanId = input.SavingsPlanId
+		return out, nil
+	}
+	return &savingsplans.DescribeSavingsPlanRatesOutput{SavingsPlanId: input.SavingsPlanId}, nil
+}
+
+func NewSavingsPlanRate(instanceType string, rate float64) savingsplanstypes.SavingsPlanRate {
+	return savingsplanstypes.SavingsPlanRate{
+		Rate:        aws.String(fmt.Sprintf("%f", rate)),
+		ProductType: savingsplanstypes.SavingsPlanProductTypeEc2,
+		ServiceCode: savingsplanstypes.SavingsPlanRateServiceCodeEc2,
+		UsageType:   aws.String(fmt.Sprintf("BoxUsage:%s", instanceType)),
+		Properties: []savingsplanstypes.SavingsPlanRateProperty{
+			{
+				Name:  savingsplanstypes.SavingsPlanRatePropertyKeyInstanceType,
+				Value: aws.String(instanceType),
+			},
+			{
+				Name:  savingsplanstypes.SavingsPlanRatePropertyKeyRegion,
+				Value: aws.String(DefaultRegion),
+			},
+		},
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicequotastypes "github.com/aws/aws-sdk-go-v2/service/servicequotas/types"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+// ServiceQuotasAPIBehavior must be reset between tests otherwise tests will
+// pollute each other.
+type ServiceQuotasAPIBehavior struct {
+	GetServiceQuotaBehavior MockedFunction[servicequotas.GetServiceQuotaInput, servicequotas.GetServiceQuotaOutput]
+	// Quotas are the values of the quotas by quota code. Quotas that aren't set don't exist.
+	Quotas map[string]float64
+}
+
+type ServiceQuotasAPI struct {
+	sdk.ServiceQuotasAPI
+	ServiceQuotasAPIBehavior
+}
+
+func NewServiceQuotasAPI() *ServiceQuotasAPI {
+	return &ServiceQuotasAPI{ServiceQuotasAPIBehavior: ServiceQuotasAPIBehavior{Quotas: map[string]float64{}}}
+}
+
+// Reset must be called between tests otherwise tests will pollute
+// each other.
+func (s *ServiceQuotasAPI) Reset() {
+	s.GetServiceQuotaBehavior.Reset()
+	s.Quotas = map[string]float64{}
+}
+
+func (s *ServiceQuotasAPI) GetServiceQuota(_ context.Context, input *servicequotas.GetServiceQuotaInput, _ ...func(*servicequotas.Options)) (*servicequotas.GetServiceQuotaOutput, error) {
+	return s.GetServiceQuotaBehavior.Invoke(input, func(input *servicequotas.GetServiceQuotaInput) (*servicequotas.GetServiceQuotaOutput, error) {
+		value, ok := s.Quotas[aws.ToString(input.QuotaCode)]
+		if !ok {
+			return nil, &servicequotastypes.NoSuchResourceException{Message: aws.String("quota doesn't exist")}
+		}
+		return &servicequotas.GetServiceQuotaOutput{
+			Quota: &servicequotastypes.ServiceQuota{
+				ServiceCode: input.ServiceCode,
+				QuotaCode:   input.QuotaCode,
+				Value:       aws.Float64(value),
+			},
+		}, nil
+	})
+}
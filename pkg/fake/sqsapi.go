@@ -17,26 +17,33 @@ package fake
 import (
 	"context"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
 
 const (
 	dummyQueueURL = "https://sqs.us-west-2.amazonaws.com/000000000000/Karpenter-cluster-Queue"
+	dummyQueueARN = "arn:aws:sqs:us-west-2:000000000000:Karpenter-cluster-Queue"
 )
 
 // SQSBehavior must be reset between tests otherwise tests will
 // pollute each other.
 type SQSBehavior struct {
-	GetQueueURLBehavior    MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
-	ReceiveMessageBehavior MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
-	DeleteMessageBehavior  MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	GetQueueURLBehavior             MockedFunction[sqs.GetQueueUrlInput, sqs.GetQueueUrlOutput]
+	ReceiveMessageBehavior          MockedFunction[sqs.ReceiveMessageInput, sqs.ReceiveMessageOutput]
+	DeleteMessageBehavior           MockedFunction[sqs.DeleteMessageInput, sqs.DeleteMessageOutput]
+	CreateQueueBehavior             MockedFunction[sqs.CreateQueueInput, sqs.CreateQueueOutput]
+	TagQueueBehavior                MockedFunction[sqs.TagQueueInput, sqs.TagQueueOutput]
+	GetQueueAttributesBehavior      MockedFunction[sqs.GetQueueAttributesInput, sqs.GetQueueAttributesOutput]
+	SetQueueAttributesBehavior      MockedFunction[sqs.SetQueueAttributesInput, sqs.SetQueueAttributesOutput]
+	ChangeMessageVisibilityBehavior MockedFunction[sqs.ChangeMessageVisibilityInput, sqs.ChangeMessageVisibilityOutput]
 }
 
 type SQSAPI struct {
-	sqsiface.SQSAPI
+	sdk.SQSAPI
 	SQSBehavior
 }
 
@@ -46,10 +53,15 @@ func (s *SQSAPI) Reset() {
 	s.GetQueueURLBehavior.Reset()
 	s.ReceiveMessageBehavior.Reset()
 	s.DeleteMessageBehavior.Reset()
+	s.CreateQueueBehavior.Reset()
+	s.TagQueueBehavior.Reset()
+	s.GetQueueAttributesBehavior.Reset()
+	s.SetQueueAttributesBehavior.Reset()
+	s.ChangeMessageVisibilityBehavior.Reset()
 }
 
 //nolint:revive,stylecheck
-func (s *SQSAPI) GetQueueUrlWithContext(_ context.Context, input *sqs.GetQueueUrlInput, _ ...request.Option) (*sqs.GetQueueUrlOutput, error) {
+func (s *SQSAPI) GetQueueUrl(_ context.Context, input *sqs.GetQueueUrlInput, _ ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
 	return s.GetQueueURLBehavior.Invoke(input, func(_ *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error) {
 		return &sqs.GetQueueUrlOutput{
 			QueueUrl: aws.String(dummyQueueURL),
@@ -57,14 +69,50 @@ func (s *SQSAPI) GetQueueUrlWithContext(_ context.Context, input *sqs.GetQueueUr
 	})
 }
 
-func (s *SQSAPI) ReceiveMessageWithContext(_ context.Context, input *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+func (s *SQSAPI) ReceiveMessage(_ context.Context, input *sqs.ReceiveMessageInput, _ ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
 	return s.ReceiveMessageBehavior.Invoke(input, func(_ *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
 		return nil, nil
 	})
 }
 
-func (s *SQSAPI) DeleteMessageWithContext(_ context.Context, input *sqs.DeleteMessageInput, _ ...request.Option) (*sqs.DeleteMessageOutput, error) {
+func (s *SQSAPI) DeleteMessage(_ context.Context, input *sqs.DeleteMessageInput, _ ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
 	return s.DeleteMessageBehavior.Invoke(input, func(_ *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
 		return nil, nil
 	})
 }
+
+func (s *SQSAPI) CreateQueue(_ context.Context, input *sqs.CreateQueueInput, _ ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error) {
+	return s.CreateQueueBehavior.Invoke(input, func(_ *sqs.CreateQueueInput) (*sqs.CreateQueueOutput, error) {
+		return &sqs.CreateQueueOutput{
+			QueueUrl: aws.String(dummyQueueURL),
+		}, nil
+	})
+}
+
+func (s *SQSAPI) TagQueue(_ context.Context, input *sqs.TagQueueInput, _ ...func(*sqs.Options)) (*sqs.TagQueueOutput, error) {
+	return s.TagQueueBehavior.Invoke(input, func(_ *sqs.TagQueueInput) (*sqs.TagQueueOutput, error) {
+		return &sqs.TagQueueOutput{}, nil
+	})
+}
+
+func (s *SQSAPI) GetQueueAttributes(_ context.Context, input *sqs.GetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	return s.GetQueueAttributesBehavior.Invoke(input, func(_ *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+		return &sqs.GetQueueAttributesOutput{
+			Attributes: map[string]string{
+				string(sqstypes.QueueAttributeNameQueueArn): dummyQueueARN,
+			},
+		}, nil
+	})
+}
+
+func (s *SQSAPI) SetQueueAttributes(_ context.Context, input *sqs.SetQueueAttributesInput, _ ...func(*sqs.Options)) (*sqs.SetQueueAttributesOutput, error) {
+	return s.SetQueueAttributesBehavior.Invoke(input, func(_ *sqs.SetQueueAttributesInput) (*sqs.SetQueueAttributesOutput, error) {
+		return &sqs.SetQueueAttributesOutput{}, nil
+	})
+}
+
+func (s *SQSAPI) ChangeMessageVisibility(_ context.Context, input *sqs.ChangeMessageVisibilityInput, _ ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	return s.ChangeMessageVisibilityBehavior.Invoke(input, func(_ *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+		return &sqs.ChangeMessageVisibilityOutput{}, nil
+	})
+}
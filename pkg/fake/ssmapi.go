@@ -16,39 +16,41 @@ package fake
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
-	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/mitchellh/hashstructure/v2"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 )
 
 type SSMAPI struct {
-	ssmiface.SSMAPI
-	Parameters         map[string]string
-	GetParameterOutput *ssm.GetParameterOutput
-	WantErr            error
+	sdk.SSMAPI
+	Parameters            map[string]string
+	GetParameterOutput    *ssm.GetParameterOutput
+	GetParametersBehavior MockedFunction[ssm.GetParametersInput, ssm.GetParametersOutput]
+	WantErr               error
 }
 
 func NewSSMAPI() *SSMAPI {
 	return &SSMAPI{}
 }
 
-func (a SSMAPI) GetParameterWithContext(_ context.Context, input *ssm.GetParameterInput, _ ...request.Option) (*ssm.GetParameterOutput, error) {
+func (a *SSMAPI) GetParameter(_ context.Context, input *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
 	if a.WantErr != nil {
 		return nil, a.WantErr
 	}
 	if len(a.Parameters) > 0 {
 		if amiID, ok := a.Parameters[*input.Name]; ok {
 			return &ssm.GetParameterOutput{
-				Parameter: &ssm.Parameter{Value: aws.String(amiID)},
+				Parameter: &ssmtypes.Parameter{Value: aws.String(amiID)},
 			}, nil
 		}
-		return nil, awserr.New(ssm.ErrCodeParameterNotFound, fmt.Sprintf("%s couldn't be found", *input.Name), nil)
+		return nil, &ssmtypes.ParameterNotFound{Message: aws.String(fmt.Sprintf("%s couldn't be found", *input.Name))}
 	}
 	hc, _ := hashstructure.Hash(input.Name, hashstructure.FormatV2, nil)
 	if a.GetParameterOutput != nil {
@@ -56,12 +58,33 @@ func (a SSMAPI) GetParameterWithContext(_ context.Context, input *ssm.GetParamet
 	}
 
 	return &ssm.GetParameterOutput{
-		Parameter: &ssm.Parameter{Value: aws.String(fmt.Sprintf("test-ami-id-%x", hc))},
+		Parameter: &ssmtypes.Parameter{Value: aws.String(fmt.Sprintf("test-ami-id-%x", hc))},
 	}, nil
 }
 
+// GetParameters returns the parameters in the same way as GetParameter, reporting the parameters
+// that aren't found as invalid
+func (a *SSMAPI) GetParameters(ctx context.Context, input *ssm.GetParametersInput, _ ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	return a.GetParametersBehavior.Invoke(input, func(input *ssm.GetParametersInput) (*ssm.GetParametersOutput, error) {
+		output := &ssm.GetParametersOutput{}
+		for _, name := range input.Names {
+			out, err := a.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name)})
+			if notFound := (&ssmtypes.ParameterNotFound{}); errors.As(err, &notFound) {
+				output.InvalidParameters = append(output.InvalidParameters, name)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			output.Parameters = append(output.Parameters, ssmtypes.Parameter{Name: aws.String(name), Value: out.Parameter.Value})
+		}
+		return output, nil
+	})
+}
+
 func (a *SSMAPI) Reset() {
 	a.GetParameterOutput = nil
+	a.GetParametersBehavior.Reset()
 	a.Parameters = nil
 	a.WantErr = nil
 }
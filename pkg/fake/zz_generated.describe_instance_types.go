@@ -17,8 +17,9 @@ limitations under the License.
 package fake
 
 import (
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 )
 
 // GENERATED FILE. DO NOT EDIT DIRECTLY.
@@ -26,559 +27,559 @@ import (
 // You can add instance types by adding to the --instance-types CLI flag
 
 var defaultDescribeInstanceTypesOutput = &ec2.DescribeInstanceTypesOutput{
-	InstanceTypes: []*ec2.InstanceTypeInfo{
+	InstanceTypes: []ec2types.InstanceTypeInfo{
 		{
-			InstanceType:                  aws.String("c6g.large"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "c6g.large",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
-				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"arm64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(2),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(2),
+				DefaultVCpus: aws.Int32(2),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(4096),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(3),
-				Ipv4AddressesPerInterface:    aws.Int64(10),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(3),
+				Ipv4AddressesPerInterface:    aws.Int32(10),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(3),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(3),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("dl1.24xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "dl1.24xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(48),
-				DefaultVCpus: aws.Int64(96),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(48),
+				DefaultVCpus: aws.Int32(96),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(786432),
 			},
-			GpuInfo: &ec2.GpuInfo{
-				Gpus: []*ec2.GpuDeviceInfo{
+			GpuInfo: &ec2types.GpuInfo{
+				Gpus: []ec2types.GpuDeviceInfo{
 					{
 						Name:         aws.String("Gaudi HL-205"),
 						Manufacturer: aws.String("Habana"),
-						Count:        aws.Int64(8),
-						MemoryInfo: &ec2.GpuDeviceMemoryInfo{
-							SizeInMiB: aws.Int64(32768),
+						Count:        aws.Int32(8),
+						MemoryInfo: &ec2types.GpuDeviceMemoryInfo{
+							SizeInMiB: aws.Int32(32768),
 						},
 					},
 				},
 			},
-			InstanceStorageInfo: &ec2.InstanceStorageInfo{NvmeSupport: aws.String("required"),
+			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
 				TotalSizeInGB: aws.Int64(4000),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				EfaInfo: &ec2.EfaInfo{
-					MaximumEfaInterfaces: aws.Int64(4),
+			NetworkInfo: &ec2types.NetworkInfo{
+				EfaInfo: &ec2types.EfaInfo{
+					MaximumEfaInterfaces: aws.Int32(4),
 				},
-				MaximumNetworkInterfaces:     aws.Int64(60),
-				Ipv4AddressesPerInterface:    aws.Int64(50),
+				MaximumNetworkInterfaces:     aws.Int32(60),
+				Ipv4AddressesPerInterface:    aws.Int32(50),
 				EncryptionInTransitSupported: aws.Bool(true),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(15),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(15),
 					},
 					{
-						NetworkCardIndex:         aws.Int64(1),
-						MaximumNetworkInterfaces: aws.Int64(15),
+						NetworkCardIndex:         aws.Int32(1),
+						MaximumNetworkInterfaces: aws.Int32(15),
 					},
 					{
-						NetworkCardIndex:         aws.Int64(2),
-						MaximumNetworkInterfaces: aws.Int64(15),
+						NetworkCardIndex:         aws.Int32(2),
+						MaximumNetworkInterfaces: aws.Int32(15),
 					},
 					{
-						NetworkCardIndex:         aws.Int64(3),
-						MaximumNetworkInterfaces: aws.Int64(15),
+						NetworkCardIndex:         aws.Int32(3),
+						MaximumNetworkInterfaces: aws.Int32(15),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("g4dn.8xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "g4dn.8xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(16),
-				DefaultVCpus: aws.Int64(32),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(16),
+				DefaultVCpus: aws.Int32(32),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(131072),
 			},
-			GpuInfo: &ec2.GpuInfo{
-				Gpus: []*ec2.GpuDeviceInfo{
+			GpuInfo: &ec2types.GpuInfo{
+				Gpus: []ec2types.GpuDeviceInfo{
 					{
 						Name:         aws.String("T4"),
 						Manufacturer: aws.String("NVIDIA"),
-						Count:        aws.Int64(1),
-						MemoryInfo: &ec2.GpuDeviceMemoryInfo{
-							SizeInMiB: aws.Int64(16384),
+						Count:        aws.Int32(1),
+						MemoryInfo: &ec2types.GpuDeviceMemoryInfo{
+							SizeInMiB: aws.Int32(16384),
 						},
 					},
 				},
 			},
-			InstanceStorageInfo: &ec2.InstanceStorageInfo{NvmeSupport: aws.String("required"),
+			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
 				TotalSizeInGB: aws.Int64(900),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				EfaInfo: &ec2.EfaInfo{
-					MaximumEfaInterfaces: aws.Int64(1),
+			NetworkInfo: &ec2types.NetworkInfo{
+				EfaInfo: &ec2types.EfaInfo{
+					MaximumEfaInterfaces: aws.Int32(1),
 				},
-				MaximumNetworkInterfaces:     aws.Int64(4),
-				Ipv4AddressesPerInterface:    aws.Int64(15),
+				MaximumNetworkInterfaces:     aws.Int32(4),
+				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(true),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(4),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(4),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("inf1.2xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "inf1.2xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(4),
-				DefaultVCpus: aws.Int64(8),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(4),
+				DefaultVCpus: aws.Int32(8),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(16384),
 			},
-			InferenceAcceleratorInfo: &ec2.InferenceAcceleratorInfo{
-				Accelerators: []*ec2.InferenceDeviceInfo{
+			InferenceAcceleratorInfo: &ec2types.InferenceAcceleratorInfo{
+				Accelerators: []ec2types.InferenceDeviceInfo{
 					{
 						Name:         aws.String("Inferentia"),
 						Manufacturer: aws.String("AWS"),
-						Count:        aws.Int64(1),
+						Count:        aws.Int32(1),
 					},
 				},
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(4),
-				Ipv4AddressesPerInterface:    aws.Int64(10),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(4),
+				Ipv4AddressesPerInterface:    aws.Int32(10),
 				EncryptionInTransitSupported: aws.Bool(true),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(4),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(4),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("inf1.6xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "inf1.6xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(12),
-				DefaultVCpus: aws.Int64(24),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(12),
+				DefaultVCpus: aws.Int32(24),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(49152),
 			},
-			InferenceAcceleratorInfo: &ec2.InferenceAcceleratorInfo{
-				Accelerators: []*ec2.InferenceDeviceInfo{
+			InferenceAcceleratorInfo: &ec2types.InferenceAcceleratorInfo{
+				Accelerators: []ec2types.InferenceDeviceInfo{
 					{
 						Name:         aws.String("Inferentia"),
 						Manufacturer: aws.String("AWS"),
-						Count:        aws.Int64(4),
+						Count:        aws.Int32(4),
 					},
 				},
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(8),
-				Ipv4AddressesPerInterface:    aws.Int64(30),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(8),
+				Ipv4AddressesPerInterface:    aws.Int32(30),
 				EncryptionInTransitSupported: aws.Bool(true),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(8),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(8),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("m5.large"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "m5.large",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(1),
-				DefaultVCpus: aws.Int64(2),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(1),
+				DefaultVCpus: aws.Int32(2),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(8192),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(3),
-				Ipv4AddressesPerInterface:    aws.Int64(10),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(3),
+				Ipv4AddressesPerInterface:    aws.Int32(10),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(3),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(3),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("m5.metal"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "m5.metal",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(true),
-			Hypervisor:                    aws.String(""),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(48),
-				DefaultVCpus: aws.Int64(96),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(48),
+				DefaultVCpus: aws.Int32(96),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(393216),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(15),
-				Ipv4AddressesPerInterface:    aws.Int64(50),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(15),
+				Ipv4AddressesPerInterface:    aws.Int32(50),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(15),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(15),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("m5.xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "m5.xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(4),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(2),
+				DefaultVCpus: aws.Int32(4),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(16384),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(4),
-				Ipv4AddressesPerInterface:    aws.Int64(15),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(4),
+				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(4),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(4),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("m6idn.32xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "m6idn.32xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(64),
-				DefaultVCpus: aws.Int64(128),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(64),
+				DefaultVCpus: aws.Int32(128),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(524288),
 			},
-			InstanceStorageInfo: &ec2.InstanceStorageInfo{NvmeSupport: aws.String("required"),
+			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
 				TotalSizeInGB: aws.Int64(7600),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				EfaInfo: &ec2.EfaInfo{
-					MaximumEfaInterfaces: aws.Int64(2),
+			NetworkInfo: &ec2types.NetworkInfo{
+				EfaInfo: &ec2types.EfaInfo{
+					MaximumEfaInterfaces: aws.Int32(2),
 				},
-				MaximumNetworkInterfaces:     aws.Int64(14),
-				Ipv4AddressesPerInterface:    aws.Int64(50),
+				MaximumNetworkInterfaces:     aws.Int32(14),
+				Ipv4AddressesPerInterface:    aws.Int32(50),
 				EncryptionInTransitSupported: aws.Bool(true),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(7),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(7),
 					},
 					{
-						NetworkCardIndex:         aws.Int64(1),
-						MaximumNetworkInterfaces: aws.Int64(7),
+						NetworkCardIndex:         aws.Int32(1),
+						MaximumNetworkInterfaces: aws.Int32(7),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("p3.8xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "p3.8xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("xen"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "xen",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(16),
-				DefaultVCpus: aws.Int64(32),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(16),
+				DefaultVCpus: aws.Int32(32),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(249856),
 			},
-			GpuInfo: &ec2.GpuInfo{
-				Gpus: []*ec2.GpuDeviceInfo{
+			GpuInfo: &ec2types.GpuInfo{
+				Gpus: []ec2types.GpuDeviceInfo{
 					{
 						Name:         aws.String("V100"),
 						Manufacturer: aws.String("NVIDIA"),
-						Count:        aws.Int64(4),
-						MemoryInfo: &ec2.GpuDeviceMemoryInfo{
-							SizeInMiB: aws.Int64(16384),
+						Count:        aws.Int32(4),
+						MemoryInfo: &ec2types.GpuDeviceMemoryInfo{
+							SizeInMiB: aws.Int32(16384),
 						},
 					},
 				},
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(8),
-				Ipv4AddressesPerInterface:    aws.Int64(30),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(8),
+				Ipv4AddressesPerInterface:    aws.Int32(30),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(8),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(8),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("t3.large"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "t3.large",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(1),
-				DefaultVCpus: aws.Int64(2),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(1),
+				DefaultVCpus: aws.Int32(2),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(8192),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(3),
-				Ipv4AddressesPerInterface:    aws.Int64(12),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(3),
+				Ipv4AddressesPerInterface:    aws.Int32(12),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(3),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(3),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("t4g.medium"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "t4g.medium",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
-				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"arm64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(2),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(2),
+				DefaultVCpus: aws.Int32(2),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(4096),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(3),
-				Ipv4AddressesPerInterface:    aws.Int64(6),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(3),
+				Ipv4AddressesPerInterface:    aws.Int32(6),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(3),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(3),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("t4g.small"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "t4g.small",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
-				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"arm64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(2),
-				DefaultVCpus: aws.Int64(2),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(2),
+				DefaultVCpus: aws.Int32(2),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(2048),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(3),
-				Ipv4AddressesPerInterface:    aws.Int64(4),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(3),
+				Ipv4AddressesPerInterface:    aws.Int32(4),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(3),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(3),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("t4g.xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "t4g.xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(true),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("AWS"),
-				SupportedArchitectures: aws.StringSlice([]string{"arm64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"arm64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(4),
-				DefaultVCpus: aws.Int64(4),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(4),
+				DefaultVCpus: aws.Int32(4),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(16384),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(4),
-				Ipv4AddressesPerInterface:    aws.Int64(15),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(4),
+				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(false),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(4),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(4),
 					},
 				},
 			},
 		},
 		{
-			InstanceType:                  aws.String("trn1.2xlarge"),
-			SupportedUsageClasses:         aws.StringSlice([]string{"on-demand", "spot"}),
-			SupportedVirtualizationTypes:  aws.StringSlice([]string{"hvm"}),
+			InstanceType:                  "trn1.2xlarge",
+			SupportedUsageClasses:         []ec2types.UsageClassType{"on-demand", "spot"},
+			SupportedVirtualizationTypes:  []ec2types.VirtualizationType{"hvm"},
 			BurstablePerformanceSupported: aws.Bool(false),
 			BareMetal:                     aws.Bool(false),
-			Hypervisor:                    aws.String("nitro"),
-			ProcessorInfo: &ec2.ProcessorInfo{
+			Hypervisor:                    "nitro",
+			ProcessorInfo: &ec2types.ProcessorInfo{
 				Manufacturer:           aws.String("Intel"),
-				SupportedArchitectures: aws.StringSlice([]string{"x86_64"}),
+				SupportedArchitectures: []ec2types.ArchitectureType{"x86_64"},
 			},
-			VCpuInfo: &ec2.VCpuInfo{
-				DefaultCores: aws.Int64(4),
-				DefaultVCpus: aws.Int64(8),
+			VCpuInfo: &ec2types.VCpuInfo{
+				DefaultCores: aws.Int32(4),
+				DefaultVCpus: aws.Int32(8),
 			},
-			MemoryInfo: &ec2.MemoryInfo{
+			MemoryInfo: &ec2types.MemoryInfo{
 				SizeInMiB: aws.Int64(32768),
 			},
-			InstanceStorageInfo: &ec2.InstanceStorageInfo{NvmeSupport: aws.String("required"),
+			InstanceStorageInfo: &ec2types.InstanceStorageInfo{NvmeSupport: "required",
 				TotalSizeInGB: aws.Int64(474),
 			},
-			NetworkInfo: &ec2.NetworkInfo{
-				MaximumNetworkInterfaces:     aws.Int64(4),
-				Ipv4AddressesPerInterface:    aws.Int64(15),
+			NetworkInfo: &ec2types.NetworkInfo{
+				MaximumNetworkInterfaces:     aws.Int32(4),
+				Ipv4AddressesPerInterface:    aws.Int32(15),
 				EncryptionInTransitSupported: aws.Bool(true),
-				DefaultNetworkCardIndex:      aws.Int64(0),
-				NetworkCards: []*ec2.NetworkCardInfo{
+				DefaultNetworkCardIndex:      aws.Int32(0),
+				NetworkCards: []ec2types.NetworkCardInfo{
 					{
-						NetworkCardIndex:         aws.Int64(0),
-						MaximumNetworkInterfaces: aws.Int64(4),
+						NetworkCardIndex:         aws.Int32(0),
+						MaximumNetworkInterfaces: aws.Int32(4),
 					},
 				},
 			},
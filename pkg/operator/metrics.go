@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	serviceLabel           = "service"
+	operationLabel         = "operation"
+	errorLabel             = "error"
+)
+
+var apiRequests = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: cloudProviderSubsystem,
+		Name:      "api_requests_total",
+		Help:      "Number of requests to AWS APIs, including retries. Labeled by service, operation and the error code of failed requests, which is empty for successful requests. Throttled requests fail with error codes such as RequestLimitExceeded and Throttling.",
+	},
+	[]string{serviceLabel, operationLabel, errorLabel},
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(apiRequests)
+}
+
+// withRequestMetrics counts every request that is sent by the clients of the config, so that the request rates can be
+// alerted on before the APIs throttle the controller
+func withRequestMetrics(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(cfg.APIOptions[:len(cfg.APIOptions):len(cfg.APIOptions)], func(stack *middleware.Stack) error {
+		// the middleware runs after the retry middleware, so that every attempt is counted
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("karpenter.RequestMetrics", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			code := ""
+			var apiErr smithy.APIError
+			if errors.As(err, &apiErr) {
+				code = apiErr.ErrorCode()
+			}
+			apiRequests.With(prometheus.Labels{
+				serviceLabel:   sdk.ServiceName(ctx),
+				operationLabel: awsmiddleware.GetOperationName(ctx),
+				errorLabel:     code,
+			}).Inc()
+			return out, metadata, err
+		}), "Retry", middleware.After)
+	})
+	return cfg
+}
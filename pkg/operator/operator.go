@@ -20,22 +20,24 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	awsclient "github.com/aws/aws-sdk-go/aws/client"
-	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
-	"github.com/aws/aws-sdk-go/aws/endpoints"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
-	"github.com/aws/aws-sdk-go/service/eks"
-	"github.com/aws/aws-sdk-go/service/eks/eksiface"
-	"github.com/aws/aws-sdk-go/service/iam"
-	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	serviceeventbridge "github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	servicesqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
@@ -52,17 +54,27 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/settings"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/eventbridge"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/noderole"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/terminationhook"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/throttling"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/tracing"
 )
 
 func init() {
@@ -74,9 +86,9 @@ func init() {
 type Operator struct {
 	*operator.Operator
 
-	Session                   *session.Session
+	Config                    aws.Config
 	UnavailableOfferingsCache *awscache.UnavailableOfferings
-	EC2API                    ec2iface.EC2API
+	EC2API                    sdk.EC2API
 	SubnetProvider            *subnet.Provider
 	SecurityGroupProvider     *securitygroup.Provider
 	InstanceProfileProvider   *instanceprofile.Provider
@@ -84,39 +96,66 @@ type Operator struct {
 	AMIResolver               *amifamily.Resolver
 	LaunchTemplateProvider    *launchtemplate.Provider
 	PricingProvider           *pricing.Provider
+	QuotaProvider             *quota.Provider
 	VersionProvider           *version.Provider
 	InstanceTypesProvider     *instancetype.Provider
 	InstanceProvider          *instance.Provider
+	AccountProvider           *account.Provider
 }
 
 func NewOperator(ctx context.Context, operator *operator.Operator) (context.Context, *Operator) {
-	config := &aws.Config{
-		STSRegionalEndpoint: endpoints.RegionalSTSEndpoint,
+	// Settings are applied before anything reads the options, since some of them are only consumed at startup
+	if options.FromContext(ctx).SettingsConfigMap != "" {
+		settingsWatcher, err := settings.NewWatcher(ctx, operator.KubernetesInterface, operator.Clock, operator.EventRecorder)
+		if err != nil {
+			logging.FromContext(ctx).Fatalf("applying settings, %s", err)
+		}
+		lo.Must0(operator.Add(settingsWatcher), "failed to add settings watcher")
+	}
+	cfg := withUserAgent(lo.Must(config.LoadDefaultConfig(ctx,
+		config.WithRetryer(func() aws.Retryer { return throttling.NewRetryer(throttling.MaxAttempts) }),
+		config.WithEndpointResolverWithOptions(endpointResolver(ctx)),
+		config.WithUseFIPSEndpoint(lo.Ternary(options.FromContext(ctx).UseFIPSEndpoints, aws.FIPSEndpointStateEnabled, aws.FIPSEndpointStateUnset)),
+	)))
+	if cfg.Region == "" {
+		logging.FromContext(ctx).Debug("retrieving region from IMDS")
+		region, err := imds.NewFromConfig(cfg).GetRegion(ctx, nil)
+		if err != nil {
+			logging.FromContext(ctx).Fatalf("failed to get region from metadata server, %s", err)
+		}
+		cfg.Region = region.Region
 	}
-
 	if assumeRoleARN := options.FromContext(ctx).AssumeRoleARN; assumeRoleARN != "" {
-		config.Credentials = stscreds.NewCredentials(session.Must(session.NewSession()), assumeRoleARN,
-			func(provider *stscreds.AssumeRoleProvider) { setDurationAndExpiry(ctx, provider) })
+		cfg.Credentials = assumeRole(ctx, sts.NewFromConfig(cfg), assumeRoleARN, "")
 	}
 
-	sess := withUserAgent(session.Must(session.NewSession(
-		request.WithRetryer(
-			config,
-			awsclient.DefaultRetryer{NumMaxRetries: awsclient.DefaultRetryerMaxNumRetries},
-		),
-	)))
+	if options.FromContext(ctx).TracingEndpoint != "" {
+		startTracing(ctx)
+	}
+	cfg = tracing.WithConfig(cfg)
+	var breaker *throttling.Breaker
+	if threshold := options.FromContext(ctx).CircuitBreakerThreshold; threshold > 0 {
+		breaker = throttling.NewBreaker(operator.Clock, threshold)
+	}
+	cfg = throttling.WithConfig(cfg, breaker)
+	if options.FromContext(ctx).AuditLog {
+		cfg = audit.WithConfig(cfg)
+	}
+	cfg = withRequestMetrics(withRateLimits(cfg, lo.Must(options.ParseAWSAPIRateLimits(options.FromContext(ctx).AWSAPIRateLimits))))
 
-	if *sess.Config.Region == "" {
-		logging.FromContext(ctx).Debug("retrieving region from IMDS")
-		region, err := ec2metadata.New(sess).Region()
-		*sess.Config.Region = lo.Must(region, err, "failed to get region from metadata server")
+	// The recorder is decorated before it's passed to the controllers, so that every event that describes a provisioning
+	// or disruption decision is published to the event bus
+	if options.FromContext(ctx).EventBridgeBus != "" {
+		eventBridgeRecorder := eventbridge.NewRecorder(ctx, operator.EventRecorder, serviceeventbridge.NewFromConfig(cfg), operator.Clock)
+		lo.Must0(operator.Add(eventBridgeRecorder), "failed to add eventbridge recorder")
+		operator.EventRecorder = eventBridgeRecorder
 	}
-	ec2api := ec2.New(sess)
+	ec2api := ec2.NewFromConfig(cfg)
 	if err := checkEC2Connectivity(ctx, ec2api); err != nil {
 		logging.FromContext(ctx).Fatalf("Checking EC2 API connectivity, %s", err)
 	}
-	logging.FromContext(ctx).With("region", *sess.Config.Region).Debugf("discovered region")
-	clusterEndpoint, err := ResolveClusterEndpoint(ctx, eks.New(sess))
+	logging.FromContext(ctx).With("region", cfg.Region).Debugf("discovered region")
+	clusterEndpoint, err := ResolveClusterEndpoint(ctx, eks.NewFromConfig(cfg))
 	if err != nil {
 		logging.FromContext(ctx).Fatalf("unable to detect the cluster endpoint, %s", err)
 	} else {
@@ -135,21 +174,23 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 	unavailableOfferingsCache := awscache.NewUnavailableOfferings()
 	subnetProvider := subnet.NewProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
 	securityGroupProvider := securitygroup.NewProvider(ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
-	instanceProfileProvider := instanceprofile.NewProvider(*sess.Config.Region, iam.New(sess), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval))
+	instanceProfileProvider := instanceprofile.NewProvider(cfg.Region, iam.NewFromConfig(cfg), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval))
 	pricingProvider := pricing.NewProvider(
 		ctx,
-		pricing.NewAPI(sess, *sess.Config.Region),
+		pricing.NewAPI(cfg, cfg.Region, options.FromContext(ctx).PricingEndpoint),
 		ec2api,
-		*sess.Config.Region,
+		pricing.NewSavingsPlansAPI(cfg),
+		cfg.Region,
 	)
+	quotaProvider := quota.NewProvider(servicequotas.NewFromConfig(cfg), ec2api)
 	versionProvider := version.NewProvider(operator.KubernetesInterface, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
-	amiProvider := amifamily.NewProvider(versionProvider, ssm.New(sess), ec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+	amiProvider := amifamily.NewProvider(versionProvider, ssm.NewFromConfig(cfg), ec2api, cache.New(options.FromContext(ctx).AMICacheTTL, awscache.DefaultCleanupInterval))
 	amiResolver := amifamily.New(amiProvider)
 	launchTemplateProvider := launchtemplate.NewProvider(
 		ctx,
 		cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
 		ec2api,
-		eks.New(sess),
+		eks.NewFromConfig(cfg),
 		amiResolver,
 		securityGroupProvider,
 		subnetProvider,
@@ -160,23 +201,86 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		clusterEndpoint,
 	)
 	instanceTypeProvider := instancetype.NewProvider(
-		*sess.Config.Region,
+		cfg.Region,
 		cache.New(awscache.InstanceTypesAndZonesTTL, awscache.DefaultCleanupInterval),
 		ec2api,
 		subnetProvider,
 		unavailableOfferingsCache,
 		pricingProvider,
+		quotaProvider,
+	)
+	terminationHookProvider := terminationhook.NewProvider(
+		operator.Clock,
+		lambda.NewFromConfig(cfg),
+		&http.Client{},
+		cache.New(awscache.PreTerminationHookTTL, awscache.DefaultCleanupInterval),
 	)
 	instanceProvider := instance.NewProvider(
 		ctx,
-		aws.StringValue(sess.Config.Region),
+		cfg.Region,
 		ec2api,
 		unavailableOfferingsCache,
 		instanceTypeProvider,
 		subnetProvider,
 		launchTemplateProvider,
+		terminationHookProvider,
+		quotaProvider,
 	)
 
+	accountProvider := account.NewProvider(&account.Providers{
+		EC2API:          ec2api,
+		Subnet:          subnetProvider,
+		SecurityGroup:   securityGroupProvider,
+		InstanceProfile: instanceProfileProvider,
+		NodeRole:        noderole.NewProvider(cfg.Region, iam.NewFromConfig(cfg), eks.NewFromConfig(cfg), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval)),
+		AMI:             amiProvider,
+		LaunchTemplate:  launchTemplateProvider,
+		Instance:        instanceProvider,
+	}, func(role account.Role) *account.Providers {
+		// Instance types, pricing and SSM parameters are shared with Karpenter's own account
+		roleCfg := cfg.Copy()
+		roleCfg.Credentials = assumeRole(ctx, sts.NewFromConfig(cfg), role.ARN, role.ExternalID)
+		roleEC2API := ec2.NewFromConfig(roleCfg)
+		roleSubnetProvider := subnet.NewProvider(roleEC2API, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+		roleSecurityGroupProvider := securitygroup.NewProvider(roleEC2API, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+		roleInstanceProfileProvider := instanceprofile.NewProvider(cfg.Region, iam.NewFromConfig(roleCfg), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval))
+		roleAMIProvider := amifamily.NewProvider(versionProvider, ssm.NewFromConfig(cfg), roleEC2API, cache.New(options.FromContext(ctx).AMICacheTTL, awscache.DefaultCleanupInterval))
+		roleLaunchTemplateProvider := launchtemplate.NewProvider(
+			ctx,
+			cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+			roleEC2API,
+			eks.NewFromConfig(cfg),
+			amifamily.New(roleAMIProvider),
+			roleSecurityGroupProvider,
+			roleSubnetProvider,
+			roleInstanceProfileProvider,
+			launchTemplateProvider.CABundle,
+			operator.Elected(),
+			kubeDNSIP,
+			clusterEndpoint,
+		)
+		return &account.Providers{
+			EC2API:          roleEC2API,
+			Subnet:          roleSubnetProvider,
+			SecurityGroup:   roleSecurityGroupProvider,
+			InstanceProfile: roleInstanceProfileProvider,
+			NodeRole:        noderole.NewProvider(cfg.Region, iam.NewFromConfig(roleCfg), eks.NewFromConfig(cfg), cache.New(awscache.InstanceProfileTTL, awscache.DefaultCleanupInterval)),
+			AMI:             roleAMIProvider,
+			LaunchTemplate:  roleLaunchTemplateProvider,
+			Instance: instance.NewProvider(
+				ctx,
+				cfg.Region,
+				roleEC2API,
+				unavailableOfferingsCache,
+				instanceTypeProvider,
+				roleSubnetProvider,
+				roleLaunchTemplateProvider,
+				terminationHookProvider,
+				nil,
+			),
+		}
+	})
+
 	lo.Must0(operator.Manager.GetFieldIndexer().IndexField(ctx, &corev1beta1.NodeClaim{}, "spec.nodeClassRef.name", func(o client.Object) []string {
 		nc := o.(*corev1beta1.NodeClaim)
 		if nc.Spec.NodeClassRef == nil {
@@ -187,7 +291,7 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 
 	return ctx, &Operator{
 		Operator:                  operator,
-		Session:                   sess,
+		Config:                    cfg,
 		UnavailableOfferingsCache: unavailableOfferingsCache,
 		EC2API:                    ec2api,
 		SubnetProvider:            subnetProvider,
@@ -198,35 +302,54 @@ func NewOperator(ctx context.Context, operator *operator.Operator) (context.Cont
 		VersionProvider:           versionProvider,
 		LaunchTemplateProvider:    launchTemplateProvider,
 		PricingProvider:           pricingProvider,
+		QuotaProvider:             quotaProvider,
 		InstanceTypesProvider:     instanceTypeProvider,
 		InstanceProvider:          instanceProvider,
+		AccountProvider:           accountProvider,
 	}
 }
 
-// withUserAgent adds a karpenter specific user-agent string to AWS session
-func withUserAgent(sess *session.Session) *session.Session {
+// startTracing exports the spans of the controller to the tracing endpoint until the context is cancelled, and flushes
+// the spans that haven't been exported yet when the controller shuts down
+func startTracing(ctx context.Context) {
+	tracerProvider, err := tracing.NewTracerProvider(ctx, options.FromContext(ctx).TracingEndpoint, options.FromContext(ctx).ClusterName, operator.Version)
+	if err != nil {
+		logging.FromContext(ctx).Fatalf("configuring tracing, %s", err)
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+			logging.FromContext(ctx).Errorf("shutting down tracing, %s", err)
+		}
+	}()
+}
+
+// withUserAgent adds a karpenter specific user-agent string to the requests of the config's clients
+func withUserAgent(cfg aws.Config) aws.Config {
 	userAgent := fmt.Sprintf("karpenter.sh-%s", operator.Version)
-	sess.Handlers.Build.PushBack(request.MakeAddToUserAgentFreeFormHandler(userAgent))
-	return sess
+	cfg.APIOptions = append(cfg.APIOptions[:len(cfg.APIOptions):len(cfg.APIOptions)], awsmiddleware.AddUserAgentKey(userAgent))
+	return cfg
 }
 
 // checkEC2Connectivity makes a dry-run call to DescribeInstanceTypes.  If it fails, we provide an early indicator that we
 // are having issues connecting to the EC2 API.
-func checkEC2Connectivity(ctx context.Context, api *ec2.EC2) error {
-	_, err := api.DescribeInstanceTypesWithContext(ctx, &ec2.DescribeInstanceTypesInput{DryRun: aws.Bool(true)})
-	var aerr awserr.Error
-	if errors.As(err, &aerr) && aerr.Code() == "DryRunOperation" {
+func checkEC2Connectivity(ctx context.Context, api sdk.EC2API) error {
+	_, err := api.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{DryRun: aws.Bool(true)})
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "DryRunOperation" {
 		return nil
 	}
 	return err
 }
 
-func ResolveClusterEndpoint(ctx context.Context, eksAPI eksiface.EKSAPI) (string, error) {
+func ResolveClusterEndpoint(ctx context.Context, eksAPI sdk.EKSAPI) (string, error) {
 	clusterEndpointFromOptions := options.FromContext(ctx).ClusterEndpoint
 	if clusterEndpointFromOptions != "" {
 		return clusterEndpointFromOptions, nil // cluster endpoint is explicitly set
 	}
-	out, err := eksAPI.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{
+	out, err := eksAPI.DescribeCluster(ctx, &eks.DescribeClusterInput{
 		Name: aws.String(options.FromContext(ctx).ClusterName),
 	})
 	if err != nil {
@@ -269,7 +392,41 @@ func kubeDNSIP(ctx context.Context, kubernetesInterface kubernetes.Interface) (n
 	return kubeDNSIP, nil
 }
 
-func setDurationAndExpiry(ctx context.Context, provider *stscreds.AssumeRoleProvider) {
-	provider.Duration = options.FromContext(ctx).AssumeRoleDuration
-	provider.ExpiryWindow = time.Duration(10) * time.Second
+// assumeRole returns the credentials of the role, which are refreshed shortly before they expire
+func assumeRole(ctx context.Context, stsapi stscreds.AssumeRoleAPIClient, roleARN, externalID string) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsapi, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.Duration = options.FromContext(ctx).AssumeRoleDuration
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	}), func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = time.Duration(10) * time.Second
+	})
+}
+
+// endpointResolver resolves the custom endpoints of the services that have one set in the options, and the standard
+// endpoints of every other service. Custom endpoints keep the signing region of the standard endpoint, so that requests
+// to global services like IAM are still signed for the region that the service expects.
+func endpointResolver(ctx context.Context) aws.EndpointResolverWithOptions {
+	custom := lo.OmitByValues(map[string]string{
+		ec2.ServiceID:        options.FromContext(ctx).EC2Endpoint,
+		ssm.ServiceID:        options.FromContext(ctx).SSMEndpoint,
+		servicesqs.ServiceID: options.FromContext(ctx).SQSEndpoint,
+		iam.ServiceID:        options.FromContext(ctx).IAMEndpoint,
+		eks.ServiceID:        options.FromContext(ctx).EKSEndpoint,
+	}, []string{""})
+	return aws.EndpointResolverWithOptionsFunc(func(service, region string, _ ...interface{}) (aws.Endpoint, error) {
+		url, ok := custom[service]
+		if !ok {
+			// the clients fall back to their standard endpoint
+			return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+		}
+		signingRegion := region
+		if service == iam.ServiceID {
+			if resolved, err := iam.NewDefaultEndpointResolver().ResolveEndpoint(region, iam.EndpointResolverOptions{}); err == nil {
+				signingRegion = resolved.SigningRegion
+			}
+		}
+		return aws.Endpoint{URL: url, SigningRegion: signingRegion, Source: aws.EndpointSourceCustom}, nil
+	})
 }
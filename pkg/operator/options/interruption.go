@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// DrainPolicyEvict evicts the pods on the node through the eviction API, which respects PodDisruptionBudgets
+	DrainPolicyEvict = "Evict"
+	// DrainPolicyDelete deletes the pods on the node without waiting on PodDisruptionBudgets
+	DrainPolicyDelete = "Delete"
+)
+
+// DrainedInterruptionEventTypes are the interruption event types that cause the affected nodes to be drained
+var DrainedInterruptionEventTypes = sets.New("SpotInterruption", "ScheduledChange", "StateChange")
+
+// ParseInterruptionDrainPolicy parses a JSON object that maps interruption event types to drain policies
+func ParseInterruptionDrainPolicy(s string) (map[string]string, error) {
+	policies := map[string]string{}
+	if s == "" {
+		return policies, nil
+	}
+	if err := json.Unmarshal([]byte(s), &policies); err != nil {
+		return nil, fmt.Errorf("unmarshaling drain policy, %w", err)
+	}
+	var errs error
+	for eventType, policy := range policies {
+		if !DrainedInterruptionEventTypes.Has(eventType) {
+			errs = multierr.Append(errs, fmt.Errorf("event type %q is not one of %v", eventType, sets.List(DrainedInterruptionEventTypes)))
+		}
+		if policy != DrainPolicyEvict && policy != DrainPolicyDelete {
+			errs = multierr.Append(errs, fmt.Errorf("drain policy %q for event type %q is not one of %s or %s", policy, eventType, DrainPolicyEvict, DrainPolicyDelete))
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return policies, nil
+}
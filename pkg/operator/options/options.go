@@ -20,8 +20,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+
 	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 )
@@ -30,18 +34,96 @@ func init() {
 	coreoptions.Injectables = append(coreoptions.Injectables, &Options{})
 }
 
+const (
+	// ValidationModeReject rejects resources that fail an optional admission validation
+	ValidationModeReject = "Reject"
+	// ValidationModeWarn admits resources that fail an optional admission validation with a warning
+	ValidationModeWarn = "Warn"
+)
+
 type optionsKey struct{}
 
 type Options struct {
-	AssumeRoleARN           string
-	AssumeRoleDuration      time.Duration
-	ClusterCABundle         string
-	ClusterName             string
-	ClusterEndpoint         string
-	IsolatedVPC             bool
-	VMMemoryOverheadPercent float64
-	InterruptionQueue       string
-	ReservedENIs            int
+	AssumeRoleARN                   string
+	AssumeRoleDuration              time.Duration
+	ClusterCABundle                 string
+	ClusterName                     string
+	ClusterEndpoint                 string
+	IsolatedVPC                     bool
+	VMMemoryOverheadPercent         float64
+	InterruptionQueue               string
+	ReservedENIs                    int
+	CommitmentAwarePricing          bool
+	PricingOverridesConfigMap       string
+	PricingFile                     string
+	PricingRefreshInterval          time.Duration
+	SpotPricingRefreshInterval      time.Duration
+	PricingEndpoint                 string
+	CostAllocationTags              string
+	ComputeOptimizerRecommendations bool
+	ScheduledMaintenanceLeadTime    time.Duration
+	ManagedInterruptionQueue        bool
+	InterruptionQueueKMSKeyID       string
+	InterruptionQueueTags           string
+	InterruptionQueueRoleARN        string
+	InterruptionDrainPolicy         string
+	InterruptionEndpointPort        int
+	InterruptionEndpointAPIKey      string
+	SharedInterruptionQueue         bool
+	InstanceStatusRepairPeriod      time.Duration
+	LaunchDiagnostics               bool
+	LaunchDiagnosticsBucket         string
+	StoppedInstancePoolSize         int
+	StoppedInstancePoolTTL          time.Duration
+	PreTerminationWebhookURL        string
+	PreTerminationLambda            string
+	PreTerminationTimeout           time.Duration
+	MaxConcurrentLaunches           int
+	VolumeGarbageCollectionAge      time.Duration
+	VolumeGarbageCollectionDryRun   bool
+	GarbageCollectionInterval       time.Duration
+	GarbageCollectionBatchSize      int
+	GarbageCollectionListWorkers    int
+	InPlaceMetadataOptionsUpdate    bool
+	InPlaceSecurityGroupsUpdate     bool
+	LabelTags                       string
+	TagLabels                       string
+	TagAnnotations                  string
+	TagPolicy                       string
+	InstanceNameTemplate            string
+	DriftReconciliationInterval     time.Duration
+	ManageNodeRoles                 bool
+	NodeRolePolicyARNs              string
+	ManageAWSAuth                   bool
+	UseFIPSEndpoints                bool
+	EC2Endpoint                     string
+	SSMEndpoint                     string
+	SQSEndpoint                     string
+	IAMEndpoint                     string
+	EKSEndpoint                     string
+	PermissionCheck                 bool
+	SettingsConfigMap               string
+	QuotaAwareProvisioning          bool
+	QuotaWarningThreshold           float64
+	TracingEndpoint                 string
+	CloudWatchMetricsLogGroup       string
+	EventBridgeBus                  string
+	AuditLog                        bool
+	DebugEndpointPort               int
+	DebugEndpointAPIKey             string
+	AWSHealthCheck                  bool
+	AMICacheTTL                     time.Duration
+	AWSAPIRateLimits                string
+	CircuitBreakerThreshold         int
+	CacheSnapshotPath               string
+	ResourceChangeEvents            bool
+	SelectorValidation              string
+	AMICompatibilityValidation      string
+	InstanceProfileValidation       string
+
+	// live holds the options that are published while the controller runs. It's set on the options that are injected
+	// into every context, since the controllers run with contexts that the manager created before the controller started.
+	live *atomic.Pointer[Options]
 }
 
 func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
@@ -52,8 +134,75 @@ func (o *Options) AddFlags(fs *coreoptions.FlagSet) {
 	fs.StringVar(&o.ClusterEndpoint, "cluster-endpoint", env.WithDefaultString("CLUSTER_ENDPOINT", ""), "The external kubernetes cluster endpoint for new nodes to connect with. If not specified, will discover the cluster endpoint using DescribeCluster API.")
 	fs.BoolVarWithEnv(&o.IsolatedVPC, "isolated-vpc", "ISOLATED_VPC", false, "If true, then assume we can't reach AWS services which don't have a VPC endpoint. This also has the effect of disabling look-ups to the AWS on-demand pricing endpoint.")
 	fs.Float64Var(&o.VMMemoryOverheadPercent, "vm-memory-overhead-percent", env.WithDefaultFloat64("VM_MEMORY_OVERHEAD_PERCENT", 0.075), "The VM memory overhead as a percent that will be subtracted from the total memory for all instance types.")
-	fs.StringVar(&o.InterruptionQueue, "interruption-queue", env.WithDefaultString("INTERRUPTION_QUEUE", ""), "Interruption queue is disabled if not specified. Enabling interruption handling may require additional permissions on the controller service account. Additional permissions are outlined in the docs.")
+	fs.StringVar(&o.InterruptionQueue, "interruption-queue", env.WithDefaultString("INTERRUPTION_QUEUE", ""), "Name or URL of the interruption queue. The URL must be used for queues that are owned by a different account. Interruption queue is disabled if not specified. Enabling interruption handling may require additional permissions on the controller service account. Additional permissions are outlined in the docs.")
 	fs.IntVar(&o.ReservedENIs, "reserved-enis", env.WithDefaultInt("RESERVED_ENIS", 0), "Reserved ENIs are not included in the calculations for max-pods or kube-reserved. This is most often used in the VPC CNI custom networking setup https://docs.aws.amazon.com/eks/latest/userguide/cni-custom-network.html.")
+	fs.BoolVarWithEnv(&o.CommitmentAwarePricing, "commitment-aware-pricing", "COMMITMENT_AWARE_PRICING", false, "If true, on-demand prices are adjusted to reflect active Reserved Instances and Savings Plans in the region that have coverage left, which isn't used by the account's running on-demand instances, so that capacity which has already been purchased is preferred. Requires additional permissions on the controller service account.")
+	fs.StringVar(&o.PricingOverridesConfigMap, "pricing-overrides-configmap", env.WithDefaultString("PRICING_OVERRIDES_CONFIGMAP", ""), "Name of a ConfigMap in the Karpenter namespace containing price overrides under the 'overrides' key. Price overrides take precedence over prices discovered from the AWS APIs. Price overrides are disabled if not specified.")
+	fs.StringVar(&o.PricingFile, "pricing-file", env.WithDefaultString("PRICING_FILE", ""), "Path to a file, usually mounted from a ConfigMap, containing static on-demand and, optionally, spot prices for the region. If specified, the AWS pricing API is not called and prices are re-read from the file on every pricing refresh.")
+	fs.DurationVar(&o.PricingRefreshInterval, "pricing-refresh-interval", env.WithDefaultDuration("PRICING_REFRESH_INTERVAL", 12*time.Hour), "The interval at which on-demand pricing data is refreshed.")
+	fs.DurationVar(&o.SpotPricingRefreshInterval, "spot-pricing-refresh-interval", env.WithDefaultDuration("SPOT_PRICING_REFRESH_INTERVAL", 12*time.Hour), "The interval at which spot pricing data is refreshed.")
+	fs.StringVar(&o.PricingEndpoint, "pricing-endpoint", env.WithDefaultString("PRICING_ENDPOINT", ""), "Custom endpoint for the AWS pricing API, such as a VPC endpoint. If specified, on-demand pricing is refreshed through this endpoint even when running in an isolated VPC.")
+	fs.StringVar(&o.CostAllocationTags, "cost-allocation-tags", env.WithDefaultString("COST_ALLOCATION_TAGS", ""), "JSON object mapping tag keys to Go templates that are rendered against the NodePool and EC2NodeClass of each launch. The rendered tags are applied to instances, volumes, and network interfaces at creation. Tags that render to an empty value are not applied.")
+	fs.BoolVarWithEnv(&o.ComputeOptimizerRecommendations, "compute-optimizer-recommendations", "COMPUTE_OPTIMIZER_RECOMMENDATIONS", false, "If true, Compute Optimizer recommendations for the instances that Karpenter launched are exposed as metrics and as events on NodePools. Requires additional permissions on the controller service account.")
+	fs.DurationVar(&o.ScheduledMaintenanceLeadTime, "scheduled-maintenance-lead-time", env.WithDefaultDuration("SCHEDULED_MAINTENANCE_LEAD_TIME", time.Hour), "Nodes affected by a scheduled maintenance event, such as an instance retirement, are replaced through drift ahead of the event while respecting disruption budgets. Nodes that have not been replaced this long before the event starts are terminated regardless of disruption budgets.")
+	fs.BoolVarWithEnv(&o.ManagedInterruptionQueue, "managed-interruption-queue", "MANAGED_INTERRUPTION_QUEUE", false, "If true, Karpenter creates and manages the queue named by --interruption-queue along with the EventBridge rules that forward interruption events to it. Requires additional permissions on the controller service account.")
+	fs.StringVar(&o.InterruptionQueueKMSKeyID, "interruption-queue-kms-key-id", env.WithDefaultString("INTERRUPTION_QUEUE_KMS_KEY_ID", ""), "ID or ARN of the KMS key used to encrypt the managed interruption queue. If not specified, the queue is encrypted with SQS owned keys. Not used unless --managed-interruption-queue is set.")
+	fs.StringVar(&o.InterruptionQueueTags, "interruption-queue-tags", env.WithDefaultString("INTERRUPTION_QUEUE_TAGS", ""), "JSON object of tags that are applied to the managed interruption queue and EventBridge rules. Not used unless --managed-interruption-queue is set.")
+	fs.StringVar(&o.InterruptionQueueRoleARN, "interruption-queue-role-arn", env.WithDefaultString("INTERRUPTION_QUEUE_ROLE_ARN", ""), "Role to assume for calling SQS on the interruption queue. This is most often used when the interruption queue is owned by a different account, in which case --interruption-queue should be set to the URL of the queue.")
+	fs.StringVar(&o.InterruptionDrainPolicy, "interruption-drain-policy", env.WithDefaultString("INTERRUPTION_DRAIN_POLICY", ""), "JSON object mapping interruption event types to how the pods on the affected nodes are drained. Event types are SpotInterruption, ScheduledChange, and StateChange. Drain policies are Evict, which evicts pods while respecting PodDisruptionBudgets, and Delete, which deletes pods without waiting on PodDisruptionBudgets. Event types that aren't specified use Evict.")
+	fs.IntVar(&o.InterruptionEndpointPort, "interruption-endpoint-port", env.WithDefaultInt("INTERRUPTION_ENDPOINT_PORT", 0), "The port the interruption endpoint binds to for receiving interruption events pushed by an EventBridge API destination. The interruption endpoint is disabled if not specified.")
+	fs.StringVar(&o.InterruptionEndpointAPIKey, "interruption-endpoint-api-key", env.WithDefaultString("INTERRUPTION_ENDPOINT_API_KEY", ""), "API key that requests to the interruption endpoint must pass in the X-Karpenter-Api-Key header. Required if --interruption-endpoint-port is set.")
+	fs.BoolVarWithEnv(&o.SharedInterruptionQueue, "shared-interruption-queue", "SHARED_INTERRUPTION_QUEUE", false, "If true, the interruption queue is assumed to be shared with other clusters. Messages for instances that were launched by a different cluster are returned to the queue rather than deleted, so that the owning cluster can handle them. Requires additional permissions on the controller service account.")
+	fs.DurationVar(&o.InstanceStatusRepairPeriod, "instance-status-repair-period", env.WithDefaultDuration("INSTANCE_STATUS_REPAIR_PERIOD", 0), "Nodes whose EC2 instance or system status checks have been impaired for this long are replaced through drift while respecting disruption budgets. Repair is disabled if not specified. Requires additional permissions on the controller service account.")
+	fs.BoolVarWithEnv(&o.LaunchDiagnostics, "launch-diagnostics", "LAUNCH_DIAGNOSTICS", false, "If true, the EC2 console output of instances that haven't registered or initialized 10 minutes after they were launched is collected and summarized on the NodeClaim. Requires additional permissions on the controller service account.")
+	fs.StringVar(&o.LaunchDiagnosticsBucket, "launch-diagnostics-bucket", env.WithDefaultString("LAUNCH_DIAGNOSTICS_BUCKET", ""), "Name of an S3 bucket that the full console output and a console screenshot are uploaded to when launch diagnostics are collected. Not used unless --launch-diagnostics is set.")
+	fs.IntVar(&o.StoppedInstancePoolSize, "stopped-instance-pool-size", env.WithDefaultInt("STOPPED_INSTANCE_POOL_SIZE", 0), "The maximum number of stopped on-demand instances that are kept for reuse per NodePool. When greater than 0, instances of NodeClaims that are disrupted through consolidation or emptiness are stopped instead of terminated, and stopped instances are started instead of launching new ones when they are compatible with a NodeClaim.")
+	fs.DurationVar(&o.StoppedInstancePoolTTL, "stopped-instance-pool-ttl", env.WithDefaultDuration("STOPPED_INSTANCE_POOL_TTL", 24*time.Hour), "The duration that stopped instances are kept for reuse before they are terminated.")
+	fs.StringVar(&o.PreTerminationWebhookURL, "pre-termination-webhook-url", env.WithDefaultString("PRE_TERMINATION_WEBHOOK_URL", ""), "URL of an HTTP endpoint that is sent a POST request with the metadata of each instance and its NodeClaim before the instance is terminated. Termination waits for the endpoint to respond with a 2xx status, bounded by --pre-termination-timeout. The pre-termination webhook is disabled if not specified.")
+	fs.StringVar(&o.PreTerminationLambda, "pre-termination-lambda", env.WithDefaultString("PRE_TERMINATION_LAMBDA", ""), "Name or ARN of a Lambda function that is invoked synchronously with the metadata of each instance and its NodeClaim before the instance is terminated. Termination waits for the function to return without an error, bounded by --pre-termination-timeout. The pre-termination Lambda is disabled if not specified. Requires additional permissions on the controller service account.")
+	fs.DurationVar(&o.PreTerminationTimeout, "pre-termination-timeout", env.WithDefaultDuration("PRE_TERMINATION_TIMEOUT", 5*time.Minute), "The maximum duration that termination of an instance waits on the pre-termination webhook and Lambda. Instances are terminated once this has elapsed since the hooks were first called, even if the hooks haven't succeeded.")
+	fs.IntVar(&o.MaxConcurrentLaunches, "max-concurrent-launches", env.WithDefaultInt("MAX_CONCURRENT_LAUNCHES", 0), "The maximum number of instance launches that are in flight at the same time across all EC2NodeClasses. Launches beyond this limit wait until earlier launches complete. Launches are not limited if not specified. Launches for a single EC2NodeClass can be limited through the karpenter.k8s.aws/max-concurrent-launches annotation.")
+	fs.DurationVar(&o.VolumeGarbageCollectionAge, "volume-garbage-collection-age", env.WithDefaultDuration("VOLUME_GARBAGE_COLLECTION_AGE", 0), "EBS volumes that were launched by Karpenter and are deleted on termination are deleted once they have been detached for longer than this age. Volume garbage collection is disabled if not specified. Requires additional permissions on the controller service account.")
+	fs.BoolVarWithEnv(&o.VolumeGarbageCollectionDryRun, "volume-garbage-collection-dry-run", "VOLUME_GARBAGE_COLLECTION_DRY_RUN", false, "If true, the unattached EBS volumes that would be garbage collected are logged instead of deleted.")
+	fs.DurationVar(&o.GarbageCollectionInterval, "garbage-collection-interval", env.WithDefaultDuration("GARBAGE_COLLECTION_INTERVAL", 2*time.Minute), "The interval at which instances, network interfaces and volumes that were leaked by Karpenter are garbage collected.")
+	fs.IntVar(&o.GarbageCollectionBatchSize, "garbage-collection-batch-size", env.WithDefaultInt("GARBAGE_COLLECTION_BATCH_SIZE", 100), "The number of leaked instances that are garbage collected concurrently.")
+	fs.IntVar(&o.GarbageCollectionListWorkers, "garbage-collection-list-workers", env.WithDefaultInt("GARBAGE_COLLECTION_LIST_WORKERS", 1), "The number of workers that describe instances concurrently for garbage collection. If greater than 1, instances are described separately for each availability zone, and the accounts of EC2NodeClasses that assume roles are described concurrently.")
+	fs.BoolVarWithEnv(&o.InPlaceMetadataOptionsUpdate, "in-place-metadata-options-update", "IN_PLACE_METADATA_OPTIONS_UPDATE", false, "If true, changes to the metadataOptions of an EC2NodeClass are applied to existing instances with ModifyInstanceMetadataOptions instead of drifting their nodes. Requires additional permissions on the controller service account.")
+	fs.BoolVarWithEnv(&o.InPlaceSecurityGroupsUpdate, "in-place-security-groups-update", "IN_PLACE_SECURITY_GROUPS_UPDATE", false, "If true, changes to the security groups that are selected by an EC2NodeClass are applied to the network interfaces of existing instances with ModifyNetworkInterfaceAttribute instead of drifting their nodes. Requires additional permissions on the controller service account.")
+	fs.StringVar(&o.LabelTags, "label-tags", env.WithDefaultString("LABEL_TAGS", ""), "JSON object mapping node label keys to the tag keys that their values are applied as. The tags are applied to instances, volumes, and network interfaces at creation, and are kept in sync with the labels of the node afterwards. Labels that aren't set are not applied.")
+	fs.StringVar(&o.TagLabels, "tag-labels", env.WithDefaultString("TAG_LABELS", ""), "JSON array of instance tag keys that are synced to node labels with the same keys once nodes have registered. Keys that end in * match all tags with that prefix. Tags whose keys or values aren't valid labels are skipped.")
+	fs.StringVar(&o.TagAnnotations, "tag-annotations", env.WithDefaultString("TAG_ANNOTATIONS", ""), "JSON array of instance tag keys that are synced to node annotations with the same keys once nodes have registered. Keys that end in * match all tags with that prefix.")
+	fs.StringVar(&o.TagPolicy, "tag-policy", env.WithDefaultString("TAG_POLICY", ""), "JSON object with the policy that the tags of EC2NodeClasses must follow. restrictedKeys is a list of regular expressions for tag keys that aren't allowed, requiredPrefixes is a list of prefixes that each tag key must have one of, and maxTags is the maximum number of tags. The policy is enforced when EC2NodeClasses are admitted by the validation webhook, and before instances are launched.")
+	fs.StringVar(&o.InstanceNameTemplate, "instance-name-template", env.WithDefaultString("INSTANCE_NAME_TEMPLATE", ""), "Go template that the Name tag of instances is rendered from once their nodes have registered, instead of the node name. Templates can reference .ClusterName, .Zone, .InstanceType, .CapacityType, .InstanceID, .NodeName, .Suffix, and the .Name, .Labels, and .Annotations of the .NodePool, .NodeClass, and .NodeClaim.")
+	fs.DurationVar(&o.DriftReconciliationInterval, "drift-reconciliation-interval", env.WithDefaultDuration("DRIFT_RECONCILIATION_INTERVAL", 0), "The interval at which every launched NodeClaim is checked for drift against the current resolution of its EC2NodeClass, independently of watch events, to catch drift that was missed. Periodic drift reconciliation is disabled if not specified.")
+	fs.BoolVarWithEnv(&o.ManageNodeRoles, "manage-node-roles", "MANAGE_NODE_ROLES", false, "If true, the IAM role that is set in spec.role of an EC2NodeClass is created when it doesn't exist, with the managed policies that nodes need to join the cluster and an EKS access entry. Roles that Karpenter created are deleted with the last EC2NodeClass that uses them. Requires additional permissions on the controller service account.")
+	fs.StringVar(&o.NodeRolePolicyARNs, "node-role-policy-arns", env.WithDefaultString("NODE_ROLE_POLICY_ARNS", ""), "Comma-separated ARNs of additional IAM policies that are attached to the node roles that are created with --manage-node-roles.")
+	fs.BoolVarWithEnv(&o.ManageAWSAuth, "manage-aws-auth", "MANAGE_AWS_AUTH", false, "If true, the node role of every EC2NodeClass is mapped in the kube-system/aws-auth ConfigMap so that nodes can join clusters that authenticate with the ConfigMap rather than EKS access entries. Mappings are only added, existing mappings are never modified or removed.")
+	fs.BoolVarWithEnv(&o.UseFIPSEndpoints, "use-fips-endpoints", "USE_FIPS_ENDPOINTS", false, "If true, AWS APIs are called through their FIPS endpoints. APIs that don't have FIPS endpoints, such as the pricing API, are called through their standard endpoints.")
+	fs.StringVar(&o.EC2Endpoint, "ec2-endpoint", env.WithDefaultString("EC2_ENDPOINT", ""), "Custom endpoint for the AWS EC2 API, such as a VPC endpoint or a proxy. The standard endpoint is used if not specified.")
+	fs.StringVar(&o.SSMEndpoint, "ssm-endpoint", env.WithDefaultString("SSM_ENDPOINT", ""), "Custom endpoint for the AWS SSM API, such as a VPC endpoint or a proxy. The standard endpoint is used if not specified.")
+	fs.StringVar(&o.SQSEndpoint, "sqs-endpoint", env.WithDefaultString("SQS_ENDPOINT", ""), "Custom endpoint for the AWS SQS API, such as a VPC endpoint or a proxy. The standard endpoint is used if not specified.")
+	fs.StringVar(&o.IAMEndpoint, "iam-endpoint", env.WithDefaultString("IAM_ENDPOINT", ""), "Custom endpoint for the AWS IAM API, such as a VPC endpoint or a proxy. The standard endpoint is used if not specified.")
+	fs.StringVar(&o.EKSEndpoint, "eks-endpoint", env.WithDefaultString("EKS_ENDPOINT", ""), "Custom endpoint for the AWS EKS API, such as a VPC endpoint or a proxy. The standard endpoint is used if not specified.")
+	fs.BoolVarWithEnv(&o.PermissionCheck, "permission-check", "PERMISSION_CHECK", false, "If true, the IAM permissions that the controller needs are simulated against the controller's identity at startup and every hour. The controller isn't ready while permissions are missing.")
+	fs.StringVar(&o.SettingsConfigMap, "settings-configmap", env.WithDefaultString("SETTINGS_CONFIGMAP", ""), "Name of a ConfigMap in the Karpenter namespace whose data sets options by their environment variable name, e.g. BATCH_MAX_DURATION. Values in the ConfigMap take precedence over environment variables and flags, and are reloaded while the controller runs. Settings are only read from environment variables and flags if not specified.")
+	fs.BoolVarWithEnv(&o.QuotaAwareProvisioning, "quota-aware-provisioning", "QUOTA_AWARE_PROVISIONING", false, "If true, offerings are unavailable while launching them would exceed the account's EC2 vCPU service quotas for on-demand and spot instances. Requires servicequotas:GetServiceQuota.")
+	fs.Float64Var(&o.QuotaWarningThreshold, "quota-warning-threshold", env.WithDefaultFloat64("QUOTA_WARNING_THRESHOLD", 0), "Percentage of an AWS service quota, such as the EC2 vCPU quotas, that may be used before warning events are published on the EC2NodeClasses. Quota utilization metrics are exported while it's set. Requires servicequotas:GetServiceQuota. 0 disables quota warnings.")
+	fs.StringVar(&o.TracingEndpoint, "tracing-endpoint", env.WithDefaultString("TRACING_ENDPOINT", ""), "The OTLP gRPC endpoint that traces of AWS API calls and NodeClaim launches are exported to, e.g. http://otel-collector.observability:4317. Tracing is disabled when empty.")
+	fs.StringVar(&o.CloudWatchMetricsLogGroup, "cloudwatch-metrics-log-group", env.WithDefaultString("CLOUDWATCH_METRICS_LOG_GROUP", ""), "Name of a CloudWatch Logs log group that key metrics, such as pending pods, node launch duration, interruption messages and the hourly cost estimate, are published to every minute in the CloudWatch embedded metric format. Requires logs:CreateLogStream and logs:PutLogEvents, and logs:CreateLogGroup if the log group doesn't exist. Publishing is disabled when empty.")
+	fs.StringVar(&o.EventBridgeBus, "eventbridge-bus", env.WithDefaultString("EVENTBRIDGE_BUS", ""), "Name or ARN of an EventBridge event bus that events are published to when NodeClaims are launched, fail to launch or are disrupted, so that automation can react to provisioning and disruption decisions. Requires events:PutEvents on the event bus. Publishing is disabled when empty.")
+	fs.BoolVarWithEnv(&o.AuditLog, "audit-log", "AUDIT_LOG", false, "If true, every AWS API call that creates, modifies or deletes resources is logged by the audit logger, with the NodeClaims and EC2NodeClass that the call was made for.")
+	fs.IntVar(&o.DebugEndpointPort, "debug-endpoint-port", env.WithDefaultInt("DEBUG_ENDPOINT_PORT", 0), "The port the debug endpoint binds to for dumping the instance types, pricing, unavailable offerings and resolved EC2NodeClass resources that the controller has cached. The debug endpoint is disabled if not specified.")
+	fs.StringVar(&o.DebugEndpointAPIKey, "debug-endpoint-api-key", env.WithDefaultString("DEBUG_ENDPOINT_API_KEY", ""), "API key that requests to the debug endpoint must pass in the X-Karpenter-Api-Key header. Required if --debug-endpoint-port is set.")
+	fs.BoolVarWithEnv(&o.AWSHealthCheck, "aws-health-check", "AWS_HEALTH_CHECK", false, "If true, the controller's AWS credentials and the read permissions that launches depend on (ec2:DescribeInstanceTypes, ec2:DescribeSubnets and ssm:GetParameter) are verified with real API calls every 5 minutes. The controller isn't ready and warning events are published on the EC2NodeClasses while the calls fail.")
+	fs.DurationVar(&o.AMICacheTTL, "ami-cache-ttl", env.WithDefaultDuration("AMI_CACHE_TTL", time.Minute), "The time that AMIs which are resolved through SSM parameters and AMI selector terms are cached for. EC2NodeClasses with the same AMI selector terms share the cached images.")
+	fs.StringVar(&o.AWSAPIRateLimits, "aws-api-rate-limits", env.WithDefaultString("AWS_API_RATE_LIMITS", ""), "JSON object mapping AWS APIs to the client-side rate limits of the requests that the controller sends to them, e.g. {\"ec2:CreateFleet\":{\"qps\":5,\"burst\":10},\"ec2\":{\"qps\":20}}. APIs are either a service, which limits all of its operations, or a service and operation separated by a colon, which take precedence over the service. Services are named as in the karpenter_cloudprovider_api_requests_total metric. burst defaults to 1. Requests wait for the rate limit, including retries, and the limits are shared by all accounts. Requests aren't limited if not specified.")
+	fs.IntVar(&o.CircuitBreakerThreshold, "circuit-breaker-threshold", env.WithDefaultInt("CIRCUIT_BREAKER_THRESHOLD", 0), "The number of requests to AWS APIs that may be throttled in a minute before non-critical requests, such as pricing refreshes and tag reconciliation, are shed for a minute so that launches and terminations aren't slowed down by throttling. The circuit breaker is disabled if not specified.")
+	fs.StringVar(&o.CacheSnapshotPath, "cache-snapshot-path", env.WithDefaultString("CACHE_SNAPSHOT_PATH", ""), "Path to a file, usually on a persistent volume, that the instance types, instance type offerings and prices that the controller retrieved from AWS are saved to every 5 minutes. The file is restored when the controller starts so that it can make launch decisions before the AWS APIs are called again. Snapshots that are older than 24 hours aren't restored. Snapshots are disabled if not specified.")
+	fs.BoolVarWithEnv(&o.ResourceChangeEvents, "resource-change-events", "RESOURCE_CHANGE_EVENTS", false, "If true, the subnet, security group and AMI caches are invalidated as soon as EventBridge reports that those resources were created, deleted or retagged, instead of when the caches expire. Requires interruption-queue to be set and CloudTrail to be enabled in the account.")
+	fs.StringVar(&o.SelectorValidation, "selector-validation", env.WithDefaultString("SELECTOR_VALIDATION", ""), "How the validation webhook handles EC2NodeClasses whose subnetSelectorTerms, securityGroupSelectorTerms or amiSelectorTerms don't match any resources when they're created or their selector terms are updated. Reject rejects them, and Warn admits them with a warning. Selector terms are resolved in the account of the EC2NodeClass. EC2NodeClasses whose selector terms can't be resolved, for example because AWS APIs are unavailable, are admitted with a warning. Selector terms aren't resolved at admission if not specified.")
+	fs.StringVar(&o.AMICompatibilityValidation, "ami-compatibility-validation", env.WithDefaultString("AMI_COMPATIBILITY_VALIDATION", ""), "How the validation webhook handles EC2NodeClasses whose AMIs aren't compatible with the requirements, such as kubernetes.io/arch, of a NodePool that references them, so that the NodePool can never launch instances. The AMIs are checked against the NodePools when the EC2NodeClass is created or its amiFamily or amiSelectorTerms are updated. Reject rejects them, and Warn admits them with a warning. AMIs are resolved in the account of the EC2NodeClass. AMIs aren't checked against NodePools at admission if not specified.")
+	fs.StringVar(&o.InstanceProfileValidation, "instance-profile-validation", env.WithDefaultString("INSTANCE_PROFILE_VALIDATION", ""), "How the validation webhook handles EC2NodeClasses whose role or instance profile doesn't exist, or whose instance profile has no role, when they're created or their role, instanceProfile or instanceProfileSelectorTerms are updated. Reject rejects them, and Warn admits them with a warning. Roles aren't checked when Karpenter manages node roles. Roles and instance profiles are resolved in the account of the EC2NodeClass. Roles and instance profiles aren't resolved at admission if not specified.")
 }
 
 func (o *Options) Parse(fs *coreoptions.FlagSet, args ...string) error {
@@ -84,3 +233,38 @@ func FromContext(ctx context.Context) *Options {
 	}
 	return retval.(*Options)
 }
+
+// SetLive replaces the options while the controller runs with the options that are stored in live. The stored options
+// must never be modified, so that readers always observe a complete copy. SetLive must be called before the options are
+// read by the controllers.
+func (o *Options) SetLive(live *atomic.Pointer[Options]) {
+	o.live = live
+}
+
+// Live returns the latest options that were stored for the options in the context, or the options in the context if
+// there are none. Options that can change while the controller runs must be read with Live rather than FromContext.
+func Live(ctx context.Context) *Options {
+	opts := FromContext(ctx)
+	if opts != nil && opts.live != nil {
+		if live := opts.live.Load(); live != nil {
+			return live
+		}
+	}
+	return opts
+}
+
+// ParseNodeRolePolicyARNs parses a comma-separated list of IAM policy ARNs
+func ParseNodeRolePolicyARNs(s string) ([]string, error) {
+	var policyARNs []string
+	if s == "" {
+		return policyARNs, nil
+	}
+	for _, policyARN := range strings.Split(s, ",") {
+		policyARN = strings.TrimSpace(policyARN)
+		if a, err := arn.Parse(policyARN); err != nil || a.Service != "iam" || !strings.HasPrefix(a.Resource, "policy/") {
+			return nil, fmt.Errorf("%q isn't the ARN of an IAM policy", policyARN)
+		}
+		policyARNs = append(policyARNs, policyARN)
+	}
+	return policyARNs, nil
+}
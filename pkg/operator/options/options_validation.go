@@ -17,6 +17,7 @@ package options
 import (
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"go.uber.org/multierr"
@@ -28,6 +29,38 @@ func (o Options) Validate() error {
 		o.validateVMMemoryOverheadPercent(),
 		o.validateAssumeRoleDuration(),
 		o.validateReservedENIs(),
+		o.validatePricingRefreshIntervals(),
+		o.validateAMICacheTTL(),
+		o.validateAWSAPIRateLimits(),
+		o.validateCircuitBreakerThreshold(),
+		o.validateServiceEndpoints(),
+		o.validateCostAllocationTags(),
+		o.validateLabelTags(),
+		o.validateTagLabels(),
+		o.validateTagPolicy(),
+		o.validateInstanceNameTemplate(),
+		o.validateScheduledMaintenanceLeadTime(),
+		o.validateInterruptionQueue(),
+		o.validateManagedInterruptionQueue(),
+		o.validateInterruptionDrainPolicy(),
+		o.validateInterruptionEndpoint(),
+		o.validateSharedInterruptionQueue(),
+		o.validateResourceChangeEvents(),
+		o.validateSelectorValidation(),
+		o.validateAMICompatibilityValidation(),
+		o.validateInstanceProfileValidation(),
+		o.validateInstanceStatusRepairPeriod(),
+		o.validateDriftReconciliationInterval(),
+		o.validateLaunchDiagnostics(),
+		o.validateStoppedInstancePool(),
+		o.validatePreTerminationHooks(),
+		o.validateMaxConcurrentLaunches(),
+		o.validateVolumeGarbageCollection(),
+		o.validateGarbageCollection(),
+		o.validateNodeRolePolicyARNs(),
+		o.validateQuotaWarningThreshold(),
+		o.validateTracingEndpoint(),
+		o.validateDebugEndpoint(),
 		o.validateRequiredFields(),
 	)
 }
@@ -66,6 +99,300 @@ func (o Options) validateReservedENIs() error {
 	return nil
 }
 
+func (o Options) validateAMICacheTTL() error {
+	if o.AMICacheTTL <= 0 {
+		return fmt.Errorf("ami-cache-ttl must be positive")
+	}
+	return nil
+}
+
+func (o Options) validateAWSAPIRateLimits() error {
+	if _, err := ParseAWSAPIRateLimits(o.AWSAPIRateLimits); err != nil {
+		return fmt.Errorf("aws-api-rate-limits is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateCircuitBreakerThreshold() error {
+	if o.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("circuit-breaker-threshold cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validatePricingRefreshIntervals() error {
+	if o.PricingRefreshInterval <= 0 {
+		return fmt.Errorf("pricing-refresh-interval must be positive")
+	}
+	if o.SpotPricingRefreshInterval <= 0 {
+		return fmt.Errorf("spot-pricing-refresh-interval must be positive")
+	}
+	return nil
+}
+
+func (o Options) validateServiceEndpoints() error {
+	var errs error
+	for _, flag := range []struct{ name, value string }{
+		{"pricing-endpoint", o.PricingEndpoint},
+		{"ec2-endpoint", o.EC2Endpoint},
+		{"ssm-endpoint", o.SSMEndpoint},
+		{"sqs-endpoint", o.SQSEndpoint},
+		{"iam-endpoint", o.IAMEndpoint},
+		{"eks-endpoint", o.EKSEndpoint},
+	} {
+		if flag.value == "" {
+			continue
+		}
+		endpoint, err := url.Parse(flag.value)
+		if err != nil || !endpoint.IsAbs() || endpoint.Hostname() == "" {
+			errs = multierr.Append(errs, fmt.Errorf("%q is not a valid %s URL", flag.value, flag.name))
+		}
+	}
+	return errs
+}
+
+func (o Options) validateCostAllocationTags() error {
+	if o.CostAllocationTags == "" {
+		return nil
+	}
+	if _, err := ParseCostAllocationTags(o.CostAllocationTags); err != nil {
+		return fmt.Errorf("cost-allocation-tags is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateLabelTags() error {
+	if _, err := ParseLabelTags(o.LabelTags); err != nil {
+		return fmt.Errorf("label-tags is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateTagLabels() error {
+	if _, err := ParseTagKeys(o.TagLabels); err != nil {
+		return fmt.Errorf("tag-labels is invalid, %w", err)
+	}
+	if _, err := ParseTagKeys(o.TagAnnotations); err != nil {
+		return fmt.Errorf("tag-annotations is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateTagPolicy() error {
+	if _, err := ParseTagPolicy(o.TagPolicy); err != nil {
+		return fmt.Errorf("tag-policy is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateInstanceNameTemplate() error {
+	if _, err := ParseInstanceNameTemplate(o.InstanceNameTemplate); err != nil {
+		return fmt.Errorf("instance-name-template is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateScheduledMaintenanceLeadTime() error {
+	if o.ScheduledMaintenanceLeadTime < 0 {
+		return fmt.Errorf("scheduled-maintenance-lead-time cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateInterruptionQueue() error {
+	if !strings.Contains(o.InterruptionQueue, "://") {
+		return nil
+	}
+	queueURL, err := url.Parse(o.InterruptionQueue)
+	if err != nil || !queueURL.IsAbs() || queueURL.Hostname() == "" || strings.Trim(queueURL.Path, "/") == "" {
+		return fmt.Errorf("%q is not a valid interruption-queue URL", o.InterruptionQueue)
+	}
+	return nil
+}
+
+func (o Options) validateManagedInterruptionQueue() error {
+	if !o.ManagedInterruptionQueue {
+		return nil
+	}
+	if o.InterruptionQueue == "" {
+		return fmt.Errorf("managed-interruption-queue requires interruption-queue to be set")
+	}
+	if strings.Contains(o.InterruptionQueue, "://") {
+		return fmt.Errorf("managed-interruption-queue requires interruption-queue to be a queue name")
+	}
+	if _, err := ParseInterruptionQueueTags(o.InterruptionQueueTags); err != nil {
+		return fmt.Errorf("interruption-queue-tags is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateInterruptionDrainPolicy() error {
+	if _, err := ParseInterruptionDrainPolicy(o.InterruptionDrainPolicy); err != nil {
+		return fmt.Errorf("interruption-drain-policy is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateInterruptionEndpoint() error {
+	if o.InterruptionEndpointPort < 0 || o.InterruptionEndpointPort > 65535 {
+		return fmt.Errorf("interruption-endpoint-port must be between 0 and 65535")
+	}
+	if o.InterruptionEndpointPort != 0 && o.InterruptionEndpointAPIKey == "" {
+		return fmt.Errorf("interruption-endpoint-port requires interruption-endpoint-api-key to be set")
+	}
+	return nil
+}
+
+func (o Options) validateSharedInterruptionQueue() error {
+	if o.SharedInterruptionQueue && o.InterruptionQueue == "" {
+		return fmt.Errorf("shared-interruption-queue requires interruption-queue to be set")
+	}
+	return nil
+}
+
+func (o Options) validateResourceChangeEvents() error {
+	if o.ResourceChangeEvents && o.InterruptionQueue == "" {
+		return fmt.Errorf("resource-change-events requires interruption-queue to be set")
+	}
+	return nil
+}
+
+func (o Options) validateSelectorValidation() error {
+	if o.SelectorValidation != "" && o.SelectorValidation != ValidationModeReject && o.SelectorValidation != ValidationModeWarn {
+		return fmt.Errorf("selector-validation must be %s or %s", ValidationModeReject, ValidationModeWarn)
+	}
+	return nil
+}
+
+func (o Options) validateAMICompatibilityValidation() error {
+	if o.AMICompatibilityValidation != "" && o.AMICompatibilityValidation != ValidationModeReject && o.AMICompatibilityValidation != ValidationModeWarn {
+		return fmt.Errorf("ami-compatibility-validation must be %s or %s", ValidationModeReject, ValidationModeWarn)
+	}
+	return nil
+}
+
+func (o Options) validateInstanceProfileValidation() error {
+	if o.InstanceProfileValidation != "" && o.InstanceProfileValidation != ValidationModeReject && o.InstanceProfileValidation != ValidationModeWarn {
+		return fmt.Errorf("instance-profile-validation must be %s or %s", ValidationModeReject, ValidationModeWarn)
+	}
+	return nil
+}
+
+func (o Options) validateInstanceStatusRepairPeriod() error {
+	if o.InstanceStatusRepairPeriod < 0 {
+		return fmt.Errorf("instance-status-repair-period cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateDriftReconciliationInterval() error {
+	if o.DriftReconciliationInterval < 0 {
+		return fmt.Errorf("drift-reconciliation-interval cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateLaunchDiagnostics() error {
+	if o.LaunchDiagnosticsBucket != "" && !o.LaunchDiagnostics {
+		return fmt.Errorf("launch-diagnostics-bucket requires launch-diagnostics to be set")
+	}
+	return nil
+}
+
+func (o Options) validateNodeRolePolicyARNs() error {
+	if o.NodeRolePolicyARNs == "" {
+		return nil
+	}
+	if !o.ManageNodeRoles {
+		return fmt.Errorf("node-role-policy-arns requires manage-node-roles to be set")
+	}
+	if _, err := ParseNodeRolePolicyARNs(o.NodeRolePolicyARNs); err != nil {
+		return fmt.Errorf("node-role-policy-arns is invalid, %w", err)
+	}
+	return nil
+}
+
+func (o Options) validateStoppedInstancePool() error {
+	if o.StoppedInstancePoolSize < 0 {
+		return fmt.Errorf("stopped-instance-pool-size cannot be negative")
+	}
+	if o.StoppedInstancePoolTTL <= 0 {
+		return fmt.Errorf("stopped-instance-pool-ttl must be positive")
+	}
+	return nil
+}
+
+func (o Options) validatePreTerminationHooks() error {
+	if o.PreTerminationWebhookURL != "" {
+		endpoint, err := url.Parse(o.PreTerminationWebhookURL)
+		if err != nil || !endpoint.IsAbs() || endpoint.Hostname() == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+			return fmt.Errorf("%q is not a valid pre-termination-webhook-url", o.PreTerminationWebhookURL)
+		}
+	}
+	if o.PreTerminationTimeout <= 0 {
+		return fmt.Errorf("pre-termination-timeout must be positive")
+	}
+	return nil
+}
+
+func (o Options) validateMaxConcurrentLaunches() error {
+	if o.MaxConcurrentLaunches < 0 {
+		return fmt.Errorf("max-concurrent-launches cannot be negative")
+	}
+	return nil
+}
+
+func (o Options) validateVolumeGarbageCollection() error {
+	if o.VolumeGarbageCollectionAge < 0 {
+		return fmt.Errorf("volume-garbage-collection-age cannot be negative")
+	}
+	if o.VolumeGarbageCollectionDryRun && o.VolumeGarbageCollectionAge == 0 {
+		return fmt.Errorf("volume-garbage-collection-dry-run requires volume-garbage-collection-age to be set")
+	}
+	return nil
+}
+
+func (o Options) validateGarbageCollection() error {
+	if o.GarbageCollectionInterval <= 0 {
+		return fmt.Errorf("garbage-collection-interval must be positive")
+	}
+	if o.GarbageCollectionBatchSize <= 0 {
+		return fmt.Errorf("garbage-collection-batch-size must be positive")
+	}
+	if o.GarbageCollectionListWorkers <= 0 {
+		return fmt.Errorf("garbage-collection-list-workers must be positive")
+	}
+	return nil
+}
+
+func (o Options) validateQuotaWarningThreshold() error {
+	if o.QuotaWarningThreshold < 0 || o.QuotaWarningThreshold > 100 {
+		return fmt.Errorf("quota-warning-threshold must be between 0 and 100")
+	}
+	return nil
+}
+
+func (o Options) validateTracingEndpoint() error {
+	if o.TracingEndpoint == "" {
+		return nil
+	}
+	endpoint, err := url.Parse(o.TracingEndpoint)
+	if err != nil || !endpoint.IsAbs() || endpoint.Hostname() == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return fmt.Errorf("%q is not a valid tracing-endpoint", o.TracingEndpoint)
+	}
+	return nil
+}
+
+func (o Options) validateDebugEndpoint() error {
+	if o.DebugEndpointPort < 0 || o.DebugEndpointPort > 65535 {
+		return fmt.Errorf("debug-endpoint-port must be between 0 and 65535")
+	}
+	if o.DebugEndpointPort != 0 && o.DebugEndpointAPIKey == "" {
+		return fmt.Errorf("debug-endpoint-port requires debug-endpoint-api-key to be set")
+	}
+	return nil
+}
+
 func (o Options) validateRequiredFields() error {
 	if o.ClusterName == "" {
 		return fmt.Errorf("missing field, cluster-name")
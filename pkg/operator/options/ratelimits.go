@@ -0,0 +1,62 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// APIRateLimit is the client-side rate limit of the requests that are sent to an AWS API
+type APIRateLimit struct {
+	QPS   float64 `json:"qps"`
+	Burst int     `json:"burst"`
+}
+
+// ParseAWSAPIRateLimits parses a JSON object that maps AWS services, or services and operations separated by a colon,
+// to rate limits. Bursts that aren't set default to 1.
+func ParseAWSAPIRateLimits(s string) (map[string]APIRateLimit, error) {
+	limits := map[string]APIRateLimit{}
+	if s == "" {
+		return limits, nil
+	}
+	if err := json.Unmarshal([]byte(s), &limits); err != nil {
+		return nil, fmt.Errorf("unmarshaling rate limits, %w", err)
+	}
+	var errs error
+	for api, limit := range limits {
+		service, operation, found := strings.Cut(api, ":")
+		if service == "" || (found && operation == "") {
+			errs = multierr.Append(errs, fmt.Errorf("api %q is not a service or a service and operation separated by a colon", api))
+		}
+		if limit.QPS <= 0 {
+			errs = multierr.Append(errs, fmt.Errorf("qps for api %q must be positive", api))
+		}
+		if limit.Burst < 0 {
+			errs = multierr.Append(errs, fmt.Errorf("burst for api %q cannot be negative", api))
+		}
+		if limit.Burst == 0 {
+			limit.Burst = 1
+			limits[api] = limit
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return limits, nil
+}
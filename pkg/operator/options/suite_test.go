@@ -65,18 +65,152 @@ var _ = Describe("Options", func() {
 			"--isolated-vpc",
 			"--vm-memory-overhead-percent", "0.1",
 			"--interruption-queue", "env-cluster",
-			"--reserved-enis", "10")
+			"--reserved-enis", "10",
+			"--commitment-aware-pricing",
+			"--pricing-overrides-configmap", "env-overrides",
+			"--pricing-file", "/etc/karpenter/pricing.json",
+			"--pricing-refresh-interval", "6h",
+			"--spot-pricing-refresh-interval", "5m",
+			"--pricing-endpoint", "https://pricing.vpce.amazonaws.com",
+			"--cost-allocation-tags", "{\"team\":\"{{ .NodePool.Labels.team }}\"}",
+			"--compute-optimizer-recommendations",
+			"--scheduled-maintenance-lead-time", "2h",
+			"--managed-interruption-queue",
+			"--interruption-queue-kms-key-id", "alias/karpenter",
+			"--interruption-queue-tags", "{\"team\":\"platform\"}",
+			"--interruption-queue-role-arn", "arn:aws:iam::111111111111:role/karpenter-interruption",
+			"--interruption-drain-policy", "{\"SpotInterruption\":\"Delete\"}",
+			"--interruption-endpoint-port", "8090",
+			"--interruption-endpoint-api-key", "api-key",
+			"--shared-interruption-queue",
+			"--instance-status-repair-period", "10m",
+			"--launch-diagnostics",
+			"--launch-diagnostics-bucket", "karpenter-diagnostics",
+			"--stopped-instance-pool-size", "5",
+			"--stopped-instance-pool-ttl", "1h",
+			"--pre-termination-webhook-url", "https://hooks.example.com/terminate",
+			"--pre-termination-lambda", "karpenter-pre-termination",
+			"--pre-termination-timeout", "10m",
+			"--max-concurrent-launches", "20",
+			"--volume-garbage-collection-age", "24h",
+			"--volume-garbage-collection-dry-run",
+			"--garbage-collection-interval", "5m",
+			"--garbage-collection-batch-size", "50",
+			"--garbage-collection-list-workers", "4",
+			"--in-place-metadata-options-update",
+			"--in-place-security-groups-update",
+			"--label-tags", "{\"team\":\"Team\"}",
+			"--tag-labels", "[\"rack\"]",
+			"--tag-annotations", "[\"billing-*\"]",
+			"--tag-policy", "{\"maxTags\":10}",
+			"--instance-name-template", "{{ .ClusterName }}-{{ .Suffix }}",
+			"--drift-reconciliation-interval", "1h",
+			"--manage-node-roles",
+			"--node-role-policy-arns", "arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy",
+			"--manage-aws-auth",
+			"--use-fips-endpoints",
+			"--ec2-endpoint", "https://ec2.vpce.amazonaws.com",
+			"--ssm-endpoint", "https://ssm.vpce.amazonaws.com",
+			"--sqs-endpoint", "https://sqs.vpce.amazonaws.com",
+			"--iam-endpoint", "https://iam.vpce.amazonaws.com",
+			"--eks-endpoint", "https://eks.vpce.amazonaws.com",
+			"--permission-check",
+			"--settings-configmap", "karpenter-settings",
+			"--quota-aware-provisioning",
+			"--quota-warning-threshold", "80",
+			"--tracing-endpoint", "http://otel-collector.observability:4317",
+			"--cloudwatch-metrics-log-group", "/karpenter/metrics",
+			"--eventbridge-bus", "karpenter-events",
+			"--audit-log",
+			"--debug-endpoint-port", "8091",
+			"--debug-endpoint-api-key", "debug-api-key",
+			"--aws-health-check",
+			"--ami-cache-ttl", "5m",
+			"--aws-api-rate-limits", `{"ec2":{"qps":20}}`,
+			"--circuit-breaker-threshold", "50",
+			"--cache-snapshot-path", "/var/lib/karpenter/snapshot.json.gz",
+			"--resource-change-events",
+			"--selector-validation", "Reject",
+			"--ami-compatibility-validation", "Warn",
+			"--instance-profile-validation", "Reject")
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			AssumeRoleARN:           lo.ToPtr("env-role"),
-			AssumeRoleDuration:      lo.ToPtr(20 * time.Minute),
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			AssumeRoleARN:                   lo.ToPtr("env-role"),
+			AssumeRoleDuration:              lo.ToPtr(20 * time.Minute),
+			ClusterCABundle:                 lo.ToPtr("env-bundle"),
+			ClusterName:                     lo.ToPtr("env-cluster"),
+			ClusterEndpoint:                 lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                     lo.ToPtr(true),
+			VMMemoryOverheadPercent:         lo.ToPtr[float64](0.1),
+			InterruptionQueue:               lo.ToPtr("env-cluster"),
+			ReservedENIs:                    lo.ToPtr(10),
+			CommitmentAwarePricing:          lo.ToPtr(true),
+			PricingOverridesConfigMap:       lo.ToPtr("env-overrides"),
+			PricingFile:                     lo.ToPtr("/etc/karpenter/pricing.json"),
+			PricingRefreshInterval:          lo.ToPtr(6 * time.Hour),
+			SpotPricingRefreshInterval:      lo.ToPtr(5 * time.Minute),
+			PricingEndpoint:                 lo.ToPtr("https://pricing.vpce.amazonaws.com"),
+			CostAllocationTags:              lo.ToPtr(`{"team":"{{ .NodePool.Labels.team }}"}`),
+			ComputeOptimizerRecommendations: lo.ToPtr(true),
+			ScheduledMaintenanceLeadTime:    lo.ToPtr(2 * time.Hour),
+			ManagedInterruptionQueue:        lo.ToPtr(true),
+			InterruptionQueueKMSKeyID:       lo.ToPtr("alias/karpenter"),
+			InterruptionQueueTags:           lo.ToPtr(`{"team":"platform"}`),
+			InterruptionQueueRoleARN:        lo.ToPtr("arn:aws:iam::111111111111:role/karpenter-interruption"),
+			InterruptionDrainPolicy:         lo.ToPtr(`{"SpotInterruption":"Delete"}`),
+			InterruptionEndpointPort:        lo.ToPtr(8090),
+			InterruptionEndpointAPIKey:      lo.ToPtr("api-key"),
+			SharedInterruptionQueue:         lo.ToPtr(true),
+			InstanceStatusRepairPeriod:      lo.ToPtr(10 * time.Minute),
+			LaunchDiagnostics:               lo.ToPtr(true),
+			LaunchDiagnosticsBucket:         lo.ToPtr("karpenter-diagnostics"),
+			StoppedInstancePoolSize:         lo.ToPtr(5),
+			StoppedInstancePoolTTL:          lo.ToPtr(time.Hour),
+			PreTerminationWebhookURL:        lo.ToPtr("https://hooks.example.com/terminate"),
+			PreTerminationLambda:            lo.ToPtr("karpenter-pre-termination"),
+			PreTerminationTimeout:           lo.ToPtr(10 * time.Minute),
+			MaxConcurrentLaunches:           lo.ToPtr(20),
+			VolumeGarbageCollectionAge:      lo.ToPtr(24 * time.Hour),
+			VolumeGarbageCollectionDryRun:   lo.ToPtr(true),
+			GarbageCollectionInterval:       lo.ToPtr(5 * time.Minute),
+			GarbageCollectionBatchSize:      lo.ToPtr(50),
+			GarbageCollectionListWorkers:    lo.ToPtr(4),
+			InPlaceMetadataOptionsUpdate:    lo.ToPtr(true),
+			InPlaceSecurityGroupsUpdate:     lo.ToPtr(true),
+			LabelTags:                       lo.ToPtr(`{"team":"Team"}`),
+			TagLabels:                       lo.ToPtr(`["rack"]`),
+			TagAnnotations:                  lo.ToPtr(`["billing-*"]`),
+			TagPolicy:                       lo.ToPtr(`{"maxTags":10}`),
+			InstanceNameTemplate:            lo.ToPtr("{{ .ClusterName }}-{{ .Suffix }}"),
+			DriftReconciliationInterval:     lo.ToPtr(time.Hour),
+			ManageNodeRoles:                 lo.ToPtr(true),
+			NodeRolePolicyARNs:              lo.ToPtr("arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy"),
+			ManageAWSAuth:                   lo.ToPtr(true),
+			UseFIPSEndpoints:                lo.ToPtr(true),
+			EC2Endpoint:                     lo.ToPtr("https://ec2.vpce.amazonaws.com"),
+			SSMEndpoint:                     lo.ToPtr("https://ssm.vpce.amazonaws.com"),
+			SQSEndpoint:                     lo.ToPtr("https://sqs.vpce.amazonaws.com"),
+			IAMEndpoint:                     lo.ToPtr("https://iam.vpce.amazonaws.com"),
+			EKSEndpoint:                     lo.ToPtr("https://eks.vpce.amazonaws.com"),
+			PermissionCheck:                 lo.ToPtr(true),
+			SettingsConfigMap:               lo.ToPtr("karpenter-settings"),
+			QuotaAwareProvisioning:          lo.ToPtr(true),
+			QuotaWarningThreshold:           lo.ToPtr(80.0),
+			TracingEndpoint:                 lo.ToPtr("http://otel-collector.observability:4317"),
+			CloudWatchMetricsLogGroup:       lo.ToPtr("/karpenter/metrics"),
+			EventBridgeBus:                  lo.ToPtr("karpenter-events"),
+			AuditLog:                        lo.ToPtr(true),
+			DebugEndpointPort:               lo.ToPtr(8091),
+			DebugEndpointAPIKey:             lo.ToPtr("debug-api-key"),
+			AWSHealthCheck:                  lo.ToPtr(true),
+			AMICacheTTL:                     lo.ToPtr(5 * time.Minute),
+			AWSAPIRateLimits:                lo.ToPtr(`{"ec2":{"qps":20}}`),
+			CircuitBreakerThreshold:         lo.ToPtr(50),
+			CacheSnapshotPath:               lo.ToPtr("/var/lib/karpenter/snapshot.json.gz"),
+			ResourceChangeEvents:            lo.ToPtr(true),
+			SelectorValidation:              lo.ToPtr("Reject"),
+			AMICompatibilityValidation:      lo.ToPtr("Warn"),
+			InstanceProfileValidation:       lo.ToPtr("Reject"),
 		}))
 	})
 	It("should correctly fallback to env vars when CLI flags aren't set", func() {
@@ -89,6 +223,73 @@ var _ = Describe("Options", func() {
 		os.Setenv("VM_MEMORY_OVERHEAD_PERCENT", "0.1")
 		os.Setenv("INTERRUPTION_QUEUE", "env-cluster")
 		os.Setenv("RESERVED_ENIS", "10")
+		os.Setenv("COMMITMENT_AWARE_PRICING", "true")
+		os.Setenv("PRICING_OVERRIDES_CONFIGMAP", "env-overrides")
+		os.Setenv("PRICING_FILE", "/etc/karpenter/pricing.json")
+		os.Setenv("PRICING_REFRESH_INTERVAL", "6h")
+		os.Setenv("SPOT_PRICING_REFRESH_INTERVAL", "5m")
+		os.Setenv("PRICING_ENDPOINT", "https://pricing.vpce.amazonaws.com")
+		os.Setenv("COST_ALLOCATION_TAGS", "{\"team\":\"{{ .NodePool.Labels.team }}\"}")
+		os.Setenv("COMPUTE_OPTIMIZER_RECOMMENDATIONS", "true")
+		os.Setenv("SCHEDULED_MAINTENANCE_LEAD_TIME", "2h")
+		os.Setenv("MANAGED_INTERRUPTION_QUEUE", "true")
+		os.Setenv("INTERRUPTION_QUEUE_KMS_KEY_ID", "alias/karpenter")
+		os.Setenv("INTERRUPTION_QUEUE_TAGS", "{\"team\":\"platform\"}")
+		os.Setenv("INTERRUPTION_QUEUE_ROLE_ARN", "arn:aws:iam::111111111111:role/karpenter-interruption")
+		os.Setenv("INTERRUPTION_DRAIN_POLICY", "{\"SpotInterruption\":\"Delete\"}")
+		os.Setenv("INTERRUPTION_ENDPOINT_PORT", "8090")
+		os.Setenv("INTERRUPTION_ENDPOINT_API_KEY", "api-key")
+		os.Setenv("SHARED_INTERRUPTION_QUEUE", "true")
+		os.Setenv("INSTANCE_STATUS_REPAIR_PERIOD", "10m")
+		os.Setenv("LAUNCH_DIAGNOSTICS", "true")
+		os.Setenv("LAUNCH_DIAGNOSTICS_BUCKET", "karpenter-diagnostics")
+		os.Setenv("STOPPED_INSTANCE_POOL_SIZE", "5")
+		os.Setenv("STOPPED_INSTANCE_POOL_TTL", "1h")
+		os.Setenv("PRE_TERMINATION_WEBHOOK_URL", "https://hooks.example.com/terminate")
+		os.Setenv("PRE_TERMINATION_LAMBDA", "karpenter-pre-termination")
+		os.Setenv("PRE_TERMINATION_TIMEOUT", "10m")
+		os.Setenv("MAX_CONCURRENT_LAUNCHES", "20")
+		os.Setenv("VOLUME_GARBAGE_COLLECTION_AGE", "24h")
+		os.Setenv("VOLUME_GARBAGE_COLLECTION_DRY_RUN", "true")
+		os.Setenv("GARBAGE_COLLECTION_INTERVAL", "5m")
+		os.Setenv("GARBAGE_COLLECTION_BATCH_SIZE", "50")
+		os.Setenv("GARBAGE_COLLECTION_LIST_WORKERS", "4")
+		os.Setenv("IN_PLACE_METADATA_OPTIONS_UPDATE", "true")
+		os.Setenv("IN_PLACE_SECURITY_GROUPS_UPDATE", "true")
+		os.Setenv("LABEL_TAGS", "{\"team\":\"Team\"}")
+		os.Setenv("TAG_LABELS", "[\"rack\"]")
+		os.Setenv("TAG_ANNOTATIONS", "[\"billing-*\"]")
+		os.Setenv("TAG_POLICY", "{\"maxTags\":10}")
+		os.Setenv("INSTANCE_NAME_TEMPLATE", "{{ .ClusterName }}-{{ .Suffix }}")
+		os.Setenv("DRIFT_RECONCILIATION_INTERVAL", "1h")
+		os.Setenv("MANAGE_NODE_ROLES", "true")
+		os.Setenv("NODE_ROLE_POLICY_ARNS", "arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy")
+		os.Setenv("MANAGE_AWS_AUTH", "true")
+		os.Setenv("USE_FIPS_ENDPOINTS", "true")
+		os.Setenv("EC2_ENDPOINT", "https://ec2.vpce.amazonaws.com")
+		os.Setenv("SSM_ENDPOINT", "https://ssm.vpce.amazonaws.com")
+		os.Setenv("SQS_ENDPOINT", "https://sqs.vpce.amazonaws.com")
+		os.Setenv("IAM_ENDPOINT", "https://iam.vpce.amazonaws.com")
+		os.Setenv("EKS_ENDPOINT", "https://eks.vpce.amazonaws.com")
+		os.Setenv("PERMISSION_CHECK", "true")
+		os.Setenv("SETTINGS_CONFIGMAP", "karpenter-settings")
+		os.Setenv("QUOTA_AWARE_PROVISIONING", "true")
+		os.Setenv("QUOTA_WARNING_THRESHOLD", "80")
+		os.Setenv("TRACING_ENDPOINT", "http://otel-collector.observability:4317")
+		os.Setenv("CLOUDWATCH_METRICS_LOG_GROUP", "/karpenter/metrics")
+		os.Setenv("EVENTBRIDGE_BUS", "karpenter-events")
+		os.Setenv("AUDIT_LOG", "true")
+		os.Setenv("DEBUG_ENDPOINT_PORT", "8091")
+		os.Setenv("DEBUG_ENDPOINT_API_KEY", "debug-api-key")
+		os.Setenv("AWS_HEALTH_CHECK", "true")
+		os.Setenv("AMI_CACHE_TTL", "5m")
+		os.Setenv("AWS_API_RATE_LIMITS", "{\"ec2\":{\"qps\":20}}")
+		os.Setenv("CIRCUIT_BREAKER_THRESHOLD", "50")
+		os.Setenv("CACHE_SNAPSHOT_PATH", "/var/lib/karpenter/snapshot.json.gz")
+		os.Setenv("RESOURCE_CHANGE_EVENTS", "true")
+		os.Setenv("SELECTOR_VALIDATION", "Reject")
+		os.Setenv("AMI_COMPATIBILITY_VALIDATION", "Warn")
+		os.Setenv("INSTANCE_PROFILE_VALIDATION", "Reject")
 
 		// Add flags after we set the environment variables so that the parsing logic correctly refers
 		// to the new environment variable values
@@ -96,15 +297,82 @@ var _ = Describe("Options", func() {
 		err := opts.Parse(fs)
 		Expect(err).ToNot(HaveOccurred())
 		expectOptionsEqual(opts, test.Options(test.OptionsFields{
-			AssumeRoleARN:           lo.ToPtr("env-role"),
-			AssumeRoleDuration:      lo.ToPtr(20 * time.Minute),
-			ClusterCABundle:         lo.ToPtr("env-bundle"),
-			ClusterName:             lo.ToPtr("env-cluster"),
-			ClusterEndpoint:         lo.ToPtr("https://env-cluster"),
-			IsolatedVPC:             lo.ToPtr(true),
-			VMMemoryOverheadPercent: lo.ToPtr[float64](0.1),
-			InterruptionQueue:       lo.ToPtr("env-cluster"),
-			ReservedENIs:            lo.ToPtr(10),
+			AssumeRoleARN:                   lo.ToPtr("env-role"),
+			AssumeRoleDuration:              lo.ToPtr(20 * time.Minute),
+			ClusterCABundle:                 lo.ToPtr("env-bundle"),
+			ClusterName:                     lo.ToPtr("env-cluster"),
+			ClusterEndpoint:                 lo.ToPtr("https://env-cluster"),
+			IsolatedVPC:                     lo.ToPtr(true),
+			VMMemoryOverheadPercent:         lo.ToPtr[float64](0.1),
+			InterruptionQueue:               lo.ToPtr("env-cluster"),
+			ReservedENIs:                    lo.ToPtr(10),
+			CommitmentAwarePricing:          lo.ToPtr(true),
+			PricingOverridesConfigMap:       lo.ToPtr("env-overrides"),
+			PricingFile:                     lo.ToPtr("/etc/karpenter/pricing.json"),
+			PricingRefreshInterval:          lo.ToPtr(6 * time.Hour),
+			SpotPricingRefreshInterval:      lo.ToPtr(5 * time.Minute),
+			PricingEndpoint:                 lo.ToPtr("https://pricing.vpce.amazonaws.com"),
+			CostAllocationTags:              lo.ToPtr(`{"team":"{{ .NodePool.Labels.team }}"}`),
+			ComputeOptimizerRecommendations: lo.ToPtr(true),
+			ScheduledMaintenanceLeadTime:    lo.ToPtr(2 * time.Hour),
+			ManagedInterruptionQueue:        lo.ToPtr(true),
+			InterruptionQueueKMSKeyID:       lo.ToPtr("alias/karpenter"),
+			InterruptionQueueTags:           lo.ToPtr(`{"team":"platform"}`),
+			InterruptionQueueRoleARN:        lo.ToPtr("arn:aws:iam::111111111111:role/karpenter-interruption"),
+			InterruptionDrainPolicy:         lo.ToPtr(`{"SpotInterruption":"Delete"}`),
+			InterruptionEndpointPort:        lo.ToPtr(8090),
+			InterruptionEndpointAPIKey:      lo.ToPtr("api-key"),
+			SharedInterruptionQueue:         lo.ToPtr(true),
+			InstanceStatusRepairPeriod:      lo.ToPtr(10 * time.Minute),
+			LaunchDiagnostics:               lo.ToPtr(true),
+			LaunchDiagnosticsBucket:         lo.ToPtr("karpenter-diagnostics"),
+			StoppedInstancePoolSize:         lo.ToPtr(5),
+			StoppedInstancePoolTTL:          lo.ToPtr(time.Hour),
+			PreTerminationWebhookURL:        lo.ToPtr("https://hooks.example.com/terminate"),
+			PreTerminationLambda:            lo.ToPtr("karpenter-pre-termination"),
+			PreTerminationTimeout:           lo.ToPtr(10 * time.Minute),
+			MaxConcurrentLaunches:           lo.ToPtr(20),
+			VolumeGarbageCollectionAge:      lo.ToPtr(24 * time.Hour),
+			VolumeGarbageCollectionDryRun:   lo.ToPtr(true),
+			GarbageCollectionInterval:       lo.ToPtr(5 * time.Minute),
+			GarbageCollectionBatchSize:      lo.ToPtr(50),
+			GarbageCollectionListWorkers:    lo.ToPtr(4),
+			InPlaceMetadataOptionsUpdate:    lo.ToPtr(true),
+			InPlaceSecurityGroupsUpdate:     lo.ToPtr(true),
+			LabelTags:                       lo.ToPtr(`{"team":"Team"}`),
+			TagLabels:                       lo.ToPtr(`["rack"]`),
+			TagAnnotations:                  lo.ToPtr(`["billing-*"]`),
+			TagPolicy:                       lo.ToPtr(`{"maxTags":10}`),
+			InstanceNameTemplate:            lo.ToPtr("{{ .ClusterName }}-{{ .Suffix }}"),
+			DriftReconciliationInterval:     lo.ToPtr(time.Hour),
+			ManageNodeRoles:                 lo.ToPtr(true),
+			NodeRolePolicyARNs:              lo.ToPtr("arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy"),
+			ManageAWSAuth:                   lo.ToPtr(true),
+			UseFIPSEndpoints:                lo.ToPtr(true),
+			EC2Endpoint:                     lo.ToPtr("https://ec2.vpce.amazonaws.com"),
+			SSMEndpoint:                     lo.ToPtr("https://ssm.vpce.amazonaws.com"),
+			SQSEndpoint:                     lo.ToPtr("https://sqs.vpce.amazonaws.com"),
+			IAMEndpoint:                     lo.ToPtr("https://iam.vpce.amazonaws.com"),
+			EKSEndpoint:                     lo.ToPtr("https://eks.vpce.amazonaws.com"),
+			PermissionCheck:                 lo.ToPtr(true),
+			SettingsConfigMap:               lo.ToPtr("karpenter-settings"),
+			QuotaAwareProvisioning:          lo.ToPtr(true),
+			QuotaWarningThreshold:           lo.ToPtr(80.0),
+			TracingEndpoint:                 lo.ToPtr("http://otel-collector.observability:4317"),
+			CloudWatchMetricsLogGroup:       lo.ToPtr("/karpenter/metrics"),
+			EventBridgeBus:                  lo.ToPtr("karpenter-events"),
+			AuditLog:                        lo.ToPtr(true),
+			DebugEndpointPort:               lo.ToPtr(8091),
+			DebugEndpointAPIKey:             lo.ToPtr("debug-api-key"),
+			AWSHealthCheck:                  lo.ToPtr(true),
+			AMICacheTTL:                     lo.ToPtr(5 * time.Minute),
+			AWSAPIRateLimits:                lo.ToPtr(`{"ec2":{"qps":20}}`),
+			CircuitBreakerThreshold:         lo.ToPtr(50),
+			CacheSnapshotPath:               lo.ToPtr("/var/lib/karpenter/snapshot.json.gz"),
+			ResourceChangeEvents:            lo.ToPtr(true),
+			SelectorValidation:              lo.ToPtr("Reject"),
+			AMICompatibilityValidation:      lo.ToPtr("Warn"),
+			InstanceProfileValidation:       lo.ToPtr("Reject"),
 		}))
 	})
 
@@ -132,6 +400,218 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--reserved-enis", "-1")
 			Expect(err).To(HaveOccurred())
 		})
+		It("should fail when pricingRefreshInterval is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--pricing-refresh-interval", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when spotPricingRefreshInterval is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--spot-pricing-refresh-interval", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when amiCacheTTL is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--ami-cache-ttl", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsAPIRateLimits is not a JSON object", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-api-rate-limits", "ec2=20")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsAPIRateLimits has an invalid api", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-api-rate-limits", `{"ec2:":{"qps":20}}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when awsAPIRateLimits has a qps that is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--aws-api-rate-limits", `{"ec2:CreateFleet":{"qps":0,"burst":10}}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when circuitBreakerThreshold is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--circuit-breaker-threshold", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when pricingEndpoint is invalid (not absolute)", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--pricing-endpoint", "pricing.vpce.amazonaws.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when ec2Endpoint is invalid (not absolute)", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--ec2-endpoint", "ec2.vpce.amazonaws.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when costAllocationTags is not a JSON object", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--cost-allocation-tags", "team={{ .NodePool.Labels.team }}")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when costAllocationTags contains an invalid template", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--cost-allocation-tags", `{"team":"{{ .NodePool.Labels.team"}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when costAllocationTags contains a restricted tag", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--cost-allocation-tags", `{"karpenter.sh/nodepool":"{{ .NodePool.Name }}"}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when labelTags is not a JSON object", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--label-tags", "team=Team")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when labelTags maps a label to a restricted tag", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--label-tags", `{"team":"karpenter.sh/nodepool"}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when tagLabels is not a JSON array", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--tag-labels", "rack")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when tagAnnotations contains a wildcard that isn't a suffix", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--tag-annotations", `["billing-*-code"]`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when tagPolicy contains an invalid restricted key pattern", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--tag-policy", `{"restrictedKeys":["^aws:("]}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when tagPolicy contains an unknown field", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--tag-policy", `{"maxTag":10}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when tagPolicy has a negative maxTags", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--tag-policy", `{"maxTags":-1}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when instanceNameTemplate is not a valid template", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-name-template", "{{ .ClusterName }-{{ .Suffix }}")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when scheduledMaintenanceLeadTime is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--scheduled-maintenance-lead-time", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when managedInterruptionQueue is set without an interruptionQueue", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--managed-interruption-queue")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionQueue is an invalid URL", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue", "https://")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should succeed when interruptionQueue is a queue URL", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-queue", "https://sqs.us-west-2.amazonaws.com/111111111111/test-cluster")
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("should fail when managedInterruptionQueue is set with an interruptionQueue URL", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--managed-interruption-queue", "--interruption-queue", "https://sqs.us-west-2.amazonaws.com/111111111111/test-cluster")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionQueueTags is not a JSON object", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--managed-interruption-queue", "--interruption-queue", "test-cluster", "--interruption-queue-tags", "team=platform")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionDrainPolicy has an unknown event type", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-drain-policy", `{"RebalanceRecommendation":"Delete"}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionDrainPolicy has an unknown drain policy", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-drain-policy", `{"SpotInterruption":"Force"}`)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionEndpointPort is set without an interruptionEndpointAPIKey", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-endpoint-port", "8090")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when sharedInterruptionQueue is set without an interruptionQueue", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--shared-interruption-queue")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when resourceChangeEvents is set without an interruptionQueue", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--resource-change-events")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when selectorValidation is unknown", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--selector-validation", "Ignore")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when amiCompatibilityValidation is unknown", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--ami-compatibility-validation", "Deny")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when instanceProfileValidation is unknown", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-profile-validation", "Deny")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when instanceStatusRepairPeriod is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--instance-status-repair-period", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when driftReconciliationInterval is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--drift-reconciliation-interval", "-1m")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when stoppedInstancePoolSize is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--stopped-instance-pool-size", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when stoppedInstancePoolTTL is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--stopped-instance-pool-ttl", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when preTerminationWebhookURL is not a valid URL", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--pre-termination-webhook-url", "hooks.example.com")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when preTerminationTimeout is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--pre-termination-timeout", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when tracingEndpoint is not a URL", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--tracing-endpoint", "otel-collector:4317")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when debugEndpointPort is set without a debugEndpointAPIKey", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--debug-endpoint-port", "8091")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when maxConcurrentLaunches is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--max-concurrent-launches", "-1")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when volumeGarbageCollectionAge is negative", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--volume-garbage-collection-age", "-1h")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when volumeGarbageCollectionDryRun is set without volumeGarbageCollectionAge", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--volume-garbage-collection-dry-run")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when garbageCollectionInterval is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--garbage-collection-interval", "0s")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when garbageCollectionBatchSize is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--garbage-collection-batch-size", "0")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when garbageCollectionListWorkers is not positive", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--garbage-collection-list-workers", "0")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when launchDiagnosticsBucket is set without launchDiagnostics", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--launch-diagnostics-bucket", "karpenter-diagnostics")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when nodeRolePolicyARNs is set without manageNodeRoles", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--node-role-policy-arns", "arn:aws:iam::aws:policy/CloudWatchAgentServerPolicy")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when nodeRolePolicyARNs contains an ARN that isn't an IAM policy", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--manage-node-roles", "--node-role-policy-arns", "arn:aws:iam::123456789012:role/KarpenterNodeRole")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when interruptionEndpointPort is out of range", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--interruption-endpoint-port", "70000", "--interruption-endpoint-api-key", "api-key")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail when quotaWarningThreshold is more than 100", func() {
+			err := opts.Parse(fs, "--cluster-name", "test-cluster", "--quota-warning-threshold", "120")
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
 
@@ -146,4 +626,71 @@ func expectOptionsEqual(optsA *options.Options, optsB *options.Options) {
 	Expect(optsA.VMMemoryOverheadPercent).To(Equal(optsB.VMMemoryOverheadPercent))
 	Expect(optsA.InterruptionQueue).To(Equal(optsB.InterruptionQueue))
 	Expect(optsA.ReservedENIs).To(Equal(optsB.ReservedENIs))
+	Expect(optsA.CommitmentAwarePricing).To(Equal(optsB.CommitmentAwarePricing))
+	Expect(optsA.PricingOverridesConfigMap).To(Equal(optsB.PricingOverridesConfigMap))
+	Expect(optsA.PricingFile).To(Equal(optsB.PricingFile))
+	Expect(optsA.PricingRefreshInterval).To(Equal(optsB.PricingRefreshInterval))
+	Expect(optsA.SpotPricingRefreshInterval).To(Equal(optsB.SpotPricingRefreshInterval))
+	Expect(optsA.PricingEndpoint).To(Equal(optsB.PricingEndpoint))
+	Expect(optsA.CostAllocationTags).To(Equal(optsB.CostAllocationTags))
+	Expect(optsA.ComputeOptimizerRecommendations).To(Equal(optsB.ComputeOptimizerRecommendations))
+	Expect(optsA.ScheduledMaintenanceLeadTime).To(Equal(optsB.ScheduledMaintenanceLeadTime))
+	Expect(optsA.ManagedInterruptionQueue).To(Equal(optsB.ManagedInterruptionQueue))
+	Expect(optsA.InterruptionQueueKMSKeyID).To(Equal(optsB.InterruptionQueueKMSKeyID))
+	Expect(optsA.InterruptionQueueTags).To(Equal(optsB.InterruptionQueueTags))
+	Expect(optsA.InterruptionQueueRoleARN).To(Equal(optsB.InterruptionQueueRoleARN))
+	Expect(optsA.InterruptionDrainPolicy).To(Equal(optsB.InterruptionDrainPolicy))
+	Expect(optsA.InterruptionEndpointPort).To(Equal(optsB.InterruptionEndpointPort))
+	Expect(optsA.InterruptionEndpointAPIKey).To(Equal(optsB.InterruptionEndpointAPIKey))
+	Expect(optsA.SharedInterruptionQueue).To(Equal(optsB.SharedInterruptionQueue))
+	Expect(optsA.InstanceStatusRepairPeriod).To(Equal(optsB.InstanceStatusRepairPeriod))
+	Expect(optsA.LaunchDiagnostics).To(Equal(optsB.LaunchDiagnostics))
+	Expect(optsA.LaunchDiagnosticsBucket).To(Equal(optsB.LaunchDiagnosticsBucket))
+	Expect(optsA.StoppedInstancePoolSize).To(Equal(optsB.StoppedInstancePoolSize))
+	Expect(optsA.StoppedInstancePoolTTL).To(Equal(optsB.StoppedInstancePoolTTL))
+	Expect(optsA.PreTerminationWebhookURL).To(Equal(optsB.PreTerminationWebhookURL))
+	Expect(optsA.PreTerminationLambda).To(Equal(optsB.PreTerminationLambda))
+	Expect(optsA.PreTerminationTimeout).To(Equal(optsB.PreTerminationTimeout))
+	Expect(optsA.MaxConcurrentLaunches).To(Equal(optsB.MaxConcurrentLaunches))
+	Expect(optsA.VolumeGarbageCollectionAge).To(Equal(optsB.VolumeGarbageCollectionAge))
+	Expect(optsA.VolumeGarbageCollectionDryRun).To(Equal(optsB.VolumeGarbageCollectionDryRun))
+	Expect(optsA.GarbageCollectionInterval).To(Equal(optsB.GarbageCollectionInterval))
+	Expect(optsA.GarbageCollectionBatchSize).To(Equal(optsB.GarbageCollectionBatchSize))
+	Expect(optsA.GarbageCollectionListWorkers).To(Equal(optsB.GarbageCollectionListWorkers))
+	Expect(optsA.InPlaceMetadataOptionsUpdate).To(Equal(optsB.InPlaceMetadataOptionsUpdate))
+	Expect(optsA.InPlaceSecurityGroupsUpdate).To(Equal(optsB.InPlaceSecurityGroupsUpdate))
+	Expect(optsA.LabelTags).To(Equal(optsB.LabelTags))
+	Expect(optsA.TagLabels).To(Equal(optsB.TagLabels))
+	Expect(optsA.TagAnnotations).To(Equal(optsB.TagAnnotations))
+	Expect(optsA.TagPolicy).To(Equal(optsB.TagPolicy))
+	Expect(optsA.InstanceNameTemplate).To(Equal(optsB.InstanceNameTemplate))
+	Expect(optsA.DriftReconciliationInterval).To(Equal(optsB.DriftReconciliationInterval))
+	Expect(optsA.ManageNodeRoles).To(Equal(optsB.ManageNodeRoles))
+	Expect(optsA.NodeRolePolicyARNs).To(Equal(optsB.NodeRolePolicyARNs))
+	Expect(optsA.ManageAWSAuth).To(Equal(optsB.ManageAWSAuth))
+	Expect(optsA.UseFIPSEndpoints).To(Equal(optsB.UseFIPSEndpoints))
+	Expect(optsA.EC2Endpoint).To(Equal(optsB.EC2Endpoint))
+	Expect(optsA.SSMEndpoint).To(Equal(optsB.SSMEndpoint))
+	Expect(optsA.SQSEndpoint).To(Equal(optsB.SQSEndpoint))
+	Expect(optsA.IAMEndpoint).To(Equal(optsB.IAMEndpoint))
+	Expect(optsA.EKSEndpoint).To(Equal(optsB.EKSEndpoint))
+	Expect(optsA.PermissionCheck).To(Equal(optsB.PermissionCheck))
+	Expect(optsA.SettingsConfigMap).To(Equal(optsB.SettingsConfigMap))
+	Expect(optsA.QuotaAwareProvisioning).To(Equal(optsB.QuotaAwareProvisioning))
+	Expect(optsA.QuotaWarningThreshold).To(Equal(optsB.QuotaWarningThreshold))
+	Expect(optsA.TracingEndpoint).To(Equal(optsB.TracingEndpoint))
+	Expect(optsA.CloudWatchMetricsLogGroup).To(Equal(optsB.CloudWatchMetricsLogGroup))
+	Expect(optsA.EventBridgeBus).To(Equal(optsB.EventBridgeBus))
+	Expect(optsA.AuditLog).To(Equal(optsB.AuditLog))
+	Expect(optsA.DebugEndpointPort).To(Equal(optsB.DebugEndpointPort))
+	Expect(optsA.DebugEndpointAPIKey).To(Equal(optsB.DebugEndpointAPIKey))
+	Expect(optsA.AWSHealthCheck).To(Equal(optsB.AWSHealthCheck))
+	Expect(optsA.AMICacheTTL).To(Equal(optsB.AMICacheTTL))
+	Expect(optsA.AWSAPIRateLimits).To(Equal(optsB.AWSAPIRateLimits))
+	Expect(optsA.CircuitBreakerThreshold).To(Equal(optsB.CircuitBreakerThreshold))
+	Expect(optsA.CacheSnapshotPath).To(Equal(optsB.CacheSnapshotPath))
+	Expect(optsA.ResourceChangeEvents).To(Equal(optsB.ResourceChangeEvents))
+	Expect(optsA.SelectorValidation).To(Equal(optsB.SelectorValidation))
+	Expect(optsA.AMICompatibilityValidation).To(Equal(optsB.AMICompatibilityValidation))
+	Expect(optsA.InstanceProfileValidation).To(Equal(optsB.InstanceProfileValidation))
 }
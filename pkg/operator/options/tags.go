@@ -0,0 +1,176 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// ParseCostAllocationTags parses a JSON object that maps tag keys to the templates used to render their values
+func ParseCostAllocationTags(s string) (map[string]*template.Template, error) {
+	raw := map[string]string{}
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		return nil, fmt.Errorf("unmarshaling tags, %w", err)
+	}
+	var errs error
+	tags := map[string]*template.Template{}
+	for k, v := range raw {
+		if k == "" {
+			errs = multierr.Append(errs, fmt.Errorf("empty tag keys aren't supported"))
+			continue
+		}
+		for _, pattern := range v1beta1.RestrictedTagPatterns {
+			if pattern.MatchString(k) {
+				errs = multierr.Append(errs, fmt.Errorf("tag %q matches restricted tag pattern %q", k, pattern.String()))
+			}
+		}
+		t, err := template.New(k).Option("missingkey=zero").Parse(v)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("parsing template for tag %q, %w", k, err))
+			continue
+		}
+		tags[k] = t
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return tags, nil
+}
+
+// ParseInterruptionQueueTags parses a JSON object of the tags that are applied to the managed interruption queue and rules
+func ParseInterruptionQueueTags(s string) (map[string]string, error) {
+	tags := map[string]string{}
+	if s == "" {
+		return tags, nil
+	}
+	if err := json.Unmarshal([]byte(s), &tags); err != nil {
+		return nil, fmt.Errorf("unmarshaling tags, %w", err)
+	}
+	if _, ok := tags[""]; ok {
+		return nil, fmt.Errorf("empty tag keys aren't supported")
+	}
+	return tags, nil
+}
+
+// ParseLabelTags parses a JSON object that maps node label keys to the tag keys that their values are applied as
+func ParseLabelTags(s string) (map[string]string, error) {
+	labelTags := map[string]string{}
+	if s == "" {
+		return labelTags, nil
+	}
+	if err := json.Unmarshal([]byte(s), &labelTags); err != nil {
+		return nil, fmt.Errorf("unmarshaling label tags, %w", err)
+	}
+	var errs error
+	for label, tag := range labelTags {
+		if label == "" || tag == "" {
+			errs = multierr.Append(errs, fmt.Errorf("empty label and tag keys aren't supported"))
+			continue
+		}
+		for _, pattern := range v1beta1.RestrictedTagPatterns {
+			if pattern.MatchString(tag) {
+				errs = multierr.Append(errs, fmt.Errorf("tag %q for label %q matches restricted tag pattern %q", tag, label, pattern.String()))
+			}
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return labelTags, nil
+}
+
+// ParseTagKeys parses a JSON array of tag keys, where keys that end in * match all tags with that prefix
+func ParseTagKeys(s string) ([]string, error) {
+	var keys []string
+	if s == "" {
+		return keys, nil
+	}
+	if err := json.Unmarshal([]byte(s), &keys); err != nil {
+		return nil, fmt.Errorf("unmarshaling tag keys, %w", err)
+	}
+	var errs error
+	for _, k := range keys {
+		if strings.TrimSuffix(k, "*") == "" {
+			errs = multierr.Append(errs, fmt.Errorf("empty tag keys and prefixes aren't supported"))
+		}
+		if strings.Contains(strings.TrimSuffix(k, "*"), "*") {
+			errs = multierr.Append(errs, fmt.Errorf("tag key %q may only contain * at the end", k))
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return keys, nil
+}
+
+// ParseTagPolicy parses a JSON object with the policy that the tags of EC2NodeClasses must follow. nil is returned if
+// there is no policy.
+func ParseTagPolicy(s string) (*v1beta1.TagPolicy, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw := struct {
+		RestrictedKeys   []string `json:"restrictedKeys"`
+		RequiredPrefixes []string `json:"requiredPrefixes"`
+		MaxTags          int      `json:"maxTags"`
+	}{}
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("unmarshaling tag policy, %w", err)
+	}
+	var errs error
+	policy := &v1beta1.TagPolicy{RequiredPrefixes: raw.RequiredPrefixes, MaxTags: raw.MaxTags}
+	for _, k := range raw.RestrictedKeys {
+		pattern, err := regexp.Compile(k)
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("parsing restricted key %q, %w", k, err))
+			continue
+		}
+		policy.RestrictedKeys = append(policy.RestrictedKeys, pattern)
+	}
+	if lo.Contains(raw.RequiredPrefixes, "") {
+		errs = multierr.Append(errs, fmt.Errorf("empty required prefixes aren't supported"))
+	}
+	if raw.MaxTags < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("maxTags cannot be negative"))
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return policy, nil
+}
+
+// ParseInstanceNameTemplate parses the template that the Name tag of instances is rendered from. nil is returned if
+// there is no template.
+func ParseInstanceNameTemplate(s string) (*template.Template, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := template.New(v1beta1.TagName).Option("missingkey=zero").Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("parsing instance name template, %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,57 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// withRateLimits delays the requests that are sent by the clients of the config, including their retries, until
+// the rate limit of their operation or service allows them. Requests are signed after they are delayed, so that their
+// signatures don't expire while they wait.
+func withRateLimits(cfg aws.Config, limits map[string]options.APIRateLimit) aws.Config {
+	if len(limits) == 0 {
+		return cfg
+	}
+	limiters := map[string]*rate.Limiter{}
+	for api, limit := range limits {
+		limiters[api] = rate.NewLimiter(rate.Limit(limit.QPS), limit.Burst)
+	}
+	cfg.APIOptions = append(cfg.APIOptions[:len(cfg.APIOptions):len(cfg.APIOptions)], func(stack *middleware.Stack) error {
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("karpenter.RateLimit", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			service := sdk.ServiceName(ctx)
+			limiter, ok := limiters[fmt.Sprintf("%s:%s", service, awsmiddleware.GetOperationName(ctx))]
+			if !ok {
+				if limiter, ok = limiters[service]; !ok {
+					return next.HandleFinalize(ctx, in)
+				}
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				return middleware.FinalizeOutput{}, middleware.Metadata{}, &aws.RequestCanceledError{Err: fmt.Errorf("waiting for rate limit, %w", err)}
+			}
+			return next.HandleFinalize(ctx, in)
+		}), "Retry", middleware.After)
+	})
+	return cfg
+}
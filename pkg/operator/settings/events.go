@@ -0,0 +1,34 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+)
+
+func RestartRequired(configMap *v1.ConfigMap, keys []string) events.Event {
+	return events.Event{
+		InvolvedObject: configMap,
+		Type:           v1.EventTypeWarning,
+		Reason:         "SettingsRestartRequired",
+		Message:        fmt.Sprintf("Settings %s are applied when the controller restarts", strings.Join(keys, ", ")),
+		DedupeValues:   []string{string(configMap.UID), strings.Join(keys, ",")},
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+
+	"sigs.k8s.io/karpenter/pkg/events"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// pollInterval is how often the settings ConfigMap is read
+const pollInterval = 10 * time.Second
+
+// setting is an option that can be set in the settings ConfigMap under the name of its environment variable
+type setting struct {
+	// live settings are applied to the running controller. Settings of the AWS provider are read with options.Live
+	// whenever they're used, and the batch windows of Karpenter core are updated in place. Other settings are consumed
+	// when the controller starts, so changes to them are reported until the controller is restarted.
+	live bool
+	// parse sets the option from the value in the ConfigMap
+	parse func(o *opts, value string) error
+}
+
+// opts are the options of the AWS provider and of Karpenter core, which are both set by the settings ConfigMap
+type opts struct {
+	aws  *options.Options
+	core *coreoptions.Options
+}
+
+var settings = map[string]setting{
+	"CLUSTER_ENDPOINT": {
+		parse: func(o *opts, value string) error { o.aws.ClusterEndpoint = value; return nil },
+	},
+	"INTERRUPTION_QUEUE": {
+		live:  true,
+		parse: func(o *opts, value string) error { o.aws.InterruptionQueue = value; return nil },
+	},
+	"RESERVED_ENIS": {
+		live: true,
+		parse: func(o *opts, value string) (err error) {
+			o.aws.ReservedENIs, err = strconv.Atoi(value)
+			return err
+		},
+	},
+	"VM_MEMORY_OVERHEAD_PERCENT": {
+		live: true,
+		parse: func(o *opts, value string) (err error) {
+			o.aws.VMMemoryOverheadPercent, err = strconv.ParseFloat(value, 64)
+			return err
+		},
+	},
+	"FEATURE_GATES": {
+		parse: func(o *opts, value string) (err error) {
+			o.core.FeatureGates, err = coreoptions.ParseFeatureGates(value)
+			return err
+		},
+	},
+	"BATCH_MAX_DURATION": {
+		live: true,
+		parse: func(o *opts, value string) (err error) {
+			o.core.BatchMaxDuration, err = time.ParseDuration(value)
+			return err
+		},
+	},
+	"BATCH_IDLE_DURATION": {
+		live: true,
+		parse: func(o *opts, value string) (err error) {
+			o.core.BatchIdleDuration, err = time.ParseDuration(value)
+			return err
+		},
+	},
+}
+
+// Watcher applies the settings ConfigMap to the options of the running controller. Settings in the ConfigMap take
+// precedence over environment variables and flags, which are restored when a setting is removed from the ConfigMap.
+// The options in the operator's context are only updated at startup, before anything reads them. Once the controller
+// runs, live settings are applied by publishing a new copy of the options, which is read with options.Live, and the
+// batch windows of Karpenter core are updated in place.
+type Watcher struct {
+	kubernetesInterface kubernetes.Interface
+	clk                 clock.Clock
+	recorder            events.Recorder
+	name                string
+
+	// current are the options in the operator's context, defaults are the options from environment variables and flags
+	current  opts
+	defaults opts
+	// live is the latest copy of the options that was published, which is never modified once it's published
+	live *atomic.Pointer[options.Options]
+	// startup is the data of the ConfigMap that the controller started with, and applied is the data that was last applied
+	startup map[string]string
+	applied map[string]string
+	// pending are the settings that changed since the controller started, but are only applied when it restarts
+	pending []string
+}
+
+// NewWatcher applies the settings ConfigMap to the options in the context, so that settings which are consumed when the
+// controller starts are set before anything reads them
+func NewWatcher(ctx context.Context, kubernetesInterface kubernetes.Interface, clk clock.Clock, recorder events.Recorder) (*Watcher, error) {
+	w := &Watcher{
+		kubernetesInterface: kubernetesInterface,
+		clk:                 clk,
+		recorder:            recorder,
+		name:                options.FromContext(ctx).SettingsConfigMap,
+		current:             opts{aws: options.FromContext(ctx), core: coreoptions.FromContext(ctx)},
+		defaults:            opts{aws: lo.ToPtr(*options.FromContext(ctx)), core: lo.ToPtr(*coreoptions.FromContext(ctx))},
+		live:                &atomic.Pointer[options.Options]{},
+	}
+	configMap, err := w.configMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data := lo.FromPtr(configMap).Data
+	o, err := w.options(data)
+	if err != nil {
+		return nil, err
+	}
+	// nothing has read the options yet, so the options in the context can be updated in place
+	*w.current.aws = *o.aws
+	*w.current.core = *o.core
+	w.current.aws.SetLive(w.live)
+	w.apply(ctx, data, o)
+	w.startup = data
+	return w, nil
+}
+
+// Start reads the settings ConfigMap every pollInterval until the context is cancelled. Invalid settings are logged
+// and the previous settings are kept.
+func (w *Watcher) Start(ctx context.Context) error {
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("configmap", w.name))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.clk.After(pollInterval):
+		}
+		if err := w.Reconcile(ctx); err != nil {
+			logging.FromContext(ctx).Errorf("applying settings, %v", err)
+		}
+	}
+}
+
+// NeedLeaderElection is false so that settings are applied to every replica
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// Reconcile reads the settings ConfigMap and applies the settings that can change while the controller runs. Settings
+// that are consumed when the controller starts keep the values that it started with, and changes to them are reported
+// rather than restarting the controller, so that settings it can't start with don't put it in a crash loop. Settings are
+// validated as a whole, so that the controller doesn't restart into settings it can't start with.
+func (w *Watcher) Reconcile(ctx context.Context) error {
+	configMap, err := w.configMap(ctx)
+	if err != nil {
+		return err
+	}
+	data := lo.FromPtr(configMap).Data
+	if _, err = w.options(data); err != nil {
+		return err
+	}
+	effective := lo.Assign(data)
+	var pending []string
+	for key, s := range settings {
+		if s.live || data[key] == w.startup[key] {
+			continue
+		}
+		pending = append(pending, key)
+		if value, ok := w.startup[key]; ok {
+			effective[key] = value
+		} else {
+			delete(effective, key)
+		}
+	}
+	sort.Strings(pending)
+	o, err := w.options(effective)
+	if err != nil {
+		return err
+	}
+	w.reportPending(ctx, configMap, pending)
+	w.apply(ctx, effective, o)
+	return nil
+}
+
+// reportPending logs the settings that are only applied when the controller restarts, and publishes an event on the
+// ConfigMap, whenever they change
+func (w *Watcher) reportPending(ctx context.Context, configMap *v1.ConfigMap, pending []string) {
+	if slices.Equal(pending, w.pending) {
+		return
+	}
+	w.pending = pending
+	if len(pending) == 0 {
+		return
+	}
+	logging.FromContext(ctx).With("settings", pending).Infof("settings are applied when the controller restarts")
+	if configMap != nil {
+		w.recorder.Publish(RestartRequired(configMap, pending))
+	}
+}
+
+// options returns a copy of the defaults with the settings applied on top of them, and validates it
+func (w *Watcher) options(data map[string]string) (opts, error) {
+	o := opts{aws: lo.ToPtr(*w.defaults.aws), core: lo.ToPtr(*w.defaults.core)}
+	var errs error
+	for key, s := range settings {
+		if value, ok := data[key]; ok {
+			if err := s.parse(&o, value); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("parsing %s, %w", key, err))
+			}
+		}
+	}
+	if errs != nil {
+		return opts{}, errs
+	}
+	if err := multierr.Combine(o.aws.Validate(), validateCore(o.core)); err != nil {
+		return opts{}, fmt.Errorf("validating settings, %w", err)
+	}
+	return o, nil
+}
+
+// apply publishes the options as the live options, which are never modified once they're published. Settings that
+// aren't live are the same as they were at startup, so the published options only differ by the live settings.
+func (w *Watcher) apply(ctx context.Context, data map[string]string, o opts) {
+	w.live.Store(o.aws)
+	// Karpenter core reads its options from the context rather than with options.Live, so the batch windows are
+	// updated in place. Each is a single word that is stored atomically, so the provisioner observes either the
+	// previous or the new window when it starts a batch.
+	atomic.StoreInt64((*int64)(&w.current.core.BatchMaxDuration), int64(o.core.BatchMaxDuration))
+	atomic.StoreInt64((*int64)(&w.current.core.BatchIdleDuration), int64(o.core.BatchIdleDuration))
+	var updated []string
+	for key := range settings {
+		value, ok := data[key]
+		if appliedValue, appliedOK := w.applied[key]; value != appliedValue || ok != appliedOK {
+			updated = append(updated, key)
+		}
+	}
+	w.applied = data
+	if len(updated) > 0 {
+		sort.Strings(updated)
+		logging.FromContext(ctx).With("settings", updated).Infof("applied settings")
+	}
+}
+
+// validateCore validates the options of Karpenter core that can be set in the settings ConfigMap
+func validateCore(o *coreoptions.Options) error {
+	var errs error
+	if o.BatchMaxDuration < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("batch max duration %s must be non-negative", o.BatchMaxDuration))
+	}
+	if o.BatchIdleDuration < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("batch idle duration %s must be non-negative", o.BatchIdleDuration))
+	}
+	return errs
+}
+
+// configMap returns the settings ConfigMap, or nil if it doesn't exist
+func (w *Watcher) configMap(ctx context.Context) (*v1.ConfigMap, error) {
+	if w.name == "" {
+		return nil, nil
+	}
+	configMap, err := w.kubernetesInterface.CoreV1().ConfigMaps(system.Namespace()).Get(ctx, w.name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting settings configmap, %w", err)
+	}
+	return configMap, nil
+}
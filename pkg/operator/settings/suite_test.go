@@ -0,0 +1,251 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package settings_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+	"knative.dev/pkg/system"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/settings"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *coretest.Environment
+var recorder *coretest.EventRecorder
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Settings")
+}
+
+var _ = BeforeSuite(func() {
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SettingsConfigMap: lo.ToPtr("karpenter-settings")}))
+	recorder = coretest.NewEventRecorder()
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Settings", func() {
+	var configMap *v1.ConfigMap
+	BeforeEach(func() {
+		configMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "karpenter-settings",
+				Namespace: system.Namespace(),
+			},
+			Data: map[string]string{},
+		}
+	})
+	AfterEach(func() {
+		ExpectDeleted(ctx, env.Client, configMap)
+	})
+	It("should keep the options from environment variables and flags when the configmap doesn't exist", func() {
+		_, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.FromContext(ctx).ClusterEndpoint).To(Equal("https://test-cluster"))
+		Expect(coreoptions.FromContext(ctx).BatchMaxDuration).To(Equal(coretest.Options().BatchMaxDuration))
+	})
+	It("should apply every setting at startup", func() {
+		configMap.Data = map[string]string{
+			"CLUSTER_ENDPOINT":   "https://settings-cluster",
+			"INTERRUPTION_QUEUE": "settings-queue",
+			"RESERVED_ENIS":      "1",
+			"FEATURE_GATES":      "Drift=false,SpotToSpotConsolidation=true",
+			"BATCH_MAX_DURATION": "30s",
+		}
+		ExpectApplied(ctx, env.Client, configMap)
+
+		_, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.FromContext(ctx).ClusterEndpoint).To(Equal("https://settings-cluster"))
+		Expect(options.FromContext(ctx).InterruptionQueue).To(Equal("settings-queue"))
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(1))
+		Expect(coreoptions.FromContext(ctx).FeatureGates.Drift).To(BeFalse())
+		Expect(coreoptions.FromContext(ctx).FeatureGates.SpotToSpotConsolidation).To(BeTrue())
+		Expect(coreoptions.FromContext(ctx).BatchMaxDuration).To(Equal(30 * time.Second))
+	})
+	It("should fail to start with invalid settings", func() {
+		configMap.Data = map[string]string{"RESERVED_ENIS": "-1"}
+		ExpectApplied(ctx, env.Client, configMap)
+
+		_, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should apply live settings while the controller runs", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+
+		configMap.Data = map[string]string{"RESERVED_ENIS": "2", "VM_MEMORY_OVERHEAD_PERCENT": "0.1"}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).To(Succeed())
+		Expect(options.Live(ctx).ReservedENIs).To(Equal(2))
+		Expect(options.Live(ctx).VMMemoryOverheadPercent).To(Equal(0.1))
+		// the options in the context aren't modified once the controller runs, since they're read concurrently
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(0))
+	})
+	It("should publish live settings while they're read concurrently", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+
+		// the race detector fails the test if publishing the settings races with reading them
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						Expect(options.Live(ctx).ReservedENIs).To(BeNumerically(">=", 0))
+					}
+				}
+			}()
+		}
+		for i := 1; i <= 5; i++ {
+			configMap.Data = map[string]string{"RESERVED_ENIS": fmt.Sprint(i)}
+			ExpectApplied(ctx, env.Client, configMap)
+			Expect(watcher.Reconcile(ctx)).To(Succeed())
+		}
+		close(done)
+		wg.Wait()
+		Expect(options.Live(ctx).ReservedENIs).To(Equal(5))
+	})
+	It("should restore the options from environment variables and flags when a setting is removed", func() {
+		configMap.Data = map[string]string{"RESERVED_ENIS": "2"}
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options.FromContext(ctx).ReservedENIs).To(Equal(2))
+
+		configMap.Data = map[string]string{}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).To(Succeed())
+		Expect(options.Live(ctx).ReservedENIs).To(Equal(0))
+	})
+	It("should keep the previous settings when the settings are invalid", func() {
+		configMap.Data = map[string]string{"RESERVED_ENIS": "2"}
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+
+		configMap.Data = map[string]string{"RESERVED_ENIS": "3", "VM_MEMORY_OVERHEAD_PERCENT": "often"}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).ToNot(Succeed())
+		Expect(options.Live(ctx).ReservedENIs).To(Equal(2))
+	})
+	It("should keep the previous settings when a setting that is consumed at startup is invalid", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+
+		configMap.Data = map[string]string{"FEATURE_GATES": "Drift"}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).ToNot(Succeed())
+		Expect(recorder.Events()).To(BeEmpty())
+	})
+	It("should fail to start with negative batch durations", func() {
+		configMap.Data = map[string]string{"BATCH_IDLE_DURATION": "-1s"}
+		ExpectApplied(ctx, env.Client, configMap)
+
+		_, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).To(HaveOccurred())
+		Expect(coreoptions.FromContext(ctx).BatchIdleDuration).To(Equal(coretest.Options().BatchIdleDuration))
+	})
+	It("should apply the batch windows of Karpenter core while the controller runs", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+
+		configMap.Data = map[string]string{"BATCH_IDLE_DURATION": "5s", "BATCH_MAX_DURATION": "30s"}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).To(Succeed())
+		Expect(coreoptions.FromContext(ctx).BatchIdleDuration).To(Equal(5 * time.Second))
+		Expect(coreoptions.FromContext(ctx).BatchMaxDuration).To(Equal(30 * time.Second))
+
+		configMap.Data = map[string]string{}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).To(Succeed())
+		Expect(coreoptions.FromContext(ctx).BatchIdleDuration).To(Equal(coretest.Options().BatchIdleDuration))
+		Expect(coreoptions.FromContext(ctx).BatchMaxDuration).To(Equal(coretest.Options().BatchMaxDuration))
+	})
+	It("should apply the interruption queue while the controller runs", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+
+		configMap.Data = map[string]string{"INTERRUPTION_QUEUE": "settings-queue"}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).To(Succeed())
+		Expect(options.Live(ctx).InterruptionQueue).To(Equal("settings-queue"))
+	})
+	It("should report rather than restart when a setting that is consumed at startup changes", func() {
+		ExpectApplied(ctx, env.Client, configMap)
+		watcher, err := settings.NewWatcher(ctx, env.KubernetesInterface, &clock.FakeClock{}, recorder)
+		Expect(err).ToNot(HaveOccurred())
+
+		configMap.Data = map[string]string{"CLUSTER_ENDPOINT": "https://settings-cluster", "FEATURE_GATES": "Drift=false", "RESERVED_ENIS": "2"}
+		ExpectApplied(ctx, env.Client, configMap)
+		Expect(watcher.Reconcile(ctx)).To(Succeed())
+		Expect(options.Live(ctx).ClusterEndpoint).To(Equal("https://test-cluster"))
+		Expect(coreoptions.FromContext(ctx).FeatureGates).To(Equal(coretest.Options().FeatureGates))
+		// live settings are still applied
+		Expect(options.Live(ctx).ReservedENIs).To(Equal(2))
+		Expect(recorder.Events()).To(HaveLen(1))
+		Expect(recorder.Events()[0].Reason).To(Equal("SettingsRestartRequired"))
+		Expect(recorder.Events()[0].Message).To(ContainSubstring("CLUSTER_ENDPOINT, FEATURE_GATES"))
+
+		// the settings are only reported again when they change
+		Expect(watcher.Reconcile(ctx)).To(Succeed())
+		Expect(recorder.Events()).To(HaveLen(1))
+	})
+})
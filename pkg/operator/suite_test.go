@@ -19,7 +19,8 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/samber/lo"
 
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
@@ -83,7 +84,7 @@ var _ = Describe("Operator", func() {
 		}))
 		fakeEKSAPI.DescribeClusterBehavior.Output.Set(
 			&eks.DescribeClusterOutput{
-				Cluster: &eks.Cluster{
+				Cluster: &ekstypes.Cluster{
 					Endpoint: lo.ToPtr("https://cluster-endpoint.test-cluster.k8s.local"),
 				},
 			},
@@ -0,0 +1,131 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package account
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/noderole"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+)
+
+// Role is the IAM role that is assumed to manage resources in another account. The zero Role is Karpenter's own account.
+type Role struct {
+	ARN        string
+	ExternalID string
+}
+
+// RoleFor returns the role that is assumed to manage the resources of the EC2NodeClass
+func RoleFor(nodeClass *v1beta1.EC2NodeClass) Role {
+	return Role{
+		ARN:        lo.FromPtr(nodeClass.Spec.AssumeRoleARN),
+		ExternalID: lo.FromPtr(nodeClass.Spec.AssumeRoleExternalID),
+	}
+}
+
+// Providers manage the resources of EC2NodeClasses in a single account
+type Providers struct {
+	EC2API          sdk.EC2API
+	Subnet          *subnet.Provider
+	SecurityGroup   *securitygroup.Provider
+	InstanceProfile *instanceprofile.Provider
+	NodeRole        *noderole.Provider
+	AMI             *amifamily.Provider
+	LaunchTemplate  *launchtemplate.Provider
+	Instance        *instance.Provider
+}
+
+// Provider returns the providers that manage the resources of an EC2NodeClass. EC2NodeClasses that set
+// spec.assumeRoleARN are managed in the role's account by providers that call AWS with the role's credentials. These
+// are created the first time that the role is used, and have their own caches. Other EC2NodeClasses are managed in
+// Karpenter's own account.
+type Provider struct {
+	mu           sync.Mutex
+	home         *Providers
+	newProviders func(Role) *Providers
+	accounts     map[Role]*Providers
+}
+
+func NewProvider(home *Providers, newProviders func(Role) *Providers) *Provider {
+	return &Provider{
+		home:         home,
+		newProviders: newProviders,
+		accounts:     map[Role]*Providers{},
+	}
+}
+
+// For returns the providers that manage the resources of the EC2NodeClass
+func (p *Provider) For(nodeClass *v1beta1.EC2NodeClass) *Providers {
+	return p.ForRole(RoleFor(nodeClass))
+}
+
+// ForRole returns the providers that manage resources with the role
+func (p *Provider) ForRole(role Role) *Providers {
+	if role.ARN == "" {
+		return p.home
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if providers, ok := p.accounts[role]; ok {
+		return providers
+	}
+	p.accounts[role] = p.newProviders(role)
+	return p.accounts[role]
+}
+
+// ForNodeClaim returns the providers that manage the instance of the NodeClaim, which are resolved from its
+// EC2NodeClass. EC2NodeClasses that are terminating are still resolved, so that the instances of their NodeClaims are
+// found. Instances of NodeClaims whose EC2NodeClass doesn't exist are managed in Karpenter's own account.
+func (p *Provider) ForNodeClaim(ctx context.Context, kubeClient client.Client, nodeClaim *corev1beta1.NodeClaim) (*Providers, error) {
+	if nodeClaim.Spec.NodeClassRef == nil {
+		return p.home, nil
+	}
+	nodeClass := &v1beta1.EC2NodeClass{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: nodeClaim.Spec.NodeClassRef.Name}, nodeClass); err != nil {
+		if errors.IsNotFound(err) {
+			return p.home, nil
+		}
+		return nil, fmt.Errorf("getting ec2nodeclass, %w", err)
+	}
+	return p.For(nodeClass), nil
+}
+
+// Home returns the providers that manage resources in Karpenter's own account
+func (p *Provider) Home() *Providers {
+	return p.home
+}
+
+// Reset removes the providers of every assumed role
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accounts = map[Role]*Providers{}
+}
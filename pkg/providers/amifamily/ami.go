@@ -16,36 +16,49 @@ package amifamily
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
-	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 	v1 "k8s.io/api/core/v1"
 	"knative.dev/pkg/logging"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/tracing"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
+// maxGetParametersNames is the largest number of parameters that can be requested with a single ssm:GetParameters call
+const maxGetParametersNames = 10
+
 type Provider struct {
-	cache           *cache.Cache
-	ssm             ssmiface.SSMAPI
-	ec2api          ec2iface.EC2API
-	cm              *pretty.ChangeMonitor
-	versionProvider *version.Provider
+	mu                  sync.Mutex
+	describeImagesGroup singleflight.Group
+	cache               *cache.Cache
+	ssm                 sdk.SSMAPI
+	ec2api              sdk.EC2API
+	cm                  *pretty.ChangeMonitor
+	versionProvider     *version.Provider
 }
 
 type AMI struct {
@@ -101,9 +114,9 @@ func (a AMIs) MapToInstanceTypes(instanceTypes []*cloudprovider.InstanceType) ma
 	return amiIDs
 }
 
-func NewProvider(versionProvider *version.Provider, ssm ssmiface.SSMAPI, ec2api ec2iface.EC2API, cache *cache.Cache) *Provider {
+func NewProvider(versionProvider *version.Provider, ssm sdk.SSMAPI, ec2api sdk.EC2API, cache *cache.Cache) *Provider {
 	return &Provider{
-		cache:           cache,
+		cache:           awscache.WithExpirationMetrics(awscache.AMIs, cache),
 		ssm:             ssm,
 		ec2api:          ec2api,
 		cm:              pretty.NewChangeMonitor(),
@@ -112,8 +125,10 @@ func NewProvider(versionProvider *version.Provider, ssm ssmiface.SSMAPI, ec2api
 }
 
 // Get Returning a list of AMIs with its associated requirements
-func (p *Provider) Get(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, options *Options) (AMIs, error) {
-	var err error
+func (p *Provider) Get(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, options *Options) (_ AMIs, err error) {
+	// default AMIs are resolved through SSM parameters, and AMIs that are selected by terms are resolved through EC2
+	ctx, span := tracing.Start(ctx, "AMI.Get", attribute.String("ec2nodeclass", nodeClass.Name))
+	defer func() { tracing.End(span, err) }()
 	var amis AMIs
 	if len(nodeClass.Spec.AMISelectorTerms) == 0 {
 		amis, err = p.getDefaultAMIs(ctx, nodeClass, options)
@@ -127,6 +142,7 @@ func (p *Provider) Get(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, opt
 		}
 	}
 	amis.Sort()
+	span.SetAttributes(attribute.Int("amis", len(amis)))
 	if p.cm.HasChanged(fmt.Sprintf("amis/%s", nodeClass.Name), amis) {
 		logging.FromContext(ctx).With("ids", amis, "count", len(amis)).Debugf("discovered amis")
 	}
@@ -134,113 +150,214 @@ func (p *Provider) Get(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, opt
 }
 
 func (p *Provider) getDefaultAMIs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, options *Options) (res AMIs, err error) {
+	// EC2NodeClasses are reconciled concurrently, so the AMIs are only resolved once while they aren't cached
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if images, ok := p.cache.Get(lo.FromPtr(nodeClass.Spec.AMIFamily)); ok {
+		awscache.RecordLookup(awscache.AMIs, true)
 		return images.(AMIs), nil
 	}
+	awscache.RecordLookup(awscache.AMIs, false)
 	amiFamily := GetAMIFamily(nodeClass.Spec.AMIFamily, options)
 	kubernetesVersion, err := p.versionProvider.Get(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting kubernetes version %w", err)
 	}
 	defaultAMIs := amiFamily.DefaultAMIs(kubernetesVersion)
+	ids, err := p.resolveSSMParameters(ctx, lo.Map(defaultAMIs, func(ami DefaultAMIOutput, _ int) string { return ami.Query }))
+	if err != nil {
+		return nil, fmt.Errorf("discovering amis from ssm, %w", err)
+	}
 	for _, ami := range defaultAMIs {
-		if id, err := p.resolveSSMParameter(ctx, ami.Query); err != nil {
-			logging.FromContext(ctx).With("query", ami.Query).Errorf("discovering amis from ssm, %s", err)
+		if id, ok := ids[ami.Query]; !ok {
+			logging.FromContext(ctx).With("query", ami.Query).Errorf("discovering amis from ssm, parameter not found")
 		} else {
 			res = append(res, AMI{AmiID: id, Requirements: ami.Requirements})
 		}
 	}
 	// Resolve Name and CreationDate information into the DefaultAMIs
-	if err = p.ec2api.DescribeImagesPagesWithContext(ctx, &ec2.DescribeImagesInput{
-		Filters:    []*ec2.Filter{{Name: aws.String("image-id"), Values: aws.StringSlice(lo.Map(res, func(a AMI, _ int) string { return a.AmiID }))}},
-		MaxResults: aws.Int64(500),
-	}, func(page *ec2.DescribeImagesOutput, _ bool) bool {
+	paginator := ec2.NewDescribeImagesPaginator(p.ec2api, &ec2.DescribeImagesInput{
+		Filters:    []ec2types.Filter{{Name: aws.String("image-id"), Values: lo.Map(res, func(a AMI, _ int) string { return a.AmiID })}},
+		MaxResults: aws.Int32(500),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing images, %w", err)
+		}
 		for i := range page.Images {
 			for j := range res {
-				if res[j].AmiID == aws.StringValue(page.Images[i].ImageId) {
-					res[j].Name = aws.StringValue(page.Images[i].Name)
-					res[j].CreationDate = aws.StringValue(page.Images[i].CreationDate)
+				if res[j].AmiID == aws.ToString(page.Images[i].ImageId) {
+					res[j].Name = aws.ToString(page.Images[i].Name)
+					res[j].CreationDate = aws.ToString(page.Images[i].CreationDate)
 				}
 			}
 		}
-		return true
-	}); err != nil {
-		return nil, fmt.Errorf("describing images, %w", err)
 	}
 	p.cache.SetDefault(lo.FromPtr(nodeClass.Spec.AMIFamily), res)
+	awscache.RecordRefresh(awscache.AMIs)
 	return res, nil
 }
 
-func (p *Provider) resolveSSMParameter(ctx context.Context, ssmQuery string) (string, error) {
-	output, err := p.ssm.GetParameterWithContext(ctx, &ssm.GetParameterInput{Name: aws.String(ssmQuery)})
-	if err != nil {
-		return "", fmt.Errorf("getting ssm parameter %q, %w", ssmQuery, err)
+// resolveSSMParameters returns the values of the SSM parameters by name, omitting the parameters that don't exist.
+// Values are cached by parameter name, so that AMI families which share parameters don't resolve them again, and the
+// parameters that aren't cached are requested in batches.
+func (p *Provider) resolveSSMParameters(ctx context.Context, names []string) (map[string]string, error) {
+	values := map[string]string{}
+	var uncached []string
+	for _, name := range lo.Uniq(names) {
+		if value, ok := p.cache.Get(ssmCacheKey(name)); ok {
+			values[name] = value.(string)
+		} else {
+			uncached = append(uncached, name)
+		}
 	}
-	ami := aws.StringValue(output.Parameter.Value)
-	return ami, nil
+	for _, batch := range lo.Chunk(uncached, maxGetParametersNames) {
+		output, err := p.ssm.GetParameters(ctx, &ssm.GetParametersInput{Names: batch})
+		// Policies that were created before parameters were requested in batches only allow ssm:GetParameter
+		if awserrors.IsAccessDenied(err) {
+			output, err = p.getParameters(ctx, batch)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting ssm parameters %v, %w", batch, err)
+		}
+		for _, parameter := range output.Parameters {
+			values[aws.ToString(parameter.Name)] = aws.ToString(parameter.Value)
+			p.cache.SetDefault(ssmCacheKey(aws.ToString(parameter.Name)), aws.ToString(parameter.Value))
+		}
+	}
+	return values, nil
+}
+
+// getParameters requests the SSM parameters one at a time, returning them in the same way as ssm:GetParameters
+func (p *Provider) getParameters(ctx context.Context, names []string) (*ssm.GetParametersOutput, error) {
+	output := &ssm.GetParametersOutput{}
+	for _, name := range names {
+		out, err := p.ssm.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name)})
+		if err != nil {
+			var notFound *ssmtypes.ParameterNotFound
+			if errors.As(err, &notFound) {
+				output.InvalidParameters = append(output.InvalidParameters, name)
+				continue
+			}
+			return nil, err
+		}
+		output.Parameters = append(output.Parameters, ssmtypes.Parameter{Name: aws.String(name), Value: out.Parameter.Value})
+	}
+	return output, nil
+}
+
+// Invalidate removes the AMIs that are cached, so that changes to images are discovered on the next Get instead of when
+// the cache expires. Resolved SSM parameters are kept, since they don't change when images do.
+func (p *Provider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.cache.Items() {
+		if !strings.HasPrefix(key, ssmCacheKey("")) {
+			p.cache.Delete(key)
+		}
+	}
+}
+
+func ssmCacheKey(name string) string {
+	return fmt.Sprintf("ssm/%s", name)
 }
 
 func (p *Provider) getAMIs(ctx context.Context, terms []v1beta1.AMISelectorTerm) (AMIs, error) {
-	filterAndOwnerSets := GetFilterAndOwnerSets(terms)
-	hash, err := hashstructure.Hash(filterAndOwnerSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	images := map[uint64]AMI{}
+	for _, filtersAndOwners := range GetFilterAndOwnerSets(terms) {
+		setImages, err := p.describeImages(ctx, filtersAndOwners)
+		if err != nil {
+			return nil, err
+		}
+		for reqsHash, image := range setImages {
+			if existing, ok := images[reqsHash]; ok && !newerImage(image, existing) {
+				continue
+			}
+			images[reqsHash] = image
+		}
+	}
+	return lo.Values(images), nil
+}
+
+// describeImages returns the newest image for each set of requirements that is selected by the filters and owners.
+// Images are cached by the filters and owners, so that EC2NodeClasses which share AMI selector terms share the images,
+// and concurrent requests for the same filters and owners are made to EC2 once.
+func (p *Provider) describeImages(ctx context.Context, filtersAndOwners FiltersAndOwners) (map[uint64]AMI, error) {
+	hash, err := hashstructure.Hash(filtersAndOwners, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
 		return nil, err
 	}
-	if images, ok := p.cache.Get(fmt.Sprintf("%d", hash)); ok {
-		return images.(AMIs), nil
+	key := fmt.Sprintf("images/%d", hash)
+	if images, ok := p.cache.Get(key); ok {
+		awscache.RecordLookup(awscache.AMIs, true)
+		return images.(map[uint64]AMI), nil
 	}
-	images := map[uint64]AMI{}
-	for _, filtersAndOwners := range filterAndOwnerSets {
-		if err = p.ec2api.DescribeImagesPagesWithContext(ctx, &ec2.DescribeImagesInput{
+	awscache.RecordLookup(awscache.AMIs, false)
+	res, err, _ := p.describeImagesGroup.Do(key, func() (interface{}, error) {
+		images := map[uint64]AMI{}
+		paginator := ec2.NewDescribeImagesPaginator(p.ec2api, &ec2.DescribeImagesInput{
 			// Don't include filters in the Describe Images call as EC2 API doesn't allow empty filters.
 			Filters:    lo.Ternary(len(filtersAndOwners.Filters) > 0, filtersAndOwners.Filters, nil),
-			Owners:     lo.Ternary(len(filtersAndOwners.Owners) > 0, aws.StringSlice(filtersAndOwners.Owners), nil),
-			MaxResults: aws.Int64(500),
-		}, func(page *ec2.DescribeImagesOutput, _ bool) bool {
+			Owners:     lo.Ternary(len(filtersAndOwners.Owners) > 0, filtersAndOwners.Owners, nil),
+			MaxResults: aws.Int32(500),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("describing images, %w", err)
+			}
 			for i := range page.Images {
 				reqs := p.getRequirementsFromImage(page.Images[i])
 				if !v1beta1.WellKnownArchitectures.Has(reqs.Get(v1.LabelArchStable).Any()) {
 					continue
 				}
 				reqsHash := lo.Must(hashstructure.Hash(reqs.NodeSelectorRequirements(), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true}))
-				// If the proposed image is newer, store it so that we can return it
-				if v, ok := images[reqsHash]; ok {
-					candidateCreationTime, _ := time.Parse(time.RFC3339, lo.FromPtr(page.Images[i].CreationDate))
-					existingCreationTime, _ := time.Parse(time.RFC3339, v.CreationDate)
-					if existingCreationTime == candidateCreationTime && lo.FromPtr(page.Images[i].Name) < v.Name {
-						continue
-					}
-					if candidateCreationTime.Unix() < existingCreationTime.Unix() {
-						continue
-					}
-				}
-				images[reqsHash] = AMI{
+				image := AMI{
 					Name:         lo.FromPtr(page.Images[i].Name),
 					AmiID:        lo.FromPtr(page.Images[i].ImageId),
 					CreationDate: lo.FromPtr(page.Images[i].CreationDate),
 					Requirements: reqs,
 				}
+				// If the proposed image is newer, store it so that we can return it
+				if existing, ok := images[reqsHash]; ok && !newerImage(image, existing) {
+					continue
+				}
+				images[reqsHash] = image
 			}
-			return true
-		}); err != nil {
-			return nil, fmt.Errorf("describing images, %w", err)
 		}
+		p.cache.SetDefault(key, images)
+		awscache.RecordRefresh(awscache.AMIs)
+		return images, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	p.cache.SetDefault(fmt.Sprintf("%d", hash), AMIs(lo.Values(images)))
-	return lo.Values(images), nil
+	return res.(map[uint64]AMI), nil
+}
+
+// newerImage returns true if the candidate image should be used instead of the existing image. Newer images are
+// preferred, and images that were created at the same time are ordered by name.
+func newerImage(candidate, existing AMI) bool {
+	candidateCreationTime, _ := time.Parse(time.RFC3339, candidate.CreationDate)
+	existingCreationTime, _ := time.Parse(time.RFC3339, existing.CreationDate)
+	if existingCreationTime == candidateCreationTime && candidate.Name < existing.Name {
+		return false
+	}
+	return candidateCreationTime.Unix() >= existingCreationTime.Unix()
 }
 
 type FiltersAndOwners struct {
-	Filters []*ec2.Filter
+	Filters []ec2types.Filter
 	Owners  []string
 }
 
 func GetFilterAndOwnerSets(terms []v1beta1.AMISelectorTerm) (res []FiltersAndOwners) {
-	idFilter := &ec2.Filter{Name: aws.String("image-id")}
+	idFilter := ec2types.Filter{Name: aws.String("image-id")}
 	for _, term := range terms {
 		switch {
 		case term.ID != "":
-			idFilter.Values = append(idFilter.Values, aws.String(term.ID))
+			idFilter.Values = append(idFilter.Values, term.ID)
 		default:
 			elem := FiltersAndOwners{
 				Owners: lo.Ternary(term.Owner != "", []string{term.Owner}, []string{}),
@@ -251,22 +368,22 @@ func GetFilterAndOwnerSets(terms []v1beta1.AMISelectorTerm) (res []FiltersAndOwn
 				elem = FiltersAndOwners{
 					Owners: lo.Ternary(term.Owner != "", []string{term.Owner}, []string{"self", "amazon"}),
 				}
-				elem.Filters = append(elem.Filters, &ec2.Filter{
+				elem.Filters = append(elem.Filters, ec2types.Filter{
 					Name:   aws.String("name"),
-					Values: aws.StringSlice([]string{term.Name}),
+					Values: []string{term.Name},
 				})
 
 			}
 			for k, v := range term.Tags {
 				if v == "*" {
-					elem.Filters = append(elem.Filters, &ec2.Filter{
+					elem.Filters = append(elem.Filters, ec2types.Filter{
 						Name:   aws.String("tag-key"),
-						Values: []*string{aws.String(k)},
+						Values: []string{k},
 					})
 				} else {
-					elem.Filters = append(elem.Filters, &ec2.Filter{
+					elem.Filters = append(elem.Filters, ec2types.Filter{
 						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
-						Values: []*string{aws.String(v)},
+						Values: []string{v},
 					})
 				}
 			}
@@ -274,15 +391,15 @@ func GetFilterAndOwnerSets(terms []v1beta1.AMISelectorTerm) (res []FiltersAndOwn
 		}
 	}
 	if len(idFilter.Values) > 0 {
-		res = append(res, FiltersAndOwners{Filters: []*ec2.Filter{idFilter}})
+		res = append(res, FiltersAndOwners{Filters: []ec2types.Filter{idFilter}})
 	}
 	return res
 }
 
-func (p *Provider) getRequirementsFromImage(ec2Image *ec2.Image) scheduling.Requirements {
+func (p *Provider) getRequirementsFromImage(ec2Image ec2types.Image) scheduling.Requirements {
 	requirements := scheduling.NewRequirements()
 	// Always add the architecture of an image as a requirement, irrespective of what's specified in EC2 tags.
-	architecture := *ec2Image.Architecture
+	architecture := string(ec2Image.Architecture)
 	if value, ok := v1beta1.AWSToKubeArchitectures[architecture]; ok {
 		architecture = value
 	}
@@ -26,7 +26,7 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go-v2/aws"
 )
 
 type Bottlerocket struct {
@@ -16,6 +16,7 @@ package amifamily
 
 import (
 	"fmt"
+	"regexp"
 
 	"github.com/samber/lo"
 
@@ -27,11 +28,32 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 
-	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
+var (
+	// bottlerocketAMINamePattern matches the names of Bottlerocket AMIs, such as
+	// bottlerocket-aws-k8s-1.29-x86_64-v1.19.2-29cc92cc, capturing the variant and architecture
+	bottlerocketAMINamePattern = regexp.MustCompile(`^bottlerocket-(.+)-(x86_64|aarch64)-v\d+\.\d+\.\d+-[0-9a-f]+$`)
+	// bottlerocketOSImagePattern matches the OS image that Bottlerocket nodes report, such as
+	// Bottlerocket OS 1.19.2 (aws-k8s-1.29), capturing the variant
+	bottlerocketOSImagePattern = regexp.MustCompile(`^Bottlerocket OS \d+\.\d+\.\d+ \((.+)\)$`)
+)
+
+// IsBottlerocketRelease returns true if the AMI is a release of the Bottlerocket variant and architecture that the node
+// is running, so that the node can be updated to the release in place instead of being replaced
+func IsBottlerocketRelease(amiName string, nodeInfo v1.NodeSystemInfo) bool {
+	ami := bottlerocketAMINamePattern.FindStringSubmatch(amiName)
+	node := bottlerocketOSImagePattern.FindStringSubmatch(nodeInfo.OSImage)
+	if ami == nil || node == nil {
+		return false
+	}
+	arch := map[string]string{corev1beta1.ArchitectureAmd64: "x86_64", corev1beta1.ArchitectureArm64: "aarch64"}[nodeInfo.Architecture]
+	return ami[1] == node[1] && ami[2] == arch
+}
+
 type Bottlerocket struct {
 	DefaultFamily
 	*Options
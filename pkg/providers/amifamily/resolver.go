@@ -19,8 +19,8 @@ import (
 	"fmt"
 	"net"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
 	core "k8s.io/api/core/v1"
@@ -37,7 +37,7 @@ import (
 
 var DefaultEBS = v1beta1.BlockDevice{
 	Encrypted:  aws.Bool(true),
-	VolumeType: aws.String(ec2.VolumeTypeGp3),
+	VolumeType: aws.String(string(ec2types.VolumeTypeGp3)),
 	VolumeSize: lo.ToPtr(resource.MustParse("20Gi")),
 }
 
@@ -61,6 +61,8 @@ type Options struct {
 	KubeDNSIP                net.IP
 	AssociatePublicIPAddress *bool
 	NodeClassName            string
+	// NodeClassGeneration is tagged on launch templates so that templates of older generations can be deleted
+	NodeClassGeneration int64 `hash:"ignore"`
 }
 
 // LaunchTemplate holds the dynamically generated launch template parameters
@@ -165,7 +167,7 @@ func (r Resolver) Resolve(ctx context.Context, nodeClass *v1beta1.EC2NodeClass,
 }
 
 func GetAMIFamily(amiFamily *string, options *Options) AMIFamily {
-	switch aws.StringValue(amiFamily) {
+	switch aws.ToString(amiFamily) {
 	case v1beta1.AMIFamilyBottlerocket:
 		return &Bottlerocket{Options: options}
 	case v1beta1.AMIFamilyUbuntu:
@@ -185,10 +187,10 @@ func GetAMIFamily(amiFamily *string, options *Options) AMIFamily {
 
 func (o Options) DefaultMetadataOptions() *v1beta1.MetadataOptions {
 	return &v1beta1.MetadataOptions{
-		HTTPEndpoint:            aws.String(ec2.LaunchTemplateInstanceMetadataEndpointStateEnabled),
-		HTTPProtocolIPv6:        aws.String(lo.Ternary(o.KubeDNSIP == nil || o.KubeDNSIP.To4() != nil, ec2.LaunchTemplateInstanceMetadataProtocolIpv6Disabled, ec2.LaunchTemplateInstanceMetadataProtocolIpv6Enabled)),
+		HTTPEndpoint:            aws.String(string(ec2types.LaunchTemplateInstanceMetadataEndpointStateEnabled)),
+		HTTPProtocolIPv6:        aws.String(string(lo.Ternary(o.KubeDNSIP == nil || o.KubeDNSIP.To4() != nil, ec2types.LaunchTemplateInstanceMetadataProtocolIpv6Disabled, ec2types.LaunchTemplateInstanceMetadataProtocolIpv6Enabled))),
 		HTTPPutResponseHopLimit: aws.Int64(2),
-		HTTPTokens:              aws.String(ec2.LaunchTemplateHttpTokensStateRequired),
+		HTTPTokens:              aws.String(string(ec2types.LaunchTemplateHttpTokensStateRequired)),
 	}
 }
 
@@ -233,7 +235,7 @@ func (r Resolver) resolveLaunchTemplate(nodeClass *v1beta1.EC2NodeClass, nodeCla
 		),
 		BlockDeviceMappings: nodeClass.Spec.BlockDeviceMappings,
 		MetadataOptions:     nodeClass.Spec.MetadataOptions,
-		DetailedMonitoring:  aws.BoolValue(nodeClass.Spec.DetailedMonitoring),
+		DetailedMonitoring:  aws.ToBool(nodeClass.Spec.DetailedMonitoring),
 		AMIID:               amiID,
 		InstanceTypes:       instanceTypes,
 		EFACount:            efaCount,
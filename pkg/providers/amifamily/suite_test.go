@@ -18,11 +18,14 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
@@ -70,13 +73,13 @@ var _ = BeforeSuite(func() {
 var _ = BeforeEach(func() {
 	// Set up the DescribeImages API so that we can call it by ID with the mock parameters that we generate
 	awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
-		Images: []*ec2.Image{
+		Images: []ec2types.Image{
 			{
 				Name:         aws.String(amd64AMI),
 				ImageId:      aws.String("amd64-ami-id"),
 				CreationDate: aws.String(time.Now().Format(time.RFC3339)),
-				Architecture: aws.String("x86_64"),
-				Tags: []*ec2.Tag{
+				Architecture: "x86_64",
+				Tags: []ec2types.Tag{
 					{Key: aws.String("Name"), Value: aws.String(amd64AMI)},
 					{Key: aws.String("foo"), Value: aws.String("bar")},
 				},
@@ -85,8 +88,8 @@ var _ = BeforeEach(func() {
 				Name:         aws.String(arm64AMI),
 				ImageId:      aws.String("arm64-ami-id"),
 				CreationDate: aws.String(time.Now().Add(time.Minute).Format(time.RFC3339)),
-				Architecture: aws.String("arm64"),
-				Tags: []*ec2.Tag{
+				Architecture: "arm64",
+				Tags: []ec2types.Tag{
 					{Key: aws.String("Name"), Value: aws.String(arm64AMI)},
 					{Key: aws.String("foo"), Value: aws.String("bar")},
 				},
@@ -95,8 +98,8 @@ var _ = BeforeEach(func() {
 				Name:         aws.String(amd64NvidiaAMI),
 				ImageId:      aws.String("amd64-nvidia-ami-id"),
 				CreationDate: aws.String(time.Now().Add(2 * time.Minute).Format(time.RFC3339)),
-				Architecture: aws.String("x86_64"),
-				Tags: []*ec2.Tag{
+				Architecture: "x86_64",
+				Tags: []ec2types.Tag{
 					{Key: aws.String("Name"), Value: aws.String(amd64NvidiaAMI)},
 					{Key: aws.String("foo"), Value: aws.String("bar")},
 				},
@@ -105,8 +108,8 @@ var _ = BeforeEach(func() {
 				Name:         aws.String(arm64NvidiaAMI),
 				ImageId:      aws.String("arm64-nvidia-ami-id"),
 				CreationDate: aws.String(time.Now().Add(2 * time.Minute).Format(time.RFC3339)),
-				Architecture: aws.String("arm64"),
-				Tags: []*ec2.Tag{
+				Architecture: "arm64",
+				Tags: []ec2types.Tag{
 					{Key: aws.String("Name"), Value: aws.String(arm64NvidiaAMI)},
 					{Key: aws.String("foo"), Value: aws.String("bar")},
 				},
@@ -196,6 +199,90 @@ var _ = Describe("AMIProvider", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(amis).To(HaveLen(0))
 	})
+	Context("SSM Parameter Batching", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyBottlerocket
+			awsEnv.SSMAPI.Parameters = map[string]string{
+				fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%s/x86_64/latest/image_id", version):        amd64AMI,
+				fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%s-nvidia/x86_64/latest/image_id", version): amd64NvidiaAMI,
+				fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%s/arm64/latest/image_id", version):         arm64AMI,
+				fmt.Sprintf("/aws/service/bottlerocket/aws-k8s-%s-nvidia/arm64/latest/image_id", version):  arm64NvidiaAMI,
+			}
+		})
+		It("should resolve the parameters of an AMI family with a single call", func() {
+			_, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.SSMAPI.GetParametersBehavior.Calls()).To(Equal(1))
+			Expect(awsEnv.SSMAPI.GetParametersBehavior.CalledWithInput.Pop().Names).To(HaveLen(4))
+		})
+		It("should resolve the parameters individually when batched calls aren't allowed", func() {
+			awsEnv.SSMAPI.GetParametersBehavior.Error.Set(&smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized to perform ssm:GetParameters"})
+			amis, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(6))
+		})
+		It("should fail to resolve AMIs when the parameters can't be retrieved", func() {
+			awsEnv.SSMAPI.GetParametersBehavior.Error.Set(fmt.Errorf("throttled"))
+			_, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should resolve cached parameters without calling ssm", func() {
+			_, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			awsEnv.SSMAPI.GetParametersBehavior.Reset()
+
+			// The AMIs of the family expire from the cache before the parameters, when they were cached at the same time
+			awsEnv.EC2Cache.Delete(v1beta1.AMIFamilyBottlerocket)
+			amis, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(amis).To(HaveLen(6))
+			Expect(awsEnv.SSMAPI.GetParametersBehavior.Calls()).To(Equal(0))
+		})
+	})
+	Context("Shared Images", func() {
+		BeforeEach(func() {
+			nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"foo": "bar"}}}
+		})
+		It("should describe images once for ec2nodeclasses with the same selector terms", func() {
+			other := test.EC2NodeClass(v1beta1.EC2NodeClass{
+				Spec: v1beta1.EC2NodeClassSpec{AMISelectorTerms: []v1beta1.AMISelectorTerm{{Tags: map[string]string{"foo": "bar"}}}},
+			})
+			amis, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			otherAMIs, err := awsEnv.AMIProvider.Get(ctx, other, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(otherAMIs).To(ConsistOf(amis))
+			Expect(awsEnv.EC2API.CalledWithDescribeImagesInput.Len()).To(Equal(1))
+		})
+		It("should describe images once for concurrent requests with the same selector terms", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					amis, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(amis).To(HaveLen(4))
+				}()
+			}
+			wg.Wait()
+			Expect(awsEnv.EC2API.CalledWithDescribeImagesInput.Len()).To(Equal(1))
+		})
+		It("should only describe images for the selector terms that aren't shared", func() {
+			other := test.EC2NodeClass(v1beta1.EC2NodeClass{
+				Spec: v1beta1.EC2NodeClassSpec{AMISelectorTerms: []v1beta1.AMISelectorTerm{
+					{Tags: map[string]string{"foo": "bar"}},
+					{Name: amd64AMI},
+				}},
+			})
+			_, err := awsEnv.AMIProvider.Get(ctx, nodeClass, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.AMIProvider.Get(ctx, other, &amifamily.Options{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(awsEnv.EC2API.CalledWithDescribeImagesInput.Len()).To(Equal(2))
+		})
+	})
 	Context("SSM Alias Missing", func() {
 		It("should succeed to partially resolve AMIs if all SSM aliases don't exist (Al2)", func() {
 			nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyAL2
@@ -244,14 +331,14 @@ var _ = Describe("AMIProvider", func() {
 		})
 	})
 	Context("AMI Tag Requirements", func() {
-		var img *ec2.Image
+		var img ec2types.Image
 		BeforeEach(func() {
-			img = &ec2.Image{
+			img = ec2types.Image{
 				Name:         aws.String(amd64AMI),
 				ImageId:      aws.String("amd64-ami-id"),
 				CreationDate: aws.String(time.Now().Format(time.RFC3339)),
-				Architecture: aws.String("x86_64"),
-				Tags: []*ec2.Tag{
+				Architecture: "x86_64",
+				Tags: []ec2types.Tag{
 					{Key: aws.String("Name"), Value: aws.String(amd64AMI)},
 					{Key: aws.String("foo"), Value: aws.String("bar")},
 					{Key: aws.String(v1.LabelInstanceTypeStable), Value: aws.String("m5.large")},
@@ -259,7 +346,7 @@ var _ = Describe("AMIProvider", func() {
 				},
 			}
 			awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{
-				Images: []*ec2.Image{
+				Images: []ec2types.Image{
 					img,
 				},
 			})
@@ -274,9 +361,9 @@ var _ = Describe("AMIProvider", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(amis).To(HaveLen(1))
 			Expect(amis).To(ConsistOf(amifamily.AMI{
-				Name:         aws.StringValue(img.Name),
-				AmiID:        aws.StringValue(img.ImageId),
-				CreationDate: aws.StringValue(img.CreationDate),
+				Name:         aws.ToString(img.Name),
+				AmiID:        aws.ToString(img.ImageId),
+				CreationDate: aws.ToString(img.CreationDate),
 				Requirements: scheduling.NewRequirements(
 					scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, corev1beta1.ArchitectureAmd64),
 				),
@@ -297,10 +384,10 @@ var _ = Describe("AMIProvider", func() {
 			filterAndOwnersSets := amifamily.GetFilterAndOwnerSets(amiSelectorTerms)
 			ExpectConsistsOfFiltersAndOwners([]amifamily.FiltersAndOwners{
 				{
-					Filters: []*ec2.Filter{
+					Filters: []ec2types.Filter{
 						{
 							Name:   aws.String("tag:Name"),
-							Values: aws.StringSlice([]string{"my-ami"}),
+							Values: []string{"my-ami"},
 						},
 					},
 					Owners: []string{},
@@ -316,10 +403,10 @@ var _ = Describe("AMIProvider", func() {
 			filterAndOwnersSets := amifamily.GetFilterAndOwnerSets(amiSelectorTerms)
 			ExpectConsistsOfFiltersAndOwners([]amifamily.FiltersAndOwners{
 				{
-					Filters: []*ec2.Filter{
+					Filters: []ec2types.Filter{
 						{
 							Name:   aws.String("name"),
-							Values: aws.StringSlice([]string{"my-ami"}),
+							Values: []string{"my-ami"},
 						},
 					},
 					Owners: []string{
@@ -341,10 +428,10 @@ var _ = Describe("AMIProvider", func() {
 			filterAndOwnersSets := amifamily.GetFilterAndOwnerSets(amiSelectorTerms)
 			ExpectConsistsOfFiltersAndOwners([]amifamily.FiltersAndOwners{
 				{
-					Filters: []*ec2.Filter{
+					Filters: []ec2types.Filter{
 						{
 							Name:   aws.String("image-id"),
-							Values: aws.StringSlice([]string{"ami-abcd1234", "ami-cafeaced"}),
+							Values: []string{"ami-abcd1234", "ami-cafeaced"},
 						},
 					},
 				},
@@ -384,19 +471,19 @@ var _ = Describe("AMIProvider", func() {
 			ExpectConsistsOfFiltersAndOwners([]amifamily.FiltersAndOwners{
 				{
 					Owners: []string{"0123456789"},
-					Filters: []*ec2.Filter{
+					Filters: []ec2types.Filter{
 						{
 							Name:   aws.String("name"),
-							Values: aws.StringSlice([]string{"my-name"}),
+							Values: []string{"my-name"},
 						},
 					},
 				},
 				{
 					Owners: []string{"self"},
-					Filters: []*ec2.Filter{
+					Filters: []ec2types.Filter{
 						{
 							Name:   aws.String("name"),
-							Values: aws.StringSlice([]string{"my-name"}),
+							Values: []string{"my-name"},
 						},
 					},
 				},
@@ -460,6 +547,27 @@ var _ = Describe("AMIProvider", func() {
 			))
 		})
 	})
+	Context("Bottlerocket Releases", func() {
+		DescribeTable("should match releases of the node's Bottlerocket variant",
+			func(amiName string, nodeInfo v1.NodeSystemInfo, expected bool) {
+				Expect(amifamily.IsBottlerocketRelease(amiName, nodeInfo)).To(Equal(expected))
+			},
+			Entry("newer release", "bottlerocket-aws-k8s-1.29-x86_64-v1.20.0-7d2d3a9c",
+				v1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (aws-k8s-1.29)", Architecture: corev1beta1.ArchitectureAmd64}, true),
+			Entry("arm64 release", "bottlerocket-aws-k8s-1.29-aarch64-v1.20.0-7d2d3a9c",
+				v1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (aws-k8s-1.29)", Architecture: corev1beta1.ArchitectureArm64}, true),
+			Entry("nvidia variant", "bottlerocket-aws-k8s-1.29-nvidia-x86_64-v1.20.0-7d2d3a9c",
+				v1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (aws-k8s-1.29-nvidia)", Architecture: corev1beta1.ArchitectureAmd64}, true),
+			Entry("different variant", "bottlerocket-aws-k8s-1.30-x86_64-v1.20.0-7d2d3a9c",
+				v1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (aws-k8s-1.29)", Architecture: corev1beta1.ArchitectureAmd64}, false),
+			Entry("different architecture", "bottlerocket-aws-k8s-1.29-aarch64-v1.20.0-7d2d3a9c",
+				v1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (aws-k8s-1.29)", Architecture: corev1beta1.ArchitectureAmd64}, false),
+			Entry("custom ami", "my-custom-ami",
+				v1.NodeSystemInfo{OSImage: "Bottlerocket OS 1.19.2 (aws-k8s-1.29)", Architecture: corev1beta1.ArchitectureAmd64}, false),
+			Entry("non-bottlerocket node", "bottlerocket-aws-k8s-1.29-x86_64-v1.20.0-7d2d3a9c",
+				v1.NodeSystemInfo{OSImage: "Amazon Linux 2", Architecture: corev1beta1.ArchitectureAmd64}, false),
+		)
+	})
 })
 
 func ExpectConsistsOfFiltersAndOwners(expected, actual []amifamily.FiltersAndOwners) {
@@ -470,7 +578,7 @@ func ExpectConsistsOfFiltersAndOwners(expected, actual []amifamily.FiltersAndOwn
 		for _, elem := range list {
 			for _, f := range elem.Filters {
 				sort.Slice(f.Values, func(i, j int) bool {
-					return lo.FromPtr(f.Values[i]) < lo.FromPtr(f.Values[j])
+					return f.Values[i] < f.Values[j]
 				})
 			}
 			sort.Slice(elem.Owners, func(i, j int) bool { return elem.Owners[i] < elem.Owners[j] })
@@ -0,0 +1,119 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/samber/lo"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+const targetID = "KarpenterInterruptionQueueTarget"
+
+// Rule is an EventBridge rule on the default event bus that matches events by source and detail type, and optionally
+// by the values of fields in the event's detail
+type Rule struct {
+	Name       string
+	Source     string
+	DetailType string
+	Detail     map[string][]string
+}
+
+type Provider struct {
+	client sdk.EventBridgeAPI
+}
+
+func NewProvider(client sdk.EventBridgeAPI) *Provider {
+	return &Provider{
+		client: client,
+	}
+}
+
+// Target is the SQS queue that a rule forwards events to
+type Target struct {
+	ARN string
+	// MessageGroupID is required for FIFO queues and must be empty for standard queues
+	MessageGroupID string
+}
+
+// EnsureRule creates or updates the rule and its tags and targets the rule at the passed queue
+func (p *Provider) EnsureRule(ctx context.Context, rule Rule, target Target, tags map[string]string) error {
+	eventPattern := map[string]any{
+		"source":      []string{rule.Source},
+		"detail-type": []string{rule.DetailType},
+	}
+	if len(rule.Detail) > 0 {
+		eventPattern["detail"] = rule.Detail
+	}
+	pattern, err := json.Marshal(eventPattern)
+	if err != nil {
+		return fmt.Errorf("marshaling event pattern, %w", err)
+	}
+	out, err := p.client.PutRule(ctx, &eventbridge.PutRuleInput{
+		Name:         aws.String(rule.Name),
+		EventPattern: aws.String(string(pattern)),
+		State:        eventbridgetypes.RuleStateEnabled,
+		Tags:         toTags(tags),
+	})
+	if err != nil {
+		return fmt.Errorf("putting rule %s, %w", rule.Name, err)
+	}
+	// Tags are only applied by PutRule when the rule doesn't exist yet
+	if len(tags) > 0 {
+		if _, err = p.client.TagResource(ctx, &eventbridge.TagResourceInput{
+			ResourceARN: out.RuleArn,
+			Tags:        toTags(tags),
+		}); err != nil {
+			return fmt.Errorf("tagging rule %s, %w", rule.Name, err)
+		}
+	}
+	targets, err := p.client.PutTargets(ctx, &eventbridge.PutTargetsInput{
+		Rule: aws.String(rule.Name),
+		Targets: []eventbridgetypes.Target{
+			{
+				Id:            aws.String(targetID),
+				Arn:           aws.String(target.ARN),
+				SqsParameters: sqsParameters(target),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("putting targets for rule %s, %w", rule.Name, err)
+	}
+	if int64(targets.FailedEntryCount) > 0 {
+		return fmt.Errorf("putting targets for rule %s, %s", rule.Name, aws.ToString(targets.FailedEntries[0].ErrorMessage))
+	}
+	return nil
+}
+
+func sqsParameters(target Target) *eventbridgetypes.SqsParameters {
+	if target.MessageGroupID == "" {
+		return nil
+	}
+	return &eventbridgetypes.SqsParameters{MessageGroupId: aws.String(target.MessageGroupID)}
+}
+
+func toTags(tags map[string]string) []eventbridgetypes.Tag {
+	return lo.MapToSlice(tags, func(k, v string) eventbridgetypes.Tag {
+		return eventbridgetypes.Tag{Key: aws.String(k), Value: aws.String(v)}
+	})
+}
@@ -0,0 +1,44 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbridge
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	eventBridgeSubsystem = "eventbridge"
+	detailTypeLabel      = "detail_type"
+	resultLabel          = "result"
+)
+
+var (
+	publishedEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: eventBridgeSubsystem,
+			Name:      "events_total",
+			Help:      "Number of events that were recorded for the EventBridge event bus. Labeled by detail type and result, which is published, failed, or dropped when the buffer of events was full.",
+		},
+		[]string{detailTypeLabel, resultLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(publishedEvents)
+}
@@ -0,0 +1,200 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbridge
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/events"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+const (
+	// Source is the source of the events that are published to the event bus. Sources that start with "aws." are
+	// reserved for AWS services.
+	Source = "karpenter.sh"
+
+	DetailTypeLaunched     = "NodeClaim Launched"
+	DetailTypeLaunchFailed = "NodeClaim Launch Failed"
+	DetailTypeDisrupted    = "NodeClaim Disrupted"
+
+	// maxEntries is the maximum number of entries of a PutEvents request
+	maxEntries = 10
+	// bufferSize is the number of events that are buffered while the event bus is unavailable, after which events
+	// are dropped so that publishing never blocks the controllers
+	bufferSize = 1000
+)
+
+// Detail is the detail of the events that are published to the event bus
+type Detail struct {
+	Cluster      string `json:"cluster"`
+	NodeClaim    string `json:"nodeClaim"`
+	NodePool     string `json:"nodePool,omitempty"`
+	ProviderID   string `json:"providerID,omitempty"`
+	InstanceType string `json:"instanceType,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	CapacityType string `json:"capacityType,omitempty"`
+	// Reason is the disruption reason of disrupted NodeClaims, or the reason that a NodeClaim failed to launch
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message"`
+}
+
+// Recorder decorates an event recorder to also publish the events that describe provisioning and disruption decisions
+// for NodeClaims to an EventBridge event bus. Events are published in batches by Start, so that a slow or unavailable
+// event bus doesn't block the controllers that record events.
+type Recorder struct {
+	events.Recorder
+
+	client      sdk.EventBridgeAPI
+	clk         clock.Clock
+	bus         string
+	clusterName string
+	entries     chan *eventbridgetypes.PutEventsRequestEntry
+}
+
+func NewRecorder(ctx context.Context, recorder events.Recorder, client sdk.EventBridgeAPI, clk clock.Clock) *Recorder {
+	return &Recorder{
+		Recorder:    recorder,
+		client:      client,
+		clk:         clk,
+		bus:         options.FromContext(ctx).EventBridgeBus,
+		clusterName: options.FromContext(ctx).ClusterName,
+		entries:     make(chan *eventbridgetypes.PutEventsRequestEntry, bufferSize),
+	}
+}
+
+func (r *Recorder) Publish(evts ...events.Event) {
+	r.Recorder.Publish(evts...)
+	for _, evt := range evts {
+		entry := r.entry(evt)
+		if entry == nil {
+			continue
+		}
+		select {
+		case r.entries <- entry:
+		default:
+			publishedEvents.WithLabelValues(aws.ToString(entry.DetailType), "dropped").Inc()
+		}
+	}
+}
+
+// entry returns the entry that is published for the event, or nil if the event isn't published to the event bus
+func (r *Recorder) entry(evt events.Event) *eventbridgetypes.PutEventsRequestEntry {
+	nodeClaim, ok := evt.InvolvedObject.(*corev1beta1.NodeClaim)
+	if !ok {
+		return nil
+	}
+	detail := Detail{
+		Cluster:      r.clusterName,
+		NodeClaim:    nodeClaim.Name,
+		NodePool:     nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
+		ProviderID:   nodeClaim.Status.ProviderID,
+		InstanceType: nodeClaim.Labels[v1.LabelInstanceTypeStable],
+		Zone:         nodeClaim.Labels[v1.LabelTopologyZone],
+		CapacityType: nodeClaim.Labels[corev1beta1.CapacityTypeLabelKey],
+		Message:      evt.Message,
+	}
+	var detailType string
+	switch evt.Reason {
+	case "Launched":
+		detailType = DetailTypeLaunched
+	case "InsufficientCapacityError", "NodeClassNotReady":
+		detailType = DetailTypeLaunchFailed
+		detail.Reason = evt.Reason
+	case "DisruptionTerminating":
+		detailType = DetailTypeDisrupted
+		// The disruption reason is only part of the message, e.g. "Disrupting NodeClaim: Drift"
+		_, reason, _ := strings.Cut(evt.Message, ": ")
+		detail.Reason = strings.ToLower(reason)
+	default:
+		return nil
+	}
+	raw, err := json.Marshal(detail)
+	if err != nil {
+		return nil
+	}
+	return &eventbridgetypes.PutEventsRequestEntry{
+		EventBusName: aws.String(r.bus),
+		Source:       aws.String(Source),
+		DetailType:   aws.String(detailType),
+		Detail:       aws.String(string(raw)),
+		Time:         aws.Time(r.clk.Now()),
+	}
+}
+
+// Start publishes the buffered events to the event bus until the context is cancelled. Events that fail to publish
+// are logged and dropped, since they describe decisions that have already been made.
+func (r *Recorder) Start(ctx context.Context) error {
+	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("event-bus", r.bus))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-r.entries:
+			r.put(ctx, append([]eventbridgetypes.PutEventsRequestEntry{*entry}, r.drain(maxEntries-1)...))
+		}
+	}
+}
+
+// drain returns up to n of the buffered entries without waiting for more entries
+func (r *Recorder) drain(n int) []eventbridgetypes.PutEventsRequestEntry {
+	var entries []eventbridgetypes.PutEventsRequestEntry
+	for len(entries) < n {
+		select {
+		case entry := <-r.entries:
+			entries = append(entries, *entry)
+		default:
+			return entries
+		}
+	}
+	return entries
+}
+
+func (r *Recorder) put(ctx context.Context, entries []eventbridgetypes.PutEventsRequestEntry) {
+	out, err := r.client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+	if err != nil {
+		for _, entry := range entries {
+			publishedEvents.WithLabelValues(aws.ToString(entry.DetailType), "failed").Inc()
+		}
+		logging.FromContext(ctx).Errorf("putting events, %s", err)
+		return
+	}
+	// Result entries are in the same order as the request entries
+	for i, entry := range entries {
+		if i < len(out.Entries) && out.Entries[i].ErrorCode != nil {
+			publishedEvents.WithLabelValues(aws.ToString(entry.DetailType), "failed").Inc()
+			logging.FromContext(ctx).With("detail-type", aws.ToString(entry.DetailType)).Errorf("putting event, %s", aws.ToString(out.Entries[i].ErrorMessage))
+			continue
+		}
+		publishedEvents.WithLabelValues(aws.ToString(entry.DetailType), "published").Inc()
+	}
+}
+
+// NeedLeaderElection is false so that events that are recorded by controllers that run on every replica are published
+func (r *Recorder) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,203 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eventbridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	serviceeventbridge "github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clock "k8s.io/utils/clock/testing"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	coreoptions "sigs.k8s.io/karpenter/pkg/operator/options"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	cloudproviderevents "github.com/aws/karpenter-provider-aws/pkg/cloudprovider/events"
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/eventbridge"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+)
+
+var ctx context.Context
+var cancel context.CancelFunc
+var fakeClock *clock.FakeClock
+var eventbridgeapi *fake.EventBridgeAPI
+var kubeRecorder *coretest.EventRecorder
+var recorder *eventbridge.Recorder
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EventBridge")
+}
+
+var _ = BeforeSuite(func() {
+	ctx = coreoptions.ToContext(ctx, coretest.Options())
+	ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EventBridgeBus: lo.ToPtr("karpenter-events")}))
+	fakeClock = clock.NewFakeClock(time.Now())
+	eventbridgeapi = &fake.EventBridgeAPI{}
+	kubeRecorder = coretest.NewEventRecorder()
+})
+
+var _ = BeforeEach(func() {
+	eventbridgeapi.Reset()
+	kubeRecorder.Reset()
+	recorder = eventbridge.NewRecorder(ctx, kubeRecorder, eventbridgeapi, fakeClock)
+})
+
+var _ = AfterEach(func() {
+	if cancel != nil {
+		cancel()
+	}
+})
+
+// start publishes the events that were recorded until the test finishes
+func start() {
+	var startCtx context.Context
+	startCtx, cancel = context.WithCancel(ctx)
+	go func() {
+		defer GinkgoRecover()
+		Expect(recorder.Start(startCtx)).To(Succeed())
+	}()
+}
+
+// expectEntries returns the entries of the PutEvents requests once the expected number of entries has been put
+func expectEntries(count int) []eventbridgetypes.PutEventsRequestEntry {
+	var entries []eventbridgetypes.PutEventsRequestEntry
+	Eventually(func(g Gomega) {
+		entries = nil
+		eventbridgeapi.PutEventsBehavior.CalledWithInput.ForEach(func(input *serviceeventbridge.PutEventsInput) {
+			entries = append(entries, input.Entries...)
+		})
+		g.Expect(entries).To(HaveLen(count))
+	}).Should(Succeed())
+	return entries
+}
+
+func expectDetail(entry eventbridgetypes.PutEventsRequestEntry) eventbridge.Detail {
+	detail := eventbridge.Detail{}
+	Expect(json.Unmarshal([]byte(aws.ToString(entry.Detail)), &detail)).To(Succeed())
+	return detail
+}
+
+var _ = Describe("Recorder", func() {
+	var nodeClaim *corev1beta1.NodeClaim
+
+	BeforeEach(func() {
+		nodeClaim = coretest.NodeClaim(corev1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					corev1beta1.NodePoolLabelKey:     "default",
+					v1.LabelInstanceTypeStable:       "m5.large",
+					v1.LabelTopologyZone:             "test-zone-1a",
+					corev1beta1.CapacityTypeLabelKey: corev1beta1.CapacityTypeSpot,
+				},
+			},
+			Status: corev1beta1.NodeClaimStatus{
+				ProviderID: "aws:///test-zone-1a/i-0123456789abcdef0",
+			},
+		})
+	})
+	It("should publish launched nodeclaims with their instance", func() {
+		recorder.Publish(cloudproviderevents.NodeClaimLaunched(nodeClaim, "i-0123456789abcdef0", "m5.large", "test-zone-1a"))
+		start()
+
+		entries := expectEntries(1)
+		Expect(aws.ToString(entries[0].EventBusName)).To(Equal("karpenter-events"))
+		Expect(aws.ToString(entries[0].Source)).To(Equal(eventbridge.Source))
+		Expect(aws.ToString(entries[0].DetailType)).To(Equal(eventbridge.DetailTypeLaunched))
+		Expect(aws.ToTime(entries[0].Time).Equal(fakeClock.Now())).To(BeTrue())
+		Expect(expectDetail(entries[0])).To(Equal(eventbridge.Detail{
+			Cluster:      options.FromContext(ctx).ClusterName,
+			NodeClaim:    nodeClaim.Name,
+			NodePool:     "default",
+			ProviderID:   "aws:///test-zone-1a/i-0123456789abcdef0",
+			InstanceType: "m5.large",
+			Zone:         "test-zone-1a",
+			CapacityType: corev1beta1.CapacityTypeSpot,
+			Message:      "Launched instance i-0123456789abcdef0 of type m5.large in test-zone-1a",
+		}))
+		// Events are still recorded with the decorated recorder
+		Expect(kubeRecorder.Calls("Launched")).To(Equal(1))
+	})
+	It("should publish disrupted nodeclaims with the disruption reason", func() {
+		node := coretest.Node()
+		recorder.Publish(disruptionevents.Terminating(node, nodeClaim, metrics.DriftReason)...)
+		recorder.Publish(disruptionevents.Terminating(node, nodeClaim, metrics.ConsolidationReason)...)
+		start()
+
+		// Only the events of the nodeclaims are published, since the node events describe the same decision
+		entries := expectEntries(2)
+		Expect(lo.Map(entries, func(e eventbridgetypes.PutEventsRequestEntry, _ int) string { return aws.ToString(e.DetailType) })).
+			To(ConsistOf(eventbridge.DetailTypeDisrupted, eventbridge.DetailTypeDisrupted))
+		Expect(lo.Map(entries, func(e eventbridgetypes.PutEventsRequestEntry, _ int) string { return expectDetail(e).Reason })).
+			To(ConsistOf(metrics.DriftReason, metrics.ConsolidationReason))
+	})
+	It("should publish nodeclaims that failed to launch with the reason", func() {
+		recorder.Publish(lifecycle.InsufficientCapacityErrorEvent(nodeClaim, fmt.Errorf("all requested instance types were unavailable during launch")))
+		start()
+
+		entries := expectEntries(1)
+		Expect(aws.ToString(entries[0].DetailType)).To(Equal(eventbridge.DetailTypeLaunchFailed))
+		Expect(expectDetail(entries[0]).Reason).To(Equal("InsufficientCapacityError"))
+	})
+	It("should not publish events that don't describe provisioning or disruption decisions", func() {
+		recorder.Publish(cloudproviderevents.NodeClaimFailedToResolveNodeClass(nodeClaim))
+		recorder.Publish(cloudproviderevents.NodePoolFailedToResolveNodeClass(coretest.NodePool()))
+		recorder.Publish(cloudproviderevents.NodeClaimLaunched(nodeClaim, "i-0123456789abcdef0", "m5.large", "test-zone-1a"))
+		start()
+
+		Expect(expectEntries(1)).To(HaveLen(1))
+		Consistently(func() int { return eventbridgeapi.PutEventsBehavior.Calls() }, time.Second/2).Should(Equal(1))
+	})
+	It("should batch events into requests of at most 10 entries", func() {
+		for i := 0; i < 25; i++ {
+			nodeClaim.Name = fmt.Sprintf("nodeclaim-%d", i)
+			recorder.Publish(cloudproviderevents.NodeClaimLaunched(nodeClaim.DeepCopy(), "i-0123456789abcdef0", "m5.large", "test-zone-1a"))
+		}
+		start()
+
+		expectEntries(25)
+		eventbridgeapi.PutEventsBehavior.CalledWithInput.ForEach(func(input *serviceeventbridge.PutEventsInput) {
+			Expect(len(input.Entries)).To(BeNumerically("<=", 10))
+		})
+	})
+	It("should continue publishing events when the event bus fails", func() {
+		eventbridgeapi.PutEventsBehavior.Error.Set(fmt.Errorf("failed"), fake.MaxCalls(1))
+		recorder.Publish(cloudproviderevents.NodeClaimLaunched(nodeClaim, "i-0123456789abcdef0", "m5.large", "test-zone-1a"))
+		start()
+		Eventually(func() int { return eventbridgeapi.PutEventsBehavior.FailedCalls() }).Should(Equal(1))
+
+		recorder.Publish(cloudproviderevents.NodeClaimLaunched(nodeClaim, "i-0123456789abcdef0", "m5.large", "test-zone-1a"))
+		expectEntries(1)
+	})
+})
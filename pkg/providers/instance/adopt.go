@@ -0,0 +1,67 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"knative.dev/pkg/logging"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// Adoptable returns an error that describes why the instance can't be adopted by a NodeClaim in the NodePool. Instances
+// can be adopted when they are running, aren't managed by another NodePool and were launched with an AMI, subnet and
+// security groups that are selected by the EC2NodeClass.
+func Adoptable(instance *Instance, nodeClass *v1beta1.EC2NodeClass, nodePoolName string) error {
+	if instance.State != string(ec2types.InstanceStateNameRunning) {
+		return fmt.Errorf("instance is %s", instance.State)
+	}
+	if name, ok := instance.Tags[corev1beta1.NodePoolLabelKey]; ok && name != nodePoolName {
+		return fmt.Errorf("instance is managed by nodepool %q", name)
+	}
+	if !CompatibleWithNodeClass(instance, nodeClass) {
+		return fmt.Errorf("instance wasn't launched with an AMI, subnet and security groups that are selected by ec2nodeclass %q", nodeClass.Name)
+	}
+	return nil
+}
+
+// adopt tags an existing instance so that it's managed by the NodeClaim, instead of launching a new instance
+func (p *Provider) adopt(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, id string,
+	instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (*Instance, error) {
+
+	instance, err := p.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting instance to adopt, %w", err)
+	}
+	if err = Adoptable(instance, nodeClass, nodeClaim.Labels[corev1beta1.NodePoolLabelKey]); err != nil {
+		return nil, fmt.Errorf("adopting instance, %w", err)
+	}
+	if !lo.ContainsBy(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == instance.Type }) {
+		return nil, fmt.Errorf("adopting instance, instance type %q isn't allowed by the nodeclaim", instance.Type)
+	}
+	if err = p.CreateTags(ctx, instance.ID, tags); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).With("id", instance.ID, "instance-type", instance.Type, "zone", instance.Zone).Infof("adopted instance")
+	instance.Tags = lo.Assign(instance.Tags, tags)
+	return instance, nil
+}
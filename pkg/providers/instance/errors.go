@@ -0,0 +1,75 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+)
+
+// FleetError is an error that CreateFleet returned for one of the instance types and zones of a launch, such as
+// InsufficientInstanceCapacity, SpotMaxPriceTooLow or Unsupported
+type FleetError struct {
+	InstanceType string
+	Zone         string
+	CapacityType string
+	Code         string
+	Message      string
+}
+
+func newFleetError(err ec2types.CreateFleetError) FleetError {
+	overrides := lo.FromPtr(lo.FromPtr(err.LaunchTemplateAndOverrides).Overrides)
+	return FleetError{
+		InstanceType: string(overrides.InstanceType),
+		Zone:         aws.ToString(overrides.AvailabilityZone),
+		CapacityType: string(err.Lifecycle),
+		Code:         aws.ToString(err.ErrorCode),
+		Message:      aws.ToString(err.ErrorMessage),
+	}
+}
+
+// Offering describes the instance type and zone of the error, e.g. "c6i.4xlarge in us-east-1a"
+func (e FleetError) Offering() string {
+	if e.Zone == "" {
+		return e.InstanceType
+	}
+	return fmt.Sprintf("%s in %s", e.InstanceType, e.Zone)
+}
+
+// LaunchFailedError is returned when CreateFleet doesn't launch an instance. It wraps the combined error of the fleet
+// errors, which may be an insufficient capacity or quota exceeded error, and keeps the error of each instance type and
+// zone, so that they can be reported individually.
+type LaunchFailedError struct {
+	error
+	FleetErrors []FleetError
+}
+
+func (e *LaunchFailedError) Unwrap() error {
+	return e.error
+}
+
+// FleetErrors returns the errors of the instance types and zones that CreateFleet couldn't launch, if the launch failed
+// with fleet errors
+func FleetErrors(err error) []FleetError {
+	var launchFailedErr *LaunchFailedError
+	if errors.As(err, &launchFailedErr) {
+		return launchFailedErr.FleetErrors
+	}
+	return nil
+}
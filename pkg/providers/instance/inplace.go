@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+// MetadataOptionsUpdatable returns true if the NodeClaim has only drifted from its EC2NodeClass because the
+// metadataOptions of the EC2NodeClass have changed, so that the change can be applied to the instance in place. The
+// metadata options that the instance was launched with aren't recorded on the NodeClaim, so the EC2NodeClass that it
+// was launched with is reconstructed from the instance's current metadata options.
+func MetadataOptionsUpdatable(nodeClaim *corev1beta1.NodeClaim, nodeClass *v1beta1.EC2NodeClass, instance *Instance) bool {
+	if instance.MetadataOptions == nil || nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion] != v1beta1.EC2NodeClassHashVersion {
+		return false
+	}
+	hash := nodeClaim.Annotations[v1beta1.AnnotationEC2NodeClassHash]
+	launched := nodeClass.DeepCopy()
+	launched.Spec.MetadataOptions = instance.MetadataOptions
+	return hash != nodeClass.Hash() && hash == launched.Hash()
+}
+
+// UpdateMetadataOptions applies the metadata options to a running instance
+func (p *Provider) UpdateMetadataOptions(ctx context.Context, id string, metadataOptions *v1beta1.MetadataOptions) error {
+	if _, err := p.ec2api.ModifyInstanceMetadataOptions(ctx, &ec2.ModifyInstanceMetadataOptionsInput{
+		InstanceId:              aws.String(id),
+		HttpEndpoint:            ec2types.InstanceMetadataEndpointState(lo.FromPtr(metadataOptions.HTTPEndpoint)),
+		HttpProtocolIpv6:        ec2types.InstanceMetadataProtocolState(lo.FromPtr(metadataOptions.HTTPProtocolIPv6)),
+		HttpPutResponseHopLimit: lo.EmptyableToPtr(int32(lo.FromPtr(metadataOptions.HTTPPutResponseHopLimit))),
+		HttpTokens:              ec2types.HttpTokensState(lo.FromPtr(metadataOptions.HTTPTokens)),
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("updating instance metadata options, %w", err))
+		}
+		return fmt.Errorf("updating instance metadata options, %w", err)
+	}
+	return nil
+}
+
+// UpdateSecurityGroups replaces the security groups of each of the network interfaces of a running instance
+func (p *Provider) UpdateSecurityGroups(ctx context.Context, instance *Instance, securityGroupIDs []string) error {
+	for _, id := range instance.NetworkInterfaceIDs {
+		if _, err := p.ec2api.ModifyNetworkInterfaceAttribute(ctx, &ec2.ModifyNetworkInterfaceAttributeInput{
+			NetworkInterfaceId: aws.String(id),
+			Groups:             securityGroupIDs,
+		}); err != nil {
+			if awserrors.IsNotFound(err) {
+				return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("updating network interface security groups, %w", err))
+			}
+			return fmt.Errorf("updating network interface security groups, %w", err)
+		}
+	}
+	return nil
+}
@@ -21,90 +21,154 @@ import (
 	"math"
 	"sort"
 	"strings"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
 	"knative.dev/pkg/logging"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 	"github.com/aws/karpenter-provider-aws/pkg/batcher"
-	"github.com/aws/karpenter-provider-aws/pkg/cache"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/terminationhook"
 	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/tracing"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
+// maxDescribeInstancesPageSize is the largest page that DescribeInstances returns, so that listing instances in large
+// accounts takes as few calls as possible without any single call timing out
+const maxDescribeInstancesPageSize = 1000
+
 var (
 	instanceTypeFlexibilityThreshold = 5 // falling back to on-demand without flexibility risks insufficient capacity errors
 
-	instanceStateFilter = &ec2.Filter{
+	instanceStateFilter = ec2types.Filter{
 		Name:   aws.String("instance-state-name"),
-		Values: aws.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning, ec2.InstanceStateNameStopping, ec2.InstanceStateNameStopped, ec2.InstanceStateNameShuttingDown}),
+		Values: []string{string(ec2types.InstanceStateNamePending), string(ec2types.InstanceStateNameRunning), string(ec2types.InstanceStateNameStopping), string(ec2types.InstanceStateNameStopped), string(ec2types.InstanceStateNameShuttingDown)},
 	}
 )
 
 type Provider struct {
-	region                 string
-	ec2api                 ec2iface.EC2API
-	unavailableOfferings   *cache.UnavailableOfferings
-	instanceTypeProvider   *instancetype.Provider
-	subnetProvider         *subnet.Provider
-	launchTemplateProvider *launchtemplate.Provider
-	ec2Batcher             *batcher.EC2API
+	region                  string
+	ec2api                  sdk.EC2API
+	unavailableOfferings    *awscache.UnavailableOfferings
+	instanceTypeProvider    *instancetype.Provider
+	subnetProvider          *subnet.Provider
+	launchTemplateProvider  *launchtemplate.Provider
+	terminationHookProvider *terminationhook.Provider
+	// quotaProvider is nil for instances that are launched in another account, whose quotas aren't tracked
+	quotaProvider  *quota.Provider
+	ec2Batcher     *batcher.EC2API
+	launchThrottle *launchThrottle
+	// startMu serializes starting stopped instances, and startedInstances tracks the instances that were recently
+	// started, so that the same stopped instance isn't started for multiple NodeClaims
+	startMu          sync.Mutex
+	startedInstances *cache.Cache
 }
 
-func NewProvider(ctx context.Context, region string, ec2api ec2iface.EC2API, unavailableOfferings *cache.UnavailableOfferings,
-	instanceTypeProvider *instancetype.Provider, subnetProvider *subnet.Provider, launchTemplateProvider *launchtemplate.Provider) *Provider {
+func NewProvider(ctx context.Context, region string, ec2api sdk.EC2API, unavailableOfferings *awscache.UnavailableOfferings,
+	instanceTypeProvider *instancetype.Provider, subnetProvider *subnet.Provider, launchTemplateProvider *launchtemplate.Provider,
+	terminationHookProvider *terminationhook.Provider, quotaProvider *quota.Provider) *Provider {
 	return &Provider{
-		region:                 region,
-		ec2api:                 ec2api,
-		unavailableOfferings:   unavailableOfferings,
-		instanceTypeProvider:   instanceTypeProvider,
-		subnetProvider:         subnetProvider,
-		launchTemplateProvider: launchTemplateProvider,
-		ec2Batcher:             batcher.EC2(ctx, ec2api),
+		region:                  region,
+		ec2api:                  ec2api,
+		unavailableOfferings:    unavailableOfferings,
+		instanceTypeProvider:    instanceTypeProvider,
+		subnetProvider:          subnetProvider,
+		launchTemplateProvider:  launchTemplateProvider,
+		terminationHookProvider: terminationHookProvider,
+		quotaProvider:           quotaProvider,
+		ec2Batcher:              batcher.EC2(ctx, ec2api),
+		launchThrottle:          newLaunchThrottle(),
+		startedInstances:        cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
 	}
 }
 
-func (p *Provider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
-	schedulingRequirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
-	// Only filter the instances if there are no minValues in the requirement.
-	if !schedulingRequirements.HasMinValues() {
-		instanceTypes = p.filterInstanceTypes(nodeClaim, instanceTypes)
+func (p *Provider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, nodePool *corev1beta1.NodePool, instanceTypes []*cloudprovider.InstanceType) (*Instance, error) {
+	instanceTypes = p.launchableInstanceTypes(nodeClaim, instanceTypes)
+	// EC2NodeClasses are only validated against the tag policy on admission if the validation webhook is enabled
+	tagPolicy, err := options.ParseTagPolicy(options.FromContext(ctx).TagPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("parsing tag policy, %w", err)
+	}
+	if tagPolicy != nil {
+		if violations := tagPolicy.Violations(nodeClass.Spec.Tags); len(violations) > 0 {
+			return nil, fmt.Errorf("tags of ec2nodeclass %q violate the tag policy, %s", nodeClass.Name, strings.Join(violations, "; "))
+		}
 	}
-	tags := getTags(ctx, nodeClass, nodeClaim)
-	fleetInstance, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
+	costTags, err := costAllocationTags(ctx, nodePool, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	labelTags, err := LabelTags(ctx, nodeClaim.Labels)
+	if err != nil {
+		return nil, err
+	}
+	tags := getTags(ctx, nodeClass, nodeClaim, lo.Assign(costTags, labelTags))
+	if id, ok := nodeClaim.Annotations[v1beta1.AnnotationAdoptInstanceID]; ok {
+		return p.adopt(ctx, nodeClass, nodeClaim, id, instanceTypes, tags)
+	}
+	efaEnabled := lo.Contains(lo.Keys(nodeClaim.Spec.Resources.Requests), v1beta1.ResourceEFA)
+	if options.FromContext(ctx).StoppedInstancePoolSize > 0 {
+		if instance, ok := p.startStoppedInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags, efaEnabled); ok {
+			return instance, nil
+		}
+	}
+	done, err := p.launchThrottle.acquire(ctx, nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	createFleetOutput, err := p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
 	if awserrors.IsLaunchTemplateNotFound(err) {
 		// retry once if launch template is not found. This allows karpenter to generate a new LT if the
 		// cache was out-of-sync on the first try
-		fleetInstance, err = p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
+		createFleetOutput, err = p.launchInstance(ctx, nodeClass, nodeClaim, instanceTypes, tags)
 	}
 	if err != nil {
 		return nil, err
 	}
-	efaEnabled := lo.Contains(lo.Keys(nodeClaim.Spec.Resources.Requests), v1beta1.ResourceEFA)
-	return NewInstanceFromFleet(fleetInstance, tags, efaEnabled), nil
+	fleetInstance := &createFleetOutput.Instances[0]
+	if p.quotaProvider != nil {
+		if instanceType, ok := lo.Find(instanceTypes, func(i *cloudprovider.InstanceType) bool {
+			return i.Name == string(fleetInstance.InstanceType)
+		}); ok {
+			p.quotaProvider.Reserve(instanceType.Name, instanceType.Capacity.Cpu().Value(), string(fleetInstance.Lifecycle))
+		}
+	}
+	instance := NewInstanceFromFleet(fleetInstance, tags, efaEnabled)
+	instance.CreateFleetRequestID, _ = awsmiddleware.GetRequestIDMetadata(createFleetOutput.ResultMetadata)
+	return instance, nil
 }
 
 func (p *Provider) Get(ctx context.Context, id string) (*Instance, error) {
 	out, err := p.ec2Batcher.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
-		InstanceIds: aws.StringSlice([]string{id}),
-		Filters:     []*ec2.Filter{instanceStateFilter},
+		InstanceIds: []string{id},
+		Filters:     []ec2types.Filter{instanceStateFilter},
 	})
 	if awserrors.IsNotFound(err) {
 		return nil, cloudprovider.NewNodeClaimNotFoundError(err)
@@ -123,37 +187,79 @@ func (p *Provider) Get(ctx context.Context, id string) (*Instance, error) {
 }
 
 func (p *Provider) List(ctx context.Context) ([]*Instance, error) {
-	var out = &ec2.DescribeInstancesOutput{}
-	err := p.ec2api.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{
-		Filters: []*ec2.Filter{
-			{
-				Name:   aws.String("tag-key"),
-				Values: aws.StringSlice([]string{corev1beta1.NodePoolLabelKey}),
-			},
-			{
-				Name:   aws.String("tag-key"),
-				Values: aws.StringSlice([]string{v1beta1.LabelNodeClass}),
-			},
-			{
-				Name:   aws.String("tag-key"),
-				Values: aws.StringSlice([]string{fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)}),
-			},
-			instanceStateFilter,
-		},
-	}, func(page *ec2.DescribeInstancesOutput, _ bool) bool {
-		out.Reservations = append(out.Reservations, page.Reservations...)
-		return true
-	})
+	shards, err := p.listShards(ctx)
 	if err != nil {
+		return nil, err
+	}
+	reservations := make([][]ec2types.Reservation, len(shards))
+	errs := make([]error, len(shards))
+	// Every shard must be described, since instances that are missing from the list are garbage collected
+	workqueue.ParallelizeUntil(ctx, lo.Max([]int{options.FromContext(ctx).GarbageCollectionListWorkers, 1}), len(shards), func(i int) {
+		paginator := ec2.NewDescribeInstancesPaginator(p.ec2api, &ec2.DescribeInstancesInput{
+			MaxResults: aws.Int32(maxDescribeInstancesPageSize),
+			Filters: append([]ec2types.Filter{
+				{
+					Name:   aws.String("tag-key"),
+					Values: []string{corev1beta1.NodePoolLabelKey},
+				},
+				{
+					Name:   aws.String("tag-key"),
+					Values: []string{v1beta1.LabelNodeClass},
+				},
+				{
+					Name:   aws.String("tag-key"),
+					Values: []string{fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)},
+				},
+				instanceStateFilter,
+			}, shards[i]...),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			reservations[i] = append(reservations[i], page.Reservations...)
+		}
+	})
+	if err = multierr.Combine(errs...); err != nil {
 		return nil, fmt.Errorf("describing ec2 instances, %w", err)
 	}
-	instances, err := instancesFromOutput(out)
-	return instances, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+	instances, err := instancesFromOutput(&ec2.DescribeInstancesOutput{Reservations: lo.Flatten(reservations)})
+	// Instances that are stopped for reuse don't belong to a NodeClaim
+	return lo.Reject(instances, func(i *Instance, _ int) bool {
+		_, ok := i.Tags[v1beta1.TagStoppedAt]
+		return ok
+	}), cloudprovider.IgnoreNodeClaimNotFoundError(err)
+}
+
+// listShards returns the filters that split listing instances into shards that are described concurrently. Instances
+// are described separately for each availability zone when there are multiple list workers. All zones are included,
+// regardless of whether they are opted in, so that every instance belongs to a shard.
+func (p *Provider) listShards(ctx context.Context) ([][]ec2types.Filter, error) {
+	if options.FromContext(ctx).GarbageCollectionListWorkers <= 1 {
+		return [][]ec2types.Filter{nil}, nil
+	}
+	output, err := p.ec2api.DescribeAvailabilityZones(ctx, &ec2.DescribeAvailabilityZonesInput{
+		AllAvailabilityZones: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing availability zones, %w", err)
+	}
+	if len(output.AvailabilityZones) == 0 {
+		return nil, fmt.Errorf("no availability zones found")
+	}
+	return lo.Map(output.AvailabilityZones, func(zone ec2types.AvailabilityZone, _ int) []ec2types.Filter {
+		return []ec2types.Filter{{Name: aws.String("availability-zone"), Values: []string{aws.ToString(zone.ZoneName)}}}
+	}), nil
 }
 
 func (p *Provider) Delete(ctx context.Context, id string) error {
+	if err := p.preTerminate(ctx, id); err != nil {
+		return err
+	}
 	if _, err := p.ec2Batcher.TerminateInstances(ctx, &ec2.TerminateInstancesInput{
-		InstanceIds: []*string{aws.String(id)},
+		InstanceIds: []string{id},
 	}); err != nil {
 		if awserrors.IsNotFound(err) {
 			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance already terminated"))
@@ -169,12 +275,38 @@ func (p *Provider) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// preTerminate calls the pre-termination hooks with the metadata of the instance, unless the instance is already
+// terminating
+func (p *Provider) preTerminate(ctx context.Context, id string) error {
+	if !terminationhook.Enabled(ctx) {
+		return nil
+	}
+	instance, err := p.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if instance.State == string(ec2types.InstanceStateNameShuttingDown) {
+		return nil
+	}
+	return p.terminationHookProvider.Call(ctx, terminationhook.Request{
+		ClusterName:  options.FromContext(ctx).ClusterName,
+		InstanceID:   instance.ID,
+		InstanceType: instance.Type,
+		Zone:         instance.Zone,
+		CapacityType: instance.CapacityType,
+		NodeClaim:    instance.Tags[v1beta1.TagNodeClaim],
+		NodePool:     instance.Tags[corev1beta1.NodePoolLabelKey],
+		NodeName:     instance.Tags[v1beta1.TagName],
+		Tags:         instance.Tags,
+	})
+}
+
 func (p *Provider) CreateTags(ctx context.Context, id string, tags map[string]string) error {
-	ec2Tags := lo.MapToSlice(tags, func(key, value string) *ec2.Tag {
-		return &ec2.Tag{Key: aws.String(key), Value: aws.String(value)}
+	ec2Tags := lo.MapToSlice(tags, func(key, value string) ec2types.Tag {
+		return ec2types.Tag{Key: aws.String(key), Value: aws.String(value)}
 	})
-	if _, err := p.ec2api.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
-		Resources: aws.StringSlice([]string{id}),
+	if _, err := p.ec2api.CreateTags(ctx, &ec2.CreateTagsInput{
+		Resources: []string{id},
 		Tags:      ec2Tags,
 	}); err != nil {
 		if awserrors.IsNotFound(err) {
@@ -185,8 +317,11 @@ func (p *Provider) CreateTags(ctx context.Context, id string, tags map[string]st
 	return nil
 }
 
-func (p *Provider) launchInstance(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (*ec2.CreateFleetInstance, error) {
+func (p *Provider) launchInstance(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, tags map[string]string) (_ *ec2.CreateFleetOutput, err error) {
 	capacityType := p.getCapacityType(nodeClaim, instanceTypes)
+	ctx, span := tracing.Start(ctx, "Instance.Launch", attribute.String("ec2nodeclass", nodeClass.Name),
+		attribute.String("capacity_type", capacityType), attribute.Int("instance_types", len(instanceTypes)))
+	defer func() { tracing.End(span, err) }()
 	zonalSubnets, err := p.subnetProvider.ZonalSubnetsForLaunch(ctx, nodeClass, instanceTypes, capacityType)
 	if err != nil {
 		return nil, fmt.Errorf("getting subnets, %w", err)
@@ -202,58 +337,81 @@ func (p *Provider) launchInstance(ctx context.Context, nodeClass *v1beta1.EC2Nod
 	}
 	// Create fleet
 	createFleetInput := &ec2.CreateFleetInput{
-		Type:                  aws.String(ec2.FleetTypeInstant),
+		Type:                  ec2types.FleetTypeInstant,
 		Context:               nodeClass.Spec.Context,
 		LaunchTemplateConfigs: launchTemplateConfigs,
-		TargetCapacitySpecification: &ec2.TargetCapacitySpecificationRequest{
-			DefaultTargetCapacityType: aws.String(capacityType),
-			TotalTargetCapacity:       aws.Int64(1),
+		TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{
+			DefaultTargetCapacityType: ec2types.DefaultTargetCapacityType(capacityType),
+			TotalTargetCapacity:       aws.Int32(1),
 		},
-		TagSpecifications: []*ec2.TagSpecification{
-			{ResourceType: aws.String(ec2.ResourceTypeInstance), Tags: utils.MergeTags(tags)},
-			{ResourceType: aws.String(ec2.ResourceTypeVolume), Tags: utils.MergeTags(tags)},
-			{ResourceType: aws.String(ec2.ResourceTypeFleet), Tags: utils.MergeTags(tags)},
+		TagSpecifications: []ec2types.TagSpecification{
+			{ResourceType: ec2types.ResourceTypeInstance, Tags: utils.MergeTags(tags)},
+			// the volumes that are created at launch are told apart from the volumes that are attached later
+			{ResourceType: ec2types.ResourceTypeVolume, Tags: utils.MergeTags(tags, map[string]string{v1beta1.TagLaunchedBy: options.FromContext(ctx).ClusterName})},
+			{ResourceType: ec2types.ResourceTypeFleet, Tags: utils.MergeTags(tags)},
 		},
 	}
 	if capacityType == corev1beta1.CapacityTypeSpot {
-		createFleetInput.SpotOptions = &ec2.SpotOptionsRequest{AllocationStrategy: aws.String(ec2.SpotAllocationStrategyPriceCapacityOptimized)}
+		createFleetInput.SpotOptions = &ec2types.SpotOptionsRequest{AllocationStrategy: ec2types.SpotAllocationStrategyPriceCapacityOptimized}
 	} else {
-		createFleetInput.OnDemandOptions = &ec2.OnDemandOptionsRequest{AllocationStrategy: aws.String(ec2.FleetOnDemandAllocationStrategyLowestPrice)}
+		createFleetInput.OnDemandOptions = &ec2types.OnDemandOptionsRequest{AllocationStrategy: ec2types.FleetOnDemandAllocationStrategyLowestPrice}
 	}
 
+	start := time.Now()
 	createFleetOutput, err := p.ec2Batcher.CreateFleet(ctx, createFleetInput)
 	p.subnetProvider.UpdateInflightIPs(createFleetInput, createFleetOutput, instanceTypes, lo.Values(zonalSubnets), capacityType)
 	if err != nil {
 		if awserrors.IsLaunchTemplateNotFound(err) {
 			for _, lt := range launchTemplateConfigs {
-				p.launchTemplateProvider.Invalidate(ctx, aws.StringValue(lt.LaunchTemplateSpecification.LaunchTemplateName), aws.StringValue(lt.LaunchTemplateSpecification.LaunchTemplateId))
+				p.launchTemplateProvider.Invalidate(ctx, aws.ToString(lt.LaunchTemplateSpecification.LaunchTemplateName), aws.ToString(lt.LaunchTemplateSpecification.LaunchTemplateId))
 			}
 			return nil, fmt.Errorf("creating fleet %w", err)
 		}
-		var reqFailure awserr.RequestFailure
+		var reqFailure *awshttp.ResponseError
 		if errors.As(err, &reqFailure) {
-			return nil, fmt.Errorf("creating fleet %w (%s)", err, reqFailure.RequestID())
+			return nil, fmt.Errorf("creating fleet %w (%s)", err, reqFailure.ServiceRequestID())
 		}
 		return nil, fmt.Errorf("creating fleet %w", err)
 	}
 	p.updateUnavailableOfferingsCache(ctx, createFleetOutput.Errors, capacityType)
+	// the errors of the fleet don't fail the request, so they're recorded on the span of the launch instead
+	span.SetAttributes(attribute.StringSlice("fleet.error_codes", lo.Uniq(lo.Map(createFleetOutput.Errors, func(e ec2types.CreateFleetError, _ int) string {
+		return aws.ToString(e.ErrorCode)
+	}))))
 	if len(createFleetOutput.Instances) == 0 || len(createFleetOutput.Instances[0].InstanceIds) == 0 {
 		return nil, combineFleetErrors(createFleetOutput.Errors)
 	}
-	return createFleetOutput.Instances[0], nil
+	createFleetDuration.With(prometheus.Labels{
+		instanceTypeLabel: string(createFleetOutput.Instances[0].InstanceType),
+		amiFamilyLabel:    lo.FromPtr(nodeClass.Spec.AMIFamily),
+		zoneLabel:         aws.ToString(createFleetOutput.Instances[0].LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
+	}).Observe(time.Since(start).Seconds())
+	span.SetAttributes(attribute.String("instance.id", createFleetOutput.Instances[0].InstanceIds[0]),
+		attribute.String("instance.type", string(createFleetOutput.Instances[0].InstanceType)))
+	return createFleetOutput, nil
+}
+
+// getTags returns the tags of a launch. Tags of the EC2NodeClass take precedence over the cost allocation and label
+// tags that are passed as extra tags.
+func getTags(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim, extraTags map[string]string) map[string]string {
+	return lo.Assign(extraTags, nodeClass.Spec.Tags, RequiredTags(ctx, nodeClaim), map[string]string{
+		v1beta1.LabelNodeClass: nodeClass.Name,
+	})
 }
 
-func getTags(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim) map[string]string {
-	staticTags := map[string]string{
+// RequiredTags returns the tags that identify the instance, volumes and network interfaces launched for the NodeClaim
+// as owned by the cluster. They take precedence over user-defined tags and are re-asserted if they are changed.
+func RequiredTags(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) map[string]string {
+	return lo.OmitByValues(map[string]string{
 		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		v1beta1.TagEKSClusterName:          options.FromContext(ctx).ClusterName,
 		corev1beta1.NodePoolLabelKey:       nodeClaim.Labels[corev1beta1.NodePoolLabelKey],
 		corev1beta1.ManagedByAnnotationKey: options.FromContext(ctx).ClusterName,
-		v1beta1.LabelNodeClass:             nodeClass.Name,
-	}
-	return lo.Assign(nodeClass.Spec.Tags, staticTags)
+		v1beta1.LabelNodeClass:             lo.FromPtr(nodeClaim.Spec.NodeClassRef).Name,
+	}, []string{""})
 }
 
-func (p *Provider) checkODFallback(nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest) error {
+func (p *Provider) checkODFallback(nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType, launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest) error {
 	// only evaluate for on-demand fallback if the capacity type for the request is OD and both OD and spot are allowed in requirements
 	if p.getCapacityType(nodeClaim, instanceTypes) != corev1beta1.CapacityTypeOnDemand || !scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(corev1beta1.CapacityTypeLabelKey).Has(corev1beta1.CapacityTypeSpot) {
 		return nil
@@ -263,8 +421,8 @@ func (p *Provider) checkODFallback(nodeClaim *corev1beta1.NodeClaim, instanceTyp
 	instanceTypeZones := map[string]struct{}{}
 	for _, ltc := range launchTemplateConfigs {
 		for _, override := range ltc.Overrides {
-			if override.InstanceType != nil {
-				instanceTypeZones[*override.InstanceType] = struct{}{}
+			if override.InstanceType != "" {
+				instanceTypeZones[string(override.InstanceType)] = struct{}{}
 			}
 		}
 
@@ -277,16 +435,16 @@ func (p *Provider) checkODFallback(nodeClaim *corev1beta1.NodeClaim, instanceTyp
 }
 
 func (p *Provider) getLaunchTemplateConfigs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim,
-	instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*ec2.Subnet, capacityType string, tags map[string]string) ([]*ec2.FleetLaunchTemplateConfigRequest, error) {
-	var launchTemplateConfigs []*ec2.FleetLaunchTemplateConfigRequest
+	instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*ec2types.Subnet, capacityType string, tags map[string]string) ([]ec2types.FleetLaunchTemplateConfigRequest, error) {
+	var launchTemplateConfigs []ec2types.FleetLaunchTemplateConfigRequest
 	launchTemplates, err := p.launchTemplateProvider.EnsureAll(ctx, nodeClass, nodeClaim, instanceTypes, capacityType, tags)
 	if err != nil {
 		return nil, fmt.Errorf("getting launch templates, %w", err)
 	}
 	for _, launchTemplate := range launchTemplates {
-		launchTemplateConfig := &ec2.FleetLaunchTemplateConfigRequest{
+		launchTemplateConfig := ec2types.FleetLaunchTemplateConfigRequest{
 			Overrides: p.getOverrides(launchTemplate.InstanceTypes, zonalSubnets, scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).Get(v1.LabelTopologyZone), capacityType, launchTemplate.ImageID),
-			LaunchTemplateSpecification: &ec2.FleetLaunchTemplateSpecificationRequest{
+			LaunchTemplateSpecification: &ec2types.FleetLaunchTemplateSpecificationRequest{
 				LaunchTemplateName: aws.String(launchTemplate.Name),
 				Version:            aws.String("$Latest"),
 			},
@@ -303,7 +461,7 @@ func (p *Provider) getLaunchTemplateConfigs(ctx context.Context, nodeClass *v1be
 
 // getOverrides creates and returns launch template overrides for the cross product of InstanceTypes and subnets (with subnets being constrained by
 // zones and the offerings in InstanceTypes)
-func (p *Provider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*ec2.Subnet, zones *scheduling.Requirement, capacityType string, image string) []*ec2.FleetLaunchTemplateOverridesRequest {
+func (p *Provider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zonalSubnets map[string]*ec2types.Subnet, zones *scheduling.Requirement, capacityType string, image string) []ec2types.FleetLaunchTemplateOverridesRequest {
 	// Unwrap all the offerings to a flat slice that includes a pointer
 	// to the parent instance type name
 	type offeringWithParentName struct {
@@ -321,7 +479,7 @@ func (p *Provider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zon
 		unwrappedOfferings = append(unwrappedOfferings, ofs...)
 	}
 
-	var overrides []*ec2.FleetLaunchTemplateOverridesRequest
+	var overrides []ec2types.FleetLaunchTemplateOverridesRequest
 	for _, offering := range unwrappedOfferings {
 		if capacityType != offering.CapacityType {
 			continue
@@ -333,8 +491,8 @@ func (p *Provider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zon
 		if !ok {
 			continue
 		}
-		overrides = append(overrides, &ec2.FleetLaunchTemplateOverridesRequest{
-			InstanceType: aws.String(offering.parentInstanceTypeName),
+		overrides = append(overrides, ec2types.FleetLaunchTemplateOverridesRequest{
+			InstanceType: ec2types.InstanceType(offering.parentInstanceTypeName),
 			SubnetId:     subnet.SubnetId,
 			ImageId:      aws.String(image),
 			// This is technically redundant, but is useful if we have to parse insufficient capacity errors from
@@ -345,14 +503,38 @@ func (p *Provider) getOverrides(instanceTypes []*cloudprovider.InstanceType, zon
 	return overrides
 }
 
-func (p *Provider) updateUnavailableOfferingsCache(ctx context.Context, errors []*ec2.CreateFleetError, capacityType string) {
+func (p *Provider) updateUnavailableOfferingsCache(ctx context.Context, errors []ec2types.CreateFleetError, capacityType string) {
 	for _, err := range errors {
 		if awserrors.IsUnfulfillableCapacity(err) {
 			p.unavailableOfferings.MarkUnavailableForFleetErr(ctx, err, capacityType)
+			insufficientCapacityErrors.With(prometheus.Labels{
+				instanceTypeLabel: string(err.LaunchTemplateAndOverrides.Overrides.InstanceType),
+				zoneLabel:         aws.ToString(err.LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
+				capacityTypeLabel: capacityType,
+				errorCodeLabel:    aws.ToString(err.ErrorCode),
+			}).Inc()
+		}
+		if awserrors.IsServiceQuotaExceeded(err) && p.quotaProvider != nil {
+			p.quotaProvider.MarkExceeded(ctx, string(err.LaunchTemplateAndOverrides.Overrides.InstanceType), capacityType)
 		}
 	}
 }
 
+// Preview returns the capacity type and instance types that Create would request from CreateFleet for the NodeClaim,
+// without launching anything
+func (p *Provider) Preview(nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) (string, []*cloudprovider.InstanceType) {
+	instanceTypes = p.launchableInstanceTypes(nodeClaim, instanceTypes)
+	return p.getCapacityType(nodeClaim, instanceTypes), instanceTypes
+}
+
+// launchableInstanceTypes filters the instance types of the NodeClaim, unless the NodeClaim's requirements have minValues
+func (p *Provider) launchableInstanceTypes(nodeClaim *corev1beta1.NodeClaim, instanceTypes []*cloudprovider.InstanceType) []*cloudprovider.InstanceType {
+	if scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...).HasMinValues() {
+		return instanceTypes
+	}
+	return p.filterInstanceTypes(nodeClaim, instanceTypes)
+}
+
 // getCapacityType selects spot if both constraints are flexible and there is an
 // available offering. The AWS Cloud Provider defaults to [ on-demand ], so spot
 // must be explicitly included in capacity type requirements.
@@ -466,7 +648,7 @@ func instancesFromOutput(out *ec2.DescribeInstancesOutput) ([]*Instance, error)
 	if len(out.Reservations) == 0 {
 		return nil, cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("instance not found"))
 	}
-	instances := lo.Flatten(lo.Map(out.Reservations, func(r *ec2.Reservation, _ int) []*ec2.Instance {
+	instances := lo.Flatten(lo.Map(out.Reservations, func(r ec2types.Reservation, _ int) []ec2types.Instance {
 		return r.Instances
 	}))
 	if len(instances) == 0 {
@@ -474,21 +656,35 @@ func instancesFromOutput(out *ec2.DescribeInstancesOutput) ([]*Instance, error)
 	}
 	// Get a consistent ordering for instances
 	sort.Slice(instances, func(i, j int) bool {
-		return aws.StringValue(instances[i].InstanceId) < aws.StringValue(instances[j].InstanceId)
+		return aws.ToString(instances[i].InstanceId) < aws.ToString(instances[j].InstanceId)
 	})
-	return lo.Map(instances, func(i *ec2.Instance, _ int) *Instance { return NewInstance(i) }), nil
+	return lo.Map(instances, func(i ec2types.Instance, _ int) *Instance { return NewInstance(&i) }), nil
 }
 
-func combineFleetErrors(errors []*ec2.CreateFleetError) (errs error) {
-	unique := sets.NewString()
-	for _, err := range errors {
-		unique.Insert(fmt.Sprintf("%s: %s", aws.StringValue(err.ErrorCode), aws.StringValue(err.ErrorMessage)))
+// combineFleetErrors combines the fleet errors of a launch that CreateFleet didn't fulfill. Errors with the same code and
+// message are combined, and list the instance types and zones that they were returned for.
+func combineFleetErrors(errors []ec2types.CreateFleetError) error {
+	fleetErrors := lo.Map(errors, func(err ec2types.CreateFleetError, _ int) FleetError { return newFleetError(err) })
+	var errs error
+	for _, group := range lo.PartitionBy(fleetErrors, func(e FleetError) string { return e.Code + ": " + e.Message }) {
+		offerings := lo.Uniq(lo.FilterMap(group, func(e FleetError, _ int) (string, bool) { return e.Offering(), e.InstanceType != "" }))
+		if len(offerings) == 0 {
+			errs = multierr.Append(errs, fmt.Errorf("%s: %s", group[0].Code, group[0].Message))
+			continue
+		}
+		errs = multierr.Append(errs, fmt.Errorf("%s for %s: %s", group[0].Code, strings.Join(offerings, ", "), group[0].Message))
 	}
-	for errorCode := range unique {
-		errs = multierr.Append(errs, fmt.Errorf(errorCode))
+	return &LaunchFailedError{error: wrapFleetErrors(errors, errs), FleetErrors: fleetErrors}
+}
+
+func wrapFleetErrors(errors []ec2types.CreateFleetError, errs error) error {
+	// If all the Fleet errors are service quota errors then the ICE error is wrapped in a quota error, so that the
+	// NodeClaim fails with a distinct reason
+	if len(errors) > 0 && lo.EveryBy(errors, awserrors.IsServiceQuotaExceeded) {
+		return quota.NewExceededError(cloudprovider.NewInsufficientCapacityError(fmt.Errorf("with fleet error(s), %w", errs)))
 	}
 	// If all the Fleet errors are ICE errors then we should wrap the combined error in the generic ICE error
-	iceErrorCount := lo.CountBy(errors, func(err *ec2.CreateFleetError) bool { return awserrors.IsUnfulfillableCapacity(err) })
+	iceErrorCount := lo.CountBy(errors, func(err ec2types.CreateFleetError) bool { return awserrors.IsUnfulfillableCapacity(err) })
 	if iceErrorCount == len(errors) {
 		return cloudprovider.NewInsufficientCapacityError(fmt.Errorf("with fleet error(s), %w", errs))
 	}
@@ -0,0 +1,86 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	launchesSubsystem = "launches"
+	nodeClassLabel    = "nodeclass"
+	instanceTypeLabel = "instance_type"
+	amiFamilyLabel    = "ami_family"
+	zoneLabel         = "zone"
+	capacityTypeLabel = "capacity_type"
+	errorCodeLabel    = "error_code"
+)
+
+var (
+	launchesInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchesSubsystem,
+			Name:      "in_flight",
+			Help:      "Number of instance launches that are in flight. Labeled by nodeclass.",
+		},
+		[]string{nodeClassLabel},
+	)
+	launchesQueued = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchesSubsystem,
+			Name:      "queued",
+			Help:      "Number of instance launches that are waiting for in flight launches to complete because of the concurrent launch limits. Labeled by nodeclass.",
+		},
+		[]string{nodeClassLabel},
+	)
+	launchQueueDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchesSubsystem,
+			Name:      "queue_duration_seconds",
+			Help:      "Duration that instance launches waited for in flight launches to complete because of the concurrent launch limits. Labeled by nodeclass.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{nodeClassLabel},
+	)
+	createFleetDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchesSubsystem,
+			Name:      "create_fleet_duration_seconds",
+			Help:      "Duration of the CreateFleet requests that launched instances, including the time that requests were batched for. Labeled by instance type, AMI family and zone of the launched instance.",
+			Buckets:   metrics.DurationBuckets(),
+		},
+		[]string{instanceTypeLabel, amiFamilyLabel, zoneLabel},
+	)
+	insufficientCapacityErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: launchesSubsystem,
+			Name:      "insufficient_capacity_errors_total",
+			Help:      "Number of insufficient capacity errors that CreateFleet returned for offerings, which are unavailable until they expire from the unavailable offerings cache. Labeled by instance type, zone, capacity type and error code.",
+		},
+		[]string{instanceTypeLabel, zoneLabel, capacityTypeLabel, errorCodeLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(launchesInFlight, launchesQueued, launchQueueDuration, createFleetDuration, insufficientCapacityErrors)
+}
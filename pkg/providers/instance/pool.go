@@ -0,0 +1,179 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// Stop stops the instance instead of terminating it, so that it can be started again for a later NodeClaim. The instance
+// is tagged with when it was stopped and with the hash of its EC2NodeClass, which determine whether it can be reused.
+func (p *Provider) Stop(ctx context.Context, id string, nodeClassHash string) error {
+	if err := p.CreateTags(ctx, id, map[string]string{
+		v1beta1.TagStoppedAt:               time.Now().UTC().Format(time.RFC3339),
+		v1beta1.AnnotationEC2NodeClassHash: nodeClassHash,
+	}); err != nil {
+		return err
+	}
+	if _, err := p.ec2api.StopInstances(ctx, &ec2.StopInstancesInput{
+		InstanceIds: []string{id},
+	}); err != nil {
+		if awserrors.IsNotFound(err) {
+			return cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("stopping instance, %w", err))
+		}
+		return fmt.Errorf("stopping instance, %w", err)
+	}
+	return nil
+}
+
+// ListStopped returns the instances that were stopped for reuse
+func (p *Provider) ListStopped(ctx context.Context) ([]*Instance, error) {
+	return p.describeStopped(ctx, []string{string(ec2types.InstanceStateNameStopping), string(ec2types.InstanceStateNameStopped)})
+}
+
+func (p *Provider) describeStopped(ctx context.Context, states []string, filters ...ec2types.Filter) ([]*Instance, error) {
+	var out = &ec2.DescribeInstancesOutput{}
+	paginator := ec2.NewDescribeInstancesPaginator(p.ec2api, &ec2.DescribeInstancesInput{
+		MaxResults: aws.Int32(maxDescribeInstancesPageSize),
+		Filters: append([]ec2types.Filter{
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{v1beta1.TagStoppedAt},
+			},
+			{
+				Name:   aws.String("tag-key"),
+				Values: []string{fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)},
+			},
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: states,
+			},
+		}, filters...),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing stopped ec2 instances, %w", err)
+		}
+		out.Reservations = append(out.Reservations, page.Reservations...)
+	}
+	instances, err := instancesFromOutput(out)
+	return instances, cloudprovider.IgnoreNodeClaimNotFoundError(err)
+}
+
+// startStoppedInstance starts the cheapest stopped instance that is compatible with the NodeClaim, if there is one.
+// Failing to start a stopped instance isn't an error, since a new instance can be launched instead.
+func (p *Provider) startStoppedInstance(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, nodeClaim *corev1beta1.NodeClaim,
+	instanceTypes []*cloudprovider.InstanceType, tags map[string]string, efaEnabled bool) (*Instance, bool) {
+
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+
+	stopped, err := p.describeStopped(ctx, []string{string(ec2types.InstanceStateNameStopped)},
+		ec2types.Filter{Name: aws.String(fmt.Sprintf("tag:%s", corev1beta1.NodePoolLabelKey)), Values: []string{nodeClaim.Labels[corev1beta1.NodePoolLabelKey]}},
+		ec2types.Filter{Name: aws.String(fmt.Sprintf("tag:%s", v1beta1.LabelNodeClass)), Values: []string{nodeClass.Name}},
+		ec2types.Filter{Name: aws.String(fmt.Sprintf("tag:%s", v1beta1.AnnotationEC2NodeClassHash)), Values: []string{nodeClass.Hash()}},
+	)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("listing stopped instances, %v", err)
+		return nil, false
+	}
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	prices := map[string]float64{}
+	candidates := lo.Filter(stopped, func(i *Instance, _ int) bool {
+		if _, ok := p.startedInstances.Get(i.ID); ok || i.EFAEnabled != efaEnabled || !CompatibleWithNodeClass(i, nodeClass) {
+			return false
+		}
+		instanceType, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == i.Type })
+		if !ok {
+			return false
+		}
+		offering, ok := instanceType.Offerings.Available().Compatible(reqs).Get(corev1beta1.CapacityTypeOnDemand, i.Zone)
+		prices[i.ID] = offering.Price
+		return ok
+	})
+	sort.Slice(candidates, func(a, b int) bool { return prices[candidates[a].ID] < prices[candidates[b].ID] })
+	for _, candidate := range candidates {
+		if instance, err := p.start(ctx, candidate, tags); err != nil {
+			logging.FromContext(ctx).With("id", candidate.ID).Errorf("starting stopped instance, %v", err)
+		} else {
+			return instance, true
+		}
+	}
+	return nil, false
+}
+
+// start removes the instance from the stopped instances, replaces its tags with the NodeClaim's tags and starts it.
+// The instance is returned to the stopped instances if it can't be started.
+func (p *Provider) start(ctx context.Context, instance *Instance, tags map[string]string) (*Instance, error) {
+	p.startedInstances.SetDefault(instance.ID, struct{}{})
+	stoppedTags := lo.PickByKeys(instance.Tags, []string{v1beta1.TagStoppedAt, v1beta1.AnnotationEC2NodeClassHash})
+	if _, err := p.ec2api.DeleteTags(ctx, &ec2.DeleteTagsInput{
+		Resources: []string{instance.ID},
+		Tags: lo.Map(lo.Keys(stoppedTags), func(k string, _ int) ec2types.Tag {
+			return ec2types.Tag{Key: aws.String(k)}
+		}),
+	}); err != nil {
+		return nil, fmt.Errorf("untagging stopped instance, %w", err)
+	}
+	if err := p.CreateTags(ctx, instance.ID, tags); err != nil {
+		return nil, restoreError(err, p.CreateTags(ctx, instance.ID, stoppedTags))
+	}
+	if _, err := p.ec2api.StartInstances(ctx, &ec2.StartInstancesInput{
+		InstanceIds: []string{instance.ID},
+	}); err != nil {
+		return nil, restoreError(fmt.Errorf("starting instance, %w", err), p.CreateTags(ctx, instance.ID, stoppedTags))
+	}
+	logging.FromContext(ctx).With("id", instance.ID, "instance-type", instance.Type, "zone", instance.Zone).Infof("started stopped instance")
+	instance.State = string(ec2types.InstanceStateNamePending)
+	instance.LaunchTime = time.Now()
+	instance.Tags = lo.Assign(lo.OmitByKeys(instance.Tags, lo.Keys(stoppedTags)), tags)
+	return instance, nil
+}
+
+// restoreError adds the error from returning an instance to the stopped instances to the error that caused it.
+// If the instance can't be returned, it's no longer tagged as stopped and is garbage collected.
+func restoreError(err error, restoreErr error) error {
+	if restoreErr != nil {
+		return fmt.Errorf("%w, returning instance to stopped instances, %s", err, restoreErr)
+	}
+	return err
+}
+
+// CompatibleWithNodeClass returns true if the instance was launched with an AMI, subnet and security groups that are
+// still selected by the EC2NodeClass
+func CompatibleWithNodeClass(instance *Instance, nodeClass *v1beta1.EC2NodeClass) bool {
+	return lo.ContainsBy(nodeClass.Status.AMIs, func(ami v1beta1.AMI) bool { return ami.ID == instance.ImageID }) &&
+		lo.ContainsBy(nodeClass.Status.Subnets, func(subnet v1beta1.Subnet) bool { return subnet.ID == instance.SubnetID }) &&
+		sets.New(instance.SecurityGroupIDs...).Equal(sets.New(lo.Map(nodeClass.Status.SecurityGroups, func(sg v1beta1.SecurityGroup, _ int) string { return sg.ID })...))
+}
@@ -16,13 +16,18 @@ package instance_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
@@ -39,6 +44,7 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -59,12 +65,12 @@ func TestAWS(t *testing.T) {
 }
 
 var _ = BeforeSuite(func() {
-	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.PricingProvider)
 })
 
 var _ = AfterSuite(func() {
@@ -122,10 +128,446 @@ var _ = Describe("InstanceProvider", func() {
 		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
 
 		// Since all the capacity pools are ICEd. This should return back an ICE error
-		instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, instanceTypes)
+		instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
 		Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
 		Expect(instance).To(BeNil())
 	})
+	It("should return a quota exceeded error when all attempted instance types exceed the vCPU service quota", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		awsEnv.EC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{
+			Errors: []ec2types.CreateFleetError{{
+				ErrorCode:    aws.String("VcpuLimitExceeded"),
+				ErrorMessage: aws.String("You have requested more vCPU capacity than your current vCPU limit allows"),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "m5.xlarge", AvailabilityZone: aws.String("test-zone-1a")},
+				},
+			}},
+		})
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+
+		instance, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+		Expect(quota.IsExceededError(err)).To(BeTrue())
+		Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+		Expect(instance).To(BeNil())
+	})
+	It("should report the fleet error of each instance type and zone that couldn't be launched", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		awsEnv.EC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{
+			Errors: []ec2types.CreateFleetError{
+				{
+					ErrorCode:    aws.String("InsufficientInstanceCapacity"),
+					ErrorMessage: aws.String("We currently do not have sufficient capacity"),
+					Lifecycle:    ec2types.InstanceLifecycleOnDemand,
+					LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+						Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "m5.xlarge", AvailabilityZone: aws.String("test-zone-1a")},
+					},
+				},
+				{
+					ErrorCode:    aws.String("Unsupported"),
+					ErrorMessage: aws.String("The requested configuration is currently not supported"),
+					Lifecycle:    ec2types.InstanceLifecycleOnDemand,
+					LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+						Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "m5.xlarge", AvailabilityZone: aws.String("test-zone-1b")},
+					},
+				},
+			},
+		})
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+		Expect(err).To(HaveOccurred())
+		Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeFalse())
+		Expect(err.Error()).To(ContainSubstring("InsufficientInstanceCapacity for m5.xlarge in test-zone-1a: We currently do not have sufficient capacity"))
+		Expect(err.Error()).To(ContainSubstring("Unsupported for m5.xlarge in test-zone-1b: The requested configuration is currently not supported"))
+		Expect(instance.FleetErrors(err)).To(ConsistOf(
+			instance.FleetError{InstanceType: "m5.xlarge", Zone: "test-zone-1a", CapacityType: corev1beta1.CapacityTypeOnDemand, Code: "InsufficientInstanceCapacity", Message: "We currently do not have sufficient capacity"},
+			instance.FleetError{InstanceType: "m5.xlarge", Zone: "test-zone-1b", CapacityType: corev1beta1.CapacityTypeOnDemand, Code: "Unsupported", Message: "The requested configuration is currently not supported"},
+		))
+	})
+	It("should combine fleet errors with the same code and message", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		awsEnv.EC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{
+			Errors: lo.Map([]string{"test-zone-1a", "test-zone-1b"}, func(zone string, _ int) ec2types.CreateFleetError {
+				return ec2types.CreateFleetError{
+					ErrorCode:    aws.String("InsufficientInstanceCapacity"),
+					ErrorMessage: aws.String("We currently do not have sufficient capacity"),
+					LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+						Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "m5.xlarge", AvailabilityZone: aws.String(zone)},
+					},
+				}
+			}),
+		})
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+		Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("InsufficientInstanceCapacity for m5.xlarge in test-zone-1a, m5.xlarge in test-zone-1b: We currently do not have sufficient capacity"))
+		Expect(instance.FleetErrors(err)).To(HaveLen(2))
+	})
+	It("should count the insufficient capacity errors of each offering", func() {
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		awsEnv.EC2API.CreateFleetBehavior.Output.Set(&ec2.CreateFleetOutput{
+			Errors: []ec2types.CreateFleetError{{
+				ErrorCode:    aws.String("InsufficientInstanceCapacity"),
+				ErrorMessage: aws.String("We currently do not have sufficient capacity"),
+				LaunchTemplateAndOverrides: &ec2types.LaunchTemplateAndOverridesResponse{
+					Overrides: &ec2types.FleetLaunchTemplateOverrides{InstanceType: "m5.xlarge", AvailabilityZone: aws.String("test-zone-1c")},
+				},
+			}},
+		})
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+		Expect(corecloudprovider.IsInsufficientCapacityError(err)).To(BeTrue())
+		metric, ok := FindMetricWithLabelValues("karpenter_launches_insufficient_capacity_errors_total", map[string]string{
+			"instance_type": "m5.xlarge",
+			"zone":          "test-zone-1c",
+			"capacity_type": corev1beta1.CapacityTypeOnDemand,
+			"error_code":    "InsufficientInstanceCapacity",
+		})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetCounter().GetValue()).To(BeNumerically("==", 1))
+		metric, ok = FindMetricWithLabelValues("karpenter_cache_unavailable_offerings", map[string]string{})
+		Expect(ok).To(BeTrue())
+		Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 1))
+	})
+	Context("Cost Allocation Tags", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				CostAllocationTags: lo.ToPtr(`{"team":"{{ .NodePool.Labels.team }}","environment":"{{ .NodeClass.Labels.environment }}","nodepool":"{{ .NodePool.Name }}"}`),
+			}))
+			nodePool.Labels = map[string]string{"team": "ml"}
+			nodeClass.Labels = map[string]string{"environment": "production"}
+		})
+		It("should apply cost allocation tags rendered from the NodePool and NodeClass", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.TagSpecifications).ToNot(BeEmpty())
+			for _, tagSpec := range createFleetInput.TagSpecifications {
+				tags := lo.SliceToMap(tagSpec.Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+				Expect(tags).To(HaveKeyWithValue("team", "ml"))
+				Expect(tags).To(HaveKeyWithValue("environment", "production"))
+				Expect(tags).To(HaveKeyWithValue("nodepool", nodePool.Name))
+			}
+			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(input *ec2.CreateLaunchTemplateInput) {
+				tagSpec, ok := lo.Find(input.LaunchTemplateData.TagSpecifications, func(t ec2types.LaunchTemplateTagSpecificationRequest) bool {
+					return t.ResourceType == ec2types.ResourceTypeNetworkInterface
+				})
+				Expect(ok).To(BeTrue())
+				tags := lo.SliceToMap(tagSpec.Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+				Expect(tags).To(HaveKeyWithValue("team", "ml"))
+			})
+		})
+		It("should not apply cost allocation tags that render to an empty value", func() {
+			nodePool.Labels = nil
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			tags := lo.SliceToMap(createFleetInput.TagSpecifications[0].Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+			Expect(tags).ToNot(HaveKey("team"))
+			Expect(tags).To(HaveKeyWithValue("environment", "production"))
+		})
+		It("should prefer NodeClass tags over cost allocation tags", func() {
+			nodeClass.Spec.Tags = map[string]string{"team": "platform"}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			tags := lo.SliceToMap(createFleetInput.TagSpecifications[0].Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+			Expect(tags).To(HaveKeyWithValue("team", "platform"))
+		})
+	})
+	It("should fail to launch when the tags of the EC2NodeClass violate the tag policy", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			TagPolicy: lo.ToPtr(`{"requiredPrefixes":["acme:"]}`),
+		}))
+		nodeClass.Spec.Tags = map[string]string{"team": "compute"}
+		ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+		Expect(err).To(HaveOccurred())
+		Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+	})
+	Context("Label Tags", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				LabelTags: lo.ToPtr(`{"karpenter.sh/nodepool":"NodePool","team":"Team","billing-code":"BillingCode"}`),
+			}))
+			nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{"team": "ml"})
+		})
+		It("should apply label tags from the labels of the NodeClaim", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			Expect(createFleetInput.TagSpecifications).ToNot(BeEmpty())
+			for _, tagSpec := range createFleetInput.TagSpecifications {
+				tags := lo.SliceToMap(tagSpec.Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+				Expect(tags).To(HaveKeyWithValue("NodePool", nodePool.Name))
+				Expect(tags).To(HaveKeyWithValue("Team", "ml"))
+				Expect(tags).ToNot(HaveKey("BillingCode"))
+			}
+		})
+		It("should prefer NodeClass tags over label tags", func() {
+			nodeClass.Spec.Tags = map[string]string{"Team": "platform"}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			tags := lo.SliceToMap(createFleetInput.TagSpecifications[0].Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+			Expect(tags).To(HaveKeyWithValue("Team", "platform"))
+		})
+	})
+	Context("Stopped Instances", func() {
+		var instanceID string
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				StoppedInstancePoolSize: lo.ToPtr(5),
+			}))
+			nodeClass.Status = v1beta1.EC2NodeClassStatus{
+				AMIs:           []v1beta1.AMI{{ID: "ami-test1"}},
+				Subnets:        []v1beta1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a"}},
+				SecurityGroups: []v1beta1.SecurityGroup{{ID: "sg-test1"}},
+			}
+			instanceID = fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, stoppedInstance(instanceID, nodePool.Name, nodeClass.Name, nodeClass.Hash()))
+		})
+		It("should start a compatible stopped instance instead of launching an instance", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			i, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(i.ID).To(Equal(instanceID))
+			Expect(i.State).To(Equal(ec2types.InstanceStateNamePending))
+			Expect(i.Tags).ToNot(HaveKey(v1beta1.TagStoppedAt))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+			stored, ok := awsEnv.EC2API.Instances.Load(instanceID)
+			Expect(ok).To(BeTrue())
+			Expect(lo.ContainsBy(stored.(*ec2types.Instance).Tags, func(t ec2types.Tag) bool { return aws.ToString(t.Key) == v1beta1.TagStoppedAt })).To(BeFalse())
+		})
+		It("should launch an instance when the stopped instance was stopped with a different EC2NodeClass", func() {
+			awsEnv.EC2API.Instances.Store(instanceID, stoppedInstance(instanceID, nodePool.Name, nodeClass.Name, "different-hash"))
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			i, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(i.ID).ToNot(Equal(instanceID))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+		})
+		It("should launch an instance when the NodeClaim requires spot capacity", func() {
+			nodeClaim.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeSpot}}},
+			}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			i, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(i.ID).ToNot(Equal(instanceID))
+			Expect(awsEnv.EC2API.StartInstancesBehavior.Calls()).To(Equal(0))
+		})
+		It("should return the instance to the stopped instances when it can't be started", func() {
+			awsEnv.EC2API.StartInstancesBehavior.Error.Set(&smithy.GenericAPIError{Code: "InsufficientInstanceCapacity", Message: ""})
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			i, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(i.ID).ToNot(Equal(instanceID))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(1))
+			stopped, err := awsEnv.InstanceProvider.ListStopped(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stopped).To(HaveLen(1))
+			Expect(stopped[0].ID).To(Equal(instanceID))
+		})
+		It("should not return stopped instances from List", func() {
+			instances, err := awsEnv.InstanceProvider.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(instances).To(BeEmpty())
+		})
+		It("should tag instances when stopping them", func() {
+			awsEnv.EC2API.Instances.Store(instanceID, &ec2types.Instance{
+				InstanceId: aws.String(instanceID),
+				State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				Placement:  &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+			})
+			Expect(awsEnv.InstanceProvider.Stop(ctx, instanceID, nodeClass.Hash())).To(Succeed())
+
+			Expect(awsEnv.EC2API.StopInstancesBehavior.CalledWithInput.Pop().InstanceIds).To(ConsistOf(instanceID))
+			stored, _ := awsEnv.EC2API.Instances.Load(instanceID)
+			tags := lo.SliceToMap(stored.(*ec2types.Instance).Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+			Expect(tags).To(HaveKey(v1beta1.TagStoppedAt))
+			Expect(tags).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+		})
+	})
+	Context("Adoption", func() {
+		var instanceID string
+		BeforeEach(func() {
+			nodeClass.Status = v1beta1.EC2NodeClassStatus{
+				AMIs:           []v1beta1.AMI{{ID: "ami-test1"}},
+				Subnets:        []v1beta1.Subnet{{ID: "subnet-test1", Zone: "test-zone-1a"}},
+				SecurityGroups: []v1beta1.SecurityGroup{{ID: "sg-test1"}},
+			}
+			instanceID = fake.InstanceID()
+			awsEnv.EC2API.Instances.Store(instanceID, unmanagedInstance(instanceID))
+			nodeClaim.Annotations = map[string]string{v1beta1.AnnotationAdoptInstanceID: instanceID}
+		})
+		It("should tag the instance instead of launching an instance", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			i, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(i.ID).To(Equal(instanceID))
+			Expect(i.Tags).To(HaveKeyWithValue(corev1beta1.NodePoolLabelKey, nodePool.Name))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+			stored, _ := awsEnv.EC2API.Instances.Load(instanceID)
+			tags := lo.SliceToMap(stored.(*ec2types.Instance).Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+			Expect(tags).To(HaveKeyWithValue(corev1beta1.NodePoolLabelKey, nodePool.Name))
+			Expect(tags).To(HaveKeyWithValue(v1beta1.LabelNodeClass, nodeClass.Name))
+			Expect(tags).To(HaveKeyWithValue(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName), "owned"))
+		})
+		It("should fail to adopt an instance that wasn't launched with the EC2NodeClass's AMIs", func() {
+			nodeClass.Status.AMIs = []v1beta1.AMI{{ID: "ami-test2"}}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).To(HaveOccurred())
+
+			Expect(awsEnv.EC2API.CreateTagsBehavior.Calls()).To(Equal(0))
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+		It("should fail to adopt an instance that is managed by another NodePool", func() {
+			instance := unmanagedInstance(instanceID)
+			instance.Tags = append(instance.Tags, ec2types.Tag{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String("other-nodepool")})
+			awsEnv.EC2API.Instances.Store(instanceID, instance)
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).To(HaveOccurred())
+
+			Expect(awsEnv.EC2API.CreateTagsBehavior.Calls()).To(Equal(0))
+		})
+		It("should fail to adopt an instance whose instance type isn't allowed by the NodeClaim", func() {
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			instanceTypes = lo.Filter(instanceTypes, func(i *corecloudprovider.InstanceType, _ int) bool { return i.Name == "m5.xlarge" })
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).To(HaveOccurred())
+
+			Expect(awsEnv.EC2API.CreateTagsBehavior.Calls()).To(Equal(0))
+		})
+	})
+	Context("Concurrent Launch Limits", func() {
+		It("should launch every NodeClaim when launches are limited", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxConcurrentLaunches: lo.ToPtr(2)}))
+			nodeClass.Annotations = map[string]string{v1beta1.AnnotationMaxConcurrentLaunches: "1"}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			queued := launchQueueSamples(nodeClass.Name)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					_, err := awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim.DeepCopy(), nodePool, instanceTypes)
+					Expect(err).ToNot(HaveOccurred())
+				}()
+			}
+			wg.Wait()
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(5))
+			Expect(launchQueueSamples(nodeClass.Name) - queued).To(BeNumerically("==", 5))
+			metric, ok := FindMetricWithLabelValues("karpenter_launches_in_flight", map[string]string{"nodeclass": nodeClass.Name})
+			Expect(ok).To(BeTrue())
+			Expect(metric.GetGauge().GetValue()).To(BeNumerically("==", 0))
+		})
+		It("should fail to launch when the EC2NodeClass has an invalid limit", func() {
+			nodeClass.Annotations = map[string]string{v1beta1.AnnotationMaxConcurrentLaunches: "many"}
+			ExpectApplied(ctx, env.Client, nodeClaim, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = awsEnv.InstanceProvider.Create(ctx, nodeClass, nodeClaim, nodePool, instanceTypes)
+			Expect(err).To(HaveOccurred())
+
+			Expect(awsEnv.EC2API.CreateFleetBehavior.Calls()).To(Equal(0))
+		})
+	})
+	Context("Pre-termination Hooks", func() {
+		var instanceID string
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+				PreTerminationLambda: lo.ToPtr("karpenter-pre-termination"),
+			}))
+			instanceID = fake.InstanceID()
+			i := unmanagedInstance(instanceID)
+			i.Tags = []ec2types.Tag{
+				{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String("default")},
+				{Key: aws.String(v1beta1.TagNodeClaim), Value: aws.String("default-abcde")},
+			}
+			awsEnv.EC2API.Instances.Store(instanceID, i)
+		})
+		It("should call the pre-termination hooks before terminating the instance", func() {
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).To(Succeed())
+
+			Expect(awsEnv.LambdaAPI.InvokeBehavior.Calls()).To(Equal(1))
+			payload := map[string]any{}
+			Expect(json.Unmarshal(awsEnv.LambdaAPI.InvokeBehavior.CalledWithInput.Pop().Payload, &payload)).To(Succeed())
+			Expect(payload).To(HaveKeyWithValue("instanceID", instanceID))
+			Expect(payload).To(HaveKeyWithValue("nodePool", "default"))
+			Expect(payload).To(HaveKeyWithValue("nodeClaim", "default-abcde"))
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(1))
+		})
+		It("should not terminate the instance when the pre-termination hooks fail", func() {
+			awsEnv.LambdaAPI.InvokeBehavior.Error.Set(fmt.Errorf("function unavailable"))
+			Expect(awsEnv.InstanceProvider.Delete(ctx, instanceID)).ToNot(Succeed())
+
+			Expect(awsEnv.EC2API.TerminateInstancesBehavior.Calls()).To(Equal(0))
+		})
+	})
 	It("should return all NodePool-owned instances from List", func() {
 		ids := sets.New[string]()
 		// Provision instances that have the karpenter.sh/nodepool key
@@ -133,11 +575,11 @@ var _ = Describe("InstanceProvider", func() {
 			instanceID := fake.InstanceID()
 			awsEnv.EC2API.Instances.Store(
 				instanceID,
-				&ec2.Instance{
-					State: &ec2.InstanceState{
-						Name: aws.String(ec2.InstanceStateNameRunning),
+				&ec2types.Instance{
+					State: &ec2types.InstanceState{
+						Name: ec2types.InstanceStateNameRunning,
 					},
-					Tags: []*ec2.Tag{
+					Tags: []ec2types.Tag{
 						{
 							Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)),
 							Value: aws.String("owned"),
@@ -156,13 +598,13 @@ var _ = Describe("InstanceProvider", func() {
 						},
 					},
 					PrivateDnsName: aws.String(fake.PrivateDNSName()),
-					Placement: &ec2.Placement{
+					Placement: &ec2types.Placement{
 						AvailabilityZone: aws.String(fake.DefaultRegion),
 					},
 					// Launch time was 1m ago
 					LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
 					InstanceId:   aws.String(instanceID),
-					InstanceType: aws.String("m5.large"),
+					InstanceType: "m5.large",
 				},
 			)
 			ids.Insert(instanceID)
@@ -172,18 +614,18 @@ var _ = Describe("InstanceProvider", func() {
 			instanceID := fake.InstanceID()
 			awsEnv.EC2API.Instances.Store(
 				instanceID,
-				&ec2.Instance{
-					State: &ec2.InstanceState{
-						Name: aws.String(ec2.InstanceStateNameRunning),
+				&ec2types.Instance{
+					State: &ec2types.InstanceState{
+						Name: ec2types.InstanceStateNameRunning,
 					},
 					PrivateDnsName: aws.String(fake.PrivateDNSName()),
-					Placement: &ec2.Placement{
+					Placement: &ec2types.Placement{
 						AvailabilityZone: aws.String(fake.DefaultRegion),
 					},
 					// Launch time was 1m ago
 					LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
 					InstanceId:   aws.String(instanceID),
-					InstanceType: aws.String("m5.large"),
+					InstanceType: "m5.large",
 				},
 			)
 		}
@@ -194,4 +636,89 @@ var _ = Describe("InstanceProvider", func() {
 		retrievedIDs := sets.New[string](lo.Map(instances, func(i *instance.Instance, _ int) string { return i.ID })...)
 		Expect(ids.Equal(retrievedIDs)).To(BeTrue())
 	})
+	Context("Sharded List", func() {
+		var ids sets.Set[string]
+
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{GarbageCollectionListWorkers: lo.ToPtr(2)}))
+			ids = sets.New[string]()
+			for _, zone := range []string{"test-zone-1a", "test-zone-1b", "test-zone-1c", "test-zone-1a-local"} {
+				instanceID := fake.InstanceID()
+				awsEnv.EC2API.Instances.Store(instanceID, &ec2types.Instance{
+					InstanceId:   aws.String(instanceID),
+					InstanceType: "m5.large",
+					State:        &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+					Placement:    &ec2types.Placement{AvailabilityZone: aws.String(zone)},
+					LaunchTime:   aws.Time(time.Now().Add(-time.Minute)),
+					Tags: []ec2types.Tag{
+						{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+						{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String("default")},
+						{Key: aws.String(v1beta1.LabelNodeClass), Value: aws.String("default")},
+					},
+				})
+				ids.Insert(instanceID)
+			}
+		})
+		It("should describe the instances of each availability zone in pages of the largest size", func() {
+			instances, err := awsEnv.InstanceProvider.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sets.New(lo.Map(instances, func(i *instance.Instance, _ int) string { return i.ID })...).Equal(ids)).To(BeTrue())
+			Expect(awsEnv.EC2API.DescribeInstancesBehavior.Calls()).To(Equal(4))
+			awsEnv.EC2API.DescribeInstancesBehavior.CalledWithInput.ForEach(func(input *ec2.DescribeInstancesInput) {
+				Expect(int64(aws.ToInt32(input.MaxResults))).To(BeNumerically("==", 1000))
+			})
+		})
+		It("should fail to list instances when describing a zone fails", func() {
+			awsEnv.EC2API.DescribeInstancesBehavior.Error.Set(fmt.Errorf("failed"), fake.MaxCalls(1))
+			_, err := awsEnv.InstanceProvider.List(ctx)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail to list instances when no availability zones are found", func() {
+			awsEnv.EC2API.DescribeAvailabilityZonesOutput.Set(&ec2.DescribeAvailabilityZonesOutput{})
+			_, err := awsEnv.InstanceProvider.List(ctx)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
+
+func stoppedInstance(id, nodePoolName, nodeClassName, nodeClassHash string) *ec2types.Instance {
+	return &ec2types.Instance{
+		InstanceId:     aws.String(id),
+		InstanceType:   "m5.large",
+		ImageId:        aws.String("ami-test1"),
+		SubnetId:       aws.String("subnet-test1"),
+		SecurityGroups: []ec2types.GroupIdentifier{{GroupId: aws.String("sg-test1")}},
+		State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNameStopped},
+		Placement:      &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+		LaunchTime:     aws.Time(time.Now().Add(-time.Hour)),
+		Tags: []ec2types.Tag{
+			{Key: aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName)), Value: aws.String("owned")},
+			{Key: aws.String(corev1beta1.NodePoolLabelKey), Value: aws.String(nodePoolName)},
+			{Key: aws.String(v1beta1.LabelNodeClass), Value: aws.String(nodeClassName)},
+			{Key: aws.String(v1beta1.TagStoppedAt), Value: aws.String(time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))},
+			{Key: aws.String(v1beta1.AnnotationEC2NodeClassHash), Value: aws.String(nodeClassHash)},
+		},
+	}
+}
+
+func launchQueueSamples(nodeClassName string) uint64 {
+	metric, ok := FindMetricWithLabelValues("karpenter_launches_queue_duration_seconds", map[string]string{"nodeclass": nodeClassName})
+	if !ok {
+		return 0
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func unmanagedInstance(id string) *ec2types.Instance {
+	return &ec2types.Instance{
+		InstanceId:     aws.String(id),
+		InstanceType:   "m5.large",
+		ImageId:        aws.String("ami-test1"),
+		SubnetId:       aws.String("subnet-test1"),
+		SecurityGroups: []ec2types.GroupIdentifier{{GroupId: aws.String("sg-test1")}},
+		State:          &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+		Placement:      &ec2types.Placement{AvailabilityZone: aws.String("test-zone-1a")},
+		LaunchTime:     aws.Time(time.Now().Add(-24 * time.Hour)),
+		PrivateDnsName: aws.String(fake.PrivateDNSName()),
+	}
+}
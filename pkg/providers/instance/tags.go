@@ -0,0 +1,133 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// CostAllocationTagData is the data that cost allocation tag templates are rendered against
+type CostAllocationTagData struct {
+	ClusterName string
+	NodePool    ObjectData
+	NodeClass   ObjectData
+}
+
+type ObjectData struct {
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// costAllocationTags renders the configured cost allocation tags for a launch from the NodePool and EC2NodeClass.
+// Tags that render to an empty value, such as those that reference a label that isn't set, are omitted.
+func costAllocationTags(ctx context.Context, nodePool *corev1beta1.NodePool, nodeClass *v1beta1.EC2NodeClass) (map[string]string, error) {
+	if options.FromContext(ctx).CostAllocationTags == "" {
+		return nil, nil
+	}
+	templates, err := options.ParseCostAllocationTags(options.FromContext(ctx).CostAllocationTags)
+	if err != nil {
+		return nil, fmt.Errorf("parsing cost allocation tags, %w", err)
+	}
+	data := CostAllocationTagData{
+		ClusterName: options.FromContext(ctx).ClusterName,
+		NodeClass:   ObjectData{Name: nodeClass.Name, Labels: nodeClass.Labels, Annotations: nodeClass.Annotations},
+	}
+	if nodePool != nil {
+		data.NodePool = ObjectData{Name: nodePool.Name, Labels: nodePool.Labels, Annotations: nodePool.Annotations}
+	}
+	tags := map[string]string{}
+	for k, t := range templates {
+		buf := &bytes.Buffer{}
+		if err := t.Execute(buf, data); err != nil {
+			return nil, fmt.Errorf("rendering cost allocation tag %q, %w", k, err)
+		}
+		if buf.Len() > 0 {
+			tags[k] = buf.String()
+		}
+	}
+	return tags, nil
+}
+
+// LabelTags returns the configured label tags for the labels of a NodeClaim or node. Labels that aren't set are omitted.
+func LabelTags(ctx context.Context, labels map[string]string) (map[string]string, error) {
+	labelTags, err := options.ParseLabelTags(options.FromContext(ctx).LabelTags)
+	if err != nil {
+		return nil, fmt.Errorf("parsing label tags, %w", err)
+	}
+	tags := map[string]string{}
+	for label, tag := range labelTags {
+		if value, ok := labels[label]; ok && value != "" {
+			tags[tag] = value
+		}
+	}
+	return tags, nil
+}
+
+// InstanceNameData is the data that the instance name template is rendered against
+type InstanceNameData struct {
+	ClusterName  string
+	Zone         string
+	InstanceType string
+	CapacityType string
+	InstanceID   string
+	NodeName     string
+	// Suffix is the random suffix of the NodeClaim's generated name
+	Suffix    string
+	NodePool  ObjectData
+	NodeClass ObjectData
+	NodeClaim ObjectData
+}
+
+// InstanceName renders the configured instance name template for the instance of a registered NodeClaim. The node
+// name is returned if there is no template, or if the template renders to an empty value.
+func InstanceName(ctx context.Context, instance *Instance, nodeClaim *corev1beta1.NodeClaim, nodePool *corev1beta1.NodePool, nodeClass *v1beta1.EC2NodeClass) (string, error) {
+	t, err := options.ParseInstanceNameTemplate(options.FromContext(ctx).InstanceNameTemplate)
+	if err != nil || t == nil {
+		return nodeClaim.Status.NodeName, err
+	}
+	data := InstanceNameData{
+		ClusterName:  options.FromContext(ctx).ClusterName,
+		Zone:         instance.Zone,
+		InstanceType: instance.Type,
+		CapacityType: instance.CapacityType,
+		InstanceID:   instance.ID,
+		NodeName:     nodeClaim.Status.NodeName,
+		Suffix:       nodeClaim.Name[strings.LastIndex(nodeClaim.Name, "-")+1:],
+		NodeClaim:    ObjectData{Name: nodeClaim.Name, Labels: nodeClaim.Labels, Annotations: nodeClaim.Annotations},
+	}
+	if nodePool != nil {
+		data.NodePool = ObjectData{Name: nodePool.Name, Labels: nodePool.Labels, Annotations: nodePool.Annotations}
+	}
+	if nodeClass != nil {
+		data.NodeClass = ObjectData{Name: nodeClass.Name, Labels: nodeClass.Labels, Annotations: nodeClass.Annotations}
+	}
+	buf := &bytes.Buffer{}
+	if err = t.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("rendering instance name, %w", err)
+	}
+	if buf.Len() == 0 {
+		return nodeClaim.Status.NodeName, nil
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,125 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// limiter bounds the number of launches that are in flight at the same time
+type limiter struct {
+	limit int64
+	sem   *semaphore.Weighted
+}
+
+// launchThrottle limits the number of launches that are in flight at the same time, both across all EC2NodeClasses
+// through --max-concurrent-launches and for each EC2NodeClass through AnnotationMaxConcurrentLaunches. Launches
+// beyond the limits wait in order until earlier launches complete.
+type launchThrottle struct {
+	mu       sync.Mutex
+	global   *limiter
+	limiters map[string]*limiter
+}
+
+func newLaunchThrottle() *launchThrottle {
+	return &launchThrottle{limiters: map[string]*limiter{}}
+}
+
+// acquire waits until the launch is allowed by the limits and returns a function that must be called once the launch
+// has completed
+func (t *launchThrottle) acquire(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (func(), error) {
+	nodeClassLimit, err := maxConcurrentLaunches(nodeClass)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	// Limiters are replaced when their limit changes, and launches that hold the replaced limiter release it as usual
+	nodeClassLimiter := t.limiter(t.limiters[nodeClass.Name], nodeClassLimit)
+	t.limiters[nodeClass.Name] = nodeClassLimiter
+	t.global = t.limiter(t.global, int64(options.FromContext(ctx).MaxConcurrentLaunches))
+	globalLimiter := t.global
+	t.mu.Unlock()
+
+	start := time.Now()
+	launchesQueued.WithLabelValues(nodeClass.Name).Inc()
+	err = acquireAll(ctx, nodeClassLimiter, globalLimiter)
+	launchesQueued.WithLabelValues(nodeClass.Name).Dec()
+	if err != nil {
+		return nil, fmt.Errorf("waiting for concurrent launches to complete, %w", err)
+	}
+	launchQueueDuration.WithLabelValues(nodeClass.Name).Observe(time.Since(start).Seconds())
+	launchesInFlight.WithLabelValues(nodeClass.Name).Inc()
+	return func() {
+		launchesInFlight.WithLabelValues(nodeClass.Name).Dec()
+		release(nodeClassLimiter, globalLimiter)
+	}, nil
+}
+
+// limiter returns the limiter for the limit, reusing the current limiter if its limit hasn't changed. Limits that
+// aren't positive don't limit launches.
+func (t *launchThrottle) limiter(current *limiter, limit int64) *limiter {
+	if limit <= 0 {
+		return nil
+	}
+	if current != nil && current.limit == limit {
+		return current
+	}
+	return &limiter{limit: limit, sem: semaphore.NewWeighted(limit)}
+}
+
+// acquireAll acquires the limiters in order, so that launches that wait on multiple limiters can't deadlock
+func acquireAll(ctx context.Context, limiters ...*limiter) error {
+	for i, l := range limiters {
+		if l == nil {
+			continue
+		}
+		if err := l.sem.Acquire(ctx, 1); err != nil {
+			release(limiters[:i]...)
+			return err
+		}
+	}
+	return nil
+}
+
+func release(limiters ...*limiter) {
+	for _, l := range limiters {
+		if l != nil {
+			l.sem.Release(1)
+		}
+	}
+}
+
+// maxConcurrentLaunches returns the limit that is set on the EC2NodeClass through AnnotationMaxConcurrentLaunches, or 0
+// if launches for the EC2NodeClass aren't limited
+func maxConcurrentLaunches(nodeClass *v1beta1.EC2NodeClass) (int64, error) {
+	value, ok := nodeClass.Annotations[v1beta1.AnnotationMaxConcurrentLaunches]
+	if !ok {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("parsing %s, %q is not a non-negative integer", v1beta1.AnnotationMaxConcurrentLaunches, value)
+	}
+	return limit, nil
+}
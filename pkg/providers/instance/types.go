@@ -15,63 +15,155 @@ limitations under the License.
 package instance
 
 import (
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
 )
 
-// Instance is an internal data representation of either an ec2.Instance or an ec2.FleetInstance
+// Instance is an internal data representation of either an ec2types.Instance or an ec2types.FleetInstance
 // It contains all the common data that is needed to inject into the Machine from either of these responses
 type Instance struct {
-	LaunchTime       time.Time
-	State            string
-	ID               string
-	ImageID          string
-	Type             string
-	Zone             string
-	CapacityType     string
-	SecurityGroupIDs []string
-	SubnetID         string
-	Tags             map[string]string
-	EFAEnabled       bool
+	LaunchTime          time.Time
+	State               string
+	ID                  string
+	ImageID             string
+	Type                string
+	Zone                string
+	CapacityType        string
+	SecurityGroupIDs    []string
+	SubnetID            string
+	Tags                map[string]string
+	EFAEnabled          bool
+	MetadataOptions     *v1beta1.MetadataOptions
+	NetworkInterfaceIDs []string
+	InstanceProfileARN  string
+	// LaunchTemplateName and CreateFleetRequestID are only known for instances that were just launched, and
+	// LaunchTemplateVersion is only known once EC2 has resolved it
+	LaunchTemplateName    string
+	LaunchTemplateVersion string
+	CreateFleetRequestID  string
+	CapacityReservationID string
+	// RootDeviceName, Volumes and PrimaryNetworkInterface are only known once the instance is described. Volumes are
+	// keyed by the device name that they're attached as.
+	RootDeviceName          string
+	Volumes                 map[string]Attachment
+	PrimaryNetworkInterface *Attachment
+}
+
+// Attachment is a volume or network interface that is attached to an instance
+type Attachment struct {
+	ID                  string
+	DeleteOnTermination bool
 }
 
-func NewInstance(out *ec2.Instance) *Instance {
+func NewInstance(out *ec2types.Instance) *Instance {
 	return &Instance{
-		LaunchTime:   aws.TimeValue(out.LaunchTime),
-		State:        aws.StringValue(out.State.Name),
-		ID:           aws.StringValue(out.InstanceId),
-		ImageID:      aws.StringValue(out.ImageId),
-		Type:         aws.StringValue(out.InstanceType),
-		Zone:         aws.StringValue(out.Placement.AvailabilityZone),
+		LaunchTime:   aws.ToTime(out.LaunchTime),
+		State:        string(out.State.Name),
+		ID:           aws.ToString(out.InstanceId),
+		ImageID:      aws.ToString(out.ImageId),
+		Type:         string(out.InstanceType),
+		Zone:         aws.ToString(out.Placement.AvailabilityZone),
 		CapacityType: lo.Ternary(out.SpotInstanceRequestId != nil, corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand),
-		SecurityGroupIDs: lo.Map(out.SecurityGroups, func(securitygroup *ec2.GroupIdentifier, _ int) string {
-			return aws.StringValue(securitygroup.GroupId)
+		SecurityGroupIDs: lo.Map(out.SecurityGroups, func(securitygroup ec2types.GroupIdentifier, _ int) string {
+			return aws.ToString(securitygroup.GroupId)
+		}),
+		SubnetID: aws.ToString(out.SubnetId),
+		Tags:     lo.SliceToMap(out.Tags, func(t ec2types.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) }),
+		EFAEnabled: lo.ContainsBy(out.NetworkInterfaces, func(ni ec2types.InstanceNetworkInterface) bool {
+			return aws.ToString(ni.InterfaceType) == string(ec2types.NetworkInterfaceTypeEfa)
+		}),
+		MetadataOptions: newMetadataOptions(out.MetadataOptions),
+		NetworkInterfaceIDs: lo.FilterMap(out.NetworkInterfaces, func(ni ec2types.InstanceNetworkInterface, _ int) (string, bool) {
+			return aws.ToString(ni.NetworkInterfaceId), ni.NetworkInterfaceId != nil
 		}),
-		SubnetID: aws.StringValue(out.SubnetId),
-		Tags:     lo.SliceToMap(out.Tags, func(t *ec2.Tag) (string, string) { return aws.StringValue(t.Key), aws.StringValue(t.Value) }),
-		EFAEnabled: lo.ContainsBy(out.NetworkInterfaces, func(ni *ec2.InstanceNetworkInterface) bool {
-			return ni != nil && lo.FromPtr(ni.InterfaceType) == ec2.NetworkInterfaceTypeEfa
+		InstanceProfileARN: aws.ToString(lo.FromPtr(out.IamInstanceProfile).Arn),
+		LaunchTemplateVersion: aws.ToString(lo.FindOrElse(out.Tags, ec2types.Tag{}, func(t ec2types.Tag) bool {
+			return aws.ToString(t.Key) == v1beta1.TagLaunchTemplateVersion
+		}).Value),
+		CapacityReservationID: aws.ToString(out.CapacityReservationId),
+		RootDeviceName:        aws.ToString(out.RootDeviceName),
+		Volumes: lo.SliceToMap(lo.Filter(out.BlockDeviceMappings, func(bdm ec2types.InstanceBlockDeviceMapping, _ int) bool {
+			return bdm.Ebs != nil && bdm.Ebs.VolumeId != nil
+		}), func(bdm ec2types.InstanceBlockDeviceMapping) (string, Attachment) {
+			return aws.ToString(bdm.DeviceName), Attachment{ID: aws.ToString(bdm.Ebs.VolumeId), DeleteOnTermination: aws.ToBool(bdm.Ebs.DeleteOnTermination)}
 		}),
+		PrimaryNetworkInterface: newPrimaryNetworkInterface(out.NetworkInterfaces),
+	}
+
+}
+
+// newPrimaryNetworkInterface returns the network interface that is attached at device index 0, which is created when
+// the instance is launched
+func newPrimaryNetworkInterface(networkInterfaces []ec2types.InstanceNetworkInterface) *Attachment {
+	ni, ok := lo.Find(networkInterfaces, func(ni ec2types.InstanceNetworkInterface) bool {
+		return ni.NetworkInterfaceId != nil && ni.Attachment != nil && aws.ToInt32(ni.Attachment.DeviceIndex) == 0
+	})
+	if !ok {
+		return nil
 	}
+	return &Attachment{ID: aws.ToString(ni.NetworkInterfaceId), DeleteOnTermination: aws.ToBool(ni.Attachment.DeleteOnTermination)}
+}
 
+func newMetadataOptions(out *ec2types.InstanceMetadataOptionsResponse) *v1beta1.MetadataOptions {
+	if out == nil {
+		return nil
+	}
+	return &v1beta1.MetadataOptions{
+		HTTPEndpoint:            lo.EmptyableToPtr(string(out.HttpEndpoint)),
+		HTTPProtocolIPv6:        lo.EmptyableToPtr(string(out.HttpProtocolIpv6)),
+		HTTPPutResponseHopLimit: lo.EmptyableToPtr(int64(aws.ToInt32(out.HttpPutResponseHopLimit))),
+		HTTPTokens:              lo.EmptyableToPtr(string(out.HttpTokens)),
+	}
 }
 
-func NewInstanceFromFleet(out *ec2.CreateFleetInstance, tags map[string]string, efaEnabled bool) *Instance {
+func NewInstanceFromFleet(out *ec2types.CreateFleetInstance, tags map[string]string, efaEnabled bool) *Instance {
+	version := aws.ToString(lo.FromPtr(out.LaunchTemplateAndOverrides.LaunchTemplateSpecification).Version)
 	return &Instance{
-		LaunchTime:   time.Now(), // estimate the launch time since we just launched
-		State:        ec2.StatePending,
-		ID:           aws.StringValue(out.InstanceIds[0]),
-		ImageID:      aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.ImageId),
-		Type:         aws.StringValue(out.InstanceType),
-		Zone:         aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
-		CapacityType: aws.StringValue(out.Lifecycle),
-		SubnetID:     aws.StringValue(out.LaunchTemplateAndOverrides.Overrides.SubnetId),
-		Tags:         tags,
-		EFAEnabled:   efaEnabled,
+		LaunchTime:         time.Now(), // estimate the launch time since we just launched
+		State:              string(ec2types.StatePending),
+		ID:                 out.InstanceIds[0],
+		ImageID:            aws.ToString(out.LaunchTemplateAndOverrides.Overrides.ImageId),
+		Type:               string(out.InstanceType),
+		Zone:               aws.ToString(out.LaunchTemplateAndOverrides.Overrides.AvailabilityZone),
+		CapacityType:       string(out.Lifecycle),
+		SubnetID:           aws.ToString(out.LaunchTemplateAndOverrides.Overrides.SubnetId),
+		Tags:               tags,
+		EFAEnabled:         efaEnabled,
+		LaunchTemplateName: aws.ToString(lo.FromPtr(out.LaunchTemplateAndOverrides.LaunchTemplateSpecification).LaunchTemplateName),
+		// the version is echoed as requested, so it's only recorded if it doesn't refer to "$Latest" or "$Default"
+		LaunchTemplateVersion: lo.Ternary(strings.HasPrefix(version, "$"), "", version),
 	}
 }
+
+// CapacityTypeLabels returns the labels that distinguish instances that were launched into a capacity reservation from
+// other on-demand instances. Instances launched by CreateFleet are only known to be in a capacity reservation once
+// they're described.
+func (i *Instance) CapacityTypeLabels() map[string]string {
+	if i.CapacityReservationID == "" {
+		return map[string]string{v1beta1.LabelCapacityType: i.CapacityType}
+	}
+	return map[string]string{
+		v1beta1.LabelCapacityType:          v1beta1.CapacityTypeReserved,
+		v1beta1.LabelCapacityReservationID: i.CapacityReservationID,
+	}
+}
+
+// LaunchAnnotations returns the annotations that record how the instance was launched. Details that aren't known for the
+// instance are omitted, so that they don't overwrite the details that were recorded when it was launched.
+func (i *Instance) LaunchAnnotations() map[string]string {
+	return lo.OmitByValues(map[string]string{
+		v1beta1.AnnotationLaunchTemplateName:    i.LaunchTemplateName,
+		v1beta1.AnnotationLaunchTemplateVersion: i.LaunchTemplateVersion,
+		v1beta1.AnnotationCreateFleetRequestID:  i.CreateFleetRequestID,
+		v1beta1.AnnotationNetworkInterfaceIDs:   strings.Join(i.NetworkInterfaceIDs, ","),
+		v1beta1.AnnotationCapacityReservationID: i.CapacityReservationID,
+	}, []string{""})
+}
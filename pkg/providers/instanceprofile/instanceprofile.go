@@ -17,10 +17,11 @@ package instanceprofile
 import (
 	"context"
 	"fmt"
+	"sort"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/iam"
-	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
@@ -29,17 +30,25 @@ import (
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 )
 
+// Reasons that the role or instance profile of an EC2NodeClass can't be used to launch instances
+const (
+	ReasonRoleNotFound             = "RoleNotFound"
+	ReasonInstanceProfileNotFound  = "InstanceProfileNotFound"
+	ReasonInstanceProfileHasNoRole = "InstanceProfileHasNoRole"
+)
+
 type Provider struct {
 	region string
-	iamapi iamiface.IAMAPI
+	iamapi sdk.IAMAPI
 	cache  *cache.Cache
 }
 
-func NewProvider(region string, iamapi iamiface.IAMAPI, cache *cache.Cache) *Provider {
+func NewProvider(region string, iamapi sdk.IAMAPI, cache *cache.Cache) *Provider {
 	return &Provider{
 		region: region,
 		iamapi: iamapi,
@@ -47,7 +56,9 @@ func NewProvider(region string, iamapi iamiface.IAMAPI, cache *cache.Cache) *Pro
 	}
 }
 
-func (p *Provider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (string, error) {
+// Create creates the instance profile of the role of the EC2NodeClass if it doesn't exist, and assigns the role to it.
+// The instance profile is returned so that its ARN can be published in the status of the EC2NodeClass.
+func (p *Provider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (*iamtypes.InstanceProfile, error) {
 	tags := lo.Assign(nodeClass.Spec.Tags, map[string]string{
 		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
 		corev1beta1.ManagedByAnnotationKey:                                            options.FromContext(ctx).ClusterName,
@@ -57,22 +68,22 @@ func (p *Provider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass)
 	profileName := GetProfileName(ctx, p.region, nodeClass)
 
 	// An instance profile exists for this NodeClass
-	if _, ok := p.cache.Get(string(nodeClass.UID)); ok {
-		return profileName, nil
+	if instanceProfile, ok := p.cache.Get(string(nodeClass.UID)); ok {
+		return instanceProfile.(*iamtypes.InstanceProfile), nil
 	}
 	// Validate if the instance profile exists and has the correct role assigned to it
-	var instanceProfile *iam.InstanceProfile
-	out, err := p.iamapi.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(profileName)})
+	var instanceProfile *iamtypes.InstanceProfile
+	out, err := p.iamapi.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(profileName)})
 	if err != nil {
 		if !awserrors.IsNotFound(err) {
-			return "", fmt.Errorf("getting instance profile %q, %w", profileName, err)
+			return nil, fmt.Errorf("getting instance profile %q, %w", profileName, err)
 		}
-		o, err := p.iamapi.CreateInstanceProfileWithContext(ctx, &iam.CreateInstanceProfileInput{
+		o, err := p.iamapi.CreateInstanceProfile(ctx, &iam.CreateInstanceProfileInput{
 			InstanceProfileName: aws.String(profileName),
-			Tags:                lo.MapToSlice(tags, func(k, v string) *iam.Tag { return &iam.Tag{Key: aws.String(k), Value: aws.String(v)} }),
+			Tags:                lo.MapToSlice(tags, func(k, v string) iamtypes.Tag { return iamtypes.Tag{Key: aws.String(k), Value: aws.String(v)} }),
 		})
 		if err != nil {
-			return "", fmt.Errorf("creating instance profile %q, %w", profileName, err)
+			return nil, fmt.Errorf("creating instance profile %q, %w", profileName, err)
 		}
 		instanceProfile = o.InstanceProfile
 	} else {
@@ -81,29 +92,29 @@ func (p *Provider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass)
 	// Instance profiles can only have a single role assigned to them so this profile either has 1 or 0 roles
 	// https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use_switch-role-ec2_instance-profiles.html
 	if len(instanceProfile.Roles) == 1 {
-		if aws.StringValue(instanceProfile.Roles[0].RoleName) == nodeClass.Spec.Role {
-			return profileName, nil
+		if aws.ToString(instanceProfile.Roles[0].RoleName) == nodeClass.Spec.Role {
+			return instanceProfile, nil
 		}
-		if _, err = p.iamapi.RemoveRoleFromInstanceProfileWithContext(ctx, &iam.RemoveRoleFromInstanceProfileInput{
+		if _, err = p.iamapi.RemoveRoleFromInstanceProfile(ctx, &iam.RemoveRoleFromInstanceProfileInput{
 			InstanceProfileName: aws.String(profileName),
 			RoleName:            instanceProfile.Roles[0].RoleName,
 		}); err != nil {
-			return "", fmt.Errorf("removing role %q for instance profile %q, %w", aws.StringValue(instanceProfile.Roles[0].RoleName), profileName, err)
+			return nil, fmt.Errorf("removing role %q for instance profile %q, %w", aws.ToString(instanceProfile.Roles[0].RoleName), profileName, err)
 		}
 	}
-	if _, err = p.iamapi.AddRoleToInstanceProfileWithContext(ctx, &iam.AddRoleToInstanceProfileInput{
+	if _, err = p.iamapi.AddRoleToInstanceProfile(ctx, &iam.AddRoleToInstanceProfileInput{
 		InstanceProfileName: aws.String(profileName),
 		RoleName:            aws.String(nodeClass.Spec.Role),
 	}); err != nil {
-		return "", fmt.Errorf("adding role %q to instance profile %q, %w", nodeClass.Spec.Role, profileName, err)
+		return nil, fmt.Errorf("adding role %q to instance profile %q, %w", nodeClass.Spec.Role, profileName, err)
 	}
-	p.cache.SetDefault(string(nodeClass.UID), nil)
-	return profileName, nil
+	p.cache.SetDefault(string(nodeClass.UID), instanceProfile)
+	return instanceProfile, nil
 }
 
 func (p *Provider) Delete(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
 	profileName := GetProfileName(ctx, p.region, nodeClass)
-	out, err := p.iamapi.GetInstanceProfileWithContext(ctx, &iam.GetInstanceProfileInput{
+	out, err := p.iamapi.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
 		InstanceProfileName: aws.String(profileName),
 	})
 	if err != nil {
@@ -112,14 +123,14 @@ func (p *Provider) Delete(ctx context.Context, nodeClass *v1beta1.EC2NodeClass)
 	// Instance profiles can only have a single role assigned to them so this profile either has 1 or 0 roles
 	// https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use_switch-role-ec2_instance-profiles.html
 	if len(out.InstanceProfile.Roles) == 1 {
-		if _, err = p.iamapi.RemoveRoleFromInstanceProfileWithContext(ctx, &iam.RemoveRoleFromInstanceProfileInput{
+		if _, err = p.iamapi.RemoveRoleFromInstanceProfile(ctx, &iam.RemoveRoleFromInstanceProfileInput{
 			InstanceProfileName: aws.String(profileName),
 			RoleName:            out.InstanceProfile.Roles[0].RoleName,
 		}); err != nil {
-			return fmt.Errorf("removing role %q from instance profile %q, %w", aws.StringValue(out.InstanceProfile.Roles[0].RoleName), profileName, err)
+			return fmt.Errorf("removing role %q from instance profile %q, %w", aws.ToString(out.InstanceProfile.Roles[0].RoleName), profileName, err)
 		}
 	}
-	if _, err = p.iamapi.DeleteInstanceProfileWithContext(ctx, &iam.DeleteInstanceProfileInput{
+	if _, err = p.iamapi.DeleteInstanceProfile(ctx, &iam.DeleteInstanceProfileInput{
 		InstanceProfileName: aws.String(profileName),
 	}); err != nil {
 		return awserrors.IgnoreNotFound(fmt.Errorf("deleting instance profile %q, %w", profileName, err))
@@ -127,6 +138,129 @@ func (p *Provider) Delete(ctx context.Context, nodeClass *v1beta1.EC2NodeClass)
 	return nil
 }
 
+// Get returns the instance profile with the name, along with the role that's assigned to it
+func (p *Provider) Get(ctx context.Context, profileName string) (*iamtypes.InstanceProfile, error) {
+	out, err := p.iamapi.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: aws.String(profileName)})
+	if err != nil {
+		return nil, fmt.Errorf("getting instance profile %q, %w", profileName, err)
+	}
+	return out.InstanceProfile, nil
+}
+
+// Check returns the reason that the role or instance profile of the EC2NodeClass can't be used to launch instances,
+// along with a message that describes it, or an empty reason if it can. Roles that are managed by Karpenter aren't
+// checked, since they're created when the EC2NodeClass is reconciled, and neither are the instance profiles of roles.
+// Nothing is checked in isolated VPCs, which can't reach IAM, or when Karpenter isn't allowed to get the role or
+// instance profile.
+func (p *Provider) Check(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (string, string, error) {
+	if options.FromContext(ctx).IsolatedVPC {
+		return "", "", nil
+	}
+	if nodeClass.Spec.Role != "" {
+		if options.FromContext(ctx).ManageNodeRoles {
+			return "", "", nil
+		}
+		if _, err := p.iamapi.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(nodeClass.Spec.Role)}); err != nil {
+			if awserrors.IsNotFound(err) {
+				return ReasonRoleNotFound, fmt.Sprintf("role %q doesn't exist", nodeClass.Spec.Role), nil
+			}
+			if awserrors.IsAccessDenied(err) {
+				return "", "", nil
+			}
+			return "", "", fmt.Errorf("getting role %q, %w", nodeClass.Spec.Role, err)
+		}
+		return "", "", nil
+	}
+	var instanceProfile *iamtypes.InstanceProfile
+	if nodeClass.Spec.InstanceProfileSelectorTerms != nil {
+		instanceProfiles, err := p.List(ctx, nodeClass)
+		if err != nil {
+			return "", "", err
+		}
+		if len(instanceProfiles) == 0 {
+			return ReasonInstanceProfileNotFound, fmt.Sprintf("no instance profiles exist given constraints %v", nodeClass.Spec.InstanceProfileSelectorTerms), nil
+		}
+		instanceProfile = instanceProfiles[0]
+	} else {
+		out, err := p.iamapi.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{InstanceProfileName: nodeClass.Spec.InstanceProfile})
+		if err != nil {
+			if awserrors.IsNotFound(err) {
+				return ReasonInstanceProfileNotFound, fmt.Sprintf("instance profile %q doesn't exist", lo.FromPtr(nodeClass.Spec.InstanceProfile)), nil
+			}
+			if awserrors.IsAccessDenied(err) {
+				return "", "", nil
+			}
+			return "", "", fmt.Errorf("getting instance profile %q, %w", lo.FromPtr(nodeClass.Spec.InstanceProfile), err)
+		}
+		instanceProfile = out.InstanceProfile
+	}
+	if len(instanceProfile.Roles) == 0 {
+		return ReasonInstanceProfileHasNoRole, fmt.Sprintf("instance profile %q has no role", aws.ToString(instanceProfile.InstanceProfileName)), nil
+	}
+	return "", "", nil
+}
+
+// List returns the instance profiles that are selected by the EC2NodeClass's instanceProfileSelectorTerms, most
+// recently created first, so that instance profiles can be rotated by creating a new one. IAM can't filter instance
+// profiles by tag and doesn't return their tags when they're listed, so the tags of every instance profile are listed
+// separately.
+func (p *Provider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*iamtypes.InstanceProfile, error) {
+	if len(nodeClass.Spec.InstanceProfileSelectorTerms) == 0 {
+		return []*iamtypes.InstanceProfile{}, nil
+	}
+	hash, err := hashstructure.Hash(nodeClass.Spec.InstanceProfileSelectorTerms, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("selector/%d", hash)
+	if instanceProfiles, ok := p.cache.Get(key); ok {
+		return instanceProfiles.([]*iamtypes.InstanceProfile), nil
+	}
+	var candidates []iamtypes.InstanceProfile
+	paginator := iam.NewListInstanceProfilesPaginator(p.iamapi, &iam.ListInstanceProfilesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing instance profiles, %w", err)
+		}
+		candidates = append(candidates, page.InstanceProfiles...)
+	}
+	instanceProfiles := []*iamtypes.InstanceProfile{}
+	for i := range candidates {
+		instanceProfile := &candidates[i]
+		out, err := p.iamapi.ListInstanceProfileTags(ctx, &iam.ListInstanceProfileTagsInput{InstanceProfileName: instanceProfile.InstanceProfileName})
+		if err != nil {
+			// The instance profile was deleted after it was listed
+			if awserrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("listing tags of instance profile %q, %w", aws.ToString(instanceProfile.InstanceProfileName), err)
+		}
+		tags := lo.SliceToMap(out.Tags, func(t iamtypes.Tag) (string, string) { return aws.ToString(t.Key), aws.ToString(t.Value) })
+		if lo.SomeBy(nodeClass.Spec.InstanceProfileSelectorTerms, func(term v1beta1.InstanceProfileSelectorTerm) bool { return matches(term.Tags, tags) }) {
+			instanceProfiles = append(instanceProfiles, instanceProfile)
+		}
+	}
+	sort.Slice(instanceProfiles, func(i, j int) bool {
+		if !aws.ToTime(instanceProfiles[i].CreateDate).Equal(aws.ToTime(instanceProfiles[j].CreateDate)) {
+			return aws.ToTime(instanceProfiles[i].CreateDate).After(aws.ToTime(instanceProfiles[j].CreateDate))
+		}
+		return aws.ToString(instanceProfiles[i].InstanceProfileName) < aws.ToString(instanceProfiles[j].InstanceProfileName)
+	})
+	p.cache.SetDefault(key, instanceProfiles)
+	return instanceProfiles, nil
+}
+
+// matches returns true if the tags have every key of the selector, with the same value unless the selector's value is '*'
+func matches(selector map[string]string, tags map[string]string) bool {
+	for k, v := range selector {
+		if value, ok := tags[k]; !ok || (v != "*" && v != value) {
+			return false
+		}
+	}
+	return true
+}
+
 // GetProfileName gets the string for the profile name based on the cluster name and the NodeClass UUID.
 // The length of this string can never exceed the maximum instance profile name limit of 128 characters.
 func GetProfileName(ctx context.Context, region string, nodeClass *v1beta1.EC2NodeClass) string {
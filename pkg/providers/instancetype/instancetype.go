@@ -28,17 +28,22 @@ import (
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/logging"
 
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/tracing"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
@@ -53,19 +58,20 @@ const (
 
 type Provider struct {
 	region          string
-	ec2api          ec2iface.EC2API
+	ec2api          sdk.EC2API
 	subnetProvider  *subnet.Provider
 	pricingProvider *pricing.Provider
 	// Has one cache entry for all the instance types (key: InstanceTypesCacheKey)
 	// Has one cache entry for all the zones for each subnet selector (key: InstanceTypesZonesCacheKeyPrefix:<hash_of_selector>)
 	// Values cached *before* considering insufficient capacity errors from the unavailableOfferings cache.
 	// Fully initialized Instance Types are also cached based on the set of all instance types, zones, unavailableOfferings cache,
-	// EC2NodeClass, and kubelet configuration from the NodePool
+	// vCPU quotas, EC2NodeClass, and kubelet configuration from the NodePool
 
 	mu    sync.Mutex
 	cache *cache.Cache
 
 	unavailableOfferings *awscache.UnavailableOfferings
+	quotaProvider        *quota.Provider
 	cm                   *pretty.ChangeMonitor
 	// instanceTypesSeqNum is a monotonically increasing change counter used to avoid the expensive hashing operation on instance types
 	instanceTypesSeqNum uint64
@@ -73,21 +79,24 @@ type Provider struct {
 	instanceTypeOfferingsSeqNum uint64
 }
 
-func NewProvider(region string, cache *cache.Cache, ec2api ec2iface.EC2API, subnetProvider *subnet.Provider,
-	unavailableOfferingsCache *awscache.UnavailableOfferings, pricingProvider *pricing.Provider) *Provider {
+func NewProvider(region string, cache *cache.Cache, ec2api sdk.EC2API, subnetProvider *subnet.Provider,
+	unavailableOfferingsCache *awscache.UnavailableOfferings, pricingProvider *pricing.Provider, quotaProvider *quota.Provider) *Provider {
 	return &Provider{
 		ec2api:               ec2api,
 		region:               region,
 		subnetProvider:       subnetProvider,
 		pricingProvider:      pricingProvider,
-		cache:                cache,
+		cache:                awscache.WithExpirationMetrics(awscache.InstanceTypes, cache),
 		unavailableOfferings: unavailableOfferingsCache,
+		quotaProvider:        quotaProvider,
 		cm:                   pretty.NewChangeMonitor(),
 		instanceTypesSeqNum:  0,
 	}
 }
 
-func (p *Provider) List(ctx context.Context, kc *corev1beta1.KubeletConfiguration, nodeClass *v1beta1.EC2NodeClass) ([]*cloudprovider.InstanceType, error) {
+func (p *Provider) List(ctx context.Context, kc *corev1beta1.KubeletConfiguration, nodeClass *v1beta1.EC2NodeClass) (_ []*cloudprovider.InstanceType, err error) {
+	ctx, span := tracing.Start(ctx, "InstanceType.List", attribute.String("ec2nodeclass", nodeClass.Name))
+	defer func() { tracing.End(span, err) }()
 	// Get InstanceTypes from EC2
 	instanceTypes, err := p.GetInstanceTypes(ctx)
 	if err != nil {
@@ -102,8 +111,8 @@ func (p *Provider) List(ctx context.Context, kc *corev1beta1.KubeletConfiguratio
 	if err != nil {
 		return nil, err
 	}
-	subnetZones := sets.New[string](lo.Map(subnets, func(s *ec2.Subnet, _ int) string {
-		return aws.StringValue(s.AvailabilityZone)
+	subnetZones := sets.New[string](lo.Map(subnets, func(s *ec2types.Subnet, _ int) string {
+		return aws.ToString(s.AvailabilityZone)
 	})...)
 
 	// Compute fully initialized instance types hash key
@@ -116,28 +125,38 @@ func (p *Provider) List(ctx context.Context, kc *corev1beta1.KubeletConfiguratio
 		kubeReservedHash, _ = hashstructure.Hash(resources.StringMap(kc.KubeReserved), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 		systemReservedHash, _ = hashstructure.Hash(resources.StringMap(kc.SystemReserved), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	}
+	// Zone IDs are only known once the subnets of the EC2NodeClass were resolved
+	zoneIDsHash, _ := hashstructure.Hash(nodeClass.ZoneIDs(), hashstructure.FormatV2, nil)
 	blockDeviceMappingsHash, _ := hashstructure.Hash(nodeClass.Spec.BlockDeviceMappings, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	// TODO: remove volumeSizeHash once resource.Quantity objects get hashed as a string in BlockDeviceMappings
 	// For more information on the resource.Quantity hash issue: https://github.com/aws/karpenter-provider-aws/issues/5447
 	volumeSizeHash, _ := hashstructure.Hash(lo.Reduce(nodeClass.Spec.BlockDeviceMappings, func(agg string, block *v1beta1.BlockDeviceMapping, _ int) string {
 		return fmt.Sprintf("%s/%s", agg, block.EBS.VolumeSize)
 	}, ""), hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
-	key := fmt.Sprintf("%d-%d-%d-%016x-%016x-%016x-%s-%s-%016x-%016x-%016x",
+	key := fmt.Sprintf("%d-%d-%d-%d-%016x-%016x-%016x-%016x-%s-%s-%016x-%016x-%016x-%d-%v",
 		p.instanceTypesSeqNum,
 		p.instanceTypeOfferingsSeqNum,
 		p.unavailableOfferings.SeqNum,
+		p.quotaProvider.SeqNum,
 		subnetZonesHash,
+		zoneIDsHash,
 		kcHash,
 		blockDeviceMappingsHash,
-		aws.StringValue((*string)(nodeClass.Spec.InstanceStorePolicy)),
-		aws.StringValue(nodeClass.Spec.AMIFamily),
+		aws.ToString((*string)(nodeClass.Spec.InstanceStorePolicy)),
+		aws.ToString(nodeClass.Spec.AMIFamily),
 		volumeSizeHash,
 		kubeReservedHash,
 		systemReservedHash,
+		// Reserved ENIs and the VM memory overhead can be changed through the settings ConfigMap while the controller runs
+		options.Live(ctx).ReservedENIs,
+		options.Live(ctx).VMMemoryOverheadPercent,
 	)
 	if item, ok := p.cache.Get(key); ok {
+		awscache.RecordLookup(awscache.InstanceTypes, true)
+		span.SetAttributes(attribute.Bool("cached", true))
 		return item.([]*cloudprovider.InstanceType), nil
 	}
+	awscache.RecordLookup(awscache.InstanceTypes, false)
 
 	// Get all zones across all offerings
 	// We don't use this in the cache key since this is produced from our instanceTypeOfferings which we do cache
@@ -150,15 +169,15 @@ func (p *Provider) List(ctx context.Context, kc *corev1beta1.KubeletConfiguratio
 	if p.cm.HasChanged("zones", allZones) {
 		logging.FromContext(ctx).With("zones", allZones.UnsortedList()).Debugf("discovered zones")
 	}
-	result := lo.Map(instanceTypes, func(i *ec2.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
+	result := lo.Map(instanceTypes, func(i *ec2types.InstanceTypeInfo, _ int) *cloudprovider.InstanceType {
 		instanceTypeVCPU.With(prometheus.Labels{
-			instanceTypeLabel: *i.InstanceType,
-		}).Set(float64(aws.Int64Value(i.VCpuInfo.DefaultVCpus)))
+			instanceTypeLabel: string(i.InstanceType),
+		}).Set(float64(aws.ToInt32(i.VCpuInfo.DefaultVCpus)))
 		instanceTypeMemory.With(prometheus.Labels{
-			instanceTypeLabel: *i.InstanceType,
-		}).Set(float64(aws.Int64Value(i.MemoryInfo.SizeInMiB) * 1024 * 1024))
+			instanceTypeLabel: string(i.InstanceType),
+		}).Set(float64(aws.ToInt64(i.MemoryInfo.SizeInMiB) * 1024 * 1024))
 
-		return NewInstanceType(ctx, i, kc, p.region, nodeClass, p.createOfferings(ctx, i, instanceTypeOfferings[aws.StringValue(i.InstanceType)], allZones, subnetZones))
+		return NewInstanceType(ctx, i, kc, p.region, nodeClass, p.createOfferings(ctx, i, instanceTypeOfferings[string(i.InstanceType)], allZones, subnetZones, nodeClass))
 	})
 	p.cache.SetDefault(key, result)
 	return result, nil
@@ -171,28 +190,33 @@ func (p *Provider) LivenessProbe(req *http.Request) error {
 	return p.pricingProvider.LivenessProbe(req)
 }
 
-func (p *Provider) createOfferings(ctx context.Context, instanceType *ec2.InstanceTypeInfo, instanceTypeZones, zones, subnetZones sets.Set[string]) []cloudprovider.Offering {
+func (p *Provider) createOfferings(ctx context.Context, instanceType *ec2types.InstanceTypeInfo, instanceTypeZones, zones, subnetZones sets.Set[string],
+	nodeClass *v1beta1.EC2NodeClass) []cloudprovider.Offering {
 	var offerings []cloudprovider.Offering
+	// Instances of EC2NodeClasses that assume a role are launched in another account, whose quotas aren't tracked
+	applyQuotas := options.FromContext(ctx).QuotaAwareProvisioning && aws.ToString(nodeClass.Spec.AssumeRoleARN) == ""
 	for zone := range zones {
 		// while usage classes should be a distinct set, there's no guarantee of that
-		for capacityType := range sets.NewString(aws.StringValueSlice(instanceType.SupportedUsageClasses)...) {
+		for capacityType := range sets.New(lo.Map(instanceType.SupportedUsageClasses, func(u ec2types.UsageClassType, _ int) string { return string(u) })...) {
 			// exclude any offerings that have recently seen an insufficient capacity error from EC2
-			isUnavailable := p.unavailableOfferings.IsUnavailable(*instanceType.InstanceType, zone, capacityType)
+			isUnavailable := p.unavailableOfferings.IsUnavailable(string(instanceType.InstanceType), zone, capacityType)
 			var price float64
 			var ok bool
 			switch capacityType {
-			case ec2.UsageClassTypeSpot:
-				price, ok = p.pricingProvider.SpotPrice(*instanceType.InstanceType, zone)
-			case ec2.UsageClassTypeOnDemand:
-				price, ok = p.pricingProvider.OnDemandPrice(*instanceType.InstanceType)
+			case string(ec2types.UsageClassTypeSpot):
+				price, ok = p.pricingProvider.SpotPrice(string(instanceType.InstanceType), zone)
+			case string(ec2types.UsageClassTypeOnDemand):
+				price, ok = p.pricingProvider.OnDemandOfferingPrice(string(instanceType.InstanceType), zone)
 			case "capacity-block":
 				// ignore since karpenter doesn't support it yet, but do not log an unknown capacity type error
 				continue
 			default:
-				logging.FromContext(ctx).Errorf("Received unknown capacity type %s for instance type %s", capacityType, *instanceType.InstanceType)
+				logging.FromContext(ctx).Errorf("Received unknown capacity type %s for instance type %s", capacityType, string(instanceType.InstanceType))
 				continue
 			}
-			available := !isUnavailable && ok && instanceTypeZones.Has(zone) && subnetZones.Has(zone)
+			// exclude any offerings whose launch would exceed the account's vCPU service quota
+			quotaExceeded := applyQuotas && p.quotaProvider.Exceeded(string(instanceType.InstanceType), int64(aws.ToInt32(instanceType.VCpuInfo.DefaultVCpus)), capacityType)
+			available := !isUnavailable && !quotaExceeded && ok && instanceTypeZones.Has(zone) && subnetZones.Has(zone)
 			offerings = append(offerings, cloudprovider.Offering{
 				Zone:         zone,
 				CapacityType: capacityType,
@@ -200,12 +224,12 @@ func (p *Provider) createOfferings(ctx context.Context, instanceType *ec2.Instan
 				Available:    available,
 			})
 			instanceTypeOfferingAvailable.With(prometheus.Labels{
-				instanceTypeLabel: *instanceType.InstanceType,
+				instanceTypeLabel: string(instanceType.InstanceType),
 				capacityTypeLabel: capacityType,
 				zoneLabel:         zone,
 			}).Set(float64(lo.Ternary(available, 1, 0)))
 			instanceTypeOfferingPriceEstimate.With(prometheus.Labels{
-				instanceTypeLabel: *instanceType.InstanceType,
+				instanceTypeLabel: string(instanceType.InstanceType),
 				capacityTypeLabel: capacityType,
 				zoneLabel:         zone,
 			}).Set(price)
@@ -222,22 +246,25 @@ func (p *Provider) getInstanceTypeOfferings(ctx context.Context) (map[string]set
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	if cached, ok := p.cache.Get(InstanceTypeOfferingsCacheKey); ok {
+		awscache.RecordLookup(awscache.InstanceTypes, true)
 		return cached.(map[string]sets.Set[string]), nil
 	}
+	awscache.RecordLookup(awscache.InstanceTypes, false)
 
 	// Get offerings from EC2
 	instanceTypeOfferings := map[string]sets.Set[string]{}
-	if err := p.ec2api.DescribeInstanceTypeOfferingsPagesWithContext(ctx, &ec2.DescribeInstanceTypeOfferingsInput{LocationType: aws.String("availability-zone")},
-		func(output *ec2.DescribeInstanceTypeOfferingsOutput, lastPage bool) bool {
-			for _, offering := range output.InstanceTypeOfferings {
-				if _, ok := instanceTypeOfferings[aws.StringValue(offering.InstanceType)]; !ok {
-					instanceTypeOfferings[aws.StringValue(offering.InstanceType)] = sets.New[string]()
-				}
-				instanceTypeOfferings[aws.StringValue(offering.InstanceType)].Insert(aws.StringValue(offering.Location))
+	paginator := ec2.NewDescribeInstanceTypeOfferingsPaginator(p.ec2api, &ec2.DescribeInstanceTypeOfferingsInput{LocationType: "availability-zone"})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing instance type zone offerings, %w", err)
+		}
+		for _, offering := range output.InstanceTypeOfferings {
+			if _, ok := instanceTypeOfferings[string(offering.InstanceType)]; !ok {
+				instanceTypeOfferings[string(offering.InstanceType)] = sets.New[string]()
 			}
-			return true
-		}); err != nil {
-		return nil, fmt.Errorf("describing instance type zone offerings, %w", err)
+			instanceTypeOfferings[string(offering.InstanceType)].Insert(aws.ToString(offering.Location))
+		}
 	}
 	if p.cm.HasChanged("instance-type-offering", instanceTypeOfferings) {
 		// Only update instanceTypesSeqNun with the instance type offerings  have been changed
@@ -246,11 +273,48 @@ func (p *Provider) getInstanceTypeOfferings(ctx context.Context) (map[string]set
 		logging.FromContext(ctx).With("instance-type-count", len(instanceTypeOfferings)).Debugf("discovered offerings for instance types")
 	}
 	p.cache.SetDefault(InstanceTypeOfferingsCacheKey, instanceTypeOfferings)
+	awscache.RecordRefresh(awscache.InstanceTypes)
 	return instanceTypeOfferings, nil
 }
 
+// Snapshot returns the instance types and instance type offerings that are cached, which are nil if they aren't cached
+func (p *Provider) Snapshot() ([]*ec2types.InstanceTypeInfo, map[string]sets.Set[string]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var instanceTypes []*ec2types.InstanceTypeInfo
+	var instanceTypeOfferings map[string]sets.Set[string]
+	if cached, ok := p.cache.Get(InstanceTypesCacheKey); ok {
+		instanceTypes = cached.([]*ec2types.InstanceTypeInfo)
+	}
+	if cached, ok := p.cache.Get(InstanceTypeOfferingsCacheKey); ok {
+		instanceTypeOfferings = cached.(map[string]sets.Set[string])
+	}
+	return instanceTypes, instanceTypeOfferings
+}
+
+// Restore caches instance types and instance type offerings from a snapshot that were retrieved by a previous
+// controller, unless they are already cached. They are retrieved from EC2 again once they expire from the cache.
+func (p *Provider) Restore(ctx context.Context, instanceTypes []*ec2types.InstanceTypeInfo, instanceTypeOfferings map[string]sets.Set[string]) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.cache.Get(InstanceTypesCacheKey); !ok && len(instanceTypes) > 0 {
+		if p.cm.HasChanged("instance-types", instanceTypes) {
+			atomic.AddUint64(&p.instanceTypesSeqNum, 1)
+		}
+		p.cache.SetDefault(InstanceTypesCacheKey, instanceTypes)
+		logging.FromContext(ctx).With("count", len(instanceTypes)).Debugf("restored instance types")
+	}
+	if _, ok := p.cache.Get(InstanceTypeOfferingsCacheKey); !ok && len(instanceTypeOfferings) > 0 {
+		if p.cm.HasChanged("instance-type-offering", instanceTypeOfferings) {
+			atomic.AddUint64(&p.instanceTypeOfferingsSeqNum, 1)
+		}
+		p.cache.SetDefault(InstanceTypeOfferingsCacheKey, instanceTypeOfferings)
+		logging.FromContext(ctx).With("instance-type-count", len(instanceTypeOfferings)).Debugf("restored offerings for instance types")
+	}
+}
+
 // GetInstanceTypes retrieves all instance types from the ec2 DescribeInstanceTypes API using some opinionated filters
-func (p *Provider) GetInstanceTypes(ctx context.Context) ([]*ec2.InstanceTypeInfo, error) {
+func (p *Provider) GetInstanceTypes(ctx context.Context) ([]*ec2types.InstanceTypeInfo, error) {
 	// DO NOT REMOVE THIS LOCK ----------------------------------------------------------------------------
 	// We lock here so that multiple callers to GetInstanceTypes do not result in cache misses and multiple
 	// calls to EC2 when we could have just made one call. This lock is here because multiple callers to EC2 result
@@ -260,25 +324,29 @@ func (p *Provider) GetInstanceTypes(ctx context.Context) ([]*ec2.InstanceTypeInf
 	defer p.mu.Unlock()
 
 	if cached, ok := p.cache.Get(InstanceTypesCacheKey); ok {
-		return cached.([]*ec2.InstanceTypeInfo), nil
+		awscache.RecordLookup(awscache.InstanceTypes, true)
+		return cached.([]*ec2types.InstanceTypeInfo), nil
 	}
-	var instanceTypes []*ec2.InstanceTypeInfo
-	if err := p.ec2api.DescribeInstanceTypesPagesWithContext(ctx, &ec2.DescribeInstanceTypesInput{
-		Filters: []*ec2.Filter{
+	awscache.RecordLookup(awscache.InstanceTypes, false)
+	var instanceTypes []*ec2types.InstanceTypeInfo
+	paginator := ec2.NewDescribeInstanceTypesPaginator(p.ec2api, &ec2.DescribeInstanceTypesInput{
+		Filters: []ec2types.Filter{
 			{
 				Name:   aws.String("supported-virtualization-type"),
-				Values: []*string{aws.String("hvm")},
+				Values: []string{"hvm"},
 			},
 			{
 				Name:   aws.String("processor-info.supported-architecture"),
-				Values: aws.StringSlice([]string{"x86_64", "arm64"}),
+				Values: []string{"x86_64", "arm64"},
 			},
 		},
-	}, func(page *ec2.DescribeInstanceTypesOutput, lastPage bool) bool {
-		instanceTypes = append(instanceTypes, page.InstanceTypes...)
-		return true
-	}); err != nil {
-		return nil, fmt.Errorf("fetching instance types using ec2.DescribeInstanceTypes, %w", err)
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("fetching instance types using ec2.DescribeInstanceTypes, %w", err)
+		}
+		instanceTypes = append(instanceTypes, lo.ToSlicePtr(page.InstanceTypes)...)
 	}
 	if p.cm.HasChanged("instance-types", instanceTypes) {
 		// Only update instanceTypesSeqNun with the instance types have been changed
@@ -288,5 +356,6 @@ func (p *Provider) GetInstanceTypes(ctx context.Context) ([]*ec2.InstanceTypeInf
 			"count", len(instanceTypes)).Debugf("discovered instance types")
 	}
 	p.cache.SetDefault(InstanceTypesCacheKey, instanceTypes)
+	awscache.RecordRefresh(awscache.InstanceTypes)
 	return instanceTypes, nil
 }
@@ -24,13 +24,15 @@ import (
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/record"
 	clock "k8s.io/utils/clock/testing"
@@ -73,13 +75,13 @@ func TestAWS(t *testing.T) {
 }
 
 var _ = BeforeSuite(func() {
-	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	ctx = options.ToContext(ctx, test.Options())
 	awsEnv = test.NewEnvironment(ctx, env)
 	fakeClock = &clock.FakeClock{}
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.PricingProvider)
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster)
 })
@@ -105,7 +107,15 @@ var _ = Describe("InstanceTypes", func() {
 	var nodeClass, windowsNodeClass *v1beta1.EC2NodeClass
 	var nodePool, windowsNodePool *corev1beta1.NodePool
 	BeforeEach(func() {
-		nodeClass = test.EC2NodeClass()
+		nodeClass = test.EC2NodeClass(v1beta1.EC2NodeClass{
+			Status: v1beta1.EC2NodeClassStatus{
+				Subnets: []v1beta1.Subnet{
+					{ID: "subnet-test1", Zone: "test-zone-1a", ZoneID: "testzone1a"},
+					{ID: "subnet-test2", Zone: "test-zone-1b", ZoneID: "testzone1b"},
+					{ID: "subnet-test3", Zone: "test-zone-1c", ZoneID: "testzone1c"},
+				},
+			},
+		})
 		nodePool = coretest.NodePool(corev1beta1.NodePool{
 			Spec: corev1beta1.NodePoolSpec{
 				Template: corev1beta1.NodeClaimTemplate{
@@ -130,6 +140,7 @@ var _ = Describe("InstanceTypes", func() {
 			Spec: v1beta1.EC2NodeClassSpec{
 				AMIFamily: &v1beta1.AMIFamilyWindows2022,
 			},
+			Status: *nodeClass.Status.DeepCopy(),
 		})
 		windowsNodePool = coretest.NodePool(corev1beta1.NodePool{
 			Spec: corev1beta1.NodePoolSpec{
@@ -161,6 +172,7 @@ var _ = Describe("InstanceTypes", func() {
 			corev1beta1.NodePoolLabelKey:     nodePool.Name,
 			v1.LabelTopologyRegion:           fake.DefaultRegion,
 			v1.LabelTopologyZone:             "test-zone-1a",
+			v1beta1.LabelTopologyZoneID:      "testzone1a",
 			v1.LabelInstanceTypeStable:       "g4dn.8xlarge",
 			v1.LabelOSStable:                 "linux",
 			v1.LabelArchStable:               "amd64",
@@ -214,6 +226,7 @@ var _ = Describe("InstanceTypes", func() {
 			corev1beta1.NodePoolLabelKey:     nodePool.Name,
 			v1.LabelTopologyRegion:           fake.DefaultRegion,
 			v1.LabelTopologyZone:             "test-zone-1a",
+			v1beta1.LabelTopologyZoneID:      "testzone1a",
 			v1.LabelInstanceTypeStable:       "g4dn.8xlarge",
 			v1.LabelOSStable:                 "linux",
 			v1.LabelArchStable:               "amd64",
@@ -265,6 +278,7 @@ var _ = Describe("InstanceTypes", func() {
 			corev1beta1.NodePoolLabelKey:     nodePool.Name,
 			v1.LabelTopologyRegion:           fake.DefaultRegion,
 			v1.LabelTopologyZone:             "test-zone-1a",
+			v1beta1.LabelTopologyZoneID:      "testzone1a",
 			v1.LabelInstanceTypeStable:       "inf1.2xlarge",
 			v1.LabelOSStable:                 "linux",
 			v1.LabelArchStable:               "amd64",
@@ -307,6 +321,65 @@ var _ = Describe("InstanceTypes", func() {
 		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	Context("Zone IDs", func() {
+		It("should launch instances in the zone of the selected zone ID", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			pod := coretest.UnschedulablePod(coretest.PodOptions{NodeSelector: map[string]string{v1beta1.LabelTopologyZoneID: "testzone1b"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelTopologyZoneID, "testzone1b"))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1b"))
+
+			Expect(awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Len()).To(Equal(1))
+			createFleetInput := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
+			for _, ltc := range createFleetInput.LaunchTemplateConfigs {
+				for _, override := range ltc.Overrides {
+					Expect(aws.ToString(override.AvailabilityZone)).To(Equal("test-zone-1b"))
+				}
+			}
+		})
+		It("should spread pods across zone IDs", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			labels := map[string]string{"app": "spread"}
+			pods := coretest.UnschedulablePods(coretest.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				TopologySpreadConstraints: []v1.TopologySpreadConstraint{{
+					MaxSkew:           1,
+					TopologyKey:       v1beta1.LabelTopologyZoneID,
+					WhenUnsatisfiable: v1.DoNotSchedule,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+				}},
+			}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			zoneIDs := sets.New[string]()
+			for _, pod := range pods {
+				node := ExpectScheduled(ctx, env.Client, pod)
+				zoneIDs.Insert(node.Labels[v1beta1.LabelTopologyZoneID])
+			}
+			Expect(sets.List(zoneIDs)).To(ConsistOf("testzone1a", "testzone1b", "testzone1c"))
+		})
+		It("should only require the zone IDs of the zones that the instance type is offered in", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+			instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, nodePool)
+			Expect(err).ToNot(HaveOccurred())
+			for _, it := range instanceTypes {
+				zones := it.Requirements.Get(v1.LabelTopologyZone).Values()
+				zoneIDs := lo.Values(lo.PickByKeys(nodeClass.ZoneIDs(), zones))
+				Expect(it.Requirements.Get(v1beta1.LabelTopologyZoneID).Values()).To(ConsistOf(zoneIDs))
+			}
+		})
+		It("should not require zone IDs when the zone IDs of the EC2NodeClass are unknown", func() {
+			info, ok := lo.Find(awsEnv.EC2API.DescribeInstanceTypesOutput.Clone().InstanceTypes, func(info ec2types.InstanceTypeInfo) bool {
+				return info.InstanceType == "m5.large"
+			})
+			Expect(ok).To(BeTrue())
+			offerings := corecloudprovider.Offerings{{Zone: "test-zone-1a", CapacityType: corev1beta1.CapacityTypeOnDemand, Price: 1, Available: true}}
+			it := instancetype.NewInstanceType(ctx, &info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, offerings)
+			Expect(it.Requirements.Get(v1beta1.LabelTopologyZoneID).Values()).To(ConsistOf("testzone1a"))
+			it = instancetype.NewInstanceType(ctx, &info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, test.EC2NodeClass(), offerings)
+			Expect(it.Requirements.Get(v1beta1.LabelTopologyZoneID).Operator()).To(Equal(v1.NodeSelectorOpDoesNotExist))
+		})
+	})
 	It("should not launch AWS Pod ENI on a t3", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 		pod := coretest.UnschedulablePod(coretest.PodOptions{
@@ -361,7 +434,7 @@ var _ = Describe("InstanceTypes", func() {
 
 		Expect(call.LaunchTemplateConfigs[0].Overrides).To(HaveLen(60))
 		for _, override := range call.LaunchTemplateConfigs[0].Overrides {
-			Expect(expected.Has(aws.StringValue(override.InstanceType))).To(BeTrue(), fmt.Sprintf("expected %s to exist in set", aws.StringValue(override.InstanceType)))
+			Expect(expected.Has(string(override.InstanceType))).To(BeTrue(), fmt.Sprintf("expected %s to exist in set", string(override.InstanceType)))
 		}
 	})
 	It("should order the instance types by price and only consider the spot types that are cheaper than the cheapest on-demand", func() {
@@ -419,7 +492,7 @@ var _ = Describe("InstanceTypes", func() {
 		// find the cheapest OD price that works
 		cheapestODPrice := math.MaxFloat64
 		for _, override := range call.LaunchTemplateConfigs[0].Overrides {
-			odPrice, ok := awsEnv.PricingProvider.OnDemandPrice(*override.InstanceType)
+			odPrice, ok := awsEnv.PricingProvider.OnDemandPrice(string(override.InstanceType))
 			Expect(ok).To(BeTrue())
 			if odPrice < cheapestODPrice {
 				cheapestODPrice = odPrice
@@ -427,7 +500,7 @@ var _ = Describe("InstanceTypes", func() {
 		}
 		// and our spot prices should be cheaper than the OD price
 		for _, override := range call.LaunchTemplateConfigs[0].Overrides {
-			spotPrice, ok := awsEnv.PricingProvider.SpotPrice(*override.InstanceType, *override.AvailabilityZone)
+			spotPrice, ok := awsEnv.PricingProvider.SpotPrice(string(override.InstanceType), *override.AvailabilityZone)
 			Expect(ok).To(BeTrue())
 			Expect(spotPrice).To(BeNumerically("<", cheapestODPrice))
 		}
@@ -462,7 +535,7 @@ var _ = Describe("InstanceTypes", func() {
 		var expensiveInstanceType bool
 		for _, ltc := range call.LaunchTemplateConfigs {
 			for _, ovr := range ltc.Overrides {
-				if strings.Contains(aws.StringValue(ovr.InstanceType), "metal") {
+				if strings.Contains(string(ovr.InstanceType), "metal") {
 					expensiveInstanceType = true
 				}
 			}
@@ -484,7 +557,7 @@ var _ = Describe("InstanceTypes", func() {
 		call := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
 		for _, ltc := range call.LaunchTemplateConfigs {
 			for _, ovr := range ltc.Overrides {
-				Expect(strings.Contains(aws.StringValue(ovr.InstanceType), "metal")).To(BeFalse())
+				Expect(strings.Contains(string(ovr.InstanceType), "metal")).To(BeFalse())
 			}
 		}
 	})
@@ -503,7 +576,7 @@ var _ = Describe("InstanceTypes", func() {
 		call := awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop()
 		for _, ltc := range call.LaunchTemplateConfigs {
 			for _, ovr := range ltc.Overrides {
-				Expect(strings.HasPrefix(aws.StringValue(ovr.InstanceType), "g")).To(BeFalse())
+				Expect(strings.HasPrefix(string(ovr.InstanceType), "g")).To(BeFalse())
 			}
 		}
 	})
@@ -756,7 +829,7 @@ var _ = Describe("InstanceTypes", func() {
 				Expect(ok).To(BeTrue())
 				Expect(metric).To(Not(BeNil()))
 				value := metric.GetGauge().Value
-				Expect(aws.Float64Value(value)).To(BeNumerically(">", 0))
+				Expect(aws.ToFloat64(value)).To(BeNumerically(">", 0))
 			}
 		})
 		It("should expose memory metrics for instance types", func() {
@@ -770,7 +843,7 @@ var _ = Describe("InstanceTypes", func() {
 				Expect(ok).To(BeTrue())
 				Expect(metric).To(Not(BeNil()))
 				value := metric.GetGauge().Value
-				Expect(aws.Float64Value(value)).To(BeNumerically(">", 0))
+				Expect(aws.ToFloat64(value)).To(BeNumerically(">", 0))
 			}
 		})
 		It("should expose availability metrics for instance types", func() {
@@ -787,7 +860,7 @@ var _ = Describe("InstanceTypes", func() {
 					Expect(ok).To(BeTrue())
 					Expect(metric).To(Not(BeNil()))
 					value := metric.GetGauge().Value
-					Expect(aws.Float64Value(value)).To(BeNumerically("==", lo.Ternary(of.Available, 1, 0)))
+					Expect(aws.ToFloat64(value)).To(BeNumerically("==", lo.Ternary(of.Available, 1, 0)))
 				}
 			}
 		})
@@ -805,7 +878,7 @@ var _ = Describe("InstanceTypes", func() {
 					Expect(ok).To(BeTrue())
 					Expect(metric).To(Not(BeNil()))
 					value := metric.GetGauge().Value
-					Expect(aws.Float64Value(value)).To(BeNumerically("==", of.Price))
+					Expect(aws.ToFloat64(value)).To(BeNumerically("==", of.Price))
 				}
 			}
 		})
@@ -825,7 +898,7 @@ var _ = Describe("InstanceTypes", func() {
 	})
 
 	Context("Overhead", func() {
-		var info *ec2.InstanceTypeInfo
+		var info *ec2types.InstanceTypeInfo
 		BeforeEach(func() {
 			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
 				ClusterName: lo.ToPtr("karpenter-cluster"),
@@ -834,8 +907,8 @@ var _ = Describe("InstanceTypes", func() {
 			var ok bool
 			instanceInfo, err := awsEnv.InstanceTypesProvider.GetInstanceTypes(ctx)
 			Expect(err).To(BeNil())
-			info, ok = lo.Find(instanceInfo, func(i *ec2.InstanceTypeInfo) bool {
-				return aws.StringValue(i.InstanceType) == "m5.xlarge"
+			info, ok = lo.Find(instanceInfo, func(i *ec2types.InstanceTypeInfo) bool {
+				return string(i.InstanceType) == "m5.xlarge"
 			})
 			Expect(ok).To(BeTrue())
 		})
@@ -1087,11 +1160,11 @@ var _ = Describe("InstanceTypes", func() {
 			instanceInfo, err := awsEnv.InstanceTypesProvider.GetInstanceTypes(ctx)
 			Expect(err).To(BeNil())
 			for _, info := range instanceInfo {
-				if *info.InstanceType == "t3.large" {
+				if string(info.InstanceType) == "t3.large" {
 					it := instancetype.NewInstanceType(ctx, info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
 					Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 35))
 				}
-				if *info.InstanceType == "m6idn.32xlarge" {
+				if string(info.InstanceType) == "m6idn.32xlarge" {
 					it := instancetype.NewInstanceType(ctx, info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
 					Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 345))
 				}
@@ -1126,8 +1199,8 @@ var _ = Describe("InstanceTypes", func() {
 
 			instanceInfo, err := awsEnv.InstanceTypesProvider.GetInstanceTypes(ctx)
 			Expect(err).To(BeNil())
-			t3Large, ok := lo.Find(instanceInfo, func(info *ec2.InstanceTypeInfo) bool {
-				return *info.InstanceType == "t3.large"
+			t3Large, ok := lo.Find(instanceInfo, func(info *ec2types.InstanceTypeInfo) bool {
+				return string(info.InstanceType) == "t3.large"
 			})
 			Expect(ok).To(Equal(true))
 			it := instancetype.NewInstanceType(ctx, t3Large, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
@@ -1146,8 +1219,8 @@ var _ = Describe("InstanceTypes", func() {
 
 			instanceInfo, err := awsEnv.InstanceTypesProvider.GetInstanceTypes(ctx)
 			Expect(err).To(BeNil())
-			t3Large, ok := lo.Find(instanceInfo, func(info *ec2.InstanceTypeInfo) bool {
-				return *info.InstanceType == "t3.large"
+			t3Large, ok := lo.Find(instanceInfo, func(info *ec2types.InstanceTypeInfo) bool {
+				return string(info.InstanceType) == "t3.large"
 			})
 			Expect(ok).To(Equal(true))
 			it := instancetype.NewInstanceType(ctx, t3Large, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
@@ -1168,7 +1241,7 @@ var _ = Describe("InstanceTypes", func() {
 			}
 			for _, info := range instanceInfo {
 				it := instancetype.NewInstanceType(ctx, info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
-				Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", ptr.Int64Value(info.VCpuInfo.DefaultVCpus)))
+				Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", int64(aws.ToInt32(info.VCpuInfo.DefaultVCpus))))
 			}
 		})
 		It("should take the minimum of pods-per-core and max-pods", func() {
@@ -1180,7 +1253,7 @@ var _ = Describe("InstanceTypes", func() {
 			}
 			for _, info := range instanceInfo {
 				it := instancetype.NewInstanceType(ctx, info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
-				Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", lo.Min([]int64{20, ptr.Int64Value(info.VCpuInfo.DefaultVCpus) * 4})))
+				Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", lo.Min([]int64{20, int64(aws.ToInt32(info.VCpuInfo.DefaultVCpus)) * 4})))
 			}
 		})
 		It("should ignore pods-per-core when using Bottlerocket AMI", func() {
@@ -1203,11 +1276,11 @@ var _ = Describe("InstanceTypes", func() {
 				PodsPerCore: ptr.Int32(0),
 			}
 			for _, info := range instanceInfo {
-				if *info.InstanceType == "t3.large" {
+				if string(info.InstanceType) == "t3.large" {
 					it := instancetype.NewInstanceType(ctx, info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
 					Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 35))
 				}
-				if *info.InstanceType == "m6idn.32xlarge" {
+				if string(info.InstanceType) == "m6idn.32xlarge" {
 					it := instancetype.NewInstanceType(ctx, info, nodePool.Spec.Template.Spec.Kubelet, fake.DefaultRegion, nodeClass, nil)
 					Expect(it.Capacity.Pods().Value()).To(BeNumerically("==", 345))
 				}
@@ -1215,7 +1288,7 @@ var _ = Describe("InstanceTypes", func() {
 		})
 		It("shouldn't report more resources than are actually available on instances", func() {
 			awsEnv.EC2API.DescribeSubnetsOutput.Set(&ec2.DescribeSubnetsOutput{
-				Subnets: []*ec2.Subnet{
+				Subnets: []ec2types.Subnet{
 					{
 						AvailabilityZone: aws.String("us-west-2a"),
 						SubnetId:         aws.String("subnet-12345"),
@@ -1223,31 +1296,31 @@ var _ = Describe("InstanceTypes", func() {
 				},
 			})
 			awsEnv.EC2API.DescribeInstanceTypeOfferingsOutput.Set(&ec2.DescribeInstanceTypeOfferingsOutput{
-				InstanceTypeOfferings: []*ec2.InstanceTypeOffering{
+				InstanceTypeOfferings: []ec2types.InstanceTypeOffering{
 					{
-						InstanceType: aws.String("t4g.small"),
+						InstanceType: "t4g.small",
 						Location:     aws.String("us-west-2a"),
 					},
 					{
-						InstanceType: aws.String("t4g.medium"),
+						InstanceType: "t4g.medium",
 						Location:     aws.String("us-west-2a"),
 					},
 					{
-						InstanceType: aws.String("t4g.xlarge"),
+						InstanceType: "t4g.xlarge",
 						Location:     aws.String("us-west-2a"),
 					},
 					{
-						InstanceType: aws.String("m5.large"),
+						InstanceType: "m5.large",
 						Location:     aws.String("us-west-2a"),
 					},
 				},
 			})
 			awsEnv.EC2API.DescribeInstanceTypesOutput.Set(&ec2.DescribeInstanceTypesOutput{
-				InstanceTypes: []*ec2.InstanceTypeInfo{
-					{InstanceType: aws.String("t4g.small")},
-					{InstanceType: aws.String("t4g.medium")},
-					{InstanceType: aws.String("t4g.xlarge")},
-					{InstanceType: aws.String("m5.large")},
+				InstanceTypes: []ec2types.InstanceTypeInfo{
+					{InstanceType: "t4g.small"},
+					{InstanceType: "t4g.medium"},
+					{InstanceType: "t4g.xlarge"},
+					{InstanceType: "m5.large"},
 				},
 			})
 
@@ -1443,12 +1516,11 @@ var _ = Describe("InstanceTypes", func() {
 				HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-1b")))
 		})
 		It("should launch on-demand capacity if flexible to both spot and on-demand, but spot is unavailable", func() {
-			Expect(awsEnv.EC2API.DescribeInstanceTypesPagesWithContext(ctx, &ec2.DescribeInstanceTypesInput{}, func(dito *ec2.DescribeInstanceTypesOutput, b bool) bool {
-				for _, it := range dito.InstanceTypes {
-					awsEnv.EC2API.InsufficientCapacityPools.Add(fake.CapacityPool{CapacityType: corev1beta1.CapacityTypeSpot, InstanceType: aws.StringValue(it.InstanceType), Zone: "test-zone-1a"})
-				}
-				return true
-			})).To(Succeed())
+			output, err := awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{})
+			Expect(err).To(Succeed())
+			for _, it := range output.InstanceTypes {
+				awsEnv.EC2API.InsufficientCapacityPools.Add(fake.CapacityPool{CapacityType: corev1beta1.CapacityTypeSpot, InstanceType: string(it.InstanceType), Zone: "test-zone-1a"})
+			}
 			nodePool.Spec.Template.Spec.Requirements = []corev1beta1.NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: corev1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand}}},
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1a"}}},
@@ -1538,10 +1610,10 @@ var _ = Describe("InstanceTypes", func() {
 		It("should fail to launch capacity when there is no zonal availability for spot", func() {
 			now := time.Now()
 			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-				SpotPriceHistory: []*ec2.SpotPrice{
+				SpotPriceHistory: []ec2types.SpotPrice{
 					{
 						AvailabilityZone: aws.String("test-zone-1a"),
-						InstanceType:     aws.String("m5.large"),
+						InstanceType:     "m5.large",
 						SpotPrice:        aws.String("0.004"),
 						Timestamp:        &now,
 					},
@@ -1564,10 +1636,10 @@ var _ = Describe("InstanceTypes", func() {
 		It("should succeed to launch spot instance when zonal availability exists", func() {
 			now := time.Now()
 			awsEnv.EC2API.DescribeSpotPriceHistoryOutput.Set(&ec2.DescribeSpotPriceHistoryOutput{
-				SpotPriceHistory: []*ec2.SpotPrice{
+				SpotPriceHistory: []ec2types.SpotPrice{
 					{
 						AvailabilityZone: aws.String("test-zone-1a"),
-						InstanceType:     aws.String("m5.large"),
+						InstanceType:     "m5.large",
 						SpotPrice:        aws.String("0.004"),
 						Timestamp:        &now,
 					},
@@ -1690,18 +1762,18 @@ var _ = Describe("InstanceTypes", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpEndpoint).To(Equal(ec2.LaunchTemplateInstanceMetadataEndpointStateEnabled))
-				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpProtocolIpv6).To(Equal(ec2.LaunchTemplateInstanceMetadataProtocolIpv6Disabled))
+				Expect(ltInput.LaunchTemplateData.MetadataOptions.HttpEndpoint).To(Equal(ec2types.LaunchTemplateInstanceMetadataEndpointStateEnabled))
+				Expect(ltInput.LaunchTemplateData.MetadataOptions.HttpProtocolIpv6).To(Equal(ec2types.LaunchTemplateInstanceMetadataProtocolIpv6Disabled))
 				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit).To(Equal(int64(2)))
-				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpTokens).To(Equal(ec2.LaunchTemplateHttpTokensStateRequired))
+				Expect(ltInput.LaunchTemplateData.MetadataOptions.HttpTokens).To(Equal(ec2types.LaunchTemplateHttpTokensStateRequired))
 			})
 		})
 		It("should set metadata options on generated launch template from nodePool configuration", func() {
 			nodeClass.Spec.MetadataOptions = &v1beta1.MetadataOptions{
-				HTTPEndpoint:            aws.String(ec2.LaunchTemplateInstanceMetadataEndpointStateDisabled),
-				HTTPProtocolIPv6:        aws.String(ec2.LaunchTemplateInstanceMetadataProtocolIpv6Enabled),
+				HTTPEndpoint:            aws.String(string(ec2types.LaunchTemplateInstanceMetadataEndpointStateDisabled)),
+				HTTPProtocolIPv6:        aws.String(string(ec2types.LaunchTemplateInstanceMetadataProtocolIpv6Enabled)),
 				HTTPPutResponseHopLimit: aws.Int64(1),
-				HTTPTokens:              aws.String(ec2.LaunchTemplateHttpTokensStateOptional),
+				HTTPTokens:              aws.String(string(ec2types.LaunchTemplateHttpTokensStateOptional)),
 			}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 			pod := coretest.UnschedulablePod()
@@ -1709,10 +1781,10 @@ var _ = Describe("InstanceTypes", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpEndpoint).To(Equal(ec2.LaunchTemplateInstanceMetadataEndpointStateDisabled))
-				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpProtocolIpv6).To(Equal(ec2.LaunchTemplateInstanceMetadataProtocolIpv6Enabled))
+				Expect(ltInput.LaunchTemplateData.MetadataOptions.HttpEndpoint).To(Equal(ec2types.LaunchTemplateInstanceMetadataEndpointStateDisabled))
+				Expect(ltInput.LaunchTemplateData.MetadataOptions.HttpProtocolIpv6).To(Equal(ec2types.LaunchTemplateInstanceMetadataProtocolIpv6Enabled))
 				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpPutResponseHopLimit).To(Equal(int64(1)))
-				Expect(*ltInput.LaunchTemplateData.MetadataOptions.HttpTokens).To(Equal(ec2.LaunchTemplateHttpTokensStateOptional))
+				Expect(ltInput.LaunchTemplateData.MetadataOptions.HttpTokens).To(Equal(ec2types.LaunchTemplateHttpTokensStateOptional))
 			})
 		})
 	})
@@ -1741,9 +1813,9 @@ func generateSpotPricing(cp *cloudprovider.CloudProvider, nodePool *corev1beta1.
 				continue
 			}
 			spotPrice := fmt.Sprintf("%0.3f", onDemandPrice*0.5)
-			rsp.SpotPriceHistory = append(rsp.SpotPriceHistory, &ec2.SpotPrice{
+			rsp.SpotPriceHistory = append(rsp.SpotPriceHistory, ec2types.SpotPrice{
 				AvailabilityZone: &o.Zone,
-				InstanceType:     &instanceType.Name,
+				InstanceType:     ec2types.InstanceType(instanceType.Name),
 				SpotPrice:        &spotPrice,
 				Timestamp:        &t,
 			})
@@ -22,8 +22,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -49,13 +49,13 @@ var (
 	instanceTypeScheme = regexp.MustCompile(`(^[a-z]+)(\-[0-9]+tb)?([0-9]+).*\.`)
 )
 
-func NewInstanceType(ctx context.Context, info *ec2.InstanceTypeInfo, kc *corev1beta1.KubeletConfiguration,
+func NewInstanceType(ctx context.Context, info *ec2types.InstanceTypeInfo, kc *corev1beta1.KubeletConfiguration,
 	region string, nodeClass *v1beta1.EC2NodeClass, offerings cloudprovider.Offerings) *cloudprovider.InstanceType {
 
 	amiFamily := amifamily.GetAMIFamily(nodeClass.Spec.AMIFamily, &amifamily.Options{})
 	it := &cloudprovider.InstanceType{
-		Name:         aws.StringValue(info.InstanceType),
-		Requirements: computeRequirements(info, offerings, region, amiFamily),
+		Name:         string(info.InstanceType),
+		Requirements: computeRequirements(info, offerings, region, amiFamily, nodeClass.ZoneIDs()),
 		Offerings:    offerings,
 		Capacity:     computeCapacity(ctx, info, amiFamily, nodeClass, kc),
 		Overhead: &cloudprovider.InstanceTypeOverhead{
@@ -71,21 +71,23 @@ func NewInstanceType(ctx context.Context, info *ec2.InstanceTypeInfo, kc *corev1
 }
 
 //nolint:gocyclo
-func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Offerings, region string, amiFamily amifamily.AMIFamily) scheduling.Requirements {
+func computeRequirements(info *ec2types.InstanceTypeInfo, offerings cloudprovider.Offerings, region string, amiFamily amifamily.AMIFamily,
+	zoneIDs map[string]string) scheduling.Requirements {
 	requirements := scheduling.NewRequirements(
 		// Well Known Upstream
-		scheduling.NewRequirement(v1.LabelInstanceTypeStable, v1.NodeSelectorOpIn, aws.StringValue(info.InstanceType)),
+		scheduling.NewRequirement(v1.LabelInstanceTypeStable, v1.NodeSelectorOpIn, string(info.InstanceType)),
 		scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, getArchitecture(info)),
 		scheduling.NewRequirement(v1.LabelOSStable, v1.NodeSelectorOpIn, getOS(info, amiFamily)...),
 		scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, lo.Map(offerings.Available(), func(o cloudprovider.Offering, _ int) string { return o.Zone })...),
 		scheduling.NewRequirement(v1.LabelTopologyRegion, v1.NodeSelectorOpIn, region),
+		scheduling.NewRequirement(v1beta1.LabelTopologyZoneID, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1.LabelWindowsBuild, v1.NodeSelectorOpDoesNotExist),
 		// Well Known to Karpenter
 		scheduling.NewRequirement(corev1beta1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, lo.Map(offerings.Available(), func(o cloudprovider.Offering, _ int) string { return o.CapacityType })...),
 		// Well Known to AWS
-		scheduling.NewRequirement(v1beta1.LabelInstanceCPU, v1.NodeSelectorOpIn, fmt.Sprint(aws.Int64Value(info.VCpuInfo.DefaultVCpus))),
+		scheduling.NewRequirement(v1beta1.LabelInstanceCPU, v1.NodeSelectorOpIn, fmt.Sprint(aws.ToInt32(info.VCpuInfo.DefaultVCpus))),
 		scheduling.NewRequirement(v1beta1.LabelInstanceCPUManufacturer, v1.NodeSelectorOpDoesNotExist),
-		scheduling.NewRequirement(v1beta1.LabelInstanceMemory, v1.NodeSelectorOpIn, fmt.Sprint(aws.Int64Value(info.MemoryInfo.SizeInMiB))),
+		scheduling.NewRequirement(v1beta1.LabelInstanceMemory, v1.NodeSelectorOpIn, fmt.Sprint(aws.ToInt64(info.MemoryInfo.SizeInMiB))),
 		scheduling.NewRequirement(v1beta1.LabelInstanceNetworkBandwidth, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceCategory, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceFamily, v1.NodeSelectorOpDoesNotExist),
@@ -99,41 +101,47 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 		scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorName, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorManufacturer, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(v1beta1.LabelInstanceAcceleratorCount, v1.NodeSelectorOpDoesNotExist),
-		scheduling.NewRequirement(v1beta1.LabelInstanceHypervisor, v1.NodeSelectorOpIn, aws.StringValue(info.Hypervisor)),
-		scheduling.NewRequirement(v1beta1.LabelInstanceEncryptionInTransitSupported, v1.NodeSelectorOpIn, fmt.Sprint(aws.BoolValue(info.NetworkInfo.EncryptionInTransitSupported))),
+		scheduling.NewRequirement(v1beta1.LabelInstanceHypervisor, v1.NodeSelectorOpIn, string(info.Hypervisor)),
+		scheduling.NewRequirement(v1beta1.LabelInstanceEncryptionInTransitSupported, v1.NodeSelectorOpIn, fmt.Sprint(aws.ToBool(info.NetworkInfo.EncryptionInTransitSupported))),
 	)
+	// Zone IDs of the zones that the instance type is offered in, which are only known once the subnets were resolved
+	for _, offering := range offerings.Available() {
+		if zoneID, ok := zoneIDs[offering.Zone]; ok {
+			requirements.Get(v1beta1.LabelTopologyZoneID).Insert(zoneID)
+		}
+	}
 	// Instance Type Labels
-	instanceFamilyParts := instanceTypeScheme.FindStringSubmatch(aws.StringValue(info.InstanceType))
+	instanceFamilyParts := instanceTypeScheme.FindStringSubmatch(string(info.InstanceType))
 	if len(instanceFamilyParts) == 4 {
 		requirements[v1beta1.LabelInstanceCategory].Insert(instanceFamilyParts[1])
 		requirements[v1beta1.LabelInstanceGeneration].Insert(instanceFamilyParts[3])
 	}
-	instanceTypeParts := strings.Split(aws.StringValue(info.InstanceType), ".")
+	instanceTypeParts := strings.Split(string(info.InstanceType), ".")
 	if len(instanceTypeParts) == 2 {
 		requirements.Get(v1beta1.LabelInstanceFamily).Insert(instanceTypeParts[0])
 		requirements.Get(v1beta1.LabelInstanceSize).Insert(instanceTypeParts[1])
 	}
-	if info.InstanceStorageInfo != nil && aws.StringValue(info.InstanceStorageInfo.NvmeSupport) != ec2.EphemeralNvmeSupportUnsupported {
-		requirements[v1beta1.LabelInstanceLocalNVME].Insert(fmt.Sprint(aws.Int64Value(info.InstanceStorageInfo.TotalSizeInGB)))
+	if info.InstanceStorageInfo != nil && info.InstanceStorageInfo.NvmeSupport != ec2types.EphemeralNvmeSupportUnsupported {
+		requirements[v1beta1.LabelInstanceLocalNVME].Insert(fmt.Sprint(aws.ToInt64(info.InstanceStorageInfo.TotalSizeInGB)))
 	}
 	// Network bandwidth
-	if bandwidth, ok := InstanceTypeBandwidthMegabits[aws.StringValue(info.InstanceType)]; ok {
+	if bandwidth, ok := InstanceTypeBandwidthMegabits[string(info.InstanceType)]; ok {
 		requirements[v1beta1.LabelInstanceNetworkBandwidth].Insert(fmt.Sprint(bandwidth))
 	}
 	// GPU Labels
 	if info.GpuInfo != nil && len(info.GpuInfo.Gpus) == 1 {
 		gpu := info.GpuInfo.Gpus[0]
-		requirements.Get(v1beta1.LabelInstanceGPUName).Insert(lowerKabobCase(aws.StringValue(gpu.Name)))
-		requirements.Get(v1beta1.LabelInstanceGPUManufacturer).Insert(lowerKabobCase(aws.StringValue(gpu.Manufacturer)))
-		requirements.Get(v1beta1.LabelInstanceGPUCount).Insert(fmt.Sprint(aws.Int64Value(gpu.Count)))
-		requirements.Get(v1beta1.LabelInstanceGPUMemory).Insert(fmt.Sprint(aws.Int64Value(gpu.MemoryInfo.SizeInMiB)))
+		requirements.Get(v1beta1.LabelInstanceGPUName).Insert(lowerKabobCase(aws.ToString(gpu.Name)))
+		requirements.Get(v1beta1.LabelInstanceGPUManufacturer).Insert(lowerKabobCase(aws.ToString(gpu.Manufacturer)))
+		requirements.Get(v1beta1.LabelInstanceGPUCount).Insert(fmt.Sprint(aws.ToInt32(gpu.Count)))
+		requirements.Get(v1beta1.LabelInstanceGPUMemory).Insert(fmt.Sprint(aws.ToInt32(gpu.MemoryInfo.SizeInMiB)))
 	}
 	// Accelerators
 	if info.InferenceAcceleratorInfo != nil && len(info.InferenceAcceleratorInfo.Accelerators) == 1 {
 		accelerator := info.InferenceAcceleratorInfo.Accelerators[0]
-		requirements.Get(v1beta1.LabelInstanceAcceleratorName).Insert(lowerKabobCase(aws.StringValue(accelerator.Name)))
-		requirements.Get(v1beta1.LabelInstanceAcceleratorManufacturer).Insert(lowerKabobCase(aws.StringValue(accelerator.Manufacturer)))
-		requirements.Get(v1beta1.LabelInstanceAcceleratorCount).Insert(fmt.Sprint(aws.Int64Value(accelerator.Count)))
+		requirements.Get(v1beta1.LabelInstanceAcceleratorName).Insert(lowerKabobCase(aws.ToString(accelerator.Name)))
+		requirements.Get(v1beta1.LabelInstanceAcceleratorManufacturer).Insert(lowerKabobCase(aws.ToString(accelerator.Manufacturer)))
+		requirements.Get(v1beta1.LabelInstanceAcceleratorCount).Insert(fmt.Sprint(aws.ToInt32(accelerator.Count)))
 	}
 	// Windows Build Version Labels
 	if family, ok := amiFamily.(*amifamily.Windows); ok {
@@ -142,19 +150,19 @@ func computeRequirements(info *ec2.InstanceTypeInfo, offerings cloudprovider.Off
 	// Trn1 Accelerators
 	// TODO: remove function once DescribeInstanceTypes contains the accelerator data
 	// Values found from: https://aws.amazon.com/ec2/instance-types/trn1/
-	if strings.HasPrefix(*info.InstanceType, "trn1") {
+	if strings.HasPrefix(string(info.InstanceType), "trn1") {
 		requirements.Get(v1beta1.LabelInstanceAcceleratorName).Insert(lowerKabobCase("Inferentia"))
 		requirements.Get(v1beta1.LabelInstanceAcceleratorManufacturer).Insert(lowerKabobCase("AWS"))
 		requirements.Get(v1beta1.LabelInstanceAcceleratorCount).Insert(fmt.Sprint(awsNeurons(info)))
 	}
 	// CPU Manufacturer, valid options: aws, intel, amd
 	if info.ProcessorInfo != nil {
-		requirements.Get(v1beta1.LabelInstanceCPUManufacturer).Insert(lowerKabobCase(aws.StringValue(info.ProcessorInfo.Manufacturer)))
+		requirements.Get(v1beta1.LabelInstanceCPUManufacturer).Insert(lowerKabobCase(aws.ToString(info.ProcessorInfo.Manufacturer)))
 	}
 	return requirements
 }
 
-func getOS(info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily) []string {
+func getOS(info *ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily) []string {
 	if _, ok := amiFamily.(*amifamily.Windows); ok {
 		if getArchitecture(info) == corev1beta1.ArchitectureAmd64 {
 			return []string{string(v1.Windows)}
@@ -164,16 +172,16 @@ func getOS(info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily) []string {
 	return []string{string(v1.Linux)}
 }
 
-func getArchitecture(info *ec2.InstanceTypeInfo) string {
+func getArchitecture(info *ec2types.InstanceTypeInfo) string {
 	for _, architecture := range info.ProcessorInfo.SupportedArchitectures {
-		if value, ok := v1beta1.AWSToKubeArchitectures[aws.StringValue(architecture)]; ok {
+		if value, ok := v1beta1.AWSToKubeArchitectures[string(architecture)]; ok {
 			return value
 		}
 	}
-	return fmt.Sprint(aws.StringValueSlice(info.ProcessorInfo.SupportedArchitectures)) // Unrecognized, but used for error printing
+	return fmt.Sprint(info.ProcessorInfo.SupportedArchitectures) // Unrecognized, but used for error printing
 }
 
-func computeCapacity(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily,
+func computeCapacity(ctx context.Context, info *ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily,
 	nodeClass *v1beta1.EC2NodeClass, kc *corev1beta1.KubeletConfiguration) v1.ResourceList {
 
 	resourceList := v1.ResourceList{
@@ -181,7 +189,7 @@ func computeCapacity(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily
 		v1.ResourceMemory:           *memory(ctx, info),
 		v1.ResourceEphemeralStorage: *ephemeralStorage(info, amiFamily, nodeClass),
 		v1.ResourcePods:             *pods(ctx, info, amiFamily, kc),
-		v1beta1.ResourceAWSPodENI:   *awsPodENI(aws.StringValue(info.InstanceType)),
+		v1beta1.ResourceAWSPodENI:   *awsPodENI(string(info.InstanceType)),
 		v1beta1.ResourceNVIDIAGPU:   *nvidiaGPUs(info),
 		v1beta1.ResourceAMDGPU:      *amdGPUs(info),
 		v1beta1.ResourceAWSNeuron:   *awsNeurons(info),
@@ -191,24 +199,24 @@ func computeCapacity(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily
 	return resourceList
 }
 
-func cpu(info *ec2.InstanceTypeInfo) *resource.Quantity {
+func cpu(info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	return resources.Quantity(fmt.Sprint(*info.VCpuInfo.DefaultVCpus))
 }
 
-func memory(ctx context.Context, info *ec2.InstanceTypeInfo) *resource.Quantity {
+func memory(ctx context.Context, info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	sizeInMib := *info.MemoryInfo.SizeInMiB
 	// Gravitons have an extra 64 MiB of cma reserved memory that we can't use
-	if len(info.ProcessorInfo.SupportedArchitectures) > 0 && *info.ProcessorInfo.SupportedArchitectures[0] == "arm64" {
+	if len(info.ProcessorInfo.SupportedArchitectures) > 0 && string(info.ProcessorInfo.SupportedArchitectures[0]) == "arm64" {
 		sizeInMib -= 64
 	}
 	mem := resources.Quantity(fmt.Sprintf("%dMi", sizeInMib))
 	// Account for VM overhead in calculation
-	mem.Sub(resource.MustParse(fmt.Sprintf("%dMi", int64(math.Ceil(float64(mem.Value())*options.FromContext(ctx).VMMemoryOverheadPercent/1024/1024)))))
+	mem.Sub(resource.MustParse(fmt.Sprintf("%dMi", int64(math.Ceil(float64(mem.Value())*options.Live(ctx).VMMemoryOverheadPercent/1024/1024)))))
 	return mem
 }
 
 // Setting ephemeral-storage to be either the default value, what is defined in blockDeviceMappings, or the combined size of local store volumes.
-func ephemeralStorage(info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily, nodeClass *v1beta1.EC2NodeClass) *resource.Quantity {
+func ephemeralStorage(info *ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily, nodeClass *v1beta1.EC2NodeClass) *resource.Quantity {
 	// If local store disks have been configured for node ephemeral-storage, use the total size of the disks.
 	if lo.FromPtr(nodeClass.Spec.InstanceStorePolicy) == v1beta1.InstanceStorePolicyRAID0 {
 		if info.InstanceStorageInfo != nil && info.InstanceStorageInfo.TotalSizeInGB != nil {
@@ -254,24 +262,24 @@ func awsPodENI(name string) *resource.Quantity {
 	return resources.Quantity("0")
 }
 
-func nvidiaGPUs(info *ec2.InstanceTypeInfo) *resource.Quantity {
+func nvidiaGPUs(info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	count := int64(0)
 	if info.GpuInfo != nil {
 		for _, gpu := range info.GpuInfo.Gpus {
 			if *gpu.Manufacturer == "NVIDIA" {
-				count += *gpu.Count
+				count += int64(*gpu.Count)
 			}
 		}
 	}
 	return resources.Quantity(fmt.Sprint(count))
 }
 
-func amdGPUs(info *ec2.InstanceTypeInfo) *resource.Quantity {
+func amdGPUs(info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	count := int64(0)
 	if info.GpuInfo != nil {
 		for _, gpu := range info.GpuInfo.Gpus {
 			if *gpu.Manufacturer == "AMD" {
-				count += *gpu.Count
+				count += int64(*gpu.Count)
 			}
 		}
 	}
@@ -280,61 +288,61 @@ func amdGPUs(info *ec2.InstanceTypeInfo) *resource.Quantity {
 
 // TODO: remove trn1 hardcode values once DescribeInstanceTypes contains the accelerator data
 // Values found from: https://aws.amazon.com/ec2/instance-types/trn1/
-func awsNeurons(info *ec2.InstanceTypeInfo) *resource.Quantity {
+func awsNeurons(info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	count := int64(0)
-	if *info.InstanceType == "trn1.2xlarge" {
+	if string(info.InstanceType) == "trn1.2xlarge" {
 		count = int64(1)
-	} else if *info.InstanceType == "trn1.32xlarge" {
+	} else if string(info.InstanceType) == "trn1.32xlarge" {
 		count = int64(16)
-	} else if *info.InstanceType == "trn1n.32xlarge" {
+	} else if string(info.InstanceType) == "trn1n.32xlarge" {
 		count = int64(16)
 	} else if info.InferenceAcceleratorInfo != nil {
 		for _, accelerator := range info.InferenceAcceleratorInfo.Accelerators {
-			count += *accelerator.Count
+			count += int64(*accelerator.Count)
 		}
 	}
 	return resources.Quantity(fmt.Sprint(count))
 }
 
-func habanaGaudis(info *ec2.InstanceTypeInfo) *resource.Quantity {
+func habanaGaudis(info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	count := int64(0)
 	if info.GpuInfo != nil {
 		for _, gpu := range info.GpuInfo.Gpus {
 			if *gpu.Manufacturer == "Habana" {
-				count += *gpu.Count
+				count += int64(*gpu.Count)
 			}
 		}
 	}
 	return resources.Quantity(fmt.Sprint(count))
 }
 
-func efas(info *ec2.InstanceTypeInfo) *resource.Quantity {
+func efas(info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	count := int64(0)
 	if info.NetworkInfo != nil && info.NetworkInfo.EfaInfo != nil {
-		count = lo.FromPtr(info.NetworkInfo.EfaInfo.MaximumEfaInterfaces)
+		count = int64(lo.FromPtr(info.NetworkInfo.EfaInfo.MaximumEfaInterfaces))
 	}
 	return resources.Quantity(fmt.Sprint(count))
 }
 
-func ENILimitedPods(ctx context.Context, info *ec2.InstanceTypeInfo) *resource.Quantity {
+func ENILimitedPods(ctx context.Context, info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	// The number of pods per node is calculated using the formula:
 	// max number of ENIs * (IPv4 Addresses per ENI -1) + 2
 	// https://github.com/awslabs/amazon-eks-ami/blob/master/files/eni-max-pods.txt#L20
 
 	// VPC CNI only uses the default network interface
 	// https://github.com/aws/amazon-vpc-cni-k8s/blob/3294231c0dce52cfe473bf6c62f47956a3b333b6/scripts/gen_vpc_ip_limits.go#L162
-	networkInterfaces := *info.NetworkInfo.NetworkCards[*info.NetworkInfo.DefaultNetworkCardIndex].MaximumNetworkInterfaces
-	usableNetworkInterfaces := lo.Max([]int64{(networkInterfaces - int64(options.FromContext(ctx).ReservedENIs)), 0})
+	networkInterfaces := int64(*info.NetworkInfo.NetworkCards[*info.NetworkInfo.DefaultNetworkCardIndex].MaximumNetworkInterfaces)
+	usableNetworkInterfaces := lo.Max([]int64{(networkInterfaces - int64(options.Live(ctx).ReservedENIs)), 0})
 	if usableNetworkInterfaces == 0 {
 		return resource.NewQuantity(0, resource.DecimalSI)
 	}
-	addressesPerInterface := *info.NetworkInfo.Ipv4AddressesPerInterface
+	addressesPerInterface := int64(*info.NetworkInfo.Ipv4AddressesPerInterface)
 	return resources.Quantity(fmt.Sprint(usableNetworkInterfaces*(addressesPerInterface-1) + 2))
 }
 
-func privateIPv4Address(info *ec2.InstanceTypeInfo) *resource.Quantity {
+func privateIPv4Address(info *ec2types.InstanceTypeInfo) *resource.Quantity {
 	//https://github.com/aws/amazon-vpc-resource-controller-k8s/blob/ecbd6965a0100d9a070110233762593b16023287/pkg/provider/ip/provider.go#L297
-	capacity := aws.Int64Value(info.NetworkInfo.Ipv4AddressesPerInterface) - 1
+	capacity := int64(aws.ToInt32(info.NetworkInfo.Ipv4AddressesPerInterface)) - 1
 	return resources.Quantity(fmt.Sprint(capacity))
 }
 
@@ -413,7 +421,7 @@ func evictionThreshold(memory *resource.Quantity, storage *resource.Quantity, am
 	return lo.Assign(overhead, override)
 }
 
-func pods(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily amifamily.AMIFamily, kc *corev1beta1.KubeletConfiguration) *resource.Quantity {
+func pods(ctx context.Context, info *ec2types.InstanceTypeInfo, amiFamily amifamily.AMIFamily, kc *corev1beta1.KubeletConfiguration) *resource.Quantity {
 	var count int64
 	switch {
 	case kc != nil && kc.MaxPods != nil:
@@ -425,7 +433,7 @@ func pods(ctx context.Context, info *ec2.InstanceTypeInfo, amiFamily amifamily.A
 
 	}
 	if kc != nil && ptr.Int32Value(kc.PodsPerCore) > 0 && amiFamily.FeatureFlags().PodsPerCoreEnabled {
-		count = lo.Min([]int64{int64(ptr.Int32Value(kc.PodsPerCore)) * ptr.Int64Value(info.VCpuInfo.DefaultVCpus), count})
+		count = lo.Min([]int64{int64(ptr.Int32Value(kc.PodsPerCore)) * int64(ptr.Int32Value(info.VCpuInfo.DefaultVCpus)), count})
 	}
 	return resources.Quantity(fmt.Sprint(count))
 }
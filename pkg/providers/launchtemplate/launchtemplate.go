@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -27,20 +28,22 @@ import (
 
 	"go.uber.org/multierr"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
-	"github.com/aws/aws-sdk-go/service/eks"
-	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/logging"
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
@@ -54,8 +57,9 @@ import (
 )
 
 const (
-	launchTemplateNameFormat = "karpenter.k8s.aws/%s"
-	karpenterManagedTagKey   = "karpenter.k8s.aws/cluster"
+	launchTemplateNameFormat  = "karpenter.k8s.aws/%s"
+	karpenterManagedTagKey    = "karpenter.k8s.aws/cluster"
+	nodeClassGenerationTagKey = "karpenter.k8s.aws/ec2nodeclass-generation"
 )
 
 type LaunchTemplate struct {
@@ -66,8 +70,8 @@ type LaunchTemplate struct {
 
 type Provider struct {
 	sync.Mutex
-	ec2api                  ec2iface.EC2API
-	eksapi                  eksiface.EKSAPI
+	ec2api                  sdk.EC2API
+	eksapi                  sdk.EKSAPI
 	amiFamily               *amifamily.Resolver
 	securityGroupProvider   *securitygroup.Provider
 	subnetProvider          *subnet.Provider
@@ -80,7 +84,7 @@ type Provider struct {
 	ClusterCIDR             atomic.Pointer[string]
 }
 
-func NewProvider(ctx context.Context, cache *cache.Cache, ec2api ec2iface.EC2API, eksapi eksiface.EKSAPI, amiFamily *amifamily.Resolver,
+func NewProvider(ctx context.Context, cache *cache.Cache, ec2api sdk.EC2API, eksapi sdk.EKSAPI, amiFamily *amifamily.Resolver,
 	securityGroupProvider *securitygroup.Provider, subnetProvider *subnet.Provider, instanceProfileProvider *instanceprofile.Provider,
 	caBundle *string, startAsync <-chan struct{}, kubeDNSIP net.IP, clusterEndpoint string) *Provider {
 	l := &Provider{
@@ -146,8 +150,20 @@ func (p *Provider) Invalidate(ctx context.Context, ltName string, ltID string) {
 	p.cache.Delete(ltName)
 }
 
+// launchTemplateName returns a name that's derived from the hash of the launch template parameters. The parameters are
+// normalized to the values that are sent to EC2 before they're hashed, so that parameters which are written differently
+// but create the same launch template (e.g. volume sizes in different units) share a launch template.
 func launchTemplateName(options *amifamily.LaunchTemplate) string {
-	hash, err := hashstructure.Hash(options, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
+	normalized := *options
+	normalized.Options = lo.ToPtr(*options.Options)
+	// Only security group IDs are sent to EC2, and duplicates would cancel each other out when hashed as a set
+	normalized.SecurityGroups = lo.Map(lo.Uniq(lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) string { return s.ID })),
+		func(id string, _ int) v1beta1.SecurityGroup { return v1beta1.SecurityGroup{ID: id} })
+	normalized.BlockDeviceMappings = nil
+	hash, err := hashstructure.Hash(struct {
+		*amifamily.LaunchTemplate
+		BlockDeviceMappings []ec2types.LaunchTemplateBlockDeviceMappingRequest
+	}{&normalized, blockDeviceMappings(options.BlockDeviceMappings)}, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
 		panic(fmt.Sprintf("hashing launch template, %s", err))
 	}
@@ -181,14 +197,15 @@ func (p *Provider) createAMIOptions(ctx context.Context, nodeClass *v1beta1.EC2N
 		ClusterCIDR:         p.ClusterCIDR.Load(),
 		InstanceProfile:     instanceProfile,
 		InstanceStorePolicy: nodeClass.Spec.InstanceStorePolicy,
-		SecurityGroups: lo.Map(securityGroups, func(s *ec2.SecurityGroup, _ int) v1beta1.SecurityGroup {
-			return v1beta1.SecurityGroup{ID: aws.StringValue(s.GroupId), Name: aws.StringValue(s.GroupName)}
+		SecurityGroups: lo.Map(securityGroups, func(s *ec2types.SecurityGroup, _ int) v1beta1.SecurityGroup {
+			return v1beta1.SecurityGroup{ID: aws.ToString(s.GroupId), Name: aws.ToString(s.GroupName)}
 		}),
-		Tags:          tags,
-		Labels:        labels,
-		CABundle:      p.CABundle,
-		KubeDNSIP:     p.KubeDNSIP,
-		NodeClassName: nodeClass.Name,
+		Tags:                tags,
+		Labels:              labels,
+		CABundle:            p.CABundle,
+		KubeDNSIP:           p.KubeDNSIP,
+		NodeClassName:       nodeClass.Name,
+		NodeClassGeneration: nodeClass.Generation,
 	}
 	if nodeClass.Spec.AssociatePublicIPAddress != nil {
 		options.AssociatePublicIPAddress = nodeClass.Spec.AssociatePublicIPAddress
@@ -205,18 +222,20 @@ func (p *Provider) createAMIOptions(ctx context.Context, nodeClass *v1beta1.EC2N
 	return options, nil
 }
 
-func (p *Provider) ensureLaunchTemplate(ctx context.Context, capacityType string, options *amifamily.LaunchTemplate) (*ec2.LaunchTemplate, error) {
-	var launchTemplate *ec2.LaunchTemplate
+func (p *Provider) ensureLaunchTemplate(ctx context.Context, capacityType string, options *amifamily.LaunchTemplate) (*ec2types.LaunchTemplate, error) {
+	var launchTemplate *ec2types.LaunchTemplate
 	name := launchTemplateName(options)
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("launch-template-name", name))
 	// Read from cache
 	if launchTemplate, ok := p.cache.Get(name); ok {
+		awscache.RecordLookup(awscache.LaunchTemplates, true)
 		p.cache.SetDefault(name, launchTemplate)
-		return launchTemplate.(*ec2.LaunchTemplate), nil
+		return launchTemplate.(*ec2types.LaunchTemplate), nil
 	}
+	awscache.RecordLookup(awscache.LaunchTemplates, false)
 	// Attempt to find an existing LT.
-	output, err := p.ec2api.DescribeLaunchTemplatesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{
-		LaunchTemplateNames: []*string{aws.String(name)},
+	output, err := p.ec2api.DescribeLaunchTemplates(ctx, &ec2.DescribeLaunchTemplatesInput{
+		LaunchTemplateNames: []string{name},
 	})
 	// Create LT if one doesn't exist
 	if awserrors.IsNotFound(err) {
@@ -232,72 +251,78 @@ func (p *Provider) ensureLaunchTemplate(ctx context.Context, capacityType string
 		if p.cm.HasChanged("launchtemplate-"+name, name) {
 			logging.FromContext(ctx).Debugf("discovered launch template")
 		}
-		launchTemplate = output.LaunchTemplates[0]
+		launchTemplate = &output.LaunchTemplates[0]
 	}
 	p.cache.SetDefault(name, launchTemplate)
+	awscache.RecordRefresh(awscache.LaunchTemplates)
 	return launchTemplate, nil
 }
 
-func (p *Provider) createLaunchTemplate(ctx context.Context, capacityType string, options *amifamily.LaunchTemplate) (*ec2.LaunchTemplate, error) {
+func (p *Provider) createLaunchTemplate(ctx context.Context, capacityType string, options *amifamily.LaunchTemplate) (*ec2types.LaunchTemplate, error) {
 	userData, err := options.UserData.Script()
 	if err != nil {
 		return nil, err
 	}
-	launchTemplateDataTags := []*ec2.LaunchTemplateTagSpecificationRequest{
-		{ResourceType: aws.String(ec2.ResourceTypeNetworkInterface), Tags: utils.MergeTags(options.Tags)},
+	launchTemplateDataTags := []ec2types.LaunchTemplateTagSpecificationRequest{
+		// the network interfaces that are created at launch are told apart from the ones that are attached later
+		{ResourceType: ec2types.ResourceTypeNetworkInterface, Tags: utils.MergeTags(options.Tags, map[string]string{v1beta1.TagLaunchedBy: options.ClusterName})},
 	}
 	// Add the spot-instances-request tag if trying to launch spot capacity
 	if capacityType == corev1beta1.CapacityTypeSpot {
-		launchTemplateDataTags = append(launchTemplateDataTags, &ec2.LaunchTemplateTagSpecificationRequest{ResourceType: aws.String(ec2.ResourceTypeSpotInstancesRequest), Tags: utils.MergeTags(options.Tags)})
+		launchTemplateDataTags = append(launchTemplateDataTags, ec2types.LaunchTemplateTagSpecificationRequest{ResourceType: ec2types.ResourceTypeSpotInstancesRequest, Tags: utils.MergeTags(options.Tags)})
 	}
 	networkInterfaces := p.generateNetworkInterfaces(options)
-	output, err := p.ec2api.CreateLaunchTemplateWithContext(ctx, &ec2.CreateLaunchTemplateInput{
+	output, err := p.ec2api.CreateLaunchTemplate(ctx, &ec2.CreateLaunchTemplateInput{
 		LaunchTemplateName: aws.String(launchTemplateName(options)),
-		LaunchTemplateData: &ec2.RequestLaunchTemplateData{
-			BlockDeviceMappings: p.blockDeviceMappings(options.BlockDeviceMappings),
-			IamInstanceProfile: &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{
+		LaunchTemplateData: &ec2types.RequestLaunchTemplateData{
+			BlockDeviceMappings: blockDeviceMappings(options.BlockDeviceMappings),
+			IamInstanceProfile: &ec2types.LaunchTemplateIamInstanceProfileSpecificationRequest{
 				Name: aws.String(options.InstanceProfile),
 			},
-			Monitoring: &ec2.LaunchTemplatesMonitoringRequest{
+			Monitoring: &ec2types.LaunchTemplatesMonitoringRequest{
 				Enabled: aws.Bool(options.DetailedMonitoring),
 			},
 			// If the network interface is defined, the security groups are defined within it
-			SecurityGroupIds: lo.Ternary(networkInterfaces != nil, nil, lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) *string { return aws.String(s.ID) })),
+			SecurityGroupIds: lo.Ternary(networkInterfaces != nil, nil, lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) string { return s.ID })),
 			UserData:         aws.String(userData),
 			ImageId:          aws.String(options.AMIID),
-			MetadataOptions: &ec2.LaunchTemplateInstanceMetadataOptionsRequest{
-				HttpEndpoint:            options.MetadataOptions.HTTPEndpoint,
-				HttpProtocolIpv6:        options.MetadataOptions.HTTPProtocolIPv6,
-				HttpPutResponseHopLimit: options.MetadataOptions.HTTPPutResponseHopLimit,
-				HttpTokens:              options.MetadataOptions.HTTPTokens,
+			MetadataOptions: &ec2types.LaunchTemplateInstanceMetadataOptionsRequest{
+				HttpEndpoint:            ec2types.LaunchTemplateInstanceMetadataEndpointState(lo.FromPtr(options.MetadataOptions.HTTPEndpoint)),
+				HttpProtocolIpv6:        ec2types.LaunchTemplateInstanceMetadataProtocolIpv6(lo.FromPtr(options.MetadataOptions.HTTPProtocolIPv6)),
+				HttpPutResponseHopLimit: toInt32(options.MetadataOptions.HTTPPutResponseHopLimit),
+				HttpTokens:              ec2types.LaunchTemplateHttpTokensState(lo.FromPtr(options.MetadataOptions.HTTPTokens)),
 			},
 			NetworkInterfaces: networkInterfaces,
 			TagSpecifications: launchTemplateDataTags,
 		},
-		TagSpecifications: []*ec2.TagSpecification{
+		TagSpecifications: []ec2types.TagSpecification{
 			{
-				ResourceType: aws.String(ec2.ResourceTypeLaunchTemplate),
-				Tags:         utils.MergeTags(options.Tags, map[string]string{karpenterManagedTagKey: options.ClusterName, v1beta1.LabelNodeClass: options.NodeClassName}),
+				ResourceType: ec2types.ResourceTypeLaunchTemplate,
+				Tags: utils.MergeTags(options.Tags, map[string]string{
+					karpenterManagedTagKey:    options.ClusterName,
+					v1beta1.LabelNodeClass:    options.NodeClassName,
+					nodeClassGenerationTagKey: strconv.FormatInt(options.NodeClassGeneration, 10),
+				}),
 			},
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
-	logging.FromContext(ctx).With("id", aws.StringValue(output.LaunchTemplate.LaunchTemplateId)).Debugf("created launch template")
+	logging.FromContext(ctx).With("id", aws.ToString(output.LaunchTemplate.LaunchTemplateId)).Debugf("created launch template")
 	return output.LaunchTemplate, nil
 }
 
 // generateNetworkInterfaces generates network interfaces for the launch template.
-func (p *Provider) generateNetworkInterfaces(options *amifamily.LaunchTemplate) []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
+func (p *Provider) generateNetworkInterfaces(options *amifamily.LaunchTemplate) []ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
 	if options.EFACount != 0 {
-		return lo.Times(options.EFACount, func(i int) *ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
-			return &ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
-				NetworkCardIndex: lo.ToPtr(int64(i)),
+		return lo.Times(options.EFACount, func(i int) ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest {
+			return ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+				NetworkCardIndex: lo.ToPtr(int32(i)),
 				// Some networking magic to ensure that one network card has higher priority than all the others (important if an instance needs a public IP w/o adding an EIP to every network card)
-				DeviceIndex:   lo.ToPtr(lo.Ternary[int64](i == 0, 0, 1)),
-				InterfaceType: lo.ToPtr(ec2.NetworkInterfaceTypeEfa),
-				Groups:        lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) *string { return aws.String(s.ID) }),
+				DeviceIndex:   lo.ToPtr(lo.Ternary[int32](i == 0, 0, 1)),
+				InterfaceType: aws.String(string(ec2types.NetworkInterfaceTypeEfa)),
+				Groups:        lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) string { return s.ID }),
 				// Instances launched with multiple pre-configured network interfaces cannot set AssociatePublicIPAddress to true. This is an EC2 limitation. However, this does not apply for instances
 				// with a single EFA network interface, and we should support those use cases. Launch failures with multiple enis should be considered user misconfiguration.
 				AssociatePublicIpAddress: options.AssociatePublicIPAddress,
@@ -306,35 +331,35 @@ func (p *Provider) generateNetworkInterfaces(options *amifamily.LaunchTemplate)
 	}
 
 	if options.AssociatePublicIPAddress != nil {
-		return []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+		return []ec2types.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
 			{
 				AssociatePublicIpAddress: options.AssociatePublicIPAddress,
-				DeviceIndex:              aws.Int64(0),
-				Groups:                   lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) *string { return aws.String(s.ID) }),
+				DeviceIndex:              aws.Int32(0),
+				Groups:                   lo.Map(options.SecurityGroups, func(s v1beta1.SecurityGroup, _ int) string { return s.ID }),
 			},
 		}
 	}
 	return nil
 }
 
-func (p *Provider) blockDeviceMappings(blockDeviceMappings []*v1beta1.BlockDeviceMapping) []*ec2.LaunchTemplateBlockDeviceMappingRequest {
+func blockDeviceMappings(blockDeviceMappings []*v1beta1.BlockDeviceMapping) []ec2types.LaunchTemplateBlockDeviceMappingRequest {
 	if len(blockDeviceMappings) == 0 {
 		// The EC2 API fails with empty slices and expects nil.
 		return nil
 	}
-	var blockDeviceMappingsRequest []*ec2.LaunchTemplateBlockDeviceMappingRequest
+	var blockDeviceMappingsRequest []ec2types.LaunchTemplateBlockDeviceMappingRequest
 	for _, blockDeviceMapping := range blockDeviceMappings {
-		blockDeviceMappingsRequest = append(blockDeviceMappingsRequest, &ec2.LaunchTemplateBlockDeviceMappingRequest{
+		blockDeviceMappingsRequest = append(blockDeviceMappingsRequest, ec2types.LaunchTemplateBlockDeviceMappingRequest{
 			DeviceName: blockDeviceMapping.DeviceName,
-			Ebs: &ec2.LaunchTemplateEbsBlockDeviceRequest{
+			Ebs: &ec2types.LaunchTemplateEbsBlockDeviceRequest{
 				DeleteOnTermination: blockDeviceMapping.EBS.DeleteOnTermination,
 				Encrypted:           blockDeviceMapping.EBS.Encrypted,
-				VolumeType:          blockDeviceMapping.EBS.VolumeType,
-				Iops:                blockDeviceMapping.EBS.IOPS,
-				Throughput:          blockDeviceMapping.EBS.Throughput,
+				VolumeType:          ec2types.VolumeType(lo.FromPtr(blockDeviceMapping.EBS.VolumeType)),
+				Iops:                toInt32(blockDeviceMapping.EBS.IOPS),
+				Throughput:          toInt32(blockDeviceMapping.EBS.Throughput),
 				KmsKeyId:            blockDeviceMapping.EBS.KMSKeyID,
 				SnapshotId:          blockDeviceMapping.EBS.SnapshotID,
-				VolumeSize:          p.volumeSize(blockDeviceMapping.EBS.VolumeSize),
+				VolumeSize:          volumeSize(blockDeviceMapping.EBS.VolumeSize),
 			},
 		})
 	}
@@ -342,12 +367,20 @@ func (p *Provider) blockDeviceMappings(blockDeviceMappings []*v1beta1.BlockDevic
 }
 
 // volumeSize returns a GiB scaled value from a resource quantity or nil if the resource quantity passed in is nil
-func (p *Provider) volumeSize(quantity *resource.Quantity) *int64 {
+func volumeSize(quantity *resource.Quantity) *int32 {
 	if quantity == nil {
 		return nil
 	}
 	// Converts the value to Gi and rounds up the value to the nearest Gi
-	return aws.Int64(int64(math.Ceil(quantity.AsApproximateFloat64() / math.Pow(2, 30))))
+	return aws.Int32(int32(math.Ceil(quantity.AsApproximateFloat64() / math.Pow(2, 30))))
+}
+
+// toInt32 converts the int64 values of the EC2NodeClass API to the int32 values of the EC2 API
+func toInt32(value *int64) *int32 {
+	if value == nil {
+		return nil
+	}
+	return aws.Int32(int32(*value))
 }
 
 // hydrateCache queries for existing Launch Templates created by Karpenter for the current cluster and adds to the LT cache.
@@ -355,18 +388,21 @@ func (p *Provider) volumeSize(quantity *resource.Quantity) *int64 {
 func (p *Provider) hydrateCache(ctx context.Context) {
 	clusterName := options.FromContext(ctx).ClusterName
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("tag-key", karpenterManagedTagKey, "tag-value", clusterName))
-	if err := p.ec2api.DescribeLaunchTemplatesPagesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{
-		Filters: []*ec2.Filter{{Name: aws.String(fmt.Sprintf("tag:%s", karpenterManagedTagKey)), Values: []*string{aws.String(clusterName)}}},
-	}, func(output *ec2.DescribeLaunchTemplatesOutput, _ bool) bool {
-		for _, lt := range output.LaunchTemplates {
-			p.cache.SetDefault(*lt.LaunchTemplateName, lt)
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(p.ec2api, &ec2.DescribeLaunchTemplatesInput{
+		Filters: []ec2types.Filter{{Name: aws.String(fmt.Sprintf("tag:%s", karpenterManagedTagKey)), Values: []string{clusterName}}},
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Errorf(fmt.Sprintf("Unable to hydrate the AWS launch template cache, %s", err))
+			return
+		}
+		for i := range output.LaunchTemplates {
+			p.cache.SetDefault(*output.LaunchTemplates[i].LaunchTemplateName, &output.LaunchTemplates[i])
 		}
-		return true
-	}); err != nil {
-		logging.FromContext(ctx).Errorf(fmt.Sprintf("Unable to hydrate the AWS launch template cache, %s", err))
-	} else {
-		logging.FromContext(ctx).With("count", p.cache.ItemCount()).Debugf("hydrated launch template cache")
 	}
+	awscache.RecordRefresh(awscache.LaunchTemplates)
+	logging.FromContext(ctx).With("count", p.cache.ItemCount()).Debugf("hydrated launch template cache")
 }
 
 func (p *Provider) cachedEvictedFunc(ctx context.Context) func(string, interface{}) {
@@ -376,21 +412,22 @@ func (p *Provider) cachedEvictedFunc(ctx context.Context) func(string, interface
 		if _, expiration, _ := p.cache.GetWithExpiration(key); expiration.After(time.Now()) {
 			return
 		}
-		launchTemplate := lt.(*ec2.LaunchTemplate)
-		if _, err := p.ec2api.DeleteLaunchTemplate(&ec2.DeleteLaunchTemplateInput{LaunchTemplateId: launchTemplate.LaunchTemplateId}); awserrors.IgnoreNotFound(err) != nil {
+		awscache.RecordExpiration(awscache.LaunchTemplates)
+		launchTemplate := lt.(*ec2types.LaunchTemplate)
+		if _, err := p.ec2api.DeleteLaunchTemplate(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateId: launchTemplate.LaunchTemplateId}); awserrors.IgnoreNotFound(err) != nil {
 			logging.FromContext(ctx).With("launch-template", launchTemplate.LaunchTemplateName).Errorf("failed to delete launch template, %v", err)
 			return
 		}
 		logging.FromContext(ctx).With(
-			"id", aws.StringValue(launchTemplate.LaunchTemplateId),
-			"name", aws.StringValue(launchTemplate.LaunchTemplateName),
+			"id", aws.ToString(launchTemplate.LaunchTemplateId),
+			"name", aws.ToString(launchTemplate.LaunchTemplateName),
 		).Debugf("deleted launch template")
 	}
 }
 
 func (p *Provider) getInstanceProfile(nodeClass *v1beta1.EC2NodeClass) (string, error) {
 	if nodeClass.Spec.InstanceProfile != nil {
-		return aws.StringValue(nodeClass.Spec.InstanceProfile), nil
+		return aws.ToString(nodeClass.Spec.InstanceProfile), nil
 	}
 	if nodeClass.Spec.Role != "" {
 		if nodeClass.Status.InstanceProfile == "" {
@@ -398,33 +435,41 @@ func (p *Provider) getInstanceProfile(nodeClass *v1beta1.EC2NodeClass) (string,
 		}
 		return nodeClass.Status.InstanceProfile, nil
 	}
-	return "", errors.New("neither spec.instanceProfile or spec.role is specified")
+	if nodeClass.Spec.InstanceProfileSelectorTerms != nil {
+		if nodeClass.Status.InstanceProfile == "" {
+			return "", cloudprovider.NewNodeClassNotReadyError(fmt.Errorf("instance profile hasn't resolved for instance profile selectors"))
+		}
+		return nodeClass.Status.InstanceProfile, nil
+	}
+	return "", errors.New("neither spec.instanceProfile, spec.instanceProfileSelectorTerms or spec.role is specified")
 }
 
 func (p *Provider) DeleteLaunchTemplates(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
 	clusterName := options.FromContext(ctx).ClusterName
 	var ltNames []*string
-	if err := p.ec2api.DescribeLaunchTemplatesPagesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{
-		Filters: []*ec2.Filter{
-			{Name: aws.String(fmt.Sprintf("tag:%s", karpenterManagedTagKey)), Values: []*string{aws.String(clusterName)}},
-			{Name: aws.String(fmt.Sprintf("tag:%s", v1beta1.LabelNodeClass)), Values: []*string{aws.String(nodeClass.Name)}},
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(p.ec2api, &ec2.DescribeLaunchTemplatesInput{
+		Filters: []ec2types.Filter{
+			{Name: aws.String(fmt.Sprintf("tag:%s", karpenterManagedTagKey)), Values: []string{clusterName}},
+			{Name: aws.String(fmt.Sprintf("tag:%s", v1beta1.LabelNodeClass)), Values: []string{nodeClass.Name}},
 		},
-	}, func(output *ec2.DescribeLaunchTemplatesOutput, _ bool) bool {
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("fetching launch templates, %w", err)
+		}
 		for _, lt := range output.LaunchTemplates {
 			ltNames = append(ltNames, lt.LaunchTemplateName)
 		}
-		return true
-	}); err != nil {
-		return fmt.Errorf("fetching launch templates, %w", err)
 	}
 
 	var deleteErr error
 	for _, name := range ltNames {
-		_, err := p.ec2api.DeleteLaunchTemplateWithContext(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateName: name})
+		_, err := p.ec2api.DeleteLaunchTemplate(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateName: name})
 		deleteErr = multierr.Append(deleteErr, err)
 	}
 	if len(ltNames) > 0 {
-		logging.FromContext(ctx).With("launchTemplates", utils.PrettySlice(aws.StringValueSlice(ltNames), 5)).Debugf("deleted launch templates")
+		logging.FromContext(ctx).With("launchTemplates", utils.PrettySlice(aws.ToStringSlice(ltNames), 5)).Debugf("deleted launch templates")
 	}
 	if deleteErr != nil {
 		return fmt.Errorf("deleting launch templates, %w", deleteErr)
@@ -432,11 +477,97 @@ func (p *Provider) DeleteLaunchTemplates(ctx context.Context, nodeClass *v1beta1
 	return nil
 }
 
+// DeleteSupersededLaunchTemplates deletes the launch templates of the EC2NodeClass that were created for an older
+// generation of the EC2NodeClass, instead of waiting for them to expire from the cache. Launch templates that are still
+// used by the current generation are created again on the next launch.
+func (p *Provider) DeleteSupersededLaunchTemplates(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
+	launchTemplates, err := p.listLaunchTemplates(ctx, ec2types.Filter{
+		Name:   aws.String(fmt.Sprintf("tag:%s", v1beta1.LabelNodeClass)),
+		Values: []string{nodeClass.Name},
+	})
+	if err != nil {
+		return err
+	}
+	return p.deleteLaunchTemplates(ctx, lo.Filter(launchTemplates, func(lt ec2types.LaunchTemplate, _ int) bool {
+		// Launch templates that were created before they were tagged with the generation expire from the cache
+		generation, err := strconv.ParseInt(tagValue(lt.Tags, nodeClassGenerationTagKey), 10, 64)
+		return err == nil && generation < nodeClass.Generation
+	}))
+}
+
+// DeleteOrphanedLaunchTemplates deletes the launch templates of the cluster whose EC2NodeClass no longer exists. Launch
+// templates that were created after the EC2NodeClasses were listed aren't deleted, since their EC2NodeClass may have
+// been created after the EC2NodeClasses were listed.
+func (p *Provider) DeleteOrphanedLaunchTemplates(ctx context.Context, nodeClassNames sets.Set[string], listedAt time.Time) error {
+	launchTemplates, err := p.listLaunchTemplates(ctx, ec2types.Filter{
+		Name:   aws.String("tag-key"),
+		Values: []string{v1beta1.LabelNodeClass},
+	})
+	if err != nil {
+		return err
+	}
+	return p.deleteLaunchTemplates(ctx, lo.Filter(launchTemplates, func(lt ec2types.LaunchTemplate, _ int) bool {
+		return !nodeClassNames.Has(tagValue(lt.Tags, v1beta1.LabelNodeClass)) && aws.ToTime(lt.CreateTime).Before(listedAt)
+	}))
+}
+
+// listLaunchTemplates returns the launch templates that Karpenter created for the cluster and that match the filters
+func (p *Provider) listLaunchTemplates(ctx context.Context, filters ...ec2types.Filter) ([]ec2types.LaunchTemplate, error) {
+	var launchTemplates []ec2types.LaunchTemplate
+	paginator := ec2.NewDescribeLaunchTemplatesPaginator(p.ec2api, &ec2.DescribeLaunchTemplatesInput{
+		Filters: append([]ec2types.Filter{
+			{Name: aws.String(fmt.Sprintf("tag:%s", karpenterManagedTagKey)), Values: []string{options.FromContext(ctx).ClusterName}},
+		}, filters...),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("describing launch templates, %w", err)
+		}
+		launchTemplates = append(launchTemplates, output.LaunchTemplates...)
+	}
+	return launchTemplates, nil
+}
+
+// deleteLaunchTemplates deletes the launch templates and removes them from the cache, so that they are created again if
+// they are needed by a launch
+func (p *Provider) deleteLaunchTemplates(ctx context.Context, launchTemplates []ec2types.LaunchTemplate) error {
+	if len(launchTemplates) == 0 {
+		return nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	defer p.cache.OnEvicted(p.cachedEvictedFunc(ctx))
+	p.cache.OnEvicted(nil)
+	var errs error
+	var deleted []string
+	for _, lt := range launchTemplates {
+		if _, err := p.ec2api.DeleteLaunchTemplate(ctx, &ec2.DeleteLaunchTemplateInput{LaunchTemplateName: lt.LaunchTemplateName}); awserrors.IgnoreNotFound(err) != nil {
+			errs = multierr.Append(errs, fmt.Errorf("deleting launch template %s, %w", aws.ToString(lt.LaunchTemplateName), err))
+			continue
+		}
+		p.cache.Delete(aws.ToString(lt.LaunchTemplateName))
+		deleted = append(deleted, aws.ToString(lt.LaunchTemplateName))
+	}
+	if len(deleted) > 0 {
+		logging.FromContext(ctx).With("launchTemplates", utils.PrettySlice(deleted, 5)).Debugf("deleted launch templates")
+	}
+	return errs
+}
+
+func tagValue(tags []ec2types.Tag, key string) string {
+	tag, ok := lo.Find(tags, func(t ec2types.Tag) bool { return aws.ToString(t.Key) == key })
+	if !ok {
+		return ""
+	}
+	return aws.ToString(tag.Value)
+}
+
 func (p *Provider) ResolveClusterCIDR(ctx context.Context) error {
 	if p.ClusterCIDR.Load() != nil {
 		return nil
 	}
-	out, err := p.eksapi.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{
+	out, err := p.eksapi.DescribeCluster(ctx, &eks.DescribeClusterInput{
 		Name: aws.String(options.FromContext(ctx).ClusterName),
 	})
 	if err != nil {
@@ -26,9 +26,10 @@ import (
 	"testing"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	admv1alpha1 "github.com/awslabs/amazon-eks-ami/nodeadm/api/v1alpha1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -80,15 +81,15 @@ func TestAWS(t *testing.T) {
 }
 
 var _ = BeforeSuite(func() {
-	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...))
+	env = coretest.NewEnvironment(scheme.Scheme, coretest.WithCRDs(apis.CRDs...), coretest.WithFieldIndexers(coretest.NodeClaimFieldIndexer(ctx)))
 	ctx = coreoptions.ToContext(ctx, coretest.Options())
 	ctx = options.ToContext(ctx, test.Options())
 	ctx, stop = context.WithCancel(ctx)
 	awsEnv = test.NewEnvironment(ctx, env)
 
 	fakeClock = &clock.FakeClock{}
-	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.InstanceProvider, events.NewRecorder(&record.FakeRecorder{}),
-		env.Client, awsEnv.AMIProvider, awsEnv.SecurityGroupProvider, awsEnv.SubnetProvider)
+	cloudProvider = cloudprovider.New(awsEnv.InstanceTypesProvider, awsEnv.AccountProvider, events.NewRecorder(&record.FakeRecorder{}),
+		env.Client, awsEnv.PricingProvider)
 	cluster = state.NewCluster(fakeClock, env.Client, cloudProvider)
 	prov = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster)
 })
@@ -196,6 +197,18 @@ var _ = Describe("LaunchTemplates", func() {
 			}
 		})
 	})
+	It("should tag launch templates with the generation of the nodeclass", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+		pod := coretest.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		generation := fmt.Sprint(ExpectExists(ctx, env.Client, nodeClass).Generation)
+		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">", 0))
+		awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
+			Expect(ltInput.TagSpecifications[0].Tags).To(ContainElement(&ec2types.Tag{Key: aws.String("karpenter.k8s.aws/ec2nodeclass-generation"), Value: aws.String(generation)}))
+		})
+	})
 	It("should default to a generated launch template", func() {
 		ExpectApplied(ctx, env.Client, nodePool, nodeClass)
 		pod := coretest.UnschedulablePod()
@@ -207,7 +220,7 @@ var _ = Describe("LaunchTemplates", func() {
 		Expect(len(createFleetInput.LaunchTemplateConfigs)).To(BeNumerically("==", awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()))
 		Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 		awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-			launchTemplate, ok := lo.Find(createFleetInput.LaunchTemplateConfigs, func(ltConfig *ec2.FleetLaunchTemplateConfigRequest) bool {
+			launchTemplate, ok := lo.Find(createFleetInput.LaunchTemplateConfigs, func(ltConfig ec2types.FleetLaunchTemplateConfigRequest) bool {
 				return *ltConfig.LaunchTemplateSpecification.LaunchTemplateName == *ltInput.LaunchTemplateName
 			})
 			Expect(ok).To(BeTrue())
@@ -293,6 +306,28 @@ var _ = Describe("LaunchTemplates", func() {
 			}
 			Expect(lts1.Equal(lts2)).To(BeTrue())
 		})
+		DescribeTable("should use the same launch template for volume sizes that are written in different units", func(size string, sameLaunchTemplates bool) {
+			launchTemplateNames := func(volumeSize string) sets.Set[string] {
+				nodeClass.Spec.BlockDeviceMappings = []*v1beta1.BlockDeviceMapping{{
+					DeviceName: aws.String("/dev/xvda"),
+					EBS:        &v1beta1.BlockDevice{VolumeSize: lo.ToPtr(resource.MustParse(volumeSize))},
+				}}
+				ExpectApplied(ctx, env.Client, nodePool, nodeClass)
+				pod := coretest.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+				names := sets.New[string]()
+				for _, ltConfig := range awsEnv.EC2API.CreateFleetBehavior.CalledWithInput.Pop().LaunchTemplateConfigs {
+					names.Insert(*ltConfig.LaunchTemplateSpecification.LaunchTemplateName)
+				}
+				return names
+			}
+			Expect(launchTemplateNames("100Gi").Equal(launchTemplateNames(size))).To(Equal(sameLaunchTemplates))
+		},
+			Entry("bytes", "107374182400", true),
+			Entry("fractional gibibytes", "99.5Gi", true),
+			Entry("different size", "200Gi", false),
+		)
 		It("should recover from an out-of-sync launch template cache", func() {
 			nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{MaxPods: aws.Int32(1)}
 			ExpectApplied(ctx, env.Client, nodePool, nodeClass)
@@ -301,13 +336,13 @@ var _ = Describe("LaunchTemplates", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				ltName := aws.StringValue(ltInput.LaunchTemplateName)
+				ltName := aws.ToString(ltInput.LaunchTemplateName)
 				lt, ok := awsEnv.LaunchTemplateCache.Get(ltName)
 				Expect(ok).To(Equal(true))
 				// Remove expiration from cached LT
 				awsEnv.LaunchTemplateCache.Set(ltName, lt, -1)
 			})
-			awsEnv.EC2API.CreateFleetBehavior.Error.Set(awserr.New("InvalidLaunchTemplateName.NotFoundException", "", nil), fake.MaxCalls(1))
+			awsEnv.EC2API.CreateFleetBehavior.Error.Set(&smithy.GenericAPIError{Code: "InvalidLaunchTemplateName.NotFoundException", Message: ""}, fake.MaxCalls(1))
 			pod = coretest.UnschedulablePod()
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectScheduled(ctx, env.Client, pod)
@@ -343,13 +378,14 @@ var _ = Describe("LaunchTemplates", func() {
 			Expect(createFleetInput.TagSpecifications).To(HaveLen(3))
 
 			// tags should be included in instance, volume, and fleet tag specification
-			Expect(*createFleetInput.TagSpecifications[0].ResourceType).To(Equal(ec2.ResourceTypeInstance))
+			Expect(createFleetInput.TagSpecifications[0].ResourceType).To(Equal(ec2types.ResourceTypeInstance))
 			ExpectTags(createFleetInput.TagSpecifications[0].Tags, nodeClass.Spec.Tags)
 
-			Expect(*createFleetInput.TagSpecifications[1].ResourceType).To(Equal(ec2.ResourceTypeVolume))
+			Expect(createFleetInput.TagSpecifications[1].ResourceType).To(Equal(ec2types.ResourceTypeVolume))
 			ExpectTags(createFleetInput.TagSpecifications[1].Tags, nodeClass.Spec.Tags)
+			ExpectTags(createFleetInput.TagSpecifications[1].Tags, map[string]string{v1beta1.TagLaunchedBy: options.FromContext(ctx).ClusterName})
 
-			Expect(*createFleetInput.TagSpecifications[2].ResourceType).To(Equal(ec2.ResourceTypeFleet))
+			Expect(createFleetInput.TagSpecifications[2].ResourceType).To(Equal(ec2types.ResourceTypeFleet))
 			ExpectTags(createFleetInput.TagSpecifications[2].Tags, nodeClass.Spec.Tags)
 		})
 		It("should request that tags be applied to both network interfaces and spot instance requests", func() {
@@ -374,10 +410,11 @@ var _ = Describe("LaunchTemplates", func() {
 				Expect(i.LaunchTemplateData.TagSpecifications).To(HaveLen(2))
 
 				// tags should be included in instance, volume, and fleet tag specification
-				Expect(*i.LaunchTemplateData.TagSpecifications[0].ResourceType).To(Equal(ec2.ResourceTypeNetworkInterface))
+				Expect(i.LaunchTemplateData.TagSpecifications[0].ResourceType).To(Equal(ec2types.ResourceTypeNetworkInterface))
 				ExpectTags(i.LaunchTemplateData.TagSpecifications[0].Tags, nodeClass.Spec.Tags)
+				ExpectTags(i.LaunchTemplateData.TagSpecifications[0].Tags, map[string]string{v1beta1.TagLaunchedBy: options.FromContext(ctx).ClusterName})
 
-				Expect(*i.LaunchTemplateData.TagSpecifications[1].ResourceType).To(Equal(ec2.ResourceTypeSpotInstancesRequest))
+				Expect(i.LaunchTemplateData.TagSpecifications[1].ResourceType).To(Equal(ec2types.ResourceTypeSpotInstancesRequest))
 				ExpectTags(i.LaunchTemplateData.TagSpecifications[1].Tags, nodeClass.Spec.Tags)
 			})
 		})
@@ -395,13 +432,13 @@ var _ = Describe("LaunchTemplates", func() {
 			Expect(createFleetInput.TagSpecifications).To(HaveLen(3))
 
 			// tags should be included in instance, volume, and fleet tag specification
-			Expect(*createFleetInput.TagSpecifications[0].ResourceType).To(Equal(ec2.ResourceTypeInstance))
+			Expect(createFleetInput.TagSpecifications[0].ResourceType).To(Equal(ec2types.ResourceTypeInstance))
 			ExpectTags(createFleetInput.TagSpecifications[0].Tags, nodeClass.Spec.Tags)
 
-			Expect(*createFleetInput.TagSpecifications[1].ResourceType).To(Equal(ec2.ResourceTypeVolume))
+			Expect(createFleetInput.TagSpecifications[1].ResourceType).To(Equal(ec2types.ResourceTypeVolume))
 			ExpectTags(createFleetInput.TagSpecifications[1].Tags, nodeClass.Spec.Tags)
 
-			Expect(*createFleetInput.TagSpecifications[2].ResourceType).To(Equal(ec2.ResourceTypeFleet))
+			Expect(createFleetInput.TagSpecifications[2].ResourceType).To(Equal(ec2types.ResourceTypeFleet))
 			ExpectTags(createFleetInput.TagSpecifications[2].Tags, nodeClass.Spec.Tags)
 		})
 	})
@@ -416,7 +453,7 @@ var _ = Describe("LaunchTemplates", func() {
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
 				Expect(len(ltInput.LaunchTemplateData.BlockDeviceMappings)).To(Equal(1))
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize).To(Equal(int64(20)))
-				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType).To(Equal("gp3"))
+				Expect(string(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType)).To(Equal("gp3"))
 				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.Iops).To(BeNil())
 			})
 		})
@@ -432,7 +469,7 @@ var _ = Describe("LaunchTemplates", func() {
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
 				Expect(len(ltInput.LaunchTemplateData.BlockDeviceMappings)).To(Equal(1))
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize).To(Equal(int64(20)))
-				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType).To(Equal("gp3"))
+				Expect(string(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType)).To(Equal("gp3"))
 				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.Iops).To(BeNil())
 			})
 		})
@@ -468,18 +505,18 @@ var _ = Describe("LaunchTemplates", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs).To(Equal(&ec2.LaunchTemplateEbsBlockDeviceRequest{
-					VolumeSize:          aws.Int64(187),
-					VolumeType:          aws.String("io2"),
-					Iops:                aws.Int64(10_000),
+				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs).To(Equal(&ec2types.LaunchTemplateEbsBlockDeviceRequest{
+					VolumeSize:          aws.Int32(187),
+					VolumeType:          "io2",
+					Iops:                aws.Int32(10_000),
 					DeleteOnTermination: aws.Bool(true),
 					Encrypted:           aws.Bool(true),
 					KmsKeyId:            aws.String("arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
 				}))
-				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs).To(Equal(&ec2.LaunchTemplateEbsBlockDeviceRequest{
-					VolumeSize:          aws.Int64(200),
-					VolumeType:          aws.String("io2"),
-					Iops:                aws.Int64(10_000),
+				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs).To(Equal(&ec2types.LaunchTemplateEbsBlockDeviceRequest{
+					VolumeSize:          aws.Int32(200),
+					VolumeType:          "io2",
+					Iops:                aws.Int32(10_000),
 					DeleteOnTermination: aws.Bool(true),
 					Encrypted:           aws.Bool(true),
 					KmsKeyId:            aws.String("arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"),
@@ -519,8 +556,8 @@ var _ = Describe("LaunchTemplates", func() {
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
 				// Both of these values are rounded up when converting to Gibibytes
-				Expect(aws.Int64Value(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize)).To(BeNumerically("==", 4))
-				Expect(aws.Int64Value(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.VolumeSize)).To(BeNumerically("==", 2))
+				Expect(int64(aws.ToInt32(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize))).To(BeNumerically("==", 4))
+				Expect(int64(aws.ToInt32(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.VolumeSize))).To(BeNumerically("==", 2))
 			})
 		})
 		It("should default bottlerocket second volume with root volume size", func() {
@@ -534,11 +571,11 @@ var _ = Describe("LaunchTemplates", func() {
 				Expect(len(ltInput.LaunchTemplateData.BlockDeviceMappings)).To(Equal(2))
 				// Bottlerocket control volume
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize).To(Equal(int64(4)))
-				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType).To(Equal("gp3"))
+				Expect(string(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType)).To(Equal("gp3"))
 				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.Iops).To(BeNil())
 				// Bottlerocket user volume
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.VolumeSize).To(Equal(int64(20)))
-				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.VolumeType).To(Equal("gp3"))
+				Expect(string(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.VolumeType)).To(Equal("gp3"))
 				Expect(ltInput.LaunchTemplateData.BlockDeviceMappings[1].Ebs.Iops).To(BeNil())
 			})
 		})
@@ -578,7 +615,7 @@ var _ = Describe("LaunchTemplates", func() {
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
 				Expect(len(ltInput.LaunchTemplateData.BlockDeviceMappings)).To(Equal(1))
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeSize).To(Equal(int64(40)))
-				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType).To(Equal("io2"))
+				Expect(string(ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.VolumeType)).To(Equal("io2"))
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.Iops).To(Equal(int64(10_000)))
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.DeleteOnTermination).To(BeTrue())
 				Expect(*ltInput.LaunchTemplateData.BlockDeviceMappings[0].Ebs.Encrypted).To(BeTrue())
@@ -790,28 +827,24 @@ var _ = Describe("LaunchTemplates", func() {
 		})
 	})
 	Context("AL2", func() {
-		var info *ec2.InstanceTypeInfo
+		var info *ec2types.InstanceTypeInfo
 		BeforeEach(func() {
 			var ok bool
-			var instanceInfo []*ec2.InstanceTypeInfo
-			err := awsEnv.EC2API.DescribeInstanceTypesPagesWithContext(ctx, &ec2.DescribeInstanceTypesInput{
-				Filters: []*ec2.Filter{
+			output, err := awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+				Filters: []ec2types.Filter{
 					{
 						Name:   aws.String("supported-virtualization-type"),
-						Values: []*string{aws.String("hvm")},
+						Values: []string{"hvm"},
 					},
 					{
 						Name:   aws.String("processor-info.supported-architecture"),
-						Values: aws.StringSlice([]string{"x86_64", "arm64"}),
+						Values: []string{"x86_64", "arm64"},
 					},
 				},
-			}, func(page *ec2.DescribeInstanceTypesOutput, lastPage bool) bool {
-				instanceInfo = append(instanceInfo, page.InstanceTypes...)
-				return true
 			})
 			Expect(err).To(BeNil())
-			info, ok = lo.Find(instanceInfo, func(i *ec2.InstanceTypeInfo) bool {
-				return aws.StringValue(i.InstanceType) == "m5.xlarge"
+			info, ok = lo.Find(lo.ToSlicePtr(output.InstanceTypes), func(i *ec2types.InstanceTypeInfo) bool {
+				return i.InstanceType == "m5.xlarge"
 			})
 			Expect(ok).To(BeTrue())
 		})
@@ -828,28 +861,24 @@ var _ = Describe("LaunchTemplates", func() {
 		})
 	})
 	Context("Bottlerocket", func() {
-		var info *ec2.InstanceTypeInfo
+		var info *ec2types.InstanceTypeInfo
 		BeforeEach(func() {
 			var ok bool
-			var instanceInfo []*ec2.InstanceTypeInfo
-			err := awsEnv.EC2API.DescribeInstanceTypesPagesWithContext(ctx, &ec2.DescribeInstanceTypesInput{
-				Filters: []*ec2.Filter{
+			output, err := awsEnv.EC2API.DescribeInstanceTypes(ctx, &ec2.DescribeInstanceTypesInput{
+				Filters: []ec2types.Filter{
 					{
 						Name:   aws.String("supported-virtualization-type"),
-						Values: []*string{aws.String("hvm")},
+						Values: []string{"hvm"},
 					},
 					{
 						Name:   aws.String("processor-info.supported-architecture"),
-						Values: aws.StringSlice([]string{"x86_64", "arm64"}),
+						Values: []string{"x86_64", "arm64"},
 					},
 				},
-			}, func(page *ec2.DescribeInstanceTypesOutput, lastPage bool) bool {
-				instanceInfo = append(instanceInfo, page.InstanceTypes...)
-				return true
 			})
 			Expect(err).To(BeNil())
-			info, ok = lo.Find(instanceInfo, func(i *ec2.InstanceTypeInfo) bool {
-				return aws.StringValue(i.InstanceType) == "m5.xlarge"
+			info, ok = lo.Find(lo.ToSlicePtr(output.InstanceTypes), func(i *ec2types.InstanceTypeInfo) bool {
+				return i.InstanceType == "m5.xlarge"
 			})
 			Expect(ok).To(BeTrue())
 		})
@@ -1612,11 +1641,11 @@ var _ = Describe("LaunchTemplates", func() {
 		Context("Custom AMI Selector", func() {
 			It("should use ami selector specified in EC2NodeClass", func() {
 				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-123"),
-						Architecture: aws.String("x86_64"),
+						Architecture: "x86_64",
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
 					},
 				}})
@@ -1633,11 +1662,11 @@ var _ = Describe("LaunchTemplates", func() {
 				nodeClass.Spec.UserData = aws.String("special user data")
 				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
 				nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyCustom
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-123"),
-						Architecture: aws.String("x86_64"),
+						Architecture: "x86_64",
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
 					},
 				}})
@@ -1649,19 +1678,19 @@ var _ = Describe("LaunchTemplates", func() {
 			})
 			It("should correctly use ami selector with specific IDs in EC2NodeClass", func() {
 				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{ID: "ami-123"}, {ID: "ami-456"}}
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-123"),
-						Architecture: aws.String("x86_64"),
-						Tags:         []*ec2.Tag{{Key: aws.String(v1.LabelInstanceTypeStable), Value: aws.String("m5.large")}},
+						Architecture: "x86_64",
+						Tags:         []ec2types.Tag{{Key: aws.String(v1.LabelInstanceTypeStable), Value: aws.String("m5.large")}},
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
 					},
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-456"),
-						Architecture: aws.String("x86_64"),
-						Tags:         []*ec2.Tag{{Key: aws.String(v1.LabelInstanceTypeStable), Value: aws.String("m5.xlarge")}},
+						Architecture: "x86_64",
+						Tags:         []ec2types.Tag{{Key: aws.String(v1.LabelInstanceTypeStable), Value: aws.String("m5.xlarge")}},
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
 					},
 				}})
@@ -1671,26 +1700,26 @@ var _ = Describe("LaunchTemplates", func() {
 				ExpectScheduled(ctx, env.Client, pod)
 				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 2))
 				actualFilter := awsEnv.EC2API.CalledWithDescribeImagesInput.Pop().Filters
-				expectedFilter := []*ec2.Filter{
+				expectedFilter := []*ec2types.Filter{
 					{
 						Name:   aws.String("image-id"),
-						Values: aws.StringSlice([]string{"ami-123", "ami-456"}),
+						Values: []string{"ami-123", "ami-456"},
 					},
 				}
 				Expect(actualFilter).To(Equal(expectedFilter))
 			})
 			It("should create multiple launch templates when multiple amis are discovered with non-equivalent requirements", func() {
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-123"),
-						Architecture: aws.String("x86_64"),
+						Architecture: "x86_64",
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
 					},
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-456"),
-						Architecture: aws.String("arm64"),
+						Architecture: "arm64",
 						CreationDate: aws.String("2022-08-10T12:00:00Z"),
 					},
 				}})
@@ -1708,24 +1737,24 @@ var _ = Describe("LaunchTemplates", func() {
 				Expect(expectedImageIds.Equal(actualImageIds)).To(BeTrue())
 			})
 			It("should create a launch template with the newest compatible AMI when multiple amis are discovered", func() {
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-123"),
-						Architecture: aws.String("x86_64"),
+						Architecture: "x86_64",
 						CreationDate: aws.String("2020-01-01T12:00:00Z"),
 					},
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-456"),
-						Architecture: aws.String("x86_64"),
+						Architecture: "x86_64",
 						CreationDate: aws.String("2021-01-01T12:00:00Z"),
 					},
 					{
 						// Incompatible because required ARM64
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("ami-789"),
-						Architecture: aws.String("arm64"),
+						Architecture: "arm64",
 						CreationDate: aws.String("2022-01-01T12:00:00Z"),
 					},
 				}})
@@ -1751,7 +1780,7 @@ var _ = Describe("LaunchTemplates", func() {
 			})
 
 			It("should fail if no amis match selector.", func() {
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{}})
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{}})
 				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
 				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
 				pod := coretest.UnschedulablePod()
@@ -1760,8 +1789,8 @@ var _ = Describe("LaunchTemplates", func() {
 				Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(Equal(0))
 			})
 			It("should fail if no instanceType matches ami requirements.", func() {
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
-					{Name: aws.String(coretest.RandomName()), ImageId: aws.String("ami-123"), Architecture: aws.String("newnew"), CreationDate: aws.String("2022-01-01T12:00:00Z")}}})
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{
+					{Name: aws.String(coretest.RandomName()), ImageId: aws.String("ami-123"), Architecture: "newnew", CreationDate: aws.String("2022-01-01T12:00:00Z")}}})
 				nodeClass.Spec.AMISelectorTerms = []v1beta1.AMISelectorTerm{{Tags: map[string]string{"*": "*"}}}
 				ExpectApplied(ctx, env.Client, nodeClass, nodePool)
 				pod := coretest.UnschedulablePod()
@@ -1774,11 +1803,11 @@ var _ = Describe("LaunchTemplates", func() {
 				awsEnv.SSMAPI.Parameters = map[string]string{
 					fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2/recommended/image_id", version): "test-ami-123",
 				}
-				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []*ec2.Image{
+				awsEnv.EC2API.DescribeImagesOutput.Set(&ec2.DescribeImagesOutput{Images: []ec2types.Image{
 					{
 						Name:         aws.String(coretest.RandomName()),
 						ImageId:      aws.String("test-ami-123"),
-						Architecture: aws.String("x86_64"),
+						Architecture: "x86_64",
 						CreationDate: aws.String("2022-08-15T12:00:00Z"),
 					},
 				}})
@@ -1897,7 +1926,7 @@ var _ = Describe("LaunchTemplates", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				Expect(aws.BoolValue(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeFalse())
+				Expect(aws.ToBool(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeFalse())
 			})
 		})
 		It("should pass detailed monitoring setting to the launch template at creation", func() {
@@ -1909,16 +1938,16 @@ var _ = Describe("LaunchTemplates", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 			Expect(awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.Len()).To(BeNumerically(">=", 1))
 			awsEnv.EC2API.CalledWithCreateLaunchTemplateInput.ForEach(func(ltInput *ec2.CreateLaunchTemplateInput) {
-				Expect(aws.BoolValue(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeTrue())
+				Expect(aws.ToBool(ltInput.LaunchTemplateData.Monitoring.Enabled)).To(BeTrue())
 			})
 		})
 	})
 })
 
 // ExpectTags verifies that the expected tags are a subset of the tags found
-func ExpectTags(tags []*ec2.Tag, expected map[string]string) {
+func ExpectTags(tags []ec2types.Tag, expected map[string]string) {
 	GinkgoHelper()
-	existingTags := lo.SliceToMap(tags, func(t *ec2.Tag) (string, string) { return *t.Key, *t.Value })
+	existingTags := lo.SliceToMap(tags, func(t ec2types.Tag) (string, string) { return *t.Key, *t.Value })
 	for expKey, expValue := range expected {
 		foundValue, ok := existingTags[expKey]
 		Expect(ok).To(BeTrue(), fmt.Sprintf("expected to find tag %s in %s", expKey, existingTags))
@@ -1926,9 +1955,9 @@ func ExpectTags(tags []*ec2.Tag, expected map[string]string) {
 	}
 }
 
-func ExpectTagsNotFound(tags []*ec2.Tag, expectNotFound map[string]string) {
+func ExpectTagsNotFound(tags []ec2types.Tag, expectNotFound map[string]string) {
 	GinkgoHelper()
-	existingTags := lo.SliceToMap(tags, func(t *ec2.Tag) (string, string) { return *t.Key, *t.Value })
+	existingTags := lo.SliceToMap(tags, func(t ec2types.Tag) (string, string) { return *t.Key, *t.Value })
 	for k, v := range expectNotFound {
 		elem, ok := existingTags[k]
 		Expect(!ok || v != elem).To(BeTrue())
@@ -0,0 +1,226 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package noderole
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// managedPolicies are the AWS managed policies that nodes need to join the cluster
+var managedPolicies = []string{
+	"AmazonEKSWorkerNodePolicy",
+	"AmazonEKS_CNI_Policy",
+	"AmazonEC2ContainerRegistryReadOnly",
+	"AmazonSSMManagedInstanceCore",
+}
+
+// assumeRolePolicyDocument allows EC2 to assume the role. The EC2 service principal has the DNS suffix of the partition,
+// e.g. ec2.amazonaws.com.cn in aws-cn.
+const assumeRolePolicyDocument = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ec2.%s"},"Action":"sts:AssumeRole"}]}`
+
+// Provider creates the node roles of EC2NodeClasses when --manage-node-roles is set. Roles are only modified or deleted
+// when they were created by Karpenter for this cluster, so roles that are managed outside of Karpenter are left as is.
+type Provider struct {
+	region string
+	iamapi sdk.IAMAPI
+	eksapi sdk.EKSAPI
+	cache  *cache.Cache
+}
+
+func NewProvider(region string, iamapi sdk.IAMAPI, eksapi sdk.EKSAPI, cache *cache.Cache) *Provider {
+	return &Provider{
+		region: region,
+		iamapi: iamapi,
+		eksapi: eksapi,
+		cache:  cache,
+	}
+}
+
+// Create creates the role of the EC2NodeClass if it doesn't exist, and makes sure that roles that Karpenter manages
+// have the node policies attached and an EKS access entry
+func (p *Provider) Create(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
+	roleName := nodeClass.Spec.Role
+	if _, ok := p.cache.Get(roleName); ok {
+		return nil
+	}
+	role, err := p.getOrCreate(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	if !p.managed(ctx, role) {
+		p.cache.SetDefault(roleName, nil)
+		return nil
+	}
+	policyARNs, err := p.policyARNs(ctx)
+	if err != nil {
+		return err
+	}
+	attached := sets.New[string]()
+	paginator := iam.NewListAttachedRolePoliciesPaginator(p.iamapi, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("listing policies of role %q, %w", roleName, err)
+		}
+		for _, policy := range out.AttachedPolicies {
+			attached.Insert(aws.ToString(policy.PolicyArn))
+		}
+	}
+	for _, policyARN := range policyARNs {
+		if attached.Has(policyARN) {
+			continue
+		}
+		if _, err = p.iamapi.AttachRolePolicy(ctx, &iam.AttachRolePolicyInput{
+			RoleName:  aws.String(roleName),
+			PolicyArn: aws.String(policyARN),
+		}); err != nil {
+			return fmt.Errorf("attaching policy %q to role %q, %w", policyARN, roleName, err)
+		}
+	}
+	if err = p.createAccessEntry(ctx, nodeClass, role); err != nil {
+		return err
+	}
+	p.cache.SetDefault(roleName, nil)
+	return nil
+}
+
+// Delete deletes the role of the EC2NodeClass and its EKS access entry if the role is managed by Karpenter
+func (p *Provider) Delete(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) error {
+	roleName := nodeClass.Spec.Role
+	out, err := p.iamapi.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+	if err != nil {
+		return awserrors.IgnoreNotFound(fmt.Errorf("getting role %q, %w", roleName, err))
+	}
+	if !p.managed(ctx, out.Role) {
+		return nil
+	}
+	if _, err = p.eksapi.DeleteAccessEntry(ctx, &eks.DeleteAccessEntryInput{
+		ClusterName:  aws.String(options.FromContext(ctx).ClusterName),
+		PrincipalArn: out.Role.Arn,
+	}); err != nil && !errors.As(err, new(*ekstypes.ResourceNotFoundException)) {
+		return fmt.Errorf("deleting access entry for role %q, %w", roleName, err)
+	}
+	var policyARNs []string
+	paginator := iam.NewListAttachedRolePoliciesPaginator(p.iamapi, &iam.ListAttachedRolePoliciesInput{RoleName: aws.String(roleName)})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return awserrors.IgnoreNotFound(fmt.Errorf("listing policies of role %q, %w", roleName, err))
+		}
+		for _, policy := range out.AttachedPolicies {
+			policyARNs = append(policyARNs, aws.ToString(policy.PolicyArn))
+		}
+	}
+	for _, policyARN := range policyARNs {
+		if _, err = p.iamapi.DetachRolePolicy(ctx, &iam.DetachRolePolicyInput{
+			RoleName:  aws.String(roleName),
+			PolicyArn: aws.String(policyARN),
+		}); awserrors.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("detaching policy %q from role %q, %w", policyARN, roleName, err)
+		}
+	}
+	if _, err = p.iamapi.DeleteRole(ctx, &iam.DeleteRoleInput{RoleName: aws.String(roleName)}); err != nil {
+		return awserrors.IgnoreNotFound(fmt.Errorf("deleting role %q, %w", roleName, err))
+	}
+	p.cache.Delete(roleName)
+	logging.FromContext(ctx).With("role", roleName).Infof("deleted node role")
+	return nil
+}
+
+func (p *Provider) getOrCreate(ctx context.Context, roleName string) (*iamtypes.Role, error) {
+	out, err := p.iamapi.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+	if err == nil {
+		return out.Role, nil
+	}
+	if !awserrors.IsNotFound(err) {
+		return nil, fmt.Errorf("getting role %q, %w", roleName, err)
+	}
+	tags := map[string]string{
+		fmt.Sprintf("kubernetes.io/cluster/%s", options.FromContext(ctx).ClusterName): "owned",
+		corev1beta1.ManagedByAnnotationKey:                                            options.FromContext(ctx).ClusterName,
+	}
+	o, err := p.iamapi.CreateRole(ctx, &iam.CreateRoleInput{
+		RoleName:                 aws.String(roleName),
+		AssumeRolePolicyDocument: aws.String(fmt.Sprintf(assumeRolePolicyDocument, utils.Partition(p.region).DNSSuffix())),
+		Description:              aws.String(fmt.Sprintf("Node role for Karpenter in cluster %s", options.FromContext(ctx).ClusterName)),
+		Tags:                     lo.MapToSlice(tags, func(k, v string) iamtypes.Tag { return iamtypes.Tag{Key: aws.String(k), Value: aws.String(v)} }),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating role %q, %w", roleName, err)
+	}
+	logging.FromContext(ctx).With("role", roleName).Infof("created node role")
+	return o.Role, nil
+}
+
+// managed returns true if the role was created by Karpenter for this cluster
+func (p *Provider) managed(ctx context.Context, role *iamtypes.Role) bool {
+	return lo.ContainsBy(role.Tags, func(t iamtypes.Tag) bool {
+		return aws.ToString(t.Key) == corev1beta1.ManagedByAnnotationKey && aws.ToString(t.Value) == options.FromContext(ctx).ClusterName
+	})
+}
+
+func (p *Provider) policyARNs(ctx context.Context) ([]string, error) {
+	partition := utils.Partition(p.region).ID()
+	extra, err := options.ParseNodeRolePolicyARNs(options.FromContext(ctx).NodeRolePolicyARNs)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Uniq(append(lo.Map(managedPolicies, func(name string, _ int) string {
+		return fmt.Sprintf("arn:%s:iam::aws:policy/%s", partition, name)
+	}), extra...)), nil
+}
+
+// createAccessEntry allows nodes with the role to join the cluster. Clusters that only authenticate with the aws-auth
+// ConfigMap don't support access entries, so the role must be mapped in the ConfigMap instead.
+func (p *Provider) createAccessEntry(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, role *iamtypes.Role) error {
+	entryType := "EC2_LINUX"
+	if lo.Contains([]string{v1beta1.AMIFamilyWindows2019, v1beta1.AMIFamilyWindows2022}, lo.FromPtr(nodeClass.Spec.AMIFamily)) {
+		entryType = "EC2_WINDOWS"
+	}
+	_, err := p.eksapi.CreateAccessEntry(ctx, &eks.CreateAccessEntryInput{
+		ClusterName:  aws.String(options.FromContext(ctx).ClusterName),
+		PrincipalArn: role.Arn,
+		Type:         aws.String(entryType),
+	})
+	switch {
+	case err == nil, errors.As(err, new(*ekstypes.ResourceInUseException)):
+		return nil
+	case errors.As(err, new(*ekstypes.InvalidRequestException)):
+		logging.FromContext(ctx).With("role", aws.ToString(role.RoleName)).Errorf("creating access entry, the role must be mapped in the aws-auth ConfigMap, %s", err)
+		return nil
+	default:
+		return fmt.Errorf("creating access entry for role %q, %w", aws.ToString(role.RoleName), err)
+	}
+}
@@ -0,0 +1,352 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
+	savingsplanstypes "github.com/aws/aws-sdk-go-v2/service/savingsplans/types"
+	"github.com/samber/lo"
+	"knative.dev/pkg/logging"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+)
+
+// NewSavingsPlansAPI returns a savings plans API. The savings plans API is a global service, so the region
+// of the config is not used to resolve the endpoint.
+func NewSavingsPlansAPI(cfg aws.Config) sdk.SavingsPlansAPI {
+	// The Savings Plans API doesn't have FIPS endpoints, so it's called through its standard endpoint
+	return savingsplans.NewFromConfig(cfg, func(o *savingsplans.Options) {
+		o.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateDisabled
+	})
+}
+
+// UpdateCommitmentPricing discovers the active Reserved Instances and Savings Plans that apply to Linux, shared
+// tenancy capacity in the region, and records the committed rate of each instance type while the commitments have
+// coverage left that isn't used by the running on-demand instances of the account. Reserved Instances are priced at
+// their recurring hourly charge since any upfront payment has already been made, and zonal Reserved Instances only
+// apply in their zone. Savings Plans are priced at the plan rate for the instance type while the hourly commitment of
+// the plans exceeds the rates of the running instances that Reserved Instances don't cover. Commitment prices are
+// cleared while commitment aware pricing is disabled, so that prices that were discovered while it was enabled aren't used.
+func (p *Provider) UpdateCommitmentPricing(ctx context.Context) error {
+	if !options.FromContext(ctx).CommitmentAwarePricing {
+		p.clearCommitmentPricing()
+		return nil
+	}
+	reservations, err := p.fetchReservedInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving reserved instance pricing data, %w", err)
+	}
+	plans := savingsPlans{rates: map[string]float64{}}
+	// the savings plans API doesn't have a VPC endpoint, so we only consider reserved instances in an isolated VPC
+	if options.FromContext(ctx).IsolatedVPC {
+		if p.cm.HasChanged("savings-plans-prices", nil) {
+			logging.FromContext(ctx).Debug("running in an isolated VPC, savings plans pricing information will not be updated")
+		}
+	} else if !utils.ServiceAvailable(p.region, savingsplans.ServiceID) {
+		if p.cm.HasChanged("savings-plans-prices", nil) {
+			logging.FromContext(ctx).With("partition", utils.Partition(p.region).ID()).Debug("savings plans API isn't available in the partition, savings plans pricing information will not be updated")
+		}
+	} else if plans, err = p.fetchSavingsPlans(ctx); err != nil {
+		return fmt.Errorf("retrieving savings plans pricing data, %w", err)
+	}
+	running, err := p.runningOnDemandInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieving running on-demand instances, %w", err)
+	}
+	prices, zonalPrices := commitmentPrices(reservations, plans, running)
+
+	defer p.updatePriceMetrics()
+	p.muCommitment.Lock()
+	defer p.muCommitment.Unlock()
+	p.commitmentPrices = prices
+	p.zonalCommitmentPrices = zonalPrices
+	if p.cm.HasChanged("commitment-prices", []any{p.commitmentPrices, p.zonalCommitmentPrices}) {
+		logging.FromContext(ctx).With("instance-type-count", len(p.commitmentPrices), "zonal-instance-type-count", len(p.zonalCommitmentPrices)).Debugf("updated commitment pricing")
+	}
+	return nil
+}
+
+// clearCommitmentPricing removes the commitment prices, if there are any
+func (p *Provider) clearCommitmentPricing() {
+	p.muCommitment.Lock()
+	if len(p.commitmentPrices) == 0 && len(p.zonalCommitmentPrices) == 0 {
+		p.muCommitment.Unlock()
+		return
+	}
+	p.commitmentPrices = map[string]float64{}
+	p.zonalCommitmentPrices = map[string]map[string]float64{}
+	p.muCommitment.Unlock()
+	p.updatePriceMetrics()
+}
+
+// reservation is a group of active Reserved Instances of an instance type. Regional Reserved Instances don't have a zone.
+type reservation struct {
+	instanceType string
+	zone         string
+	count        int
+	price        float64
+}
+
+// savingsPlans are the hourly commitment of the active Savings Plans, and the lowest rate that they cover each instance
+// type at
+type savingsPlans struct {
+	commitment float64
+	rates      map[string]float64
+}
+
+// commitmentPrices returns the committed rates of the instance types that commitments have coverage left for, both
+// regionally and by zone. Running instances use up zonal Reserved Instances first, then regional Reserved Instances, and
+// then Savings Plans, which is the order that AWS applies commitments in. Instance size flexibility of regional Reserved
+// Instances isn't considered, so they only cover their own instance type.
+func commitmentPrices(reservations []reservation, plans savingsPlans, running map[string]map[string]int) (map[string]float64, map[string]map[string]float64) {
+	prices := map[string]float64{}
+	zonalPrices := map[string]map[string]float64{}
+	zonal := map[string]map[string]reservation{}
+	regional := map[string]reservation{}
+	for _, r := range reservations {
+		if r.zone == "" {
+			regional[r.instanceType] = merge(regional[r.instanceType], r)
+			continue
+		}
+		if zonal[r.instanceType] == nil {
+			zonal[r.instanceType] = map[string]reservation{}
+		}
+		zonal[r.instanceType][r.zone] = merge(zonal[r.instanceType][r.zone], r)
+	}
+	// uncovered is the number of running instances of each instance type that zonal Reserved Instances don't cover
+	uncovered := map[string]int{}
+	for instanceType, zones := range running {
+		for zone, count := range zones {
+			uncovered[instanceType] += max(count-zonal[instanceType][zone].count, 0)
+		}
+	}
+	for instanceType, zones := range zonal {
+		for zone, r := range zones {
+			if r.count > running[instanceType][zone] {
+				if zonalPrices[instanceType] == nil {
+					zonalPrices[instanceType] = map[string]float64{}
+				}
+				zonalPrices[instanceType][zone] = r.price
+			}
+		}
+	}
+	for instanceType, r := range regional {
+		if r.count > uncovered[instanceType] {
+			prices[instanceType] = r.price
+		}
+		uncovered[instanceType] = max(uncovered[instanceType]-r.count, 0)
+	}
+	// the commitment that's left is the hourly commitment of the plans that isn't used by the running instances that
+	// Reserved Instances don't cover, which are billed at their plan rate
+	remaining := plans.commitment
+	for instanceType, count := range uncovered {
+		remaining -= float64(count) * plans.rates[instanceType]
+	}
+	for instanceType, rate := range plans.rates {
+		if rate <= remaining {
+			prices[instanceType] = math.Min(lo.ValueOr(prices, instanceType, rate), rate)
+		}
+	}
+	return prices, zonalPrices
+}
+
+// merge returns a reservation of both groups of Reserved Instances, which is priced at the lower of their prices
+func merge(r, other reservation) reservation {
+	if r.count == 0 {
+		return other
+	}
+	return reservation{instanceType: r.instanceType, zone: r.zone, count: r.count + other.count, price: math.Min(r.price, other.price)}
+}
+
+func (p *Provider) fetchReservedInstances(ctx context.Context) ([]reservation, error) {
+	out, err := p.ec2.DescribeReservedInstances(ctx, &ec2.DescribeReservedInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("state"),
+				Values: []string{string(ec2types.ReservedInstanceStateActive)},
+			},
+			{
+				Name:   aws.String("instance-tenancy"),
+				Values: []string{string(ec2types.TenancyDefault)},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var reservations []reservation
+	for _, ri := range out.ReservedInstances {
+		if !strings.HasPrefix(string(ri.ProductDescription), "Linux/UNIX") {
+			continue
+		}
+		price := float64(aws.ToFloat32(ri.UsagePrice))
+		for _, charge := range ri.RecurringCharges {
+			if charge.Frequency == ec2types.RecurringChargeFrequencyHourly {
+				price += aws.ToFloat64(charge.Amount)
+			}
+		}
+		reservations = append(reservations, reservation{
+			instanceType: string(ri.InstanceType),
+			zone:         lo.Ternary(ri.Scope == ec2types.ScopeAvailabilityZone, aws.ToString(ri.AvailabilityZone), ""),
+			count:        int(aws.ToInt32(ri.InstanceCount)),
+			price:        price,
+		})
+	}
+	return reservations, nil
+}
+
+// runningOnDemandInstances returns the number of running Linux, shared tenancy on-demand instances of the account in
+// the region, by instance type and zone. These are the instances that commitments apply to, whether or not Karpenter
+// launched them.
+func (p *Provider) runningOnDemandInstances(ctx context.Context) (map[string]map[string]int, error) {
+	running := map[string]map[string]int{}
+	input := &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: []string{string(ec2types.InstanceStateNameRunning)},
+			},
+		},
+	}
+	for {
+		out, err := p.ec2.DescribeInstances(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range out.Reservations {
+			for _, instance := range reservation.Instances {
+				// spot and scheduled instances are billed separately, and the commitments that are discovered don't cover
+				// Windows or dedicated instances
+				if instance.InstanceLifecycle != "" || instance.Platform != "" ||
+					(instance.Placement != nil && instance.Placement.Tenancy != "" && instance.Placement.Tenancy != ec2types.TenancyDefault) {
+					continue
+				}
+				zone := aws.ToString(lo.FromPtr(instance.Placement).AvailabilityZone)
+				if running[string(instance.InstanceType)] == nil {
+					running[string(instance.InstanceType)] = map[string]int{}
+				}
+				running[string(instance.InstanceType)][zone]++
+			}
+		}
+		if aws.ToString(out.NextToken) == "" {
+			return running, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+func (p *Provider) fetchSavingsPlans(ctx context.Context) (savingsPlans, error) {
+	var plans []savingsplanstypes.SavingsPlan
+	input := &savingsplans.DescribeSavingsPlansInput{
+		States: []savingsplanstypes.SavingsPlanState{savingsplanstypes.SavingsPlanStateActive},
+	}
+	for {
+		out, err := p.savingsPlans.DescribeSavingsPlans(ctx, input)
+		if err != nil {
+			return savingsPlans{}, err
+		}
+		plans = append(plans, out.SavingsPlans...)
+		if aws.ToString(out.NextToken) == "" {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	result := savingsPlans{rates: map[string]float64{}}
+	for _, plan := range plans {
+		if !lo.Contains(plan.ProductTypes, savingsplanstypes.SavingsPlanProductTypeEc2) {
+			continue
+		}
+		// EC2 Instance Savings Plans are scoped to a single region, Compute Savings Plans apply to all regions
+		if plan.SavingsPlanType == savingsplanstypes.SavingsPlanTypeEc2Instance && aws.ToString(plan.Region) != p.region {
+			continue
+		}
+		commitment, err := strconv.ParseFloat(aws.ToString(plan.Commitment), 64)
+		// these errors shouldn't occur, but if the savings plans API does have an error, we ignore the plan
+		if err != nil {
+			logging.FromContext(ctx).Debugf("unable to parse savings plan commitment %#v", plan)
+			continue
+		}
+		if err = p.fetchSavingsPlanRates(ctx, aws.ToString(plan.SavingsPlanId), result.rates); err != nil {
+			return savingsPlans{}, err
+		}
+		result.commitment += commitment
+	}
+	return result, nil
+}
+
+func (p *Provider) fetchSavingsPlanRates(ctx context.Context, id string, prices map[string]float64) error {
+	input := &savingsplans.DescribeSavingsPlanRatesInput{
+		SavingsPlanId: aws.String(id),
+		Filters: []savingsplanstypes.SavingsPlanRateFilter{
+			{
+				Name:   savingsplanstypes.SavingsPlanRateFilterNameRegion,
+				Values: []string{p.region},
+			},
+			{
+				Name:   savingsplanstypes.SavingsPlanRateFilterNameProductType,
+				Values: []string{string(savingsplanstypes.SavingsPlanProductTypeEc2)},
+			},
+			{
+				Name:   savingsplanstypes.SavingsPlanRateFilterNameProductDescription,
+				Values: []string{"Linux/UNIX"},
+			},
+			{
+				Name:   savingsplanstypes.SavingsPlanRateFilterNameTenancy,
+				Values: []string{"shared"},
+			},
+		},
+	}
+	for {
+		out, err := p.savingsPlans.DescribeSavingsPlanRates(ctx, input)
+		if err != nil {
+			return err
+		}
+		for _, rate := range out.SearchResults {
+			// only consider box usage, this excludes rates for dedicated hosts and other usage types
+			if !strings.Contains(aws.ToString(rate.UsageType), "BoxUsage") {
+				continue
+			}
+			property, ok := lo.Find(rate.Properties, func(p savingsplanstypes.SavingsPlanRateProperty) bool {
+				return p.Name == savingsplanstypes.SavingsPlanRatePropertyKeyInstanceType
+			})
+			if !ok {
+				continue
+			}
+			price, err := strconv.ParseFloat(aws.ToString(rate.Rate), 64)
+			// these errors shouldn't occur, but if the savings plans API does have an error, we ignore the record
+			if err != nil {
+				logging.FromContext(ctx).Debugf("unable to parse savings plan rate %#v", rate)
+				continue
+			}
+			instanceType := aws.ToString(property.Value)
+			prices[instanceType] = math.Min(lo.ValueOr(prices, instanceType, price), price)
+		}
+		if aws.ToString(out.NextToken) == "" {
+			return nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
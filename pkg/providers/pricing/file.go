@@ -0,0 +1,89 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/yaml"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+)
+
+// stalePricingFileAge is the age after which prices loaded from a pricing file are considered stale
+const stalePricingFileAge = 30 * 24 * time.Hour
+
+// File is a static set of prices for the region that is loaded from disk, typically from a mounted ConfigMap. This
+// is used in place of the pricing and EC2 APIs in partitions and networks where they can't be reached.
+type File struct {
+	// UpdatedAt is the time at which the prices were collected. If not set, the modification time of the file is used.
+	UpdatedAt time.Time `json:"updatedAt,omitempty"`
+	// OnDemand maps an instance type to its hourly on-demand price
+	OnDemand map[string]float64 `json:"onDemand,omitempty"`
+	// Spot maps an instance type and zone to its hourly spot price. If no spot prices are provided, spot prices are
+	// retrieved from the EC2 API.
+	Spot map[string]map[string]float64 `json:"spot,omitempty"`
+}
+
+func readFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{}
+	if err := yaml.UnmarshalStrict(data, f); err != nil {
+		return nil, fmt.Errorf("unmarshaling pricing file, %w", err)
+	}
+	if f.UpdatedAt.IsZero() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		f.UpdatedAt = info.ModTime()
+	}
+	return f, nil
+}
+
+func (p *Provider) updateOnDemandPricingFromFile(ctx context.Context, path string) error {
+	f, err := readFile(path)
+	if err != nil {
+		return fmt.Errorf("reading pricing file, %w", err)
+	}
+	if len(f.OnDemand) == 0 {
+		return fmt.Errorf("no on-demand pricing found in pricing file")
+	}
+
+	defer p.updatePriceMetrics()
+	p.muOnDemand.Lock()
+	defer p.muOnDemand.Unlock()
+	p.onDemandPrices = f.OnDemand
+	lastUpdatedTimestamp.With(prometheus.Labels{capacityTypeLabel: corev1beta1.CapacityTypeOnDemand}).Set(float64(f.UpdatedAt.Unix()))
+	p.warnIfStale(ctx, f)
+	if p.cm.HasChanged("on-demand-prices", p.onDemandPrices) {
+		logging.FromContext(ctx).With("instance-type-count", len(p.onDemandPrices), "updated-at", f.UpdatedAt).Debugf("updated on-demand pricing from pricing file")
+	}
+	return nil
+}
+
+func (p *Provider) warnIfStale(ctx context.Context, f *File) {
+	if age := time.Since(f.UpdatedAt); age > stalePricingFileAge && p.cm.HasChanged("stale-pricing-file", f.UpdatedAt) {
+		logging.FromContext(ctx).With("updated-at", f.UpdatedAt, "age", age.Truncate(time.Hour)).Warnf("pricing file is stale, prices may not reflect current AWS pricing")
+	}
+}
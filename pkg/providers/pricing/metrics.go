@@ -0,0 +1,60 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	pricingSubsystem  = "pricing"
+	instanceTypeLabel = "instance_type"
+	capacityTypeLabel = "capacity_type"
+	zoneLabel         = "zone"
+)
+
+var (
+	instanceTypePrice = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: pricingSubsystem,
+			Name:      "instance_type_price",
+			Help:      "Hourly price known by the pricing provider, including commitment adjustments and price overrides. Labeled by instance type, capacity type, and zone. On-demand prices are regional and have an empty zone.",
+		},
+		[]string{
+			instanceTypeLabel,
+			capacityTypeLabel,
+			zoneLabel,
+		},
+	)
+	lastUpdatedTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: pricingSubsystem,
+			Name:      "last_updated_timestamp_seconds",
+			Help:      "Unix timestamp at which the pricing data in use was collected, either from the last successful refresh from the AWS APIs or from the pricing file. Labeled by capacity type.",
+		},
+		[]string{
+			capacityTypeLabel,
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(instanceTypePrice, lastUpdatedTimestamp)
+}
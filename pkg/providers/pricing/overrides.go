@@ -0,0 +1,94 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pricing
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"sigs.k8s.io/yaml"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+)
+
+// Override is an operator provided price for an instance type that takes precedence over the prices discovered
+// from the pricing and EC2 APIs. Overrides without a capacity type apply to both spot and on-demand capacity.
+// On-demand prices are regional, so a zone may only be specified for spot overrides.
+type Override struct {
+	InstanceType string  `json:"instanceType"`
+	CapacityType string  `json:"capacityType,omitempty"`
+	Zone         string  `json:"zone,omitempty"`
+	Price        float64 `json:"price"`
+}
+
+type overrideKey struct {
+	instanceType string
+	capacityType string
+	zone         string
+}
+
+// ParseOverrides parses a YAML or JSON list of price overrides
+func ParseOverrides(data []byte) ([]Override, error) {
+	var overrides []Override
+	if err := yaml.UnmarshalStrict(data, &overrides); err != nil {
+		return nil, fmt.Errorf("unmarshaling price overrides, %w", err)
+	}
+	var errs error
+	for i, o := range overrides {
+		if o.InstanceType == "" {
+			errs = multierr.Append(errs, fmt.Errorf("override %d, instanceType is required", i))
+		}
+		if o.CapacityType != "" && !lo.Contains([]string{corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand}, o.CapacityType) {
+			errs = multierr.Append(errs, fmt.Errorf("override %d, capacityType must be one of %s or %s", i, corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand))
+		}
+		if o.Zone != "" && o.CapacityType != corev1beta1.CapacityTypeSpot {
+			errs = multierr.Append(errs, fmt.Errorf("override %d, zone may only be specified for the %s capacityType", i, corev1beta1.CapacityTypeSpot))
+		}
+		if o.Price < 0 {
+			errs = multierr.Append(errs, fmt.Errorf("override %d, price must be non-negative", i))
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return overrides, nil
+}
+
+// SetOverrides replaces the set of operator provided price overrides
+func (p *Provider) SetOverrides(overrides []Override) {
+	defer p.updatePriceMetrics()
+	p.muOverride.Lock()
+	defer p.muOverride.Unlock()
+	p.overrides = lo.SliceToMap(overrides, func(o Override) (overrideKey, float64) {
+		return overrideKey{instanceType: o.InstanceType, capacityType: o.CapacityType, zone: o.Zone}, o.Price
+	})
+}
+
+// override returns the most specific operator provided price for the instance type, capacity type, and zone
+func (p *Provider) override(instanceType, capacityType, zone string) (float64, bool) {
+	p.muOverride.RLock()
+	defer p.muOverride.RUnlock()
+	for _, key := range []overrideKey{
+		{instanceType: instanceType, capacityType: capacityType, zone: zone},
+		{instanceType: instanceType, capacityType: capacityType},
+		{instanceType: instanceType},
+	} {
+		if price, ok := p.overrides[key]; ok {
+			return price, true
+		}
+	}
+	return 0.0, false
+}
@@ -15,7 +15,6 @@ limitations under the License.
 package pricing
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -25,23 +24,42 @@ import (
 	"sync"
 	"time"
 
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
-
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
-	"github.com/aws/aws-sdk-go/service/pricing"
-	"github.com/aws/aws-sdk-go/service/pricing/pricingiface"
+	"github.com/aws/karpenter-provider-aws/pkg/utils"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/tracing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingtypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	"knative.dev/pkg/logging"
 
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 )
 
 var initialOnDemandPrices = lo.Assign(InitialOnDemandPricesAWS, InitialOnDemandPricesUSGov, InitialOnDemandPricesCN)
 
+// staticPricingPartitionRegions are the regions whose static prices are used for regions without static prices, by
+// partition. Regions in other partitions use the prices of us-east-1.
+var staticPricingPartitionRegions = map[string]string{
+	utils.AWSCNPartitionID:    "cn-north-1",
+	utils.AWSUSGovPartitionID: "us-gov-west-1",
+}
+
+// pricingAPIPartitionRegions are the regions that the pricing API is called in, by partition, for partitions other
+// than the aws partition which have the pricing API available in a single region
+var pricingAPIPartitionRegions = map[string]string{
+	utils.AWSCNPartitionID: "cn-northwest-1",
+	"aws-iso":              "us-iso-east-1",
+	"aws-iso-b":            "us-isob-east-1",
+}
+
 // Provider provides actual pricing data to the AWS cloud provider to allow it to make more informed decisions
 // regarding which instances to launch.  This is initialized at startup with a periodically updated static price list to
 // support running in locations where pricing data is unavailable.  In those cases the static pricing data provides a
@@ -49,10 +67,11 @@ var initialOnDemandPrices = lo.Assign(InitialOnDemandPricesAWS, InitialOnDemandP
 // fails, the previous pricing information is retained and used which may be the static initial pricing data if pricing
 // updates never succeed.
 type Provider struct {
-	ec2     ec2iface.EC2API
-	pricing pricingiface.PricingAPI
-	region  string
-	cm      *pretty.ChangeMonitor
+	ec2          sdk.EC2API
+	pricing      sdk.PricingAPI
+	savingsPlans sdk.SavingsPlansAPI
+	region       string
+	cm           *pretty.ChangeMonitor
 
 	muOnDemand     sync.RWMutex
 	onDemandPrices map[string]float64
@@ -60,6 +79,19 @@ type Provider struct {
 	muSpot             sync.RWMutex
 	spotPrices         map[string]zonal
 	spotPricingUpdated bool
+
+	// commitmentPrices are the committed rates of instance types that commitments have coverage left for in every zone,
+	// and zonalCommitmentPrices are the rates of zonal Reserved Instances with coverage left, by instance type and zone
+	muCommitment          sync.RWMutex
+	commitmentPrices      map[string]float64
+	zonalCommitmentPrices map[string]map[string]float64
+
+	muOverride sync.RWMutex
+	overrides  map[overrideKey]float64
+
+	// muMetrics serializes updates to the price metrics, and priceMetrics are the series that were last published
+	muMetrics    sync.Mutex
+	priceMetrics map[string]prometheus.Labels
 }
 
 // zonalPricing is used to capture the per-zone price
@@ -79,29 +111,36 @@ func newZonalPricing(defaultPrice float64) zonal {
 	return z
 }
 
-// NewPricingAPI returns a pricing API configured based on a particular region
-func NewAPI(sess *session.Session, region string) pricingiface.PricingAPI {
-	if sess == nil {
-		return nil
-	}
+// NewAPI returns a pricing API configured based on a particular region. If an endpoint is provided, such as a VPC
+// endpoint, it is used in place of the default endpoint for the pricing API region.
+func NewAPI(cfg aws.Config, region string, endpoint string) sdk.PricingAPI {
 	// pricing API doesn't have an endpoint in all regions
 	pricingAPIRegion := "us-east-1"
-	if strings.HasPrefix(region, "ap-") {
+	if partition := utils.Partition(region); partition.ID() != utils.AWSPartitionID {
+		// other partitions have a single pricing API region, if the pricing API is available at all
+		pricingAPIRegion = lo.ValueOr(pricingAPIPartitionRegions, partition.ID(), pricingAPIRegion)
+	} else if strings.HasPrefix(region, "ap-") {
 		pricingAPIRegion = "ap-south-1"
-	} else if strings.HasPrefix(region, "cn-") {
-		pricingAPIRegion = "cn-northwest-1"
 	} else if strings.HasPrefix(region, "eu-") {
 		pricingAPIRegion = "eu-central-1"
 	}
-	return pricing.New(sess, &aws.Config{Region: aws.String(pricingAPIRegion)})
+	// The pricing API doesn't have FIPS endpoints, so it's called through its standard endpoint
+	return pricing.NewFromConfig(cfg, func(o *pricing.Options) {
+		o.Region = pricingAPIRegion
+		o.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateDisabled
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
 }
 
-func NewProvider(_ context.Context, pricing pricingiface.PricingAPI, ec2Api ec2iface.EC2API, region string) *Provider {
+func NewProvider(_ context.Context, pricing sdk.PricingAPI, ec2Api sdk.EC2API, savingsPlans sdk.SavingsPlansAPI, region string) *Provider {
 	p := &Provider{
-		region:  region,
-		ec2:     ec2Api,
-		pricing: pricing,
-		cm:      pretty.NewChangeMonitor(),
+		region:       region,
+		ec2:          ec2Api,
+		pricing:      pricing,
+		savingsPlans: savingsPlans,
+		cm:           pretty.NewChangeMonitor(),
 	}
 	// sets the pricing data from the static default state for the provider
 	p.Reset()
@@ -113,55 +152,114 @@ func NewProvider(_ context.Context, pricing pricingiface.PricingAPI, ec2Api ec2i
 func (p *Provider) InstanceTypes() []string {
 	p.muOnDemand.RLock()
 	p.muSpot.RLock()
+	p.muOverride.RLock()
 	defer p.muOnDemand.RUnlock()
 	defer p.muSpot.RUnlock()
-	return lo.Union(lo.Keys(p.onDemandPrices), lo.Keys(p.spotPrices))
+	defer p.muOverride.RUnlock()
+	return lo.Union(lo.Keys(p.onDemandPrices), lo.Keys(p.spotPrices), lo.Uniq(lo.Map(lo.Keys(p.overrides), func(k overrideKey, _ int) string { return k.instanceType })))
 }
 
 // OnDemandPrice returns the last known on-demand price for a given instance type, returning an error if there is no
-// known on-demand pricing for the instance type.
+// known on-demand pricing for the instance type. Operator provided overrides take precedence over all other prices. If
+// an active commitment (Reserved Instance or Savings Plan) with coverage left covers the instance type in every zone at
+// a lower rate, the committed rate is returned instead of the public on-demand price.
 func (p *Provider) OnDemandPrice(instanceType string) (float64, bool) {
+	if price, ok := p.override(instanceType, corev1beta1.CapacityTypeOnDemand, ""); ok {
+		return price, true
+	}
 	p.muOnDemand.RLock()
 	defer p.muOnDemand.RUnlock()
 	price, ok := p.onDemandPrices[instanceType]
+	awscache.RecordLookup(awscache.Pricing, ok)
+	if !ok {
+		return 0.0, false
+	}
+	p.muCommitment.RLock()
+	defer p.muCommitment.RUnlock()
+	if committed, ok := p.commitmentPrices[instanceType]; ok && committed < price {
+		return committed, true
+	}
+	return price, true
+}
+
+// OnDemandOfferingPrice returns the on-demand price of an instance type in a zone. It's the price returned by
+// OnDemandPrice, unless a zonal Reserved Instance with coverage left covers the instance type in the zone at a lower rate.
+func (p *Provider) OnDemandOfferingPrice(instanceType string, zone string) (float64, bool) {
+	if price, ok := p.override(instanceType, corev1beta1.CapacityTypeOnDemand, ""); ok {
+		return price, true
+	}
+	price, ok := p.OnDemandPrice(instanceType)
 	if !ok {
 		return 0.0, false
 	}
+	p.muCommitment.RLock()
+	defer p.muCommitment.RUnlock()
+	if committed, ok := p.zonalCommitmentPrices[instanceType][zone]; ok && committed < price {
+		return committed, true
+	}
 	return price, true
 }
 
 // SpotPrice returns the last known spot price for a given instance type and zone, returning an error
-// if there is no known spot pricing for that instance type or zone
+// if there is no known spot pricing for that instance type or zone. Operator provided overrides take precedence.
 func (p *Provider) SpotPrice(instanceType string, zone string) (float64, bool) {
+	if price, ok := p.override(instanceType, corev1beta1.CapacityTypeSpot, zone); ok {
+		return price, true
+	}
+	return p.LaunchSpotPrice(instanceType, zone)
+}
+
+// LaunchSpotPrice returns the last known spot price for an instance type in a zone, which is recorded for instances
+// when they're launched. Operator provided overrides aren't applied, since they change which instances are launched
+// rather than the price that they're billed at.
+func (p *Provider) LaunchSpotPrice(instanceType string, zone string) (float64, bool) {
 	p.muSpot.RLock()
 	defer p.muSpot.RUnlock()
 	if val, ok := p.spotPrices[instanceType]; ok {
 		if !p.spotPricingUpdated {
+			awscache.RecordLookup(awscache.Pricing, true)
 			return val.defaultPrice, true
 		}
 		if price, ok := p.spotPrices[instanceType].prices[zone]; ok {
+			awscache.RecordLookup(awscache.Pricing, true)
 			return price, true
 		}
-		return 0.0, false
 	}
+	awscache.RecordLookup(awscache.Pricing, false)
 	return 0.0, false
 }
 
-func (p *Provider) UpdateOnDemandPricing(ctx context.Context) error {
+func (p *Provider) UpdateOnDemandPricing(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "Pricing.UpdateOnDemandPricing")
+	defer func() { tracing.End(span, err) }()
 	// standard on-demand instances
 	var wg sync.WaitGroup
 	var onDemandPrices, onDemandMetalPrices map[string]float64
 	var onDemandErr, onDemandMetalErr error
 
+	// a pricing file replaces the pricing API entirely
+	if path := options.FromContext(ctx).PricingFile; path != "" {
+		return p.updateOnDemandPricingFromFile(ctx, path)
+	}
+
 	// if we are in isolated vpc, skip updating on demand pricing
-	// as pricing api may not be available
-	if options.FromContext(ctx).IsolatedVPC {
+	// as pricing api may not be available unless we've been given an endpoint for it
+	if options.FromContext(ctx).IsolatedVPC && options.FromContext(ctx).PricingEndpoint == "" {
 		if p.cm.HasChanged("on-demand-prices", nil) {
 			logging.FromContext(ctx).Debug("running in an isolated VPC, on-demand pricing information will not be updated")
 		}
 		return nil
 	}
+	// the pricing API isn't available in every partition, e.g. aws-us-gov, so the static prices are used instead
+	if !utils.ServiceAvailable(p.region, pricing.ServiceID) && options.FromContext(ctx).PricingEndpoint == "" {
+		if p.cm.HasChanged("on-demand-prices", nil) {
+			logging.FromContext(ctx).With("partition", utils.Partition(p.region).ID()).Debug("pricing API isn't available in the partition, on-demand pricing information will not be updated")
+		}
+		return nil
+	}
 
+	// metrics are updated after the lock is released since they read back the effective prices
+	defer p.updatePriceMetrics()
 	p.muOnDemand.Lock()
 	defer p.muOnDemand.Unlock()
 
@@ -169,14 +267,14 @@ func (p *Provider) UpdateOnDemandPricing(ctx context.Context) error {
 	go func() {
 		defer wg.Done()
 		onDemandPrices, onDemandErr = p.fetchOnDemandPricing(ctx,
-			&pricing.Filter{
+			pricingtypes.Filter{
 				Field: aws.String("tenancy"),
-				Type:  aws.String("TERM_MATCH"),
+				Type:  pricingtypes.FilterTypeTermMatch,
 				Value: aws.String("Shared"),
 			},
-			&pricing.Filter{
+			pricingtypes.Filter{
 				Field: aws.String("productFamily"),
-				Type:  aws.String("TERM_MATCH"),
+				Type:  pricingtypes.FilterTypeTermMatch,
 				Value: aws.String("Compute Instance"),
 			})
 	}()
@@ -186,21 +284,21 @@ func (p *Provider) UpdateOnDemandPricing(ctx context.Context) error {
 	go func() {
 		defer wg.Done()
 		onDemandMetalPrices, onDemandMetalErr = p.fetchOnDemandPricing(ctx,
-			&pricing.Filter{
+			pricingtypes.Filter{
 				Field: aws.String("tenancy"),
-				Type:  aws.String("TERM_MATCH"),
+				Type:  pricingtypes.FilterTypeTermMatch,
 				Value: aws.String("Dedicated"),
 			},
-			&pricing.Filter{
+			pricingtypes.Filter{
 				Field: aws.String("productFamily"),
-				Type:  aws.String("TERM_MATCH"),
+				Type:  pricingtypes.FilterTypeTermMatch,
 				Value: aws.String("Compute Instance (bare metal)"),
 			})
 	}()
 
 	wg.Wait()
 
-	err := multierr.Append(onDemandErr, onDemandMetalErr)
+	err = multierr.Append(onDemandErr, onDemandMetalErr)
 	if err != nil {
 		return fmt.Errorf("retreiving on-demand pricing data, %w", err)
 	}
@@ -210,66 +308,72 @@ func (p *Provider) UpdateOnDemandPricing(ctx context.Context) error {
 	}
 
 	p.onDemandPrices = lo.Assign(onDemandPrices, onDemandMetalPrices)
+	lastUpdatedTimestamp.With(prometheus.Labels{capacityTypeLabel: corev1beta1.CapacityTypeOnDemand}).SetToCurrentTime()
+	awscache.RecordRefresh(awscache.Pricing)
 	if p.cm.HasChanged("on-demand-prices", p.onDemandPrices) {
 		logging.FromContext(ctx).With("instance-type-count", len(p.onDemandPrices)).Debugf("updated on-demand pricing")
 	}
 	return nil
 }
 
-func (p *Provider) fetchOnDemandPricing(ctx context.Context, additionalFilters ...*pricing.Filter) (map[string]float64, error) {
+func (p *Provider) fetchOnDemandPricing(ctx context.Context, additionalFilters ...pricingtypes.Filter) (map[string]float64, error) {
 	prices := map[string]float64{}
-	filters := append([]*pricing.Filter{
+	filters := append([]pricingtypes.Filter{
 		{
 			Field: aws.String("regionCode"),
-			Type:  aws.String("TERM_MATCH"),
+			Type:  pricingtypes.FilterTypeTermMatch,
 			Value: aws.String(p.region),
 		},
 		{
 			Field: aws.String("serviceCode"),
-			Type:  aws.String("TERM_MATCH"),
+			Type:  pricingtypes.FilterTypeTermMatch,
 			Value: aws.String("AmazonEC2"),
 		},
 		{
 			Field: aws.String("preInstalledSw"),
-			Type:  aws.String("TERM_MATCH"),
+			Type:  pricingtypes.FilterTypeTermMatch,
 			Value: aws.String("NA"),
 		},
 		{
 			Field: aws.String("operatingSystem"),
-			Type:  aws.String("TERM_MATCH"),
+			Type:  pricingtypes.FilterTypeTermMatch,
 			Value: aws.String("Linux"),
 		},
 		{
 			Field: aws.String("capacitystatus"),
-			Type:  aws.String("TERM_MATCH"),
+			Type:  pricingtypes.FilterTypeTermMatch,
 			Value: aws.String("Used"),
 		},
 		{
 			Field: aws.String("marketoption"),
-			Type:  aws.String("TERM_MATCH"),
+			Type:  pricingtypes.FilterTypeTermMatch,
 			Value: aws.String("OnDemand"),
 		}},
 		additionalFilters...)
 
-	err := p.pricing.GetProductsPagesWithContext(
-		ctx,
-		&pricing.GetProductsInput{
-			Filters:     filters,
-			ServiceCode: aws.String("AmazonEC2"),
-		},
-		p.onDemandPage(ctx, prices),
-	)
-	if err != nil {
-		return nil, err
+	paginator := pricing.NewGetProductsPaginator(p.pricing, &pricing.GetProductsInput{
+		Filters:     filters,
+		ServiceCode: aws.String("AmazonEC2"),
+	})
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.onDemandPage(ctx, prices, output)
 	}
-
 	return prices, nil
 }
 
-func (p *Provider) spotPage(ctx context.Context, prices map[string]map[string]float64) func(output *ec2.DescribeSpotPriceHistoryOutput, b bool) bool {
-	return func(output *ec2.DescribeSpotPriceHistoryOutput, b bool) bool {
+func (p *Provider) fetchSpotPricing(ctx context.Context, input *ec2.DescribeSpotPriceHistoryInput, prices map[string]map[string]float64) error {
+	paginator := ec2.NewDescribeSpotPriceHistoryPaginator(p.ec2, input)
+	for paginator.HasMorePages() {
+		output, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
 		for _, sph := range output.SpotPriceHistory {
-			spotPriceStr := aws.StringValue(sph.SpotPrice)
+			spotPriceStr := aws.ToString(sph.SpotPrice)
 			spotPrice, err := strconv.ParseFloat(spotPriceStr, 64)
 			// these errors shouldn't occur, but if pricing API does have an error, we ignore the record
 			if err != nil {
@@ -279,22 +383,22 @@ func (p *Provider) spotPage(ctx context.Context, prices map[string]map[string]fl
 			if sph.Timestamp == nil {
 				continue
 			}
-			instanceType := aws.StringValue(sph.InstanceType)
-			az := aws.StringValue(sph.AvailabilityZone)
+			instanceType := string(sph.InstanceType)
+			az := aws.ToString(sph.AvailabilityZone)
 			_, ok := prices[instanceType]
 			if !ok {
 				prices[instanceType] = map[string]float64{}
 			}
 			prices[instanceType][az] = spotPrice
 		}
-		return true
 	}
+	return nil
 }
 
 // turning off cyclo here, it measures as a 12 due to all of the type checks of the pricing data which returns a deeply
 // nested map[string]interface{}
 // nolint: gocyclo
-func (p *Provider) onDemandPage(ctx context.Context, prices map[string]float64) func(output *pricing.GetProductsOutput, b bool) bool {
+func (p *Provider) onDemandPage(ctx context.Context, prices map[string]float64, output *pricing.GetProductsOutput) {
 	// this isn't the full pricing struct, just the portions we care about
 	type priceItem struct {
 		Product struct {
@@ -311,60 +415,63 @@ func (p *Provider) onDemandPage(ctx context.Context, prices map[string]float64)
 		}
 	}
 
-	return func(output *pricing.GetProductsOutput, b bool) bool {
-		currency := "USD"
-		if p.region == "cn-north-1" {
-			currency = "CNY"
+	currency := lo.Ternary(utils.Partition(p.region).ID() == utils.AWSCNPartitionID, "CNY", "USD")
+	for _, outer := range output.PriceList {
+		var pItem priceItem
+		if err := json.Unmarshal([]byte(outer), &pItem); err != nil {
+			logging.FromContext(ctx).Errorf("decoding %s", err)
 		}
-		for _, outer := range output.PriceList {
-			var buf bytes.Buffer
-			enc := json.NewEncoder(&buf)
-			if err := enc.Encode(outer); err != nil {
-				logging.FromContext(ctx).Errorf("encoding %s", err)
-			}
-			dec := json.NewDecoder(&buf)
-			var pItem priceItem
-			if err := dec.Decode(&pItem); err != nil {
-				logging.FromContext(ctx).Errorf("decoding %s", err)
-			}
-			if pItem.Product.Attributes.InstanceType == "" {
-				continue
-			}
-			for _, term := range pItem.Terms.OnDemand {
-				for _, v := range term.PriceDimensions {
-					price, err := strconv.ParseFloat(v.PricePerUnit[currency], 64)
-					if err != nil || price == 0 {
-						continue
-					}
-					prices[pItem.Product.Attributes.InstanceType] = price
+		if pItem.Product.Attributes.InstanceType == "" {
+			continue
+		}
+		for _, term := range pItem.Terms.OnDemand {
+			for _, v := range term.PriceDimensions {
+				price, err := strconv.ParseFloat(v.PricePerUnit[currency], 64)
+				if err != nil || price == 0 {
+					continue
 				}
+				prices[pItem.Product.Attributes.InstanceType] = price
 			}
 		}
-		return true
 	}
 }
 
 // nolint: gocyclo
-func (p *Provider) UpdateSpotPricing(ctx context.Context) error {
+func (p *Provider) UpdateSpotPricing(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "Pricing.UpdateSpotPricing")
+	defer func() { tracing.End(span, err) }()
 	prices := map[string]map[string]float64{}
 
+	updatedAt := time.Now()
+
+	// spot prices are only read from a pricing file if it provides them, otherwise we fall back to the EC2 API
+	var f *File
+	if path := options.FromContext(ctx).PricingFile; path != "" {
+		var err error
+		if f, err = readFile(path); err != nil {
+			return fmt.Errorf("reading pricing file, %w", err)
+		}
+	}
+
+	defer p.updatePriceMetrics()
 	p.muSpot.Lock()
 	defer p.muSpot.Unlock()
-	err := p.ec2.DescribeSpotPriceHistoryPagesWithContext(
-		ctx,
-		&ec2.DescribeSpotPriceHistoryInput{
-			ProductDescriptions: []*string{
-				aws.String("Linux/UNIX"),
-				aws.String("Linux/UNIX (Amazon VPC)"),
+	if f != nil && len(f.Spot) > 0 {
+		prices = f.Spot
+		updatedAt = f.UpdatedAt
+		p.warnIfStale(ctx, f)
+	} else {
+		err := p.fetchSpotPricing(ctx, &ec2.DescribeSpotPriceHistoryInput{
+			ProductDescriptions: []string{
+				"Linux/UNIX",
+				"Linux/UNIX (Amazon VPC)",
 			},
 			// get the latest spot price for each instance type
 			StartTime: aws.Time(time.Now()),
-		},
-		p.spotPage(ctx, prices),
-	)
-
-	if err != nil {
-		return fmt.Errorf("retrieving spot pricing data, %w", err)
+		}, prices)
+		if err != nil {
+			return fmt.Errorf("retrieving spot pricing data, %w", err)
+		}
 	}
 	if len(prices) == 0 {
 		return fmt.Errorf("no spot pricing found")
@@ -382,6 +489,8 @@ func (p *Provider) UpdateSpotPricing(ctx context.Context) error {
 	}
 
 	p.spotPricingUpdated = true
+	lastUpdatedTimestamp.With(prometheus.Labels{capacityTypeLabel: corev1beta1.CapacityTypeSpot}).Set(float64(updatedAt.Unix()))
+	awscache.RecordRefresh(awscache.Pricing)
 	if p.cm.HasChanged("spot-prices", p.spotPrices) {
 		logging.FromContext(ctx).With(
 			"instance-type-count", len(p.onDemandPrices),
@@ -390,13 +499,73 @@ func (p *Provider) UpdateSpotPricing(ctx context.Context) error {
 	return nil
 }
 
+// updatePriceMetrics publishes the effective price of every known instance type offering. Series are updated in place
+// and only the series of offerings that no longer have a price are deleted, so that prices never disappear from a scrape
+// while they're updated.
+func (p *Provider) updatePriceMetrics() {
+	p.muMetrics.Lock()
+	defer p.muMetrics.Unlock()
+	published := map[string]prometheus.Labels{}
+	publish := func(instanceType, capacityType, zone string, price float64) {
+		labels := prometheus.Labels{instanceTypeLabel: instanceType, capacityTypeLabel: capacityType, zoneLabel: zone}
+		instanceTypePrice.With(labels).Set(price)
+		published[strings.Join([]string{instanceType, capacityType, zone}, "/")] = labels
+	}
+	for _, instanceType := range p.InstanceTypes() {
+		if price, ok := p.OnDemandPrice(instanceType); ok {
+			publish(instanceType, corev1beta1.CapacityTypeOnDemand, "", price)
+		}
+		for _, zone := range p.spotZones(instanceType) {
+			if price, ok := p.SpotPrice(instanceType, zone); ok {
+				publish(instanceType, corev1beta1.CapacityTypeSpot, zone, price)
+			}
+		}
+	}
+	for key, labels := range p.priceMetrics {
+		if _, ok := published[key]; !ok {
+			instanceTypePrice.Delete(labels)
+		}
+	}
+	p.priceMetrics = published
+}
+
+// SpotPrices returns the last known spot price for the instance type in each zone that a spot price is known for
+func (p *Provider) SpotPrices(instanceType string) map[string]float64 {
+	prices := map[string]float64{}
+	for _, zone := range p.spotZones(instanceType) {
+		if price, ok := p.SpotPrice(instanceType, zone); ok {
+			prices[zone] = price
+		}
+	}
+	return prices
+}
+
+// spotZones returns the zones for which a spot price is known for the instance type
+func (p *Provider) spotZones(instanceType string) []string {
+	p.muSpot.RLock()
+	defer p.muSpot.RUnlock()
+	p.muOverride.RLock()
+	defer p.muOverride.RUnlock()
+	zones := lo.Keys(p.spotPrices[instanceType].prices)
+	for key := range p.overrides {
+		if key.instanceType == instanceType && key.capacityType == corev1beta1.CapacityTypeSpot && key.zone != "" {
+			zones = append(zones, key.zone)
+		}
+	}
+	return lo.Uniq(zones)
+}
+
 func (p *Provider) LivenessProbe(_ *http.Request) error {
 	// ensure we don't deadlock and nolint for the empty critical section
 	p.muOnDemand.Lock()
 	p.muSpot.Lock()
+	p.muCommitment.Lock()
+	p.muOverride.Lock()
 	//nolint: staticcheck
 	p.muOnDemand.Unlock()
 	p.muSpot.Unlock()
+	p.muCommitment.Unlock()
+	p.muOverride.Unlock()
 	return nil
 }
 
@@ -408,16 +577,58 @@ func populateInitialSpotPricing(pricing map[string]float64) map[string]zonal {
 	return m
 }
 
+// Snapshot returns the on-demand prices and, once they have been retrieved, the spot prices, in the format of a pricing
+// file
+func (p *Provider) Snapshot() *File {
+	p.muOnDemand.RLock()
+	defer p.muOnDemand.RUnlock()
+	p.muSpot.RLock()
+	defer p.muSpot.RUnlock()
+	f := &File{UpdatedAt: time.Now(), OnDemand: lo.Assign(p.onDemandPrices)}
+	if p.spotPricingUpdated {
+		f.Spot = lo.MapValues(p.spotPrices, func(z zonal, _ string) map[string]float64 { return lo.Assign(z.prices) })
+	}
+	return f
+}
+
+// Restore replaces the static initial prices with the prices of a snapshot that were retrieved by a previous
+// controller. Prices are retrieved from the pricing and EC2 APIs again on the next pricing refresh.
+func (p *Provider) Restore(ctx context.Context, f *File) {
+	defer p.updatePriceMetrics()
+	if len(f.OnDemand) > 0 {
+		p.muOnDemand.Lock()
+		p.onDemandPrices = lo.Assign(f.OnDemand)
+		p.muOnDemand.Unlock()
+	}
+	if len(f.Spot) > 0 {
+		p.muSpot.Lock()
+		for it, prices := range f.Spot {
+			if _, ok := p.spotPrices[it]; !ok {
+				p.spotPrices[it] = newZonalPricing(0)
+			}
+			for zone, price := range prices {
+				p.spotPrices[it].prices[zone] = price
+			}
+		}
+		p.spotPricingUpdated = true
+		p.muSpot.Unlock()
+	}
+	logging.FromContext(ctx).With("instance-type-count", len(f.OnDemand), "updated-at", f.UpdatedAt).Debugf("restored pricing")
+}
+
 func (p *Provider) Reset() {
 	// see if we've got region specific pricing data
 	staticPricing, ok := initialOnDemandPrices[p.region]
 	if !ok {
-		// and if not, fall back to the always available us-east-1
-		staticPricing = initialOnDemandPrices["us-east-1"]
+		// and if not, fall back to a region in the same partition, so that prices are in the partition's currency
+		staticPricing = initialOnDemandPrices[lo.ValueOr(staticPricingPartitionRegions, utils.Partition(p.region).ID(), "us-east-1")]
 	}
 
 	p.onDemandPrices = staticPricing
 	// default our spot pricing to the same as the on-demand pricing until a price update
 	p.spotPrices = populateInitialSpotPricing(staticPricing)
 	p.spotPricingUpdated = false
+	p.commitmentPrices = map[string]float64{}
+	p.zonalCommitmentPrices = map[string]map[string]float64{}
+	p.overrides = map[overrideKey]float64{}
 }
@@ -0,0 +1,91 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	quotasSubsystem   = "quotas"
+	groupLabel        = "quota_group"
+	capacityTypeLabel = "capacity_type"
+	resourceLabel     = "resource"
+	quotaLabel        = "quota"
+)
+
+var (
+	vcpuLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: quotasSubsystem,
+			Name:      "vcpu_limit",
+			Help:      "EC2 vCPU service quota of the account. Labeled by quota group and capacity type.",
+		},
+		[]string{groupLabel, capacityTypeLabel},
+	)
+	vcpuUsage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: quotasSubsystem,
+			Name:      "vcpu_usage",
+			Help:      "vCPUs of the account's pending and running instances that count towards the EC2 vCPU service quota. Labeled by quota group and capacity type.",
+		},
+		[]string{groupLabel, capacityTypeLabel},
+	)
+	resourceLimit = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: quotasSubsystem,
+			Name:      "resource_limit",
+			Help:      "Region-wide quota of the account for resources that are created for launches, such as launch templates and network interfaces. Labeled by resource.",
+		},
+		[]string{resourceLabel},
+	)
+	resourceUsage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: quotasSubsystem,
+			Name:      "resource_usage",
+			Help:      "Number of the account's resources that count towards the region-wide quota, such as launch templates and network interfaces. Labeled by resource.",
+		},
+		[]string{resourceLabel},
+	)
+	utilizationRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: quotasSubsystem,
+			Name:      "utilization_ratio",
+			Help:      "Fraction of the quota that is used. Labeled by quota, which is either a resource or a vCPU quota named after its group and capacity type.",
+		},
+		[]string{quotaLabel},
+	)
+	exceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: quotasSubsystem,
+			Name:      "exceeded_total",
+			Help:      "Number of instance launches that EC2 rejected because they would exceed the EC2 vCPU service quota. Labeled by quota group and capacity type.",
+		},
+		[]string{groupLabel, capacityTypeLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(vcpuLimit, vcpuUsage, resourceLimit, resourceUsage, utilizationRatio, exceeded)
+}
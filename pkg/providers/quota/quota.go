@@ -0,0 +1,381 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/servicequotas"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"knative.dev/pkg/logging"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+// key identifies an EC2 vCPU service quota by the group of instance families that share it and the capacity type
+type key struct {
+	group        string
+	capacityType string
+}
+
+// codes are the service quota codes of the EC2 vCPU quotas. High memory and HPC instances can't be launched as spot.
+var codes = map[key]string{
+	{"standard", corev1beta1.CapacityTypeOnDemand}:    "L-1216C47A",
+	{"g-vt", corev1beta1.CapacityTypeOnDemand}:        "L-DB2E81BA",
+	{"p", corev1beta1.CapacityTypeOnDemand}:           "L-417A185B",
+	{"x", corev1beta1.CapacityTypeOnDemand}:           "L-7295265B",
+	{"f", corev1beta1.CapacityTypeOnDemand}:           "L-74FC7D96",
+	{"inf", corev1beta1.CapacityTypeOnDemand}:         "L-1945791B",
+	{"dl", corev1beta1.CapacityTypeOnDemand}:          "L-6E869C2A",
+	{"trn", corev1beta1.CapacityTypeOnDemand}:         "L-2C3B7624",
+	{"hpc", corev1beta1.CapacityTypeOnDemand}:         "L-F7808C92",
+	{"high-memory", corev1beta1.CapacityTypeOnDemand}: "L-43DA4232",
+	{"standard", corev1beta1.CapacityTypeSpot}:        "L-34B43A08",
+	{"g-vt", corev1beta1.CapacityTypeSpot}:            "L-3819A6DF",
+	{"p", corev1beta1.CapacityTypeSpot}:               "L-7212CCBC",
+	{"x", corev1beta1.CapacityTypeSpot}:               "L-E3A00192",
+	{"f", corev1beta1.CapacityTypeSpot}:               "L-88CF9481",
+	{"inf", corev1beta1.CapacityTypeSpot}:             "L-B5D1601B",
+	{"dl", corev1beta1.CapacityTypeSpot}:              "L-85EED4F7",
+	{"trn", corev1beta1.CapacityTypeSpot}:             "L-6B0D517C",
+}
+
+// Region-wide quotas of the resources that are created for launches, other than vCPUs. The number of launch templates
+// per region isn't adjustable and isn't available from Service Quotas.
+const (
+	LaunchTemplates   = "launch-templates"
+	NetworkInterfaces = "network-interfaces"
+
+	launchTemplatesLimit       = 5000
+	networkInterfacesQuotaCode = "L-DF5E4CA3"
+)
+
+// groups maps the letters that an instance family starts with to its quota group. Families that aren't listed share
+// the standard quota if they start with one of standardFamilies. Mac instances run on dedicated hosts and aren't
+// limited by a vCPU quota.
+var (
+	groups = map[string]string{
+		"g":   "g-vt",
+		"vt":  "g-vt",
+		"p":   "p",
+		"x":   "x",
+		"f":   "f",
+		"inf": "inf",
+		"dl":  "dl",
+		"trn": "trn",
+		"hpc": "hpc",
+		"u":   "high-memory",
+		"mac": "",
+	}
+	standardFamilies = "acdhimrtz"
+)
+
+// Group returns the group of instance families that shares a vCPU service quota with the instance type
+func Group(instanceType string) (string, bool) {
+	family := strings.SplitN(instanceType, ".", 2)[0]
+	letters := family[:len(family)-len(strings.TrimLeftFunc(family, unicode.IsLetter))]
+	if group, ok := groups[letters]; ok {
+		return group, group != ""
+	}
+	if letters != "" && strings.ContainsRune(standardFamilies, rune(letters[0])) {
+		return "standard", true
+	}
+	return "", false
+}
+
+// Provider tracks the EC2 vCPU service quotas of the account and the vCPUs of its pending and running instances, so
+// that offerings whose launch would exceed a quota are unavailable instead of failing with VcpuLimitExceeded or
+// MaxSpotInstanceCountExceeded. Quotas that aren't known don't limit launches.
+type Provider struct {
+	servicequotasapi sdk.ServiceQuotasAPI
+	ec2api           sdk.EC2API
+
+	mu             sync.RWMutex
+	limits         map[key]float64
+	usage          map[key]float64
+	resourceLimits map[string]float64
+	resourceUsage  map[string]float64
+	// SeqNum is a monotonically increasing change counter that is incremented whenever the limits or usage change
+	SeqNum uint64
+}
+
+func NewProvider(servicequotasapi sdk.ServiceQuotasAPI, ec2api sdk.EC2API) *Provider {
+	return &Provider{
+		servicequotasapi: servicequotasapi,
+		ec2api:           ec2api,
+		limits:           map[key]float64{},
+		usage:            map[key]float64{},
+		resourceLimits:   map[string]float64{},
+		resourceUsage:    map[string]float64{},
+	}
+}
+
+// UpdateLimits gets the vCPU and network interface service quotas of the account
+func (p *Provider) UpdateLimits(ctx context.Context) error {
+	limits := map[key]float64{}
+	for k, code := range codes {
+		limit, ok, err := p.getServiceQuota(ctx, "ec2", code)
+		if err != nil {
+			return err
+		}
+		if ok {
+			limits[k] = limit
+		}
+	}
+	resourceLimits := map[string]float64{LaunchTemplates: launchTemplatesLimit}
+	limit, ok, err := p.getServiceQuota(ctx, "vpc", networkInterfacesQuotaCode)
+	if err != nil {
+		return err
+	}
+	if ok {
+		resourceLimits[NetworkInterfaces] = limit
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resourceLimits = resourceLimits
+	for resource, limit := range resourceLimits {
+		resourceLimit.With(prometheus.Labels{resourceLabel: resource}).Set(limit)
+	}
+	defer p.recordUtilization()
+	if maps.Equal(p.limits, limits) {
+		return nil
+	}
+	p.limits = limits
+	for k, limit := range limits {
+		vcpuLimit.With(labels(k)).Set(limit)
+	}
+	atomic.AddUint64(&p.SeqNum, 1)
+	logging.FromContext(ctx).With("quotas", len(limits)).Debugf("updated vcpu service quotas")
+	return nil
+}
+
+// getServiceQuota returns the value of the quota, or false if the quota doesn't exist in the region
+func (p *Provider) getServiceQuota(ctx context.Context, serviceCode, quotaCode string) (float64, bool, error) {
+	out, err := p.servicequotasapi.GetServiceQuota(ctx, &servicequotas.GetServiceQuotaInput{
+		ServiceCode: aws.String(serviceCode),
+		QuotaCode:   aws.String(quotaCode),
+	})
+	if err != nil {
+		// Quotas that don't exist in the region don't limit launches
+		if awserrors.IsNotFound(err) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("getting service quota %s, %w", quotaCode, err)
+	}
+	return aws.ToFloat64(out.Quota.Value), true, nil
+}
+
+// UpdateUsage sums the vCPUs of the account's pending and running instances by quota
+func (p *Provider) UpdateUsage(ctx context.Context) error {
+	usage := map[key]float64{}
+	paginator := ec2.NewDescribeInstancesPaginator(p.ec2api, &ec2.DescribeInstancesInput{
+		Filters: []ec2types.Filter{{
+			Name:   aws.String("instance-state-name"),
+			Values: []string{string(ec2types.InstanceStateNamePending), string(ec2types.InstanceStateNameRunning)},
+		}},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing instances, %w", err)
+		}
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				group, ok := Group(string(instance.InstanceType))
+				if !ok || instance.CpuOptions == nil {
+					continue
+				}
+				capacityType := lo.Ternary(instance.InstanceLifecycle == ec2types.InstanceLifecycleTypeSpot, corev1beta1.CapacityTypeSpot, corev1beta1.CapacityTypeOnDemand)
+				usage[key{group, capacityType}] += float64(aws.ToInt32(instance.CpuOptions.CoreCount) * aws.ToInt32(instance.CpuOptions.ThreadsPerCore))
+			}
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.recordUtilization()
+	if maps.Equal(p.usage, usage) {
+		return nil
+	}
+	p.usage = usage
+	for k := range codes {
+		vcpuUsage.With(labels(k)).Set(usage[k])
+	}
+	atomic.AddUint64(&p.SeqNum, 1)
+	return nil
+}
+
+// UpdateResourceUsage counts the launch templates and network interfaces of the account
+func (p *Provider) UpdateResourceUsage(ctx context.Context) error {
+	launchTemplates := 0
+	templates := ec2.NewDescribeLaunchTemplatesPaginator(p.ec2api, &ec2.DescribeLaunchTemplatesInput{})
+	for templates.HasMorePages() {
+		page, err := templates.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing launch templates, %w", err)
+		}
+		launchTemplates += len(page.LaunchTemplates)
+	}
+	networkInterfaces := 0
+	interfaces := ec2.NewDescribeNetworkInterfacesPaginator(p.ec2api, &ec2.DescribeNetworkInterfacesInput{})
+	for interfaces.HasMorePages() {
+		page, err := interfaces.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("describing network interfaces, %w", err)
+		}
+		networkInterfaces += len(page.NetworkInterfaces)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resourceUsage = map[string]float64{
+		LaunchTemplates:   float64(launchTemplates),
+		NetworkInterfaces: float64(networkInterfaces),
+	}
+	for resource, usage := range p.resourceUsage {
+		resourceUsage.With(prometheus.Labels{resourceLabel: resource}).Set(usage)
+	}
+	p.recordUtilization()
+	return nil
+}
+
+// Utilization returns the fraction of each known quota that is used, by the name of the quota. vCPU quotas are named
+// after their group and capacity type, e.g. standard-spot-vcpus.
+func (p *Provider) Utilization() map[string]float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.utilization()
+}
+
+func (p *Provider) utilization() map[string]float64 {
+	utilization := map[string]float64{}
+	for k, limit := range p.limits {
+		if limit > 0 {
+			utilization[fmt.Sprintf("%s-%s-vcpus", k.group, k.capacityType)] = p.usage[k] / limit
+		}
+	}
+	for resource, limit := range p.resourceLimits {
+		if usage, ok := p.resourceUsage[resource]; ok && limit > 0 {
+			utilization[resource] = usage / limit
+		}
+	}
+	return utilization
+}
+
+// recordUtilization must be called with the lock held
+func (p *Provider) recordUtilization() {
+	for name, ratio := range p.utilization() {
+		utilizationRatio.With(prometheus.Labels{quotaLabel: name}).Set(ratio)
+	}
+}
+
+// Exceeded returns true if launching an instance with the vCPUs as the capacity type would exceed the quota of the
+// instance type's group
+func (p *Provider) Exceeded(instanceType string, vcpus int64, capacityType string) bool {
+	group, ok := Group(instanceType)
+	if !ok {
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	limit, ok := p.limits[key{group, capacityType}]
+	return ok && p.usage[key{group, capacityType}]+float64(vcpus) > limit
+}
+
+// Reserve adds the vCPUs of a launched instance to the usage, so that the quota is enforced before the usage is
+// updated from EC2
+func (p *Provider) Reserve(instanceType string, vcpus int64, capacityType string) {
+	group, ok := Group(instanceType)
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k := key{group, capacityType}
+	if _, ok := p.limits[k]; !ok {
+		return
+	}
+	p.usage[k] += float64(vcpus)
+	vcpuUsage.With(labels(k)).Set(p.usage[k])
+	atomic.AddUint64(&p.SeqNum, 1)
+	p.recordUtilization()
+}
+
+// MarkExceeded records that EC2 rejected a launch of the instance type because it would exceed the quota. The usage
+// is raised to the quota so that the group is unavailable until the usage is updated from EC2.
+func (p *Provider) MarkExceeded(ctx context.Context, instanceType string, capacityType string) {
+	group, ok := Group(instanceType)
+	if !ok {
+		return
+	}
+	k := key{group, capacityType}
+	exceeded.With(labels(k)).Inc()
+	logging.FromContext(ctx).With("instance-type", instanceType, "capacity-type", capacityType, "quota-group", group).Debugf("vcpu service quota exceeded")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if limit, ok := p.limits[k]; ok && p.usage[k] < limit {
+		p.usage[k] = limit
+		vcpuUsage.With(labels(k)).Set(limit)
+		atomic.AddUint64(&p.SeqNum, 1)
+		p.recordUtilization()
+	}
+}
+
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.limits = map[key]float64{}
+	p.usage = map[key]float64{}
+	p.resourceLimits = map[string]float64{}
+	p.resourceUsage = map[string]float64{}
+}
+
+func labels(k key) prometheus.Labels {
+	return prometheus.Labels{groupLabel: k.group, capacityTypeLabel: k.capacityType}
+}
+
+// ExceededError is returned when a launch fails because it would exceed the account's vCPU service quotas. It wraps
+// an insufficient capacity error, so the NodeClaim is still deleted and its pods are scheduled to other offerings.
+type ExceededError struct {
+	error
+}
+
+func NewExceededError(err error) *ExceededError {
+	return &ExceededError{error: err}
+}
+
+func (e *ExceededError) Unwrap() error {
+	return e.error
+}
+
+func IsExceededError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var exceededErr *ExceededError
+	return errors.As(err, &exceededErr)
+}
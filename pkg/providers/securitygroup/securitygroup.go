@@ -20,9 +20,9 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
@@ -31,27 +31,29 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 )
 
 type Provider struct {
 	sync.Mutex
-	ec2api ec2iface.EC2API
+	ec2api sdk.EC2API
 	cache  *cache.Cache
 	cm     *pretty.ChangeMonitor
 }
 
 const TTL = 5 * time.Minute
 
-func NewProvider(ec2api ec2iface.EC2API, cache *cache.Cache) *Provider {
+func NewProvider(ec2api sdk.EC2API, cache *cache.Cache) *Provider {
 	return &Provider{
 		ec2api: ec2api,
 		cm:     pretty.NewChangeMonitor(),
 		// TODO: Remove cache cache when we utilize the security groups from the EC2NodeClass.status
-		cache: cache,
+		cache: awscache.WithExpirationMetrics(awscache.SecurityGroups, cache),
 	}
 }
 
-func (p *Provider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*ec2.SecurityGroup, error) {
+func (p *Provider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*ec2types.SecurityGroup, error) {
 	p.Lock()
 	defer p.Unlock()
 
@@ -63,57 +65,68 @@ func (p *Provider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([
 	}
 	if p.cm.HasChanged(fmt.Sprintf("security-groups/%s", nodeClass.Name), securityGroups) {
 		logging.FromContext(ctx).
-			With("security-groups", lo.Map(securityGroups, func(s *ec2.SecurityGroup, _ int) string {
-				return aws.StringValue(s.GroupId)
+			With("security-groups", lo.Map(securityGroups, func(s *ec2types.SecurityGroup, _ int) string {
+				return aws.ToString(s.GroupId)
 			})).
 			Debugf("discovered security groups")
 	}
 	return securityGroups, nil
 }
 
-func (p *Provider) getSecurityGroups(ctx context.Context, filterSets [][]*ec2.Filter) ([]*ec2.SecurityGroup, error) {
+// Invalidate removes the security groups that are cached, so that changes to security groups are discovered on the next
+// List instead of when the cache expires
+func (p *Provider) Invalidate() {
+	p.Lock()
+	defer p.Unlock()
+	p.cache.Flush()
+}
+
+func (p *Provider) getSecurityGroups(ctx context.Context, filterSets [][]ec2types.Filter) ([]*ec2types.SecurityGroup, error) {
 	hash, err := hashstructure.Hash(filterSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
 		return nil, err
 	}
 	if sg, ok := p.cache.Get(fmt.Sprint(hash)); ok {
-		return sg.([]*ec2.SecurityGroup), nil
+		awscache.RecordLookup(awscache.SecurityGroups, true)
+		return sg.([]*ec2types.SecurityGroup), nil
 	}
-	securityGroups := map[string]*ec2.SecurityGroup{}
+	awscache.RecordLookup(awscache.SecurityGroups, false)
+	securityGroups := map[string]*ec2types.SecurityGroup{}
 	for _, filters := range filterSets {
-		output, err := p.ec2api.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{Filters: filters})
+		output, err := p.ec2api.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{Filters: filters})
 		if err != nil {
 			return nil, fmt.Errorf("describing security groups %+v, %w", filterSets, err)
 		}
 		for i := range output.SecurityGroups {
-			securityGroups[lo.FromPtr(output.SecurityGroups[i].GroupId)] = output.SecurityGroups[i]
+			securityGroups[lo.FromPtr(output.SecurityGroups[i].GroupId)] = &output.SecurityGroups[i]
 		}
 	}
 	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(securityGroups))
+	awscache.RecordRefresh(awscache.SecurityGroups)
 	return lo.Values(securityGroups), nil
 }
 
-func getFilterSets(terms []v1beta1.SecurityGroupSelectorTerm) (res [][]*ec2.Filter) {
-	idFilter := &ec2.Filter{Name: aws.String("group-id")}
-	nameFilter := &ec2.Filter{Name: aws.String("group-name")}
+func getFilterSets(terms []v1beta1.SecurityGroupSelectorTerm) (res [][]ec2types.Filter) {
+	idFilter := ec2types.Filter{Name: aws.String("group-id")}
+	nameFilter := ec2types.Filter{Name: aws.String("group-name")}
 	for _, term := range terms {
 		switch {
 		case term.ID != "":
-			idFilter.Values = append(idFilter.Values, aws.String(term.ID))
+			idFilter.Values = append(idFilter.Values, term.ID)
 		case term.Name != "":
-			nameFilter.Values = append(nameFilter.Values, aws.String(term.Name))
+			nameFilter.Values = append(nameFilter.Values, term.Name)
 		default:
-			var filters []*ec2.Filter
+			var filters []ec2types.Filter
 			for k, v := range term.Tags {
 				if v == "*" {
-					filters = append(filters, &ec2.Filter{
+					filters = append(filters, ec2types.Filter{
 						Name:   aws.String("tag-key"),
-						Values: []*string{aws.String(k)},
+						Values: []string{k},
 					})
 				} else {
-					filters = append(filters, &ec2.Filter{
+					filters = append(filters, ec2types.Filter{
 						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
-						Values: []*string{aws.String(v)},
+						Values: []string{v},
 					})
 				}
 			}
@@ -121,10 +134,10 @@ func getFilterSets(terms []v1beta1.SecurityGroupSelectorTerm) (res [][]*ec2.Filt
 		}
 	}
 	if len(idFilter.Values) > 0 {
-		res = append(res, []*ec2.Filter{idFilter})
+		res = append(res, []ec2types.Filter{idFilter})
 	}
 	if len(nameFilter.Values) > 0 {
-		res = append(res, []*ec2.Filter{nameFilter})
+		res = append(res, []ec2types.Filter{nameFilter})
 	}
 	return res
 }
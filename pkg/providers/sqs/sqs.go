@@ -18,54 +18,199 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awserrors "github.com/aws/karpenter-provider-aws/pkg/errors"
+)
+
+const (
+	fifoSuffix = ".fifo"
+	// sendMessageGroupID is the message group that messages sent by the provider are added to on FIFO queues
+	sendMessageGroupID = "karpenter"
 )
 
 type Provider struct {
-	client sqsiface.SQSAPI
+	client sdk.SQSAPI
 
 	name string
+	mu   sync.Mutex
 	url  string
 }
 
-func NewProvider(ctx context.Context, client sqsiface.SQSAPI, queueName string) (*Provider, error) {
-	ret, err := client.GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{
-		QueueName: aws.String(queueName),
+// NewAPI returns an SQS API for the passed queue name or URL. Queue URLs may refer to queues in other regions or accounts,
+// so the region is taken from the URL. If a role is provided, it is assumed for all calls to SQS.
+func NewAPI(cfg aws.Config, queue string, roleARN string) sdk.SQSAPI {
+	return sqs.NewFromConfig(cfg, func(o *sqs.Options) {
+		if region := queueRegion(queue); region != "" {
+			o.Region = region
+		}
+		if roleARN != "" {
+			o.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN))
+		}
 	})
-	if err != nil {
-		return nil, fmt.Errorf("fetching queue url, %w", err)
+}
+
+// queueRegion returns the region from a queue URL, e.g. https://sqs.us-west-2.amazonaws.com/000000000000/queue
+func queueRegion(queue string) string {
+	u, err := url.Parse(queue)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	if parts := strings.Split(u.Hostname(), "."); len(parts) > 2 && parts[0] == "sqs" {
+		return parts[1]
+	}
+	return ""
+}
+
+// NewProvider returns a Provider for the passed queue name or URL. Queue URLs are used as-is so that queues
+// that are owned by other accounts can be used. The URL of a queue name is looked up the first time that the queue is
+// called, so that the provider can be created before a queue that Karpenter manages exists.
+func NewProvider(client sdk.SQSAPI, queueName string) *Provider {
+	if strings.Contains(queueName, "://") {
+		return &Provider{
+			client: client,
+			name:   path.Base(queueName),
+			url:    queueName,
+		}
 	}
 	return &Provider{
 		client: client,
 		name:   queueName,
-		url:    aws.StringValue(ret.QueueUrl),
-	}, nil
+	}
+}
+
+// queueURL returns the URL of the queue, looking it up by name if it isn't known yet
+func (p *Provider) queueURL(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.url != "" {
+		return p.url, nil
+	}
+	ret, err := p.client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{
+		QueueName: aws.String(p.name),
+	})
+	if err != nil {
+		return "", fmt.Errorf("fetching queue url, %w", err)
+	}
+	p.url = aws.ToString(ret.QueueUrl)
+	return p.url, nil
+}
+
+// EnsureQueue creates the queue if it doesn't exist and updates its attributes and tags so that EventBridge can
+// deliver interruption events to it. It returns the ARN of the queue.
+func EnsureQueue(ctx context.Context, client sdk.SQSAPI, queueName string, kmsKeyID string, tags map[string]string) (string, error) {
+	input := &sqs.CreateQueueInput{
+		QueueName: aws.String(queueName),
+		Tags:      tags,
+	}
+	// FIFO queues can only be configured when the queue is created
+	if IsFIFO(queueName) {
+		input.Attributes = map[string]string{
+			string(sqstypes.QueueAttributeNameFifoQueue):                 "true",
+			string(sqstypes.QueueAttributeNameContentBasedDeduplication): "true",
+		}
+	}
+	created, err := client.CreateQueue(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("creating queue, %w", err)
+	}
+	// Tags are only applied by CreateQueue when the queue doesn't exist yet
+	if len(tags) > 0 {
+		if _, err = client.TagQueue(ctx, &sqs.TagQueueInput{
+			QueueUrl: created.QueueUrl,
+			Tags:     tags,
+		}); err != nil {
+			return "", fmt.Errorf("tagging queue, %w", err)
+		}
+	}
+	out, err := client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       created.QueueUrl,
+		AttributeNames: []sqstypes.QueueAttributeName{sqstypes.QueueAttributeNameQueueArn},
+	})
+	if err != nil {
+		return "", fmt.Errorf("getting queue arn, %w", err)
+	}
+	arn := out.Attributes[string(sqstypes.QueueAttributeNameQueueArn)]
+	policy, err := json.Marshal(queuePolicy(arn))
+	if err != nil {
+		return "", fmt.Errorf("marshaling queue policy, %w", err)
+	}
+	attributes := map[string]string{
+		string(sqstypes.QueueAttributeNameMessageRetentionPeriod): "300",
+		string(sqstypes.QueueAttributeNamePolicy):                 string(policy),
+	}
+	if kmsKeyID != "" {
+		attributes[string(sqstypes.QueueAttributeNameKmsMasterKeyId)] = kmsKeyID
+	} else {
+		attributes[string(sqstypes.QueueAttributeNameSqsManagedSseEnabled)] = "true"
+	}
+	if _, err = client.SetQueueAttributes(ctx, &sqs.SetQueueAttributesInput{
+		QueueUrl:   created.QueueUrl,
+		Attributes: attributes,
+	}); err != nil {
+		return "", fmt.Errorf("setting queue attributes, %w", err)
+	}
+	return arn, nil
+}
+
+// queuePolicy allows EventBridge to send messages to the queue
+func queuePolicy(arn string) map[string]interface{} {
+	return map[string]interface{}{
+		"Version": "2012-10-17",
+		"Id":      "EC2InterruptionPolicy",
+		"Statement": []map[string]interface{}{
+			{
+				"Effect": "Allow",
+				"Principal": map[string]interface{}{
+					"Service": []string{"events.amazonaws.com", "sqs.amazonaws.com"},
+				},
+				"Action":   "sqs:SendMessage",
+				"Resource": arn,
+			},
+		},
+	}
 }
 
 func (p *Provider) Name() string {
 	return p.name
 }
 
-func (p *Provider) GetSQSMessages(ctx context.Context) ([]*sqs.Message, error) {
+func (p *Provider) GetSQSMessages(ctx context.Context) ([]sqstypes.Message, error) {
+	queueURL, err := p.queueURL(ctx)
+	if err != nil {
+		return nil, err
+	}
 	input := &sqs.ReceiveMessageInput{
-		MaxNumberOfMessages: aws.Int64(10),
-		VisibilityTimeout:   aws.Int64(20), // Seconds
-		WaitTimeSeconds:     aws.Int64(20), // Seconds, maximum for long polling
-		AttributeNames: []*string{
-			aws.String(sqs.MessageSystemAttributeNameSentTimestamp),
+		MaxNumberOfMessages: 10,
+		VisibilityTimeout:   20, // Seconds
+		WaitTimeSeconds:     20, // Seconds, maximum for long polling
+		MessageSystemAttributeNames: []sqstypes.MessageSystemAttributeName{
+			sqstypes.MessageSystemAttributeNameSentTimestamp,
+			sqstypes.MessageSystemAttributeNameMessageGroupId,
+			sqstypes.MessageSystemAttributeNameApproximateReceiveCount,
 		},
-		MessageAttributeNames: []*string{
-			aws.String(sqs.QueueAttributeNameAll),
+		MessageAttributeNames: []string{
+			string(sqstypes.QueueAttributeNameAll),
 		},
-		QueueUrl: aws.String(p.url),
+		QueueUrl: aws.String(queueURL),
 	}
 
-	result, err := p.client.ReceiveMessageWithContext(ctx, input)
+	result, err := p.client.ReceiveMessage(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("receiving sqs messages, %w", err)
+		return nil, fmt.Errorf("receiving sqs messages, %w", kmsError(err))
 	}
 
 	return result.Messages, nil
@@ -76,25 +221,90 @@ func (p *Provider) SendMessage(ctx context.Context, body interface{}) (string, e
 	if err != nil {
 		return "", fmt.Errorf("marshaling the passed body as json, %w", err)
 	}
+	queueURL, err := p.queueURL(ctx)
+	if err != nil {
+		return "", err
+	}
 	input := &sqs.SendMessageInput{
 		MessageBody: aws.String(string(raw)),
-		QueueUrl:    aws.String(p.url),
+		QueueUrl:    aws.String(queueURL),
+	}
+	if p.IsFIFO() {
+		input.MessageGroupId = aws.String(sendMessageGroupID)
+		input.MessageDeduplicationId = aws.String(string(uuid.NewUUID()))
 	}
-	result, err := p.client.SendMessageWithContext(ctx, input)
+	result, err := p.client.SendMessage(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("sending messages to sqs queue, %w", err)
+		return "", fmt.Errorf("sending messages to sqs queue, %w", kmsError(err))
 	}
-	return aws.StringValue(result.MessageId), nil
+	return aws.ToString(result.MessageId), nil
 }
 
-func (p *Provider) DeleteSQSMessage(ctx context.Context, msg *sqs.Message) error {
+func (p *Provider) DeleteSQSMessage(ctx context.Context, msg *sqstypes.Message) error {
+	queueURL, err := p.queueURL(ctx)
+	if err != nil {
+		return err
+	}
 	input := &sqs.DeleteMessageInput{
-		QueueUrl:      aws.String(p.url),
+		QueueUrl:      aws.String(queueURL),
 		ReceiptHandle: msg.ReceiptHandle,
 	}
 
-	if _, err := p.client.DeleteMessageWithContext(ctx, input); err != nil {
-		return fmt.Errorf("deleting messages from sqs queue, %w", err)
+	if _, err := p.client.DeleteMessage(ctx, input); err != nil {
+		return fmt.Errorf("deleting messages from sqs queue, %w", kmsError(err))
+	}
+	return nil
+}
+
+// ReleaseSQSMessage makes the message visible on the queue again once the visibility timeout passes, so that it can be
+// received by another consumer of the queue
+func (p *Provider) ReleaseSQSMessage(ctx context.Context, msg *sqstypes.Message, visibilityTimeout time.Duration) error {
+	queueURL, err := p.queueURL(ctx)
+	if err != nil {
+		return err
+	}
+	input := &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     msg.ReceiptHandle,
+		VisibilityTimeout: int32(visibilityTimeout.Seconds()),
+	}
+
+	if _, err := p.client.ChangeMessageVisibility(ctx, input); err != nil {
+		return fmt.Errorf("changing message visibility on sqs queue, %w", err)
 	}
 	return nil
 }
+
+// IsFIFO returns true if the queue is a FIFO queue
+func (p *Provider) IsFIFO() bool {
+	return IsFIFO(p.name)
+}
+
+// IsFIFO returns true if the queue name or URL refers to a FIFO queue
+func IsFIFO(queue string) bool {
+	return strings.HasSuffix(queue, fifoSuffix)
+}
+
+// MessageGroupID returns the message group of the message. Messages that aren't part of a group, such as
+// messages from standard queues, are each considered their own group.
+func MessageGroupID(msg sqstypes.Message) string {
+	if id, ok := msg.Attributes[string(sqstypes.MessageSystemAttributeNameMessageGroupId)]; ok {
+		return id
+	}
+	return aws.ToString(msg.MessageId)
+}
+
+// ReceiveCount returns the number of times that the message has been received from the queue, or zero if it isn't known
+func ReceiveCount(msg *sqstypes.Message) int {
+	count, _ := strconv.Atoi(msg.Attributes[string(sqstypes.MessageSystemAttributeNameApproximateReceiveCount)])
+	return count
+}
+
+// kmsError adds context to errors that are caused by SQS being unable to use the KMS key that encrypts the queue,
+// since these errors otherwise only surface as opaque failures to process messages
+func kmsError(err error) error {
+	if !awserrors.IsKMSError(err) {
+		return err
+	}
+	return fmt.Errorf("unable to use the KMS key that encrypts the queue, ensure that the controller is allowed kms:Decrypt and kms:GenerateDataKey on the key, %w", err)
+}
@@ -21,15 +21,17 @@ import (
 	"sort"
 	"sync"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	"knative.dev/pkg/logging"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
@@ -37,56 +39,59 @@ import (
 
 type Provider struct {
 	sync.RWMutex
-	ec2api      ec2iface.EC2API
+	ec2api      sdk.EC2API
 	cache       *cache.Cache
 	cm          *pretty.ChangeMonitor
 	inflightIPs map[string]int64
 }
 
-func NewProvider(ec2api ec2iface.EC2API, cache *cache.Cache) *Provider {
+func NewProvider(ec2api sdk.EC2API, cache *cache.Cache) *Provider {
 	return &Provider{
 		ec2api: ec2api,
 		cm:     pretty.NewChangeMonitor(),
 		// TODO: Remove cache when we utilize the resolved subnets from the EC2NodeClass.status
 		// Subnets are sorted on AvailableIpAddressCount, descending order
-		cache: cache,
+		cache: awscache.WithExpirationMetrics(awscache.Subnets, cache),
 		// inflightIPs is used to track IPs from known launched instances
 		inflightIPs: map[string]int64{},
 	}
 }
 
-func (p *Provider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*ec2.Subnet, error) {
+func (p *Provider) List(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) ([]*ec2types.Subnet, error) {
 	p.Lock()
 	defer p.Unlock()
 	filterSets := getFilterSets(nodeClass.Spec.SubnetSelectorTerms)
 	if len(filterSets) == 0 {
-		return []*ec2.Subnet{}, nil
+		return []*ec2types.Subnet{}, nil
 	}
 	hash, err := hashstructure.Hash(filterSets, hashstructure.FormatV2, &hashstructure.HashOptions{SlicesAsSets: true})
 	if err != nil {
 		return nil, err
 	}
 	if subnets, ok := p.cache.Get(fmt.Sprint(hash)); ok {
-		return subnets.([]*ec2.Subnet), nil
+		awscache.RecordLookup(awscache.Subnets, true)
+		return subnets.([]*ec2types.Subnet), nil
 	}
+	awscache.RecordLookup(awscache.Subnets, false)
 
 	// Ensure that all the subnets that are returned here are unique
-	subnets := map[string]*ec2.Subnet{}
+	subnets := map[string]*ec2types.Subnet{}
 	for _, filters := range filterSets {
-		output, err := p.ec2api.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: filters})
+		output, err := p.ec2api.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{Filters: filters})
 		if err != nil {
 			return nil, fmt.Errorf("describing subnets %s, %w", pretty.Concise(filters), err)
 		}
 		for i := range output.Subnets {
-			subnets[lo.FromPtr(output.Subnets[i].SubnetId)] = output.Subnets[i]
+			subnets[lo.FromPtr(output.Subnets[i].SubnetId)] = &output.Subnets[i]
 			delete(p.inflightIPs, lo.FromPtr(output.Subnets[i].SubnetId)) // remove any previously tracked IP addresses since we just refreshed from EC2
 		}
 	}
 	p.cache.SetDefault(fmt.Sprint(hash), lo.Values(subnets))
+	awscache.RecordRefresh(awscache.Subnets)
 	if p.cm.HasChanged(fmt.Sprintf("subnets/%s", nodeClass.Name), subnets) {
 		logging.FromContext(ctx).
-			With("subnets", lo.Map(lo.Values(subnets), func(s *ec2.Subnet, _ int) string {
-				return fmt.Sprintf("%s (%s)", aws.StringValue(s.SubnetId), aws.StringValue(s.AvailabilityZone))
+			With("subnets", lo.Map(lo.Values(subnets), func(s *ec2types.Subnet, _ int) string {
+				return fmt.Sprintf("%s (%s)", aws.ToString(s.SubnetId), aws.ToString(s.AvailabilityZone))
 			})).
 			Debugf("discovered subnets")
 	}
@@ -99,14 +104,14 @@ func (p *Provider) CheckAnyPublicIPAssociations(ctx context.Context, nodeClass *
 	if err != nil {
 		return false, err
 	}
-	_, ok := lo.Find(subnets, func(s *ec2.Subnet) bool {
-		return aws.BoolValue(s.MapPublicIpOnLaunch)
+	_, ok := lo.Find(subnets, func(s *ec2types.Subnet) bool {
+		return aws.ToBool(s.MapPublicIpOnLaunch)
 	})
 	return ok, nil
 }
 
 // ZonalSubnetsForLaunch returns a mapping of zone to the subnet with the most available IP addresses and deducts the passed ips from the available count
-func (p *Provider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType, capacityType string) (map[string]*ec2.Subnet, error) {
+func (p *Provider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *v1beta1.EC2NodeClass, instanceTypes []*cloudprovider.InstanceType, capacityType string) (map[string]*ec2types.Subnet, error) {
 	subnets, err := p.List(ctx, nodeClass)
 	if err != nil {
 		return nil, err
@@ -117,11 +122,11 @@ func (p *Provider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *v1beta1
 	p.Lock()
 	defer p.Unlock()
 	// sort subnets in ascending order of available IP addresses and populate map with most available subnet per AZ
-	zonalSubnets := map[string]*ec2.Subnet{}
+	zonalSubnets := map[string]*ec2types.Subnet{}
 	sort.Slice(subnets, func(i, j int) bool {
-		iIPs := aws.Int64Value(subnets[i].AvailableIpAddressCount)
-		jIPs := aws.Int64Value(subnets[j].AvailableIpAddressCount)
-		// override ip count from ec2.Subnet if we've tracked launches
+		iIPs := int64(aws.ToInt32(subnets[i].AvailableIpAddressCount))
+		jIPs := int64(aws.ToInt32(subnets[j].AvailableIpAddressCount))
+		// override ip count from ec2types.Subnet if we've tracked launches
 		if ips, ok := p.inflightIPs[*subnets[i].SubnetId]; ok {
 			iIPs = ips
 		}
@@ -135,7 +140,7 @@ func (p *Provider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *v1beta1
 	}
 	for _, subnet := range zonalSubnets {
 		predictedIPsUsed := p.minPods(instanceTypes, *subnet.AvailabilityZone, capacityType)
-		prevIPs := *subnet.AvailableIpAddressCount
+		prevIPs := int64(*subnet.AvailableIpAddressCount)
 		if trackedIPs, ok := p.inflightIPs[*subnet.SubnetId]; ok {
 			prevIPs = trackedIPs
 		}
@@ -146,16 +151,13 @@ func (p *Provider) ZonalSubnetsForLaunch(ctx context.Context, nodeClass *v1beta1
 
 // UpdateInflightIPs is used to refresh the in-memory IP usage by adding back unused IPs after a CreateFleet response is returned
 func (p *Provider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInput, createFleetOutput *ec2.CreateFleetOutput, instanceTypes []*cloudprovider.InstanceType,
-	subnets []*ec2.Subnet, capacityType string) {
+	subnets []*ec2types.Subnet, capacityType string) {
 	p.Lock()
 	defer p.Unlock()
 
 	// Process the CreateFleetInput to pull out all the requested subnetIDs
-	fleetInputSubnets := lo.Compact(lo.Uniq(lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(req *ec2.FleetLaunchTemplateConfigRequest, _ int) []string {
-		return lo.Map(req.Overrides, func(override *ec2.FleetLaunchTemplateOverridesRequest, _ int) string {
-			if override == nil {
-				return ""
-			}
+	fleetInputSubnets := lo.Compact(lo.Uniq(lo.FlatMap(createFleetInput.LaunchTemplateConfigs, func(req ec2types.FleetLaunchTemplateConfigRequest, _ int) []string {
+		return lo.Map(req.Overrides, func(override ec2types.FleetLaunchTemplateOverridesRequest, _ int) string {
 			return lo.FromPtr(override.SubnetId)
 		})
 	})))
@@ -163,8 +165,8 @@ func (p *Provider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInput, cre
 	// Process the CreateFleetOutput to pull out all the fulfilled subnetIDs
 	var fleetOutputSubnets []string
 	if createFleetOutput != nil {
-		fleetOutputSubnets = lo.Compact(lo.Uniq(lo.Map(createFleetOutput.Instances, func(fleetInstance *ec2.CreateFleetInstance, _ int) string {
-			if fleetInstance == nil || fleetInstance.LaunchTemplateAndOverrides == nil || fleetInstance.LaunchTemplateAndOverrides.Overrides == nil {
+		fleetOutputSubnets = lo.Compact(lo.Uniq(lo.Map(createFleetOutput.Instances, func(fleetInstance ec2types.CreateFleetInstance, _ int) string {
+			if fleetInstance.LaunchTemplateAndOverrides == nil || fleetInstance.LaunchTemplateAndOverrides.Overrides == nil {
 				return ""
 			}
 			return lo.FromPtr(fleetInstance.LaunchTemplateAndOverrides.Overrides.SubnetId)
@@ -175,9 +177,9 @@ func (p *Provider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInput, cre
 	subnetIDsToAddBackIPs, _ := lo.Difference(fleetInputSubnets, fleetOutputSubnets)
 
 	// Aggregate all the cached subnets
-	cachedSubnets := lo.UniqBy(lo.Flatten(lo.MapToSlice(p.cache.Items(), func(_ string, item cache.Item) []*ec2.Subnet {
-		return item.Object.([]*ec2.Subnet)
-	})), func(subnet *ec2.Subnet) string { return *subnet.SubnetId })
+	cachedSubnets := lo.UniqBy(lo.Flatten(lo.MapToSlice(p.cache.Items(), func(_ string, item cache.Item) []*ec2types.Subnet {
+		return item.Object.([]*ec2types.Subnet)
+	})), func(subnet *ec2types.Subnet) string { return *subnet.SubnetId })
 
 	// Update the inflight IP tracking of subnets stored in the cache that have not be synchronized since the initial
 	// deduction of IP addresses before the instance launch
@@ -185,7 +187,7 @@ func (p *Provider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInput, cre
 		if !lo.Contains(subnetIDsToAddBackIPs, *cachedSubnet.SubnetId) {
 			continue
 		}
-		originalSubnet, ok := lo.Find(subnets, func(subnet *ec2.Subnet) bool {
+		originalSubnet, ok := lo.Find(subnets, func(subnet *ec2types.Subnet) bool {
 			return *subnet.SubnetId == *cachedSubnet.SubnetId
 		})
 		if !ok {
@@ -203,6 +205,14 @@ func (p *Provider) UpdateInflightIPs(createFleetInput *ec2.CreateFleetInput, cre
 	}
 }
 
+// Invalidate removes the subnets that are cached, so that changes to subnets are discovered on the next List instead of
+// when the cache expires
+func (p *Provider) Invalidate() {
+	p.Lock()
+	defer p.Unlock()
+	p.cache.Flush()
+}
+
 func (p *Provider) LivenessProbe(_ *http.Request) error {
 	p.Lock()
 	//nolint: staticcheck
@@ -229,24 +239,24 @@ func (p *Provider) minPods(instanceTypes []*cloudprovider.InstanceType, zone str
 	return pods
 }
 
-func getFilterSets(terms []v1beta1.SubnetSelectorTerm) (res [][]*ec2.Filter) {
-	idFilter := &ec2.Filter{Name: aws.String("subnet-id")}
+func getFilterSets(terms []v1beta1.SubnetSelectorTerm) (res [][]ec2types.Filter) {
+	idFilter := ec2types.Filter{Name: aws.String("subnet-id")}
 	for _, term := range terms {
 		switch {
 		case term.ID != "":
-			idFilter.Values = append(idFilter.Values, aws.String(term.ID))
+			idFilter.Values = append(idFilter.Values, term.ID)
 		default:
-			var filters []*ec2.Filter
+			var filters []ec2types.Filter
 			for k, v := range term.Tags {
 				if v == "*" {
-					filters = append(filters, &ec2.Filter{
+					filters = append(filters, ec2types.Filter{
 						Name:   aws.String("tag-key"),
-						Values: []*string{aws.String(k)},
+						Values: []string{k},
 					})
 				} else {
-					filters = append(filters, &ec2.Filter{
+					filters = append(filters, ec2types.Filter{
 						Name:   aws.String(fmt.Sprintf("tag:%s", k)),
-						Values: []*string{aws.String(v)},
+						Values: []string{v},
 					})
 				}
 			}
@@ -254,7 +264,7 @@ func getFilterSets(terms []v1beta1.SubnetSelectorTerm) (res [][]*ec2.Filter) {
 		}
 	}
 	if len(idFilter.Values) > 0 {
-		res = append(res, []*ec2.Filter{idFilter})
+		res = append(res, []ec2types.Filter{idFilter})
 	}
 	return res
 }
@@ -18,12 +18,13 @@ import (
 	"context"
 	"testing"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
 	"github.com/aws/karpenter-provider-aws/pkg/test"
 
@@ -101,11 +102,11 @@ var _ = Describe("SubnetProvider", func() {
 			}
 			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
 			Expect(err).To(BeNil())
-			ExpectConsistsOfSubnets([]*ec2.Subnet{
+			ExpectConsistsOfSubnets([]*ec2types.Subnet{
 				{
 					SubnetId:                lo.ToPtr("subnet-test1"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 			}, subnets)
 		})
@@ -120,16 +121,16 @@ var _ = Describe("SubnetProvider", func() {
 			}
 			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
 			Expect(err).To(BeNil())
-			ExpectConsistsOfSubnets([]*ec2.Subnet{
+			ExpectConsistsOfSubnets([]*ec2types.Subnet{
 				{
 					SubnetId:                lo.ToPtr("subnet-test1"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 				{
 					SubnetId:                lo.ToPtr("subnet-test2"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1b"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 			}, subnets)
 		})
@@ -146,16 +147,16 @@ var _ = Describe("SubnetProvider", func() {
 			}
 			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
 			Expect(err).To(BeNil())
-			ExpectConsistsOfSubnets([]*ec2.Subnet{
+			ExpectConsistsOfSubnets([]*ec2types.Subnet{
 				{
 					SubnetId:                lo.ToPtr("subnet-test1"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 				{
 					SubnetId:                lo.ToPtr("subnet-test2"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1b"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 			}, subnets)
 		})
@@ -167,11 +168,11 @@ var _ = Describe("SubnetProvider", func() {
 			}
 			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
 			Expect(err).To(BeNil())
-			ExpectConsistsOfSubnets([]*ec2.Subnet{
+			ExpectConsistsOfSubnets([]*ec2types.Subnet{
 				{
 					SubnetId:                lo.ToPtr("subnet-test1"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 			}, subnets)
 		})
@@ -186,16 +187,16 @@ var _ = Describe("SubnetProvider", func() {
 			}
 			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
 			Expect(err).To(BeNil())
-			ExpectConsistsOfSubnets([]*ec2.Subnet{
+			ExpectConsistsOfSubnets([]*ec2types.Subnet{
 				{
 					SubnetId:                lo.ToPtr("subnet-test1"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1a"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 				{
 					SubnetId:                lo.ToPtr("subnet-test2"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1b"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 			}, subnets)
 		})
@@ -208,14 +209,33 @@ var _ = Describe("SubnetProvider", func() {
 			}
 			subnets, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
 			Expect(err).To(BeNil())
-			ExpectConsistsOfSubnets([]*ec2.Subnet{
+			ExpectConsistsOfSubnets([]*ec2types.Subnet{
 				{
 					SubnetId:                lo.ToPtr("subnet-test2"),
 					AvailabilityZone:        lo.ToPtr("test-zone-1b"),
-					AvailableIpAddressCount: lo.ToPtr[int64](100),
+					AvailableIpAddressCount: lo.ToPtr[int32](100),
 				},
 			}, subnets)
 		})
+		It("should record cache hits and misses", func() {
+			nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{ID: "subnet-test1"}}
+			_, err := awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			misses, ok := FindMetricWithLabelValues("karpenter_cache_misses_total", map[string]string{"cache": awscache.Subnets})
+			Expect(ok).To(BeTrue())
+			hits, _ := FindMetricWithLabelValues("karpenter_cache_hits_total", map[string]string{"cache": awscache.Subnets})
+			hitCount := hits.GetCounter().GetValue()
+
+			_, err = awsEnv.SubnetProvider.List(ctx, nodeClass)
+			Expect(err).To(BeNil())
+			hits, ok = FindMetricWithLabelValues("karpenter_cache_hits_total", map[string]string{"cache": awscache.Subnets})
+			Expect(ok).To(BeTrue())
+			Expect(hits.GetCounter().GetValue()).To(BeNumerically("==", hitCount+1))
+			Expect(misses.GetCounter().GetValue()).To(BeNumerically(">", 0))
+			refresh, ok := FindMetricWithLabelValues("karpenter_cache_last_refresh_timestamp_seconds", map[string]string{"cache": awscache.Subnets})
+			Expect(ok).To(BeTrue())
+			Expect(refresh.GetGauge().GetValue()).To(BeNumerically(">", 0))
+		})
 	})
 	Context("CheckAnyPublicIPAssociations", func() {
 		It("should note that no subnets assign a public IPv4 address to EC2 instances on launch", func() {
@@ -242,11 +262,11 @@ var _ = Describe("SubnetProvider", func() {
 	})
 })
 
-func ExpectConsistsOfSubnets(expected, actual []*ec2.Subnet) {
+func ExpectConsistsOfSubnets(expected, actual []*ec2types.Subnet) {
 	GinkgoHelper()
 	Expect(actual).To(HaveLen(len(expected)))
 	for _, elem := range expected {
-		_, ok := lo.Find(actual, func(s *ec2.Subnet) bool {
+		_, ok := lo.Find(actual, func(s *ec2types.Subnet) bool {
 			return lo.FromPtr(s.SubnetId) == lo.FromPtr(elem.SubnetId) &&
 				lo.FromPtr(s.AvailabilityZone) == lo.FromPtr(elem.AvailabilityZone) &&
 				lo.FromPtr(s.AvailableIpAddressCount) == lo.FromPtr(elem.AvailableIpAddressCount)
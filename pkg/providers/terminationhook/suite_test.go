@@ -0,0 +1,191 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminationhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	clock "k8s.io/utils/clock/testing"
+
+	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/terminationhook"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+)
+
+var ctx context.Context
+var fakeClock *clock.FakeClock
+var lambdaAPI *fake.LambdaAPI
+var server *httptest.Server
+var webhook *fakeWebhook
+var terminationHookProvider *terminationhook.Provider
+
+func TestAWS(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Provider/AWS/TerminationHook")
+}
+
+// fakeWebhook records the requests that it receives and responds with the configured status
+type fakeWebhook struct {
+	mu       sync.Mutex
+	status   int
+	requests []terminationhook.Request
+}
+
+func (f *fakeWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	request := terminationhook.Request{}
+	lo.Must0(json.Unmarshal(lo.Must(io.ReadAll(r.Body)), &request))
+	f.requests = append(f.requests, request)
+	w.WriteHeader(f.status)
+}
+
+func (f *fakeWebhook) SetStatus(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = status
+}
+
+func (f *fakeWebhook) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status = http.StatusOK
+	f.requests = nil
+}
+
+func (f *fakeWebhook) Requests() []terminationhook.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]terminationhook.Request{}, f.requests...)
+}
+
+var _ = BeforeSuite(func() {
+	webhook = &fakeWebhook{}
+	server = httptest.NewServer(webhook)
+	fakeClock = clock.NewFakeClock(time.Now())
+	lambdaAPI = &fake.LambdaAPI{}
+})
+
+var _ = AfterSuite(func() {
+	server.Close()
+})
+
+var _ = BeforeEach(func() {
+	webhook.Reset()
+	lambdaAPI.Reset()
+	fakeClock.SetTime(time.Now())
+	terminationHookProvider = terminationhook.NewProvider(fakeClock, lambdaAPI, server.Client(), cache.New(time.Hour, time.Minute))
+})
+
+var _ = Describe("TerminationHook", func() {
+	var request terminationhook.Request
+
+	BeforeEach(func() {
+		request = terminationhook.Request{
+			ClusterName:  "test-cluster",
+			InstanceID:   fake.InstanceID(),
+			InstanceType: "m5.large",
+			Zone:         "test-zone-1a",
+			CapacityType: "on-demand",
+			NodeClaim:    "default-abcde",
+			NodePool:     "default",
+			NodeName:     "ip-192-168-0-1.ec2.internal",
+		}
+	})
+	It("should not call any hooks when none are configured", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		Expect(terminationHookProvider.Call(ctx, request)).To(Succeed())
+		Expect(webhook.Requests()).To(BeEmpty())
+		Expect(lambdaAPI.InvokeBehavior.Calls()).To(Equal(0))
+	})
+	It("should send the instance metadata to the webhook", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PreTerminationWebhookURL: lo.ToPtr(server.URL)}))
+		Expect(terminationHookProvider.Call(ctx, request)).To(Succeed())
+		Expect(webhook.Requests()).To(ConsistOf(request))
+	})
+	It("should invoke the lambda with the instance metadata", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PreTerminationLambda: lo.ToPtr("karpenter-pre-termination")}))
+		Expect(terminationHookProvider.Call(ctx, request)).To(Succeed())
+		Expect(lambdaAPI.InvokeBehavior.CalledWithInput.Len()).To(Equal(1))
+		input := lambdaAPI.InvokeBehavior.CalledWithInput.Pop()
+		Expect(aws.ToString(input.FunctionName)).To(Equal("karpenter-pre-termination"))
+		Expect(input.InvocationType).To(Equal(lambdatypes.InvocationTypeRequestResponse))
+		payload := terminationhook.Request{}
+		Expect(json.Unmarshal(input.Payload, &payload)).To(Succeed())
+		Expect(payload).To(Equal(request))
+	})
+	It("should only call the hooks once after they succeed", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PreTerminationWebhookURL: lo.ToPtr(server.URL)}))
+		Expect(terminationHookProvider.Call(ctx, request)).To(Succeed())
+		Expect(terminationHookProvider.Call(ctx, request)).To(Succeed())
+		Expect(webhook.Requests()).To(HaveLen(1))
+	})
+	It("should fail when the webhook doesn't respond with a 2xx status", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PreTerminationWebhookURL: lo.ToPtr(server.URL)}))
+		webhook.SetStatus(http.StatusServiceUnavailable)
+		Expect(terminationHookProvider.Call(ctx, request)).ToNot(Succeed())
+
+		// The webhook is called again when termination is retried
+		webhook.SetStatus(http.StatusOK)
+		Expect(terminationHookProvider.Call(ctx, request)).To(Succeed())
+		Expect(webhook.Requests()).To(HaveLen(2))
+	})
+	It("should fail when the lambda returns a function error", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{PreTerminationLambda: lo.ToPtr("karpenter-pre-termination")}))
+		lambdaAPI.InvokeBehavior.Output.Set(&lambda.InvokeOutput{
+			StatusCode:    200,
+			FunctionError: aws.String("Unhandled"),
+			Payload:       []byte(`{"errorMessage": "storage fabric unavailable"}`),
+		})
+		Expect(terminationHookProvider.Call(ctx, request)).To(MatchError(ContainSubstring("storage fabric unavailable")))
+	})
+	It("should succeed once the timeout has elapsed since the hooks were first called", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			PreTerminationWebhookURL: lo.ToPtr(server.URL),
+			PreTerminationTimeout:    lo.ToPtr(5 * time.Minute),
+		}))
+		webhook.SetStatus(http.StatusInternalServerError)
+		Expect(terminationHookProvider.Call(ctx, request)).ToNot(Succeed())
+		fakeClock.Step(4 * time.Minute)
+		Expect(terminationHookProvider.Call(ctx, request)).ToNot(Succeed())
+		fakeClock.Step(time.Minute)
+		Expect(terminationHookProvider.Call(ctx, request)).To(Succeed())
+		Expect(webhook.Requests()).To(HaveLen(2))
+
+		// The timeout is tracked per instance
+		other := request
+		other.InstanceID = fake.InstanceID()
+		Expect(terminationHookProvider.Call(ctx, other)).To(MatchError(ContainSubstring(fmt.Sprint(http.StatusInternalServerError))))
+	})
+})
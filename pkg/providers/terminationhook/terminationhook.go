@@ -0,0 +1,175 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminationhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/multierr"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+)
+
+// Request is the metadata of an instance that is about to be terminated. It's sent as the body of the webhook request
+// and as the payload of the Lambda invocation.
+type Request struct {
+	ClusterName  string            `json:"clusterName"`
+	InstanceID   string            `json:"instanceID"`
+	InstanceType string            `json:"instanceType"`
+	Zone         string            `json:"zone"`
+	CapacityType string            `json:"capacityType"`
+	NodeClaim    string            `json:"nodeClaim,omitempty"`
+	NodePool     string            `json:"nodePool,omitempty"`
+	NodeName     string            `json:"nodeName,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// call tracks the pre-termination hooks of an instance across calls to Delete
+type call struct {
+	started   time.Time
+	completed bool
+}
+
+// Provider calls the pre-termination webhook and Lambda before instances are terminated. Termination is retried until
+// the hooks succeed or until the pre-termination timeout has elapsed since they were first called for the instance, so
+// a hook that is unavailable doesn't prevent instances from being terminated.
+type Provider struct {
+	sync.Mutex
+
+	clk        clock.Clock
+	lambdaAPI  sdk.LambdaAPI
+	httpClient *http.Client
+	cache      *cache.Cache
+}
+
+func NewProvider(clk clock.Clock, lambdaAPI sdk.LambdaAPI, httpClient *http.Client, cache *cache.Cache) *Provider {
+	return &Provider{
+		clk:        clk,
+		lambdaAPI:  lambdaAPI,
+		httpClient: httpClient,
+		cache:      cache,
+	}
+}
+
+// Enabled returns true if a pre-termination webhook or Lambda is configured
+func Enabled(ctx context.Context) bool {
+	return options.FromContext(ctx).PreTerminationWebhookURL != "" || options.FromContext(ctx).PreTerminationLambda != ""
+}
+
+// Call calls the pre-termination hooks for the instance. It returns nil once the hooks have succeeded, or once the
+// pre-termination timeout has elapsed since the hooks were first called for the instance.
+func (p *Provider) Call(ctx context.Context, request Request) error {
+	if !Enabled(ctx) {
+		return nil
+	}
+	c := p.get(request.InstanceID)
+	if c.completed {
+		return nil
+	}
+	remaining := options.FromContext(ctx).PreTerminationTimeout - p.clk.Since(c.started)
+	if remaining <= 0 {
+		logging.FromContext(ctx).With("timeout", options.FromContext(ctx).PreTerminationTimeout).Errorf("pre-termination hooks didn't succeed before the timeout, terminating instance")
+		p.complete(request.InstanceID, c)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, remaining)
+	defer cancel()
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshaling pre-termination request, %w", err)
+	}
+	if err = multierr.Combine(p.callWebhook(ctx, payload), p.invokeLambda(ctx, payload)); err != nil {
+		return fmt.Errorf("calling pre-termination hooks, %w", err)
+	}
+	logging.FromContext(ctx).Debugf("called pre-termination hooks")
+	p.complete(request.InstanceID, c)
+	return nil
+}
+
+// get returns the pre-termination hook call of the instance, starting a new call if the hooks haven't been called yet
+func (p *Provider) get(id string) call {
+	p.Lock()
+	defer p.Unlock()
+	c := call{started: p.clk.Now()}
+	if cached, ok := p.cache.Get(id); ok {
+		c = cached.(call)
+	}
+	// The call is stored again on every Delete so that it doesn't expire while termination is retried
+	p.cache.SetDefault(id, c)
+	return c
+}
+
+func (p *Provider) complete(id string, c call) {
+	p.Lock()
+	defer p.Unlock()
+	c.completed = true
+	p.cache.SetDefault(id, c)
+}
+
+func (p *Provider) callWebhook(ctx context.Context, payload []byte) error {
+	url := options.FromContext(ctx).PreTerminationWebhookURL
+	if url == "" {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating webhook request, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling webhook, %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("calling webhook, received status %d, %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func (p *Provider) invokeLambda(ctx context.Context, payload []byte) error {
+	functionName := options.FromContext(ctx).PreTerminationLambda
+	if functionName == "" {
+		return nil
+	}
+	out, err := p.lambdaAPI.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(functionName),
+		InvocationType: lambdatypes.InvocationTypeRequestResponse,
+		Payload:        payload,
+	})
+	if err != nil {
+		return fmt.Errorf("invoking lambda, %w", err)
+	}
+	if out.FunctionError != nil {
+		return fmt.Errorf("invoking lambda, function returned %s, %s", aws.ToString(out.FunctionError), bytes.TrimSpace(out.Payload))
+	}
+	return nil
+}
@@ -17,10 +17,12 @@ package test
 import (
 	"context"
 	"net"
+	"net/http"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
 	"knative.dev/pkg/ptr"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -29,14 +31,18 @@ import (
 	"github.com/aws/karpenter-provider-aws/pkg/apis"
 	awscache "github.com/aws/karpenter-provider-aws/pkg/cache"
 	"github.com/aws/karpenter-provider-aws/pkg/fake"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instance"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instanceprofile"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/launchtemplate"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/noderole"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/pricing"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/quota"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/securitygroup"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/subnet"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/terminationhook"
 	"github.com/aws/karpenter-provider-aws/pkg/providers/version"
 
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -51,11 +57,17 @@ func init() {
 
 type Environment struct {
 	// API
-	EC2API     *fake.EC2API
-	EKSAPI     *fake.EKSAPI
-	SSMAPI     *fake.SSMAPI
-	IAMAPI     *fake.IAMAPI
-	PricingAPI *fake.PricingAPI
+	EC2API           *fake.EC2API
+	EKSAPI           *fake.EKSAPI
+	SSMAPI           *fake.SSMAPI
+	IAMAPI           *fake.IAMAPI
+	PricingAPI       *fake.PricingAPI
+	SavingsPlansAPI  *fake.SavingsPlansAPI
+	LambdaAPI        *fake.LambdaAPI
+	ServiceQuotasAPI *fake.ServiceQuotasAPI
+	// AccountEC2API and AccountIAMAPI are called for EC2NodeClasses that assume a role in another account
+	AccountEC2API *fake.EC2API
+	AccountIAMAPI *fake.IAMAPI
 
 	// Cache
 	EC2Cache                  *cache.Cache
@@ -66,6 +78,8 @@ type Environment struct {
 	SubnetCache               *cache.Cache
 	SecurityGroupCache        *cache.Cache
 	InstanceProfileCache      *cache.Cache
+	NodeRoleCache             *cache.Cache
+	TerminationHookCache      *cache.Cache
 
 	// Providers
 	InstanceTypesProvider   *instancetype.Provider
@@ -73,11 +87,15 @@ type Environment struct {
 	SubnetProvider          *subnet.Provider
 	SecurityGroupProvider   *securitygroup.Provider
 	InstanceProfileProvider *instanceprofile.Provider
+	NodeRoleProvider        *noderole.Provider
 	PricingProvider         *pricing.Provider
+	QuotaProvider           *quota.Provider
 	AMIProvider             *amifamily.Provider
 	AMIResolver             *amifamily.Resolver
 	VersionProvider         *version.Provider
 	LaunchTemplateProvider  *launchtemplate.Provider
+	TerminationHookProvider *terminationhook.Provider
+	AccountProvider         *account.Provider
 }
 
 func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment {
@@ -86,6 +104,10 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	eksapi := fake.NewEKSAPI()
 	ssmapi := fake.NewSSMAPI()
 	iamapi := fake.NewIAMAPI()
+	lambdaapi := &fake.LambdaAPI{}
+	servicequotasapi := fake.NewServiceQuotasAPI()
+	accountec2api := fake.NewEC2API()
+	accountiamapi := fake.NewIAMAPI()
 
 	// cache
 	ec2Cache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
@@ -96,17 +118,22 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 	subnetCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	securityGroupCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
 	instanceProfileCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	nodeRoleCache := cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)
+	terminationHookCache := cache.New(awscache.PreTerminationHookTTL, awscache.DefaultCleanupInterval)
 	fakePricingAPI := &fake.PricingAPI{}
+	fakeSavingsPlansAPI := &fake.SavingsPlansAPI{}
 
 	// Providers
-	pricingProvider := pricing.NewProvider(ctx, fakePricingAPI, ec2api, fake.DefaultRegion)
+	pricingProvider := pricing.NewProvider(ctx, fakePricingAPI, ec2api, fakeSavingsPlansAPI, fake.DefaultRegion)
+	quotaProvider := quota.NewProvider(servicequotasapi, ec2api)
 	subnetProvider := subnet.NewProvider(ec2api, subnetCache)
 	securityGroupProvider := securitygroup.NewProvider(ec2api, securityGroupCache)
 	versionProvider := version.NewProvider(env.KubernetesInterface, kubernetesVersionCache)
 	instanceProfileProvider := instanceprofile.NewProvider(fake.DefaultRegion, iamapi, instanceProfileCache)
+	nodeRoleProvider := noderole.NewProvider(fake.DefaultRegion, iamapi, eksapi, nodeRoleCache)
 	amiProvider := amifamily.NewProvider(versionProvider, ssmapi, ec2api, ec2Cache)
 	amiResolver := amifamily.New(amiProvider)
-	instanceTypesProvider := instancetype.NewProvider(fake.DefaultRegion, instanceTypeCache, ec2api, subnetProvider, unavailableOfferingsCache, pricingProvider)
+	instanceTypesProvider := instancetype.NewProvider(fake.DefaultRegion, instanceTypeCache, ec2api, subnetProvider, unavailableOfferingsCache, pricingProvider, quotaProvider)
 	launchTemplateProvider :=
 		launchtemplate.NewProvider(
 			ctx,
@@ -122,6 +149,7 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			net.ParseIP("10.0.100.10"),
 			"https://test-cluster",
 		)
+	terminationHookProvider := terminationhook.NewProvider(clock.RealClock{}, lambdaapi, &http.Client{}, terminationHookCache)
 	instanceProvider :=
 		instance.NewProvider(ctx,
 			"",
@@ -130,14 +158,69 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 			instanceTypesProvider,
 			subnetProvider,
 			launchTemplateProvider,
+			terminationHookProvider,
+			quotaProvider,
 		)
+	accountProvider := account.NewProvider(&account.Providers{
+		EC2API:          ec2api,
+		Subnet:          subnetProvider,
+		SecurityGroup:   securityGroupProvider,
+		InstanceProfile: instanceProfileProvider,
+		NodeRole:        nodeRoleProvider,
+		AMI:             amiProvider,
+		LaunchTemplate:  launchTemplateProvider,
+		Instance:        instanceProvider,
+	}, func(_ account.Role) *account.Providers {
+		roleSubnetProvider := subnet.NewProvider(accountec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+		roleSecurityGroupProvider := securitygroup.NewProvider(accountec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+		roleInstanceProfileProvider := instanceprofile.NewProvider(fake.DefaultRegion, accountiamapi, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+		roleAMIProvider := amifamily.NewProvider(versionProvider, ssmapi, accountec2api, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval))
+		roleLaunchTemplateProvider := launchtemplate.NewProvider(
+			ctx,
+			cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval),
+			accountec2api,
+			eksapi,
+			amifamily.New(roleAMIProvider),
+			roleSecurityGroupProvider,
+			roleSubnetProvider,
+			roleInstanceProfileProvider,
+			ptr.String("ca-bundle"),
+			make(chan struct{}),
+			net.ParseIP("10.0.100.10"),
+			"https://test-cluster",
+		)
+		return &account.Providers{
+			EC2API:          accountec2api,
+			Subnet:          roleSubnetProvider,
+			SecurityGroup:   roleSecurityGroupProvider,
+			InstanceProfile: roleInstanceProfileProvider,
+			NodeRole:        noderole.NewProvider(fake.DefaultRegion, accountiamapi, eksapi, cache.New(awscache.DefaultTTL, awscache.DefaultCleanupInterval)),
+			AMI:             roleAMIProvider,
+			LaunchTemplate:  roleLaunchTemplateProvider,
+			Instance: instance.NewProvider(ctx,
+				"",
+				accountec2api,
+				unavailableOfferingsCache,
+				instanceTypesProvider,
+				roleSubnetProvider,
+				roleLaunchTemplateProvider,
+				terminationHookProvider,
+				nil,
+			),
+		}
+	})
 
 	return &Environment{
-		EC2API:     ec2api,
-		EKSAPI:     eksapi,
-		SSMAPI:     ssmapi,
-		IAMAPI:     iamapi,
-		PricingAPI: fakePricingAPI,
+		EC2API:           ec2api,
+		EKSAPI:           eksapi,
+		SSMAPI:           ssmapi,
+		IAMAPI:           iamapi,
+		PricingAPI:       fakePricingAPI,
+		SavingsPlansAPI:  fakeSavingsPlansAPI,
+		LambdaAPI:        lambdaapi,
+		ServiceQuotasAPI: servicequotasapi,
+		AccountEC2API:    accountec2api,
+		AccountIAMAPI:    accountiamapi,
 
 		EC2Cache:                  ec2Cache,
 		KubernetesVersionCache:    kubernetesVersionCache,
@@ -146,6 +229,8 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 		SubnetCache:               subnetCache,
 		SecurityGroupCache:        securityGroupCache,
 		InstanceProfileCache:      instanceProfileCache,
+		NodeRoleCache:             nodeRoleCache,
+		TerminationHookCache:      terminationHookCache,
 		UnavailableOfferingsCache: unavailableOfferingsCache,
 
 		InstanceTypesProvider:   instanceTypesProvider,
@@ -154,10 +239,14 @@ func NewEnvironment(ctx context.Context, env *coretest.Environment) *Environment
 		SecurityGroupProvider:   securityGroupProvider,
 		LaunchTemplateProvider:  launchTemplateProvider,
 		InstanceProfileProvider: instanceProfileProvider,
+		NodeRoleProvider:        nodeRoleProvider,
 		PricingProvider:         pricingProvider,
+		QuotaProvider:           quotaProvider,
 		AMIProvider:             amiProvider,
 		AMIResolver:             amiResolver,
 		VersionProvider:         versionProvider,
+		TerminationHookProvider: terminationHookProvider,
+		AccountProvider:         accountProvider,
 	}
 }
 
@@ -167,7 +256,14 @@ func (env *Environment) Reset() {
 	env.SSMAPI.Reset()
 	env.IAMAPI.Reset()
 	env.PricingAPI.Reset()
+	env.SavingsPlansAPI.Reset()
+	env.LambdaAPI.Reset()
+	env.ServiceQuotasAPI.Reset()
+	env.AccountEC2API.Reset()
+	env.AccountIAMAPI.Reset()
 	env.PricingProvider.Reset()
+	env.QuotaProvider.Reset()
+	env.AccountProvider.Reset()
 
 	env.EC2Cache.Flush()
 	env.KubernetesVersionCache.Flush()
@@ -177,6 +273,8 @@ func (env *Environment) Reset() {
 	env.SubnetCache.Flush()
 	env.SecurityGroupCache.Flush()
 	env.InstanceProfileCache.Flush()
+	env.NodeRoleCache.Flush()
+	env.TerminationHookCache.Flush()
 
 	mfs, err := crmetrics.Registry.Gather()
 	if err != nil {
@@ -25,15 +25,82 @@ import (
 )
 
 type OptionsFields struct {
-	AssumeRoleARN           *string
-	AssumeRoleDuration      *time.Duration
-	ClusterCABundle         *string
-	ClusterName             *string
-	ClusterEndpoint         *string
-	IsolatedVPC             *bool
-	VMMemoryOverheadPercent *float64
-	InterruptionQueue       *string
-	ReservedENIs            *int
+	AssumeRoleARN                   *string
+	AssumeRoleDuration              *time.Duration
+	ClusterCABundle                 *string
+	ClusterName                     *string
+	ClusterEndpoint                 *string
+	IsolatedVPC                     *bool
+	VMMemoryOverheadPercent         *float64
+	InterruptionQueue               *string
+	ReservedENIs                    *int
+	CommitmentAwarePricing          *bool
+	PricingOverridesConfigMap       *string
+	PricingFile                     *string
+	PricingRefreshInterval          *time.Duration
+	SpotPricingRefreshInterval      *time.Duration
+	PricingEndpoint                 *string
+	CostAllocationTags              *string
+	ComputeOptimizerRecommendations *bool
+	ScheduledMaintenanceLeadTime    *time.Duration
+	ManagedInterruptionQueue        *bool
+	InterruptionQueueKMSKeyID       *string
+	InterruptionQueueTags           *string
+	InterruptionQueueRoleARN        *string
+	InterruptionDrainPolicy         *string
+	InterruptionEndpointPort        *int
+	InterruptionEndpointAPIKey      *string
+	SharedInterruptionQueue         *bool
+	InstanceStatusRepairPeriod      *time.Duration
+	LaunchDiagnostics               *bool
+	LaunchDiagnosticsBucket         *string
+	StoppedInstancePoolSize         *int
+	StoppedInstancePoolTTL          *time.Duration
+	PreTerminationWebhookURL        *string
+	PreTerminationLambda            *string
+	PreTerminationTimeout           *time.Duration
+	MaxConcurrentLaunches           *int
+	VolumeGarbageCollectionAge      *time.Duration
+	VolumeGarbageCollectionDryRun   *bool
+	GarbageCollectionInterval       *time.Duration
+	GarbageCollectionBatchSize      *int
+	GarbageCollectionListWorkers    *int
+	InPlaceMetadataOptionsUpdate    *bool
+	InPlaceSecurityGroupsUpdate     *bool
+	LabelTags                       *string
+	TagLabels                       *string
+	TagAnnotations                  *string
+	TagPolicy                       *string
+	InstanceNameTemplate            *string
+	DriftReconciliationInterval     *time.Duration
+	ManageNodeRoles                 *bool
+	NodeRolePolicyARNs              *string
+	ManageAWSAuth                   *bool
+	UseFIPSEndpoints                *bool
+	EC2Endpoint                     *string
+	SSMEndpoint                     *string
+	SQSEndpoint                     *string
+	IAMEndpoint                     *string
+	EKSEndpoint                     *string
+	PermissionCheck                 *bool
+	SettingsConfigMap               *string
+	QuotaAwareProvisioning          *bool
+	QuotaWarningThreshold           *float64
+	TracingEndpoint                 *string
+	CloudWatchMetricsLogGroup       *string
+	EventBridgeBus                  *string
+	AuditLog                        *bool
+	DebugEndpointPort               *int
+	DebugEndpointAPIKey             *string
+	AWSHealthCheck                  *bool
+	AMICacheTTL                     *time.Duration
+	AWSAPIRateLimits                *string
+	CircuitBreakerThreshold         *int
+	CacheSnapshotPath               *string
+	ResourceChangeEvents            *bool
+	SelectorValidation              *string
+	AMICompatibilityValidation      *string
+	InstanceProfileValidation       *string
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -44,14 +111,81 @@ func Options(overrides ...OptionsFields) *options.Options {
 		}
 	}
 	return &options.Options{
-		AssumeRoleARN:           lo.FromPtrOr(opts.AssumeRoleARN, ""),
-		AssumeRoleDuration:      lo.FromPtrOr(opts.AssumeRoleDuration, 15*time.Minute),
-		ClusterCABundle:         lo.FromPtrOr(opts.ClusterCABundle, ""),
-		ClusterName:             lo.FromPtrOr(opts.ClusterName, "test-cluster"),
-		ClusterEndpoint:         lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
-		IsolatedVPC:             lo.FromPtrOr(opts.IsolatedVPC, false),
-		VMMemoryOverheadPercent: lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
-		InterruptionQueue:       lo.FromPtrOr(opts.InterruptionQueue, ""),
-		ReservedENIs:            lo.FromPtrOr(opts.ReservedENIs, 0),
+		AssumeRoleARN:                   lo.FromPtrOr(opts.AssumeRoleARN, ""),
+		AssumeRoleDuration:              lo.FromPtrOr(opts.AssumeRoleDuration, 15*time.Minute),
+		ClusterCABundle:                 lo.FromPtrOr(opts.ClusterCABundle, ""),
+		ClusterName:                     lo.FromPtrOr(opts.ClusterName, "test-cluster"),
+		ClusterEndpoint:                 lo.FromPtrOr(opts.ClusterEndpoint, "https://test-cluster"),
+		IsolatedVPC:                     lo.FromPtrOr(opts.IsolatedVPC, false),
+		VMMemoryOverheadPercent:         lo.FromPtrOr(opts.VMMemoryOverheadPercent, 0.075),
+		InterruptionQueue:               lo.FromPtrOr(opts.InterruptionQueue, ""),
+		ReservedENIs:                    lo.FromPtrOr(opts.ReservedENIs, 0),
+		CommitmentAwarePricing:          lo.FromPtrOr(opts.CommitmentAwarePricing, false),
+		PricingOverridesConfigMap:       lo.FromPtrOr(opts.PricingOverridesConfigMap, ""),
+		PricingFile:                     lo.FromPtrOr(opts.PricingFile, ""),
+		PricingRefreshInterval:          lo.FromPtrOr(opts.PricingRefreshInterval, 12*time.Hour),
+		SpotPricingRefreshInterval:      lo.FromPtrOr(opts.SpotPricingRefreshInterval, 12*time.Hour),
+		PricingEndpoint:                 lo.FromPtrOr(opts.PricingEndpoint, ""),
+		CostAllocationTags:              lo.FromPtrOr(opts.CostAllocationTags, ""),
+		ComputeOptimizerRecommendations: lo.FromPtrOr(opts.ComputeOptimizerRecommendations, false),
+		ScheduledMaintenanceLeadTime:    lo.FromPtrOr(opts.ScheduledMaintenanceLeadTime, time.Hour),
+		ManagedInterruptionQueue:        lo.FromPtrOr(opts.ManagedInterruptionQueue, false),
+		InterruptionQueueKMSKeyID:       lo.FromPtrOr(opts.InterruptionQueueKMSKeyID, ""),
+		InterruptionQueueTags:           lo.FromPtrOr(opts.InterruptionQueueTags, ""),
+		InterruptionQueueRoleARN:        lo.FromPtrOr(opts.InterruptionQueueRoleARN, ""),
+		InterruptionDrainPolicy:         lo.FromPtrOr(opts.InterruptionDrainPolicy, ""),
+		InterruptionEndpointPort:        lo.FromPtrOr(opts.InterruptionEndpointPort, 0),
+		InterruptionEndpointAPIKey:      lo.FromPtrOr(opts.InterruptionEndpointAPIKey, ""),
+		SharedInterruptionQueue:         lo.FromPtrOr(opts.SharedInterruptionQueue, false),
+		InstanceStatusRepairPeriod:      lo.FromPtrOr(opts.InstanceStatusRepairPeriod, 0),
+		LaunchDiagnostics:               lo.FromPtrOr(opts.LaunchDiagnostics, false),
+		LaunchDiagnosticsBucket:         lo.FromPtrOr(opts.LaunchDiagnosticsBucket, ""),
+		StoppedInstancePoolSize:         lo.FromPtrOr(opts.StoppedInstancePoolSize, 0),
+		StoppedInstancePoolTTL:          lo.FromPtrOr(opts.StoppedInstancePoolTTL, 24*time.Hour),
+		PreTerminationWebhookURL:        lo.FromPtrOr(opts.PreTerminationWebhookURL, ""),
+		PreTerminationLambda:            lo.FromPtrOr(opts.PreTerminationLambda, ""),
+		PreTerminationTimeout:           lo.FromPtrOr(opts.PreTerminationTimeout, 5*time.Minute),
+		MaxConcurrentLaunches:           lo.FromPtrOr(opts.MaxConcurrentLaunches, 0),
+		VolumeGarbageCollectionAge:      lo.FromPtrOr(opts.VolumeGarbageCollectionAge, 0),
+		VolumeGarbageCollectionDryRun:   lo.FromPtrOr(opts.VolumeGarbageCollectionDryRun, false),
+		GarbageCollectionInterval:       lo.FromPtrOr(opts.GarbageCollectionInterval, 2*time.Minute),
+		GarbageCollectionBatchSize:      lo.FromPtrOr(opts.GarbageCollectionBatchSize, 100),
+		GarbageCollectionListWorkers:    lo.FromPtrOr(opts.GarbageCollectionListWorkers, 1),
+		InPlaceMetadataOptionsUpdate:    lo.FromPtrOr(opts.InPlaceMetadataOptionsUpdate, false),
+		InPlaceSecurityGroupsUpdate:     lo.FromPtrOr(opts.InPlaceSecurityGroupsUpdate, false),
+		LabelTags:                       lo.FromPtrOr(opts.LabelTags, ""),
+		TagLabels:                       lo.FromPtrOr(opts.TagLabels, ""),
+		TagAnnotations:                  lo.FromPtrOr(opts.TagAnnotations, ""),
+		TagPolicy:                       lo.FromPtrOr(opts.TagPolicy, ""),
+		InstanceNameTemplate:            lo.FromPtrOr(opts.InstanceNameTemplate, ""),
+		DriftReconciliationInterval:     lo.FromPtrOr(opts.DriftReconciliationInterval, 0),
+		ManageNodeRoles:                 lo.FromPtrOr(opts.ManageNodeRoles, false),
+		NodeRolePolicyARNs:              lo.FromPtrOr(opts.NodeRolePolicyARNs, ""),
+		ManageAWSAuth:                   lo.FromPtrOr(opts.ManageAWSAuth, false),
+		UseFIPSEndpoints:                lo.FromPtrOr(opts.UseFIPSEndpoints, false),
+		EC2Endpoint:                     lo.FromPtrOr(opts.EC2Endpoint, ""),
+		SSMEndpoint:                     lo.FromPtrOr(opts.SSMEndpoint, ""),
+		SQSEndpoint:                     lo.FromPtrOr(opts.SQSEndpoint, ""),
+		IAMEndpoint:                     lo.FromPtrOr(opts.IAMEndpoint, ""),
+		EKSEndpoint:                     lo.FromPtrOr(opts.EKSEndpoint, ""),
+		PermissionCheck:                 lo.FromPtrOr(opts.PermissionCheck, false),
+		SettingsConfigMap:               lo.FromPtrOr(opts.SettingsConfigMap, ""),
+		QuotaAwareProvisioning:          lo.FromPtrOr(opts.QuotaAwareProvisioning, false),
+		QuotaWarningThreshold:           lo.FromPtrOr(opts.QuotaWarningThreshold, 0),
+		TracingEndpoint:                 lo.FromPtrOr(opts.TracingEndpoint, ""),
+		CloudWatchMetricsLogGroup:       lo.FromPtrOr(opts.CloudWatchMetricsLogGroup, ""),
+		EventBridgeBus:                  lo.FromPtrOr(opts.EventBridgeBus, ""),
+		AuditLog:                        lo.FromPtrOr(opts.AuditLog, false),
+		DebugEndpointPort:               lo.FromPtrOr(opts.DebugEndpointPort, 0),
+		DebugEndpointAPIKey:             lo.FromPtrOr(opts.DebugEndpointAPIKey, ""),
+		AWSHealthCheck:                  lo.FromPtrOr(opts.AWSHealthCheck, false),
+		AMICacheTTL:                     lo.FromPtrOr(opts.AMICacheTTL, time.Minute),
+		AWSAPIRateLimits:                lo.FromPtrOr(opts.AWSAPIRateLimits, ""),
+		CircuitBreakerThreshold:         lo.FromPtrOr(opts.CircuitBreakerThreshold, 0),
+		CacheSnapshotPath:               lo.FromPtrOr(opts.CacheSnapshotPath, ""),
+		ResourceChangeEvents:            lo.FromPtrOr(opts.ResourceChangeEvents, false),
+		SelectorValidation:              lo.FromPtrOr(opts.SelectorValidation, ""),
+		AMICompatibilityValidation:      lo.FromPtrOr(opts.AMICompatibilityValidation, ""),
+		InstanceProfileValidation:       lo.FromPtrOr(opts.InstanceProfileValidation, ""),
 	}
 }
@@ -0,0 +1,174 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+)
+
+// LoggerName is the name of the logger that audit records are logged by, so that log pipelines can route them to
+// separate storage
+const LoggerName = "audit"
+
+var (
+	// readOnlyPrefixes are the prefixes of the operations that don't create, modify or delete resources
+	readOnlyPrefixes = []string{"Describe", "Get", "List", "Simulate", "Receive", "Assume"}
+	// ignoredOperations change resources that only exist to operate the controller, such as messages of the
+	// interruption queue and the controller's own telemetry
+	ignoredOperations = sets.New(
+		"SQS.DeleteMessage",
+		"SQS.ChangeMessageVisibility",
+		"CloudWatch Logs.CreateLogStream",
+		"CloudWatch Logs.PutLogEvents",
+		"EventBridge.PutEvents",
+	)
+	// resourceFields are the fields of request inputs that identify the resources that the request changes
+	resourceFields = []string{"InstanceIds", "InstanceId", "Resources", "LaunchTemplateName", "LaunchTemplateId",
+		"InstanceProfileName", "RoleName", "FunctionName", "Bucket", "Key", "Name"}
+)
+
+type subjectKey struct{}
+
+// Subject is what the AWS API calls that are made with a context are made for
+type Subject struct {
+	NodeClaims []string
+	NodeClass  string
+}
+
+// WithNodeClaim records that the AWS API calls that are made with the context are made for the NodeClaim
+func WithNodeClaim(ctx context.Context, nodeClaim *corev1beta1.NodeClaim) context.Context {
+	return context.WithValue(ctx, subjectKey{}, Subject{
+		NodeClaims: []string{nodeClaim.Name},
+		NodeClass:  lo.FromPtr(nodeClaim.Spec.NodeClassRef).Name,
+	})
+}
+
+// WithNodeClass records that the AWS API calls that are made with the context are made for the EC2NodeClass
+func WithNodeClass(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) context.Context {
+	return context.WithValue(ctx, subjectKey{}, Subject{NodeClass: nodeClass.Name})
+}
+
+// Merge records the NodeClaims of every context on the first context, for calls that are batched from the requests
+// of multiple NodeClaims
+func Merge(ctx context.Context, ctxs ...context.Context) context.Context {
+	subject := FromContext(ctx)
+	for _, c := range ctxs {
+		subject.NodeClaims = lo.Union(subject.NodeClaims, FromContext(c).NodeClaims)
+	}
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+func FromContext(ctx context.Context) Subject {
+	subject, _ := ctx.Value(subjectKey{}).(Subject)
+	return subject
+}
+
+// Mutating returns whether the operation of the service creates, modifies or deletes resources that are audited
+func Mutating(serviceID, operation string) bool {
+	if ignoredOperations.Has(serviceID + "." + operation) {
+		return false
+	}
+	return !lo.ContainsBy(readOnlyPrefixes, func(prefix string) bool { return strings.HasPrefix(operation, prefix) })
+}
+
+// WithConfig logs an audit record for every request that is sent by the clients of the config and creates, modifies
+// or deletes resources. Records are logged once the request completes, including its retries, and have the request ID
+// of the CloudTrail event for the call.
+func WithConfig(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(cfg.APIOptions[:len(cfg.APIOptions):len(cfg.APIOptions)], func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("karpenter.audit", handleInitialize), middleware.After)
+	})
+	return cfg
+}
+
+func handleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	serviceID, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+	if !Mutating(serviceID, operation) {
+		return out, metadata, err
+	}
+	requestID, _ := awsmiddleware.GetRequestIDMetadata(metadata)
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		requestID = responseErr.ServiceRequestID()
+	}
+	subject := FromContext(ctx)
+	logger := logging.FromContext(ctx).Named(LoggerName).With(
+		"service", serviceID,
+		"operation", operation,
+		"region", awsmiddleware.GetRegion(ctx),
+		"request-id", requestID,
+		"resources", resources(in.Parameters, lo.Ternary(err == nil, out.Result, nil)),
+	)
+	if len(subject.NodeClaims) > 0 {
+		logger = logger.With("nodeclaims", subject.NodeClaims)
+	}
+	if subject.NodeClass != "" {
+		logger = logger.With("nodeclass", subject.NodeClass)
+	}
+	if err != nil {
+		code := "Unknown"
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			code = apiErr.ErrorCode()
+		}
+		logger.With("error-code", code).Infof("aws api call failed")
+		return out, metadata, err
+	}
+	logger.Infof("aws api call succeeded")
+	return out, metadata, err
+}
+
+// resources returns the resources that the request changes, from the fields of its input that identify resources and
+// the instances that are launched by CreateFleet
+func resources(input, output any) []string {
+	var ids []string
+	if v := reflect.Indirect(reflect.ValueOf(input)); v.Kind() == reflect.Struct {
+		for _, name := range resourceFields {
+			switch field := v.FieldByName(name); {
+			case !field.IsValid():
+			case field.Type() == reflect.TypeOf((*string)(nil)):
+				if s := field.Interface().(*string); s != nil {
+					ids = append(ids, *s)
+				}
+			case field.Type() == reflect.TypeOf([]string(nil)):
+				ids = append(ids, field.Interface().([]string)...)
+			}
+		}
+	}
+	if out, ok := output.(*ec2.CreateFleetOutput); ok {
+		for _, instance := range out.Instances {
+			ids = append(ids, instance.InstanceIds...)
+		}
+	}
+	return lo.Uniq(ids)
+}
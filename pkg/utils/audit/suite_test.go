@@ -0,0 +1,166 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/logging"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/utils/audit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var ctx context.Context
+var logs *observer.ObservedLogs
+
+func TestAudit(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Audit")
+}
+
+var _ = BeforeEach(func() {
+	var core zapcore.Core
+	core, logs = observer.New(zap.InfoLevel)
+	ctx = logging.WithLogger(context.Background(), zap.New(core).Sugar())
+})
+
+// auditEntries returns the entries that were logged by the audit logger
+func auditEntries() []observer.LoggedEntry {
+	return lo.Filter(logs.All(), func(e observer.LoggedEntry, _ int) bool { return e.LoggerName == audit.LoggerName })
+}
+
+func nodeClaim(name string) *corev1beta1.NodeClaim {
+	return &corev1beta1.NodeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1beta1.NodeClaimSpec{NodeClassRef: &corev1beta1.NodeClassReference{Name: "default"}},
+	}
+}
+
+var _ = Describe("Audit", func() {
+	var api *ec2.Client
+	var status int
+	var body string
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		body = `<TerminateInstancesResponse><requestId>request-id</requestId></TerminateInstancesResponse>`
+		api = ec2.NewFromConfig(audit.WithConfig(aws.Config{
+			Region:      "us-west-2",
+			Credentials: credentials.NewStaticCredentialsProvider("id", "secret", ""),
+			Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+			// responses are returned without sending the requests
+			HTTPClient: httpClientFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: status,
+					Header:     http.Header{"X-Amzn-Requestid": []string{"request-id"}},
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			}),
+		}))
+	})
+	It("should log mutating calls with the nodeclaim and resources that they were made for", func() {
+		_, err := api.TerminateInstances(audit.WithNodeClaim(ctx, nodeClaim("default-abcde")), &ec2.TerminateInstancesInput{
+			InstanceIds: []string{"i-0123456789abcdef0"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Message).To(Equal("aws api call succeeded"))
+		Expect(entries[0].ContextMap()).To(MatchAllKeys(Keys{
+			"service":    Equal("EC2"),
+			"operation":  Equal("TerminateInstances"),
+			"region":     Equal("us-west-2"),
+			"request-id": Equal("request-id"),
+			"resources":  ConsistOf("i-0123456789abcdef0"),
+			"nodeclaims": ConsistOf("default-abcde"),
+			"nodeclass":  Equal("default"),
+		}))
+	})
+	It("should log failed mutating calls with the error code", func() {
+		status = http.StatusBadRequest
+		body = `<Response><Errors><Error><Code>UnauthorizedOperation</Code><Message>denied</Message></Error></Errors><RequestID>request-id</RequestID></Response>`
+		_, err := api.CreateTags(audit.WithNodeClass(ctx, &v1beta1.EC2NodeClass{ObjectMeta: metav1.ObjectMeta{Name: "default"}}), &ec2.CreateTagsInput{
+			Resources: []string{"i-0123456789abcdef0"},
+			Tags:      []ec2types.Tag{{Key: aws.String("key"), Value: aws.String("value")}},
+		})
+		Expect(err).To(HaveOccurred())
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Message).To(Equal("aws api call failed"))
+		Expect(entries[0].ContextMap()).To(HaveKeyWithValue("error-code", "UnauthorizedOperation"))
+		Expect(entries[0].ContextMap()).To(HaveKeyWithValue("nodeclass", "default"))
+		Expect(entries[0].ContextMap()).ToNot(HaveKey("nodeclaims"))
+	})
+	It("should log the instances that were launched by CreateFleet", func() {
+		body = `<CreateFleetResponse><requestId>request-id</requestId><fleetInstanceSet><item><instanceIds><item>i-0123456789abcdef0</item></instanceIds></item></fleetInstanceSet></CreateFleetResponse>`
+		_, err := api.CreateFleet(ctx, &ec2.CreateFleetInput{
+			LaunchTemplateConfigs:       []ec2types.FleetLaunchTemplateConfigRequest{{}},
+			TargetCapacitySpecification: &ec2types.TargetCapacitySpecificationRequest{TotalTargetCapacity: aws.Int32(1)},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		entries := auditEntries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].ContextMap()).To(HaveKeyWithValue("resources", ConsistOf("i-0123456789abcdef0")))
+	})
+	It("should not log read-only calls", func() {
+		body = `<DescribeInstancesResponse><requestId>request-id</requestId></DescribeInstancesResponse>`
+		_, err := api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(auditEntries()).To(BeEmpty())
+	})
+	It("should merge the nodeclaims of batched calls", func() {
+		merged := audit.Merge(audit.WithNodeClaim(ctx, nodeClaim("default-abcde")), audit.WithNodeClaim(ctx, nodeClaim("default-fghij")), ctx)
+		Expect(audit.FromContext(merged)).To(Equal(audit.Subject{NodeClaims: []string{"default-abcde", "default-fghij"}, NodeClass: "default"}))
+	})
+	It("should only audit operations that create, modify or delete resources", func() {
+		Expect(lo.Filter([]lo.Tuple2[string, string]{
+			{A: "EC2", B: "CreateFleet"},
+			{A: "EC2", B: "DescribeInstances"},
+			{A: "IAM", B: "AddRoleToInstanceProfile"},
+			{A: "SQS", B: "DeleteMessage"},
+			{A: "STS", B: "AssumeRoleWithWebIdentity"},
+		}, func(t lo.Tuple2[string, string], _ int) bool { return audit.Mutating(t.A, t.B) })).To(ConsistOf(
+			lo.Tuple2[string, string]{A: "EC2", B: "CreateFleet"},
+			lo.Tuple2[string, string]{A: "IAM", B: "AddRoleToInstanceProfile"},
+		))
+	})
+})
+
+type httpClientFunc func(*http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+const (
+	cloudProviderSubsystem = "cloudprovider"
+	serviceLabel           = "service"
+	operationLabel         = "operation"
+)
+
+var (
+	retryQuotaTokens = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "retry_quota_tokens",
+			Help:      "Number of tokens in the quota that retries of failed requests to AWS APIs spend. Requests aren't retried while the quota doesn't have enough tokens.",
+		},
+	)
+	retryQuotaExhausted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "retry_quota_exhausted_total",
+			Help:      "Number of failed requests to AWS APIs that weren't retried because the retry quota didn't have enough tokens. Labeled by service and operation.",
+		},
+		[]string{serviceLabel, operationLabel},
+	)
+	circuitBreakerOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "circuit_breaker_open",
+			Help:      "Whether non-critical requests to AWS APIs, such as pricing refreshes and tag reconciliation, are shed because AWS APIs are throttling requests.",
+		},
+	)
+	shedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: cloudProviderSubsystem,
+			Name:      "shed_requests_total",
+			Help:      "Number of non-critical requests to AWS APIs that were shed while the circuit breaker was open. Labeled by service and operation.",
+		},
+		[]string{serviceLabel, operationLabel},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(retryQuotaTokens, retryQuotaExhausted, circuitBreakerOpen, shedRequests)
+}
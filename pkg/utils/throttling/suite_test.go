@@ -0,0 +1,136 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttling_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/smithy-go"
+	clock "k8s.io/utils/clock/testing"
+
+	"github.com/aws/karpenter-provider-aws/pkg/utils/throttling"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+func TestThrottling(t *testing.T) {
+	ctx = context.Background()
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Throttling")
+}
+
+const throttledBody = `<Response><Errors><Error><Code>RequestLimitExceeded</Code><Message>throttled</Message></Error></Errors><RequestID>request-id</RequestID></Response>`
+
+var _ = Describe("Throttling", func() {
+	var fakeClock *clock.FakeClock
+	var api *ec2.Client
+	var status int
+	var sent int
+
+	BeforeEach(func() {
+		fakeClock = clock.NewFakeClock(time.Now())
+		status = http.StatusOK
+		sent = 0
+		api = ec2.NewFromConfig(throttling.WithConfig(aws.Config{
+			Region:      "us-west-2",
+			Credentials: credentials.NewStaticCredentialsProvider("id", "secret", ""),
+			Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+			// responses are returned without sending the requests
+			HTTPClient: httpClientFunc(func(*http.Request) (*http.Response, error) {
+				sent++
+				body := `<DescribeInstancesResponse><requestId>request-id</requestId></DescribeInstancesResponse>`
+				if status != http.StatusOK {
+					body = throttledBody
+				}
+				return &http.Response{
+					StatusCode: status,
+					Header:     http.Header{"X-Amzn-Requestid": []string{"request-id"}},
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			}),
+		}, throttling.NewBreaker(fakeClock, 3)))
+	})
+	It("should shed non-critical requests once requests have been throttled", func() {
+		status = http.StatusServiceUnavailable
+		for i := 0; i < 3; i++ {
+			_, err := api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+			Expect(err).To(HaveOccurred())
+		}
+		status = http.StatusOK
+		sent = 0
+
+		_, err := api.DescribeInstances(throttling.NonCritical(ctx), &ec2.DescribeInstancesInput{})
+		var apiErr smithy.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.ErrorCode()).To(Equal(throttling.ErrCodeCircuitBreakerOpen))
+		Expect(sent).To(Equal(0))
+
+		// Critical requests are still sent
+		_, err = api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sent).To(Equal(1))
+	})
+	It("should send non-critical requests once the circuit breaker closes", func() {
+		status = http.StatusServiceUnavailable
+		for i := 0; i < 3; i++ {
+			_, err := api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+			Expect(err).To(HaveOccurred())
+		}
+		status = http.StatusOK
+		fakeClock.Step(time.Minute)
+
+		_, err := api.DescribeInstances(throttling.NonCritical(ctx), &ec2.DescribeInstancesInput{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should not open the circuit breaker when throttled requests are spread out", func() {
+		status = http.StatusServiceUnavailable
+		for i := 0; i < 3; i++ {
+			_, err := api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+			Expect(err).To(HaveOccurred())
+			fakeClock.Step(time.Minute)
+		}
+		status = http.StatusOK
+
+		_, err := api.DescribeInstances(throttling.NonCritical(ctx), &ec2.DescribeInstancesInput{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+	It("should stop retrying once the retry quota is spent", func() {
+		retryer := throttling.NewRetryer(3)
+		throttled := &smithy.GenericAPIError{Code: "RequestLimitExceeded", Message: "throttled"}
+		for i := 0; i < 100; i++ {
+			_, err := retryer.GetRetryToken(ctx, throttled)
+			Expect(err).ToNot(HaveOccurred())
+		}
+		// requests that can't be retried fail with their own error
+		_, err := retryer.GetRetryToken(ctx, throttled)
+		Expect(err).To(Equal(throttled))
+	})
+})
+
+type httpClientFunc func(*http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(r *http.Request) (*http.Response, error) { return f(r) }
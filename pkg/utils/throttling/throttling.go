@@ -0,0 +1,195 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package throttling keeps the controller working while AWS APIs throttle it. Retries are limited by a quota of tokens
+// that is spent by failed requests and refilled by successful ones, so that retries back off when requests fail
+// broadly, and a circuit breaker sheds non-critical requests while requests are throttled.
+package throttling
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+
+	sdk "github.com/aws/karpenter-provider-aws/pkg/aws"
+)
+
+const (
+	// ErrCodeCircuitBreakerOpen is the error code of non-critical requests that are shed while the circuit breaker is open
+	ErrCodeCircuitBreakerOpen = "CircuitBreakerOpen"
+	// MaxAttempts is the number of attempts of each request. It keeps the 3 retries that v1 of the SDK made by default,
+	// since v2 of the SDK only makes 3 attempts.
+	MaxAttempts = 4
+
+	retryQuota       = 500
+	retryCost        = 5
+	timeoutRetryCost = 10
+	noRetryIncrement = 1
+
+	// breakerWindow is the window in which throttled requests are counted towards opening the circuit breaker
+	breakerWindow = time.Minute
+	// breakerCooldown is how long the circuit breaker stays open
+	breakerCooldown = time.Minute
+)
+
+type nonCriticalKey struct{}
+
+// NonCritical marks the AWS API calls that are made with the context as non-critical, so that they are shed while the
+// circuit breaker is open
+func NonCritical(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nonCriticalKey{}, true)
+}
+
+func IsNonCritical(ctx context.Context) bool {
+	nonCritical, _ := ctx.Value(nonCriticalKey{}).(bool)
+	return nonCritical
+}
+
+// Retryer retries requests with the SDK's adaptive retry mode while the retry quota has tokens. Each retry spends
+// tokens from the quota, and successful requests refill it. Requests that can't be retried because the quota is spent
+// fail with their own error, rather than the quota's.
+type Retryer struct {
+	aws.RetryerV2
+}
+
+func NewRetryer(maxAttempts int) *Retryer {
+	retryQuotaTokens.Set(retryQuota)
+	return &Retryer{RetryerV2: retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+		o.StandardOptions = append(o.StandardOptions, func(so *retry.StandardOptions) {
+			so.MaxAttempts = maxAttempts
+			so.RateLimiter = &quota{TokenRateLimit: ratelimit.NewTokenRateLimit(retryQuota)}
+			so.RetryCost = retryCost
+			so.RetryTimeoutCost = timeoutRetryCost
+			so.NoRetryIncrement = noRetryIncrement
+		})
+	})}
+}
+
+func (r *Retryer) GetRetryToken(ctx context.Context, opErr error) (func(error) error, error) {
+	release, err := r.RetryerV2.GetRetryToken(ctx, opErr)
+	if quotaErr := (ratelimit.QuotaExceededError{}); errors.As(err, &quotaErr) {
+		return nil, opErr
+	}
+	return release, err
+}
+
+// quota is the retry quota. Retries spend tokens when they're attempted and requests that succeed refill it: by the
+// cost of a retry if they were retried, or by noRetryIncrement if they weren't.
+type quota struct {
+	*ratelimit.TokenRateLimit
+}
+
+func (q *quota) GetToken(ctx context.Context, cost uint) (func() error, error) {
+	release, err := q.TokenRateLimit.GetToken(ctx, cost)
+	if quotaErr := (ratelimit.QuotaExceededError{}); errors.As(err, &quotaErr) {
+		retryQuotaExhausted.With(prometheus.Labels{serviceLabel: sdk.ServiceName(ctx), operationLabel: awsmiddleware.GetOperationName(ctx)}).Inc()
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	retryQuotaTokens.Set(float64(q.Remaining()))
+	return func() error {
+		defer func() { retryQuotaTokens.Set(float64(q.Remaining())) }()
+		return release()
+	}, nil
+}
+
+func (q *quota) AddTokens(v uint) error {
+	defer func() { retryQuotaTokens.Set(float64(q.Remaining())) }()
+	return q.TokenRateLimit.AddTokens(v)
+}
+
+// Breaker opens when the number of throttled requests in a minute reaches the threshold, and closes a minute after it
+// opened
+type Breaker struct {
+	clk       clock.Clock
+	threshold int
+
+	mu        sync.Mutex
+	throttled []time.Time
+	openUntil time.Time
+}
+
+func NewBreaker(clk clock.Clock, threshold int) *Breaker {
+	circuitBreakerOpen.Set(0)
+	return &Breaker{clk: clk, threshold: threshold}
+}
+
+// Open returns whether non-critical requests are shed
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	open := b.clk.Now().Before(b.openUntil)
+	circuitBreakerOpen.Set(lo.Ternary(open, 1.0, 0.0))
+	return open
+}
+
+func (b *Breaker) recordThrottle() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clk.Now()
+	b.throttled = append(b.throttled, now)
+	for len(b.throttled) > 0 && now.Sub(b.throttled[0]) > breakerWindow {
+		b.throttled = b.throttled[1:]
+	}
+	if len(b.throttled) >= b.threshold {
+		b.openUntil = now.Add(breakerCooldown)
+		b.throttled = nil
+		circuitBreakerOpen.Set(1)
+	}
+}
+
+// WithConfig counts the throttled requests of the config's clients towards opening the breaker and sheds their
+// non-critical requests while it's open. Throttled requests are counted once per attempt, so that the retries of a
+// request count towards the threshold. The config isn't changed if the breaker is nil.
+func WithConfig(cfg aws.Config, breaker *Breaker) aws.Config {
+	if breaker == nil {
+		return cfg
+	}
+	cfg.APIOptions = append(cfg.APIOptions[:len(cfg.APIOptions):len(cfg.APIOptions)], func(stack *middleware.Stack) error {
+		if err := stack.Initialize.Add(middleware.InitializeMiddlewareFunc("karpenter.throttling.Shed", func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			if IsNonCritical(ctx) && breaker.Open() {
+				shedRequests.With(prometheus.Labels{serviceLabel: sdk.ServiceName(ctx), operationLabel: awsmiddleware.GetOperationName(ctx)}).Inc()
+				return middleware.InitializeOutput{}, middleware.Metadata{}, &smithy.GenericAPIError{
+					Code:    ErrCodeCircuitBreakerOpen,
+					Message: "shedding non-critical request while aws apis are throttling",
+					Fault:   smithy.FaultClient,
+				}
+			}
+			return next.HandleInitialize(ctx, in)
+		}), middleware.After); err != nil {
+			return err
+		}
+		return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("karpenter.throttling.Record", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			if err != nil && retry.IsErrorThrottles(retry.DefaultThrottles).IsErrorThrottle(err).Bool() {
+				breaker.recordThrottle()
+			}
+			return out, metadata, err
+		}), "Retry", middleware.After)
+	})
+	return cfg
+}
@@ -0,0 +1,125 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/aws/karpenter-provider-aws/pkg/utils/tracing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "knative.dev/pkg/logging/testing"
+)
+
+var ctx context.Context
+var recorder *tracetest.SpanRecorder
+
+func TestTracing(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing")
+}
+
+var _ = BeforeEach(func() {
+	recorder = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+})
+
+var _ = Describe("Tracing", func() {
+	var api *ec2.Client
+	var status int
+	var body string
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		body = `<DescribeInstancesResponse><requestId>request-id</requestId></DescribeInstancesResponse>`
+		api = ec2.NewFromConfig(tracing.WithConfig(aws.Config{
+			Region:      "us-west-2",
+			Credentials: credentials.NewStaticCredentialsProvider("id", "secret", ""),
+			Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+			// responses are returned without sending the requests
+			HTTPClient: httpClientFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: status,
+					Header:     http.Header{"X-Amzn-Requestid": []string{"request-id"}},
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			}),
+		}))
+	})
+	It("should record a span with the request ID for each request", func() {
+		ctx, parent := tracing.Start(ctx, "Parent")
+		_, err := api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+		Expect(err).ToNot(HaveOccurred())
+		parent.End()
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name()).To(Equal("EC2.DescribeInstances"))
+		Expect(spans[0].Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+		Expect(spans[0].Attributes()).To(ContainElements(
+			attribute.String("rpc.method", "DescribeInstances"),
+			attribute.String("aws.request_id", "request-id"),
+			attribute.Int("http.response.status_code", http.StatusOK),
+		))
+		Expect(spans[0].Status().Code).To(Equal(codes.Unset))
+	})
+	It("should mark the span of a failed request as failed", func() {
+		status = http.StatusBadRequest
+		body = `<Response><Errors><Error><Code>UnauthorizedOperation</Code><Message>denied</Message></Error></Errors><RequestID>request-id</RequestID></Response>`
+		_, err := api.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
+		Expect(err).To(HaveOccurred())
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Attributes()).To(ContainElement(attribute.String("aws.request_id", "request-id")))
+		Expect(spans[0].Status().Code).To(Equal(codes.Error))
+	})
+	It("should only end the spans that it started", func() {
+		ctx, parent := tracing.Start(ctx, "Parent")
+		// requests that fail validation aren't traced
+		_, err := api.TerminateInstances(ctx, &ec2.TerminateInstancesInput{})
+		Expect(err).To(HaveOccurred())
+		Expect(recorder.Ended()).To(BeEmpty())
+		parent.End()
+	})
+	It("should end spans with an error", func() {
+		_, span := tracing.Start(ctx, "Failed")
+		tracing.End(span, fmt.Errorf("failed"))
+
+		spans := recorder.Ended()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Status()).To(Equal(sdktrace.Status{Code: codes.Error, Description: "failed"}))
+	})
+})
+
+type httpClientFunc func(*http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(r *http.Request) (*http.Response, error) { return f(r) }
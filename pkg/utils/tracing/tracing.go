@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"knative.dev/pkg/logging"
+)
+
+// instrumentationName identifies the spans that are started by the controller
+const instrumentationName = "github.com/aws/karpenter-provider-aws"
+
+// NewTracerProvider registers a tracer provider that exports spans to the OTLP gRPC endpoint, e.g.
+// http://otel-collector.observability:4317. The exporter reads the standard OTEL_EXPORTER_OTLP_* environment variables
+// for headers, TLS and timeouts, and the sampler is configured through OTEL_TRACES_SAMPLER. Spans are exported in the
+// background, so the provider must be shut down to flush them when the controller exits.
+func NewTracerProvider(ctx context.Context, endpoint string, clusterName string, version string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("creating otlp exporter, %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName("karpenter"), semconv.ServiceVersion(version), semconv.K8SClusterName(clusterName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating tracing resource, %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider, nil
+}
+
+// Start starts a span that is a child of the span in the context. Spans aren't recorded or exported unless a tracer
+// provider has been registered with NewTracerProvider.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End marks the span as failed if err is set, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// WithTraceID adds the ID of the trace that the context belongs to to the logger, so that log lines can be looked up
+// in the tracing backend
+func WithTraceID(ctx context.Context) context.Context {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ctx
+	}
+	return logging.WithLogger(ctx, logging.FromContext(ctx).With("trace-id", spanContext.TraceID().String()))
+}
+
+// WithConfig starts a span for every request that is sent by the clients of the config, including its retries. The
+// span records the request ID that AWS returns, which is the requestID of the CloudTrail event for the call.
+func WithConfig(cfg aws.Config) aws.Config {
+	cfg.APIOptions = append(cfg.APIOptions[:len(cfg.APIOptions):len(cfg.APIOptions)], func(stack *middleware.Stack) error {
+		// the middleware runs after the operation's input is validated, so requests that fail validation aren't traced
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("karpenter.tracing", handleInitialize), middleware.After)
+	})
+	return cfg
+}
+
+func handleInitialize(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+	serviceID, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, fmt.Sprintf("%s.%s", serviceID, operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("aws-api"),
+			semconv.RPCService(serviceID),
+			semconv.RPCMethod(operation),
+			semconv.CloudRegion(awsmiddleware.GetRegion(ctx)),
+		),
+	)
+	out, metadata, err := next.HandleInitialize(ctx, in)
+	requestID, _ := awsmiddleware.GetRequestIDMetadata(metadata)
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) {
+		requestID = responseErr.ServiceRequestID()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(responseErr.HTTPStatusCode()))
+	} else if raw, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response); ok {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(raw.StatusCode))
+	}
+	attempts, _ := retry.GetAttemptResults(metadata)
+	span.SetAttributes(
+		semconv.AWSRequestID(requestID),
+		attribute.Int("aws.retry_count", max(len(attempts.Results)-1, 0)),
+	)
+	End(span, err)
+	return out, metadata, err
+}
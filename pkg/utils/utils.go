@@ -15,19 +15,61 @@ limitations under the License.
 package utils
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/computeoptimizer"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/savingsplans"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
+)
+
+const (
+	AWSPartitionID      = "aws"
+	AWSCNPartitionID    = "aws-cn"
+	AWSUSGovPartitionID = "aws-us-gov"
 )
 
 var (
 	instanceIDRegex = regexp.MustCompile(`aws:///(?P<AZ>.*)/(?P<InstanceID>.*)`)
+
+	// partitions mirrors the partition metadata that the SDK uses to resolve endpoints, which v2 of the SDK doesn't
+	// expose. Only the services whose availability differs between partitions are tracked.
+	partitions = []PartitionMetadata{
+		{id: AWSCNPartitionID, dnsSuffix: "amazonaws.com.cn", regionRegex: regexp.MustCompile(`^cn-\w+-\d+$`), services: sets.New(pricing.ServiceID, savingsplans.ServiceID, computeoptimizer.ServiceID)},
+		{id: AWSUSGovPartitionID, dnsSuffix: "amazonaws.com", regionRegex: regexp.MustCompile(`^us-gov-\w+-\d+$`), services: sets.New(computeoptimizer.ServiceID)},
+		{id: "aws-iso", dnsSuffix: "c2s.ic.gov", regionRegex: regexp.MustCompile(`^us-iso-\w+-\d+$`), services: sets.New(pricing.ServiceID)},
+		{id: "aws-iso-b", dnsSuffix: "sc2s.sgov.gov", regionRegex: regexp.MustCompile(`^us-isob-\w+-\d+$`), services: sets.New(pricing.ServiceID)},
+		{id: "aws-iso-e", dnsSuffix: "cloud.adc-e.uk", regionRegex: regexp.MustCompile(`^eu-isoe-\w+-\d+$`), services: sets.New[string]()},
+		{id: "aws-iso-f", dnsSuffix: "csp.hci.ic.gov", regionRegex: regexp.MustCompile(`^us-isof-\w+-\d+$`), services: sets.New[string]()},
+	}
+	awsPartition = PartitionMetadata{id: AWSPartitionID, dnsSuffix: "amazonaws.com", services: sets.New(pricing.ServiceID, savingsplans.ServiceID, computeoptimizer.ServiceID)}
 )
 
+// PartitionMetadata describes an AWS partition
+type PartitionMetadata struct {
+	id          string
+	dnsSuffix   string
+	regionRegex *regexp.Regexp
+	services    sets.Set[string]
+}
+
+func (p PartitionMetadata) ID() string        { return p.id }
+func (p PartitionMetadata) DNSSuffix() string { return p.dnsSuffix }
+
 // ParseInstanceID parses the provider ID stored on the node to get the instance ID
 // associated with a node
 func ParseInstanceID(providerID string) (string, error) {
@@ -45,9 +87,9 @@ func ParseInstanceID(providerID string) (string, error) {
 
 // MergeTags takes a variadic list of maps and merges them together into a list of
 // EC2 tags to be passed into EC2 API calls
-func MergeTags(tags ...map[string]string) []*ec2.Tag {
-	return lo.MapToSlice(lo.Assign(tags...), func(k, v string) *ec2.Tag {
-		return &ec2.Tag{Key: aws.String(k), Value: aws.String(v)}
+func MergeTags(tags ...map[string]string) []ec2types.Tag {
+	return lo.MapToSlice(lo.Assign(tags...), func(k, v string) ec2types.Tag {
+		return ec2types.Tag{Key: aws.String(k), Value: aws.String(v)}
 	})
 }
 
@@ -66,3 +108,71 @@ func PrettySlice[T any](s []T, maxItems int) string {
 	}
 	return sb.String()
 }
+
+// NodeClassEventHandler maps an EC2NodeClass to the NodeClaims that reference it and enqueues reconcile.Requests for
+// the NodeClaims
+func NodeClassEventHandler(c client.Client) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
+		nodeClaimList := &corev1beta1.NodeClaimList{}
+		if err := c.List(ctx, nodeClaimList, client.MatchingFields{"spec.nodeClassRef.name": o.GetName()}); err != nil {
+			return nil
+		}
+		return lo.Map(nodeClaimList.Items, func(nc corev1beta1.NodeClaim, _ int) reconcile.Request {
+			return reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&nc)}
+		})
+	})
+}
+
+// RemainingResources returns the resources that can still be launched for the NodePool before it exceeds its limits.
+// Resources that aren't limited aren't included.
+func RemainingResources(nodePool *corev1beta1.NodePool) v1.ResourceList {
+	remaining := v1.ResourceList{}
+	for name, limit := range nodePool.Spec.Limits {
+		quantity := limit.DeepCopy()
+		quantity.Sub(nodePool.Status.Resources[name])
+		remaining[name] = quantity
+	}
+	return remaining
+}
+
+// FilterByRemainingResources returns the instance types whose capacity doesn't exceed any of the remaining resources
+func FilterByRemainingResources(instanceTypes []*cloudprovider.InstanceType, remaining v1.ResourceList) []*cloudprovider.InstanceType {
+	return lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		for name, quantity := range remaining {
+			if resources.Cmp(it.Capacity[name], quantity) > 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// SubtractMaxResources subtracts the largest capacity of the instance types from the remaining resources, since a
+// NodeClaim may launch as any of its instance types
+func SubtractMaxResources(remaining v1.ResourceList, instanceTypes []*cloudprovider.InstanceType) v1.ResourceList {
+	capacity := resources.MaxResources(lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) v1.ResourceList {
+		return it.Capacity
+	})...)
+	result := v1.ResourceList{}
+	for name, quantity := range remaining {
+		quantity = quantity.DeepCopy()
+		quantity.Sub(capacity[name])
+		result[name] = quantity
+	}
+	return result
+}
+
+// Partition returns the AWS partition of the region, e.g. aws, aws-cn or aws-us-gov. Regions that don't match any
+// other partition are assumed to be in the aws partition.
+func Partition(region string) PartitionMetadata {
+	if p, ok := lo.Find(partitions, func(p PartitionMetadata) bool { return p.regionRegex.MatchString(region) }); ok {
+		return p
+	}
+	return awsPartition
+}
+
+// ServiceAvailable returns true if the service, identified by its SDK service ID, is available in the partition of the
+// region. Services like the pricing and Savings Plans APIs aren't available in every partition.
+func ServiceAvailable(region string, service string) bool {
+	return Partition(region).services.Has(service)
+}
@@ -0,0 +1,78 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+)
+
+// amiCompatibilityResolver checks the AMIs of EC2NodeClasses against the requirements of the NodePools that reference
+// them. Instance types are only launched with AMIs whose requirements intersect with theirs, so NodePools whose
+// requirements don't intersect with any of the AMIs can't launch instances.
+type amiCompatibilityResolver struct {
+	kubeClient      client.Client
+	accountProvider *account.Provider
+}
+
+func (r amiCompatibilityResolver) IncompatibleNodePools(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (map[string]string, error) {
+	nodePoolList := &corev1beta1.NodePoolList{}
+	if err := r.kubeClient.List(ctx, nodePoolList); err != nil {
+		return nil, fmt.Errorf("listing nodepools, %w", err)
+	}
+	nodePools := lo.Filter(nodePoolList.Items, func(nodePool corev1beta1.NodePool, _ int) bool {
+		return nodePool.Spec.Template.Spec.NodeClassRef != nil && nodePool.Spec.Template.Spec.NodeClassRef.Name == nodeClass.Name
+	})
+	if len(nodePools) == 0 {
+		return nil, nil
+	}
+	// the options are only used to resolve the AMIs of the Custom amiFamily, which don't have default AMIs
+	amis, err := r.accountProvider.For(nodeClass).AMI.Get(ctx, nodeClass, &amifamily.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("getting amis, %w", err)
+	}
+	// EC2NodeClasses without AMIs are caught by selector validation
+	if len(amis) == 0 {
+		return nil, nil
+	}
+	// only the requirements that the AMIs have are reported, as the others don't affect which AMIs are compatible
+	amiKeys := sets.New[string]()
+	for _, ami := range amis {
+		amiKeys = amiKeys.Union(ami.Requirements.Keys())
+	}
+	amiRequirements := lo.Uniq(lo.Map(amis, func(ami amifamily.AMI, _ int) string { return fmt.Sprintf("%q", ami.Requirements) }))
+	incompatible := map[string]string{}
+	for i := range nodePools {
+		requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePools[i].Spec.Template.Spec.Requirements...)
+		if lo.ContainsBy(amis, func(ami amifamily.AMI) bool { return requirements.Intersects(ami.Requirements) == nil }) {
+			continue
+		}
+		shared := scheduling.NewRequirements(lo.Filter(requirements.Values(), func(r *scheduling.Requirement, _ int) bool { return amiKeys.Has(r.Key) })...)
+		incompatible[nodePools[i].Name] = fmt.Sprintf("the nodepool requires %q, and the amis require %s", shared, strings.Join(amiRequirements, " or "))
+	}
+	return incompatible, nil
+}
@@ -0,0 +1,33 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+)
+
+// instanceProfileResolver checks the role or instance profile of EC2NodeClasses with the providers of the account that
+// they're managed in, in the same way that the EC2NodeClass controller does before it resolves them
+type instanceProfileResolver struct {
+	accountProvider *account.Provider
+}
+
+func (r instanceProfileResolver) InstanceProfile(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (string, error) {
+	_, message, err := r.accountProvider.For(nodeClass).InstanceProfile.Check(ctx, nodeClass)
+	return message, err
+}
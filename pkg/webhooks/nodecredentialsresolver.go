@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// podCredentialsEnvVars are the environment variables that are injected into the containers of pods that get their
+// credentials from IAM roles for service accounts or EKS Pod Identity
+var podCredentialsEnvVars = []string{"AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_CONTAINER_CREDENTIALS_FULL_URI"}
+
+// nodeCredentialsResolver resolves whether the cluster relies on the credentials of the node role from the pods that
+// are cached for scheduling. Clusters where no pod gets its credentials in another way are assumed to rely on them.
+type nodeCredentialsResolver struct {
+	kubeClient client.Client
+}
+
+func (r nodeCredentialsResolver) UsesNodeRoleCredentials(ctx context.Context) (bool, error) {
+	podList := &v1.PodList{}
+	if err := r.kubeClient.List(ctx, podList); err != nil {
+		return false, fmt.Errorf("listing pods, %w", err)
+	}
+	return !lo.ContainsBy(podList.Items, func(pod v1.Pod) bool {
+		return lo.ContainsBy(pod.Spec.Containers, func(container v1.Container) bool {
+			return lo.ContainsBy(container.Env, func(env v1.EnvVar) bool { return lo.Contains(podCredentialsEnvVars, env.Name) })
+		})
+	}), nil
+}
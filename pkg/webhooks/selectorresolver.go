@@ -0,0 +1,46 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/amifamily"
+)
+
+// selectorResolver resolves the selector terms of EC2NodeClasses with the providers of the account that they're
+// managed in. The providers cache what they resolve, so EC2NodeClasses that are admitted are resolved again cheaply
+// when they're first reconciled.
+type selectorResolver struct {
+	accountProvider *account.Provider
+}
+
+func (r selectorResolver) Subnets(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (int, error) {
+	subnets, err := r.accountProvider.For(nodeClass).Subnet.List(ctx, nodeClass)
+	return len(subnets), err
+}
+
+func (r selectorResolver) SecurityGroups(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (int, error) {
+	securityGroups, err := r.accountProvider.For(nodeClass).SecurityGroup.List(ctx, nodeClass)
+	return len(securityGroups), err
+}
+
+func (r selectorResolver) AMIs(ctx context.Context, nodeClass *v1beta1.EC2NodeClass) (int, error) {
+	// the options are only used to resolve the default AMIs of the amiFamily, which aren't resolved at admission
+	amis, err := r.accountProvider.For(nodeClass).AMI.Get(ctx, nodeClass, &amifamily.Options{})
+	return len(amis), err
+}
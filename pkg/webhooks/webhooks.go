@@ -17,6 +17,7 @@ package webhooks
 import (
 	"context"
 
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
@@ -24,14 +25,19 @@ import (
 	"knative.dev/pkg/webhook/resourcesemantics"
 	"knative.dev/pkg/webhook/resourcesemantics/defaulting"
 	"knative.dev/pkg/webhook/resourcesemantics/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/account"
 )
 
-func NewWebhooks() []knativeinjection.ControllerConstructor {
+func NewWebhooks(kubeClient client.Client, accountProvider *account.Provider) []knativeinjection.ControllerConstructor {
 	return []knativeinjection.ControllerConstructor{
 		NewCRDDefaultingWebhook,
-		NewCRDValidationWebhook,
+		func(ctx context.Context, w configmap.Watcher) *controller.Impl {
+			return NewCRDValidationWebhook(ctx, w, kubeClient, accountProvider)
+		},
 	}
 }
 
@@ -45,12 +51,32 @@ func NewCRDDefaultingWebhook(ctx context.Context, _ configmap.Watcher) *controll
 	)
 }
 
-func NewCRDValidationWebhook(ctx context.Context, _ configmap.Watcher) *controller.Impl {
+func NewCRDValidationWebhook(ctx context.Context, _ configmap.Watcher, kubeClient client.Client, accountProvider *account.Provider) *controller.Impl {
+	// The tag policy is validated with the rest of the options at startup
+	tagPolicy := lo.Must(options.ParseTagPolicy(options.FromContext(ctx).TagPolicy))
+	selectorValidation := options.FromContext(ctx).SelectorValidation
+	amiCompatibilityValidation := options.FromContext(ctx).AMICompatibilityValidation
+	instanceProfileValidation := options.FromContext(ctx).InstanceProfileValidation
 	return validation.NewAdmissionController(ctx,
 		"validation.webhook.karpenter.k8s.aws",
 		"/validate/karpenter.k8s.aws",
 		Resources,
-		func(ctx context.Context) context.Context { return ctx },
+		func(ctx context.Context) context.Context {
+			ctx = v1beta1.WithNodeCredentialsResolver(ctx, nodeCredentialsResolver{kubeClient: kubeClient})
+			if tagPolicy != nil {
+				ctx = v1beta1.WithTagPolicy(ctx, tagPolicy)
+			}
+			if selectorValidation != "" {
+				ctx = v1beta1.WithSelectorResolver(ctx, selectorResolver{accountProvider: accountProvider}, selectorValidation == options.ValidationModeWarn)
+			}
+			if amiCompatibilityValidation != "" {
+				ctx = v1beta1.WithAMICompatibilityResolver(ctx, amiCompatibilityResolver{kubeClient: kubeClient, accountProvider: accountProvider}, amiCompatibilityValidation == options.ValidationModeWarn)
+			}
+			if instanceProfileValidation != "" {
+				ctx = v1beta1.WithInstanceProfileResolver(ctx, instanceProfileResolver{accountProvider: accountProvider}, instanceProfileValidation == options.ValidationModeWarn)
+			}
+			return ctx
+		},
 		true,
 	)
 }
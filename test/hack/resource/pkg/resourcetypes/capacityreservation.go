@@ -0,0 +1,155 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcetypes
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"golang.org/x/exp/slices"
+)
+
+// CapacityReservation cleans up the capacity reservations that were created by the ODCR tests. Capacity reservations are
+// billed whether they're used or not, so only the reservations that weren't cancelled or didn't expire are cleaned up.
+type CapacityReservation struct {
+	ec2Client *ec2.Client
+}
+
+func NewCapacityReservation(ec2Client *ec2.Client) *CapacityReservation {
+	return &CapacityReservation{ec2Client: ec2Client}
+}
+
+func (c *CapacityReservation) String() string {
+	return "CapacityReservations"
+}
+
+func (c *CapacityReservation) Global() bool {
+	return false
+}
+
+func (c *CapacityReservation) Get(ctx context.Context, clusterName string) (ids []string, err error) {
+	var nextToken *string
+	for {
+		out, err := c.ec2Client.DescribeCapacityReservations(ctx, &ec2.DescribeCapacityReservationsInput{
+			Filters: []ec2types.Filter{
+				{
+					Name:   lo.ToPtr("tag:" + karpenterTestingTag),
+					Values: []string{clusterName},
+				},
+				{
+					Name:   lo.ToPtr("state"),
+					Values: []string{string(ec2types.CapacityReservationStatePending), string(ec2types.CapacityReservationStateActive)},
+				},
+			},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return ids, err
+		}
+		for _, reservation := range out.CapacityReservations {
+			ids = append(ids, lo.FromPtr(reservation.CapacityReservationId))
+		}
+		nextToken = out.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	return ids, err
+}
+
+func (c *CapacityReservation) CountAll(ctx context.Context) (count int, err error) {
+	var nextToken *string
+	for {
+		out, err := c.ec2Client.DescribeCapacityReservations(ctx, &ec2.DescribeCapacityReservationsInput{
+			Filters: []ec2types.Filter{
+				{
+					Name:   lo.ToPtr("state"),
+					Values: []string{string(ec2types.CapacityReservationStatePending), string(ec2types.CapacityReservationStateActive)},
+				},
+			},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return count, err
+		}
+
+		count += len(out.CapacityReservations)
+
+		nextToken = out.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	return count, err
+}
+
+func (c *CapacityReservation) GetExpired(ctx context.Context, expirationTime time.Time, excludedClusters []string) (ids []string, err error) {
+	var nextToken *string
+	for {
+		out, err := c.ec2Client.DescribeCapacityReservations(ctx, &ec2.DescribeCapacityReservationsInput{
+			Filters: []ec2types.Filter{
+				{
+					Name:   lo.ToPtr("tag-key"),
+					Values: []string{karpenterTestingTag},
+				},
+				{
+					Name:   lo.ToPtr("state"),
+					Values: []string{string(ec2types.CapacityReservationStatePending), string(ec2types.CapacityReservationStateActive)},
+				},
+			},
+			NextToken: nextToken,
+		})
+		if err != nil {
+			return ids, err
+		}
+		for _, reservation := range out.CapacityReservations {
+			clusterName, found := lo.Find(reservation.Tags, func(tag ec2types.Tag) bool {
+				return *tag.Key == karpenterTestingTag
+			})
+			if found && slices.Contains(excludedClusters, lo.FromPtr(clusterName.Value)) {
+				continue
+			}
+			if lo.FromPtr(reservation.CreateDate).Before(expirationTime) {
+				ids = append(ids, lo.FromPtr(reservation.CapacityReservationId))
+			}
+		}
+		nextToken = out.NextToken
+		if nextToken == nil {
+			break
+		}
+	}
+	return ids, err
+}
+
+// Cleanup cancels the capacity reservations, which can only be cancelled one at a time
+func (c *CapacityReservation) Cleanup(ctx context.Context, ids []string) ([]string, error) {
+	var cancelled []string
+	var errs error
+	for i := range ids {
+		_, err := c.ec2Client.CancelCapacityReservation(ctx, &ec2.CancelCapacityReservationInput{
+			CapacityReservationId: lo.ToPtr(ids[i]),
+		})
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		cancelled = append(cancelled, ids[i])
+	}
+	return cancelled, errs
+}
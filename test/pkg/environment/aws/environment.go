@@ -19,20 +19,16 @@ import (
 	"os"
 	"testing"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/client"
-	"github.com/aws/aws-sdk-go/aws/endpoints"
-	"github.com/aws/aws-sdk-go/aws/request"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/eks"
-	"github.com/aws/aws-sdk-go/service/fis"
-	"github.com/aws/aws-sdk-go/service/iam"
-	servicesqs "github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/aws/aws-sdk-go/service/sts"
-	"github.com/aws/aws-sdk-go/service/timestreamwrite"
-	"github.com/aws/aws-sdk-go/service/timestreamwrite/timestreamwriteiface"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/fis"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	servicesqs "github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/timestreamwrite"
 	. "github.com/onsi/ginkgo/v2"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
@@ -63,13 +59,13 @@ type Environment struct {
 	*common.Environment
 	Region string
 
-	STSAPI        *sts.STS
-	EC2API        *ec2.EC2
-	SSMAPI        *ssm.SSM
-	IAMAPI        *iam.IAM
-	FISAPI        *fis.FIS
-	EKSAPI        *eks.EKS
-	TimeStreamAPI timestreamwriteiface.TimestreamWriteAPI
+	STSAPI        *sts.Client
+	EC2API        *ec2.Client
+	SSMAPI        *ssm.Client
+	IAMAPI        *iam.Client
+	FISAPI        *fis.Client
+	EKSAPI        *eks.Client
+	TimeStreamAPI TimestreamWriteAPI
 
 	SQSProvider *sqs.Provider
 
@@ -80,42 +76,36 @@ type Environment struct {
 
 func NewEnvironment(t *testing.T) *Environment {
 	env := common.NewEnvironment(t)
-	session := session.Must(session.NewSessionWithOptions(
-		session.Options{
-			Config: *request.WithRetryer(
-				&aws.Config{STSRegionalEndpoint: endpoints.RegionalSTSEndpoint},
-				client.DefaultRetryer{NumMaxRetries: 10},
-			),
-			SharedConfigState: session.SharedConfigEnable,
-		},
-	))
+	cfg := lo.Must(config.LoadDefaultConfig(env.Context, config.WithRetryMaxAttempts(11)))
 
 	awsEnv := &Environment{
-		Region:      *session.Config.Region,
+		Region:      cfg.Region,
 		Environment: env,
 
-		STSAPI:        sts.New(session),
-		EC2API:        ec2.New(session),
-		SSMAPI:        ssm.New(session),
-		IAMAPI:        iam.New(session),
-		FISAPI:        fis.New(session),
-		EKSAPI:        eks.New(session),
-		TimeStreamAPI: GetTimeStreamAPI(session),
+		STSAPI:        sts.NewFromConfig(cfg),
+		EC2API:        ec2.NewFromConfig(cfg),
+		SSMAPI:        ssm.NewFromConfig(cfg),
+		IAMAPI:        iam.NewFromConfig(cfg),
+		FISAPI:        fis.NewFromConfig(cfg),
+		EKSAPI:        eks.NewFromConfig(cfg),
+		TimeStreamAPI: GetTimeStreamAPI(cfg),
 
 		ClusterName:     lo.Must(os.LookupEnv("CLUSTER_NAME")),
 		ClusterEndpoint: lo.Must(os.LookupEnv("CLUSTER_ENDPOINT")),
 	}
 	// Initialize the provider only if the INTERRUPTION_QUEUE environment variable is defined
 	if v, ok := os.LookupEnv("INTERRUPTION_QUEUE"); ok {
-		awsEnv.SQSProvider = lo.Must(sqs.NewProvider(env.Context, servicesqs.New(session), v))
+		awsEnv.SQSProvider = sqs.NewProvider(servicesqs.NewFromConfig(cfg), v)
 	}
 	return awsEnv
 }
 
-func GetTimeStreamAPI(session *session.Session) timestreamwriteiface.TimestreamWriteAPI {
+func GetTimeStreamAPI(cfg aws.Config) TimestreamWriteAPI {
 	if lo.Must(env.GetBool("ENABLE_METRICS", false)) {
 		By("enabling metrics firing for this suite")
-		return timestreamwrite.New(session, &aws.Config{Region: aws.String(env.GetString("METRICS_REGION", metricsDefaultRegion))})
+		return timestreamwrite.NewFromConfig(cfg, func(o *timestreamwrite.Options) {
+			o.Region = env.GetString("METRICS_REGION", metricsDefaultRegion)
+		})
 	}
 	return &NoOpTimeStreamAPI{}
 }
@@ -22,15 +22,19 @@ import (
 	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/fis"
-	"github.com/aws/aws-sdk-go/service/iam"
-	"github.com/aws/aws-sdk-go/service/ssm"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/fis"
+	fistypes "github.com/aws/aws-sdk-go-v2/service/fis/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
@@ -77,54 +81,93 @@ func (env *Environment) ExpectIPv6ClusterDNS() string {
 	return kubeDNSIP.String()
 }
 
-func (env *Environment) ExpectSpotInterruptionExperiment(instanceIDs ...string) *fis.Experiment {
+// ExpectIPv6Node asserts that the node registered with a single IPv6 internal address, which is assigned to one of the
+// network interfaces of its instance, and returns that address
+func (env *Environment) ExpectIPv6Node(node *v1.Node) string {
+	GinkgoHelper()
+	internalIPv6Addrs := lo.FilterMap(node.Status.Addresses, func(addr v1.NodeAddress, _ int) (string, bool) {
+		return addr.Address, addr.Type == v1.NodeInternalIP && net.ParseIP(addr.Address).To4() == nil
+	})
+	Expect(internalIPv6Addrs).To(HaveLen(1))
+	instance := env.GetInstanceByID(env.ExpectParsedProviderID(node.Spec.ProviderID))
+	instanceIPv6Addrs := lo.FlatMap(instance.NetworkInterfaces, func(ni ec2types.InstanceNetworkInterface, _ int) []string {
+		return lo.Map(ni.Ipv6Addresses, func(addr ec2types.InstanceIpv6Address, _ int) string {
+			return net.ParseIP(aws.ToString(addr.Ipv6Address)).String()
+		})
+	})
+	Expect(instanceIPv6Addrs).To(ContainElement(net.ParseIP(internalIPv6Addrs[0]).String()))
+	return internalIPv6Addrs[0]
+}
+
+// ExpectIPv6Pods asserts that the pods were assigned IPv6 addresses
+func (env *Environment) ExpectIPv6Pods(pods ...*v1.Pod) {
+	GinkgoHelper()
+	for _, pod := range pods {
+		Expect(pod.Status.PodIPs).ToNot(BeEmpty(), fmt.Sprintf("expected pod %s/%s to have an IP", pod.Namespace, pod.Name))
+		Expect(lo.SomeBy(pod.Status.PodIPs, func(ip v1.PodIP) bool { return net.ParseIP(ip.IP).To4() == nil })).To(BeTrue(),
+			fmt.Sprintf("expected pod %s/%s to have an IPv6 address, found %v", pod.Namespace, pod.Name, pod.Status.PodIPs))
+	}
+}
+
+// GetInstanceTypeInfo returns the instance type as it's described by EC2
+func (env *Environment) GetInstanceTypeInfo(instanceType string) ec2types.InstanceTypeInfo {
+	GinkgoHelper()
+	out, err := env.EC2API.DescribeInstanceTypes(env.Context, &ec2.DescribeInstanceTypesInput{
+		InstanceTypes: []ec2types.InstanceType{ec2types.InstanceType(instanceType)},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(out.InstanceTypes).To(HaveLen(1))
+	return out.InstanceTypes[0]
+}
+
+func (env *Environment) ExpectSpotInterruptionExperiment(instanceIDs ...string) *fistypes.Experiment {
 	GinkgoHelper()
 	template := &fis.CreateExperimentTemplateInput{
-		Actions:        map[string]*fis.CreateExperimentTemplateActionInput{},
-		Targets:        map[string]*fis.CreateExperimentTemplateTargetInput{},
-		StopConditions: []*fis.CreateExperimentTemplateStopConditionInput{{Source: aws.String("none")}},
+		Actions:        map[string]fistypes.CreateExperimentTemplateActionInput{},
+		Targets:        map[string]fistypes.CreateExperimentTemplateTargetInput{},
+		StopConditions: []fistypes.CreateExperimentTemplateStopConditionInput{{Source: aws.String("none")}},
 		RoleArn:        env.ExpectSpotInterruptionRole().Arn,
 		Description:    aws.String(fmt.Sprintf("trigger spot ITN for instances %v", instanceIDs)),
 	}
 	for j, ids := range lo.Chunk(instanceIDs, fisTargetLimit) {
 		key := fmt.Sprintf("itn%d", j)
-		template.Actions[key] = &fis.CreateExperimentTemplateActionInput{
+		template.Actions[key] = fistypes.CreateExperimentTemplateActionInput{
 			ActionId: aws.String(spotITNAction),
-			Parameters: map[string]*string{
+			Parameters: map[string]string{
 				// durationBeforeInterruption is the time before the instance is terminated, so we add 2 minutes
-				"durationBeforeInterruption": aws.String("PT120S"),
+				"durationBeforeInterruption": "PT120S",
 			},
-			Targets: map[string]*string{"SpotInstances": aws.String(key)},
+			Targets: map[string]string{"SpotInstances": key},
 		}
-		template.Targets[key] = &fis.CreateExperimentTemplateTargetInput{
+		template.Targets[key] = fistypes.CreateExperimentTemplateTargetInput{
 			ResourceType:  aws.String("aws:ec2:spot-instance"),
 			SelectionMode: aws.String("ALL"),
-			ResourceArns: aws.StringSlice(lo.Map(ids, func(id string, _ int) string {
+			ResourceArns: lo.Map(ids, func(id string, _ int) string {
 				return fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", env.Region, env.ExpectAccountID(), id)
-			})),
+			}),
 		}
 	}
-	experimentTemplate, err := env.FISAPI.CreateExperimentTemplateWithContext(env.Context, template)
+	experimentTemplate, err := env.FISAPI.CreateExperimentTemplate(env.Context, template)
 	Expect(err).ToNot(HaveOccurred())
-	experiment, err := env.FISAPI.StartExperimentWithContext(env.Context, &fis.StartExperimentInput{ExperimentTemplateId: experimentTemplate.ExperimentTemplate.Id})
+	experiment, err := env.FISAPI.StartExperiment(env.Context, &fis.StartExperimentInput{ExperimentTemplateId: experimentTemplate.ExperimentTemplate.Id})
 	Expect(err).ToNot(HaveOccurred())
 	return experiment.Experiment
 }
 
 func (env *Environment) ExpectExperimentTemplateDeleted(id string) {
 	GinkgoHelper()
-	_, err := env.FISAPI.DeleteExperimentTemplateWithContext(env.Context, &fis.DeleteExperimentTemplateInput{
+	_, err := env.FISAPI.DeleteExperimentTemplate(env.Context, &fis.DeleteExperimentTemplateInput{
 		Id: aws.String(id),
 	})
 	Expect(err).ToNot(HaveOccurred())
 }
 
-func (env *Environment) EventuallyExpectInstanceProfileExists(profileName string) iam.InstanceProfile {
+func (env *Environment) EventuallyExpectInstanceProfileExists(profileName string) iamtypes.InstanceProfile {
 	GinkgoHelper()
 	By(fmt.Sprintf("eventually expecting instance profile %s to exist", profileName))
-	var instanceProfile iam.InstanceProfile
+	var instanceProfile iamtypes.InstanceProfile
 	Eventually(func(g Gomega) {
-		out, err := env.IAMAPI.GetInstanceProfileWithContext(env.Context, &iam.GetInstanceProfileInput{
+		out, err := env.IAMAPI.GetInstanceProfile(env.Context, &iam.GetInstanceProfileInput{
 			InstanceProfileName: aws.String(profileName),
 		})
 		g.Expect(err).ToNot(HaveOccurred())
@@ -141,7 +184,7 @@ func (env *Environment) GetInstanceProfileName(nodeClass *v1beta1.EC2NodeClass)
 	return fmt.Sprintf("%s_%d", env.ClusterName, lo.Must(hashstructure.Hash(fmt.Sprintf("%s%s", env.Region, nodeClass.Name), hashstructure.FormatV2, nil)))
 }
 
-func (env *Environment) GetInstance(nodeName string) ec2.Instance {
+func (env *Environment) GetInstance(nodeName string) ec2types.Instance {
 	node := env.Environment.GetNode(nodeName)
 	return env.GetInstanceByID(env.ExpectParsedProviderID(node.Spec.ProviderID))
 }
@@ -149,9 +192,9 @@ func (env *Environment) GetInstance(nodeName string) ec2.Instance {
 func (env *Environment) ExpectInstanceStopped(nodeName string) {
 	GinkgoHelper()
 	node := env.Environment.GetNode(nodeName)
-	_, err := env.EC2API.StopInstances(&ec2.StopInstancesInput{
+	_, err := env.EC2API.StopInstances(env.Context, &ec2.StopInstancesInput{
 		Force:       aws.Bool(true),
-		InstanceIds: aws.StringSlice([]string{env.ExpectParsedProviderID(node.Spec.ProviderID)}),
+		InstanceIds: []string{env.ExpectParsedProviderID(node.Spec.ProviderID)},
 	})
 	Expect(err).To(Succeed())
 }
@@ -159,61 +202,109 @@ func (env *Environment) ExpectInstanceStopped(nodeName string) {
 func (env *Environment) ExpectInstanceTerminated(nodeName string) {
 	GinkgoHelper()
 	node := env.Environment.GetNode(nodeName)
-	_, err := env.EC2API.TerminateInstances(&ec2.TerminateInstancesInput{
-		InstanceIds: aws.StringSlice([]string{env.ExpectParsedProviderID(node.Spec.ProviderID)}),
+	_, err := env.EC2API.TerminateInstances(env.Context, &ec2.TerminateInstancesInput{
+		InstanceIds: []string{env.ExpectParsedProviderID(node.Spec.ProviderID)},
 	})
 	Expect(err).To(Succeed())
 }
 
-func (env *Environment) GetInstanceByID(instanceID string) ec2.Instance {
+func (env *Environment) GetInstanceByID(instanceID string) ec2types.Instance {
 	GinkgoHelper()
-	instance, err := env.EC2API.DescribeInstances(&ec2.DescribeInstancesInput{
-		InstanceIds: aws.StringSlice([]string{instanceID}),
+	instance, err := env.EC2API.DescribeInstances(env.Context, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
 	})
 	Expect(err).ToNot(HaveOccurred())
 	Expect(instance.Reservations).To(HaveLen(1))
 	Expect(instance.Reservations[0].Instances).To(HaveLen(1))
-	return *instance.Reservations[0].Instances[0]
+	return instance.Reservations[0].Instances[0]
 }
 
-func (env *Environment) GetVolume(id *string) *ec2.Volume {
+func (env *Environment) GetVolume(id *string) ec2types.Volume {
 	volumes := env.GetVolumes(id)
 	Expect(volumes).To(HaveLen(1))
 	return volumes[0]
 }
 
-func (env *Environment) GetVolumes(ids ...*string) []*ec2.Volume {
+func (env *Environment) GetVolumes(ids ...*string) []ec2types.Volume {
 	GinkgoHelper()
-	dvo, err := env.EC2API.DescribeVolumes(&ec2.DescribeVolumesInput{VolumeIds: ids})
+	dvo, err := env.EC2API.DescribeVolumes(env.Context, &ec2.DescribeVolumesInput{VolumeIds: aws.ToStringSlice(ids)})
 	Expect(err).ToNot(HaveOccurred())
 	return dvo.Volumes
 }
 
-func (env *Environment) GetNetworkInterface(id *string) *ec2.NetworkInterface {
+func (env *Environment) GetNetworkInterface(id *string) ec2types.NetworkInterface {
 	networkInterfaces := env.GetNetworkInterfaces(id)
 	Expect(networkInterfaces).To(HaveLen(1))
 	return networkInterfaces[0]
 }
 
-func (env *Environment) GetNetworkInterfaces(ids ...*string) []*ec2.NetworkInterface {
+func (env *Environment) GetNetworkInterfaces(ids ...*string) []ec2types.NetworkInterface {
 	GinkgoHelper()
-	dnio, err := env.EC2API.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: ids})
+	dnio, err := env.EC2API.DescribeNetworkInterfaces(env.Context, &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: aws.ToStringSlice(ids)})
 	Expect(err).ToNot(HaveOccurred())
 	return dnio.NetworkInterfaces
 }
 
-func (env *Environment) GetSpotInstanceRequest(id *string) *ec2.SpotInstanceRequest {
+func (env *Environment) GetSpotInstanceRequest(id *string) ec2types.SpotInstanceRequest {
 	GinkgoHelper()
-	siro, err := env.EC2API.DescribeSpotInstanceRequests(&ec2.DescribeSpotInstanceRequestsInput{SpotInstanceRequestIds: []*string{id}})
+	siro, err := env.EC2API.DescribeSpotInstanceRequests(env.Context, &ec2.DescribeSpotInstanceRequestsInput{SpotInstanceRequestIds: []string{aws.ToString(id)}})
 	Expect(err).ToNot(HaveOccurred())
 	Expect(siro.SpotInstanceRequests).To(HaveLen(1))
 	return siro.SpotInstanceRequests[0]
 }
 
+// ExpectCapacityReservationCreated creates an active capacity reservation for Linux instances of the instance type in the
+// zone, which is cancelled when the test is cleaned up, and returns its ID. Open reservations are consumed by any matching
+// on-demand instance, while targeted reservations are only consumed by instances that are launched into them.
+func (env *Environment) ExpectCapacityReservationCreated(instanceType, zone string, count int32, matchCriteria ec2types.InstanceMatchCriteria) string {
+	GinkgoHelper()
+	out, err := env.EC2API.CreateCapacityReservation(env.Context, &ec2.CreateCapacityReservationInput{
+		InstanceType:          aws.String(instanceType),
+		InstancePlatform:      ec2types.CapacityReservationInstancePlatformLinuxUnix,
+		AvailabilityZone:      aws.String(zone),
+		InstanceCount:         aws.Int32(count),
+		InstanceMatchCriteria: matchCriteria,
+		EndDateType:           ec2types.EndDateTypeUnlimited,
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeCapacityReservation,
+			Tags:         []ec2types.Tag{{Key: aws.String("testing/cluster"), Value: aws.String(env.ClusterName)}},
+		}},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	id := aws.ToString(out.CapacityReservation.CapacityReservationId)
+	DeferCleanup(env.ExpectCapacityReservationCanceled, id)
+	Eventually(func(g Gomega) {
+		g.Expect(env.GetCapacityReservation(id).State).To(Equal(ec2types.CapacityReservationStateActive))
+	}).Should(Succeed())
+	return id
+}
+
+// ExpectCapacityReservationCanceled cancels the capacity reservation, unless it was already cancelled or expired. The
+// instances that were running in the reservation keep running as on-demand instances.
+func (env *Environment) ExpectCapacityReservationCanceled(id string) {
+	GinkgoHelper()
+	if state := env.GetCapacityReservation(id).State; state == ec2types.CapacityReservationStateCancelled || state == ec2types.CapacityReservationStateExpired {
+		return
+	}
+	_, err := env.EC2API.CancelCapacityReservation(env.Context, &ec2.CancelCapacityReservationInput{CapacityReservationId: aws.String(id)})
+	Expect(err).ToNot(HaveOccurred())
+	Eventually(func(g Gomega) {
+		g.Expect(env.GetCapacityReservation(id).State).To(Equal(ec2types.CapacityReservationStateCancelled))
+	}).Should(Succeed())
+}
+
+func (env *Environment) GetCapacityReservation(id string) ec2types.CapacityReservation {
+	GinkgoHelper()
+	out, err := env.EC2API.DescribeCapacityReservations(env.Context, &ec2.DescribeCapacityReservationsInput{CapacityReservationIds: []string{id}})
+	Expect(err).ToNot(HaveOccurred())
+	Expect(out.CapacityReservations).To(HaveLen(1))
+	return out.CapacityReservations[0]
+}
+
 // GetZones returns all available zones mapped from zone -> zone type
 func (env *Environment) GetZones() map[string]string {
-	output := lo.Must(env.EC2API.DescribeAvailabilityZones(&ec2.DescribeAvailabilityZonesInput{}))
-	return lo.Associate(output.AvailabilityZones, func(zone *ec2.AvailabilityZone) (string, string) {
+	output := lo.Must(env.EC2API.DescribeAvailabilityZones(env.Context, &ec2.DescribeAvailabilityZonesInput{}))
+	return lo.Associate(output.AvailabilityZones, func(zone ec2types.AvailabilityZone) (string, string) {
 		return lo.FromPtr(zone.ZoneName), lo.FromPtr(zone.ZoneType)
 	})
 }
@@ -221,24 +312,71 @@ func (env *Environment) GetZones() map[string]string {
 // GetSubnets returns all subnets matching the label selector
 // mapped from AZ -> {subnet-ids...}
 func (env *Environment) GetSubnets(tags map[string]string) map[string][]string {
-	var filters []*ec2.Filter
+	var filters []ec2types.Filter
 	for key, val := range tags {
-		filters = append(filters, &ec2.Filter{
+		filters = append(filters, ec2types.Filter{
 			Name:   aws.String(fmt.Sprintf("tag:%s", key)),
-			Values: []*string{aws.String(val)},
+			Values: []string{val},
 		})
 	}
 	subnets := map[string][]string{}
-	err := env.EC2API.DescribeSubnetsPages(&ec2.DescribeSubnetsInput{Filters: filters}, func(dso *ec2.DescribeSubnetsOutput, _ bool) bool {
+	paginator := ec2.NewDescribeSubnetsPaginator(env.EC2API, &ec2.DescribeSubnetsInput{Filters: filters})
+	for paginator.HasMorePages() {
+		dso, err := paginator.NextPage(env.Context)
+		Expect(err).To(BeNil())
 		for _, subnet := range dso.Subnets {
 			subnets[*subnet.AvailabilityZone] = append(subnets[*subnet.AvailabilityZone], *subnet.SubnetId)
 		}
-		return true
-	})
-	Expect(err).To(BeNil())
+	}
 	return subnets
 }
 
+// ExpectSubnetsBlackholed associates the subnets with a network ACL that denies all traffic, which impairs the
+// instances in them the way that an AZ outage would. The returned function associates the subnets with their original
+// network ACLs again, and is called when the spec is cleaned up if it wasn't called before.
+func (env *Environment) ExpectSubnetsBlackholed(subnetIDs ...string) (restore func()) {
+	GinkgoHelper()
+	subnets := lo.Must(env.EC2API.DescribeSubnets(env.Context, &ec2.DescribeSubnetsInput{SubnetIds: subnetIDs}))
+	Expect(subnets.Subnets).ToNot(BeEmpty())
+	// network ACLs deny all traffic that isn't allowed by their rules, so an ACL without rules blackholes the subnets
+	acl := lo.Must(env.EC2API.CreateNetworkAcl(env.Context, &ec2.CreateNetworkAclInput{
+		VpcId: subnets.Subnets[0].VpcId,
+		TagSpecifications: []ec2types.TagSpecification{{
+			ResourceType: ec2types.ResourceTypeNetworkAcl,
+			Tags:         []ec2types.Tag{{Key: aws.String("testing/cluster"), Value: aws.String(env.ClusterName)}},
+		}},
+	}))
+	acls := lo.Must(env.EC2API.DescribeNetworkAcls(env.Context, &ec2.DescribeNetworkAclsInput{
+		Filters: []ec2types.Filter{{Name: aws.String("association.subnet-id"), Values: subnetIDs}},
+	}))
+	// the ID of the association changes every time that it's replaced, so the original ACLs are restored through the
+	// associations that replaced them
+	originalACLs := map[string]string{}
+	for _, original := range acls.NetworkAcls {
+		for _, association := range original.Associations {
+			if !lo.Contains(subnetIDs, aws.ToString(association.SubnetId)) {
+				continue
+			}
+			out := lo.Must(env.EC2API.ReplaceNetworkAclAssociation(env.Context, &ec2.ReplaceNetworkAclAssociationInput{
+				AssociationId: association.NetworkAclAssociationId,
+				NetworkAclId:  acl.NetworkAcl.NetworkAclId,
+			}))
+			originalACLs[aws.ToString(out.NewAssociationId)] = aws.ToString(original.NetworkAclId)
+		}
+	}
+	restore = sync.OnceFunc(func() {
+		for associationID, aclID := range originalACLs {
+			lo.Must(env.EC2API.ReplaceNetworkAclAssociation(env.Context, &ec2.ReplaceNetworkAclAssociationInput{
+				AssociationId: aws.String(associationID),
+				NetworkAclId:  aws.String(aclID),
+			}))
+		}
+		lo.Must(env.EC2API.DeleteNetworkAcl(env.Context, &ec2.DeleteNetworkAclInput{NetworkAclId: acl.NetworkAcl.NetworkAclId}))
+	})
+	DeferCleanup(restore)
+	return restore
+}
+
 // SubnetInfo is a simple struct for testing
 type SubnetInfo struct {
 	Name string
@@ -247,53 +385,55 @@ type SubnetInfo struct {
 
 // GetSubnetNameAndIds returns all subnets matching the label selector
 func (env *Environment) GetSubnetNameAndIds(tags map[string]string) []SubnetInfo {
-	var filters []*ec2.Filter
+	var filters []ec2types.Filter
 	for key, val := range tags {
-		filters = append(filters, &ec2.Filter{
+		filters = append(filters, ec2types.Filter{
 			Name:   aws.String(fmt.Sprintf("tag:%s", key)),
-			Values: []*string{aws.String(val)},
+			Values: []string{val},
 		})
 	}
 	var subnetInfo []SubnetInfo
-	err := env.EC2API.DescribeSubnetsPages(&ec2.DescribeSubnetsInput{Filters: filters}, func(dso *ec2.DescribeSubnetsOutput, _ bool) bool {
-		subnetInfo = lo.Map(dso.Subnets, func(s *ec2.Subnet, _ int) SubnetInfo {
-			elem := SubnetInfo{ID: aws.StringValue(s.SubnetId)}
-			if tag, ok := lo.Find(s.Tags, func(t *ec2.Tag) bool { return aws.StringValue(t.Key) == "Name" }); ok {
-				elem.Name = aws.StringValue(tag.Value)
+	paginator := ec2.NewDescribeSubnetsPaginator(env.EC2API, &ec2.DescribeSubnetsInput{Filters: filters})
+	for paginator.HasMorePages() {
+		dso, err := paginator.NextPage(env.Context)
+		Expect(err).To(BeNil())
+		subnetInfo = lo.Map(dso.Subnets, func(s ec2types.Subnet, _ int) SubnetInfo {
+			elem := SubnetInfo{ID: aws.ToString(s.SubnetId)}
+			if tag, ok := lo.Find(s.Tags, func(t ec2types.Tag) bool { return aws.ToString(t.Key) == "Name" }); ok {
+				elem.Name = aws.ToString(tag.Value)
 			}
 			return elem
 		})
-		return true
-	})
-	Expect(err).To(BeNil())
+	}
 	return subnetInfo
 }
 
 type SecurityGroup struct {
-	ec2.GroupIdentifier
-	Tags []*ec2.Tag
+	ec2types.GroupIdentifier
+	Tags []ec2types.Tag
 }
 
 // GetSecurityGroups returns all getSecurityGroups matching the label selector
 func (env *Environment) GetSecurityGroups(tags map[string]string) []SecurityGroup {
-	var filters []*ec2.Filter
+	var filters []ec2types.Filter
 	for key, val := range tags {
-		filters = append(filters, &ec2.Filter{
+		filters = append(filters, ec2types.Filter{
 			Name:   aws.String(fmt.Sprintf("tag:%s", key)),
-			Values: []*string{aws.String(val)},
+			Values: []string{val},
 		})
 	}
 	var securityGroups []SecurityGroup
-	err := env.EC2API.DescribeSecurityGroupsPages(&ec2.DescribeSecurityGroupsInput{Filters: filters}, func(dso *ec2.DescribeSecurityGroupsOutput, _ bool) bool {
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(env.EC2API, &ec2.DescribeSecurityGroupsInput{Filters: filters})
+	for paginator.HasMorePages() {
+		dso, err := paginator.NextPage(env.Context)
+		Expect(err).To(BeNil())
 		for _, sg := range dso.SecurityGroups {
 			securityGroups = append(securityGroups, SecurityGroup{
 				Tags:            sg.Tags,
-				GroupIdentifier: ec2.GroupIdentifier{GroupId: sg.GroupId, GroupName: sg.GroupName},
+				GroupIdentifier: ec2types.GroupIdentifier{GroupId: sg.GroupId, GroupName: sg.GroupName},
 			})
 		}
-		return true
-	})
-	Expect(err).To(BeNil())
+	}
 	return securityGroups
 }
 
@@ -348,32 +488,32 @@ func (env *Environment) GetK8sMinorVersion(offset int) (int, error) {
 
 func (env *Environment) GetCustomAMI(amiPath string, versionOffset int) string {
 	version := env.GetK8sVersion(versionOffset)
-	parameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{
+	parameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{
 		Name: aws.String(fmt.Sprintf(amiPath, version)),
 	})
 	Expect(err).To(BeNil())
 	return *parameter.Parameter.Value
 }
 
-func (env *Environment) EventuallyExpectRunInstances(instanceInput *ec2.RunInstancesInput) *ec2.Reservation {
+func (env *Environment) EventuallyExpectRunInstances(instanceInput *ec2.RunInstancesInput) *ec2.RunInstancesOutput {
 	GinkgoHelper()
 	// implement IMDSv2
-	instanceInput.MetadataOptions = &ec2.InstanceMetadataOptionsRequest{
-		HttpEndpoint: aws.String("enabled"),
-		HttpTokens:   aws.String("required"),
+	instanceInput.MetadataOptions = &ec2types.InstanceMetadataOptionsRequest{
+		HttpEndpoint: ec2types.InstanceMetadataEndpointStateEnabled,
+		HttpTokens:   ec2types.HttpTokensStateRequired,
 	}
-	var out *ec2.Reservation
+	var out *ec2.RunInstancesOutput
 	var err error
 	Eventually(func(g Gomega) {
-		out, err = env.EC2API.RunInstances(instanceInput)
+		out, err = env.EC2API.RunInstances(env.Context, instanceInput)
 		g.Expect(err).ToNot(HaveOccurred())
 	}).WithTimeout(30 * time.Second).WithPolling(5 * time.Second).Should(Succeed())
 	return out
 }
 
-func (env *Environment) ExpectSpotInterruptionRole() *iam.Role {
+func (env *Environment) ExpectSpotInterruptionRole() *iamtypes.Role {
 	GinkgoHelper()
-	out, err := env.IAMAPI.GetRoleWithContext(env.Context, &iam.GetRoleInput{
+	out, err := env.IAMAPI.GetRole(env.Context, &iam.GetRoleInput{
 		RoleName: aws.String(fisRoleName),
 	})
 	Expect(err).ToNot(HaveOccurred())
@@ -382,16 +522,16 @@ func (env *Environment) ExpectSpotInterruptionRole() *iam.Role {
 
 func (env *Environment) ExpectAccountID() string {
 	GinkgoHelper()
-	identity, err := env.STSAPI.GetCallerIdentityWithContext(env.Context, &sts.GetCallerIdentityInput{})
+	identity, err := env.STSAPI.GetCallerIdentity(env.Context, &sts.GetCallerIdentityInput{})
 	Expect(err).ToNot(HaveOccurred())
-	return aws.StringValue(identity.Account)
+	return aws.ToString(identity.Account)
 }
 
 func (env *Environment) ExpectInstanceProfileCreated(instanceProfileName, roleName string) {
 	By("creating an instance profile")
 	createInstanceProfile := &iam.CreateInstanceProfileInput{
 		InstanceProfileName: aws.String(instanceProfileName),
-		Tags: []*iam.Tag{
+		Tags: []iamtypes.Tag{
 			{
 				Key:   aws.String(coretest.DiscoveryLabel),
 				Value: aws.String(env.ClusterName),
@@ -399,13 +539,13 @@ func (env *Environment) ExpectInstanceProfileCreated(instanceProfileName, roleNa
 		},
 	}
 	By("adding the karpenter role to new instance profile")
-	_, err := env.IAMAPI.CreateInstanceProfile(createInstanceProfile)
+	_, err := env.IAMAPI.CreateInstanceProfile(env.Context, createInstanceProfile)
 	Expect(awserrors.IgnoreAlreadyExists(err)).ToNot(HaveOccurred())
 	addInstanceProfile := &iam.AddRoleToInstanceProfileInput{
 		InstanceProfileName: aws.String(instanceProfileName),
 		RoleName:            aws.String(roleName),
 	}
-	_, err = env.IAMAPI.AddRoleToInstanceProfile(addInstanceProfile)
+	_, err = env.IAMAPI.AddRoleToInstanceProfile(env.Context, addInstanceProfile)
 	Expect(ignoreAlreadyContainsRole(err)).ToNot(HaveOccurred())
 }
 
@@ -415,13 +555,13 @@ func (env *Environment) ExpectInstanceProfileDeleted(instanceProfileName, roleNa
 		InstanceProfileName: aws.String(instanceProfileName),
 		RoleName:            aws.String(roleName),
 	}
-	_, err := env.IAMAPI.RemoveRoleFromInstanceProfile(removeRoleFromInstanceProfile)
+	_, err := env.IAMAPI.RemoveRoleFromInstanceProfile(env.Context, removeRoleFromInstanceProfile)
 	Expect(awserrors.IgnoreNotFound(err)).To(BeNil())
 
 	deleteInstanceProfile := &iam.DeleteInstanceProfileInput{
 		InstanceProfileName: aws.String(instanceProfileName),
 	}
-	_, err = env.IAMAPI.DeleteInstanceProfile(deleteInstanceProfile)
+	_, err = env.IAMAPI.DeleteInstanceProfile(env.Context, deleteInstanceProfile)
 	Expect(awserrors.IgnoreNotFound(err)).ToNot(HaveOccurred())
 }
 
@@ -0,0 +1,63 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Distribution is a set of samples, such as the latencies of pods, that percentiles are computed from
+type Distribution struct {
+	mu      sync.Mutex
+	samples []float64
+}
+
+func (d *Distribution) Observe(sample float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.samples = append(d.samples, sample)
+}
+
+func (d *Distribution) Count() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.samples)
+}
+
+// Percentile returns the nearest-rank percentile of the samples, where p is between 0 and 100, or 0 if there are no
+// samples
+func (d *Distribution) Percentile(p float64) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), d.samples...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+// Summary returns the percentiles of the samples that the scale tests report, keyed by their name
+func (d *Distribution) Summary() map[string]float64 {
+	return map[string]float64{
+		"p50": d.Percentile(50),
+		"p90": d.Percentile(90),
+		"p99": d.Percentile(99),
+		"max": d.Percentile(100),
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+// GeneratorLabel is set on the pods of a generator to its name, so that the pods of all of its deployments are selected
+const GeneratorLabel = "testing/load-generator"
+
+type Options struct {
+	// Name identifies the deployments and pods of the generator
+	Name string
+	// Deployments is the number of deployments that the pods are spread over
+	Deployments int
+	// Pods is the number of pods across all deployments that the load starts at
+	Pods int
+	// PodOptions are the options of the pods of the deployments
+	PodOptions test.PodOptions
+	// ChurnInterval is how often the deployments are scaled while churning, and ChurnRatio is the fraction of Pods that
+	// are added or removed every interval. The number of pods stays within ChurnRatio of Pods.
+	ChurnInterval time.Duration
+	ChurnRatio    float64
+	// Seed seeds the choice of the deployments that are scaled, so that the churn of runs can be reproduced
+	Seed int64
+}
+
+// Generator generates load for scale and soak tests, by creating deployments and scaling them up and down. The latency
+// of every pod of the deployments is observed, from when the pod is created to when it's scheduled and ready.
+type Generator struct {
+	kubeClient client.Client
+	opts       Options
+	rand       *rand.Rand
+
+	deployments []*appsv1.Deployment
+	observed    sets.Set[types.UID]
+
+	// SchedulingLatency is the number of seconds from pods being created until they're scheduled, and ReadyLatency
+	// until they're ready
+	SchedulingLatency *Distribution
+	ReadyLatency      *Distribution
+	// Scaled is the number of pods that were added or removed while churning
+	Scaled int
+}
+
+func NewGenerator(kubeClient client.Client, opts Options) *Generator {
+	g := &Generator{
+		kubeClient:        kubeClient,
+		opts:              opts,
+		rand:              rand.New(rand.NewSource(opts.Seed)), //nolint:gosec
+		observed:          sets.New[types.UID](),
+		SchedulingLatency: &Distribution{},
+		ReadyLatency:      &Distribution{},
+	}
+	podOptions := opts.PodOptions
+	for i := 0; i < opts.Deployments; i++ {
+		name := fmt.Sprintf("%s-%d", opts.Name, i)
+		podOptions.Labels = lo.Assign(opts.PodOptions.Labels, map[string]string{GeneratorLabel: opts.Name, "app": name})
+		// the pods are spread as evenly as possible, with the remainder going to the first deployments
+		replicas := opts.Pods / opts.Deployments
+		if i < opts.Pods%opts.Deployments {
+			replicas++
+		}
+		g.deployments = append(g.deployments, test.Deployment(test.DeploymentOptions{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Replicas:   int32(replicas),
+			PodOptions: podOptions,
+		}))
+	}
+	return g
+}
+
+// Deployments returns the deployments that the load is generated with, which are created by the test
+func (g *Generator) Deployments() []*appsv1.Deployment {
+	return g.deployments
+}
+
+// Selector selects the pods of all deployments of the generator
+func (g *Generator) Selector() labels.Selector {
+	return labels.SelectorFromSet(map[string]string{GeneratorLabel: g.opts.Name})
+}
+
+// Replicas returns the number of pods that the deployments are currently scaled to
+func (g *Generator) Replicas() int {
+	return lo.SumBy(g.deployments, func(d *appsv1.Deployment) int { return int(lo.FromPtr(d.Spec.Replicas)) })
+}
+
+// Churn scales random deployments up and down every ChurnInterval until the duration elapsed or the context is done,
+// and observes the latencies of their pods in between
+func (g *Generator) Churn(ctx context.Context, duration time.Duration) error {
+	delta := max(int(float64(g.opts.Pods)*g.opts.ChurnRatio), 1)
+	minReplicas, maxReplicas := g.opts.Pods-delta, g.opts.Pods+delta
+	ticker := time.NewTicker(g.opts.ChurnInterval)
+	defer ticker.Stop()
+	deadline := time.After(duration)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return nil
+		case <-ticker.C:
+		}
+		// scale up if scaling down would go below the minimum, and down if scaling up would go above the maximum
+		change := lo.Ternary(g.rand.Intn(2) == 0, delta, -delta)
+		if g.Replicas()+change > maxReplicas || g.Replicas()+change < minReplicas {
+			change = -change
+		}
+		if err := g.scale(ctx, change); err != nil {
+			return err
+		}
+		if err := g.Observe(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// scale spreads the change in the number of pods over random deployments, without scaling any below zero
+func (g *Generator) scale(ctx context.Context, change int) error {
+	replicas := lo.SliceToMap(g.deployments, func(d *appsv1.Deployment) (*appsv1.Deployment, int32) { return d, lo.FromPtr(d.Spec.Replicas) })
+	for remaining := change; remaining != 0; {
+		d := g.deployments[g.rand.Intn(len(g.deployments))]
+		if remaining > 0 {
+			replicas[d]++
+			remaining--
+		} else if replicas[d] > 0 {
+			replicas[d]--
+			remaining++
+		}
+	}
+	for _, d := range g.deployments {
+		if replicas[d] == lo.FromPtr(d.Spec.Replicas) {
+			continue
+		}
+		stored := d.DeepCopy()
+		d.Spec.Replicas = lo.ToPtr(replicas[d])
+		if err := g.kubeClient.Patch(ctx, d, client.MergeFrom(stored)); err != nil {
+			return fmt.Errorf("scaling deployment %s, %w", d.Name, err)
+		}
+	}
+	g.Scaled += lo.Ternary(change < 0, -change, change)
+	logging.FromContext(ctx).With("change", change, "replicas", g.Replicas()).Debugf("scaled load")
+	return nil
+}
+
+// Observe records the latencies of the pods that became ready since they were last observed. Pods that are deleted
+// before they're ready aren't observed, so the latencies should be observed before the load is torn down.
+func (g *Generator) Observe(ctx context.Context) error {
+	pods := &v1.PodList{}
+	if err := g.kubeClient.List(ctx, pods, client.MatchingLabelsSelector{Selector: g.Selector()}); err != nil {
+		return fmt.Errorf("listing pods, %w", err)
+	}
+	for _, pod := range pods.Items {
+		if g.observed.Has(pod.UID) {
+			continue
+		}
+		scheduled, ok := condition(pod, v1.PodScheduled)
+		if !ok {
+			continue
+		}
+		ready, ok := condition(pod, v1.PodReady)
+		if !ok {
+			continue
+		}
+		g.observed.Insert(pod.UID)
+		g.SchedulingLatency.Observe(scheduled.Sub(pod.CreationTimestamp.Time).Seconds())
+		g.ReadyLatency.Observe(ready.Sub(pod.CreationTimestamp.Time).Seconds())
+	}
+	return nil
+}
+
+// condition returns when the condition of the pod became true
+func condition(pod v1.Pod, conditionType v1.PodConditionType) (time.Time, bool) {
+	c, ok := lo.Find(pod.Status.Conditions, func(c v1.PodCondition) bool { return c.Type == conditionType })
+	if !ok || c.Status != v1.ConditionTrue {
+		return time.Time{}, false
+	}
+	return c.LastTransitionTime.Time, true
+}
@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MetricsPort is the port that Karpenter serves its metrics on
+const MetricsPort = "8000"
+
+// Metrics are the metrics that were scraped from a Karpenter pod at a point in time. Counters and histograms are
+// cumulative, so the metrics of a test are the difference between the metrics scraped before and after it.
+type Metrics map[string]*dto.MetricFamily
+
+// Scrape scrapes the metrics of the pod through the API server's pod proxy
+func Scrape(ctx context.Context, kubeClient kubernetes.Interface, pod *v1.Pod) (Metrics, error) {
+	raw, err := kubeClient.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, MetricsPort, "/metrics", nil).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("scraping metrics of %s/%s, %w", pod.Namespace, pod.Name, err)
+	}
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parsing metrics of %s/%s, %w", pod.Namespace, pod.Name, err)
+	}
+	return families, nil
+}
+
+// CounterBy returns the sum of the series of the counter, grouped by the value of the label
+func (m Metrics) CounterBy(name, label string) map[string]float64 {
+	res := map[string]float64{}
+	for _, metric := range m[name].GetMetric() {
+		res[labelValue(metric, label)] += metric.GetCounter().GetValue()
+	}
+	return res
+}
+
+// Histogram returns the sum of the series of the histogram whose labels match the given labels
+func (m Metrics) Histogram(name string, labels map[string]string) Histogram {
+	h := Histogram{Buckets: map[float64]float64{}}
+	for _, metric := range m[name].GetMetric() {
+		if lo.SomeBy(lo.Entries(labels), func(e lo.Entry[string, string]) bool { return labelValue(metric, e.Key) != e.Value }) {
+			continue
+		}
+		h.Count += float64(metric.GetHistogram().GetSampleCount())
+		h.Sum += metric.GetHistogram().GetSampleSum()
+		for _, bucket := range metric.GetHistogram().GetBucket() {
+			h.Buckets[bucket.GetUpperBound()] += float64(bucket.GetCumulativeCount())
+		}
+	}
+	return h
+}
+
+// SubCounters returns the difference of the counters that were grouped by CounterBy
+func SubCounters(after, before map[string]float64) map[string]float64 {
+	return lo.MapValues(after, func(v float64, k string) float64 { return v - before[k] })
+}
+
+// Histogram is the cumulative count of observations per upper bound of its buckets
+type Histogram struct {
+	Buckets map[float64]float64
+	Count   float64
+	Sum     float64
+}
+
+// Sub returns the observations of the histogram that were made since the other histogram was scraped
+func (h Histogram) Sub(other Histogram) Histogram {
+	return Histogram{
+		Buckets: lo.MapValues(h.Buckets, func(v float64, k float64) float64 { return v - other.Buckets[k] }),
+		Count:   h.Count - other.Count,
+		Sum:     h.Sum - other.Sum,
+	}
+}
+
+// Quantile estimates the quantile of the observations, where q is between 0 and 1, by interpolating linearly within
+// the bucket that it falls in, like histogram_quantile in PromQL does. Quantiles that fall in the +Inf bucket are
+// estimated as the largest finite upper bound.
+func (h Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	bounds := lo.Keys(h.Buckets)
+	sort.Float64s(bounds)
+	rank := q * h.Count
+	lower, previous := 0.0, 0.0
+	for _, upper := range bounds {
+		count := h.Buckets[upper]
+		if count >= rank {
+			if math.IsInf(upper, 1) {
+				return lower
+			}
+			if count == previous {
+				return upper
+			}
+			return lower + (upper-lower)*(rank-previous)/(count-previous)
+		}
+		lower, previous = upper, count
+	}
+	return lower
+}
+
+func labelValue(metric *dto.Metric, name string) string {
+	pair, _ := lo.Find(metric.GetLabel(), func(l *dto.LabelPair) bool { return l.GetName() == name })
+	return pair.GetValue()
+}
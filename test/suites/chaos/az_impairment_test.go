@@ -0,0 +1,160 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AZ Impairment", func() {
+	var dep *appsv1.Deployment
+	var selector labels.Selector
+	var numPods int
+
+	BeforeEach(func() {
+		numPods = 6
+		// one pod per node, spread across zones, so that every zone has nodes that are impaired when it is
+		dep = coretest.Deployment(coretest.DeploymentOptions{
+			Replicas: int32(numPods),
+			PodOptions: coretest.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "az-impairment"},
+				},
+				PodAntiRequirements: []v1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "az-impairment"}},
+					TopologyKey:   v1.LabelHostname,
+				}},
+				// the impaired zone remains a topology domain for the kube-scheduler while its nodes exist, so the pods
+				// are only spread as long as they can be
+				TopologySpreadConstraints: []v1.TopologySpreadConstraint{{
+					MaxSkew:           1,
+					TopologyKey:       v1.LabelTopologyZone,
+					WhenUnsatisfiable: v1.ScheduleAnyway,
+					LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "az-impairment"}},
+				}},
+				TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+			},
+		})
+		selector = labels.SelectorFromSet(dep.Spec.Selector.MatchLabels)
+		nodePool.Spec.Disruption.Budgets = []corev1beta1.Budget{{Nodes: "1"}}
+	})
+	It("should reroute capacity away from an impaired AZ, respect budgets and recover once it's restored", func() {
+		ctx, cancel := context.WithCancel(env.Context)
+		defer cancel()
+		// the impaired nodes are replaced one at a time, and the pods on them are only evicted once they're unreachable
+		SetDefaultEventuallyTimeout(30 * time.Minute)
+		DeferCleanup(SetDefaultEventuallyTimeout, 5*time.Minute)
+
+		env.ExpectCreated(nodeClass, nodePool, dep)
+		env.EventuallyExpectHealthyPodCount(selector, numPods)
+		nodes := env.EventuallyExpectCreatedNodeCount("==", numPods)
+
+		// Karpenter runs on nodes in the subnets that are blackholed, so a zone that it doesn't run in is impaired
+		karpenterZones := lo.Map(env.ExpectKarpenterPods(), func(p *v1.Pod, _ int) string {
+			return env.GetNode(p.Spec.NodeName).Labels[v1.LabelTopologyZone]
+		})
+		zone, ok := lo.Find(lo.Uniq(lo.Map(nodes, func(n *v1.Node, _ int) string { return n.Labels[v1.LabelTopologyZone] })), func(z string) bool {
+			return !lo.Contains(karpenterZones, z)
+		})
+		if !ok {
+			Skip("Karpenter runs in every zone that nodes were launched in")
+		}
+		zoneSelector := labels.SelectorFromSet(map[string]string{v1.LabelTopologyZone: zone, corev1beta1.NodePoolLabelKey: nodePool.Name})
+		impairedNodeClaims := lo.Filter(env.EventuallyExpectNodeClaimCount("==", numPods), func(nc *corev1beta1.NodeClaim, _ int) bool {
+			return nc.Labels[v1.LabelTopologyZone] == zone
+		})
+		Expect(impairedNodeClaims).ToNot(BeEmpty())
+
+		By(fmt.Sprintf("blackholing the subnets of %s", zone))
+		subnets := env.GetSubnets(map[string]string{"karpenter.sh/discovery": env.ClusterName})
+		Expect(subnets).To(HaveKey(zone))
+		restore := env.ExpectSubnetsBlackholed(subnets[zone]...)
+
+		By(fmt.Sprintf("excluding the subnets of %s from the EC2NodeClass", zone))
+		nodeClass.Spec.SubnetSelectorTerms = lo.Map(lo.Flatten(lo.Values(lo.OmitByKeys(subnets, []string{zone}))), func(id string, _ int) v1beta1.SubnetSelectorTerm {
+			return v1beta1.SubnetSelectorTerm{ID: id}
+		})
+		maxDisrupting := startDisruptionMonitor(ctx, env.Client)
+		env.ExpectUpdated(nodeClass)
+
+		By("replacing the nodes of the impaired zone through drift")
+		env.EventuallyExpectDrifted(impairedNodeClaims...)
+		env.EventuallyExpectNodeCountWithSelector("==", 0, zoneSelector)
+		env.EventuallyExpectHealthyPodCount(selector, numPods)
+		env.EventuallyExpectNotFound(lo.Map(impairedNodeClaims, func(nc *corev1beta1.NodeClaim, _ int) client.Object { return nc })...)
+		// nodes are only disrupted one at a time, even though the impaired nodes can't be drained gracefully
+		Expect(maxDisrupting.Load()).To(BeNumerically("<=", 1))
+		// no capacity is launched into the impaired zone while it's excluded
+		env.ConsistentlyExpectNodeCount("==", numPods, time.Minute)
+		Expect(env.Monitor.CreatedNodes()).ToNot(ContainElement(HaveField("Labels", HaveKeyWithValue(v1.LabelTopologyZone, zone))))
+
+		By(fmt.Sprintf("restoring %s", zone))
+		restore()
+		nodeClass.Spec.SubnetSelectorTerms = []v1beta1.SubnetSelectorTerm{{Tags: map[string]string{"karpenter.sh/discovery": env.ClusterName}}}
+		env.ExpectUpdated(nodeClass)
+		dep.Spec.Replicas = lo.ToPtr(int32(numPods + 3))
+		env.ExpectUpdated(dep)
+
+		// the pods are spread into the restored zone again, and no NodeClaims are leaked
+		env.EventuallyExpectHealthyPodCount(selector, numPods+3)
+		env.EventuallyExpectNodeCountWithSelector(">", 0, zoneSelector)
+		env.EventuallyExpectNodeClaimCount("==", numPods+3)
+		env.EventuallyExpectNodeCount("==", numPods+3)
+	})
+})
+
+// startDisruptionMonitor returns the largest number of nodes that were disrupted at the same time, which is recorded
+// until the context is done
+func startDisruptionMonitor(ctx context.Context, kubeClient client.Client) *atomic.Int64 {
+	maxDisrupting := &atomic.Int64{}
+	go func() {
+		defer GinkgoRecover()
+		for {
+			list := &v1.NodeList{}
+			if err := kubeClient.List(ctx, list, client.HasLabels{coretest.DiscoveryLabel}); err == nil {
+				disrupting := int64(lo.CountBy(list.Items, func(n v1.Node) bool {
+					return lo.ContainsBy(n.Spec.Taints, corev1beta1.IsDisruptingTaint)
+				}))
+				if disrupting > maxDisrupting.Load() {
+					maxDisrupting.Store(disrupting)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second * 5):
+			}
+		}
+	}()
+	return maxDisrupting
+}
@@ -33,10 +33,11 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	awssdk "github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/eks"
-	"github.com/aws/aws-sdk-go/service/ssm"
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -401,7 +402,7 @@ var _ = Describe("Drift", func() {
 			"/aws/service/eks/optimized-ami/1.27/amazon-linux-2/amazon-eks-node-1.27-v20240129/image_id",
 			"/aws/service/eks/optimized-ami/1.23/amazon-linux-2/amazon-eks-node-1.23-v20230322/image_id",
 		)
-		parameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{Name: awssdk.String(parameterName)})
+		parameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{Name: awssdk.String(parameterName)})
 		Expect(err).To(BeNil())
 		oldCustomAMI := *parameter.Parameter.Value
 		nodeClass.Spec.AMIFamily = &v1beta1.AMIFamilyCustom
@@ -426,7 +427,7 @@ var _ = Describe("Drift", func() {
 	})
 	It("should return drifted if the AMI no longer matches the existing NodeClaims instance type", func() {
 		version := env.GetK8sVersion(1)
-		armParameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{
+		armParameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{
 			Name: awssdk.String(fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2-arm64/recommended/image_id", version)),
 		})
 		Expect(err).To(BeNil())
@@ -454,7 +455,7 @@ var _ = Describe("Drift", func() {
 		version := env.GetK8sVersion(1)
 		env.ExpectSettingsOverridden(v1.EnvVar{Name: "FEATURE_GATES", Value: "Drift=false"})
 		// choose an old static image
-		parameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{
+		parameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{
 			Name: awssdk.String(fmt.Sprintf("/aws/service/eks/optimized-ami/%s/amazon-linux-2-arm64/recommended/image_id", version)),
 		})
 		Expect(err).To(BeNil())
@@ -478,7 +479,7 @@ var _ = Describe("Drift", func() {
 	})
 	It("should disrupt nodes that have drifted due to securitygroup", func() {
 		By("getting the cluster vpc id")
-		output, err := env.EKSAPI.DescribeCluster(&eks.DescribeClusterInput{Name: awssdk.String(env.ClusterName)})
+		output, err := env.EKSAPI.DescribeCluster(env.Context, &eks.DescribeClusterInput{Name: awssdk.String(env.ClusterName)})
 		Expect(err).To(BeNil())
 
 		By("creating new security group")
@@ -486,10 +487,10 @@ var _ = Describe("Drift", func() {
 			GroupName:   awssdk.String("security-group-drift"),
 			Description: awssdk.String("End-to-end Drift Test, should delete after drift test is completed"),
 			VpcId:       output.Cluster.ResourcesVpcConfig.VpcId,
-			TagSpecifications: []*ec2.TagSpecification{
+			TagSpecifications: []ec2types.TagSpecification{
 				{
-					ResourceType: awssdk.String("security-group"),
-					Tags: []*ec2.Tag{
+					ResourceType: ec2types.ResourceTypeSecurityGroup,
+					Tags: []ec2types.Tag{
 						{
 							Key:   awssdk.String("karpenter.sh/discovery"),
 							Value: awssdk.String(env.ClusterName),
@@ -506,7 +507,7 @@ var _ = Describe("Drift", func() {
 				},
 			},
 		}
-		_, _ = env.EC2API.CreateSecurityGroup(createSecurityGroup)
+		_, _ = env.EC2API.CreateSecurityGroup(env.Context, createSecurityGroup)
 
 		By("looking for security groups")
 		var securitygroups []aws.SecurityGroup
@@ -514,19 +515,19 @@ var _ = Describe("Drift", func() {
 		Eventually(func(g Gomega) {
 			securitygroups = env.GetSecurityGroups(map[string]string{"karpenter.sh/discovery": env.ClusterName})
 			testSecurityGroup, _ = lo.Find(securitygroups, func(sg aws.SecurityGroup) bool {
-				return awssdk.StringValue(sg.GroupName) == "security-group-drift"
+				return awssdk.ToString(sg.GroupName) == "security-group-drift"
 			})
 			g.Expect(testSecurityGroup).ToNot(BeNil())
 		}).Should(Succeed())
 
 		By("creating a new provider with the new securitygroup")
 		awsIDs := lo.FilterMap(securitygroups, func(sg aws.SecurityGroup, _ int) (string, bool) {
-			if awssdk.StringValue(sg.GroupId) != awssdk.StringValue(testSecurityGroup.GroupId) {
-				return awssdk.StringValue(sg.GroupId), true
+			if awssdk.ToString(sg.GroupId) != awssdk.ToString(testSecurityGroup.GroupId) {
+				return awssdk.ToString(sg.GroupId), true
 			}
 			return "", false
 		})
-		sgTerms := []v1beta1.SecurityGroupSelectorTerm{{ID: awssdk.StringValue(testSecurityGroup.GroupId)}}
+		sgTerms := []v1beta1.SecurityGroupSelectorTerm{{ID: awssdk.ToString(testSecurityGroup.GroupId)}}
 		for _, id := range awsIDs {
 			sgTerms = append(sgTerms, v1beta1.SecurityGroupSelectorTerm{ID: id})
 		}
@@ -538,7 +539,7 @@ var _ = Describe("Drift", func() {
 		node := env.ExpectCreatedNodeCount("==", 1)[0]
 
 		sgTerms = lo.Reject(sgTerms, func(t v1beta1.SecurityGroupSelectorTerm, _ int) bool {
-			return t.ID == awssdk.StringValue(testSecurityGroup.GroupId)
+			return t.ID == awssdk.ToString(testSecurityGroup.GroupId)
 		})
 		nodeClass.Spec.SecurityGroupSelectorTerms = sgTerms
 		env.ExpectCreatedOrUpdated(nodeClass)
@@ -838,7 +839,7 @@ var _ = Describe("Drift", func() {
 			env.EventuallyExpectCreatedNodeCount("==", int(numPods))
 
 			// Drift the nodeClaim with bad configuration that will not register a NodeClaim
-			parameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{
+			parameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{
 				Name: awssdk.String("/aws/service/ami-amazon-linux-latest/amzn2-ami-hvm-x86_64-ebs"),
 			})
 			Expect(err).ToNot(HaveOccurred())
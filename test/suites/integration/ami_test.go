@@ -21,9 +21,10 @@ import (
 	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -61,7 +62,7 @@ var _ = Describe("AMI", func() {
 	})
 	It("should use the most recent AMI when discovering multiple", func() {
 		// choose an old static image
-		parameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{
+		parameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{
 			Name: aws.String("/aws/service/eks/optimized-ami/1.23/amazon-linux-2/amazon-eks-node-1.23-v20230322/image_id"),
 		})
 		Expect(err).To(BeNil())
@@ -85,8 +86,8 @@ var _ = Describe("AMI", func() {
 		env.ExpectInstance(pod.Spec.NodeName).To(HaveField("ImageId", HaveValue(Equal(customAMI))))
 	})
 	It("should support AMI Selector Terms for Name but fail with incorrect owners", func() {
-		output, err := env.EC2API.DescribeImages(&ec2.DescribeImagesInput{
-			ImageIds: []*string{aws.String(customAMI)},
+		output, err := env.EC2API.DescribeImages(env.Context, &ec2.DescribeImagesInput{
+			ImageIds: []string{customAMI},
 		})
 		Expect(err).To(BeNil())
 		Expect(output.Images).To(HaveLen(1))
@@ -105,8 +106,8 @@ var _ = Describe("AMI", func() {
 		Expect(pod.Spec.NodeName).To(Equal(""))
 	})
 	It("should support ami selector Name with default owners", func() {
-		output, err := env.EC2API.DescribeImages(&ec2.DescribeImagesInput{
-			ImageIds: []*string{aws.String(customAMI)},
+		output, err := env.EC2API.DescribeImages(env.Context, &ec2.DescribeImagesInput{
+			ImageIds: []string{customAMI},
 		})
 		Expect(err).To(BeNil())
 		Expect(output.Images).To(HaveLen(1))
@@ -172,7 +173,7 @@ var _ = Describe("AMI", func() {
 					"/aws/service/canonical/ubuntu/eks/20.04/1.28/stable/current/amd64/hvm/ebs-gp2/ami-id",
 					"/aws/service/canonical/ubuntu/eks/20.04/1.28/stable/current/arm64/hvm/ebs-gp2/ami-id",
 				}, func(arg string, _ int) v1beta1.AMISelectorTerm {
-					parameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{Name: lo.ToPtr(arg)})
+					parameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{Name: lo.ToPtr(arg)})
 					Expect(err).To(BeNil())
 					return v1beta1.AMISelectorTerm{ID: *parameter.Parameter.Value}
 				})
@@ -351,9 +352,9 @@ func getInstanceAttribute(nodeName string, attribute string) *ec2.DescribeInstan
 	Expect(env.Client.Get(env.Context, types.NamespacedName{Name: nodeName}, &node)).To(Succeed())
 	providerIDSplit := strings.Split(node.Spec.ProviderID, "/")
 	instanceID := providerIDSplit[len(providerIDSplit)-1]
-	instanceAttribute, err := env.EC2API.DescribeInstanceAttribute(&ec2.DescribeInstanceAttributeInput{
+	instanceAttribute, err := env.EC2API.DescribeInstanceAttribute(env.Context, &ec2.DescribeInstanceAttributeInput{
 		InstanceId: aws.String(instanceID),
-		Attribute:  aws.String(attribute),
+		Attribute:  ec2types.InstanceAttributeName(attribute),
 	})
 	Expect(err).ToNot(HaveOccurred())
 	return instanceAttribute
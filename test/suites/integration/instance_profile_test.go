@@ -17,8 +17,8 @@ package integration_test
 import (
 	"fmt"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/samber/lo"
 
 	coretest "sigs.k8s.io/karpenter/pkg/test"
@@ -52,7 +52,7 @@ var _ = Describe("InstanceProfile Generation", func() {
 
 		env.ExpectDeleted(nodePool, nodeClass)
 		Eventually(func(g Gomega) {
-			_, err := env.IAMAPI.GetInstanceProfileWithContext(env.Context, &iam.GetInstanceProfileInput{
+			_, err := env.IAMAPI.GetInstanceProfile(env.Context, &iam.GetInstanceProfileInput{
 				InstanceProfileName: aws.String(env.GetInstanceProfileName(nodeClass)),
 			})
 			g.Expect(awserrors.IsNotFound(err)).To(BeTrue())
@@ -26,7 +26,7 @@ import (
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
-	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/samber/lo"
 
 	"github.com/aws/karpenter-provider-aws/test/pkg/environment/aws"
@@ -95,7 +95,7 @@ var _ = Describe("KubeletConfiguration Overrides", func() {
 						"/aws/service/canonical/ubuntu/eks/20.04/1.28/stable/current/amd64/hvm/ebs-gp2/ami-id",
 						"/aws/service/canonical/ubuntu/eks/20.04/1.28/stable/current/arm64/hvm/ebs-gp2/ami-id",
 					}, func(arg string, _ int) v1beta1.AMISelectorTerm {
-						parameter, err := env.SSMAPI.GetParameter(&ssm.GetParameterInput{Name: lo.ToPtr(arg)})
+						parameter, err := env.SSMAPI.GetParameter(env.Context, &ssm.GetParameterInput{Name: lo.ToPtr(arg)})
 						Expect(err).To(BeNil())
 						return v1beta1.AMISelectorTerm{ID: *parameter.Parameter.Value}
 					})
@@ -17,7 +17,8 @@ package integration_test
 import (
 	"time"
 
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/onsi/gomega/types"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
@@ -127,15 +128,15 @@ var _ = Describe("Subnets", func() {
 
 func ExpectResourceBasedNamingEnabled(subnetIDs ...string) {
 	for subnetID := range subnetIDs {
-		_, err := env.EC2API.ModifySubnetAttribute(&ec2.ModifySubnetAttributeInput{
-			EnableResourceNameDnsARecordOnLaunch: &ec2.AttributeBooleanValue{
+		_, err := env.EC2API.ModifySubnetAttribute(env.Context, &ec2.ModifySubnetAttributeInput{
+			EnableResourceNameDnsARecordOnLaunch: &ec2types.AttributeBooleanValue{
 				Value: lo.ToPtr(true),
 			},
 			SubnetId: lo.ToPtr(subnetIDs[subnetID]),
 		})
 		Expect(err).To(BeNil())
-		_, err = env.EC2API.ModifySubnetAttribute(&ec2.ModifySubnetAttributeInput{
-			PrivateDnsHostnameTypeOnLaunch: lo.ToPtr("resource-name"),
+		_, err = env.EC2API.ModifySubnetAttribute(env.Context, &ec2.ModifySubnetAttributeInput{
+			PrivateDnsHostnameTypeOnLaunch: ec2types.HostnameTypeResourceName,
 			SubnetId:                       lo.ToPtr(subnetIDs[subnetID]),
 		})
 		Expect(err).To(BeNil())
@@ -144,15 +145,15 @@ func ExpectResourceBasedNamingEnabled(subnetIDs ...string) {
 
 func ExpectResourceBasedNamingDisabled(subnetIDs ...string) {
 	for subnetID := range subnetIDs {
-		_, err := env.EC2API.ModifySubnetAttribute(&ec2.ModifySubnetAttributeInput{
-			EnableResourceNameDnsARecordOnLaunch: &ec2.AttributeBooleanValue{
+		_, err := env.EC2API.ModifySubnetAttribute(env.Context, &ec2.ModifySubnetAttributeInput{
+			EnableResourceNameDnsARecordOnLaunch: &ec2types.AttributeBooleanValue{
 				Value: lo.ToPtr(false),
 			},
 			SubnetId: lo.ToPtr(subnetIDs[subnetID]),
 		})
 		Expect(err).To(BeNil())
-		_, err = env.EC2API.ModifySubnetAttribute(&ec2.ModifySubnetAttributeInput{
-			PrivateDnsHostnameTypeOnLaunch: lo.ToPtr("ip-name"),
+		_, err = env.EC2API.ModifySubnetAttribute(env.Context, &ec2.ModifySubnetAttributeInput{
+			PrivateDnsHostnameTypeOnLaunch: ec2types.HostnameTypeIpName,
 			SubnetId:                       lo.ToPtr(subnetIDs[subnetID]),
 		})
 		Expect(err).To(BeNil())
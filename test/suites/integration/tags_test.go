@@ -17,8 +17,7 @@ package integration_test
 import (
 	"time"
 
-	"github.com/aws/aws-sdk-go/service/ec2"
-
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -46,10 +45,10 @@ var _ = Describe("Tags", func() {
 			env.EventuallyExpectHealthy(pod)
 			env.ExpectCreatedNodeCount("==", 1)
 			instance := env.GetInstance(pod.Spec.NodeName)
-			volumes := env.GetVolumes(lo.Map(instance.BlockDeviceMappings, func(bdm *ec2.InstanceBlockDeviceMapping, _ int) *string {
+			volumes := env.GetVolumes(lo.Map(instance.BlockDeviceMappings, func(bdm ec2types.InstanceBlockDeviceMapping, _ int) *string {
 				return bdm.Ebs.VolumeId
 			})...)
-			networkInterfaces := env.GetNetworkInterfaces(lo.Map(instance.NetworkInterfaces, func(ni *ec2.InstanceNetworkInterface, _ int) *string {
+			networkInterfaces := env.GetNetworkInterfaces(lo.Map(instance.NetworkInterfaces, func(ni ec2types.InstanceNetworkInterface, _ int) *string {
 				return ni.NetworkInterfaceId
 			})...)
 
@@ -137,8 +136,8 @@ var _ = Describe("Tags", func() {
 	})
 })
 
-func tagMap(tags []*ec2.Tag) map[string]string {
-	return lo.SliceToMap(tags, func(tag *ec2.Tag) (string, string) {
+func tagMap(tags []ec2types.Tag) map[string]string {
+	return lo.SliceToMap(tags, func(tag ec2types.Tag) (string, string) {
 		return *tag.Key, *tag.Value
 	})
 }
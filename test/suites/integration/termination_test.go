@@ -19,7 +19,8 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"github.com/samber/lo"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 
 	"sigs.k8s.io/karpenter/pkg/test"
 )
@@ -44,7 +45,7 @@ var _ = Describe("Termination", func() {
 		env.ExpectDeleted(nodes[0])
 		env.EventuallyExpectNotFound(nodes[0])
 		Eventually(func(g Gomega) {
-			g.Expect(lo.FromPtr(env.GetInstanceByID(instanceID).State.Name)).To(Equal("shutting-down"))
+			g.Expect(env.GetInstanceByID(instanceID).State.Name).To(Equal(ec2types.InstanceStateNameShuttingDown))
 		}, time.Second*10).Should(Succeed())
 	})
 })
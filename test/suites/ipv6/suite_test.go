@@ -15,16 +15,21 @@ limitations under the License.
 package ipv6_test
 
 import (
-	"net"
 	"testing"
+	"time"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	coretest "sigs.k8s.io/karpenter/pkg/test"
 
 	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/pkg/operator/options"
+	"github.com/aws/karpenter-provider-aws/pkg/providers/instancetype"
+	"github.com/aws/karpenter-provider-aws/pkg/test"
 	"github.com/aws/karpenter-provider-aws/test/pkg/environment/aws"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -76,10 +81,7 @@ var _ = Describe("IPv6", func() {
 		env.EventuallyExpectHealthy(pod)
 		env.ExpectCreatedNodeCount("==", 1)
 		node := env.GetNode(pod.Spec.NodeName)
-		internalIPv6Addrs := lo.Filter(node.Status.Addresses, func(addr v1.NodeAddress, _ int) bool {
-			return addr.Type == v1.NodeInternalIP && net.ParseIP(addr.Address).To4() == nil
-		})
-		Expect(internalIPv6Addrs).To(HaveLen(1))
+		env.ExpectIPv6Node(&node)
 	})
 	It("should provision an IPv6 node by discovering kubeletConfig kube-dns IP", func() {
 		clusterDNSAddr := env.ExpectIPv6ClusterDNS()
@@ -89,9 +91,109 @@ var _ = Describe("IPv6", func() {
 		env.EventuallyExpectHealthy(pod)
 		env.ExpectCreatedNodeCount("==", 1)
 		node := env.GetNode(pod.Spec.NodeName)
-		internalIPv6Addrs := lo.Filter(node.Status.Addresses, func(addr v1.NodeAddress, _ int) bool {
-			return addr.Type == v1.NodeInternalIP && net.ParseIP(addr.Address).To4() == nil
+		env.ExpectIPv6Node(&node)
+	})
+	It("should assign IPv6 addresses to the pods that are scheduled to the node", func() {
+		numPods := 3
+		dep := coretest.Deployment(coretest.DeploymentOptions{
+			Replicas: int32(numPods),
+			PodOptions: coretest.PodOptions{
+				ObjectMeta:                    metav1.ObjectMeta{Labels: map[string]string{"app": "ipv6"}},
+				TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+			},
+		})
+		env.ExpectCreated(nodeClass, nodePool, dep)
+		pods := env.EventuallyExpectHealthyPodCount(labels.SelectorFromSet(dep.Spec.Selector.MatchLabels), numPods)
+		env.ExpectCreatedNodeCount("==", 1)
+		env.ExpectIPv6Pods(pods...)
+	})
+	Context("Max Pods", func() {
+		It("should register the node with the ENI-limited pod capacity of its instance type", func() {
+			pod := coretest.Pod()
+			env.ExpectCreated(pod, nodeClass, nodePool)
+			env.EventuallyExpectHealthy(pod)
+			nodeClaim := env.EventuallyExpectCreatedNodeClaimCount("==", 1)[0]
+			node := env.ExpectCreatedNodeCount("==", 1)[0]
+
+			// the max-pods of the kubelet of IPv6 nodes is computed by the bootstrap script the same way as for IPv4 nodes,
+			// so that it's only limited by the ENIs, and not by the IPv6 prefix that is assigned to the node
+			info := env.GetInstanceTypeInfo(node.Labels[v1.LabelInstanceTypeStable])
+			expected := instancetype.ENILimitedPods(options.ToContext(env.Context, test.Options()), &info)
+			Expect(node.Status.Capacity.Pods().Value()).To(Equal(expected.Value()))
+			Expect(nodeClaim.Status.Capacity.Pods().Value()).To(Equal(expected.Value()))
+		})
+		It("should register the node with the max pods of the kubelet configuration", func() {
+			nodePool.Spec.Template.Spec.Kubelet = &corev1beta1.KubeletConfiguration{MaxPods: lo.ToPtr[int32](8)}
+			pod := coretest.Pod()
+			env.ExpectCreated(pod, nodeClass, nodePool)
+			env.EventuallyExpectHealthy(pod)
+			nodeClaim := env.EventuallyExpectCreatedNodeClaimCount("==", 1)[0]
+			node := env.ExpectCreatedNodeCount("==", 1)[0]
+			Expect(node.Status.Capacity.Pods().Value()).To(BeNumerically("==", 8))
+			Expect(nodeClaim.Status.Capacity.Pods().Value()).To(BeNumerically("==", 8))
+		})
+	})
+	Context("Drift", func() {
+		It("should replace a drifted IPv6 node with an IPv6 node", func() {
+			dep := coretest.Deployment(coretest.DeploymentOptions{
+				Replicas: 1,
+				PodOptions: coretest.PodOptions{
+					ObjectMeta:                    metav1.ObjectMeta{Labels: map[string]string{"app": "ipv6"}},
+					TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+				},
+			})
+			selector := labels.SelectorFromSet(dep.Spec.Selector.MatchLabels)
+			env.ExpectCreated(nodeClass, nodePool, dep)
+			env.EventuallyExpectHealthyPodCount(selector, 1)
+			nodeClaim := env.EventuallyExpectCreatedNodeClaimCount("==", 1)[0]
+			node := env.ExpectCreatedNodeCount("==", 1)[0]
+			env.ExpectIPv6Node(node)
+
+			nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, map[string]string{"test-drift": "true"})
+			env.ExpectUpdated(nodePool)
+			env.EventuallyExpectDrifted(nodeClaim)
+			env.EventuallyExpectNotFound(nodeClaim, node)
+
+			pods := env.EventuallyExpectHealthyPodCount(selector, 1)
+			replacement := env.GetNode(pods[0].Spec.NodeName)
+			Expect(replacement.Labels).To(HaveKeyWithValue("test-drift", "true"))
+			env.ExpectIPv6Node(&replacement)
+			env.ExpectIPv6Pods(pods...)
+		})
+	})
+	Context("Interruption", func() {
+		It("should replace an interrupted IPv6 spot node with an IPv6 node", func() {
+			nodePool = coretest.ReplaceRequirements(nodePool, corev1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{
+					Key:      corev1beta1.CapacityTypeLabelKey,
+					Operator: v1.NodeSelectorOpIn,
+					Values:   []string{corev1beta1.CapacityTypeSpot},
+				}})
+			dep := coretest.Deployment(coretest.DeploymentOptions{
+				Replicas: 1,
+				PodOptions: coretest.PodOptions{
+					ObjectMeta:                    metav1.ObjectMeta{Labels: map[string]string{"app": "ipv6"}},
+					TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+				},
+			})
+			selector := labels.SelectorFromSet(dep.Spec.Selector.MatchLabels)
+			env.ExpectCreated(nodeClass, nodePool, dep)
+			env.EventuallyExpectHealthyPodCount(selector, 1)
+			node := env.ExpectCreatedNodeCount("==", 1)[0]
+			env.ExpectIPv6Node(node)
+
+			By("interrupting the spot instance")
+			exp := env.ExpectSpotInterruptionExperiment(env.ExpectParsedProviderID(node.Spec.ProviderID))
+			DeferCleanup(func() {
+				env.ExpectExperimentTemplateDeleted(*exp.ExperimentTemplateId)
+			})
+			// the node is expected to be drained and terminated before the instance is
+			env.EventuallyExpectNotFoundAssertion(node).WithTimeout(time.Second * 110).Should(Succeed())
+
+			pods := env.EventuallyExpectHealthyPodCount(selector, 1)
+			replacement := env.GetNode(pods[0].Spec.NodeName)
+			env.ExpectIPv6Node(&replacement)
+			env.ExpectIPv6Pods(pods...)
 		})
-		Expect(internalIPv6Addrs).To(HaveLen(1))
 	})
 })
@@ -20,8 +20,9 @@ import (
 	"os"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 
@@ -53,30 +54,30 @@ var _ = Describe("GarbageCollection", func() {
 		instanceProfileName = fmt.Sprintf("KarpenterNodeInstanceProfile-%s", env.ClusterName)
 		roleName = fmt.Sprintf("KarpenterNodeRole-%s", env.ClusterName)
 		instanceInput = &ec2.RunInstancesInput{
-			InstanceType: aws.String("c5.large"),
-			IamInstanceProfile: &ec2.IamInstanceProfileSpecification{
+			InstanceType: "c5.large",
+			IamInstanceProfile: &ec2types.IamInstanceProfileSpecification{
 				Name: aws.String(instanceProfileName),
 			},
-			SecurityGroupIds: lo.Map(securityGroups, func(s environmentaws.SecurityGroup, _ int) *string {
-				return s.GroupIdentifier.GroupId
+			SecurityGroupIds: lo.Map(securityGroups, func(s environmentaws.SecurityGroup, _ int) string {
+				return aws.ToString(s.GroupIdentifier.GroupId)
 			}),
 			SubnetId: aws.String(subnets[0].ID),
-			BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+			BlockDeviceMappings: []ec2types.BlockDeviceMapping{
 				{
 					DeviceName: aws.String("/dev/xvda"),
-					Ebs: &ec2.EbsBlockDevice{
+					Ebs: &ec2types.EbsBlockDevice{
 						Encrypted:           aws.Bool(true),
 						DeleteOnTermination: aws.Bool(true),
-						VolumeType:          aws.String(ec2.VolumeTypeGp3),
-						VolumeSize:          aws.Int64(20),
+						VolumeType:          ec2types.VolumeTypeGp3,
+						VolumeSize:          aws.Int32(20),
 					},
 				},
 			},
 			ImageId: aws.String(customAMI), // EKS AL2-based AMI
-			TagSpecifications: []*ec2.TagSpecification{
+			TagSpecifications: []ec2types.TagSpecification{
 				{
-					ResourceType: aws.String(ec2.ResourceTypeInstance),
-					Tags: []*ec2.Tag{
+					ResourceType: ec2types.ResourceTypeInstance,
+					Tags: []ec2types.Tag{
 						{
 							Key:   aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", env.ClusterName)),
 							Value: aws.String("owned"),
@@ -92,8 +93,8 @@ var _ = Describe("GarbageCollection", func() {
 					},
 				},
 			},
-			MinCount: aws.Int64(1),
-			MaxCount: aws.Int64(1),
+			MinCount: aws.Int32(1),
+			MaxCount: aws.Int32(1),
 		}
 	})
 	It("should succeed to garbage collect an Instance that was launched by a NodeClaim but has no Instance mapping", func() {
@@ -113,8 +114,8 @@ var _ = Describe("GarbageCollection", func() {
 
 		// Always ensure that we cleanup the instance
 		DeferCleanup(func() {
-			_, err := env.EC2API.TerminateInstances(&ec2.TerminateInstancesInput{
-				InstanceIds: []*string{out.Instances[0].InstanceId},
+			_, err := env.EC2API.TerminateInstances(env.Context, &ec2.TerminateInstancesInput{
+				InstanceIds: []string{aws.ToString(out.Instances[0].InstanceId)},
 			})
 			if awserrors.IsNotFound(err) {
 				return
@@ -126,9 +127,9 @@ var _ = Describe("GarbageCollection", func() {
 		node := env.EventuallyExpectCreatedNodeCount("==", 1)[0]
 
 		// Update the tags to add the karpenter.sh/managed-by tag
-		_, err = env.EC2API.CreateTagsWithContext(env.Context, &ec2.CreateTagsInput{
-			Resources: []*string{out.Instances[0].InstanceId},
-			Tags: []*ec2.Tag{
+		_, err = env.EC2API.CreateTags(env.Context, &ec2.CreateTagsInput{
+			Resources: []string{aws.ToString(out.Instances[0].InstanceId)},
+			Tags: []ec2types.Tag{
 				{
 					Key:   aws.String(corev1beta1.ManagedByAnnotationKey),
 					Value: aws.String(env.ClusterName),
@@ -140,7 +141,7 @@ var _ = Describe("GarbageCollection", func() {
 		// Eventually expect the node and the instance to be removed (shutting-down)
 		env.EventuallyExpectNotFound(node)
 		Eventually(func(g Gomega) {
-			g.Expect(lo.FromPtr(env.GetInstanceByID(aws.StringValue(out.Instances[0].InstanceId)).State.Name)).To(Equal("shutting-down"))
+			g.Expect(env.GetInstanceByID(aws.ToString(out.Instances[0].InstanceId)).State.Name).To(Equal(ec2types.InstanceStateNameShuttingDown))
 		}, time.Second*10).Should(Succeed())
 	})
 	It("should succeed to garbage collect an Instance that was deleted without the cluster's knowledge", func() {
@@ -152,8 +153,8 @@ var _ = Describe("GarbageCollection", func() {
 		env.EventuallyExpectHealthy(pod)
 		node := env.ExpectCreatedNodeCount("==", 1)[0]
 
-		_, err := env.EC2API.TerminateInstances(&ec2.TerminateInstancesInput{
-			InstanceIds: aws.StringSlice([]string{lo.Must(utils.ParseInstanceID(node.Spec.ProviderID))}),
+		_, err := env.EC2API.TerminateInstances(env.Context, &ec2.TerminateInstancesInput{
+			InstanceIds: []string{lo.Must(utils.ParseInstanceID(node.Spec.ProviderID))},
 		})
 		Expect(err).ToNot(HaveOccurred())
 
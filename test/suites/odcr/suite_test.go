@@ -0,0 +1,202 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package odcr_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/test/pkg/environment/aws"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// instanceType is the instance type that capacity is reserved for, which is launched by the NodePool of every test
+const instanceType = "m5.large"
+
+var env *aws.Environment
+var nodeClass *v1beta1.EC2NodeClass
+var nodePool *corev1beta1.NodePool
+var zone string
+
+func TestODCR(t *testing.T) {
+	RegisterFailHandler(Fail)
+	BeforeSuite(func() {
+		env = aws.NewEnvironment(t)
+	})
+	AfterSuite(func() {
+		env.Stop()
+	})
+	RunSpecs(t, "ODCR")
+}
+
+var _ = BeforeEach(func() {
+	env.BeforeEach()
+	// capacity is reserved in a single zone of the cluster, so that the instances that are launched match the reservation
+	zones := lo.Keys(lo.PickByValues(env.GetZones(), []string{"availability-zone"}))
+	zones = lo.Intersect(zones, lo.Keys(env.GetSubnets(map[string]string{"karpenter.sh/discovery": env.ClusterName})))
+	Expect(zones).ToNot(BeEmpty())
+	sort.Strings(zones)
+	zone = zones[0]
+
+	nodeClass = env.DefaultEC2NodeClass()
+	nodePool = env.DefaultNodePool(nodeClass)
+	nodePool = coretest.ReplaceRequirements(nodePool,
+		corev1beta1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{
+				Key:      corev1beta1.CapacityTypeLabelKey,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   []string{corev1beta1.CapacityTypeOnDemand},
+			},
+		},
+		corev1beta1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{
+				Key:      v1.LabelInstanceTypeStable,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   []string{instanceType},
+			},
+		},
+		corev1beta1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{
+				Key:      v1.LabelTopologyZone,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   []string{zone},
+			},
+		},
+	)
+})
+var _ = AfterEach(func() { env.Cleanup() })
+var _ = AfterEach(func() { env.AfterEach() })
+
+var _ = Describe("ODCR", func() {
+	var dep *appsv1.Deployment
+	var selector labels.Selector
+
+	BeforeEach(func() {
+		// one pod per node, so that every replica launches an instance that could be launched into a reservation
+		dep = coretest.Deployment(coretest.DeploymentOptions{
+			Replicas: 1,
+			PodOptions: coretest.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "odcr"}},
+				PodAntiRequirements: []v1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "odcr"}},
+					TopologyKey:   v1.LabelHostname,
+				}},
+				TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+			},
+		})
+		selector = labels.SelectorFromSet(dep.Spec.Selector.MatchLabels)
+	})
+	It("should launch on-demand instances into an open capacity reservation", func() {
+		id := env.ExpectCapacityReservationCreated(instanceType, zone, 1, ec2types.InstanceMatchCriteriaOpen)
+
+		env.ExpectCreated(nodeClass, nodePool, dep)
+		env.EventuallyExpectHealthyPodCount(selector, 1)
+		nodeClaim := env.EventuallyExpectCreatedNodeClaimCount("==", 1)[0]
+		node := env.ExpectCreatedNodeCount("==", 1)[0]
+
+		Expect(awssdk.ToString(env.GetInstance(node.Name).CapacityReservationId)).To(Equal(id))
+		Expect(awssdk.ToInt32(env.GetCapacityReservation(id).AvailableInstanceCount)).To(BeNumerically("==", 0))
+		expectCapacityType(node, v1beta1.CapacityTypeReserved, id)
+		Eventually(func(g Gomega) {
+			g.Expect(env.Client.Get(env, client.ObjectKeyFromObject(nodeClaim), nodeClaim)).To(Succeed())
+			g.Expect(nodeClaim.Labels).To(HaveKeyWithValue(v1beta1.LabelCapacityType, v1beta1.CapacityTypeReserved))
+			g.Expect(nodeClaim.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationCapacityReservationID, id))
+		}).Should(Succeed())
+	})
+	It("should fall back to on-demand capacity once an open capacity reservation is used up", func() {
+		id := env.ExpectCapacityReservationCreated(instanceType, zone, 1, ec2types.InstanceMatchCriteriaOpen)
+
+		dep.Spec.Replicas = lo.ToPtr[int32](2)
+		env.ExpectCreated(nodeClass, nodePool, dep)
+		env.EventuallyExpectHealthyPodCount(selector, 2)
+		nodes := env.ExpectCreatedNodeCount("==", 2)
+
+		reserved := lo.Filter(nodes, func(n *v1.Node, _ int) bool {
+			return awssdk.ToString(env.GetInstance(n.Name).CapacityReservationId) == id
+		})
+		onDemand := lo.Without(nodes, reserved...)
+		Expect(reserved).To(HaveLen(1))
+		Expect(onDemand).To(HaveLen(1))
+		expectCapacityType(reserved[0], v1beta1.CapacityTypeReserved, id)
+		expectCapacityType(onDemand[0], corev1beta1.CapacityTypeOnDemand, "")
+	})
+	It("should not launch instances into a targeted capacity reservation", func() {
+		id := env.ExpectCapacityReservationCreated(instanceType, zone, 1, ec2types.InstanceMatchCriteriaTargeted)
+
+		env.ExpectCreated(nodeClass, nodePool, dep)
+		env.EventuallyExpectHealthyPodCount(selector, 1)
+		node := env.ExpectCreatedNodeCount("==", 1)[0]
+
+		// instances are only launched into targeted reservations when the reservation is specified at launch
+		Expect(env.GetInstance(node.Name).CapacityReservationId).To(BeNil())
+		Expect(awssdk.ToInt32(env.GetCapacityReservation(id).AvailableInstanceCount)).To(BeNumerically("==", 1))
+		expectCapacityType(node, corev1beta1.CapacityTypeOnDemand, "")
+	})
+	It("should keep the instances of a cancelled capacity reservation and fall back to on-demand capacity", func() {
+		// a reservation that expires ends the same way that a cancelled one does, and its instances keep running as
+		// on-demand instances
+		id := env.ExpectCapacityReservationCreated(instanceType, zone, 1, ec2types.InstanceMatchCriteriaOpen)
+
+		env.ExpectCreated(nodeClass, nodePool, dep)
+		env.EventuallyExpectHealthyPodCount(selector, 1)
+		node := env.ExpectCreatedNodeCount("==", 1)[0]
+		expectCapacityType(node, v1beta1.CapacityTypeReserved, id)
+
+		By("cancelling the capacity reservation")
+		env.ExpectCapacityReservationCanceled(id)
+		env.ConsistentlyExpectNoDisruptions(1, time.Minute)
+		Expect(env.GetInstance(node.Name).State.Name).To(Equal(ec2types.InstanceStateNameRunning))
+
+		By("launching capacity after the reservation is cancelled")
+		dep.Spec.Replicas = lo.ToPtr[int32](2)
+		env.ExpectUpdated(dep)
+		env.EventuallyExpectHealthyPodCount(selector, 2)
+		onDemand, ok := lo.Find(env.EventuallyExpectCreatedNodeCount("==", 2), func(n *v1.Node) bool { return n.Name != node.Name })
+		Expect(ok).To(BeTrue())
+		Expect(env.GetInstance(onDemand.Name).CapacityReservationId).To(BeNil())
+		expectCapacityType(onDemand, corev1beta1.CapacityTypeOnDemand, "")
+	})
+})
+
+// expectCapacityType expects the node to be labeled with the capacity type that its instance is billed as, and with the
+// capacity reservation that it was launched into if there is one
+func expectCapacityType(node *v1.Node, capacityType, capacityReservationID string) {
+	GinkgoHelper()
+	Eventually(func(g Gomega) {
+		g.Expect(env.Client.Get(env, client.ObjectKeyFromObject(node), node)).To(Succeed())
+		g.Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelCapacityType, capacityType))
+		if capacityReservationID == "" {
+			g.Expect(node.Labels).ToNot(HaveKey(v1beta1.LabelCapacityReservationID))
+		} else {
+			g.Expect(node.Labels).To(HaveKeyWithValue(v1beta1.LabelCapacityReservationID, capacityReservationID))
+		}
+	}).Should(Succeed())
+}
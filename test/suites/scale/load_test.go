@@ -0,0 +1,164 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilsenv "k8s.io/utils/env"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/test/pkg/debug"
+	"github.com/aws/karpenter-provider-aws/test/pkg/environment/aws"
+	"github.com/aws/karpenter-provider-aws/test/pkg/load"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const loadTestGroup = "load"
+
+// loadConfig is the size and churn of the load and the thresholds that it's asserted against, which can be overridden
+// through environment variables to soak test for longer or at a larger scale
+type loadConfig struct {
+	pods          int
+	deployments   int
+	churnDuration time.Duration
+	churnInterval time.Duration
+	churnRatio    float64
+
+	// maxSchedulingLatencyP99 is the p99 of the latency from pods being created until they're scheduled
+	maxSchedulingLatencyP99 time.Duration
+	// maxLaunchLatencyP99 is the p99 of the latency from instances being launched until they're running
+	maxLaunchLatencyP99 time.Duration
+	// maxThrottledRequestRatio is the fraction of AWS API requests that are throttled
+	maxThrottledRequestRatio float64
+}
+
+func newLoadConfig() loadConfig {
+	GinkgoHelper()
+	return loadConfig{
+		pods:                     lo.Must(utilsenv.GetInt("LOAD_PODS", 3000)),
+		deployments:              lo.Must(utilsenv.GetInt("LOAD_DEPLOYMENTS", 30)),
+		churnDuration:            lo.Must(time.ParseDuration(utilsenv.GetString("LOAD_CHURN_DURATION", "20m"))),
+		churnInterval:            lo.Must(time.ParseDuration(utilsenv.GetString("LOAD_CHURN_INTERVAL", "1m"))),
+		churnRatio:               lo.Must(utilsenv.GetFloat64("LOAD_CHURN_RATIO", 0.1)),
+		maxSchedulingLatencyP99:  lo.Must(time.ParseDuration(utilsenv.GetString("LOAD_MAX_SCHEDULING_LATENCY_P99", "4m"))),
+		maxLaunchLatencyP99:      lo.Must(time.ParseDuration(utilsenv.GetString("LOAD_MAX_LAUNCH_LATENCY_P99", "90s"))),
+		maxThrottledRequestRatio: lo.Must(utilsenv.GetFloat64("LOAD_MAX_THROTTLED_REQUEST_RATIO", 0.01)),
+	}
+}
+
+var _ = Describe("Load", Label(debug.NoWatch), Label(debug.NoEvents), func() {
+	var nodePool *corev1beta1.NodePool
+	var nodeClass *v1beta1.EC2NodeClass
+	var config loadConfig
+	var generator *load.Generator
+
+	BeforeEach(func() {
+		config = newLoadConfig()
+		nodeClass = env.DefaultEC2NodeClass()
+		nodePool = env.DefaultNodePool(nodeClass)
+		nodePool.Spec.Limits = nil
+		// consolidation removes the capacity that was left over from scaling down, so that it's churned as well
+		nodePool.Spec.Disruption.ConsolidationPolicy = corev1beta1.ConsolidationPolicyWhenUnderutilized
+		nodePool.Spec.Disruption.ConsolidateAfter = nil
+		test.ReplaceRequirements(nodePool, corev1beta1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{
+				Key:      v1beta1.LabelInstanceHypervisor,
+				Operator: v1.NodeSelectorOpIn,
+				Values:   []string{"nitro"},
+			}})
+		generator = load.NewGenerator(env.Client, load.Options{
+			Name:        "load",
+			Deployments: config.deployments,
+			Pods:        config.pods,
+			PodOptions: test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("100m"),
+						v1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+				},
+				TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+			},
+			ChurnInterval: config.churnInterval,
+			ChurnRatio:    config.churnRatio,
+			Seed:          GinkgoRandomSeed(),
+		})
+	})
+	It("should keep up with churning pods", func(ctx context.Context) {
+		before := lo.Must(load.Scrape(ctx, env.KubeClient, env.ExpectActiveKarpenterPod()))
+
+		By(fmt.Sprintf("scaling up to %d pods across %d deployments", config.pods, config.deployments))
+		env.ExpectCreated(nodePool, nodeClass)
+		env.ExpectCreated(lo.Map(generator.Deployments(), func(d *appsv1.Deployment, _ int) client.Object { return d })...)
+		env.EventuallyExpectHealthyPodCount(generator.Selector(), config.pods)
+		Expect(generator.Observe(ctx)).To(Succeed())
+
+		By(fmt.Sprintf("churning %.0f%% of the pods every %s for %s", config.churnRatio*100, config.churnInterval, config.churnDuration))
+		Expect(generator.Churn(ctx, config.churnDuration)).To(Succeed())
+		env.EventuallyExpectHealthyPodCount(generator.Selector(), generator.Replicas())
+		Expect(generator.Observe(ctx)).To(Succeed())
+
+		after := lo.Must(load.Scrape(ctx, env.KubeClient, env.ExpectActiveKarpenterPod()))
+		launchLatency := after.Histogram("karpenter_launches_phase_duration_seconds", map[string]string{"phase": "instance_running"}).
+			Sub(before.Histogram("karpenter_launches_phase_duration_seconds", map[string]string{"phase": "instance_running"}))
+		createFleetLatency := after.Histogram("karpenter_launches_create_fleet_duration_seconds", nil).
+			Sub(before.Histogram("karpenter_launches_create_fleet_duration_seconds", nil))
+		requests := load.SubCounters(after.CounterBy("karpenter_cloudprovider_api_requests_total", "operation"), before.CounterBy("karpenter_cloudprovider_api_requests_total", "operation"))
+		failedRequests := load.SubCounters(after.CounterBy("karpenter_cloudprovider_api_requests_total", "error"), before.CounterBy("karpenter_cloudprovider_api_requests_total", "error"))
+		throttledRequests := lo.Sum(lo.Values(lo.PickByKeys(failedRequests, []string{"RequestLimitExceeded", "Throttling", "ThrottlingException"})))
+		totalRequests := lo.Sum(lo.Values(requests))
+
+		dimensions := map[string]string{
+			aws.TestCategoryDimension: loadTestGroup,
+			aws.TestNameDimension:     "churn",
+			aws.PodDensityDimension:   strconv.Itoa(config.pods),
+		}
+		for name, value := range generator.SchedulingLatency.Summary() {
+			env.ExpectMetric("podSchedulingLatency", value, lo.Assign(dimensions, map[string]string{"statistic": name}))
+		}
+		for name, value := range generator.ReadyLatency.Summary() {
+			env.ExpectMetric("podReadyLatency", value, lo.Assign(dimensions, map[string]string{"statistic": name}))
+		}
+		env.ExpectMetric("instanceRunningLatencyP99", launchLatency.Quantile(0.99), dimensions)
+		env.ExpectMetric("createFleetLatencyP99", createFleetLatency.Quantile(0.99), dimensions)
+		for operation, count := range requests {
+			env.ExpectMetric("apiRequests", count, lo.Assign(dimensions, map[string]string{"operation": operation}))
+		}
+		GinkgoWriter.Printf("pods: %d observed, %d scaled\n", generator.SchedulingLatency.Count(), generator.Scaled)
+		GinkgoWriter.Printf("pod scheduling latency (s): %v\n", generator.SchedulingLatency.Summary())
+		GinkgoWriter.Printf("pod ready latency (s): %v\n", generator.ReadyLatency.Summary())
+		GinkgoWriter.Printf("instance running latency p99 (s): %.1f, CreateFleet latency p99 (s): %.1f\n", launchLatency.Quantile(0.99), createFleetLatency.Quantile(0.99))
+		GinkgoWriter.Printf("AWS API requests: %v, failed: %v\n", requests, failedRequests)
+
+		By("asserting that the latencies and API requests didn't regress")
+		Expect(generator.SchedulingLatency.Percentile(99)).To(BeNumerically("<=", config.maxSchedulingLatencyP99.Seconds()))
+		Expect(launchLatency.Quantile(0.99)).To(BeNumerically("<=", config.maxLaunchLatencyP99.Seconds()))
+		Expect(throttledRequests).To(BeNumerically("<=", config.maxThrottledRequestRatio*totalRequests))
+	}, NodeTimeout(time.Hour*2))
+})
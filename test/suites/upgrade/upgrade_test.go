@@ -0,0 +1,140 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upgrade_test
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1beta1 "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	coretest "sigs.k8s.io/karpenter/pkg/test"
+
+	"github.com/aws/karpenter-provider-aws/pkg/apis/v1beta1"
+	"github.com/aws/karpenter-provider-aws/test/pkg/debug"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	// name is the name of the NodePool, EC2NodeClass and deployment that are created before the upgrade
+	name     = "upgrade"
+	replicas = 6
+
+	// stateName is the name of the ConfigMap that the NodeClaims and hash version before the upgrade are recorded in
+	stateName           = "upgrade-state"
+	stateNodeClaimsKey  = "nodeClaims"
+	stateHashVersionKey = "hashVersion"
+)
+
+var selector = labels.SelectorFromSet(map[string]string{"app": name})
+
+var _ = Describe("Upgrade", func() {
+	It("BeforeUpgrade", func() {
+		env.BeforeEach()
+		DeferCleanup(env.AfterEach)
+
+		nodeClass := env.DefaultEC2NodeClass()
+		nodeClass.Name = name
+		nodePool := env.DefaultNodePool(nodeClass)
+		nodePool.Name = name
+		dep := coretest.Deployment(coretest.DeploymentOptions{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Replicas:   replicas,
+			PodOptions: coretest.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+				// the pods are spread over multiple nodes, so that more than one NodeClaim could drift
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+				PodAntiRequirements: []v1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+					TopologyKey:   v1.LabelHostname,
+				}},
+				TerminationGracePeriodSeconds: lo.ToPtr[int64](0),
+			},
+		})
+		env.ExpectCreated(nodeClass, nodePool, dep)
+		env.EventuallyExpectHealthyPodCount(selector, replicas)
+		nodeClaims := env.EventuallyExpectNodeClaimCount("==", replicas)
+		env.EventuallyExpectNodeClaimsReady(nodeClaims...)
+
+		By("recording the NodeClaims and hash version before the upgrade")
+		env.ExpectExists(nodeClass)
+		env.ExpectCreated(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: stateName, Namespace: "default"},
+			Data: map[string]string{
+				stateNodeClaimsKey:  strings.Join(lo.Map(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) string { return nc.Name }), ","),
+				stateHashVersionKey: nodeClass.Annotations[v1beta1.AnnotationEC2NodeClassHashVersion],
+			},
+		})
+	})
+	It("AfterUpgrade", func() {
+		DeferCleanup(func() {
+			env.Cleanup()
+			env.ExpectDeleted(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: stateName, Namespace: "default"}})
+		})
+		// the cluster isn't clean after the upgrade, so only the debugging of the environment is set up
+		debug.BeforeEach(env.Context, env.Config, env.Client)
+		DeferCleanup(env.Environment.AfterEach)
+
+		state := env.ExpectExists(&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: stateName, Namespace: "default"}}).(*v1.ConfigMap)
+		nodeClass := env.ExpectExists(&v1beta1.EC2NodeClass{ObjectMeta: metav1.ObjectMeta{Name: name}}).(*v1beta1.EC2NodeClass)
+		nodeClaims := lo.Map(strings.Split(state.Data[stateNodeClaimsKey], ","), func(n string, _ int) *corev1beta1.NodeClaim {
+			return env.ExpectExists(&corev1beta1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Name: n}}).(*corev1beta1.NodeClaim)
+		})
+		GinkgoWriter.Printf("upgraded from hash version %q to %q\n", state.Data[stateHashVersionKey], v1beta1.EC2NodeClassHashVersion)
+
+		By("migrating the hash of the EC2NodeClass and its NodeClaims to the current hash version")
+		Eventually(func(g Gomega) {
+			g.Expect(env.Client.Get(env, client.ObjectKeyFromObject(nodeClass), nodeClass)).To(Succeed())
+			g.Expect(nodeClass.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHashVersion, v1beta1.EC2NodeClassHashVersion))
+			g.Expect(nodeClass.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+			for _, nc := range nodeClaims {
+				g.Expect(env.Client.Get(env, client.ObjectKeyFromObject(nc), nc)).To(Succeed())
+				g.Expect(nc.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHashVersion, v1beta1.EC2NodeClassHashVersion))
+				g.Expect(nc.Annotations).To(HaveKeyWithValue(v1beta1.AnnotationEC2NodeClassHash, nodeClass.Hash()))
+			}
+		}).Should(Succeed())
+
+		By("expecting none of the NodeClaims to drift or be replaced")
+		env.ConsistentlyExpectNodeClaimsNotDrifted(5*time.Minute, nodeClaims...)
+		env.ConsistentlyExpectNoDisruptions(replicas, time.Minute)
+		env.EventuallyExpectHealthyPodCount(selector, replicas)
+
+		By("expecting every instance of the NodePool to have a NodeClaim")
+		instanceIDs := lo.FlatMap(lo.Must(env.EC2API.DescribeInstances(env.Context, &ec2.DescribeInstancesInput{
+			Filters: []ec2types.Filter{
+				{Name: aws.String(fmt.Sprintf("tag:%s", corev1beta1.NodePoolLabelKey)), Values: []string{name}},
+				{Name: aws.String("tag:testing/cluster"), Values: []string{env.ClusterName}},
+				{Name: aws.String("instance-state-name"), Values: []string{string(ec2types.InstanceStateNamePending), string(ec2types.InstanceStateNameRunning)}},
+			},
+		})).Reservations, func(r ec2types.Reservation, _ int) []string {
+			return lo.Map(r.Instances, func(i ec2types.Instance, _ int) string { return aws.ToString(i.InstanceId) })
+		})
+		Expect(instanceIDs).To(ConsistOf(lo.Map(nodeClaims, func(nc *corev1beta1.NodeClaim, _ int) string {
+			return env.ExpectParsedProviderID(nc.Status.ProviderID)
+		})))
+	})
+})
@@ -73,12 +73,10 @@ func main() {
 	})
 	cloudProvider := cloudprovider.New(
 		op.InstanceTypesProvider,
-		op.InstanceProvider,
+		op.AccountProvider,
 		op.EventRecorder,
 		op.GetClient(),
-		op.AMIProvider,
-		op.SecurityGroupProvider,
-		op.SubnetProvider,
+		op.PricingProvider,
 	)
 	instanceTypes := lo.Must(cloudProvider.GetInstanceTypes(ctx, nil))
 
@@ -28,6 +28,7 @@ cat << EOF > controller-policy.json
         {
             "Action": [
                 "ssm:GetParameter",
+                "ssm:GetParameters",
                 "ec2:DescribeImages",
                 "ec2:RunInstances",
                 "ec2:DescribeSubnets",